@@ -7,6 +7,7 @@ import (
 	"gamehangar/internal/database/psqlDatabase"
 	"gamehangar/internal/delivery/http/v1/handlers"
 	"gamehangar/internal/delivery/http/v1/routes"
+	"gamehangar/internal/domain/models"
 	"gamehangar/internal/enforcer/psqlCasbinClient"
 	"gamehangar/internal/repository/psqlRepository"
 	"gamehangar/internal/services"
@@ -14,6 +15,8 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-playground/validator/v10"
@@ -49,6 +52,77 @@ func getEnv() {
 	// }
 }
 
+// Builds the content filter pipeline from the CONTENT_FILTER_* environment variables
+func newContentFilter(u services.ContentFilterUserRepository, q services.ReviewQueue) (*services.ContentFilter, error) {
+	var filters []services.Filter
+
+	if path := os.Getenv("CONTENT_FILTER_BLOCKLIST"); path != "" {
+		entries, err := services.LoadBlocklist(path)
+		if err != nil {
+			return nil, err
+		}
+		action := os.Getenv("CONTENT_FILTER_BLOCKLIST_ACTION")
+		if action == "" {
+			action = services.FilterReject
+		}
+		blocklist, err := services.NewBlocklistFilter(entries, action)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, blocklist)
+	}
+
+	maxLinks, err := strconv.Atoi(os.Getenv("CONTENT_FILTER_MAX_LINKS"))
+	if err != nil {
+		maxLinks = 2
+	}
+	newAccountHours, err := strconv.Atoi(os.Getenv("CONTENT_FILTER_NEW_ACCOUNT_HOURS"))
+	if err != nil {
+		newAccountHours = 72
+	}
+	filters = append(filters, services.NewLinkLimitFilter(u, maxLinks, time.Duration(newAccountHours)*time.Hour))
+
+	duplicateMinutes, err := strconv.Atoi(os.Getenv("CONTENT_FILTER_DUPLICATE_MINUTES"))
+	if err != nil {
+		duplicateMinutes = 10
+	}
+	filters = append(filters, services.NewDuplicateFilter(time.Duration(duplicateMinutes)*time.Minute))
+
+	return services.NewContentFilter(q, filters...), nil
+}
+
+// Builds the mail transport from the MAIL_* and SMTP_* environment variables.
+// Mail goes to MAIL_FILE_DIR, or to the log, unless MAIL_TRANSPORT is smtp
+func newMailTransport(l echo.Logger) services.MailTransport {
+	from := os.Getenv("MAIL_FROM")
+	if os.Getenv("MAIL_TRANSPORT") != "smtp" {
+		return services.NewFileTransport(os.Getenv("MAIL_FILE_DIR"), from, l)
+	}
+
+	port, err := strconv.Atoi(os.Getenv("SMTP_PORT"))
+	if err != nil {
+		port = 587
+	}
+	return services.NewSMTPTransport(os.Getenv("SMTP_HOST"), port, os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), from)
+}
+
+// Finds or creates the category demo threads are synced to, from the DEMO_CATEGORY_* environment variables.
+// Only moderators may start threads in a new one, since its threads belong to demos
+func provisionDemoCategory(r *psqlRepository.PsqlForumRepository) (*models.Topic, error) {
+	slug := os.Getenv("DEMO_CATEGORY_SLUG")
+	if slug == "" {
+		slug = "demos"
+	}
+	name := os.Getenv("DEMO_CATEGORY_NAME")
+	if name == "" {
+		name = "Demos"
+	}
+	description := "Discussions of the demos published on Game Hangar"
+	access := "moderators"
+
+	return r.ProvisionTopic(models.Topic{Name: &name, Slug: &slug, Description: &description, ThreadAccess: &access})
+}
+
 //	@title						Game Hangar
 //	@version					1.0
 //	@host						d5df6jka59qn3n45eubv.yl4tuxdu.apigw.yandexcloud.net
@@ -61,7 +135,7 @@ func getEnv() {
 //	@name						sessionID
 func main() {
 	e := echo.New()
-	v := validator.New(validator.WithRequiredStructEnabled())
+	v := handlers.NewValidator()
 	getEnv()
 
 	cfg := &appConfig{
@@ -104,29 +178,135 @@ func main() {
 		app.logger.Fatalf("Error setting object uploader: %v", err)
 	}
 
-	userRepo := psqlRepository.NewPsqlUserRepository(databaseClient, ce, ou)
+	var trustedProxies []string
+	if p := os.Getenv("TRUSTED_PROXIES"); p != "" {
+		trustedProxies = strings.Split(p, ",")
+	}
+	e.IPExtractor, err = services.ClientIPExtractor(trustedProxies)
+	if err != nil {
+		app.logger.Fatalf("Error parsing TRUSTED_PROXIES: %v", err)
+	}
+
+	// Applies to every route, including requests rejected by the authorizer
+	auditRepo := psqlRepository.NewPsqlAuditRepository(databaseClient)
+	e.Use(services.NewAuditLogger(auditRepo, app.logger).Middleware())
+	// Repositories change policies through it, so that the changes are audited
+	ae := psqlRepository.NewAuditedEnforcer(ce, auditRepo)
+
+	userRepo := psqlRepository.NewPsqlUserRepository(databaseClient, ae, ou)
 	userAuthorizer := services.NewUserAuthorizer(userRepo, ce)
-	userHandler := handlers.NewUserHandler(e, userRepo, app.validator, ou, userAuthorizer)
+	e.Use(userAuthorizer.IdentifySession)
+
+	var rateLimitStore services.RateLimitStore = services.NewMemoryRateLimitStore()
+	if os.Getenv("RATE_LIMIT_STORE") == "postgres" {
+		rateLimitStore = psqlRepository.NewPsqlRateLimitRepository(databaseClient)
+	}
+	rateLimiter := services.NewRateLimiter(rateLimitStore, services.DefaultRateLimits, app.logger)
+	e.Use(rateLimiter.Middleware())
+
+	moderationRepo := psqlRepository.NewPsqlModerationRepository(databaseClient)
+	contentFilter, err := newContentFilter(userRepo, moderationRepo)
+	if err != nil {
+		app.logger.Fatalf("Error setting up content filter: %v", err)
+	}
+	var captchaVerifier handlers.CaptchaVerifier
+	if secret := os.Getenv("CAPTCHA_SECRET"); secret != "" {
+		captchaVerifier = services.NewSiteVerifyCaptcha(os.Getenv("CAPTCHA_VERIFY_URL"), secret)
+	} else {
+		app.logger.Warn("CAPTCHA_SECRET is not set, registrations only need CAPTCHA_LOCAL_TOKEN")
+		captchaVerifier = services.NewLocalCaptcha(os.Getenv("CAPTCHA_LOCAL_TOKEN"))
+	}
+
+	userHandler := handlers.NewUserHandler(e, userRepo, app.validator, ou, userAuthorizer, contentFilter, captchaVerifier)
 	routes.NewUserRoutes(userHandler, userAuthorizer).InitRoutes(app.echo)
 
 	assetRepo := psqlRepository.NewPsqlAssetRepository(databaseClient, ou)
 	assetHandler := handlers.NewAssetHandler(e, assetRepo, app.validator, ou)
 	routes.NewAssetRoutes(assetHandler, userAuthorizer).InitRoutes(app.echo)
+	assetLibHandler := handlers.NewAssetLibHandler(e, assetRepo, app.validator)
+	routes.NewAssetLibRoutes(assetLibHandler).InitRoutes(app.echo)
+
+	markdownRenderer := services.NewMarkdownRenderer(psqlRepository.NewPsqlMarkdownRepository(databaseClient), app.logger)
 
-	forumRepo := psqlRepository.NewPsqlForumRepository(databaseClient, ce)
-	forumHandler := handlers.NewForumHandler(e, forumRepo, app.validator)
+	forumRepo := psqlRepository.NewPsqlForumRepository(databaseClient, ae)
+	forumHandler := handlers.NewForumHandler(e, forumRepo, app.validator, contentFilter, markdownRenderer)
 	routes.NewForumRoutes(forumHandler, userAuthorizer).InitRoutes(app.echo)
 
-	demoRepo := psqlRepository.NewPsqlDemoRepository(databaseClient, ou, ce)
-	demoThreadSyncer := services.NewThreadSyncer(forumRepo, demoRepo, 1)
-	demoHandler := handlers.NewDemoHandler(e, demoRepo, app.validator, demoThreadSyncer, ou)
+	demoRepo := psqlRepository.NewPsqlDemoRepository(databaseClient, ou, ae)
+	demoCategory, err := provisionDemoCategory(forumRepo)
+	if err != nil {
+		app.logger.Fatalf("Error provisioning demo category: %v", err)
+	}
+	demoThreadSyncer := services.NewThreadSyncer(forumRepo, demoRepo, *demoCategory.ID)
+	demoHandler := handlers.NewDemoHandler(e, demoRepo, app.validator, demoThreadSyncer, ou, contentFilter, markdownRenderer)
 	routes.NewDemoRoutes(demoHandler, userAuthorizer).InitRoutes(app.echo)
 
+	trashRetentionDays, err := strconv.Atoi(os.Getenv("TRASH_RETENTION_DAYS"))
+	if err != nil {
+		trashRetentionDays = 30
+	}
+	trashRepo := psqlRepository.NewPsqlTrashRepository(databaseClient, ou, ae, time.Duration(trashRetentionDays)*24*time.Hour)
+	trashHandler := handlers.NewTrashHandler(e, trashRepo)
+	routes.NewTrashRoutes(trashHandler, userAuthorizer).InitRoutes(app.echo)
+
+	moderationHandler := handlers.NewModerationHandler(e, moderationRepo, app.validator)
+	routes.NewModerationRoutes(moderationHandler, userAuthorizer).InitRoutes(app.echo)
+
+	sanctionHandler := handlers.NewSanctionHandler(e, userRepo, app.validator)
+	routes.NewSanctionRoutes(sanctionHandler, userAuthorizer).InitRoutes(app.echo)
+
+	topicModeratorHandler := handlers.NewTopicModeratorHandler(e, userRepo, app.validator)
+	routes.NewTopicModeratorRoutes(topicModeratorHandler, userAuthorizer).InitRoutes(app.echo)
+
+	notificationRepo := psqlRepository.NewPsqlNotificationRepository(databaseClient)
+	notificationHandler := handlers.NewNotificationHandler(e, notificationRepo, app.validator)
+	routes.NewNotificationRoutes(notificationHandler, userAuthorizer).InitRoutes(app.echo)
+
+	subscriptionRepo := psqlRepository.NewPsqlSubscriptionRepository(databaseClient)
+	subscriptionHandler := handlers.NewSubscriptionHandler(e, subscriptionRepo, app.validator)
+	routes.NewSubscriptionRoutes(subscriptionHandler, userAuthorizer).InitRoutes(app.echo)
+
+	tagRepo := psqlRepository.NewPsqlTagRepository(databaseClient)
+	tagHandler := handlers.NewTagHandler(e, tagRepo, app.validator)
+	routes.NewTagRoutes(tagHandler, userAuthorizer).InitRoutes(app.echo)
+
+	searchHandler := handlers.NewSearchHandler(e, psqlRepository.NewPsqlSearchRepository(databaseClient), app.validator)
+	routes.NewSearchRoutes(searchHandler).InitRoutes(app.echo)
+
+	liveRepo := psqlRepository.NewPsqlLiveRepository(databaseClient)
+	liveHub := services.NewLiveHub(liveRepo, app.logger)
+	liveHandler := handlers.NewLiveHandler(e, liveRepo, liveHub, app.validator)
+	routes.NewLiveRoutes(liveHandler).InitRoutes(app.echo)
+
+	revisionHandler := handlers.NewRevisionHandler(e, psqlRepository.NewPsqlRevisionRepository(databaseClient), app.validator)
+	routes.NewRevisionRoutes(revisionHandler).InitRoutes(app.echo)
+
+	mailRepo := psqlRepository.NewPsqlMailRepository(databaseClient)
+	mailHandler := handlers.NewMailHandler(e, mailRepo, app.validator)
+	routes.NewMailRoutes(mailHandler, userAuthorizer).InitRoutes(app.echo)
+	mailAPIURL := os.Getenv("MAIL_API_URL")
+	if mailAPIURL == "" {
+		mailAPIURL = os.Getenv("MAIL_SITE_URL")
+	}
+	mailer, err := services.NewMailer(mailRepo, newMailTransport(app.logger), os.Getenv("MAIL_SITE_URL"), mailAPIURL, app.logger)
+	if err != nil {
+		app.logger.Fatalf("Error setting up mailer: %v", err)
+	}
+
+	auditHandler := handlers.NewAuditHandler(e, auditRepo, app.validator)
+	routes.NewAuditRoutes(auditHandler, userAuthorizer).InitRoutes(app.echo)
+
 	app.appRouter = app.routes(app.echo)
 
 	// Graceful shutdown
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
 	defer stop()
+	go services.NewTrashPurger(trashRepo, time.Hour, app.logger).Run(ctx)
+	go rateLimiter.Run(ctx, 10*time.Minute)
+	go mailer.Run(ctx, time.Minute)
+	// Ends the event streams of live clients, which would otherwise hold up the shutdown
+	go liveHub.Run(ctx)
+	go markdownRenderer.RenderStored(ctx)
 	go func() {
 		if err := app.echo.Start(app.appConfig.port); err != nil && err != http.ErrServerClosed {
 			app.logger.Fatal("Shutting down the server")
@@ -13,8 +13,14 @@ type PsqlDatabaseConfig struct {
 	MigrationsRoot  string
 	ExpectedVersion int
 	VersionTable    string
+
+	// How similar to the keywords misspelled titles have to be, from 0 to 1
+	SimilarityThreshold float64
 }
 
+// Similarity threshold used when PSQL_SIMILARITY_THRESHOLD is unset or out of range
+const defaultSimilarityThreshold = 0.5
+
 func (p PsqlConfig) NewConfig(migrations any, migrationsRoot string) (*PsqlDatabaseConfig, error) {
 	migrateDatabase, err := strconv.ParseBool(os.Getenv("PSQL_MIGRATE_DATABASE"))
 	if err != nil {
@@ -28,11 +34,18 @@ func (p PsqlConfig) NewConfig(migrations any, migrationsRoot string) (*PsqlDatab
 		return nil, err
 	}
 
+	similarityThreshold, err := strconv.ParseFloat(os.Getenv("PSQL_SIMILARITY_THRESHOLD"), 64)
+	if err != nil || similarityThreshold <= 0 || similarityThreshold > 1 {
+		similarityThreshold = defaultSimilarityThreshold
+	}
+
 	return &PsqlDatabaseConfig{
 		MigrateDatabse:  migrateDatabase,
 		Migrations:      migrations,
 		MigrationsRoot:  migrationsRoot,
 		ExpectedVersion: int(expectedVersion),
 		VersionTable:    versionTable,
+
+		SimilarityThreshold: similarityThreshold,
 	}, nil
 }
@@ -0,0 +1,38 @@
+CREATE TABLE demo.demo_assets (
+	"demo_id" INTEGER NOT NULL REFERENCES demo.demos (id) ON DELETE CASCADE,
+	"asset_id" INTEGER NOT NULL REFERENCES asset.assets (id) ON DELETE CASCADE,
+	"asset_version" INTEGER,
+	"created_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+	PRIMARY KEY ("demo_id", "asset_id")
+);
+CREATE INDEX demo_assets_asset_id_index ON demo.demo_assets (asset_id);
+
+ALTER TABLE asset.assets ADD COLUMN usage_count INTEGER NOT NULL DEFAULT 0;
+
+CREATE FUNCTION update_asset_usage_count()
+	RETURNS TRIGGER AS
+$func$
+BEGIN
+	IF (TG_OP = 'INSERT') THEN
+		UPDATE asset.assets SET usage_count = usage_count + 1 WHERE id = NEW.asset_id;
+		RETURN NEW;
+	ELSIF (TG_OP = 'DELETE') THEN
+		UPDATE asset.assets SET usage_count = usage_count - 1 WHERE id = OLD.asset_id;
+		RETURN OLD;
+	END IF;
+	RETURN NULL;
+END;
+$func$ LANGUAGE plpgsql;
+
+CREATE TRIGGER update_asset_usage_count_on_change
+	AFTER INSERT OR DELETE ON demo.demo_assets
+	FOR EACH ROW
+	EXECUTE FUNCTION update_asset_usage_count();
+
+---- create above / drop below ----
+
+DROP TRIGGER IF EXISTS update_asset_usage_count_on_change ON demo.demo_assets;
+DROP FUNCTION IF EXISTS update_asset_usage_count;
+ALTER TABLE asset.assets DROP COLUMN IF EXISTS usage_count;
+DROP INDEX IF EXISTS demo.demo_assets_asset_id_index;
+DROP TABLE IF EXISTS demo.demo_assets;
@@ -0,0 +1,9 @@
+ALTER TABLE demo.demos ADD COLUMN forked_from INTEGER REFERENCES demo.demos (id) ON DELETE SET NULL;
+ALTER TABLE demo.demos ADD COLUMN allow_remix BOOLEAN NOT NULL DEFAULT true;
+CREATE INDEX demo_forked_from_index ON demo.demos (forked_from);
+
+---- create above / drop below ----
+
+DROP INDEX IF EXISTS demo.demo_forked_from_index;
+ALTER TABLE demo.demos DROP COLUMN IF EXISTS forked_from;
+ALTER TABLE demo.demos DROP COLUMN IF EXISTS allow_remix;
@@ -0,0 +1,38 @@
+-- Categories mirror the ones used by the official Godot Asset Library
+-- "type" is 0 for addons and 1 for projects, as the editor expects
+CREATE TABLE asset.categories (
+	"id" INTEGER PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+	"name" VARCHAR(255) NOT NULL UNIQUE,
+	"type" SMALLINT NOT NULL DEFAULT 0 CHECK ("type" IN (0, 1))
+);
+
+INSERT INTO asset.categories (name, type) VALUES
+	('2D Tools', 0),
+	('3D Tools', 0),
+	('Shaders', 0),
+	('Materials', 0),
+	('Tools', 0),
+	('Scripts', 0),
+	('Misc', 0),
+	('Templates', 1),
+	('Projects', 1),
+	('Demos', 1);
+
+ALTER TABLE asset.assets ADD COLUMN category_id INTEGER REFERENCES asset.categories (id) ON DELETE SET NULL;
+ALTER TABLE asset.assets ADD COLUMN godot_version VARCHAR(16);
+ALTER TABLE asset.assets ADD COLUMN support_level VARCHAR(16) NOT NULL DEFAULT 'community'
+	CHECK (support_level IN ('official', 'community', 'testing'));
+ALTER TABLE asset.assets ADD COLUMN license VARCHAR(64) NOT NULL DEFAULT 'MIT';
+ALTER TABLE asset.assets ADD COLUMN download_hash VARCHAR(64);
+
+CREATE INDEX asset_category_index ON asset.assets (category_id);
+
+---- create above / drop below ----
+
+DROP INDEX IF EXISTS asset.asset_category_index;
+ALTER TABLE asset.assets DROP COLUMN IF EXISTS category_id;
+ALTER TABLE asset.assets DROP COLUMN IF EXISTS godot_version;
+ALTER TABLE asset.assets DROP COLUMN IF EXISTS support_level;
+ALTER TABLE asset.assets DROP COLUMN IF EXISTS license;
+ALTER TABLE asset.assets DROP COLUMN IF EXISTS download_hash;
+DROP TABLE IF EXISTS asset.categories;
@@ -0,0 +1,37 @@
+-- Deleted content stays in the trash until purged after the retention period.
+-- Children deleted along with their parent share its deleted_at, so they can be restored together
+ALTER TABLE demo.demos ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE demo.demos ADD COLUMN deleted_by UUID;
+ALTER TABLE asset.assets ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE asset.assets ADD COLUMN deleted_by UUID;
+ALTER TABLE forum.topics ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE forum.topics ADD COLUMN deleted_by UUID;
+ALTER TABLE forum.threads ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE forum.threads ADD COLUMN deleted_by UUID;
+ALTER TABLE forum.messages ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE forum.messages ADD COLUMN deleted_by UUID;
+
+CREATE INDEX demo_deleted_index ON demo.demos (deleted_by, deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX asset_deleted_index ON asset.assets (deleted_by, deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX topic_deleted_index ON forum.topics (deleted_by, deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX thread_deleted_index ON forum.threads (deleted_by, deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX message_deleted_index ON forum.messages (deleted_by, deleted_at) WHERE deleted_at IS NOT NULL;
+
+---- create above / drop below ----
+
+DROP INDEX IF EXISTS demo.demo_deleted_index;
+DROP INDEX IF EXISTS asset.asset_deleted_index;
+DROP INDEX IF EXISTS forum.topic_deleted_index;
+DROP INDEX IF EXISTS forum.thread_deleted_index;
+DROP INDEX IF EXISTS forum.message_deleted_index;
+
+ALTER TABLE demo.demos DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE demo.demos DROP COLUMN IF EXISTS deleted_by;
+ALTER TABLE asset.assets DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE asset.assets DROP COLUMN IF EXISTS deleted_by;
+ALTER TABLE forum.topics DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE forum.topics DROP COLUMN IF EXISTS deleted_by;
+ALTER TABLE forum.threads DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE forum.threads DROP COLUMN IF EXISTS deleted_by;
+ALTER TABLE forum.messages DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE forum.messages DROP COLUMN IF EXISTS deleted_by;
@@ -0,0 +1,39 @@
+CREATE SCHEMA IF NOT EXISTS audit;
+
+-- Actor is not a foreign key: events must outlive the users they mention
+CREATE TABLE audit.events (
+	"id" BIGSERIAL PRIMARY KEY,
+	"actor_id" UUID,
+	"action" VARCHAR(255) NOT NULL,
+	"target_type" VARCHAR(64) NOT NULL,
+	"target_id" VARCHAR(255),
+	"ip" VARCHAR(45),
+	"status" SMALLINT NOT NULL,
+	"before" TEXT,
+	"after" TEXT,
+	"created_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX audit_created_index ON audit.events (created_at);
+CREATE INDEX audit_actor_index ON audit.events (actor_id, created_at);
+CREATE INDEX audit_target_index ON audit.events (target_type, created_at);
+
+-- The log is append-only
+CREATE FUNCTION audit.reject_change() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'audit.events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_events_append_only
+	BEFORE UPDATE OR DELETE ON audit.events
+	FOR EACH ROW EXECUTE FUNCTION audit.reject_change();
+CREATE TRIGGER audit_events_no_truncate
+	BEFORE TRUNCATE ON audit.events
+	FOR EACH STATEMENT EXECUTE FUNCTION audit.reject_change();
+
+---- create above / drop below ----
+
+DROP TABLE IF EXISTS audit.events;
+DROP FUNCTION IF EXISTS audit.reject_change();
+DROP SCHEMA IF EXISTS audit;
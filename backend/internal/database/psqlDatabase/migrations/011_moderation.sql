@@ -0,0 +1,55 @@
+ALTER TABLE demo.demos ADD COLUMN hidden_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE asset.assets ADD COLUMN hidden_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE forum.threads ADD COLUMN hidden_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE forum.threads ADD COLUMN locked_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE forum.messages ADD COLUMN hidden_at TIMESTAMP WITH TIME ZONE;
+
+CREATE SCHEMA IF NOT EXISTS moderation;
+
+CREATE TABLE moderation.reports (
+	"id" SERIAL PRIMARY KEY,
+	"reporter_id" UUID REFERENCES "user".users (id) ON DELETE SET NULL,
+	"target_type" VARCHAR(16) NOT NULL CHECK (target_type IN ('demo', 'asset', 'thread', 'message')),
+	"target_id" INTEGER NOT NULL,
+	"reason" VARCHAR(16) NOT NULL
+		CHECK (reason IN ('spam', 'abuse', 'harassment', 'nsfw', 'illegal', 'copyright', 'other')),
+	"details" TEXT,
+	"state" VARCHAR(16) NOT NULL DEFAULT 'open' CHECK (state IN ('open', 'actioned', 'dismissed')),
+	"created_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+	"resolved_at" TIMESTAMP WITH TIME ZONE,
+	"resolved_by" UUID,
+	"resolution" TEXT
+);
+
+-- A user may only have one open report per piece of content
+CREATE UNIQUE INDEX report_open_unique_index ON moderation.reports (reporter_id, target_type, target_id)
+	WHERE state = 'open';
+CREATE INDEX report_queue_index ON moderation.reports (state, created_at);
+CREATE INDEX report_target_index ON moderation.reports (target_type, target_id);
+
+-- Every moderator action is kept with its reason, including warnings to the author
+CREATE TABLE moderation.actions (
+	"id" SERIAL PRIMARY KEY,
+	"moderator_id" UUID NOT NULL,
+	"target_type" VARCHAR(16) NOT NULL CHECK (target_type IN ('demo', 'asset', 'thread', 'message')),
+	"target_id" INTEGER NOT NULL,
+	"author_id" UUID,
+	"action" VARCHAR(16) NOT NULL CHECK (action IN ('hide', 'unhide', 'lock', 'unlock', 'delete', 'warn')),
+	"reason" TEXT NOT NULL,
+	"created_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX moderation_action_target_index ON moderation.actions (target_type, target_id);
+CREATE INDEX moderation_action_author_index ON moderation.actions (author_id, created_at);
+
+---- create above / drop below ----
+
+DROP TABLE IF EXISTS moderation.actions;
+DROP TABLE IF EXISTS moderation.reports;
+DROP SCHEMA IF EXISTS moderation;
+
+ALTER TABLE demo.demos DROP COLUMN IF EXISTS hidden_at;
+ALTER TABLE asset.assets DROP COLUMN IF EXISTS hidden_at;
+ALTER TABLE forum.threads DROP COLUMN IF EXISTS hidden_at;
+ALTER TABLE forum.threads DROP COLUMN IF EXISTS locked_at;
+ALTER TABLE forum.messages DROP COLUMN IF EXISTS hidden_at;
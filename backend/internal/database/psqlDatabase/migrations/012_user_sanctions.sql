@@ -0,0 +1,30 @@
+-- A sanction without expires_at is permanent until revoked
+CREATE TABLE "user".sanctions (
+	"id" SERIAL PRIMARY KEY,
+	"user_id" UUID NOT NULL REFERENCES "user".users (id) ON DELETE CASCADE,
+	"type" VARCHAR(16) NOT NULL CHECK (type IN ('ban', 'post_mute', 'upload_mute')),
+	"reason" TEXT NOT NULL,
+	"issued_by" UUID,
+	"created_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+	"expires_at" TIMESTAMP WITH TIME ZONE,
+	"revoked_at" TIMESTAMP WITH TIME ZONE,
+	"revoked_by" UUID
+);
+
+CREATE INDEX sanction_user_index ON "user".sanctions (user_id, created_at);
+
+-- Appeals by the sanctioned user and replies by moderators
+CREATE TABLE "user".sanction_notes (
+	"id" SERIAL PRIMARY KEY,
+	"sanction_id" INTEGER NOT NULL REFERENCES "user".sanctions (id) ON DELETE CASCADE,
+	"author_id" UUID,
+	"body" TEXT NOT NULL,
+	"created_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX sanction_note_index ON "user".sanction_notes (sanction_id, created_at);
+
+---- create above / drop below ----
+
+DROP TABLE IF EXISTS "user".sanction_notes;
+DROP TABLE IF EXISTS "user".sanctions;
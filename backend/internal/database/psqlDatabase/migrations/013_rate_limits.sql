@@ -0,0 +1,15 @@
+CREATE SCHEMA IF NOT EXISTS ratelimit;
+
+-- Token buckets shared by all API instances, keyed by user or IP and route class
+CREATE UNLOGGED TABLE ratelimit.buckets (
+	"key" VARCHAR(255) PRIMARY KEY,
+	"tokens" DOUBLE PRECISION NOT NULL,
+	"updated_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX bucket_updated_at_index ON ratelimit.buckets (updated_at);
+
+---- create above / drop below ----
+
+DROP TABLE IF EXISTS ratelimit.buckets;
+DROP SCHEMA IF EXISTS ratelimit;
@@ -0,0 +1,121 @@
+CREATE SCHEMA IF NOT EXISTS notification;
+
+-- Events of the same type on the same target are coalesced into one unread notification
+CREATE TABLE notification.notifications (
+	"id" SERIAL PRIMARY KEY,
+	"user_id" UUID NOT NULL REFERENCES "user".users (id) ON DELETE CASCADE,
+	"type" VARCHAR(16) NOT NULL CHECK (type IN ('reply', 'vote', 'moderation')),
+	"target_type" VARCHAR(16) NOT NULL,
+	"target_id" INTEGER NOT NULL,
+	"actor_id" UUID,
+	"count" INTEGER NOT NULL DEFAULT 1,
+	"created_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+	"updated_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+	"read_at" TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX notification_unread_unique_index ON notification.notifications (user_id, type, target_type, target_id)
+	WHERE read_at IS NULL;
+CREATE INDEX notification_user_index ON notification.notifications (user_id, updated_at);
+
+-- Types without a row are enabled
+CREATE TABLE notification.preferences (
+	"user_id" UUID NOT NULL REFERENCES "user".users (id) ON DELETE CASCADE,
+	"type" VARCHAR(16) NOT NULL CHECK (type IN ('reply', 'vote', 'moderation')),
+	"enabled" BOOLEAN NOT NULL,
+	PRIMARY KEY (user_id, type)
+);
+
+-- Adds events to the unread notification of the recipient, unless the recipient caused them
+-- or turned the type off
+CREATE FUNCTION notification.notify(
+	_user_id UUID, _type VARCHAR, _target_type VARCHAR, _target_id INTEGER, _actor_id UUID, _count INTEGER
+) RETURNS void AS $$
+BEGIN
+	IF _user_id IS NULL OR _user_id = _actor_id THEN
+		RETURN;
+	END IF;
+	IF EXISTS (SELECT 1 FROM notification.preferences WHERE user_id = _user_id AND type = _type AND NOT enabled) THEN
+		RETURN;
+	END IF;
+
+	INSERT INTO notification.notifications AS n
+	(user_id, type, target_type, target_id, actor_id, count)
+	VALUES
+	(_user_id, _type, _target_type, _target_id, _actor_id, _count)
+	ON CONFLICT (user_id, type, target_type, target_id) WHERE read_at IS NULL DO UPDATE SET
+	actor_id=EXCLUDED.actor_id, count=n.count + EXCLUDED.count, updated_at=NOW();
+END;
+$$ LANGUAGE plpgsql;
+
+-- Notifies the thread author and everyone who posted in the thread
+CREATE FUNCTION notification.notify_reply() RETURNS trigger AS $$
+DECLARE
+	recipient UUID;
+BEGIN
+	FOR recipient IN
+		SELECT user_id FROM forum.threads WHERE id = NEW.thread_id
+		UNION
+		SELECT user_id FROM forum.messages WHERE thread_id = NEW.thread_id AND deleted_at IS NULL
+	LOOP
+		PERFORM notification.notify(recipient, 'reply', 'thread', NEW.thread_id, NEW.user_id, 1);
+	END LOOP;
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Counts new votes only. TG_ARGV[0] is the target type
+CREATE FUNCTION notification.notify_vote() RETURNS trigger AS $$
+DECLARE
+	votes INTEGER := GREATEST(NEW.upvotes - OLD.upvotes, 0) + GREATEST(NEW.downvotes - OLD.downvotes, 0);
+BEGIN
+	IF votes = 0 THEN
+		RETURN NULL;
+	END IF;
+	-- Votes on a demo are mirrored to its thread, the demo notification covers both
+	IF TG_ARGV[0] = 'thread' AND EXISTS (SELECT 1 FROM demo.demos WHERE thread_id = NEW.id) THEN
+		RETURN NULL;
+	END IF;
+
+	PERFORM notification.notify(NEW.user_id, 'vote', TG_ARGV[0], NEW.id, NULL, votes);
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE FUNCTION notification.notify_moderation() RETURNS trigger AS $$
+BEGIN
+	PERFORM notification.notify(NEW.author_id, 'moderation', NEW.target_type, NEW.target_id, NEW.moderator_id, 1);
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER message_notify_reply
+	AFTER INSERT ON forum.messages
+	FOR EACH ROW EXECUTE FUNCTION notification.notify_reply();
+CREATE TRIGGER demo_notify_vote
+	AFTER UPDATE OF upvotes, downvotes ON demo.demos
+	FOR EACH ROW EXECUTE FUNCTION notification.notify_vote('demo');
+CREATE TRIGGER thread_notify_vote
+	AFTER UPDATE OF upvotes, downvotes ON forum.threads
+	FOR EACH ROW EXECUTE FUNCTION notification.notify_vote('thread');
+CREATE TRIGGER message_notify_vote
+	AFTER UPDATE OF upvotes, downvotes ON forum.messages
+	FOR EACH ROW EXECUTE FUNCTION notification.notify_vote('message');
+CREATE TRIGGER action_notify_moderation
+	AFTER INSERT ON moderation.actions
+	FOR EACH ROW EXECUTE FUNCTION notification.notify_moderation();
+
+---- create above / drop below ----
+
+DROP TRIGGER IF EXISTS action_notify_moderation ON moderation.actions;
+DROP TRIGGER IF EXISTS message_notify_vote ON forum.messages;
+DROP TRIGGER IF EXISTS thread_notify_vote ON forum.threads;
+DROP TRIGGER IF EXISTS demo_notify_vote ON demo.demos;
+DROP TRIGGER IF EXISTS message_notify_reply ON forum.messages;
+DROP FUNCTION IF EXISTS notification.notify_moderation();
+DROP FUNCTION IF EXISTS notification.notify_vote();
+DROP FUNCTION IF EXISTS notification.notify_reply();
+DROP FUNCTION IF EXISTS notification.notify(UUID, VARCHAR, VARCHAR, INTEGER, UUID, INTEGER);
+DROP TABLE IF EXISTS notification.preferences;
+DROP TABLE IF EXISTS notification.notifications;
+DROP SCHEMA IF EXISTS notification;
@@ -0,0 +1,53 @@
+-- Every user has a row, created along with the user
+CREATE TABLE notification.email_preferences (
+	"user_id" UUID PRIMARY KEY REFERENCES "user".users (id) ON DELETE CASCADE,
+	"frequency" VARCHAR(16) NOT NULL DEFAULT 'daily' CHECK (frequency IN ('off', 'immediate', 'daily', 'weekly')),
+	"locale" VARCHAR(8) NOT NULL DEFAULT 'en' CHECK (locale IN ('en', 'ru')),
+	"followed_tags" VARCHAR(255)[] NOT NULL DEFAULT '{}',
+	"unsubscribe_token" UUID NOT NULL UNIQUE DEFAULT gen_random_uuid(),
+	"last_digest_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO notification.email_preferences (user_id) SELECT id FROM "user".users;
+
+CREATE FUNCTION notification.create_email_preferences() RETURNS trigger AS $$
+BEGIN
+	INSERT INTO notification.email_preferences (user_id) VALUES (NEW.id);
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER user_create_email_preferences
+	AFTER INSERT ON "user".users
+	FOR EACH ROW EXECUTE FUNCTION notification.create_email_preferences();
+
+-- Notifications are mailed once, later events coalesced into them are left to the digest
+ALTER TABLE notification.notifications ADD COLUMN "emailed_at" TIMESTAMP WITH TIME ZONE;
+
+-- Mail is rendered when queued, so retries send exactly the same message
+CREATE TABLE notification.outbox (
+	"id" BIGSERIAL PRIMARY KEY,
+	"user_id" UUID REFERENCES "user".users (id) ON DELETE CASCADE,
+	"recipient" VARCHAR(255) NOT NULL,
+	"subject" TEXT NOT NULL,
+	"html" TEXT NOT NULL,
+	"text" TEXT NOT NULL,
+	"unsubscribe_url" TEXT,
+	"attempts" INTEGER NOT NULL DEFAULT 0,
+	"next_attempt_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+	"last_error" TEXT,
+	"created_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+	"sent_at" TIMESTAMP WITH TIME ZONE,
+	"failed_at" TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX outbox_pending_index ON notification.outbox (next_attempt_at)
+	WHERE sent_at IS NULL AND failed_at IS NULL;
+
+---- create above / drop below ----
+
+DROP TABLE IF EXISTS notification.outbox;
+ALTER TABLE notification.notifications DROP COLUMN IF EXISTS emailed_at;
+DROP TRIGGER IF EXISTS user_create_email_preferences ON "user".users;
+DROP FUNCTION IF EXISTS notification.create_email_preferences();
+DROP TABLE IF EXISTS notification.email_preferences;
@@ -0,0 +1,103 @@
+CREATE SCHEMA IF NOT EXISTS subscription;
+
+-- Exactly one target is set. Subscriptions are removed along with their target
+CREATE TABLE subscription.subscriptions (
+	"id" SERIAL PRIMARY KEY,
+	"user_id" UUID NOT NULL REFERENCES "user".users (id) ON DELETE CASCADE,
+	"thread_id" INTEGER REFERENCES forum.threads (id) ON DELETE CASCADE,
+	"topic_id" INTEGER REFERENCES forum.topics (id) ON DELETE CASCADE,
+	"tag" VARCHAR(255),
+	"followed_user_id" UUID REFERENCES "user".users (id) ON DELETE CASCADE,
+	"created_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+	CHECK (num_nonnulls(thread_id, topic_id, tag, followed_user_id) = 1),
+	UNIQUE (user_id, thread_id),
+	UNIQUE (user_id, topic_id),
+	UNIQUE (user_id, tag),
+	UNIQUE (user_id, followed_user_id)
+);
+
+CREATE INDEX subscription_user_index ON subscription.subscriptions (user_id, created_at);
+CREATE INDEX subscription_thread_index ON subscription.subscriptions (thread_id) WHERE thread_id IS NOT NULL;
+CREATE INDEX subscription_topic_index ON subscription.subscriptions (topic_id) WHERE topic_id IS NOT NULL;
+CREATE INDEX subscription_tag_index ON subscription.subscriptions (tag) WHERE tag IS NOT NULL;
+CREATE INDEX subscription_followed_user_index ON subscription.subscriptions (followed_user_id) WHERE followed_user_id IS NOT NULL;
+
+ALTER TABLE forum.threads ADD COLUMN "subscribers" INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE forum.topics ADD COLUMN "subscribers" INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE "user".users ADD COLUMN "subscribers" INTEGER NOT NULL DEFAULT 0;
+
+CREATE FUNCTION subscription.count_subscribers() RETURNS trigger AS $$
+DECLARE
+	s subscription.subscriptions;
+	delta INTEGER := 1;
+BEGIN
+	IF TG_OP = 'DELETE' THEN
+		s := OLD;
+		delta := -1;
+	ELSE
+		s := NEW;
+	END IF;
+
+	UPDATE forum.threads SET subscribers = subscribers + delta WHERE id = s.thread_id;
+	UPDATE forum.topics SET subscribers = subscribers + delta WHERE id = s.topic_id;
+	UPDATE "user".users SET subscribers = subscribers + delta WHERE id = s.followed_user_id;
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER subscription_count_subscribers
+	AFTER INSERT OR DELETE ON subscription.subscriptions
+	FOR EACH ROW EXECUTE FUNCTION subscription.count_subscribers();
+
+-- Followed tags of the email digest are tag subscriptions from now on
+INSERT INTO subscription.subscriptions (user_id, tag)
+SELECT DISTINCT user_id, unnest(followed_tags) FROM notification.email_preferences;
+ALTER TABLE notification.email_preferences DROP COLUMN followed_tags;
+
+-- Notifies the thread author, everyone who posted in the thread and its subscribers
+CREATE OR REPLACE FUNCTION notification.notify_reply() RETURNS trigger AS $$
+DECLARE
+	recipient UUID;
+BEGIN
+	FOR recipient IN
+		SELECT user_id FROM forum.threads WHERE id = NEW.thread_id
+		UNION
+		SELECT user_id FROM forum.messages WHERE thread_id = NEW.thread_id AND deleted_at IS NULL
+		UNION
+		SELECT user_id FROM subscription.subscriptions WHERE thread_id = NEW.thread_id
+	LOOP
+		PERFORM notification.notify(recipient, 'reply', 'thread', NEW.thread_id, NEW.user_id, 1);
+	END LOOP;
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+---- create above / drop below ----
+
+CREATE OR REPLACE FUNCTION notification.notify_reply() RETURNS trigger AS $$
+DECLARE
+	recipient UUID;
+BEGIN
+	FOR recipient IN
+		SELECT user_id FROM forum.threads WHERE id = NEW.thread_id
+		UNION
+		SELECT user_id FROM forum.messages WHERE thread_id = NEW.thread_id AND deleted_at IS NULL
+	LOOP
+		PERFORM notification.notify(recipient, 'reply', 'thread', NEW.thread_id, NEW.user_id, 1);
+	END LOOP;
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE notification.email_preferences ADD COLUMN IF NOT EXISTS "followed_tags" VARCHAR(255)[] NOT NULL DEFAULT '{}';
+UPDATE notification.email_preferences p SET followed_tags = s.tags
+FROM (SELECT user_id, array_agg(tag) AS tags FROM subscription.subscriptions WHERE tag IS NOT NULL GROUP BY user_id) s
+WHERE s.user_id = p.user_id;
+
+DROP TRIGGER IF EXISTS subscription_count_subscribers ON subscription.subscriptions;
+DROP FUNCTION IF EXISTS subscription.count_subscribers();
+ALTER TABLE "user".users DROP COLUMN IF EXISTS subscribers;
+ALTER TABLE forum.topics DROP COLUMN IF EXISTS subscribers;
+ALTER TABLE forum.threads DROP COLUMN IF EXISTS subscribers;
+DROP TABLE IF EXISTS subscription.subscriptions;
+DROP SCHEMA IF EXISTS subscription;
@@ -0,0 +1,92 @@
+CREATE SCHEMA IF NOT EXISTS live;
+
+-- Every API instance listens on live_events and forwards events to its clients subscribed to the channel.
+-- Payloads only carry IDs and counters, clients fetch the content through the API, which checks visibility
+CREATE FUNCTION live.publish(_channel TEXT, _event TEXT, _data JSONB) RETURNS void AS $$
+BEGIN
+	PERFORM pg_notify('live_events', jsonb_build_object('channel', _channel, 'event', _event, 'data', _data)::TEXT);
+END;
+$$ LANGUAGE plpgsql;
+
+-- Messages that become visible are created for clients, e.g. when restored from the trash,
+-- and messages that are hidden or moved to the trash are deleted
+CREATE FUNCTION live.publish_message() RETURNS trigger AS $$
+DECLARE
+	was_visible BOOLEAN := TG_OP = 'UPDATE' AND OLD.deleted_at IS NULL AND OLD.hidden_at IS NULL;
+	is_visible BOOLEAN := NEW.deleted_at IS NULL AND NEW.hidden_at IS NULL;
+	event TEXT;
+BEGIN
+	IF is_visible AND NOT was_visible THEN
+		event := 'message.created';
+	ELSIF was_visible AND NOT is_visible THEN
+		event := 'message.deleted';
+	ELSIF NOT is_visible THEN
+		RETURN NULL;
+	ELSIF (OLD.title, OLD.body, OLD.tags) IS DISTINCT FROM (NEW.title, NEW.body, NEW.tags) THEN
+		event := 'message.edited';
+	ELSIF (OLD.upvotes, OLD.downvotes) IS DISTINCT FROM (NEW.upvotes, NEW.downvotes) THEN
+		event := 'message.voted';
+	ELSE
+		RETURN NULL;
+	END IF;
+
+	PERFORM live.publish('thread:' || NEW.thread_id, event, jsonb_build_object(
+		'id', NEW.id, 'threadID', NEW.thread_id, 'userID', NEW.user_id, 'updatedAt', NEW.updated_at,
+		'upvotes', NEW.upvotes, 'downvotes', NEW.downvotes
+	));
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Votes on a demo are mirrored to its thread, so they are published on the thread channel too
+CREATE FUNCTION live.publish_thread_vote() RETURNS trigger AS $$
+BEGIN
+	IF (OLD.upvotes, OLD.downvotes) IS NOT DISTINCT FROM (NEW.upvotes, NEW.downvotes) THEN
+		RETURN NULL;
+	END IF;
+
+	PERFORM live.publish('thread:' || NEW.id, 'thread.voted', jsonb_build_object(
+		'id', NEW.id, 'upvotes', NEW.upvotes, 'downvotes', NEW.downvotes
+	));
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE FUNCTION live.publish_notification() RETURNS trigger AS $$
+DECLARE
+	event TEXT := 'notification';
+BEGIN
+	IF NEW.read_at IS NOT NULL THEN
+		IF TG_OP = 'UPDATE' AND OLD.read_at IS NOT NULL THEN
+			RETURN NULL;
+		END IF;
+		event := 'notification.read';
+	END IF;
+
+	PERFORM live.publish('user:' || NEW.user_id, event, jsonb_build_object(
+		'id', NEW.id, 'type', NEW.type, 'targetType', NEW.target_type, 'targetID', NEW.target_id, 'count', NEW.count
+	));
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER message_publish
+	AFTER INSERT OR UPDATE ON forum.messages
+	FOR EACH ROW EXECUTE FUNCTION live.publish_message();
+CREATE TRIGGER thread_publish_vote
+	AFTER UPDATE OF upvotes, downvotes ON forum.threads
+	FOR EACH ROW EXECUTE FUNCTION live.publish_thread_vote();
+CREATE TRIGGER notification_publish
+	AFTER INSERT OR UPDATE OF count, read_at ON notification.notifications
+	FOR EACH ROW EXECUTE FUNCTION live.publish_notification();
+
+---- create above / drop below ----
+
+DROP TRIGGER IF EXISTS notification_publish ON notification.notifications;
+DROP TRIGGER IF EXISTS thread_publish_vote ON forum.threads;
+DROP TRIGGER IF EXISTS message_publish ON forum.messages;
+DROP FUNCTION IF EXISTS live.publish_notification();
+DROP FUNCTION IF EXISTS live.publish_thread_vote();
+DROP FUNCTION IF EXISTS live.publish_message();
+DROP FUNCTION IF EXISTS live.publish(TEXT, TEXT, JSONB);
+DROP SCHEMA IF EXISTS live;
@@ -0,0 +1,56 @@
+-- Replies keep their parent when it is deleted, it is shown as a tombstone instead.
+-- The trash keeps messages with replies, see purgeSpecs
+ALTER TABLE forum.messages ADD COLUMN "reply_to" INTEGER REFERENCES forum.messages (id) ON DELETE SET NULL;
+-- Excerpt of the parent message quoted by the reply, kept as it was when replying
+ALTER TABLE forum.messages ADD COLUMN "quote" VARCHAR(1000);
+-- Visible direct replies
+ALTER TABLE forum.messages ADD COLUMN "replies" INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX message_reply_to_index ON forum.messages (reply_to) WHERE reply_to IS NOT NULL;
+
+CREATE FUNCTION forum.count_replies() RETURNS trigger AS $$
+BEGIN
+	UPDATE forum.messages p SET replies = (
+		SELECT COUNT(*) FROM forum.messages r
+		WHERE r.reply_to = p.id AND r.deleted_at IS NULL AND r.hidden_at IS NULL
+	)
+	WHERE p.id IN (
+		CASE WHEN TG_OP <> 'INSERT' THEN OLD.reply_to END,
+		CASE WHEN TG_OP <> 'DELETE' THEN NEW.reply_to END
+	);
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- A message moved to another thread leaves its parent and its replies behind, unless they moved along
+CREATE FUNCTION forum.keep_replies_in_thread() RETURNS trigger AS $$
+BEGIN
+	IF OLD.thread_id = NEW.thread_id THEN
+		RETURN NULL;
+	END IF;
+
+	UPDATE forum.messages SET reply_to = NULL
+	WHERE (id = NEW.id AND reply_to IN (SELECT id FROM forum.messages WHERE thread_id <> NEW.thread_id))
+		OR (reply_to = NEW.id AND thread_id <> NEW.thread_id);
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER message_count_replies
+	AFTER INSERT OR UPDATE OF reply_to, deleted_at, hidden_at OR DELETE ON forum.messages
+	FOR EACH ROW EXECUTE FUNCTION forum.count_replies();
+CREATE TRIGGER message_keep_replies_in_thread
+	AFTER UPDATE OF thread_id ON forum.messages
+	FOR EACH ROW EXECUTE FUNCTION forum.keep_replies_in_thread();
+
+---- create above / drop below ----
+
+DROP TRIGGER IF EXISTS message_keep_replies_in_thread ON forum.messages;
+DROP TRIGGER IF EXISTS message_count_replies ON forum.messages;
+DROP FUNCTION IF EXISTS forum.keep_replies_in_thread();
+DROP FUNCTION IF EXISTS forum.count_replies();
+
+DROP INDEX IF EXISTS forum.message_reply_to_index;
+ALTER TABLE forum.messages DROP COLUMN IF EXISTS replies;
+ALTER TABLE forum.messages DROP COLUMN IF EXISTS quote;
+ALTER TABLE forum.messages DROP COLUMN IF EXISTS reply_to;
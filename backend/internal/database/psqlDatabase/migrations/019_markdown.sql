@@ -0,0 +1,14 @@
+-- HTML rendered from the Markdown source by the API whenever the source is written.
+-- Rows without HTML are rendered on start, so clearing it renders everything again
+ALTER TABLE forum.messages ADD COLUMN "body_html" TEXT;
+ALTER TABLE demo.demos ADD COLUMN "description_html" TEXT;
+
+CREATE INDEX message_unrendered_index ON forum.messages (id) WHERE body_html IS NULL;
+CREATE INDEX demo_unrendered_index ON demo.demos (id) WHERE description_html IS NULL AND description IS NOT NULL;
+
+---- create above / drop below ----
+
+DROP INDEX IF EXISTS demo.demo_unrendered_index;
+DROP INDEX IF EXISTS forum.message_unrendered_index;
+ALTER TABLE demo.demos DROP COLUMN IF EXISTS description_html;
+ALTER TABLE forum.messages DROP COLUMN IF EXISTS body_html;
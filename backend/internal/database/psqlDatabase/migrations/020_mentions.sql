@@ -0,0 +1,48 @@
+-- Users mentioned as @username in a message, resolved when the message is written
+CREATE TABLE forum.mentions (
+	"message_id" INTEGER NOT NULL REFERENCES forum.messages (id) ON DELETE CASCADE,
+	"user_id" UUID NOT NULL REFERENCES "user".users (id) ON DELETE CASCADE,
+	"created_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+	PRIMARY KEY (message_id, user_id)
+);
+
+CREATE INDEX mention_user_index ON forum.mentions (user_id, created_at);
+-- Mentions are resolved case-insensitively
+CREATE INDEX user_username_case_insensitive_index ON "user".users (username COLLATE case_insensitive);
+
+ALTER TABLE notification.notifications DROP CONSTRAINT notifications_type_check;
+ALTER TABLE notification.notifications ADD CONSTRAINT notifications_type_check
+	CHECK (type IN ('reply', 'vote', 'moderation', 'mention'));
+ALTER TABLE notification.preferences DROP CONSTRAINT preferences_type_check;
+ALTER TABLE notification.preferences ADD CONSTRAINT preferences_type_check
+	CHECK (type IN ('reply', 'vote', 'moderation', 'mention'));
+
+-- Only new mentions notify, editing a message does not ping the users it already mentioned
+CREATE FUNCTION notification.notify_mention() RETURNS trigger AS $$
+BEGIN
+	PERFORM notification.notify(NEW.user_id, 'mention', 'message', NEW.message_id, m.user_id, 1)
+	FROM forum.messages m WHERE m.id = NEW.message_id;
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER mention_notify_mention
+	AFTER INSERT ON forum.mentions
+	FOR EACH ROW EXECUTE FUNCTION notification.notify_mention();
+
+---- create above / drop below ----
+
+DROP TRIGGER IF EXISTS mention_notify_mention ON forum.mentions;
+DROP FUNCTION IF EXISTS notification.notify_mention();
+
+DELETE FROM notification.preferences WHERE type = 'mention';
+DELETE FROM notification.notifications WHERE type = 'mention';
+ALTER TABLE notification.preferences DROP CONSTRAINT IF EXISTS preferences_type_check;
+ALTER TABLE notification.preferences ADD CONSTRAINT preferences_type_check
+	CHECK (type IN ('reply', 'vote', 'moderation'));
+ALTER TABLE notification.notifications DROP CONSTRAINT IF EXISTS notifications_type_check;
+ALTER TABLE notification.notifications ADD CONSTRAINT notifications_type_check
+	CHECK (type IN ('reply', 'vote', 'moderation'));
+
+DROP INDEX IF EXISTS "user".user_username_case_insensitive_index;
+DROP TABLE IF EXISTS forum.mentions;
@@ -0,0 +1,123 @@
+-- Every update sets edited_by to the user behind it, NULL for updates made by the site itself.
+-- When the content changed, the previous content is kept as a revision along with the editor,
+-- otherwise edited_by keeps the editor of the latest revision
+ALTER TABLE forum.messages ADD COLUMN "edited_at" TIMESTAMP WITH TIME ZONE;
+ALTER TABLE forum.messages ADD COLUMN "edited_by" UUID;
+ALTER TABLE forum.messages ADD COLUMN "revisions" INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE forum.threads ADD COLUMN "edited_at" TIMESTAMP WITH TIME ZONE;
+ALTER TABLE forum.threads ADD COLUMN "edited_by" UUID;
+ALTER TABLE forum.threads ADD COLUMN "revisions" INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE demo.demos ADD COLUMN "edited_at" TIMESTAMP WITH TIME ZONE;
+ALTER TABLE demo.demos ADD COLUMN "edited_by" UUID;
+ALTER TABLE demo.demos ADD COLUMN "revisions" INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE forum.message_revisions (
+	"id" SERIAL PRIMARY KEY,
+	"message_id" INTEGER NOT NULL REFERENCES forum.messages (id) ON DELETE CASCADE,
+	"title" VARCHAR(255) NOT NULL,
+	"body" VARCHAR NOT NULL,
+	"tags" VARCHAR(255)[],
+	"edited_by" UUID,
+	"edited_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE forum.thread_revisions (
+	"id" SERIAL PRIMARY KEY,
+	"thread_id" INTEGER NOT NULL REFERENCES forum.threads (id) ON DELETE CASCADE,
+	"title" VARCHAR(255) NOT NULL,
+	"tags" VARCHAR(255)[],
+	"edited_by" UUID,
+	"edited_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE demo.demo_revisions (
+	"id" SERIAL PRIMARY KEY,
+	"demo_id" INTEGER NOT NULL REFERENCES demo.demos (id) ON DELETE CASCADE,
+	"title" TEXT NOT NULL,
+	"description" TEXT,
+	"tags" TEXT[],
+	"edited_by" UUID,
+	"edited_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX message_revision_message_index ON forum.message_revisions (message_id, id);
+CREATE INDEX thread_revision_thread_index ON forum.thread_revisions (thread_id, id);
+CREATE INDEX demo_revision_demo_index ON demo.demo_revisions (demo_id, id);
+
+CREATE FUNCTION forum.record_message_revision() RETURNS trigger AS $$
+BEGIN
+	IF (OLD.title, OLD.body, OLD.tags) IS NOT DISTINCT FROM (NEW.title, NEW.body, NEW.tags) THEN
+		NEW.edited_by := OLD.edited_by;
+		RETURN NEW;
+	END IF;
+
+	INSERT INTO forum.message_revisions (message_id, title, body, tags, edited_by)
+	VALUES (OLD.id, OLD.title, OLD.body, OLD.tags, NEW.edited_by);
+	NEW.edited_at := NOW();
+	NEW.revisions := OLD.revisions + 1;
+	RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE FUNCTION forum.record_thread_revision() RETURNS trigger AS $$
+BEGIN
+	IF (OLD.title, OLD.tags) IS NOT DISTINCT FROM (NEW.title, NEW.tags) THEN
+		NEW.edited_by := OLD.edited_by;
+		RETURN NEW;
+	END IF;
+
+	INSERT INTO forum.thread_revisions (thread_id, title, tags, edited_by)
+	VALUES (OLD.id, OLD.title, OLD.tags, NEW.edited_by);
+	NEW.edited_at := NOW();
+	NEW.revisions := OLD.revisions + 1;
+	RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE FUNCTION demo.record_demo_revision() RETURNS trigger AS $$
+BEGIN
+	IF (OLD.title, OLD.description, OLD.tags) IS NOT DISTINCT FROM (NEW.title, NEW.description, NEW.tags) THEN
+		NEW.edited_by := OLD.edited_by;
+		RETURN NEW;
+	END IF;
+
+	INSERT INTO demo.demo_revisions (demo_id, title, description, tags, edited_by)
+	VALUES (OLD.id, OLD.title, OLD.description, OLD.tags, NEW.edited_by);
+	NEW.edited_at := NOW();
+	NEW.revisions := OLD.revisions + 1;
+	RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER message_record_revision
+	BEFORE UPDATE OF title, body, tags, edited_by ON forum.messages
+	FOR EACH ROW EXECUTE FUNCTION forum.record_message_revision();
+CREATE TRIGGER thread_record_revision
+	BEFORE UPDATE OF title, tags, edited_by ON forum.threads
+	FOR EACH ROW EXECUTE FUNCTION forum.record_thread_revision();
+CREATE TRIGGER demo_record_revision
+	BEFORE UPDATE OF title, description, tags, edited_by ON demo.demos
+	FOR EACH ROW EXECUTE FUNCTION demo.record_demo_revision();
+
+---- create above / drop below ----
+
+DROP TRIGGER IF EXISTS demo_record_revision ON demo.demos;
+DROP TRIGGER IF EXISTS thread_record_revision ON forum.threads;
+DROP TRIGGER IF EXISTS message_record_revision ON forum.messages;
+DROP FUNCTION IF EXISTS demo.record_demo_revision();
+DROP FUNCTION IF EXISTS forum.record_thread_revision();
+DROP FUNCTION IF EXISTS forum.record_message_revision();
+
+DROP TABLE IF EXISTS demo.demo_revisions;
+DROP TABLE IF EXISTS forum.thread_revisions;
+DROP TABLE IF EXISTS forum.message_revisions;
+
+ALTER TABLE demo.demos DROP COLUMN IF EXISTS revisions;
+ALTER TABLE demo.demos DROP COLUMN IF EXISTS edited_by;
+ALTER TABLE demo.demos DROP COLUMN IF EXISTS edited_at;
+ALTER TABLE forum.threads DROP COLUMN IF EXISTS revisions;
+ALTER TABLE forum.threads DROP COLUMN IF EXISTS edited_by;
+ALTER TABLE forum.threads DROP COLUMN IF EXISTS edited_at;
+ALTER TABLE forum.messages DROP COLUMN IF EXISTS revisions;
+ALTER TABLE forum.messages DROP COLUMN IF EXISTS edited_by;
+ALTER TABLE forum.messages DROP COLUMN IF EXISTS edited_at;
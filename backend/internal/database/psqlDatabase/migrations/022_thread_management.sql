@@ -0,0 +1,111 @@
+-- Threads are managed by moderators and by the owner of their topic
+ALTER TABLE forum.topics ADD COLUMN "user_id" UUID;
+-- Pinned threads are listed first in their topic
+ALTER TABLE forum.threads ADD COLUMN "pinned_at" TIMESTAMP WITH TIME ZONE;
+-- Incremented by every thread operation, which must name the version it was meant for
+ALTER TABLE forum.threads ADD COLUMN "version" INTEGER NOT NULL DEFAULT 1;
+
+-- Target thread is not a foreign key: the thread merged into or split off may be purged from the trash
+CREATE TABLE forum.thread_operations (
+	"id" SERIAL PRIMARY KEY,
+	"thread_id" INTEGER NOT NULL REFERENCES forum.threads (id) ON DELETE CASCADE,
+	"user_id" UUID NOT NULL,
+	"operation" VARCHAR(16) NOT NULL
+		CHECK (operation IN ('pin', 'unpin', 'lock', 'unlock', 'move', 'merge', 'split')),
+	"version" INTEGER NOT NULL,
+	"from_topic_id" INTEGER,
+	"topic_id" INTEGER,
+	"target_thread_id" INTEGER,
+	"message_ids" INTEGER[],
+	"title" VARCHAR(255),
+	"reason" TEXT,
+	"created_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX thread_operation_thread_index ON forum.thread_operations (thread_id, id);
+CREATE INDEX thread_operation_target_index ON forum.thread_operations (target_thread_id, id)
+	WHERE target_thread_id IS NOT NULL;
+CREATE INDEX thread_pinned_index ON forum.threads (topic_id, pinned_at) WHERE pinned_at IS NOT NULL;
+
+-- Messages merged or split into another thread are deleted from the channel of the thread they left
+-- and created on the channel of the thread they joined
+CREATE OR REPLACE FUNCTION live.publish_message() RETURNS trigger AS $$
+DECLARE
+	was_visible BOOLEAN := TG_OP = 'UPDATE' AND OLD.deleted_at IS NULL AND OLD.hidden_at IS NULL;
+	is_visible BOOLEAN := NEW.deleted_at IS NULL AND NEW.hidden_at IS NULL;
+	event TEXT;
+BEGIN
+	IF TG_OP = 'UPDATE' AND OLD.thread_id <> NEW.thread_id THEN
+		IF was_visible THEN
+			PERFORM live.publish('thread:' || OLD.thread_id, 'message.deleted', jsonb_build_object(
+				'id', OLD.id, 'threadID', OLD.thread_id, 'userID', OLD.user_id, 'updatedAt', OLD.updated_at,
+				'upvotes', OLD.upvotes, 'downvotes', OLD.downvotes
+			));
+		END IF;
+		IF is_visible THEN
+			PERFORM live.publish('thread:' || NEW.thread_id, 'message.created', jsonb_build_object(
+				'id', NEW.id, 'threadID', NEW.thread_id, 'userID', NEW.user_id, 'updatedAt', NEW.updated_at,
+				'upvotes', NEW.upvotes, 'downvotes', NEW.downvotes
+			));
+		END IF;
+		RETURN NULL;
+	END IF;
+
+	IF is_visible AND NOT was_visible THEN
+		event := 'message.created';
+	ELSIF was_visible AND NOT is_visible THEN
+		event := 'message.deleted';
+	ELSIF NOT is_visible THEN
+		RETURN NULL;
+	ELSIF (OLD.title, OLD.body, OLD.tags) IS DISTINCT FROM (NEW.title, NEW.body, NEW.tags) THEN
+		event := 'message.edited';
+	ELSIF (OLD.upvotes, OLD.downvotes) IS DISTINCT FROM (NEW.upvotes, NEW.downvotes) THEN
+		event := 'message.voted';
+	ELSE
+		RETURN NULL;
+	END IF;
+
+	PERFORM live.publish('thread:' || NEW.thread_id, event, jsonb_build_object(
+		'id', NEW.id, 'threadID', NEW.thread_id, 'userID', NEW.user_id, 'updatedAt', NEW.updated_at,
+		'upvotes', NEW.upvotes, 'downvotes', NEW.downvotes
+	));
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+---- create above / drop below ----
+
+CREATE OR REPLACE FUNCTION live.publish_message() RETURNS trigger AS $$
+DECLARE
+	was_visible BOOLEAN := TG_OP = 'UPDATE' AND OLD.deleted_at IS NULL AND OLD.hidden_at IS NULL;
+	is_visible BOOLEAN := NEW.deleted_at IS NULL AND NEW.hidden_at IS NULL;
+	event TEXT;
+BEGIN
+	IF is_visible AND NOT was_visible THEN
+		event := 'message.created';
+	ELSIF was_visible AND NOT is_visible THEN
+		event := 'message.deleted';
+	ELSIF NOT is_visible THEN
+		RETURN NULL;
+	ELSIF (OLD.title, OLD.body, OLD.tags) IS DISTINCT FROM (NEW.title, NEW.body, NEW.tags) THEN
+		event := 'message.edited';
+	ELSIF (OLD.upvotes, OLD.downvotes) IS DISTINCT FROM (NEW.upvotes, NEW.downvotes) THEN
+		event := 'message.voted';
+	ELSE
+		RETURN NULL;
+	END IF;
+
+	PERFORM live.publish('thread:' || NEW.thread_id, event, jsonb_build_object(
+		'id', NEW.id, 'threadID', NEW.thread_id, 'userID', NEW.user_id, 'updatedAt', NEW.updated_at,
+		'upvotes', NEW.upvotes, 'downvotes', NEW.downvotes
+	));
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP INDEX IF EXISTS forum.thread_pinned_index;
+DROP TABLE IF EXISTS forum.thread_operations;
+
+ALTER TABLE forum.threads DROP COLUMN IF EXISTS version;
+ALTER TABLE forum.threads DROP COLUMN IF EXISTS pinned_at;
+ALTER TABLE forum.topics DROP COLUMN IF EXISTS user_id;
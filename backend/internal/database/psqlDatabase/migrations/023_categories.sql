@@ -0,0 +1,49 @@
+-- Topics are categories: they nest under a parent category and carry their own posting rules
+ALTER TABLE forum.topics ADD COLUMN "slug" VARCHAR(90);
+ALTER TABLE forum.topics ADD COLUMN "description" VARCHAR(2000);
+ALTER TABLE forum.topics ADD COLUMN "icon" VARCHAR(255);
+ALTER TABLE forum.topics ADD COLUMN "sort_order" INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE forum.topics ADD COLUMN "parent_id" INTEGER REFERENCES forum.topics (id) ON DELETE CASCADE;
+-- Who may create threads: everyone, the owner of the category or moderators only. Moderators are never restricted
+ALTER TABLE forum.topics ADD COLUMN "thread_access" VARCHAR(16) NOT NULL DEFAULT 'everyone'
+	CHECK (thread_access IN ('everyone', 'owner', 'moderators'));
+-- Accounts younger than this may neither create threads nor post messages in the category
+ALTER TABLE forum.topics ADD COLUMN "min_account_age_days" INTEGER NOT NULL DEFAULT 0
+	CHECK (min_account_age_days >= 0);
+
+-- Demos used to go to topic 1, which is taken over as the Demos category
+UPDATE forum.topics SET slug = 'demos', thread_access = 'moderators'
+WHERE id = (SELECT MIN(t.topic_id) FROM forum.threads t JOIN demo.demos d ON d.thread_id = t.id);
+
+UPDATE forum.topics t SET slug = s.slug
+FROM (
+	SELECT id, CASE
+		WHEN base = '' THEN 'topic-' || id
+		WHEN ROW_NUMBER() OVER (PARTITION BY base ORDER BY id) > 1 OR base = 'demos' THEN base || '-' || id
+		ELSE base
+	END AS slug
+	FROM (
+		SELECT id, TRIM(BOTH '-' FROM REGEXP_REPLACE(LOWER(name), '[^[:alnum:]]+', '-', 'g')) AS base
+		FROM forum.topics WHERE slug IS NULL
+	) b
+) s
+WHERE t.id = s.id;
+
+ALTER TABLE forum.topics ALTER COLUMN slug SET NOT NULL;
+
+-- Slugs of categories in the trash may be taken again
+CREATE UNIQUE INDEX topic_slug_unique_index ON forum.topics (slug) WHERE deleted_at IS NULL;
+CREATE INDEX topic_parent_index ON forum.topics (parent_id, sort_order);
+
+---- create above / drop below ----
+
+DROP INDEX IF EXISTS forum.topic_parent_index;
+DROP INDEX IF EXISTS forum.topic_slug_unique_index;
+
+ALTER TABLE forum.topics DROP COLUMN IF EXISTS min_account_age_days;
+ALTER TABLE forum.topics DROP COLUMN IF EXISTS thread_access;
+ALTER TABLE forum.topics DROP COLUMN IF EXISTS parent_id;
+ALTER TABLE forum.topics DROP COLUMN IF EXISTS sort_order;
+ALTER TABLE forum.topics DROP COLUMN IF EXISTS icon;
+ALTER TABLE forum.topics DROP COLUMN IF EXISTS description;
+ALTER TABLE forum.topics DROP COLUMN IF EXISTS slug;
@@ -0,0 +1,31 @@
+-- Users assigned by admins to moderate the threads and messages of a category
+CREATE TABLE forum.topic_moderators (
+	"topic_id" INTEGER NOT NULL REFERENCES forum.topics (id) ON DELETE CASCADE,
+	"user_id" UUID NOT NULL REFERENCES "user".users (id) ON DELETE CASCADE,
+	"assigned_by" UUID,
+	"created_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+	PRIMARY KEY (topic_id, user_id)
+);
+
+CREATE INDEX topic_moderator_user_index ON forum.topic_moderators (user_id);
+
+-- Moderators of a category also moderate its subcategories
+CREATE OR REPLACE FUNCTION forum.moderates_topic(INTEGER, UUID) RETURNS BOOLEAN AS $$
+	WITH RECURSIVE ancestors AS (
+		SELECT id, parent_id FROM forum.topics WHERE id = $1
+		UNION
+		SELECT tp.id, tp.parent_id FROM forum.topics tp JOIN ancestors a ON tp.id = a.parent_id
+	)
+	SELECT EXISTS (
+		SELECT 1 FROM forum.topic_moderators m JOIN ancestors a ON a.id = m.topic_id WHERE m.user_id = $2
+	);
+$$ LANGUAGE sql STABLE;
+
+-- Category the action was limited to, when taken by a moderator of the category
+ALTER TABLE moderation.actions ADD COLUMN "topic_id" INTEGER;
+
+---- create above / drop below ----
+
+ALTER TABLE moderation.actions DROP COLUMN IF EXISTS topic_id;
+DROP FUNCTION IF EXISTS forum.moderates_topic(INTEGER, UUID);
+DROP TABLE IF EXISTS forum.topic_moderators;
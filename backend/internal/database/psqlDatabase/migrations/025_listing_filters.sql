@@ -0,0 +1,14 @@
+-- Size in bytes of the uploaded project file, NULL for files uploaded before sizes were recorded
+ALTER TABLE demo.demos ADD COLUMN "file_size" BIGINT;
+ALTER TABLE asset.assets ADD COLUMN "file_size" BIGINT;
+
+-- Listings are filtered by author
+CREATE INDEX demo_user_index ON demo.demos (user_id);
+CREATE INDEX thread_user_index ON forum.threads (user_id);
+
+---- create above / drop below ----
+
+DROP INDEX IF EXISTS forum.thread_user_index;
+DROP INDEX IF EXISTS demo.demo_user_index;
+ALTER TABLE asset.assets DROP COLUMN IF EXISTS file_size;
+ALTER TABLE demo.demos DROP COLUMN IF EXISTS file_size;
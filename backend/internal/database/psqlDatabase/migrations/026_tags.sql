@@ -0,0 +1,192 @@
+CREATE SCHEMA IF NOT EXISTS tag;
+
+-- Content and subscriptions store tags by slug, names are only shown
+CREATE TABLE tag.tags (
+	"id" SERIAL PRIMARY KEY,
+	"slug" VARCHAR(64) NOT NULL UNIQUE,
+	"name" VARCHAR(64) NOT NULL,
+	"created_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Other slugs of a tag, added by admins or left behind by merges. No tag has the slug of an alias
+CREATE TABLE tag.aliases (
+	"slug" VARCHAR(64) PRIMARY KEY,
+	"tag_id" INTEGER NOT NULL REFERENCES tag.tags (id) ON DELETE CASCADE,
+	"created_by" UUID,
+	"created_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Number of demos, assets, threads and messages tagged with a tag, not counting deleted ones
+CREATE TABLE tag.usage (
+	"tag_id" INTEGER NOT NULL REFERENCES tag.tags (id) ON DELETE CASCADE,
+	"content_type" VARCHAR(16) NOT NULL,
+	"count" INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY (tag_id, content_type)
+);
+
+CREATE INDEX tag_slug_prefix_index ON tag.tags (slug varchar_pattern_ops);
+CREATE INDEX tag_alias_slug_prefix_index ON tag.aliases (slug varchar_pattern_ops);
+CREATE INDEX tag_alias_tag_index ON tag.aliases (tag_id);
+
+-- Lowercase with runs of characters other than letters, digits, "+", "#" and "." turned into a dash, like
+-- category slugs, so "2D", "2d" and " 2d " are the same tag while "C#" and "C++" are not
+CREATE FUNCTION tag.slugify(TEXT) RETURNS TEXT AS $$
+	SELECT left(trim(BOTH '-' FROM regexp_replace(lower($1), '[^[:alnum:]+#.]+', '-', 'g')), 64);
+$$ LANGUAGE sql IMMUTABLE;
+
+-- Canonical slugs of the tags in their first order, following aliases. Unknown tags keep their slug
+CREATE FUNCTION tag.canonical(TEXT[]) RETURNS TEXT[] AS $$
+	SELECT CASE WHEN $1 IS NULL THEN NULL ELSE COALESCE((
+		SELECT array_agg(slug ORDER BY n) FROM (
+			SELECT DISTINCT ON (slug) slug, n FROM (
+				SELECT COALESCE(t.slug, tag.slugify(s.name)) AS slug, s.n
+				FROM unnest($1) WITH ORDINALITY AS s (name, n)
+				LEFT JOIN tag.aliases a ON a.slug = tag.slugify(s.name)
+				LEFT JOIN tag.tags t ON t.id = a.tag_id
+			) resolved
+			WHERE slug <> ''
+			ORDER BY slug, n
+		) deduplicated
+	), '{}') END;
+$$ LANGUAGE sql STABLE;
+
+-- Creates the tags not known yet, named as first spelled, and returns the canonical slugs
+CREATE FUNCTION tag.resolve(TEXT[]) RETURNS TEXT[] AS $$
+BEGIN
+	INSERT INTO tag.tags (slug, name)
+	SELECT DISTINCT ON (slug) slug, left(regexp_replace(trim(name), '[[:space:]]+', ' ', 'g'), 64) FROM (
+		SELECT tag.slugify(s.name) AS slug, s.name, s.n FROM unnest($1) WITH ORDINALITY AS s (name, n)
+	) spelled
+	WHERE slug <> '' AND NOT EXISTS (SELECT 1 FROM tag.aliases a WHERE a.slug = spelled.slug)
+	ORDER BY slug, n
+	ON CONFLICT (slug) DO NOTHING;
+
+	RETURN tag.canonical($1);
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE FUNCTION tag.normalize_tags() RETURNS trigger AS $$
+BEGIN
+	NEW.tags := tag.resolve(NEW.tags);
+	RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE FUNCTION tag.normalize_subscription_tag() RETURNS trigger AS $$
+BEGIN
+	IF NEW.tag IS NOT NULL THEN
+		NEW.tag := (tag.resolve(ARRAY[NEW.tag]))[1];
+	END IF;
+	RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Keeps tag.usage in step with the tags of live content. The content type is the trigger argument
+CREATE FUNCTION tag.count_usage() RETURNS trigger AS $$
+DECLARE
+	old_tags TEXT[] := '{}';
+	new_tags TEXT[] := '{}';
+BEGIN
+	IF TG_OP <> 'INSERT' AND OLD.deleted_at IS NULL THEN
+		old_tags := COALESCE(OLD.tags, '{}');
+	END IF;
+	IF TG_OP <> 'DELETE' AND NEW.deleted_at IS NULL THEN
+		new_tags := COALESCE(NEW.tags, '{}');
+	END IF;
+	IF old_tags = new_tags THEN
+		RETURN NULL;
+	END IF;
+
+	INSERT INTO tag.usage AS u (tag_id, content_type, count)
+	SELECT t.id, TG_ARGV[0], changes.delta FROM (
+		SELECT slug, SUM(delta) AS delta FROM (
+			SELECT unnest(new_tags) AS slug, 1 AS delta
+			UNION ALL
+			SELECT unnest(old_tags), -1
+		) changed
+		GROUP BY slug HAVING SUM(delta) <> 0
+	) changes
+	JOIN tag.tags t ON t.slug = changes.slug
+	ON CONFLICT (tag_id, content_type) DO UPDATE SET count = u.count + EXCLUDED.count;
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Existing tags are normalized without recording edits or notifying anyone
+ALTER TABLE demo.demos DISABLE TRIGGER USER;
+ALTER TABLE asset.assets DISABLE TRIGGER USER;
+ALTER TABLE forum.threads DISABLE TRIGGER USER;
+ALTER TABLE forum.messages DISABLE TRIGGER USER;
+UPDATE demo.demos SET tags = tag.resolve(tags) WHERE tags IS NOT NULL;
+UPDATE asset.assets SET tags = tag.resolve(tags) WHERE tags IS NOT NULL;
+UPDATE forum.threads SET tags = tag.resolve(tags) WHERE tags IS NOT NULL;
+UPDATE forum.messages SET tags = tag.resolve(tags) WHERE tags IS NOT NULL;
+ALTER TABLE demo.demos ENABLE TRIGGER USER;
+ALTER TABLE asset.assets ENABLE TRIGGER USER;
+ALTER TABLE forum.threads ENABLE TRIGGER USER;
+ALTER TABLE forum.messages ENABLE TRIGGER USER;
+
+-- Subscriptions to spellings of the same tag collapse into one
+DELETE FROM subscription.subscriptions s
+WHERE s.tag IS NOT NULL AND (tag.slugify(s.tag) = '' OR EXISTS (
+	SELECT 1 FROM subscription.subscriptions d
+	WHERE d.user_id = s.user_id AND d.tag IS NOT NULL AND d.id < s.id AND tag.slugify(d.tag) = tag.slugify(s.tag)
+));
+UPDATE subscription.subscriptions SET tag = (tag.resolve(ARRAY[tag]))[1] WHERE tag IS NOT NULL;
+
+INSERT INTO tag.usage (tag_id, content_type, count)
+SELECT t.id, tagged.content_type, COUNT(*) FROM (
+	SELECT 'demo' AS content_type, unnest(tags) AS slug FROM demo.demos WHERE deleted_at IS NULL
+	UNION ALL
+	SELECT 'asset', unnest(tags) FROM asset.assets WHERE deleted_at IS NULL
+	UNION ALL
+	SELECT 'thread', unnest(tags) FROM forum.threads WHERE deleted_at IS NULL
+	UNION ALL
+	SELECT 'message', unnest(tags) FROM forum.messages WHERE deleted_at IS NULL
+) tagged
+JOIN tag.tags t ON t.slug = tagged.slug
+GROUP BY t.id, tagged.content_type;
+
+-- Tags are normalized before revisions are recorded, which compares them
+CREATE TRIGGER demo_normalize_tags
+	BEFORE INSERT OR UPDATE OF tags ON demo.demos
+	FOR EACH ROW EXECUTE FUNCTION tag.normalize_tags();
+CREATE TRIGGER asset_normalize_tags
+	BEFORE INSERT OR UPDATE OF tags ON asset.assets
+	FOR EACH ROW EXECUTE FUNCTION tag.normalize_tags();
+CREATE TRIGGER thread_normalize_tags
+	BEFORE INSERT OR UPDATE OF tags ON forum.threads
+	FOR EACH ROW EXECUTE FUNCTION tag.normalize_tags();
+CREATE TRIGGER message_normalize_tags
+	BEFORE INSERT OR UPDATE OF tags ON forum.messages
+	FOR EACH ROW EXECUTE FUNCTION tag.normalize_tags();
+CREATE TRIGGER subscription_normalize_tag
+	BEFORE INSERT OR UPDATE OF tag ON subscription.subscriptions
+	FOR EACH ROW EXECUTE FUNCTION tag.normalize_subscription_tag();
+
+CREATE TRIGGER demo_count_tag_usage
+	AFTER INSERT OR DELETE OR UPDATE OF tags, deleted_at ON demo.demos
+	FOR EACH ROW EXECUTE FUNCTION tag.count_usage('demo');
+CREATE TRIGGER asset_count_tag_usage
+	AFTER INSERT OR DELETE OR UPDATE OF tags, deleted_at ON asset.assets
+	FOR EACH ROW EXECUTE FUNCTION tag.count_usage('asset');
+CREATE TRIGGER thread_count_tag_usage
+	AFTER INSERT OR DELETE OR UPDATE OF tags, deleted_at ON forum.threads
+	FOR EACH ROW EXECUTE FUNCTION tag.count_usage('thread');
+CREATE TRIGGER message_count_tag_usage
+	AFTER INSERT OR DELETE OR UPDATE OF tags, deleted_at ON forum.messages
+	FOR EACH ROW EXECUTE FUNCTION tag.count_usage('message');
+
+---- create above / drop below ----
+
+-- Tags stay normalized
+DROP TRIGGER IF EXISTS message_count_tag_usage ON forum.messages;
+DROP TRIGGER IF EXISTS thread_count_tag_usage ON forum.threads;
+DROP TRIGGER IF EXISTS asset_count_tag_usage ON asset.assets;
+DROP TRIGGER IF EXISTS demo_count_tag_usage ON demo.demos;
+DROP TRIGGER IF EXISTS subscription_normalize_tag ON subscription.subscriptions;
+DROP TRIGGER IF EXISTS message_normalize_tags ON forum.messages;
+DROP TRIGGER IF EXISTS thread_normalize_tags ON forum.threads;
+DROP TRIGGER IF EXISTS asset_normalize_tags ON asset.assets;
+DROP TRIGGER IF EXISTS demo_normalize_tags ON demo.demos;
+DROP SCHEMA IF EXISTS tag CASCADE;
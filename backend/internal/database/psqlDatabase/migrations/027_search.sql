@@ -0,0 +1,27 @@
+-- User
+ALTER TABLE "user".users ADD COLUMN user_ts tsvector GENERATED ALWAYS AS (
+	setweight(to_tsvector_multilang("username"), 'A') ||
+	setweight(to_tsvector_multilang(COALESCE("display_name", '')), 'B')
+) STORED;
+CREATE INDEX user_gin_index_ts ON "user".users USING GIN (user_ts);
+
+-- Fragments of the text around the matches of the query, in the language the query matches in.
+-- The text is escaped, only the highlighting <b> tags are markup
+CREATE OR REPLACE FUNCTION ts_headline_multilang(TEXT, VARCHAR) RETURNS TEXT AS $$
+DECLARE
+	escaped TEXT := replace(replace(replace($1, '&', '&amp;'), '<', '&lt;'), '>', '&gt;');
+	options TEXT := 'MaxFragments=2, MaxWords=30, MinWords=10, FragmentDelimiter=" … "';
+BEGIN
+	IF to_tsvector('russian', $1) @@ websearch_to_tsquery('russian', $2)
+		AND NOT to_tsvector('english', $1) @@ websearch_to_tsquery('english', $2) THEN
+		RETURN ts_headline('russian', escaped, websearch_to_tsquery('russian', $2), options);
+	END IF;
+	RETURN ts_headline('english', escaped, websearch_to_tsquery('english', $2), options);
+END;
+$$ LANGUAGE plpgsql IMMUTABLE;
+
+---- create above / drop below ----
+
+DROP FUNCTION IF EXISTS ts_headline_multilang(TEXT, VARCHAR);
+DROP INDEX IF EXISTS "user".user_gin_index_ts;
+ALTER TABLE "user".users DROP COLUMN IF EXISTS user_ts;
@@ -0,0 +1,23 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Typo-tolerant matching of titles, names and usernames, see pg_trgm.word_similarity_threshold
+CREATE INDEX demo_title_trgm_index ON demo.demos USING GIN (title gin_trgm_ops);
+CREATE INDEX asset_name_trgm_index ON asset.assets USING GIN (name gin_trgm_ops);
+CREATE INDEX thread_title_trgm_index ON forum.threads USING GIN (title gin_trgm_ops);
+CREATE INDEX user_username_trgm_index ON "user".users USING GIN (username gin_trgm_ops);
+
+-- Matches words starting with the last word of the query, the one still being typed. NULL without words
+CREATE OR REPLACE FUNCTION to_tsquery_prefix(VARCHAR) RETURNS tsquery AS $$
+	SELECT to_tsquery('simple', quote_literal(word) || ':*')
+	FROM lower(substring($1 FROM '([[:alnum:]]+)[^[:alnum:]]*$')) AS word
+	WHERE word IS NOT NULL;
+$$ LANGUAGE sql IMMUTABLE;
+
+---- create above / drop below ----
+
+DROP FUNCTION IF EXISTS to_tsquery_prefix(VARCHAR);
+DROP INDEX IF EXISTS "user".user_username_trgm_index;
+DROP INDEX IF EXISTS forum.thread_title_trgm_index;
+DROP INDEX IF EXISTS asset.asset_name_trgm_index;
+DROP INDEX IF EXISTS demo.demo_title_trgm_index;
+DROP EXTENSION IF EXISTS pg_trgm;
@@ -0,0 +1,9 @@
+-- Changes recorded by the repositories are not requests and have no status
+ALTER TABLE audit.events ALTER COLUMN "status" DROP NOT NULL;
+
+---- create above / drop below ----
+
+ALTER TABLE audit.events DISABLE TRIGGER audit_events_append_only;
+DELETE FROM audit.events WHERE "status" IS NULL;
+ALTER TABLE audit.events ENABLE TRIGGER audit_events_append_only;
+ALTER TABLE audit.events ALTER COLUMN "status" SET NOT NULL;
@@ -4,6 +4,7 @@ import (
 	"context"
 	"embed"
 	"gamehangar/internal/config/psqlDatabseConfig"
+	"strconv"
 	"time"
 
 	"github.com/jackc/pgx/v5"
@@ -50,7 +51,15 @@ func (p PsqlDatabase) NewDatabaseClient(connstring string, migrator PsqlDatabase
 func (p PsqlDatabase) setup(c *PsqlDatabaseClient) error {
 	var err error
 
-	c.ConnPool, err = pgxpool.New(context.Background(), c.connstring)
+	poolConfig, err := pgxpool.ParseConfig(c.connstring)
+	if err != nil {
+		return err
+	}
+	// Every session matches misspelled titles as loosely as configured
+	poolConfig.ConnConfig.RuntimeParams["pg_trgm.word_similarity_threshold"] =
+		strconv.FormatFloat(c.config.SimilarityThreshold, 'f', -1, 64)
+
+	c.ConnPool, err = pgxpool.NewWithConfig(context.Background(), poolConfig)
 	if err != nil {
 		return err
 	}
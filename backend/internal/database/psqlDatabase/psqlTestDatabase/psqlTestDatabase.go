@@ -0,0 +1,56 @@
+package psqlTestDatabase
+
+import (
+	"context"
+	"errors"
+	"gamehangar/internal/config/psqlDatabseConfig"
+	"gamehangar/pkg/ternMigrate"
+	"io/fs"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Drops everything the migrations create and runs all of them again, so integration tests run
+// against the schema the API does. WARNING! Only ever give it a test database
+func Reset(conn *pgx.Conn, config *psqlDatabseConfig.PsqlDatabaseConfig) error {
+	migrations, ok := config.Migrations.(fs.FS)
+	if !ok {
+		return errors.New("Failed to reset PSQL Database: Invalid migration files!")
+	}
+	files, err := fs.Glob(migrations, config.MigrationsRoot+"/*.sql")
+	if err != nil {
+		return err
+	}
+
+	_, err = conn.Exec(context.Background(), `
+		DROP SCHEMA IF EXISTS "asset" CASCADE;
+		DROP SCHEMA IF EXISTS "demo" CASCADE;
+		DROP SCHEMA IF EXISTS "user" CASCADE;
+		DROP SCHEMA IF EXISTS "forum" CASCADE;
+		DROP SCHEMA IF EXISTS "audit" CASCADE;
+		DROP SCHEMA IF EXISTS "moderation" CASCADE;
+		DROP SCHEMA IF EXISTS "ratelimit" CASCADE;
+		DROP SCHEMA IF EXISTS "notification" CASCADE;
+		DROP SCHEMA IF EXISTS "subscription" CASCADE;
+		DROP SCHEMA IF EXISTS "live" CASCADE;
+		DROP SCHEMA IF EXISTS "tag" CASCADE;
+
+		DROP FUNCTION IF EXISTS increment_version() CASCADE;
+		DROP FUNCTION IF EXISTS update_asset_usage_count() CASCADE;
+		DROP FUNCTION IF EXISTS to_tsvector_multilang(VARCHAR) CASCADE;
+		DROP FUNCTION IF EXISTS to_tsquery_multilang(VARCHAR) CASCADE;
+		DROP FUNCTION IF EXISTS to_tsquery_prefix(VARCHAR) CASCADE;
+		DROP FUNCTION IF EXISTS ts_headline_multilang(TEXT, VARCHAR) CASCADE;
+		DROP COLLATION IF EXISTS case_insensitive;
+		`+`DROP TABLE IF EXISTS `+config.VersionTable,
+	)
+	if err != nil {
+		return err
+	}
+
+	migrator, err := ternMigrate.Migrator{}.NewMigrator(config.Migrations, config.MigrationsRoot, config.VersionTable)
+	if err != nil {
+		return err
+	}
+	return migrator.(*ternMigrate.Migrator).MigrateDatabase(conn, len(files))
+}
@@ -9,6 +9,7 @@ import (
 	_ "gamehangar/docs"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 )
 
@@ -86,6 +87,7 @@ func (h *AssetHandler) PostAsset(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, &e)
 	}
 	defer assetMultipartFile.Close()
+	asset.FileSize = &assetFormFile.Size
 
 	err = h.objectUploader.CheckFileSize(assetFormFile.Size, c.Get("userTier").(string))
 	if err != nil {
@@ -193,6 +195,11 @@ func (h *AssetHandler) GetAssetById(c echo.Context) error {
 		h.logger.Print(&e)
 		return c.JSON(http.StatusInternalServerError, &e)
 	}
+	if asset.HiddenAt != nil && !canViewHidden(c, nil) {
+		e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusNotFound, &e)
+	}
 
 	return c.JSON(http.StatusOK, &asset)
 }
@@ -200,40 +207,47 @@ func (h *AssetHandler) GetAssetById(c echo.Context) error {
 //	@Summary	Fetches all assets.
 //	@Tags		Assets
 //	@Produce	application/json
-//	@Param		q	query		[]string	false	"Keyword Query"
-//	@Param		l	query		int			false	"Record number limit"
-//	@Param		o	query		string		false	"Record ordering. Default newest updated"	Enums(newest-updated, highest-rated, most-views)
-//	@Success	200	{object}	models.Asset
-//	@Failure	400	{object}	HTTPError
-//	@Failure	404	{object}	HTTPError
-//	@Failure	500	{object}	HTTPError
+//	@Param		q				query		[]string	false	"Keyword Query"
+//	@Param		l				query		int			false	"Page size, at most 100. Default 20"
+//	@Param		c				query		string		false	"Page cursor from the next or prev of a previous page"
+//	@Param		o				query		string		false	"Record ordering. Default newest updated"	Enums(newest-updated, highest-rated, most-views, most-used)
+//	@Param		tag				query		[]string	false	"Tags the content has"
+//	@Param		tagMatch		query		string		false	"Whether content has all or any of the tags. Default all"	Enums(all, any)
+//	@Param		notTag			query		[]string	false	"Tags the content must not have"
+//	@Param		createdFrom		query		string		false	"Created at or after, RFC 3339"
+//	@Param		createdTo		query		string		false	"Created before, RFC 3339"
+//	@Param		updatedFrom		query		string		false	"Updated at or after, RFC 3339"
+//	@Param		updatedTo		query		string		false	"Updated before, RFC 3339"
+//	@Param		minRating		query		number		false	"Minimum rating"
+//	@Param		godotVersion	query		string		false	"Engine version the asset is compatible with, e.g. 4.2"
+//	@Param		minSize			query		int			false	"Minimum project file size in bytes"
+//	@Param		maxSize			query		int			false	"Maximum project file size in bytes"
+//	@Success	200				{object}	models.Page[models.Asset]
+//	@Failure	400				{object}	HTTPError
+//	@Failure	404				{object}	HTTPError
+//	@Failure	422				{object}	HTTPError
+//	@Failure	500				{object}	HTTPError
 //	@Router		/v1/assets [get]
 func (h *AssetHandler) GetAssets(c echo.Context) error {
 	var (
 		err    error
-		limit  uint64
 		order  string
-		assets *[]models.Asset
+		assets *models.Page[models.Asset]
 	)
 
-	l := c.Request().URL.Query()["l"]
-	if l != nil {
-		err = h.validator.Var(l[0], "omitnil,number,min=0")
-		if err != nil {
-			e := HTTPError{
-				Code:    http.StatusUnprocessableEntity,
-				Message: "Error in GetAssets repository: " + err.Error(),
-			}
-			h.logger.Print(&e)
-			return c.JSON(http.StatusUnprocessableEntity, &e)
-		}
-		limit, err = strconv.ParseUint(l[0], 10, 64)
+	page, ok, err := parsePageQuery(c, h.logger, h.validator, "GetAssets")
+	if !ok {
+		return err
+	}
+	filter, ok, err := parseListFilter(c, h.logger, h.validator, "GetAssets", assetFilters)
+	if !ok {
+		return err
 	}
 	tags := c.Request().URL.Query()["q"]
 
 	o := c.Request().URL.Query()["o"]
 	if o != nil {
-		err = h.validator.Var(o[0], `oneof=newest-updated highest-rated most-views`)
+		err = h.validator.Var(o[0], `oneof=newest-updated highest-rated most-views most-used`)
 		if err != nil {
 			e := HTTPError{
 				Code:    http.StatusUnprocessableEntity,
@@ -247,13 +261,18 @@ func (h *AssetHandler) GetAssets(c echo.Context) error {
 		order = "newest-updated"
 	}
 
-	assets, err = h.repository.FindAssets(tags, limit, order)
+	assets, err = h.repository.FindAssets(tags, filter, page, order)
 	if err != nil {
 		if err == h.repository.NotFoundErr() {
 			e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
 			h.logger.Print(&e)
 			return c.JSON(http.StatusNotFound, &e)
 		}
+		if err == h.repository.CursorErr() {
+			e := HTTPError{Code: http.StatusUnprocessableEntity, Message: err.Error()}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusUnprocessableEntity, &e)
+		}
 		e := HTTPError{
 			Code:    http.StatusInternalServerError,
 			Message: "Error in FindAssets repository: " + err.Error(),
@@ -318,6 +337,7 @@ func (h *AssetHandler) PatchAsset(c echo.Context) error {
 	}
 
 	var assetMultipartFile, thumbnailMultipartFile multipart.File
+	asset.FileSize = nil
 	assetFormFile, err := c.FormFile("assetFile")
 	if assetFormFile != nil {
 		assetMultipartFile, err = assetFormFile.Open()
@@ -330,6 +350,7 @@ func (h *AssetHandler) PatchAsset(c echo.Context) error {
 			return c.JSON(http.StatusBadRequest, &e)
 		}
 		defer assetMultipartFile.Close()
+		asset.FileSize = &assetFormFile.Size
 
 		err = h.objectUploader.CheckFileSize(assetFormFile.Size, c.Get("userTier").(string))
 		if err != nil {
@@ -416,6 +437,7 @@ func (h *AssetHandler) PatchAsset(c echo.Context) error {
 //	@Produce	text/plain
 //	@Param		id	path		string	true	"Delete Asset of ID"
 //	@Success	200	{string}	string
+//	@Failure	401	{object}	HTTPError
 //	@Failure	403	{object}	HTTPError
 //	@Failure	404	{object}	HTTPError
 //	@Failure	422	{object}	HTTPError
@@ -434,7 +456,14 @@ func (h *AssetHandler) DeleteAsset(c echo.Context) error {
 	}
 	id, _ := strconv.ParseInt(p, 10, 64)
 
-	err = h.repository.DeleteAsset(int(id))
+	userID, ok := c.Get("userID").(uuid.UUID)
+	if !ok {
+		e := HTTPError{Code: http.StatusUnauthorized, Message: "No session provided!"}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusUnauthorized, &e)
+	}
+
+	err = h.repository.DeleteAsset(int(id), userID)
 	if err != nil {
 		if err == h.repository.NotFoundErr() {
 			e := HTTPError{
@@ -454,3 +483,97 @@ func (h *AssetHandler) DeleteAsset(c echo.Context) error {
 
 	return c.String(http.StatusOK, "Asset sucessfully deleted!")
 }
+
+//	@Summary	Restores the specified asset from the trash.
+//	@Tags		Assets
+//	@Accept		text/plain
+//	@Produce	text/plain
+//	@Param		id	path		int	true	"Restore Asset of ID"
+//	@Success	200	{string}	string
+//	@Failure	401	{object}	HTTPError
+//	@Failure	403	{object}	HTTPError
+//	@Failure	404	{object}	HTTPError
+//	@Failure	422	{object}	HTTPError
+//	@Failure	500	{object}	HTTPError
+//	@Router		/v1/assets/{id}/restore [post]
+func (h *AssetHandler) RestoreAsset(c echo.Context) error {
+	p := c.Param("id")
+	err := h.validator.Var(p, "required,number")
+	if err != nil {
+		e := HTTPError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Error in RestoreAsset handler: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusUnprocessableEntity, &e)
+	}
+	id, _ := strconv.ParseInt(p, 10, 64)
+
+	userID, ok := c.Get("userID").(uuid.UUID)
+	if !ok {
+		e := HTTPError{Code: http.StatusUnauthorized, Message: "No session provided!"}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusUnauthorized, &e)
+	}
+
+	err = h.repository.RestoreAsset(int(id), restorer(c, userID))
+	if err != nil {
+		if err == h.repository.NotFoundErr() {
+			e := HTTPError{
+				Code:    http.StatusNotFound,
+				Message: "Not Found!",
+			}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusNotFound, &e)
+		}
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in RestoreAsset repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+
+	return c.String(http.StatusOK, "Asset successfully restored!")
+}
+
+//	@Summary	Fetches demos that use the asset of ID.
+//	@Tags		Assets
+//	@Accept		text/plain
+//	@Produce	application/json
+//	@Param		id	path		int	true	"Get usages of Asset of ID"
+//	@Success	200	{object}	[]models.Demo
+//	@Failure	404	{object}	HTTPError
+//	@Failure	422	{object}	HTTPError
+//	@Failure	500	{object}	HTTPError
+//	@Router		/v1/assets/{id}/used-in [get]
+func (h *AssetHandler) GetAssetUsages(c echo.Context) error {
+	p := c.Param("id")
+	err := h.validator.Var(p, "required,number")
+	if err != nil {
+		e := HTTPError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Error in GetAssetUsages handler: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusUnprocessableEntity, &e)
+	}
+	id, _ := strconv.ParseInt(p, 10, 64)
+
+	demos, err := h.repository.FindAssetUsages(int(id))
+	if err != nil {
+		if err == h.repository.NotFoundErr() {
+			e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusNotFound, &e)
+		}
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in FindAssetUsages repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+
+	return c.JSON(http.StatusOK, &demos)
+}
@@ -13,31 +13,36 @@ import (
 	"slices"
 	"testing"
 
-	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
 )
 
 type mockAssetRepo struct {
-	data        map[int]models.Asset
-	notFoundErr error
-	conflictErr error
+	data         map[int]models.Asset
+	deletedAsset map[int]models.Asset
+	notFoundErr  error
+	cursorErr    error
+	conflictErr  error
 }
 
 type mockObjectUploader struct{}
 
 var (
-	v  = validator.New(validator.WithRequiredStructEnabled())
+	v  = NewValidator()
 	ma = mockAssetRepo{
-		data:        make(map[int]models.Asset, 1),
-		notFoundErr: errors.New("Not Found"),
-		conflictErr: errors.New("Record conflict!"),
+		data:         make(map[int]models.Asset, 1),
+		deletedAsset: make(map[int]models.Asset, 1),
+		notFoundErr:  errors.New("Not Found"),
+		cursorErr:    errors.New("Cursor is not valid!"),
+		conflictErr:  errors.New("Record conflict!"),
 	}
 
 	mockFileUploader mockObjectUploader
 	mockURI          string = "https://example.com"
-	mockFileInfo     os.FileInfo
-	mockFileContents []byte
+	// The mock project file is this file
+	mockFileContents, mockFileInfo = readMockFile("./assetHandlers_test.go")
+	mockFileSize                   = fmt.Sprint(mockFileInfo.Size())
 
 	// notFoundResponse = `{"code":404,"message":"Not Found!"}` + "\n"
 	// conflictResponse = `{"code":409,"message":"Error: unable to update the record due to an edit conflict, please try again!"}` + "\n"
@@ -47,28 +52,30 @@ var (
 	// queryOrder                  = `newest-updated`
 
 	assetJSON                   = `{"name":"Cool asset","description":"A very nice asset to use in your game!"}`
-	assetJSONExpected           = `{"id":1,"name":"Cool asset","description":"A very nice asset to use in your game!","version":1,"key":"` + mockURI + `","thumbnailKey":"` + mockURI + `"}` + "\n"
-	assetJSONExpectedMany       = `[{"id":1,"name":"Cool asset","description":"A very nice asset to use in your game!","version":1,"key":"` + mockURI + `","thumbnailKey":"` + mockURI + `"}]` + "\n"
-	assetJSONQueryExpected      = `[{"id":1,"name":"cheeseboiger","tags":null,"key":"` + mockURI + `","thumbnailKey":"` + mockURI + `"},{"id":2,"name":"asset two","tags":["cheeseboiger"],"key":null,"thumbnailKey":null}]` + "\n"
-	assetJSONQueryExpectedLimit = `[{"id":1,"name":"cheeseboiger","tags":null,"key":"` + mockURI + `","thumbnailKey":"` + mockURI + `"}]` + "\n"
+	assetJSONExpected           = `{"id":1,"name":"Cool asset","description":"A very nice asset to use in your game!","version":1,"key":"` + mockURI + `","thumbnailKey":"` + mockURI + `","fileSize":` + mockFileSize + `}` + "\n"
+	assetJSONExpectedMany       = `{"items":[{"id":1,"name":"Cool asset","description":"A very nice asset to use in your game!","version":1,"key":"` + mockURI + `","thumbnailKey":"` + mockURI + `","fileSize":` + mockFileSize + `}],"total":1}` + "\n"
+	assetJSONQueryExpected      = `{"items":[{"id":1,"name":"cheeseboiger","tags":null,"key":"` + mockURI + `","thumbnailKey":"` + mockURI + `"},{"id":2,"name":"asset two","tags":["cheeseboiger"],"key":null,"thumbnailKey":null}],"total":2}` + "\n"
+	assetJSONQueryExpectedLimit = `{"items":[{"id":1,"name":"cheeseboiger","tags":null,"key":"` + mockURI + `","thumbnailKey":"` + mockURI + `"}],"total":2}` + "\n"
+	assetJSONUsagesExpected     = `[{"id":1,"title":"cheeseboiger","key":null,"thumbnailKey":null}]` + "\n"
 	assetJSONUpdate             = `{"name":"Updated cool asset","version":1}`
-	assetJSONUpdateExpected     = `{"id":1,"name":"Updated cool asset","description":"A very nice asset to use in your game!","version":2,"key":"` + mockURI + `","thumbnailKey":"` + mockURI + `"}` + "\n"
+	assetJSONUpdateExpected     = `{"id":1,"name":"Updated cool asset","description":"A very nice asset to use in your game!","version":2,"key":"` + mockURI + `","thumbnailKey":"` + mockURI + `","fileSize":` + mockFileSize + `}` + "\n"
 )
 
-func init() {
-	file, err := os.Open("./assetHandlers_test.go")
+func readMockFile(name string) ([]byte, os.FileInfo) {
+	file, err := os.Open(name)
 	if err != nil {
 		panic(err)
 	}
-	mockFileContents, err = io.ReadAll(file)
+	defer file.Close()
+	contents, err := io.ReadAll(file)
 	if err != nil {
 		panic(err)
 	}
-	mockFileInfo, err = file.Stat()
+	info, err := file.Stat()
 	if err != nil {
 		panic(err)
 	}
-	file.Close()
+	return contents, info
 }
 
 func (r *mockAssetRepo) CreateAsset(asset models.Asset, assetFile, assetThumbnail io.Reader) (*models.Asset, error) {
@@ -87,7 +94,7 @@ func (r *mockAssetRepo) FindAssetByID(id int) (*models.Asset, error) {
 	}
 	return &a, nil
 }
-func (r *mockAssetRepo) FindAssets(query []string, limit uint64, order string) (*[]models.Asset, error) {
+func (r *mockAssetRepo) FindAssets(query []string, filter models.ListFilter, page models.PageQuery, order string) (*models.Page[models.Asset], error) {
 	var (
 		assetIDs    []int          = []int{1, 2, 3}
 		assetTitles []string       = []string{"cheeseboiger", "asset two", "asset three"}
@@ -114,10 +121,11 @@ func (r *mockAssetRepo) FindAssets(query []string, limit uint64, order string) (
 			resultAssets = append(resultAssets, v)
 		}
 	}
-	if limit != 0 {
-		resultAssets = resultAssets[:limit]
+	total := int64(len(resultAssets))
+	if page.Limit != 0 && int(page.Limit) < len(resultAssets) {
+		resultAssets = resultAssets[:page.Limit]
 	}
-	return &resultAssets, nil
+	return &models.Page[models.Asset]{Items: resultAssets, Total: total}, nil
 }
 func (r *mockAssetRepo) UpdateAsset(id int, asset models.Asset, assetFile, assetThumbnail io.Reader) (*models.Asset, error) {
 	var a models.Asset
@@ -138,15 +146,37 @@ func (r *mockAssetRepo) UpdateAsset(id int, asset models.Asset, assetFile, asset
 	a = r.data[id]
 	return &a, nil
 }
-func (r *mockAssetRepo) DeleteAsset(id int) error {
-	_, ok := r.data[id]
+func (r *mockAssetRepo) DeleteAsset(id int, deletedBy uuid.UUID) error {
+	x, ok := r.data[id]
 	if !ok {
 		return r.NotFoundErr()
 	}
+	r.deletedAsset[id] = x
 	delete(r.data, id)
 	return nil
 }
+func (r *mockAssetRepo) RestoreAsset(id int, restoredBy *uuid.UUID) error {
+	x, ok := r.deletedAsset[id]
+	if !ok {
+		return r.NotFoundErr()
+	}
+	r.data[id] = x
+	delete(r.deletedAsset, id)
+	return nil
+}
+func (r *mockAssetRepo) FindAssetUsages(assetID int) (*[]models.Demo, error) {
+	var (
+		demoID    int    = 1
+		demoTitle string = "cheeseboiger"
+	)
+	_, ok := r.data[assetID]
+	if !ok {
+		return nil, r.NotFoundErr()
+	}
+	return &[]models.Demo{{ID: &demoID, Title: &demoTitle}}, nil
+}
 func (r *mockAssetRepo) NotFoundErr() error { return r.notFoundErr }
+func (r *mockAssetRepo) CursorErr() error   { return r.cursorErr }
 func (r *mockAssetRepo) ConflictErr() error { return r.conflictErr }
 
 func (u *mockObjectUploader) CheckFileSize(size int64, userTier string) error { return nil }
@@ -298,6 +328,33 @@ func TestGetAssetsQueryLimitOrder(t *testing.T) {
 	}
 }
 
+func TestPatchAssetGodotVersion(t *testing.T) {
+	for _, version := range []string{"4.x", "4.2-beta", "4"} {
+		// Setup
+		e := echo.New()
+		bodyBuffer := new(bytes.Buffer)
+		mw := multipart.NewWriter(bodyBuffer)
+		mw.WriteField("Version", "1")
+		mw.WriteField("godotVersion", version)
+		mw.Close()
+
+		req := httptest.NewRequest(http.MethodPatch, "/game-hangar/v1/assets", bodyBuffer)
+		req.Header.Set(echo.HeaderContentType, mw.FormDataContentType())
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/:id")
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+		c.Set("userTier", "freetier")
+		h := &AssetHandler{logger: e.Logger, validator: v, repository: &ma, objectUploader: &mockFileUploader}
+
+		// Assertions: stored versions must compare as numbers
+		if assert.NoError(t, h.PatchAsset(c)) {
+			assert.Equal(t, http.StatusUnprocessableEntity, rec.Code, version)
+		}
+	}
+}
+
 func TestPatchAssetNotFound(t *testing.T) {
 	// Setup
 	e := echo.New()
@@ -369,6 +426,7 @@ func TestDeleteAsset(t *testing.T) {
 	c.SetPath("/:id")
 	c.SetParamNames("id")
 	c.SetParamValues("1")
+	c.Set("userID", genericUUID)
 	h := &AssetHandler{logger: e.Logger, validator: v, repository: &ma}
 
 	// Assertions
@@ -386,6 +444,7 @@ func TestDeleteAssetNotFound(t *testing.T) {
 	c.SetPath("/:id")
 	c.SetParamNames("id")
 	c.SetParamValues("5")
+	c.Set("userID", genericUUID)
 	h := &AssetHandler{logger: e.Logger, validator: v, repository: &ma}
 
 	// Assertions
@@ -394,3 +453,61 @@ func TestDeleteAssetNotFound(t *testing.T) {
 		assert.Equal(t, notFoundResponse, rec.Body.String())
 	}
 }
+
+func TestGetAssetUsages(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/game-hangar/v1/assets", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/:id/used-in")
+	c.SetParamNames("id")
+	c.SetParamValues("1")
+	h := &AssetHandler{logger: e.Logger, validator: v, repository: &ma}
+
+	// Assertions
+	if assert.NoError(t, h.GetAssetUsages(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, assetJSONUsagesExpected, rec.Body.String())
+	}
+}
+
+func TestGetAssetUsagesNotFound(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/game-hangar/v1/assets", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/:id/used-in")
+	c.SetParamNames("id")
+	c.SetParamValues("9")
+	h := &AssetHandler{logger: e.Logger, validator: v, repository: &ma}
+
+	// Assertions
+	if assert.NoError(t, h.GetAssetUsages(c)) {
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Equal(t, notFoundResponse, rec.Body.String())
+	}
+}
+
+func TestRestoreAsset(t *testing.T) {
+	// Setup
+	deletedID := 7
+	ma.deletedAsset[deletedID] = models.Asset{ID: &deletedID}
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/game-hangar/v1/", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/:id/restore")
+	c.SetParamNames("id")
+	c.SetParamValues("7")
+	c.Set("userID", genericUUID)
+	h := &AssetHandler{logger: e.Logger, validator: v, repository: &ma}
+
+	// Assertions
+	if assert.NoError(t, h.RestoreAsset(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.NotContains(t, ma.deletedAsset, deletedID)
+	}
+	delete(ma.data, deletedID)
+}
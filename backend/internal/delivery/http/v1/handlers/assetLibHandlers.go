@@ -0,0 +1,349 @@
+package handlers
+
+import (
+	"errors"
+	"gamehangar/internal/domain/models"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	assetLibDefaultPageLength = 10
+	assetLibMaxPageLength     = 500
+)
+
+// Assets are published by the site, which the editor shows as their author
+const assetLibAuthor = "Game Hangar"
+
+var godotVersionRegexp = regexp.MustCompile(`^[0-9]+\.[0-9]+(\.[0-9]+)?$`)
+
+// Serves asset.assets in the format of the Godot Asset Library API,
+// so the editor's AssetLib tab can browse and install our assets
+type AssetLibHandler struct {
+	logger     echo.Logger
+	repository AssetLibRepository
+	validator  *validator.Validate
+}
+
+func NewAssetLibHandler(e *echo.Echo, repo AssetLibRepository, v *validator.Validate) *AssetLibHandler {
+	return &AssetLibHandler{
+		logger:     e.Logger,
+		repository: repo,
+		validator:  v,
+	}
+}
+
+//	@Summary	Returns the AssetLib configuration with the list of categories.
+//	@Tags		AssetLib
+//	@Produce	application/json
+//	@Param		type	query		string	false	"Category type. Default any"	Enums(any, addon, project)
+//	@Success	200		{object}	models.AssetLibConfiguration
+//	@Failure	422		{object}	HTTPError
+//	@Failure	500		{object}	HTTPError
+//	@Router		/v1/asset-library/configure [get]
+func (h *AssetLibHandler) GetConfiguration(c echo.Context) error {
+	assetType := c.QueryParam("type")
+	err := h.validator.Var(assetType, "omitempty,oneof=any addon project")
+	if err != nil {
+		return h.unprocessable(c, "GetConfiguration", err)
+	}
+
+	categories, err := h.repository.FindAssetCategories()
+	if err != nil && err != h.repository.NotFoundErr() {
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in FindAssetCategories repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+
+	config := models.AssetLibConfiguration{Categories: []models.AssetLibCategory{}}
+	if categories != nil {
+		for _, cat := range *categories {
+			if (assetType == "addon" && *cat.Type != 0) || (assetType == "project" && *cat.Type != 1) {
+				continue
+			}
+			config.Categories = append(config.Categories, models.AssetLibCategory{
+				ID:   strconv.Itoa(*cat.ID),
+				Name: *cat.Name,
+				Type: strconv.Itoa(*cat.Type),
+			})
+		}
+	}
+
+	return c.JSON(http.StatusOK, &config)
+}
+
+//	@Summary	Fetches a page of assets in the AssetLib format.
+//	@Tags		AssetLib
+//	@Produce	application/json
+//	@Param		type			query		string	false	"Asset type. Default any"	Enums(any, addon, project)
+//	@Param		category		query		int		false	"Category ID"
+//	@Param		support			query		string	false	"Support levels separated by +"
+//	@Param		filter			query		string	false	"Text filter on asset name"
+//	@Param		godot_version	query		string	false	"Engine version, e.g. 4.2"
+//	@Param		max_results		query		int		false	"Page length. Default 10"
+//	@Param		page			query		int		false	"Page number, starting from 0"
+//	@Param		offset			query		int		false	"Record offset, overrides page"
+//	@Param		sort			query		string	false	"Record ordering. Default updated"	Enums(rating, cost, name, updated)
+//	@Param		reverse			query		bool	false	"Reverse the ordering"
+//	@Success	200				{object}	models.AssetLibPage
+//	@Failure	422				{object}	HTTPError
+//	@Failure	500				{object}	HTTPError
+//	@Router		/v1/asset-library/asset [get]
+func (h *AssetLibHandler) GetAssets(c echo.Context) error {
+	var (
+		err  error
+		page uint64
+		q    = models.AssetLibQuery{Limit: assetLibDefaultPageLength}
+	)
+
+	q.Type = c.QueryParam("type")
+	err = h.validator.Var(q.Type, "omitempty,oneof=any addon project")
+	if err != nil {
+		return h.unprocessable(c, "GetAssets", err)
+	}
+
+	if p := c.QueryParam("category"); p != "" {
+		err = h.validator.Var(p, "number,gt=0")
+		if err != nil {
+			return h.unprocessable(c, "GetAssets", err)
+		}
+		q.CategoryID, _ = strconv.Atoi(p)
+	}
+
+	// The editor joins levels with "+", which may already be decoded as spaces
+	if p := c.QueryParam("support"); p != "" {
+		q.SupportLevel = strings.FieldsFunc(p, func(r rune) bool { return r == '+' || r == ' ' })
+		err = h.validator.Var(q.SupportLevel, "dive,oneof=official community testing")
+		if err != nil {
+			return h.unprocessable(c, "GetAssets", err)
+		}
+	}
+
+	q.Filter = c.QueryParam("filter")
+	err = h.validator.Var(q.Filter, "max=255")
+	if err != nil {
+		return h.unprocessable(c, "GetAssets", err)
+	}
+
+	if p := c.QueryParam("godot_version"); p != "" && p != "any" {
+		if !godotVersionRegexp.MatchString(p) {
+			return h.unprocessable(c, "GetAssets", errors.New("invalid godot_version "+p))
+		}
+		q.GodotVersion = p
+	}
+
+	if p := c.QueryParam("max_results"); p != "" {
+		err = h.validator.Var(p, "number,gt=0")
+		if err != nil {
+			return h.unprocessable(c, "GetAssets", err)
+		}
+		q.Limit, _ = strconv.ParseUint(p, 10, 64)
+		q.Limit = min(q.Limit, assetLibMaxPageLength)
+	}
+	if p := c.QueryParam("page"); p != "" {
+		err = h.validator.Var(p, "number,min=0")
+		if err != nil {
+			return h.unprocessable(c, "GetAssets", err)
+		}
+		page, _ = strconv.ParseUint(p, 10, 64)
+		q.Offset = page * q.Limit
+	}
+	if p := c.QueryParam("offset"); p != "" {
+		err = h.validator.Var(p, "number,min=0")
+		if err != nil {
+			return h.unprocessable(c, "GetAssets", err)
+		}
+		q.Offset, _ = strconv.ParseUint(p, 10, 64)
+		page = q.Offset / q.Limit
+	}
+
+	q.Sort = c.QueryParam("sort")
+	err = h.validator.Var(q.Sort, "omitempty,oneof=rating cost name updated")
+	if err != nil {
+		return h.unprocessable(c, "GetAssets", err)
+	}
+	_, q.Reverse = c.QueryParams()["reverse"]
+
+	result := models.AssetLibPage{Result: []models.AssetLibSummary{}, Page: page, PageLength: q.Limit}
+
+	assets, total, err := h.repository.FindAssetLibAssets(q)
+	if err != nil {
+		if err == h.repository.NotFoundErr() {
+			// The editor expects an empty page rather than an error
+			return c.JSON(http.StatusOK, &result)
+		}
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in FindAssetLibAssets repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+
+	categories, err := h.findCategories()
+	if err != nil {
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in FindAssetCategories repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+
+	for _, a := range *assets {
+		result.Result = append(result.Result, toAssetLibSummary(a, categories))
+	}
+	result.TotalItems = total
+	result.Pages = (total + q.Limit - 1) / q.Limit
+
+	return c.JSON(http.StatusOK, &result)
+}
+
+//	@Summary	Fetches an asset by its ID in the AssetLib format.
+//	@Tags		AssetLib
+//	@Accept		text/plain
+//	@Produce	application/json
+//	@Param		id	path		int	true	"Get Asset of ID"
+//	@Success	200	{object}	models.AssetLibAsset
+//	@Failure	404	{object}	HTTPError
+//	@Failure	422	{object}	HTTPError
+//	@Failure	500	{object}	HTTPError
+//	@Router		/v1/asset-library/asset/{id} [get]
+func (h *AssetLibHandler) GetAssetByID(c echo.Context) error {
+	p := c.Param("id")
+	err := h.validator.Var(p, "required,number")
+	if err != nil {
+		return h.unprocessable(c, "GetAssetByID", err)
+	}
+	id, _ := strconv.ParseInt(p, 10, 64)
+
+	// Editors fetch the asset to install it, which is not a view of its page
+	asset, err := h.repository.PeekAssetByID(int(id))
+	if err != nil {
+		if err == h.repository.NotFoundErr() {
+			e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusNotFound, &e)
+		}
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in PeekAssetByID repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	if asset.HiddenAt != nil && !canViewHidden(c, nil) {
+		e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusNotFound, &e)
+	}
+
+	categories, err := h.findCategories()
+	if err != nil {
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in FindAssetCategories repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+
+	result := models.AssetLibAsset{
+		AssetLibSummary:  toAssetLibSummary(*asset, categories),
+		Type:             "addon",
+		DownloadProvider: "Custom",
+		Searchable:       "1",
+		Previews:         []string{},
+	}
+	if asset.CategoryID != nil {
+		if cat, ok := categories[*asset.CategoryID]; ok && *cat.Type == 1 {
+			result.Type = "project"
+		}
+	}
+	if asset.Description != nil {
+		result.Description = *asset.Description
+	}
+	if asset.Key != nil {
+		result.DownloadURL = *asset.Key // Presigned link to our storage
+	}
+	if asset.DownloadHash != nil {
+		result.DownloadHash = *asset.DownloadHash
+	}
+
+	return c.JSON(http.StatusOK, &result)
+}
+
+func (h *AssetLibHandler) unprocessable(c echo.Context, handler string, err error) error {
+	e := HTTPError{
+		Code:    http.StatusUnprocessableEntity,
+		Message: "Error in " + handler + " handler: " + err.Error(),
+	}
+	h.logger.Print(&e)
+	return c.JSON(http.StatusUnprocessableEntity, &e)
+}
+
+// Returns categories by ID. A missing category table is not an error
+func (h *AssetLibHandler) findCategories() (map[int]models.AssetCategory, error) {
+	categories := make(map[int]models.AssetCategory)
+	cats, err := h.repository.FindAssetCategories()
+	if err != nil {
+		if err == h.repository.NotFoundErr() {
+			return categories, nil
+		}
+		return nil, err
+	}
+	for _, cat := range *cats {
+		categories[*cat.ID] = cat
+	}
+	return categories, nil
+}
+
+func toAssetLibSummary(a models.Asset, categories map[int]models.AssetCategory) models.AssetLibSummary {
+	s := models.AssetLibSummary{
+		AssetID:      strconv.Itoa(*a.ID),
+		Author:       assetLibAuthor,
+		SupportLevel: "community",
+		Cost:         "MIT",
+		Rating:       "0",
+	}
+	if a.Name != nil {
+		s.Title = *a.Name
+	}
+	if a.CategoryID != nil {
+		s.CategoryID = strconv.Itoa(*a.CategoryID)
+		if cat, ok := categories[*a.CategoryID]; ok {
+			s.Category = *cat.Name
+		}
+	}
+	if a.GodotVersion != nil {
+		s.GodotVersion = *a.GodotVersion
+	}
+	if a.Rating != nil {
+		s.Rating = strconv.FormatFloat(*a.Rating, 'f', -1, 64)
+	}
+	if a.License != nil {
+		s.Cost = *a.License
+	}
+	if a.SupportLevel != nil {
+		s.SupportLevel = *a.SupportLevel
+	}
+	if a.ThumbnailKey != nil {
+		s.IconURL = *a.ThumbnailKey
+	}
+	if a.Version != nil {
+		s.Version = strconv.Itoa(*a.Version)
+		s.VersionString = s.Version
+	}
+	if a.UpdatedAt != nil {
+		s.ModifyDate = *a.UpdatedAt
+	}
+	return s
+}
@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"errors"
+	"gamehangar/internal/domain/models"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockAssetLibRepo struct {
+	categories  []models.AssetCategory
+	assets      []models.Asset
+	notFoundErr error
+}
+
+var (
+	libCategoryIDs   = []int{1, 8}
+	libCategoryNames = []string{"2D Tools", "Templates"}
+	libCategoryTypes = []int{0, 1}
+	libAssetIDs      = []int{1, 2}
+	libAssetNames    = []string{"Cool addon", "Cool template"}
+	libAssetVersions = []string{"4.2", "3.5"}
+	libAssetHash     = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	libAssetSupport  = "community"
+	libAssetLicense  = "MIT"
+	libAssetRating   = 1.0
+	libAssetVersion  = 1
+	libAssetUpdated  = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	mal = mockAssetLibRepo{
+		categories: []models.AssetCategory{
+			{ID: &libCategoryIDs[0], Name: &libCategoryNames[0], Type: &libCategoryTypes[0]},
+			{ID: &libCategoryIDs[1], Name: &libCategoryNames[1], Type: &libCategoryTypes[1]},
+		},
+		assets: []models.Asset{
+			{
+				ID: &libAssetIDs[0], Name: &libAssetNames[0], CategoryID: &libCategoryIDs[0], GodotVersion: &libAssetVersions[0],
+				SupportLevel: &libAssetSupport, License: &libAssetLicense, Rating: &libAssetRating, Version: &libAssetVersion,
+				UpdatedAt: &libAssetUpdated, Key: &mockURI, ThumbnailKey: &mockURI, DownloadHash: &libAssetHash,
+			},
+			{
+				ID: &libAssetIDs[1], Name: &libAssetNames[1], CategoryID: &libCategoryIDs[1], GodotVersion: &libAssetVersions[1],
+				SupportLevel: &libAssetSupport, License: &libAssetLicense, Rating: &libAssetRating, Version: &libAssetVersion,
+				UpdatedAt: &libAssetUpdated, Key: &mockURI, ThumbnailKey: &mockURI,
+			},
+		},
+		notFoundErr: errors.New("Not Found"),
+	}
+
+	assetLibConfigureJSONExpected = `{"categories":[{"id":"8","name":"Templates","type":"1"}]}` + "\n"
+	assetLibPageJSONExpected      = `{"result":[{"asset_id":"1","title":"Cool addon","author":"Game Hangar","author_id":"","category":"2D Tools","category_id":"1","godot_version":"4.2","rating":"1","cost":"MIT","support_level":"community","icon_url":"` + mockURI + `","version":"1","version_string":"1","modify_date":"2025-01-01T00:00:00Z"}],"page":0,"pages":1,"page_length":10,"total_items":1}` + "\n"
+	assetLibEmptyPageJSONExpected = `{"result":[],"page":2,"pages":1,"page_length":10,"total_items":2}` + "\n"
+	assetLibAssetJSONExpected     = `{"asset_id":"1","title":"Cool addon","author":"Game Hangar","author_id":"","category":"2D Tools","category_id":"1","godot_version":"4.2","rating":"1","cost":"MIT","support_level":"community","icon_url":"` + mockURI + `","version":"1","version_string":"1","modify_date":"2025-01-01T00:00:00Z","type":"addon","description":"","download_provider":"Custom","download_commit":"","download_hash":"` + libAssetHash + `","download_url":"` + mockURI + `","browse_url":"","issues_url":"","searchable":"1","previews":[]}` + "\n"
+)
+
+func (r *mockAssetLibRepo) FindAssetCategories() (*[]models.AssetCategory, error) {
+	return &r.categories, nil
+}
+func (r *mockAssetLibRepo) FindAssetLibAssets(q models.AssetLibQuery) (*[]models.Asset, uint64, error) {
+	var result []models.Asset
+	for _, a := range r.assets {
+		if q.CategoryID != 0 && *a.CategoryID != q.CategoryID {
+			continue
+		}
+		if len(q.SupportLevel) != 0 && !slices.Contains(q.SupportLevel, *a.SupportLevel) {
+			continue
+		}
+		if q.GodotVersion != "" && (*a.GodotVersion)[0] != q.GodotVersion[0] {
+			continue
+		}
+		result = append(result, a)
+	}
+	total := uint64(len(result))
+	if total == 0 {
+		return nil, 0, r.NotFoundErr()
+	}
+	result = result[min(q.Offset, total):min(q.Offset+q.Limit, total)]
+	return &result, total, nil
+}
+func (r *mockAssetLibRepo) PeekAssetByID(id int) (*models.Asset, error) {
+	for _, a := range r.assets {
+		if *a.ID == id {
+			return &a, nil
+		}
+	}
+	return nil, r.NotFoundErr()
+}
+func (r *mockAssetLibRepo) NotFoundErr() error { return r.notFoundErr }
+
+func TestGetAssetLibConfiguration(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/game-hangar/v1/asset-library/configure?type=project", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	h := &AssetLibHandler{logger: e.Logger, validator: v, repository: &mal}
+
+	// Assertions
+	if assert.NoError(t, h.GetConfiguration(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, assetLibConfigureJSONExpected, rec.Body.String())
+	}
+}
+
+func TestGetAssetLibAssets(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet,
+		"/game-hangar/v1/asset-library/asset?godot_version=4.3&support=official+community&sort=updated", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	h := &AssetLibHandler{logger: e.Logger, validator: v, repository: &mal}
+
+	// Assertions
+	if assert.NoError(t, h.GetAssets(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, assetLibPageJSONExpected, rec.Body.String())
+	}
+}
+
+func TestGetAssetLibAssetsEmptyPage(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/game-hangar/v1/asset-library/asset?page=2", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	h := &AssetLibHandler{logger: e.Logger, validator: v, repository: &mal}
+
+	// Assertions
+	if assert.NoError(t, h.GetAssets(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, assetLibEmptyPageJSONExpected, rec.Body.String())
+	}
+}
+
+func TestGetAssetLibAssetsInvalidVersion(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/game-hangar/v1/asset-library/asset?godot_version=four", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	h := &AssetLibHandler{logger: e.Logger, validator: v, repository: &mal}
+
+	// Assertions
+	if assert.NoError(t, h.GetAssets(c)) {
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	}
+}
+
+func TestGetAssetLibAssetByID(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/game-hangar/v1/asset-library/asset", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/:id")
+	c.SetParamNames("id")
+	c.SetParamValues("1")
+	h := &AssetLibHandler{logger: e.Logger, validator: v, repository: &mal}
+
+	// Assertions
+	if assert.NoError(t, h.GetAssetByID(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, assetLibAssetJSONExpected, rec.Body.String())
+	}
+}
+
+func TestGetAssetLibAssetByIDNotFound(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/game-hangar/v1/asset-library/asset", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/:id")
+	c.SetParamNames("id")
+	c.SetParamValues("42")
+	h := &AssetLibHandler{logger: e.Logger, validator: v, repository: &mal}
+
+	// Assertions
+	if assert.NoError(t, h.GetAssetByID(c)) {
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Equal(t, notFoundResponse, rec.Body.String())
+	}
+}
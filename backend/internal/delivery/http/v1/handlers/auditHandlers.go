@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"gamehangar/internal/domain/models"
+	"net/http"
+	"time"
+
+	_ "gamehangar/docs"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type AuditHandler struct {
+	logger     echo.Logger
+	repository AuditRepository
+	validator  *validator.Validate
+}
+
+func NewAuditHandler(e *echo.Echo, repo AuditRepository, v *validator.Validate) *AuditHandler {
+	return &AuditHandler{
+		logger:     e.Logger,
+		repository: repo,
+		validator:  v,
+	}
+}
+
+//	@Summary	Fetches the audit log, newest first. Admin only.
+//	@Tags		Audit
+//	@Produce	application/json
+//	@Security	ApiSessionCookie
+//	@param		sessionID	header		string	false	"Session ID"
+//	@Param		actor		query		string	false	"Actor user ID"
+//	@Param		targetType	query		string	false	"Target type, e.g. demos, threads, users, sessions, roles"
+//	@Param		from		query		string	false	"Start of time range, RFC 3339"
+//	@Param		to			query		string	false	"End of time range (exclusive), RFC 3339"
+//	@Param		l			query		int		false	"Page size, at most 100. Default 20"
+//	@Param		c			query		string	false	"Page cursor from the next or prev of a previous page"
+//	@Success	200			{object}	models.Page[models.AuditEvent]
+//	@Failure	403			{object}	HTTPError
+//	@Failure	404			{object}	HTTPError
+//	@Failure	422			{object}	HTTPError
+//	@Failure	500			{object}	HTTPError
+//	@Router		/v1/audit [get]
+func (h *AuditHandler) GetAuditEvents(c echo.Context) error {
+	var filter models.AuditFilter
+
+	if p := c.QueryParam("actor"); p != "" {
+		err := h.validator.Var(p, "uuid")
+		if err != nil {
+			return h.unprocessable(c, err)
+		}
+		actorID, _ := uuid.Parse(p)
+		filter.ActorID = &actorID
+	}
+	if p := c.QueryParam("targetType"); p != "" {
+		err := h.validator.Var(p, "max=64")
+		if err != nil {
+			return h.unprocessable(c, err)
+		}
+		filter.TargetType = &p
+	}
+	if p := c.QueryParam("from"); p != "" {
+		from, err := time.Parse(time.RFC3339, p)
+		if err != nil {
+			return h.unprocessable(c, err)
+		}
+		filter.From = &from
+	}
+	if p := c.QueryParam("to"); p != "" {
+		to, err := time.Parse(time.RFC3339, p)
+		if err != nil {
+			return h.unprocessable(c, err)
+		}
+		filter.To = &to
+	}
+	page, ok, err := parsePageQuery(c, h.logger, h.validator, "GetAuditEvents")
+	if !ok {
+		return err
+	}
+
+	events, err := h.repository.FindAuditEvents(filter, page)
+	if err != nil {
+		if err == h.repository.NotFoundErr() {
+			e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusNotFound, &e)
+		}
+		if err == h.repository.CursorErr() {
+			e := HTTPError{Code: http.StatusUnprocessableEntity, Message: err.Error()}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusUnprocessableEntity, &e)
+		}
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in FindAuditEvents repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+
+	return c.JSON(http.StatusOK, &events)
+}
+
+func (h *AuditHandler) unprocessable(c echo.Context, err error) error {
+	e := HTTPError{
+		Code:    http.StatusUnprocessableEntity,
+		Message: "Error in GetAuditEvents handler: " + err.Error(),
+	}
+	h.logger.Print(&e)
+	return c.JSON(http.StatusUnprocessableEntity, &e)
+}
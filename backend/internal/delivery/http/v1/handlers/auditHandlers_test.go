@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"errors"
+	"gamehangar/internal/domain/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockAuditRepo struct {
+	data        []models.AuditEvent
+	notFoundErr error
+	cursorErr   error
+}
+
+var (
+	auditEventID    int64 = 1
+	auditAction           = "DELETE threads/:id"
+	auditTargetType       = "threads"
+	auditTargetID         = "1"
+	auditIP               = "192.0.2.1"
+	auditStatus           = 200
+	auditAfter            = "Thread successfully deleted!"
+	auditCreatedAt        = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	mau = mockAuditRepo{
+		data: []models.AuditEvent{{
+			ID: &auditEventID, ActorID: &genericUUID, Action: &auditAction, TargetType: &auditTargetType,
+			TargetID: &auditTargetID, IP: &auditIP, Status: &auditStatus, After: &auditAfter, CreatedAt: &auditCreatedAt,
+		}},
+		notFoundErr: errors.New("Not Found"),
+		cursorErr:   errors.New("Cursor is not valid!"),
+	}
+
+	auditJSONExpected = `{"items":[{"id":1,"actorID":"` + genericUUID.String() + `","action":"DELETE threads/:id","targetType":"threads",` +
+		`"targetID":"1","ip":"192.0.2.1","status":200,"before":null,"after":"Thread successfully deleted!",` +
+		`"createdAt":"2025-01-01T00:00:00Z"}],"total":1}` + "\n"
+)
+
+func (r *mockAuditRepo) FindAuditEvents(f models.AuditFilter, page models.PageQuery) (*models.Page[models.AuditEvent], error) {
+	var events []models.AuditEvent
+	// Every listing fits on the first page
+	if page.Cursor != "" {
+		return nil, r.CursorErr()
+	}
+	for _, e := range r.data {
+		if f.ActorID != nil && *f.ActorID != *e.ActorID {
+			continue
+		}
+		if f.TargetType != nil && *f.TargetType != *e.TargetType {
+			continue
+		}
+		if f.From != nil && e.CreatedAt.Before(*f.From) {
+			continue
+		}
+		if f.To != nil && !e.CreatedAt.Before(*f.To) {
+			continue
+		}
+		events = append(events, e)
+	}
+	if len(events) == 0 {
+		return nil, r.NotFoundErr()
+	}
+	return &models.Page[models.AuditEvent]{Items: events, Total: int64(len(events))}, nil
+}
+func (r *mockAuditRepo) NotFoundErr() error { return r.notFoundErr }
+func (r *mockAuditRepo) CursorErr() error   { return r.cursorErr }
+
+func TestGetAuditEvents(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet,
+		"/game-hangar/v1/audit?actor="+genericUUID.String()+"&targetType=threads&from=2024-12-31T00:00:00Z", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	h := NewAuditHandler(e, &mau, v)
+
+	// Assertions
+	if assert.NoError(t, h.GetAuditEvents(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, auditJSONExpected, rec.Body.String())
+	}
+}
+
+func TestGetAuditEventsNotFound(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/game-hangar/v1/audit?to=2024-12-31T00:00:00Z", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	h := NewAuditHandler(e, &mau, v)
+
+	// Assertions
+	if assert.NoError(t, h.GetAuditEvents(c)) {
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Equal(t, notFoundResponse, rec.Body.String())
+	}
+}
+
+func TestGetAuditEventsInvalidFilter(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/game-hangar/v1/audit?actor=nobody", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	h := NewAuditHandler(e, &mau, v)
+
+	// Assertions
+	if assert.NoError(t, h.GetAuditEvents(c)) {
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	}
+}
+
+func TestGetAuditEventsCursorUnprocessable(t *testing.T) {
+	// Setup
+	e := echo.New()
+	h := NewAuditHandler(e, &mau, v)
+	get := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/game-hangar/v1/audit?"+query, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		assert.NoError(t, h.GetAuditEvents(c))
+		return rec
+	}
+
+	// Assertions
+	rec := get("c=stale")
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Equal(t, `{"code":422,"message":"Cursor is not valid!"}`+"\n", rec.Body.String())
+	assert.Equal(t, http.StatusUnprocessableEntity, get("l=-1").Code)
+}
@@ -9,6 +9,7 @@ import (
 	_ "gamehangar/docs"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 )
 
@@ -18,20 +19,25 @@ type DemoHandler struct {
 	validator      *validator.Validate
 	objectUploader ObjectUploader
 	syncer         ThreadSyncer
+	contentFilter  ContentFilter
+	markdown       MarkdownRenderer
 }
 
 type ThreadSyncer interface {
 	PostThread(demo models.Demo) (*int, error)
 	PatchThread(demoID int, demo models.Demo) error
+	DropThread(threadID int, userID uuid.UUID) error
 }
 
-func NewDemoHandler(e *echo.Echo, repo DemoRepository, v *validator.Validate, s ThreadSyncer, o ObjectUploader) *DemoHandler {
+func NewDemoHandler(e *echo.Echo, repo DemoRepository, v *validator.Validate, s ThreadSyncer, o ObjectUploader, f ContentFilter, m MarkdownRenderer) *DemoHandler {
 	return &DemoHandler{
 		logger:         e.Logger,
 		repository:     repo,
 		validator:      v,
 		objectUploader: o,
 		syncer:         s,
+		contentFilter:  f,
+		markdown:       m,
 	}
 }
 
@@ -73,6 +79,15 @@ func (h *DemoHandler) PostDemo(c echo.Context) error {
 		return c.JSON(http.StatusUnprocessableEntity, &e)
 	}
 
+	subject := models.FilterSubject{Kind: "demo", AuthorID: demo.UserID, Text: filterText(demo.Title, demo.Description)}
+	verdict, err := checkContent(c, h.logger, h.contentFilter, subject)
+	if verdict == nil {
+		return err
+	}
+	if ok, err := renderMarkdown(c, h.logger, h.markdown, demo.Description, &demo.DescriptionHTML); !ok {
+		return err
+	}
+
 	demoFormFile, err := c.FormFile("demoFile")
 	if demoFormFile == nil {
 		e := HTTPError{
@@ -92,6 +107,7 @@ func (h *DemoHandler) PostDemo(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, &e)
 	}
 	defer demoMultipartFile.Close()
+	demo.FileSize = &demoFormFile.Size
 	err = h.objectUploader.CheckFileSize(demoFormFile.Size, c.Get("userTier").(string))
 	if err != nil {
 		if err == h.objectUploader.ObjectTooLargeErr() {
@@ -167,6 +183,9 @@ func (h *DemoHandler) PostDemo(c echo.Context) error {
 		h.logger.Print(&e)
 		return c.JSON(http.StatusInternalServerError, &e)
 	}
+	if hiddenAt := contentStored(h.logger, h.contentFilter, subject, *newDemo.ID, *verdict); hiddenAt != nil {
+		newDemo.HiddenAt = hiddenAt
+	}
 
 	return c.JSON(http.StatusCreated, &newDemo)
 }
@@ -209,6 +228,11 @@ func (h *DemoHandler) GetDemoById(c echo.Context) error {
 		h.logger.Print(&e)
 		return c.JSON(http.StatusInternalServerError, &e)
 	}
+	if demo.HiddenAt != nil && !canViewHidden(c, demo.UserID) {
+		e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusNotFound, &e)
+	}
 
 	return c.JSON(http.StatusOK, &demo)
 }
@@ -216,34 +240,41 @@ func (h *DemoHandler) GetDemoById(c echo.Context) error {
 // @Summary	Fetches all demos.
 // @Tags		Demos
 // @Produce	application/json
-// @Param		q	query		[]string	false	"Keyword Query"
-// @Param		l	query		int			false	"Record number limit"
-// @Param		o	query		string		false	"Record ordering. Default newest updated"	Enums(newest-updated, highest-rated, most-views)
-// @Success	200	{object}	models.Demo
-// @Failure	400	{object}	HTTPError
-// @Failure	404	{object}	HTTPError
-// @Failure	500	{object}	HTTPError
+// @Param		q			query		[]string	false	"Keyword Query"
+// @Param		l			query		int			false	"Page size, at most 100. Default 20"
+// @Param		c			query		string		false	"Page cursor from the next or prev of a previous page"
+// @Param		o			query		string		false	"Record ordering. Default newest updated"	Enums(newest-updated, highest-rated, most-views)
+// @Param		author		query		string		false	"Author user ID"
+// @Param		tag			query		[]string	false	"Tags the content has"
+// @Param		tagMatch	query		string		false	"Whether content has all or any of the tags. Default all"	Enums(all, any)
+// @Param		notTag		query		[]string	false	"Tags the content must not have"
+// @Param		createdFrom	query		string		false	"Created at or after, RFC 3339"
+// @Param		createdTo	query		string		false	"Created before, RFC 3339"
+// @Param		updatedFrom	query		string		false	"Updated at or after, RFC 3339"
+// @Param		updatedTo	query		string		false	"Updated before, RFC 3339"
+// @Param		minRating	query		number		false	"Minimum rating"
+// @Param		minSize		query		int			false	"Minimum project file size in bytes"
+// @Param		maxSize		query		int			false	"Maximum project file size in bytes"
+// @Success	200			{object}	models.Page[models.Demo]
+// @Failure	400			{object}	HTTPError
+// @Failure	404			{object}	HTTPError
+// @Failure	422			{object}	HTTPError
+// @Failure	500			{object}	HTTPError
 // @Router		/v1/demos [get]
 func (h *DemoHandler) GetDemos(c echo.Context) error {
 	var (
 		err   error
-		limit uint64
 		order string
-		demos *[]models.Demo
+		demos *models.Page[models.Demo]
 	)
 
-	l := c.Request().URL.Query()["l"]
-	if l != nil {
-		err = h.validator.Var(l[0], "omitnil,number,min=0")
-		if err != nil {
-			e := HTTPError{
-				Code:    http.StatusUnprocessableEntity,
-				Message: "Error in GetDemos repository: " + err.Error(),
-			}
-			h.logger.Print(&e)
-			return c.JSON(http.StatusUnprocessableEntity, &e)
-		}
-		limit, err = strconv.ParseUint(l[0], 10, 64)
+	page, ok, err := parsePageQuery(c, h.logger, h.validator, "GetDemos")
+	if !ok {
+		return err
+	}
+	filter, ok, err := parseListFilter(c, h.logger, h.validator, "GetDemos", demoFilters)
+	if !ok {
+		return err
 	}
 	tags := c.Request().URL.Query()["q"]
 
@@ -263,13 +294,18 @@ func (h *DemoHandler) GetDemos(c echo.Context) error {
 		order = "newest-updated"
 	}
 
-	demos, err = h.repository.FindDemos(tags, limit, order)
+	demos, err = h.repository.FindDemos(tags, filter, page, order)
 	if err != nil {
 		if err == h.repository.NotFoundErr() {
 			e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
 			h.logger.Print(&e)
 			return c.JSON(http.StatusNotFound, &e)
 		}
+		if err == h.repository.CursorErr() {
+			e := HTTPError{Code: http.StatusUnprocessableEntity, Message: err.Error()}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusUnprocessableEntity, &e)
+		}
 		e := HTTPError{
 			Code:    http.StatusInternalServerError,
 			Message: "Error in FindDemos repository: " + err.Error(),
@@ -333,7 +369,21 @@ func (h *DemoHandler) PatchDemo(c echo.Context) error {
 		return c.JSON(http.StatusUnprocessableEntity, &e)
 	}
 
+	var subject models.FilterSubject
+	verdict := &models.FilterVerdict{Action: "allow"}
+	if demo.Title != nil || demo.Description != nil {
+		subject = models.FilterSubject{Kind: "demo", AuthorID: sessionUserID(c), Text: filterText(demo.Title, demo.Description)}
+		verdict, err = checkContent(c, h.logger, h.contentFilter, subject)
+		if verdict == nil {
+			return err
+		}
+	}
+	if ok, err := renderMarkdown(c, h.logger, h.markdown, demo.Description, &demo.DescriptionHTML); !ok {
+		return err
+	}
+
 	var demoMultipartFile, thumbnailMultipartFile multipart.File
+	demo.FileSize = nil
 	demoFormFile, err := c.FormFile("demoFile")
 	if demoFormFile != nil {
 		demoMultipartFile, err = demoFormFile.Open()
@@ -346,6 +396,7 @@ func (h *DemoHandler) PatchDemo(c echo.Context) error {
 			return c.JSON(http.StatusBadRequest, &e)
 		}
 		defer demoMultipartFile.Close()
+		demo.FileSize = &demoFormFile.Size
 
 		err = h.objectUploader.CheckFileSize(demoFormFile.Size, c.Get("userTier").(string))
 		if err != nil {
@@ -398,6 +449,7 @@ func (h *DemoHandler) PatchDemo(c echo.Context) error {
 		}
 	}
 
+	demo.EditedBy = sessionUserID(c)
 	updDemo, err := h.repository.UpdateDemo(int(id), demo, demoMultipartFile, thumbnailMultipartFile)
 	if err != nil {
 		if err == h.repository.NotFoundErr() {
@@ -433,6 +485,9 @@ func (h *DemoHandler) PatchDemo(c echo.Context) error {
 		h.logger.Print(&e)
 		return c.JSON(http.StatusInternalServerError, &e)
 	}
+	if hiddenAt := contentStored(h.logger, h.contentFilter, subject, int(id), *verdict); hiddenAt != nil {
+		updDemo.HiddenAt = hiddenAt
+	}
 
 	return c.JSON(http.StatusOK, &updDemo)
 }
@@ -443,6 +498,7 @@ func (h *DemoHandler) PatchDemo(c echo.Context) error {
 // @Produce	text/plain
 // @Param		id	path		int	true	"Delete Demo of ID"
 // @Success	200	{string}	string
+// @Failure	401	{object}	HTTPError
 // @Failure	403	{object}	HTTPError
 // @Failure	404	{object}	HTTPError
 // @Failure	422	{object}	HTTPError
@@ -461,7 +517,14 @@ func (h *DemoHandler) DeleteDemo(c echo.Context) error {
 	}
 	id, _ := strconv.ParseInt(p, 10, 64)
 
-	err = h.repository.DeleteDemo(int(id))
+	userID, ok := c.Get("userID").(uuid.UUID)
+	if !ok {
+		e := HTTPError{Code: http.StatusUnauthorized, Message: "No session provided!"}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusUnauthorized, &e)
+	}
+
+	err = h.repository.DeleteDemo(int(id), userID)
 	if err != nil {
 		if err == h.repository.NotFoundErr() {
 			e := HTTPError{
@@ -481,3 +544,388 @@ func (h *DemoHandler) DeleteDemo(c echo.Context) error {
 
 	return c.String(http.StatusOK, "Demo successfully deleted!")
 }
+
+// @Summary	Restores the specified demo from the trash.
+// @Tags		Demos
+// @Accept		text/plain
+// @Produce	text/plain
+// @Param		id	path		int	true	"Restore Demo of ID"
+// @Success	200	{string}	string
+// @Failure	401	{object}	HTTPError
+// @Failure	403	{object}	HTTPError
+// @Failure	404	{object}	HTTPError
+// @Failure	422	{object}	HTTPError
+// @Failure	500	{object}	HTTPError
+// @Router		/v1/demos/{id}/restore [post]
+func (h *DemoHandler) RestoreDemo(c echo.Context) error {
+	p := c.Param("id")
+	err := h.validator.Var(p, "required,number")
+	if err != nil {
+		e := HTTPError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Error in RestoreDemo handler: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusUnprocessableEntity, &e)
+	}
+	id, _ := strconv.ParseInt(p, 10, 64)
+
+	userID, ok := c.Get("userID").(uuid.UUID)
+	if !ok {
+		e := HTTPError{Code: http.StatusUnauthorized, Message: "No session provided!"}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusUnauthorized, &e)
+	}
+
+	err = h.repository.RestoreDemo(int(id), restorer(c, userID))
+	if err != nil {
+		if err == h.repository.NotFoundErr() {
+			e := HTTPError{
+				Code:    http.StatusNotFound,
+				Message: "Not Found!",
+			}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusNotFound, &e)
+		}
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in RestoreDemo repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+
+	return c.String(http.StatusOK, "Demo successfully restored!")
+}
+
+// @Summary	Fetches assets declared by the demo of ID.
+// @Tags		Demos
+// @Accept		text/plain
+// @Produce	application/json
+// @Param		id	path		int	true	"Get Assets of Demo ID"
+// @Success	200	{object}	[]models.DemoAsset
+// @Failure	404	{object}	HTTPError
+// @Failure	422	{object}	HTTPError
+// @Failure	500	{object}	HTTPError
+// @Router		/v1/demos/{id}/assets [get]
+func (h *DemoHandler) GetDemoAssets(c echo.Context) error {
+	p := c.Param("id")
+	err := h.validator.Var(p, "required,number")
+	if err != nil {
+		e := HTTPError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Error in GetDemoAssets handler: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusUnprocessableEntity, &e)
+	}
+	id, _ := strconv.ParseInt(p, 10, 64)
+
+	demoAssets, err := h.repository.FindDemoAssets(int(id))
+	if err != nil {
+		if err == h.repository.NotFoundErr() {
+			e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusNotFound, &e)
+		}
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in FindDemoAssets repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+
+	return c.JSON(http.StatusOK, &demoAssets)
+}
+
+// @Summary	Replaces the set of assets used by the demo.
+// @Tags		Demos
+// @Accept		application/json
+// @Produce	application/json
+// @Param		id			path		int					true	"Update Assets of Demo ID"
+// @Param		DemoAssets	body		[]models.DemoAsset	true	"Assets used by the Demo"
+// @Success	200			{object}	[]models.DemoAsset
+// @Failure	400			{object}	HTTPError
+// @Failure	403			{object}	HTTPError
+// @Failure	404			{object}	HTTPError
+// @Failure	422			{object}	HTTPError
+// @Failure	500			{object}	HTTPError
+// @Router		/v1/demos/{id}/assets [put]
+func (h *DemoHandler) PutDemoAssets(c echo.Context) error {
+	var demoAssets []models.DemoAsset
+
+	p := c.Param("id")
+	err := h.validator.Var(p, "required,number")
+	if err != nil {
+		e := HTTPError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Error in PutDemoAssets handler: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusUnprocessableEntity, &e)
+	}
+	id, _ := strconv.ParseInt(p, 10, 64)
+
+	err = c.Bind(&demoAssets)
+	if err != nil {
+		e := HTTPError{
+			Code:    http.StatusBadRequest,
+			Message: "Error in PutDemoAssets handler: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusBadRequest, &e)
+	}
+
+	err = h.validator.Var(demoAssets, "max=100,unique=AssetID,dive")
+	if err != nil {
+		e := HTTPError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Error in PutDemoAssets handler: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusUnprocessableEntity, &e)
+	}
+
+	updDemoAssets, err := h.repository.UpdateDemoAssets(int(id), demoAssets)
+	if err != nil {
+		if err == h.repository.NotFoundErr() {
+			e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusNotFound, &e)
+		}
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in UpdateDemoAssets repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+
+	return c.JSON(http.StatusOK, &updDemoAssets)
+}
+
+// @Summary	Forks the demo into a new demo owned by the caller.
+// @Tags		Demos
+// @Accept		multipart/form-data
+// @Produce	application/json
+// @Security	ApiSessionCookie
+// @param		sessionID	header		string		false	"Session ID"
+// @Param		id			path		int			true	"Fork Demo of ID"
+// @Param		Demo		formData	models.Demo	false	"Fields to override in the fork"
+// @Success	201			{object}	models.Demo
+// @Failure	400			{object}	HTTPError
+// @Failure	401			{object}	HTTPError
+// @Failure	403			{object}	HTTPError
+// @Failure	404			{object}	HTTPError
+// @Failure	422			{object}	HTTPError
+// @Failure	500			{object}	HTTPError
+// @Router		/v1/demos/{id}/fork [post]
+func (h *DemoHandler) ForkDemo(c echo.Context) error {
+	var fork models.Demo
+
+	p := c.Param("id")
+	err := h.validator.Var(p, "required,number")
+	if err != nil {
+		e := HTTPError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Error in ForkDemo handler: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusUnprocessableEntity, &e)
+	}
+	id, _ := strconv.ParseInt(p, 10, 64)
+
+	err = c.Bind(&fork)
+	if err != nil {
+		e := HTTPError{
+			Code:    http.StatusBadRequest,
+			Message: "Error in ForkDemo handler: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusBadRequest, &e)
+	}
+
+	err = h.validator.Struct(&fork)
+	if err != nil {
+		e := HTTPError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Error in ForkDemo handler: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusUnprocessableEntity, &e)
+	}
+
+	userID, ok := c.Get("userID").(uuid.UUID)
+	if !ok {
+		e := HTTPError{Code: http.StatusUnauthorized, Message: "No session provided!"}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusUnauthorized, &e)
+	}
+	fork.UserID = &userID
+
+	var subject models.FilterSubject
+	verdict := &models.FilterVerdict{Action: "allow"}
+	if fork.Title != nil || fork.Description != nil {
+		subject = models.FilterSubject{Kind: "demo", AuthorID: &userID, Text: filterText(fork.Title, fork.Description)}
+		verdict, err = checkContent(c, h.logger, h.contentFilter, subject)
+		if verdict == nil {
+			return err
+		}
+	}
+	if ok, err := renderMarkdown(c, h.logger, h.markdown, fork.Description, &fork.DescriptionHTML); !ok {
+		return err
+	}
+
+	src, err := h.repository.PeekDemoByID(int(id))
+	if err != nil {
+		if err == h.repository.NotFoundErr() {
+			e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusNotFound, &e)
+		}
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in PeekDemoByID repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	if src.HiddenAt != nil && !canViewHidden(c, src.UserID) {
+		e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusNotFound, &e)
+	}
+	if src.AllowRemix != nil && !*src.AllowRemix {
+		e := HTTPError{Code: http.StatusForbidden, Message: h.repository.RemixForbiddenErr().Error()}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusForbidden, &e)
+	}
+	if fork.Title == nil {
+		fork.Title = src.Title
+	}
+	if fork.Tags == nil {
+		fork.Tags = src.Tags
+	}
+
+	fork.ThreadID, err = h.syncer.PostThread(fork)
+	if err != nil {
+		e := HTTPError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Error in ForkDemo handler: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusUnprocessableEntity, &e)
+	}
+
+	newDemo, err := h.repository.ForkDemo(int(id), fork)
+	if err != nil {
+		// The fork is not published, neither is its thread
+		if dropErr := h.syncer.DropThread(*fork.ThreadID, userID); dropErr != nil {
+			h.logger.Print("Error dropping the thread of a failed fork: " + dropErr.Error())
+		}
+		if err == h.repository.RemixForbiddenErr() {
+			e := HTTPError{Code: http.StatusForbidden, Message: err.Error()}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusForbidden, &e)
+		}
+		if err == h.repository.NotFoundErr() {
+			e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusNotFound, &e)
+		}
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in ForkDemo repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	if hiddenAt := contentStored(h.logger, h.contentFilter, subject, *newDemo.ID, *verdict); hiddenAt != nil {
+		newDemo.HiddenAt = hiddenAt
+	}
+
+	return c.JSON(http.StatusCreated, &newDemo)
+}
+
+// @Summary	Fetches direct forks of the demo of ID.
+// @Tags		Demos
+// @Accept		text/plain
+// @Produce	application/json
+// @Param		id	path		int	true	"Get forks of Demo ID"
+// @Success	200	{object}	[]models.Demo
+// @Failure	404	{object}	HTTPError
+// @Failure	422	{object}	HTTPError
+// @Failure	500	{object}	HTTPError
+// @Router		/v1/demos/{id}/forks [get]
+func (h *DemoHandler) GetDemoForks(c echo.Context) error {
+	p := c.Param("id")
+	err := h.validator.Var(p, "required,number")
+	if err != nil {
+		e := HTTPError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Error in GetDemoForks handler: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusUnprocessableEntity, &e)
+	}
+	id, _ := strconv.ParseInt(p, 10, 64)
+
+	demos, err := h.repository.FindDemoForks(int(id))
+	if err != nil {
+		if err == h.repository.NotFoundErr() {
+			e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusNotFound, &e)
+		}
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in FindDemoForks repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+
+	return c.JSON(http.StatusOK, &demos)
+}
+
+// @Summary	Fetches the fork lineage of the demo of ID, from the direct parent up to the root.
+// @Tags		Demos
+// @Accept		text/plain
+// @Produce	application/json
+// @Param		id	path		int	true	"Get ancestry of Demo ID"
+// @Success	200	{object}	[]models.Demo
+// @Failure	404	{object}	HTTPError
+// @Failure	422	{object}	HTTPError
+// @Failure	500	{object}	HTTPError
+// @Router		/v1/demos/{id}/ancestry [get]
+func (h *DemoHandler) GetDemoAncestry(c echo.Context) error {
+	p := c.Param("id")
+	err := h.validator.Var(p, "required,number")
+	if err != nil {
+		e := HTTPError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Error in GetDemoAncestry handler: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusUnprocessableEntity, &e)
+	}
+	id, _ := strconv.ParseInt(p, 10, 64)
+
+	demos, err := h.repository.FindDemoAncestry(int(id))
+	if err != nil {
+		if err == h.repository.NotFoundErr() {
+			e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusNotFound, &e)
+		}
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in FindDemoAncestry repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+
+	return c.JSON(http.StatusOK, &demos)
+}
@@ -10,28 +10,41 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	// "github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
 )
 
 type mockThreadSyncer struct {
 	topicID int
+	dropped []int
 }
 
 type mockDemoRepo struct {
-	data        map[int]models.Demo
-	notFoundErr error
+	data              map[int]models.Demo
+	deletedDemo       map[int]models.Demo
+	demoAssets        map[int][]models.DemoAsset
+	notFoundErr       error
+	cursorErr         error
+	remixForbiddenErr error
+	forkErr           error
 }
 
 var (
 	// v  = validator.New(validator.WithRequiredStructEnabled())
 	mt = mockThreadSyncer{topicID: 1}
 	md = mockDemoRepo{
-		data:        make(map[int]models.Demo, 1),
-		notFoundErr: errors.New("Not Found"),
+		data:              make(map[int]models.Demo, 1),
+		deletedDemo:       make(map[int]models.Demo, 1),
+		demoAssets:        make(map[int][]models.DemoAsset, 1),
+		notFoundErr:       errors.New("Not Found"),
+		cursorErr:         errors.New("Cursor is not valid!"),
+		remixForbiddenErr: errors.New("Remixing is not allowed for this demo!"),
 	}
 
 	// mockFileUploader mockObjectUploader
@@ -48,12 +61,17 @@ var (
 	// queryOrder        = `newest-updated`
 
 	demoJSON                   = `{"title":"Cool demo","description":"A very nice demo to use in your game!","userID":"` + genericUUID.String() + `"}`
-	demoJSONExpected           = `{"id":1,"title":"Cool demo","description":"A very nice demo to use in your game!","userID":"` + genericUUID.String() + `","threadID":1,"key":"` + mockURI + `","thumbnailKey":"` + mockURI + `"}` + "\n"
-	demoJSONExpectedMany       = `[{"id":1,"title":"Cool demo","description":"A very nice demo to use in your game!","userID":"` + genericUUID.String() + `","threadID":1,"key":"` + mockURI + `","thumbnailKey":"` + mockURI + `"}]` + "\n"
-	demoJSONQueryExpected      = `[{"id":1,"title":"cheeseboiger","tags":null,"userID":"` + genericUUID.String() + `","key":"` + mockURI + `","thumbnailKey":"` + mockURI + `"},{"id":2,"title":"demo two","tags":["cheeseboiger"],"userID":"` + genericUUID.String() + `","key":null,"thumbnailKey":null}]` + "\n"
-	demoJSONQueryExpectedLimit = `[{"id":1,"title":"cheeseboiger","tags":null,"userID":"` + genericUUID.String() + `","key":"` + mockURI + `","thumbnailKey":"` + mockURI + `"}]` + "\n"
+	demoJSONExpected           = `{"id":1,"title":"Cool demo","description":"A very nice demo to use in your game!","userID":"` + genericUUID.String() + `","threadID":1,"key":"` + mockURI + `","thumbnailKey":"` + mockURI + `","descriptionHTML":"\u003cp\u003eA very nice demo to use in your game!\u003c/p\u003e","fileSize":` + mockFileSize + `}` + "\n"
+	demoJSONExpectedMany       = `{"items":[{"id":1,"title":"Cool demo","description":"A very nice demo to use in your game!","userID":"` + genericUUID.String() + `","threadID":1,"key":"` + mockURI + `","thumbnailKey":"` + mockURI + `","descriptionHTML":"\u003cp\u003eA very nice demo to use in your game!\u003c/p\u003e","fileSize":` + mockFileSize + `}],"total":1}` + "\n"
+	demoJSONQueryExpected      = `{"items":[{"id":1,"title":"cheeseboiger","tags":null,"userID":"` + genericUUID.String() + `","key":"` + mockURI + `","thumbnailKey":"` + mockURI + `"},{"id":2,"title":"demo two","tags":["cheeseboiger"],"userID":"` + genericUUID.String() + `","key":null,"thumbnailKey":null}],"total":2}` + "\n"
+	demoJSONFilterExpected     = `{"items":[{"id":2,"title":"demo two","tags":["cheeseboiger"],"userID":"` + genericUUID.String() + `","key":null,"thumbnailKey":null}],"total":1}` + "\n"
+	demoJSONQueryExpectedLimit = `{"items":[{"id":1,"title":"cheeseboiger","tags":null,"userID":"` + genericUUID.String() + `","key":"` + mockURI + `","thumbnailKey":"` + mockURI + `"}],"total":2}` + "\n"
 	demoJSONUpdate             = `{"title":"Updated cool demo","threadID":1}`
-	demoJSONUpdateExpected     = `{"id":1,"title":"Updated cool demo","description":"A very nice demo to use in your game!","userID":"` + genericUUID.String() + `","threadID":1,"key":"` + mockURI + `","thumbnailKey":"` + mockURI + `"}` + "\n"
+	demoAssetsJSON             = `[{"assetID":1,"assetVersion":1}]`
+	demoAssetsJSONExpected     = `[{"demoID":1,"assetID":1,"assetVersion":1}]` + "\n"
+	demoAssetsJSONDuplicate    = `[{"assetID":1},{"assetID":1}]`
+	demoForkJSONExpected       = `{"id":2,"title":"Updated cool demo","userID":"` + genericUUID.String() + `","threadID":1,"key":null,"thumbnailKey":null,"forkedFrom":1}` + "\n"
+	demoJSONUpdateExpected     = `{"id":1,"title":"Updated cool demo","description":"A very nice demo to use in your game!","userID":"` + genericUUID.String() + `","threadID":1,"key":"` + mockURI + `","thumbnailKey":"` + mockURI + `","descriptionHTML":"\u003cp\u003eA very nice demo to use in your game!\u003c/p\u003e","fileSize":` + mockFileSize + `}` + "\n"
 )
 
 func (r *mockDemoRepo) CreateDemo(demo models.Demo, demoFile, demoThumbnail io.Reader) (*models.Demo, error) {
@@ -71,7 +89,10 @@ func (r *mockDemoRepo) FindDemoByID(id int) (*models.Demo, error) {
 	}
 	return &a, nil
 }
-func (r *mockDemoRepo) FindDemos(query []string, limit uint64, order string) (*[]models.Demo, error) {
+func (r *mockDemoRepo) PeekDemoByID(id int) (*models.Demo, error) {
+	return r.FindDemoByID(id)
+}
+func (r *mockDemoRepo) FindDemos(query []string, filter models.ListFilter, page models.PageQuery, order string) (*models.Page[models.Demo], error) {
 	var (
 		demoIDs    []int         = []int{1, 2, 3}
 		demoTitles []string      = []string{"cheeseboiger", "demo two", "demo three"}
@@ -83,6 +104,10 @@ func (r *mockDemoRepo) FindDemos(query []string, limit uint64, order string) (*[
 		}
 		resultDemos []models.Demo
 	)
+	// Every listing fits on the first page
+	if page.Cursor != "" {
+		return nil, r.CursorErr()
+	}
 
 	if len(query) != 0 {
 		for _, d := range demos {
@@ -98,10 +123,15 @@ func (r *mockDemoRepo) FindDemos(query []string, limit uint64, order string) (*[
 			resultDemos = append(resultDemos, v)
 		}
 	}
-	if limit != 0 {
-		resultDemos = resultDemos[:limit]
+	// Of the filters only tags are mocked
+	resultDemos = slices.DeleteFunc(resultDemos, func(d models.Demo) bool {
+		return len(filter.Tags) != 0 && (d.Tags == nil || !slices.Contains(*d.Tags, filter.Tags[0]))
+	})
+	total := int64(len(resultDemos))
+	if page.Limit != 0 && int(page.Limit) < len(resultDemos) {
+		resultDemos = resultDemos[:page.Limit]
 	}
-	return &resultDemos, nil
+	return &models.Page[models.Demo]{Items: resultDemos, Total: total}, nil
 }
 func (r *mockDemoRepo) UpdateDemo(id int, demo models.Demo, demoFile, demoThumbnail io.Reader) (*models.Demo, error) {
 	var a models.Demo
@@ -117,21 +147,98 @@ func (r *mockDemoRepo) UpdateDemo(id int, demo models.Demo, demoFile, demoThumbn
 	a = r.data[id]
 	return &a, nil
 }
-func (r *mockDemoRepo) DeleteDemo(id int) error {
-	_, ok := r.data[id]
+func (r *mockDemoRepo) DeleteDemo(id int, deletedBy uuid.UUID) error {
+	x, ok := r.data[id]
 	if !ok {
 		return r.NotFoundErr()
 	}
+	r.deletedDemo[id] = x
 	delete(r.data, id)
 	return nil
 }
-func (r *mockDemoRepo) NotFoundErr() error { return r.notFoundErr }
+func (r *mockDemoRepo) RestoreDemo(id int, restoredBy *uuid.UUID) error {
+	x, ok := r.deletedDemo[id]
+	if !ok {
+		return r.NotFoundErr()
+	}
+	r.data[id] = x
+	delete(r.deletedDemo, id)
+	return nil
+}
+func (r *mockDemoRepo) FindDemoAssets(demoID int) (*[]models.DemoAsset, error) {
+	a, ok := r.demoAssets[demoID]
+	if !ok {
+		return nil, r.NotFoundErr()
+	}
+	return &a, nil
+}
+func (r *mockDemoRepo) UpdateDemoAssets(demoID int, demoAssets []models.DemoAsset) (*[]models.DemoAsset, error) {
+	_, ok := r.data[demoID]
+	if !ok {
+		return nil, r.NotFoundErr()
+	}
+	for i := range demoAssets {
+		demoAssets[i].DemoID = &demoID
+	}
+	r.demoAssets[demoID] = demoAssets
+	return &demoAssets, nil
+}
+func (r *mockDemoRepo) ForkDemo(id int, fork models.Demo) (*models.Demo, error) {
+	if r.forkErr != nil {
+		return nil, r.forkErr
+	}
+	src, ok := r.data[id]
+	if !ok {
+		return nil, r.NotFoundErr()
+	}
+	if src.AllowRemix != nil && !*src.AllowRemix {
+		return nil, r.RemixForbiddenErr()
+	}
+	forkID := len(r.data) + 1
+	fork.ID = &forkID
+	fork.ForkedFrom = &id
+	r.data[forkID] = fork
+	return &fork, nil
+}
+func (r *mockDemoRepo) FindDemoForks(id int) (*[]models.Demo, error) {
+	var forks []models.Demo
+	for _, d := range r.data {
+		if d.ForkedFrom != nil && *d.ForkedFrom == id {
+			forks = append(forks, d)
+		}
+	}
+	if len(forks) == 0 {
+		return nil, r.NotFoundErr()
+	}
+	return &forks, nil
+}
+func (r *mockDemoRepo) FindDemoAncestry(id int) (*[]models.Demo, error) {
+	var ancestry []models.Demo
+	d, ok := r.data[id]
+	for ok && d.ForkedFrom != nil {
+		d, ok = r.data[*d.ForkedFrom]
+		if ok {
+			ancestry = append(ancestry, d)
+		}
+	}
+	if len(ancestry) == 0 {
+		return nil, r.NotFoundErr()
+	}
+	return &ancestry, nil
+}
+func (r *mockDemoRepo) NotFoundErr() error       { return r.notFoundErr }
+func (r *mockDemoRepo) CursorErr() error         { return r.cursorErr }
+func (r *mockDemoRepo) RemixForbiddenErr() error { return r.remixForbiddenErr }
 
 func (s *mockThreadSyncer) PostThread(demo models.Demo) (*int, error) {
 	threadID := 1
 	return &threadID, nil
 }
 func (s *mockThreadSyncer) PatchThread(demoID int, demo models.Demo) error { return nil }
+func (s *mockThreadSyncer) DropThread(threadID int, userID uuid.UUID) error {
+	s.dropped = append(s.dropped, threadID)
+	return nil
+}
 
 func TestPostDemo(t *testing.T) {
 	// Setup
@@ -158,7 +265,7 @@ func TestPostDemo(t *testing.T) {
 	rec := httptest.NewRecorder()
 	c := e.NewContext(req, rec)
 	c.Set("userTier", "freetier") // Required for attachment size check
-	h := &DemoHandler{logger: e.Logger, validator: v, repository: &md, syncer: &mt, objectUploader: &mockFileUploader}
+	h := &DemoHandler{logger: e.Logger, validator: v, repository: &md, syncer: &mt, objectUploader: &mockFileUploader, contentFilter: &mcf, markdown: &mmd}
 
 	// Assertions
 	if assert.NoError(t, h.PostDemo(c)) {
@@ -245,7 +352,7 @@ func TestPatchDemo(t *testing.T) {
 	c.SetParamNames("id")
 	c.SetParamValues("1")
 	c.Set("userTier", "freetier") // Required for attachment size check
-	h := &DemoHandler{logger: e.Logger, validator: v, repository: &md, syncer: &mt, objectUploader: &mockFileUploader}
+	h := &DemoHandler{logger: e.Logger, validator: v, repository: &md, syncer: &mt, objectUploader: &mockFileUploader, contentFilter: &mcf, markdown: &mmd}
 
 	// Assertions
 	if assert.NoError(t, h.PatchDemo(c)) {
@@ -284,6 +391,119 @@ func TestGetDemosQueryLimit(t *testing.T) {
 	}
 }
 
+func TestGetDemosCursorUnprocessable(t *testing.T) {
+	// Setup
+	e := echo.New()
+	h := &DemoHandler{logger: e.Logger, validator: v, repository: &md, syncer: &mt}
+	get := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/game-hangar/v1/demos?"+query, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		assert.NoError(t, h.GetDemos(c))
+		return rec
+	}
+
+	// Assertions
+	rec := get("c=stale")
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Equal(t, `{"code":422,"message":"Cursor is not valid!"}`+"\n", rec.Body.String())
+	assert.Equal(t, http.StatusUnprocessableEntity, get("l=-1").Code)
+}
+
+func TestGetDemosFilter(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/game-hangar/v1/demos?q=%v&tag=%v", queryTags, queryTags), nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	h := &DemoHandler{logger: e.Logger, validator: v, repository: &md, syncer: &mt}
+
+	// Assertions
+	if assert.NoError(t, h.GetDemos(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, demoJSONFilterExpected, rec.Body.String())
+	}
+}
+
+func TestGetDemosFilterUnprocessable(t *testing.T) {
+	// Setup
+	e := echo.New()
+	h := &DemoHandler{logger: e.Logger, validator: v, repository: &md, syncer: &mt}
+
+	// Assertions
+	for _, query := range []string{
+		"author=someone",
+		"tag=",
+		"tagMatch=some",
+		"createdFrom=yesterday",
+		"createdFrom=2025-02-01T00:00:00Z&createdTo=2025-01-01T00:00:00Z",
+		"minRating=high",
+		"minSize=-1",
+		"minSize=20&maxSize=10",
+		"topic=1",          // Demos have no topic
+		"godotVersion=4.2", // nor an engine version
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/game-hangar/v1/demos?"+query, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		if assert.NoError(t, h.GetDemos(c)) {
+			assert.Equal(t, http.StatusUnprocessableEntity, rec.Code, query)
+		}
+	}
+}
+
+func TestParseListFilter(t *testing.T) {
+	// Setup
+	e := echo.New()
+	parse := func(query string, supported []string) models.ListFilter {
+		req := httptest.NewRequest(http.MethodGet, "/game-hangar/v1/threads?"+query, nil)
+		c := e.NewContext(req, httptest.NewRecorder())
+		filter, ok, err := parseListFilter(c, e.Logger, v, "GetThreads", supported)
+		assert.True(t, ok)
+		assert.NoError(t, err)
+		return filter
+	}
+	topicID := 3
+	rating := 0.5
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	version := "4.2"
+	var size int64 = 1024
+
+	// Assertions
+	assert.Equal(t, models.ListFilter{}, parse("q=cheeseboiger&o=most-views", threadFilters))
+	assert.Equal(t, models.ListFilter{
+		UserID:      &genericUUID,
+		Tags:        []string{"godot", "2d"},
+		AnyTag:      true,
+		ExcludeTags: []string{"wip"},
+		TopicID:     &topicID,
+		CreatedFrom: &from,
+		MinRating:   &rating,
+	}, parse("author="+genericUUID.String()+"&tag=godot&tag=2d&tagMatch=any&notTag=wip&topic=3&createdFrom=2025-01-01T00:00:00Z&minRating=0.5", threadFilters))
+	assert.Equal(t, models.ListFilter{GodotVersion: &version, MinSize: &size, MaxSize: &size},
+		parse("godotVersion=4.2&minSize=1024&maxSize=1024", assetFilters))
+}
+
+func TestParsePageQuery(t *testing.T) {
+	// Setup
+	e := echo.New()
+	parse := func(query string) models.PageQuery {
+		req := httptest.NewRequest(http.MethodGet, "/game-hangar/v1/demos?"+query, nil)
+		c := e.NewContext(req, httptest.NewRecorder())
+		page, ok, err := parsePageQuery(c, e.Logger, v, "GetDemos")
+		assert.True(t, ok)
+		assert.NoError(t, err)
+		return page
+	}
+
+	// Assertions
+	assert.Equal(t, models.PageQuery{Limit: defaultPageSize}, parse(""))
+	assert.Equal(t, models.PageQuery{Limit: defaultPageSize}, parse("l=0"))
+	assert.Equal(t, models.PageQuery{Limit: 5, Cursor: "next"}, parse("l=5&c=next"))
+	assert.Equal(t, models.PageQuery{Limit: maxPageSize}, parse("l=1000"))
+	assert.Equal(t, models.PageQuery{Limit: maxPageSize}, parse("l=99999999999999999999999"))
+}
+
 func TestPatchDemoNotFound(t *testing.T) {
 	// Setup
 	e := echo.New()
@@ -311,7 +531,7 @@ func TestPatchDemoNotFound(t *testing.T) {
 	c.SetParamNames("id")
 	c.SetParamValues("4")
 	c.Set("userTier", "freetier") // Required for attachment size check
-	h := &DemoHandler{logger: e.Logger, validator: v, repository: &md, syncer: &mt, objectUploader: &mockFileUploader}
+	h := &DemoHandler{logger: e.Logger, validator: v, repository: &md, syncer: &mt, objectUploader: &mockFileUploader, contentFilter: &mcf, markdown: &mmd}
 
 	// Assertions
 	if assert.NoError(t, h.PatchDemo(c)) {
@@ -329,6 +549,7 @@ func TestDeleteDemo(t *testing.T) {
 	c.SetPath("/:id")
 	c.SetParamNames("id")
 	c.SetParamValues("1")
+	c.Set("userID", genericUUID)
 	h := &DemoHandler{logger: e.Logger, validator: v, repository: &md, syncer: &mt}
 
 	// Assertions
@@ -346,6 +567,7 @@ func TestDeleteDemoNotFound(t *testing.T) {
 	c.SetPath("/:id")
 	c.SetParamNames("id")
 	c.SetParamValues("5")
+	c.Set("userID", genericUUID)
 	h := &DemoHandler{logger: e.Logger, validator: v, repository: &md, syncer: &mt}
 
 	// Assertions
@@ -354,3 +576,216 @@ func TestDeleteDemoNotFound(t *testing.T) {
 		assert.Equal(t, notFoundResponse, rec.Body.String())
 	}
 }
+
+func TestPutDemoAssets(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPut, "/game-hangar/v1/demos", strings.NewReader(demoAssetsJSON))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/:id/assets")
+	c.SetParamNames("id")
+	c.SetParamValues("1")
+	h := &DemoHandler{logger: e.Logger, validator: v, repository: &md, syncer: &mt}
+
+	// Assertions
+	if assert.NoError(t, h.PutDemoAssets(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, demoAssetsJSONExpected, rec.Body.String())
+	}
+}
+
+func TestPutDemoAssetsDuplicate(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPut, "/game-hangar/v1/demos", strings.NewReader(demoAssetsJSONDuplicate))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/:id/assets")
+	c.SetParamNames("id")
+	c.SetParamValues("1")
+	h := &DemoHandler{logger: e.Logger, validator: v, repository: &md, syncer: &mt}
+
+	// Assertions
+	if assert.NoError(t, h.PutDemoAssets(c)) {
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	}
+}
+
+func TestGetDemoAssets(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/game-hangar/v1/demos", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/:id/assets")
+	c.SetParamNames("id")
+	c.SetParamValues("1")
+	h := &DemoHandler{logger: e.Logger, validator: v, repository: &md, syncer: &mt}
+
+	// Assertions
+	if assert.NoError(t, h.GetDemoAssets(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, demoAssetsJSONExpected, rec.Body.String())
+	}
+}
+
+func TestGetDemoAssetsNotFound(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/game-hangar/v1/demos", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/:id/assets")
+	c.SetParamNames("id")
+	c.SetParamValues("5")
+	h := &DemoHandler{logger: e.Logger, validator: v, repository: &md, syncer: &mt}
+
+	// Assertions
+	if assert.NoError(t, h.GetDemoAssets(c)) {
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Equal(t, notFoundResponse, rec.Body.String())
+	}
+}
+
+func TestForkDemo(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/game-hangar/v1/demos", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/:id/fork")
+	c.SetParamNames("id")
+	c.SetParamValues("1")
+	c.Set("userID", genericUUID) // Set by the authorizer from the session
+	h := &DemoHandler{logger: e.Logger, validator: v, repository: &md, syncer: &mt, contentFilter: &mcf, markdown: &mmd}
+
+	// Assertions
+	if assert.NoError(t, h.ForkDemo(c)) {
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, demoForkJSONExpected, rec.Body.String())
+	}
+}
+
+func TestForkDemoForbidden(t *testing.T) {
+	// Setup
+	f := false
+	title := "Closed demo"
+	md.data[10] = models.Demo{ID: new(int), Title: &title, AllowRemix: &f}
+	defer delete(md.data, 10)
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/game-hangar/v1/demos", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/:id/fork")
+	c.SetParamNames("id")
+	c.SetParamValues("10")
+	c.Set("userID", genericUUID)
+	h := &DemoHandler{logger: e.Logger, validator: v, repository: &md, syncer: &mt, contentFilter: &mcf, markdown: &mmd}
+
+	// Assertions
+	if assert.NoError(t, h.ForkDemo(c)) {
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	}
+}
+
+func TestForkDemoFailed(t *testing.T) {
+	// Setup
+	md.forkErr = errors.New("Copying the project failed")
+	defer func() { md.forkErr, mt.dropped = nil, nil }()
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/game-hangar/v1/demos", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/:id/fork")
+	c.SetParamNames("id")
+	c.SetParamValues("1")
+	c.Set("userID", genericUUID)
+	h := &DemoHandler{logger: e.Logger, validator: v, repository: &md, syncer: &mt, contentFilter: &mcf, markdown: &mmd}
+
+	// Assertions: the thread posted for the fork is dropped
+	if assert.NoError(t, h.ForkDemo(c)) {
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.Equal(t, []int{1}, mt.dropped)
+	}
+}
+
+func TestGetDemoForks(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/game-hangar/v1/demos", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/:id/forks")
+	c.SetParamNames("id")
+	c.SetParamValues("1")
+	h := &DemoHandler{logger: e.Logger, validator: v, repository: &md, syncer: &mt}
+
+	// Assertions
+	if assert.NoError(t, h.GetDemoForks(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "["+strings.TrimSuffix(demoForkJSONExpected, "\n")+"]\n", rec.Body.String())
+	}
+}
+
+func TestGetDemoAncestry(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/game-hangar/v1/demos", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/:id/ancestry")
+	c.SetParamNames("id")
+	c.SetParamValues("1")
+	h := &DemoHandler{logger: e.Logger, validator: v, repository: &md, syncer: &mt}
+
+	// Assertions: the original demo has no ancestors
+	if assert.NoError(t, h.GetDemoAncestry(c)) {
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Equal(t, notFoundResponse, rec.Body.String())
+	}
+}
+
+func TestRestoreDemo(t *testing.T) {
+	// Setup
+	deletedID := 7
+	md.deletedDemo[deletedID] = models.Demo{ID: &deletedID}
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/game-hangar/v1/", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/:id/restore")
+	c.SetParamNames("id")
+	c.SetParamValues("7")
+	c.Set("userID", genericUUID)
+	h := &DemoHandler{logger: e.Logger, validator: v, repository: &md, syncer: &mt}
+
+	// Assertions
+	if assert.NoError(t, h.RestoreDemo(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.NotContains(t, md.deletedDemo, deletedID)
+	}
+	delete(md.data, deletedID)
+}
+
+func TestRestoreDemoNotFound(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/game-hangar/v1/", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/:id/restore")
+	c.SetParamNames("id")
+	c.SetParamValues("5")
+	c.Set("userID", genericUUID)
+	h := &DemoHandler{logger: e.Logger, validator: v, repository: &md, syncer: &mt}
+
+	// Assertions
+	if assert.NoError(t, h.RestoreDemo(c)) {
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	}
+}
@@ -8,35 +8,42 @@ import (
 	_ "gamehangar/docs"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 )
 
 type ForumHandler struct {
-	logger     echo.Logger
-	repository ForumRepository
-	validator  *validator.Validate
+	logger        echo.Logger
+	repository    ForumRepository
+	validator     *validator.Validate
+	contentFilter ContentFilter
+	markdown      MarkdownRenderer
 }
 
-func NewForumHandler(e *echo.Echo, repo ForumRepository, v *validator.Validate) *ForumHandler {
+func NewForumHandler(e *echo.Echo, repo ForumRepository, v *validator.Validate, f ContentFilter, m MarkdownRenderer) *ForumHandler {
 	return &ForumHandler{
-		logger:     e.Logger,
-		repository: repo,
-		validator:  v,
+		logger:        e.Logger,
+		repository:    repo,
+		validator:     v,
+		contentFilter: f,
+		markdown:      m,
 	}
 }
 
-//	@Summary	Creates a new topic.
-//	@Tags		Topics
-//	@Accept		application/json
-//	@Produce	application/json
-//	@Param		Topic	body		models.Topic	true	"Create Topic"
-//	@Success	201		{object}	models.Topic
-//	@Failure	400		{object}	HTTPError
-//	@Failure	403		{object}	HTTPError
-//	@Failure	404		{object}	HTTPError
-//	@Failure	422		{object}	HTTPError
-//	@Failure	500		{object}	HTTPError
-//	@Router		/v1/topics [post]
+//	@Summary		Creates a new topic.
+//	@Description	The slug is derived from the name when left out.
+//	@Tags			Topics
+//	@Accept			application/json
+//	@Produce		application/json
+//	@Param			Topic	body		models.Topic	true	"Create Topic"
+//	@Success		201		{object}	models.Topic
+//	@Failure		400		{object}	HTTPError
+//	@Failure		403		{object}	HTTPError
+//	@Failure		404		{object}	HTTPError
+//	@Failure		409		{object}	HTTPError
+//	@Failure		422		{object}	HTTPError
+//	@Failure		500		{object}	HTTPError
+//	@Router			/v1/topics [post]
 func (h *ForumHandler) PostTopic(c echo.Context) error {
 	var topic models.Topic
 
@@ -60,8 +67,19 @@ func (h *ForumHandler) PostTopic(c echo.Context) error {
 		return c.JSON(http.StatusUnprocessableEntity, &e)
 	}
 
+	topic.UserID = sessionUserID(c)
 	newTopic, err := h.repository.CreateTopic(topic)
 	if err != nil {
+		if err == h.repository.SlugConflictErr() {
+			e := HTTPError{Code: http.StatusConflict, Message: err.Error()}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusConflict, &e)
+		}
+		if err == h.repository.ParentErr() {
+			e := HTTPError{Code: http.StatusUnprocessableEntity, Message: err.Error()}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusUnprocessableEntity, &e)
+		}
 		e := HTTPError{
 			Code:    http.StatusInternalServerError,
 			Message: "Error in CreateTopic repository: " + err.Error(),
@@ -115,16 +133,72 @@ func (h *ForumHandler) GetTopicByID(c echo.Context) error {
 	return c.JSON(http.StatusOK, &topic)
 }
 
-//	@Summary	Fetches all topics.
+//	@Summary	Fetches a topic by its slug.
 //	@Tags		Topics
+//	@Accept		text/plain
 //	@Produce	application/json
-//	@Success	200	{object}	models.Topic
-//	@Failure	400	{object}	HTTPError
-//	@Failure	404	{object}	HTTPError
-//	@Failure	500	{object}	HTTPError
-//	@Router		/v1/topics [get]
+//	@Param		slug	path		string	true	"Get Topic of Slug"
+//	@Success	200		{object}	models.Topic
+//	@Failure	400		{object}	HTTPError
+//	@Failure	404		{object}	HTTPError
+//	@Failure	422		{object}	HTTPError
+//	@Failure	500		{object}	HTTPError
+//	@Router		/v1/topics/slug/{slug} [get]
+func (h *ForumHandler) GetTopicBySlug(c echo.Context) error {
+	slug := c.Param("slug")
+	err := h.validator.Var(slug, "required,max=90")
+	if err != nil {
+		e := HTTPError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Error in GetTopicBySlug handler: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusUnprocessableEntity, &e)
+	}
+
+	topic, err := h.repository.FindTopicBySlug(slug)
+	if err != nil {
+		if err == h.repository.NotFoundErr() {
+			e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusNotFound, &e)
+		}
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in FindTopicBySlug repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+
+	return c.JSON(http.StatusOK, &topic)
+}
+
+//	@Summary		Fetches all topics.
+//	@Description	Flat mode lists all topics, nested mode lists top-level topics with their subtopics as children.
+//	@Description	Topics are ordered by sort order, then name.
+//	@Tags			Topics
+//	@Produce		application/json
+//	@Param			mode	query		string	false	"Listing mode. Default flat"	Enums(flat, nested)
+//	@Success		200		{object}	models.Topic
+//	@Failure		400		{object}	HTTPError
+//	@Failure		404		{object}	HTTPError
+//	@Failure		422		{object}	HTTPError
+//	@Failure		500		{object}	HTTPError
+//	@Router			/v1/topics [get]
 func (h *ForumHandler) GetTopics(c echo.Context) error {
-	topics, err := h.repository.FindTopics()
+	mode := c.QueryParam("mode")
+	err := h.validator.Var(mode, "omitempty,oneof=flat nested")
+	if err != nil {
+		e := HTTPError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Error in GetTopics handler: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusUnprocessableEntity, &e)
+	}
+
+	topics, err := h.repository.FindTopics(mode == "nested")
 	if err != nil {
 		if err == h.repository.NotFoundErr() {
 			e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
@@ -142,20 +216,21 @@ func (h *ForumHandler) GetTopics(c echo.Context) error {
 	return c.JSON(http.StatusOK, &topics)
 }
 
-//	@Summary	Updates an topic.
-//	@Tags		Topics
-//	@Accept		application/json
-//	@Produce	application/json
-//	@Param		id		path		int				true	"Update Topic of ID"
-//	@Param		Topic	body		models.Topic	true	"Update Topic"
-//	@Success	200		{object}	models.Topic
-//	@Failure	400		{object}	HTTPError
-//	@Failure	403		{object}	HTTPError
-//	@Failure	404		{object}	HTTPError
-//	@Failure	409		{object}	HTTPError
-//	@Failure	422		{object}	HTTPError
-//	@Failure	500		{object}	HTTPError
-//	@Router		/v1/topics/{id} [patch]
+//	@Summary		Updates an topic.
+//	@Description	A parentID of 0 moves the topic to the top level. The slug is kept on rename.
+//	@Tags			Topics
+//	@Accept			application/json
+//	@Produce		application/json
+//	@Param			id		path		int				true	"Update Topic of ID"
+//	@Param			Topic	body		models.Topic	true	"Update Topic"
+//	@Success		200		{object}	models.Topic
+//	@Failure		400		{object}	HTTPError
+//	@Failure		403		{object}	HTTPError
+//	@Failure		404		{object}	HTTPError
+//	@Failure		409		{object}	HTTPError
+//	@Failure		422		{object}	HTTPError
+//	@Failure		500		{object}	HTTPError
+//	@Router			/v1/topics/{id} [patch]
 func (h *ForumHandler) PatchTopic(c echo.Context) error {
 	var topic models.Topic
 	p := c.Param("id")
@@ -207,6 +282,14 @@ func (h *ForumHandler) PatchTopic(c echo.Context) error {
 			}
 			h.logger.Print(&e)
 			return c.JSON(http.StatusConflict, &e)
+		} else if err == h.repository.SlugConflictErr() {
+			e := HTTPError{Code: http.StatusConflict, Message: err.Error()}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusConflict, &e)
+		} else if err == h.repository.ParentErr() {
+			e := HTTPError{Code: http.StatusUnprocessableEntity, Message: err.Error()}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusUnprocessableEntity, &e)
 		}
 		e := HTTPError{
 			Code:    http.StatusInternalServerError,
@@ -225,6 +308,7 @@ func (h *ForumHandler) PatchTopic(c echo.Context) error {
 //	@Produce	text/plain
 //	@Param		id	path		int	true	"Delete Topic of ID"
 //	@Success	200	{string}	string
+//	@Failure	401	{object}	HTTPError
 //	@Failure	403	{object}	HTTPError
 //	@Failure	404	{object}	HTTPError
 //	@Failure	422	{object}	HTTPError
@@ -243,7 +327,14 @@ func (h *ForumHandler) DeleteTopic(c echo.Context) error {
 	}
 	id, _ := strconv.ParseInt(p, 10, 64)
 
-	err = h.repository.DeleteTopic(int(id))
+	userID, ok := c.Get("userID").(uuid.UUID)
+	if !ok {
+		e := HTTPError{Code: http.StatusUnauthorized, Message: "No session provided!"}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusUnauthorized, &e)
+	}
+
+	err = h.repository.DeleteTopic(int(id), userID)
 	if err != nil {
 		if err == h.repository.NotFoundErr() {
 			e := HTTPError{
@@ -253,6 +344,11 @@ func (h *ForumHandler) DeleteTopic(c echo.Context) error {
 			h.logger.Print(&e)
 			return c.JSON(http.StatusNotFound, &e)
 		}
+		if err == h.repository.ChildrenErr() {
+			e := HTTPError{Code: http.StatusUnprocessableEntity, Message: err.Error()}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusUnprocessableEntity, &e)
+		}
 		e := HTTPError{
 			Code:    http.StatusInternalServerError,
 			Message: "Error in DeleteTopic repository: " + err.Error(),
@@ -264,6 +360,65 @@ func (h *ForumHandler) DeleteTopic(c echo.Context) error {
 	return c.String(http.StatusOK, "Topic sucessfully deleted!")
 }
 
+//	@Summary	Restores the specified topic from the trash.
+//	@Tags		Topics
+//	@Accept		text/plain
+//	@Produce	text/plain
+//	@Param		id	path		int	true	"Restore Topic of ID"
+//	@Success	200	{string}	string
+//	@Failure	401	{object}	HTTPError
+//	@Failure	403	{object}	HTTPError
+//	@Failure	404	{object}	HTTPError
+//	@Failure	409	{object}	HTTPError
+//	@Failure	422	{object}	HTTPError
+//	@Failure	500	{object}	HTTPError
+//	@Router		/v1/topics/{id}/restore [post]
+func (h *ForumHandler) RestoreTopic(c echo.Context) error {
+	p := c.Param("id")
+	err := h.validator.Var(p, "required,number")
+	if err != nil {
+		e := HTTPError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Error in RestoreTopic handler: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusUnprocessableEntity, &e)
+	}
+	id, _ := strconv.ParseInt(p, 10, 64)
+
+	userID, ok := c.Get("userID").(uuid.UUID)
+	if !ok {
+		e := HTTPError{Code: http.StatusUnauthorized, Message: "No session provided!"}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusUnauthorized, &e)
+	}
+
+	err = h.repository.RestoreTopic(int(id), restorer(c, userID))
+	if err != nil {
+		if err == h.repository.NotFoundErr() {
+			e := HTTPError{
+				Code:    http.StatusNotFound,
+				Message: "Not Found!",
+			}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusNotFound, &e)
+		}
+		if err == h.repository.SlugConflictErr() {
+			e := HTTPError{Code: http.StatusConflict, Message: err.Error()}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusConflict, &e)
+		}
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in RestoreTopic repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+
+	return c.String(http.StatusOK, "Topic successfully restored!")
+}
+
 //	@Summary	Creates a new thread.
 //	@Tags		Threads
 //	@Accept		application/json
@@ -300,6 +455,34 @@ func (h *ForumHandler) PostThread(c echo.Context) error {
 		return c.JSON(http.StatusUnprocessableEntity, &e)
 	}
 
+	if !isModerator(c) {
+		err = h.repository.CheckThreadRules(*thread.TopicID, *thread.UserID)
+		if err != nil {
+			switch err {
+			case h.repository.NotFoundErr():
+				e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
+				h.logger.Print(&e)
+				return c.JSON(http.StatusNotFound, &e)
+			case h.repository.ThreadAccessErr(), h.repository.AccountAgeErr():
+				e := HTTPError{Code: http.StatusForbidden, Message: err.Error()}
+				h.logger.Print(&e)
+				return c.JSON(http.StatusForbidden, &e)
+			}
+			e := HTTPError{
+				Code:    http.StatusInternalServerError,
+				Message: "Error in CheckThreadRules repository: " + err.Error(),
+			}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusInternalServerError, &e)
+		}
+	}
+
+	subject := models.FilterSubject{Kind: "thread", AuthorID: thread.UserID, Text: filterText(thread.Title)}
+	verdict, err := checkContent(c, h.logger, h.contentFilter, subject)
+	if verdict == nil {
+		return err
+	}
+
 	newThread, err := h.repository.CreateThread(thread)
 	if err != nil {
 		e := HTTPError{
@@ -309,6 +492,9 @@ func (h *ForumHandler) PostThread(c echo.Context) error {
 		h.logger.Print(&e)
 		return c.JSON(http.StatusInternalServerError, &e)
 	}
+	if hiddenAt := contentStored(h.logger, h.contentFilter, subject, *newThread.ID, *verdict); hiddenAt != nil {
+		newThread.HiddenAt = hiddenAt
+	}
 
 	return c.JSON(http.StatusCreated, &newThread)
 }
@@ -351,6 +537,22 @@ func (h *ForumHandler) GetThreadByID(c echo.Context) error {
 		h.logger.Print(&e)
 		return c.JSON(http.StatusInternalServerError, &e)
 	}
+	if thread.HiddenAt != nil {
+		visible, err := canViewHiddenPost(c, h.repository, thread.UserID, "thread", int(id))
+		if err != nil {
+			e := HTTPError{
+				Code:    http.StatusInternalServerError,
+				Message: "Error in ModeratesContent repository: " + err.Error(),
+			}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusInternalServerError, &e)
+		}
+		if !visible {
+			e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusNotFound, &e)
+		}
+	}
 
 	return c.JSON(http.StatusOK, &thread)
 }
@@ -358,36 +560,124 @@ func (h *ForumHandler) GetThreadByID(c echo.Context) error {
 //	@Summary	Fetches all threads.
 //	@Tags		Threads
 //	@Produce	application/json
-//	@Param		q	query		[]string	false	"Keyword Query"
-//	@Param		l	query		int			false	"Record number limit"
-//	@Param		o	query		string		false	"Record ordering. Default newest updated"	Enums(newest-updated, highest-rated, most-views)
-//	@Success	200	{object}	models.Thread
-//	@Failure	400	{object}	HTTPError
-//	@Failure	404	{object}	HTTPError
-//	@Failure	500	{object}	HTTPError
+//	@Param		q			query		[]string	false	"Keyword Query"
+//	@Param		l			query		int			false	"Page size, at most 100. Default 20"
+//	@Param		c			query		string		false	"Page cursor from the next or prev of a previous page"
+//	@Param		o			query		string		false	"Record ordering. Default newest updated"	Enums(newest-updated, highest-rated, most-views)
+//	@Param		author		query		string		false	"Author user ID"
+//	@Param		tag			query		[]string	false	"Tags the content has"
+//	@Param		tagMatch	query		string		false	"Whether content has all or any of the tags. Default all"	Enums(all, any)
+//	@Param		notTag		query		[]string	false	"Tags the content must not have"
+//	@Param		topic		query		int			false	"Topic ID, including its subcategories"
+//	@Param		createdFrom	query		string		false	"Created at or after, RFC 3339"
+//	@Param		createdTo	query		string		false	"Created before, RFC 3339"
+//	@Param		updatedFrom	query		string		false	"Updated at or after, RFC 3339"
+//	@Param		updatedTo	query		string		false	"Updated before, RFC 3339"
+//	@Param		minRating	query		number		false	"Minimum rating"
+//	@Success	200			{object}	models.Page[models.Thread]
+//	@Failure	400			{object}	HTTPError
+//	@Failure	404			{object}	HTTPError
+//	@Failure	422			{object}	HTTPError
+//	@Failure	500			{object}	HTTPError
 //	@Router		/v1/threads [get]
 func (h *ForumHandler) GetThreads(c echo.Context) error {
 	var (
 		err     error
-		limit   uint64
 		order   string
-		threads *[]models.Thread
+		threads *models.Page[models.Thread]
+	)
+
+	page, ok, err := parsePageQuery(c, h.logger, h.validator, "GetThreads")
+	if !ok {
+		return err
+	}
+	filter, ok, err := parseListFilter(c, h.logger, h.validator, "GetThreads", threadFilters)
+	if !ok {
+		return err
+	}
+	tags := c.Request().URL.Query()["q"]
+
+	o := c.Request().URL.Query()["o"]
+	if o != nil {
+		err = h.validator.Var(o[0], `oneof=newest-updated highest-rated most-views`)
+		if err != nil {
+			e := HTTPError{
+				Code:    http.StatusUnprocessableEntity,
+				Message: "Error in GetAssets repository: " + err.Error(),
+			}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusUnprocessableEntity, &e)
+		}
+		order = o[0]
+	} else {
+		order = "newest-updated"
+	}
+
+	threads, err = h.repository.FindThreads(tags, filter, page, order)
+	if err != nil {
+		if err == h.repository.NotFoundErr() {
+			e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusNotFound, &e)
+		}
+		if err == h.repository.CursorErr() {
+			e := HTTPError{Code: http.StatusUnprocessableEntity, Message: err.Error()}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusUnprocessableEntity, &e)
+		}
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in FindThreads repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+
+	return c.JSON(http.StatusOK, &threads)
+}
+
+//	@Summary	Fetches the threads of a topic, pinned threads first.
+//	@Tags		Threads
+//	@Produce	application/json
+//	@Param		id	path		int		true	"Get Threads of Topic of ID"
+//	@Param		l	query		int		false	"Record number limit"
+//	@Param		o	query		string	false	"Record ordering of threads that are not pinned. Default newest updated"	Enums(newest-updated, highest-rated, most-views)
+//	@Success	200	{object}	[]models.Thread
+//	@Failure	404	{object}	HTTPError
+//	@Failure	422	{object}	HTTPError
+//	@Failure	500	{object}	HTTPError
+//	@Router		/v1/topics/{id}/threads [get]
+func (h *ForumHandler) GetThreadsByTopicID(c echo.Context) error {
+	var (
+		limit uint64
+		order = "newest-updated"
 	)
 
+	p := c.Param("id")
+	err := h.validator.Var(p, "required,number")
+	if err != nil {
+		e := HTTPError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Error in GetThreadsByTopicID handler: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusUnprocessableEntity, &e)
+	}
+	id, _ := strconv.ParseInt(p, 10, 64)
+
 	l := c.Request().URL.Query()["l"]
 	if l != nil {
 		err = h.validator.Var(l[0], "omitnil,number,min=0")
 		if err != nil {
 			e := HTTPError{
 				Code:    http.StatusUnprocessableEntity,
-				Message: "Error in GetThreads repository: " + err.Error(),
+				Message: "Error in GetThreadsByTopicID handler: " + err.Error(),
 			}
 			h.logger.Print(&e)
 			return c.JSON(http.StatusUnprocessableEntity, &e)
 		}
-		limit, err = strconv.ParseUint(l[0], 10, 64)
+		limit, _ = strconv.ParseUint(l[0], 10, 64)
 	}
-	tags := c.Request().URL.Query()["q"]
 
 	o := c.Request().URL.Query()["o"]
 	if o != nil {
@@ -395,17 +685,15 @@ func (h *ForumHandler) GetThreads(c echo.Context) error {
 		if err != nil {
 			e := HTTPError{
 				Code:    http.StatusUnprocessableEntity,
-				Message: "Error in GetAssets repository: " + err.Error(),
+				Message: "Error in GetThreadsByTopicID handler: " + err.Error(),
 			}
 			h.logger.Print(&e)
 			return c.JSON(http.StatusUnprocessableEntity, &e)
 		}
 		order = o[0]
-	} else {
-		order = "newest-updated"
 	}
 
-	threads, err = h.repository.FindThreads(tags, limit, order)
+	threads, err := h.repository.FindThreadsByTopicID(int(id), limit, order)
 	if err != nil {
 		if err == h.repository.NotFoundErr() {
 			e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
@@ -414,7 +702,7 @@ func (h *ForumHandler) GetThreads(c echo.Context) error {
 		}
 		e := HTTPError{
 			Code:    http.StatusInternalServerError,
-			Message: "Error in FindThreads repository: " + err.Error(),
+			Message: "Error in FindThreadsByTopicID repository: " + err.Error(),
 		}
 		h.logger.Print(&e)
 		return c.JSON(http.StatusInternalServerError, &e)
@@ -470,6 +758,17 @@ func (h *ForumHandler) PatchThread(c echo.Context) error {
 		return c.JSON(http.StatusUnprocessableEntity, &e)
 	}
 
+	var subject models.FilterSubject
+	verdict := &models.FilterVerdict{Action: "allow"}
+	if thread.Title != nil {
+		subject = models.FilterSubject{Kind: "thread", AuthorID: sessionUserID(c), Text: filterText(thread.Title)}
+		verdict, err = checkContent(c, h.logger, h.contentFilter, subject)
+		if verdict == nil {
+			return err
+		}
+	}
+
+	thread.EditedBy = sessionUserID(c)
 	updThread, err := h.repository.UpdateThread(int(id), thread)
 	if err != nil {
 		if err == h.repository.NotFoundErr() {
@@ -487,6 +786,9 @@ func (h *ForumHandler) PatchThread(c echo.Context) error {
 		h.logger.Print(&e)
 		return c.JSON(http.StatusInternalServerError, &e)
 	}
+	if hiddenAt := contentStored(h.logger, h.contentFilter, subject, int(id), *verdict); hiddenAt != nil {
+		updThread.HiddenAt = hiddenAt
+	}
 
 	return c.JSON(http.StatusOK, &updThread)
 }
@@ -497,6 +799,7 @@ func (h *ForumHandler) PatchThread(c echo.Context) error {
 //	@Produce	text/plain
 //	@Param		id	path		int	true	"Delete Thread of ID"
 //	@Success	200	{string}	string
+//	@Failure	401	{object}	HTTPError
 //	@Failure	403	{object}	HTTPError
 //	@Failure	404	{object}	HTTPError
 //	@Failure	422	{object}	HTTPError
@@ -515,7 +818,14 @@ func (h *ForumHandler) DeleteThread(c echo.Context) error {
 	}
 	id, _ := strconv.ParseInt(p, 10, 64)
 
-	err = h.repository.DeleteThread(int(id))
+	userID, ok := c.Get("userID").(uuid.UUID)
+	if !ok {
+		e := HTTPError{Code: http.StatusUnauthorized, Message: "No session provided!"}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusUnauthorized, &e)
+	}
+
+	err = h.repository.DeleteThread(int(id), userID)
 	if err != nil {
 		if err == h.repository.NotFoundErr() {
 			e := HTTPError{
@@ -536,35 +846,250 @@ func (h *ForumHandler) DeleteThread(c echo.Context) error {
 	return c.String(http.StatusOK, "Thread sucessfully deleted!")
 }
 
-//	@Summary	Creates a new message.
-//	@Tags		Messages
-//	@Accept		application/json
-//	@Produce	application/json
-//	@Param		Message	body		models.Message	true	"Create Message"
-//	@Success	201		{object}	models.Message
-//	@Failure	400		{object}	HTTPError
-//	@Failure	403		{object}	HTTPError
-//	@Failure	404		{object}	HTTPError
-//	@Failure	422		{object}	HTTPError
-//	@Failure	500		{object}	HTTPError
-//	@Router		/v1/messages [post]
-func (h *ForumHandler) PostMessage(c echo.Context) error {
-	var message models.Message
-
-	err := c.Bind(&message)
+//	@Summary	Restores the specified thread from the trash.
+//	@Tags		Threads
+//	@Accept		text/plain
+//	@Produce	text/plain
+//	@Param		id	path		int	true	"Restore Thread of ID"
+//	@Success	200	{string}	string
+//	@Failure	401	{object}	HTTPError
+//	@Failure	403	{object}	HTTPError
+//	@Failure	404	{object}	HTTPError
+//	@Failure	409	{object}	HTTPError
+//	@Failure	422	{object}	HTTPError
+//	@Failure	500	{object}	HTTPError
+//	@Router		/v1/threads/{id}/restore [post]
+func (h *ForumHandler) RestoreThread(c echo.Context) error {
+	p := c.Param("id")
+	err := h.validator.Var(p, "required,number")
 	if err != nil {
 		e := HTTPError{
-			Code:    http.StatusBadRequest,
-			Message: "Error in PostMessage handler: " + err.Error(),
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Error in RestoreThread handler: " + err.Error(),
 		}
 		h.logger.Print(&e)
-		return c.JSON(http.StatusBadRequest, &e)
+		return c.JSON(http.StatusUnprocessableEntity, &e)
 	}
-	message.Method = "POST"
+	id, _ := strconv.ParseInt(p, 10, 64)
 
-	err = h.validator.Struct(&message)
-	if err != nil {
-		e := HTTPError{
+	userID, ok := c.Get("userID").(uuid.UUID)
+	if !ok {
+		e := HTTPError{Code: http.StatusUnauthorized, Message: "No session provided!"}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusUnauthorized, &e)
+	}
+
+	err = h.repository.RestoreThread(int(id), restorer(c, userID))
+	if err != nil {
+		if err == h.repository.NotFoundErr() {
+			e := HTTPError{
+				Code:    http.StatusNotFound,
+				Message: "Not Found!",
+			}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusNotFound, &e)
+		}
+		if err == h.repository.TopicTrashedErr() {
+			e := HTTPError{Code: http.StatusConflict, Message: err.Error()}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusConflict, &e)
+		}
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in RestoreThread repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+
+	return c.String(http.StatusOK, "Thread successfully restored!")
+}
+
+//	@Summary		Pins, locks, moves, merges or splits a thread.
+//	@Description	Threads are managed by moderators and by the owner of their topic. The operation must name
+//	@Description	the current version of the thread and increments it. Merging moves all messages into the
+//	@Description	target thread and moves the merged thread to the trash, demo threads can only be merged into.
+//	@Description	Splitting moves the messages into a new thread in the same topic.
+//	@Tags			Threads
+//	@Accept			application/json
+//	@Produce		application/json
+//	@Param			id				path		int						true	"Manage Thread of ID"
+//	@Param			ThreadOperation	body		models.ThreadOperation	true	"Thread Operation"
+//	@Success		201				{object}	models.ThreadOperation
+//	@Failure		400				{object}	HTTPError
+//	@Failure		401				{object}	HTTPError
+//	@Failure		403				{object}	HTTPError
+//	@Failure		404				{object}	HTTPError
+//	@Failure		409				{object}	HTTPError
+//	@Failure		422				{object}	HTTPError
+//	@Failure		500				{object}	HTTPError
+//	@Router			/v1/threads/{id}/operations [post]
+func (h *ForumHandler) PostThreadOperation(c echo.Context) error {
+	var (
+		op        models.ThreadOperation
+		managerID *uuid.UUID
+	)
+
+	p := c.Param("id")
+	err := h.validator.Var(p, "required,number")
+	if err != nil {
+		e := HTTPError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Error in PostThreadOperation handler: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusUnprocessableEntity, &e)
+	}
+	id, _ := strconv.ParseInt(p, 10, 64)
+
+	err = c.Bind(&op)
+	if err != nil {
+		e := HTTPError{
+			Code:    http.StatusBadRequest,
+			Message: "Error in PostThreadOperation handler: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusBadRequest, &e)
+	}
+
+	err = h.validator.Struct(&op)
+	if err != nil {
+		e := HTTPError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Error in PostThreadOperation handler: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusUnprocessableEntity, &e)
+	}
+
+	userID, ok := c.Get("userID").(uuid.UUID)
+	if !ok {
+		e := HTTPError{Code: http.StatusUnauthorized, Message: "No session provided!"}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusUnauthorized, &e)
+	}
+	op.UserID = &userID
+	// Moderators manage threads in every topic, other users only in topics they own
+	if !isModerator(c) {
+		managerID = &userID
+	}
+
+	var subject models.FilterSubject
+	verdict := &models.FilterVerdict{Action: "allow"}
+	if *op.Operation == "split" {
+		subject = models.FilterSubject{Kind: "thread", AuthorID: &userID, Text: filterText(op.Title)}
+		verdict, err = checkContent(c, h.logger, h.contentFilter, subject)
+		if verdict == nil {
+			return err
+		}
+	}
+
+	newOp, err := h.repository.CreateThreadOperation(int(id), op, managerID)
+	if err != nil {
+		switch err {
+		case h.repository.NotFoundErr():
+			e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusNotFound, &e)
+		case h.repository.ThreadForbiddenErr():
+			e := HTTPError{Code: http.StatusForbidden, Message: err.Error()}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusForbidden, &e)
+		case h.repository.ConflictErr():
+			e := HTTPError{
+				Code:    http.StatusConflict,
+				Message: "Error: unable to update the record due to an edit conflict, please try again!",
+			}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusConflict, &e)
+		case h.repository.ThreadOperationErr(), h.repository.DemoThreadErr():
+			e := HTTPError{Code: http.StatusUnprocessableEntity, Message: err.Error()}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusUnprocessableEntity, &e)
+		}
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in CreateThreadOperation repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	if *newOp.Operation == "split" {
+		contentStored(h.logger, h.contentFilter, subject, *newOp.TargetThreadID, *verdict)
+	}
+
+	return c.JSON(http.StatusCreated, &newOp)
+}
+
+//	@Summary	Fetches the operations on a thread, including merges into it and splits from it.
+//	@Tags		Threads
+//	@Produce	application/json
+//	@Param		id	path		int	true	"Get Operations on Thread of ID"
+//	@Success	200	{object}	[]models.ThreadOperation
+//	@Failure	404	{object}	HTTPError
+//	@Failure	422	{object}	HTTPError
+//	@Failure	500	{object}	HTTPError
+//	@Router		/v1/threads/{id}/operations [get]
+func (h *ForumHandler) GetThreadOperations(c echo.Context) error {
+	p := c.Param("id")
+	err := h.validator.Var(p, "required,number")
+	if err != nil {
+		e := HTTPError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Error in GetThreadOperations handler: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusUnprocessableEntity, &e)
+	}
+	id, _ := strconv.ParseInt(p, 10, 64)
+
+	ops, err := h.repository.FindThreadOperations(int(id))
+	if err != nil {
+		if err == h.repository.NotFoundErr() {
+			e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusNotFound, &e)
+		}
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in FindThreadOperations repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+
+	return c.JSON(http.StatusOK, &ops)
+}
+
+//	@Summary	Creates a new message.
+//	@Tags		Messages
+//	@Accept		application/json
+//	@Produce	application/json
+//	@Param		Message	body		models.Message	true	"Create Message"
+//	@Success	201		{object}	models.Message
+//	@Failure	400		{object}	HTTPError
+//	@Failure	403		{object}	HTTPError
+//	@Failure	404		{object}	HTTPError
+//	@Failure	422		{object}	HTTPError
+//	@Failure	500		{object}	HTTPError
+//	@Router		/v1/messages [post]
+func (h *ForumHandler) PostMessage(c echo.Context) error {
+	var message models.Message
+
+	err := c.Bind(&message)
+	if err != nil {
+		e := HTTPError{
+			Code:    http.StatusBadRequest,
+			Message: "Error in PostMessage handler: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusBadRequest, &e)
+	}
+	message.Method = "POST"
+
+	err = h.validator.Struct(&message)
+	if err != nil {
+		e := HTTPError{
 			Code:    http.StatusUnprocessableEntity,
 			Message: "Error in PostMessage handler: " + err.Error(),
 		}
@@ -572,8 +1097,54 @@ func (h *ForumHandler) PostMessage(c echo.Context) error {
 		return c.JSON(http.StatusUnprocessableEntity, &e)
 	}
 
+	if !isModerator(c) {
+		err = h.repository.CheckMessageRules(*message.ThreadID, *message.UserID)
+		if err != nil {
+			switch err {
+			case h.repository.NotFoundErr():
+				e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
+				h.logger.Print(&e)
+				return c.JSON(http.StatusNotFound, &e)
+			case h.repository.ThreadAccessErr(), h.repository.AccountAgeErr():
+				e := HTTPError{Code: http.StatusForbidden, Message: err.Error()}
+				h.logger.Print(&e)
+				return c.JSON(http.StatusForbidden, &e)
+			}
+			e := HTTPError{
+				Code:    http.StatusInternalServerError,
+				Message: "Error in CheckMessageRules repository: " + err.Error(),
+			}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusInternalServerError, &e)
+		}
+	}
+
+	subject := models.FilterSubject{Kind: "message", AuthorID: message.UserID, Text: filterText(message.Title, message.Body)}
+	verdict, err := checkContent(c, h.logger, h.contentFilter, subject)
+	if verdict == nil {
+		return err
+	}
+	if ok, err := renderMarkdown(c, h.logger, h.markdown, message.Body, &message.BodyHTML); !ok {
+		return err
+	}
+
 	newMessage, err := h.repository.CreateMessage(message)
 	if err != nil {
+		if err == h.repository.NotFoundErr() {
+			e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusNotFound, &e)
+		}
+		if err == h.repository.ThreadLockedErr() {
+			e := HTTPError{Code: http.StatusForbidden, Message: err.Error()}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusForbidden, &e)
+		}
+		if err == h.repository.ReplyErr() || err == h.repository.QuoteErr() || err == h.repository.MentionsErr() {
+			e := HTTPError{Code: http.StatusUnprocessableEntity, Message: err.Error()}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusUnprocessableEntity, &e)
+		}
 		e := HTTPError{
 			Code:    http.StatusInternalServerError,
 			Message: "Error in CreateMessage repository: " + err.Error(),
@@ -581,6 +1152,9 @@ func (h *ForumHandler) PostMessage(c echo.Context) error {
 		h.logger.Print(&e)
 		return c.JSON(http.StatusInternalServerError, &e)
 	}
+	if hiddenAt := contentStored(h.logger, h.contentFilter, subject, *newMessage.ID, *verdict); hiddenAt != nil {
+		newMessage.HiddenAt = hiddenAt
+	}
 
 	return c.JSON(http.StatusCreated, &newMessage)
 }
@@ -623,41 +1197,79 @@ func (h *ForumHandler) GetMessageByID(c echo.Context) error {
 		h.logger.Print(&e)
 		return c.JSON(http.StatusInternalServerError, &e)
 	}
+	if message.HiddenAt != nil {
+		visible, err := canViewHiddenPost(c, h.repository, message.UserID, "message", int(id))
+		if err != nil {
+			e := HTTPError{
+				Code:    http.StatusInternalServerError,
+				Message: "Error in ModeratesContent repository: " + err.Error(),
+			}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusInternalServerError, &e)
+		}
+		if !visible {
+			e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusNotFound, &e)
+		}
+	}
+
+	visible, err := canViewThreadMessages(c, h.repository, *message.ThreadID)
+	if err != nil {
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in FindThreadVisibility repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	if !visible {
+		e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusNotFound, &e)
+	}
 
 	return c.JSON(http.StatusOK, &message)
 }
 
+// Messages of hidden threads are shown to those who may see the thread, messages of deleted ones to no one
+func canViewThreadMessages(c echo.Context, r ForumRepository, threadID int) (bool, error) {
+	thread, err := r.FindThreadVisibility(threadID)
+	if err == r.NotFoundErr() {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if thread.HiddenAt == nil {
+		return true, nil
+	}
+	return canViewHiddenPost(c, r, thread.UserID, "thread", threadID)
+}
+
 //	@Summary	Fetches all messages.
 //	@Tags		Messages
 //	@Produce	application/json
 //	@Param		q	query		[]string	false	"Keyword Query"
-//	@Param		l	query		int			false	"Record number limit"
+//	@Param		l	query		int			false	"Page size, at most 100. Default 20"
+//	@Param		c	query		string		false	"Page cursor from the next or prev of a previous page"
 //	@Param		o	query		string		false	"Record ordering. Default newest updated"	Enums(newest-updated, highest-rated, most-views)
-//	@Success	200	{object}	models.Message
+//	@Success	200	{object}	models.Page[models.Message]
 //	@Failure	400	{object}	HTTPError
 //	@Failure	404	{object}	HTTPError
+//	@Failure	422	{object}	HTTPError
 //	@Failure	500	{object}	HTTPError
 //	@Router		/v1/messages [get]
 func (h *ForumHandler) GetMessages(c echo.Context) error {
 	var (
 		err      error
-		limit    uint64
 		order    string
-		messages *[]models.Message
+		messages *models.Page[models.Message]
 	)
 
-	l := c.Request().URL.Query()["l"]
-	if l != nil {
-		err = h.validator.Var(l[0], "omitnil,number,min=0")
-		if err != nil {
-			e := HTTPError{
-				Code:    http.StatusUnprocessableEntity,
-				Message: "Error in GetMessages repository: " + err.Error(),
-			}
-			h.logger.Print(&e)
-			return c.JSON(http.StatusUnprocessableEntity, &e)
-		}
-		limit, err = strconv.ParseUint(l[0], 10, 64)
+	page, ok, err := parsePageQuery(c, h.logger, h.validator, "GetMessages")
+	if !ok {
+		return err
 	}
 	tags := c.Request().URL.Query()["q"]
 
@@ -677,13 +1289,18 @@ func (h *ForumHandler) GetMessages(c echo.Context) error {
 		order = "newest-updated"
 	}
 
-	messages, err = h.repository.FindMessages(tags, limit, order)
+	messages, err = h.repository.FindMessages(tags, page, order)
 	if err != nil {
 		if err == h.repository.NotFoundErr() {
 			e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
 			h.logger.Print(&e)
 			return c.JSON(http.StatusNotFound, &e)
 		}
+		if err == h.repository.CursorErr() {
+			e := HTTPError{Code: http.StatusUnprocessableEntity, Message: err.Error()}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusUnprocessableEntity, &e)
+		}
 		e := HTTPError{
 			Code:    http.StatusInternalServerError,
 			Message: "Error in FindMessages repository: " + err.Error(),
@@ -695,16 +1312,23 @@ func (h *ForumHandler) GetMessages(c echo.Context) error {
 	return c.JSON(http.StatusOK, &messages)
 }
 
-//	@Summary	Fetches all messages in the thread of ID.
-//	@Tags		Messages
-//	@Accept		text/plain
-//	@Produce	application/json
-//	@Param		threadID	path		int	true	"Get Messages of Thread ID"
-//	@Success	200			{object}	models.Message
-//	@Failure	400			{object}	HTTPError
-//	@Failure	404			{object}	HTTPError
-//	@Failure	500			{object}	HTTPError
-//	@Router		/v1/messages/thread/{threadID} [get]
+//	@Summary		Fetches all messages in the thread of ID.
+//	@Description	Flat mode lists messages oldest first, replies carry the context of their parent.
+//	@Description	Nested mode lists top-level messages with their replies nested under them,
+//	@Description	deleted messages with replies are kept as tombstones. Nested pages count top-level messages.
+//	@Tags			Messages
+//	@Accept			text/plain
+//	@Produce		application/json
+//	@Param			threadID	path		int		true	"Get Messages of Thread ID"
+//	@Param			mode		query		string	false	"Listing mode. Default flat"	Enums(flat, nested)
+//	@Param			l			query		int		false	"Page size, at most 100. Default 20"
+//	@Param			c			query		string	false	"Page cursor from the next or prev of a previous page"
+//	@Success		200			{object}	models.Page[models.Message]
+//	@Failure		400			{object}	HTTPError
+//	@Failure		404			{object}	HTTPError
+//	@Failure		422			{object}	HTTPError
+//	@Failure		500			{object}	HTTPError
+//	@Router			/v1/messages/thread/{threadID} [get]
 func (h *ForumHandler) GetMessagesByThreadID(c echo.Context) error {
 	p := c.Param("threadID")
 	err := h.validator.Var(p, "required,number")
@@ -718,13 +1342,49 @@ func (h *ForumHandler) GetMessagesByThreadID(c echo.Context) error {
 	}
 	threadID, _ := strconv.ParseInt(p, 10, 64)
 
-	messages, err := h.repository.FindMessagesByThreadID(int(threadID))
+	mode := c.QueryParam("mode")
+	err = h.validator.Var(mode, "omitempty,oneof=flat nested")
+	if err != nil {
+		e := HTTPError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Error in GetMessagesByThreadID handler: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusUnprocessableEntity, &e)
+	}
+
+	page, ok, err := parsePageQuery(c, h.logger, h.validator, "GetMessagesByThreadID")
+	if !ok {
+		return err
+	}
+
+	visible, err := canViewThreadMessages(c, h.repository, int(threadID))
+	if err != nil {
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in FindThreadVisibility repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	if !visible {
+		e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusNotFound, &e)
+	}
+
+	messages, err := h.repository.FindMessagesByThreadID(int(threadID), mode == "nested", page)
 	if err != nil {
 		if err == h.repository.NotFoundErr() {
 			e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
 			h.logger.Print(&e)
 			return c.JSON(http.StatusNotFound, &e)
 		}
+		if err == h.repository.CursorErr() {
+			e := HTTPError{Code: http.StatusUnprocessableEntity, Message: err.Error()}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusUnprocessableEntity, &e)
+		}
 		e := HTTPError{
 			Code:    http.StatusInternalServerError,
 			Message: "Error in FindMessagesByThreadID repository: " + err.Error(),
@@ -736,6 +1396,63 @@ func (h *ForumHandler) GetMessagesByThreadID(c echo.Context) error {
 	return c.JSON(http.StatusOK, &messages)
 }
 
+//	@Summary	Fetches the messages mentioning the user of ID, newest mention first.
+//	@Tags		Messages
+//	@Produce	application/json
+//	@Param		id	path		string	true	"Get Mentions of User ID"
+//	@Param		l	query		int		false	"Record number limit"
+//	@Success	200	{object}	models.Message
+//	@Failure	404	{object}	HTTPError
+//	@Failure	422	{object}	HTTPError
+//	@Failure	500	{object}	HTTPError
+//	@Router		/v1/users/{id}/mentions [get]
+func (h *ForumHandler) GetMentions(c echo.Context) error {
+	var limit uint64
+
+	p := c.Param("id")
+	err := h.validator.Var(p, "required,uuid")
+	if err != nil {
+		e := HTTPError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Error in GetMentions handler: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusUnprocessableEntity, &e)
+	}
+	userID, _ := uuid.Parse(p)
+
+	l := c.Request().URL.Query()["l"]
+	if l != nil {
+		err = h.validator.Var(l[0], "omitnil,number,min=0")
+		if err != nil {
+			e := HTTPError{
+				Code:    http.StatusUnprocessableEntity,
+				Message: "Error in GetMentions handler: " + err.Error(),
+			}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusUnprocessableEntity, &e)
+		}
+		limit, _ = strconv.ParseUint(l[0], 10, 64)
+	}
+
+	messages, err := h.repository.FindMentions(userID, limit)
+	if err != nil {
+		if err == h.repository.NotFoundErr() {
+			e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusNotFound, &e)
+		}
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in FindMentions repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+
+	return c.JSON(http.StatusOK, &messages)
+}
+
 //	@Summary	Updates an message.
 //	@Tags		Messages
 //	@Accept		application/json
@@ -783,6 +1500,20 @@ func (h *ForumHandler) PatchMessage(c echo.Context) error {
 		return c.JSON(http.StatusUnprocessableEntity, &e)
 	}
 
+	var subject models.FilterSubject
+	verdict := &models.FilterVerdict{Action: "allow"}
+	if message.Title != nil || message.Body != nil {
+		subject = models.FilterSubject{Kind: "message", AuthorID: sessionUserID(c), Text: filterText(message.Title, message.Body)}
+		verdict, err = checkContent(c, h.logger, h.contentFilter, subject)
+		if verdict == nil {
+			return err
+		}
+	}
+	if ok, err := renderMarkdown(c, h.logger, h.markdown, message.Body, &message.BodyHTML); !ok {
+		return err
+	}
+
+	message.EditedBy = sessionUserID(c)
 	updMessage, err := h.repository.UpdateMessage(int(id), message)
 	if err != nil {
 		if err == h.repository.NotFoundErr() {
@@ -793,6 +1524,11 @@ func (h *ForumHandler) PatchMessage(c echo.Context) error {
 			h.logger.Print(&e)
 			return c.JSON(http.StatusNotFound, &e)
 		}
+		if err == h.repository.MentionsErr() {
+			e := HTTPError{Code: http.StatusUnprocessableEntity, Message: err.Error()}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusUnprocessableEntity, &e)
+		}
 		e := HTTPError{
 			Code:    http.StatusInternalServerError,
 			Message: "Error in UpdateMessage repository: " + err.Error(),
@@ -800,6 +1536,9 @@ func (h *ForumHandler) PatchMessage(c echo.Context) error {
 		h.logger.Print(&e)
 		return c.JSON(http.StatusInternalServerError, &e)
 	}
+	if hiddenAt := contentStored(h.logger, h.contentFilter, subject, int(id), *verdict); hiddenAt != nil {
+		updMessage.HiddenAt = hiddenAt
+	}
 
 	return c.JSON(http.StatusOK, &updMessage)
 }
@@ -810,6 +1549,7 @@ func (h *ForumHandler) PatchMessage(c echo.Context) error {
 //	@Produce	text/plain
 //	@Param		id	path		int	true	"Delete Message of ID"
 //	@Success	200	{string}	string
+//	@Failure	401	{object}	HTTPError
 //	@Failure	403	{object}	HTTPError
 //	@Failure	404	{object}	HTTPError
 //	@Failure	422	{object}	HTTPError
@@ -828,7 +1568,14 @@ func (h *ForumHandler) DeleteMessage(c echo.Context) error {
 	}
 	id, _ := strconv.ParseInt(p, 10, 64)
 
-	err = h.repository.DeleteMessage(int(id))
+	userID, ok := c.Get("userID").(uuid.UUID)
+	if !ok {
+		e := HTTPError{Code: http.StatusUnauthorized, Message: "No session provided!"}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusUnauthorized, &e)
+	}
+
+	err = h.repository.DeleteMessage(int(id), userID)
 	if err != nil {
 		if err == h.repository.NotFoundErr() {
 			e := HTTPError{
@@ -848,3 +1595,62 @@ func (h *ForumHandler) DeleteMessage(c echo.Context) error {
 
 	return c.String(http.StatusOK, "Message sucessfully deleted!")
 }
+
+//	@Summary	Restores the specified message from the trash.
+//	@Tags		Messages
+//	@Accept		text/plain
+//	@Produce	text/plain
+//	@Param		id	path		int	true	"Restore Message of ID"
+//	@Success	200	{string}	string
+//	@Failure	401	{object}	HTTPError
+//	@Failure	403	{object}	HTTPError
+//	@Failure	404	{object}	HTTPError
+//	@Failure	409	{object}	HTTPError
+//	@Failure	422	{object}	HTTPError
+//	@Failure	500	{object}	HTTPError
+//	@Router		/v1/messages/{id}/restore [post]
+func (h *ForumHandler) RestoreMessage(c echo.Context) error {
+	p := c.Param("id")
+	err := h.validator.Var(p, "required,number")
+	if err != nil {
+		e := HTTPError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Error in RestoreMessage handler: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusUnprocessableEntity, &e)
+	}
+	id, _ := strconv.ParseInt(p, 10, 64)
+
+	userID, ok := c.Get("userID").(uuid.UUID)
+	if !ok {
+		e := HTTPError{Code: http.StatusUnauthorized, Message: "No session provided!"}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusUnauthorized, &e)
+	}
+
+	err = h.repository.RestoreMessage(int(id), restorer(c, userID))
+	if err != nil {
+		if err == h.repository.NotFoundErr() {
+			e := HTTPError{
+				Code:    http.StatusNotFound,
+				Message: "Not Found!",
+			}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusNotFound, &e)
+		}
+		if err == h.repository.ThreadTrashedErr() {
+			e := HTTPError{Code: http.StatusConflict, Message: err.Error()}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusConflict, &e)
+		}
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in RestoreMessage repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+
+	return c.String(http.StatusOK, "Message successfully restored!")
+}
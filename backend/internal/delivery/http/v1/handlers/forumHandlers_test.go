@@ -4,11 +4,14 @@ import (
 	"errors"
 	"fmt"
 	"gamehangar/internal/domain/models"
+	"html"
 	"net/http"
 	"net/http/httptest"
 	"slices"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	// "github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
@@ -17,21 +20,65 @@ import (
 )
 
 type mockForumRepo struct {
-	topicData   map[int]models.Topic
-	threadData  map[int]models.Thread
-	messageData map[int]models.Message
-	notFoundErr error
-	conflictErr error
+	topicData       map[int]models.Topic
+	threadData      map[int]models.Thread
+	messageData     map[int]models.Message
+	deletedTopic    map[int]models.Topic
+	deletedThread   map[int]models.Thread
+	deletedMessage  map[int]models.Message
+	notFoundErr     error
+	cursorErr       error
+	conflictErr     error
+	threadLockedErr error
+	replyErr        error
+	quoteErr        error
+	mentionsErr     error
+
+	threadForbiddenErr error
+	threadOperationErr error
+	demoThreadErr      error
+
+	slugConflictErr  error
+	parentErr        error
+	childrenErr      error
+	threadAccessErr  error
+	accountAgeErr    error
+	topicTrashedErr  error
+	threadTrashedErr error
 }
 
+// Wraps the escaped source in a paragraph
+type mockMarkdownRenderer struct{}
+
 var (
 	// v = validator.New(validator.WithRequiredStructEnabled())
-	mf = mockForumRepo{
-		topicData:   make(map[int]models.Topic, 1),
-		threadData:  make(map[int]models.Thread, 1),
-		messageData: make(map[int]models.Message, 1),
-		notFoundErr: errors.New("Not Found"),
-		conflictErr: errors.New("Record conflict!"),
+	mmd = mockMarkdownRenderer{}
+	mf  = mockForumRepo{
+		topicData:       make(map[int]models.Topic, 1),
+		threadData:      make(map[int]models.Thread, 1),
+		messageData:     make(map[int]models.Message, 1),
+		deletedTopic:    make(map[int]models.Topic, 1),
+		deletedThread:   make(map[int]models.Thread, 1),
+		deletedMessage:  make(map[int]models.Message, 1),
+		notFoundErr:     errors.New("Not Found"),
+		cursorErr:       errors.New("Cursor is not valid!"),
+		conflictErr:     errors.New("Record conflict!"),
+		threadLockedErr: errors.New("Thread is locked!"),
+		replyErr:        errors.New("Replied message is not in this thread!"),
+		quoteErr:        errors.New("Quote is not part of the replied message!"),
+		mentionsErr:     errors.New("Messages can mention at most 10 users!"),
+
+		threadForbiddenErr: errors.New("Only moderators and the topic owner can manage this thread!"),
+		threadOperationErr: errors.New("Operation is not valid for this thread!"),
+		demoThreadErr:      errors.New("Demo threads cannot be merged into other threads!"),
+
+		slugConflictErr:  errors.New("Category slug is already taken!"),
+		parentErr:        errors.New("Parent category is not valid!"),
+		childrenErr:      errors.New("Category has subcategories!"),
+		threadAccessErr:  errors.New("Creating threads in this category is restricted!"),
+		accountAgeErr:    errors.New("Account is too new to post in this category!"),
+		topicTrashedErr:  errors.New("Topic of the thread is in the trash, restore it first!"),
+		threadTrashedErr: errors.New("Thread of the message is in the trash, restore it first!"),
 	}
 
 	genericUUID uuid.UUID = uuid.New()
@@ -52,22 +99,25 @@ var (
 
 	threadJSON                   = `{"title":"Cool Thread","userID":"` + genericUUID.String() + `","topicID":1}`
 	threadJSONExpected           = `{"id":1,"title":"Cool Thread","userID":"` + genericUUID.String() + `","topicID":1}` + "\n"
-	threadJSONExpectedMany       = `[{"id":1,"title":"Cool Thread","userID":"` + genericUUID.String() + `","topicID":1}]` + "\n"
-	threadJSONQueryExpected      = `[{"id":1,"title":"cheeseboiger","userID":"` + genericUUID.String() + `","topicID":2,"tags":null},{"id":2,"title":"thread two","userID":"` + genericUUID.String() + `","topicID":2,"tags":["cheeseboiger"]}]` + "\n"
-	threadJSONQueryExpectedLimit = `[{"id":1,"title":"cheeseboiger","userID":"` + genericUUID.String() + `","topicID":2,"tags":null}]` + "\n"
+	threadJSONExpectedMany       = `{"items":[{"id":1,"title":"Cool Thread","userID":"` + genericUUID.String() + `","topicID":1}],"total":1}` + "\n"
+	threadJSONQueryExpected      = `{"items":[{"id":1,"title":"cheeseboiger","userID":"` + genericUUID.String() + `","topicID":2,"tags":null},{"id":2,"title":"thread two","userID":"` + genericUUID.String() + `","topicID":2,"tags":["cheeseboiger"]}],"total":2}` + "\n"
+	threadJSONQueryExpectedLimit = `{"items":[{"id":1,"title":"cheeseboiger","userID":"` + genericUUID.String() + `","topicID":2,"tags":null}],"total":2}` + "\n"
 	threadJSONUpdate             = `{"title":"Updated cool Thread"}`
 	threadJSONUpdateExpected     = `{"id":1,"title":"Updated cool Thread","userID":"` + genericUUID.String() + `","topicID":1}` + "\n"
 
 	messageJSON                   = `{"title":"Cool message","userID":"` + genericUUID.String() + `","threadID":1}`
 	messageJSONExpected           = `{"id":1,"threadID":1,"userID":"` + genericUUID.String() + `","title":"Cool message"}` + "\n"
-	messageJSONExpectedMany       = `[{"id":1,"threadID":1,"userID":"` + genericUUID.String() + `","title":"Cool message"}]` + "\n"
-	messageJSONQueryExpected      = `[{"id":1,"threadID":2,"userID":"` + genericUUID.String() + `","title":"cheeseboiger","tags":null},{"id":2,"threadID":2,"userID":"` + genericUUID.String() + `","title":"message two","tags":["cheeseboiger"]}]` + "\n"
-	messageJSONQueryExpectedLimit = `[{"id":1,"threadID":2,"userID":"` + genericUUID.String() + `","title":"cheeseboiger","tags":null}]` + "\n"
+	messageJSONExpectedMany       = `{"items":[{"id":1,"threadID":1,"userID":"` + genericUUID.String() + `","title":"Cool message"}],"total":1}` + "\n"
+	messageJSONQueryExpected      = `{"items":[{"id":1,"threadID":2,"userID":"` + genericUUID.String() + `","title":"cheeseboiger","tags":null},{"id":2,"threadID":2,"userID":"` + genericUUID.String() + `","title":"message two","tags":["cheeseboiger"]}],"total":2}` + "\n"
+	messageJSONQueryExpectedLimit = `{"items":[{"id":1,"threadID":2,"userID":"` + genericUUID.String() + `","title":"cheeseboiger","tags":null}],"total":2}` + "\n"
 	messageJSONUpdate             = `{"title":"Updated cool message"}`
 	messageJSONUpdateExpected     = `{"id":1,"threadID":1,"userID":"` + genericUUID.String() + `","title":"Updated cool message"}` + "\n"
 )
 
 func (r *mockForumRepo) CreateTopic(topic models.Topic) (*models.Topic, error) {
+	if topic.Slug != nil && *topic.Slug == "taken" {
+		return nil, r.SlugConflictErr()
+	}
 	id := 1
 	topic.ID = &id
 	version := 1
@@ -86,9 +136,20 @@ func (r *mockForumRepo) FindTopicByID(id int) (*models.Topic, error) {
 	}
 	return &topic, nil
 }
-func (r *mockForumRepo) FindTopics() (*[]models.Topic, error) {
+func (r *mockForumRepo) FindTopicBySlug(slug string) (*models.Topic, error) {
+	for _, topic := range r.topicData {
+		if topic.Slug != nil && *topic.Slug == slug {
+			return &topic, nil
+		}
+	}
+	return nil, r.NotFoundErr()
+}
+func (r *mockForumRepo) FindTopics(nested bool) (*[]models.Topic, error) {
 	var t []models.Topic
 	for _, v := range r.topicData {
+		if nested && v.ParentID != nil {
+			continue
+		}
 		t = append(t, v)
 	}
 	return &t, nil
@@ -112,14 +173,47 @@ func (r *mockForumRepo) UpdateTopic(id int, topic models.Topic) (*models.Topic,
 	resultTopic = r.topicData[id]
 	return &resultTopic, nil
 }
-func (r *mockForumRepo) DeleteTopic(id int) error {
-	_, ok := r.topicData[id]
+func (r *mockForumRepo) DeleteTopic(id int, deletedBy uuid.UUID) error {
+	x, ok := r.topicData[id]
 	if !ok {
 		return r.NotFoundErr()
 	}
+	r.deletedTopic[id] = x
 	delete(r.topicData, id)
 	return nil
 }
+func (r *mockForumRepo) RestoreTopic(id int, restoredBy *uuid.UUID) error {
+	x, ok := r.deletedTopic[id]
+	if !ok {
+		return r.NotFoundErr()
+	}
+	r.topicData[id] = x
+	delete(r.deletedTopic, id)
+	return nil
+}
+
+// Topics missing from topicData have no posting rules
+func (r *mockForumRepo) CheckThreadRules(topicID int, userID uuid.UUID) error {
+	topic, ok := r.topicData[topicID]
+	if ok && topic.ThreadAccess != nil && *topic.ThreadAccess == "moderators" {
+		return r.ThreadAccessErr()
+	}
+	if ok && topic.MinAccountAgeDays != nil && *topic.MinAccountAgeDays > 0 {
+		return r.AccountAgeErr()
+	}
+	return nil
+}
+func (r *mockForumRepo) CheckMessageRules(threadID int, userID uuid.UUID) error {
+	thread, ok := r.threadData[threadID]
+	if !ok || thread.TopicID == nil {
+		return nil
+	}
+	topic, ok := r.topicData[*thread.TopicID]
+	if ok && topic.MinAccountAgeDays != nil && *topic.MinAccountAgeDays > 0 {
+		return r.AccountAgeErr()
+	}
+	return nil
+}
 
 func (r *mockForumRepo) CreateThread(thread models.Thread) (*models.Thread, error) {
 	id := 1
@@ -131,7 +225,7 @@ func (r *mockForumRepo) CreateThread(thread models.Thread) (*models.Thread, erro
 	}
 	return &resultThread, nil
 }
-func (r *mockForumRepo) FindThreads(query []string, limit uint64, order string) (*[]models.Thread, error) {
+func (r *mockForumRepo) FindThreads(query []string, filter models.ListFilter, page models.PageQuery, order string) (*models.Page[models.Thread], error) {
 	var (
 		topicID      int             = 2
 		threadIDs    []int           = []int{1, 2, 3}
@@ -158,10 +252,11 @@ func (r *mockForumRepo) FindThreads(query []string, limit uint64, order string)
 			resultThreads = append(resultThreads, v)
 		}
 	}
-	if limit != 0 {
-		resultThreads = resultThreads[:limit]
+	total := int64(len(resultThreads))
+	if page.Limit != 0 && int(page.Limit) < len(resultThreads) {
+		resultThreads = resultThreads[:page.Limit]
 	}
-	return &resultThreads, nil
+	return &models.Page[models.Thread]{Items: resultThreads, Total: total}, nil
 }
 func (r *mockForumRepo) FindThreadByID(id int) (*models.Thread, error) {
 	thread, ok := r.threadData[id]
@@ -170,6 +265,13 @@ func (r *mockForumRepo) FindThreadByID(id int) (*models.Thread, error) {
 	}
 	return &thread, nil
 }
+func (r *mockForumRepo) FindThreadVisibility(id int) (*models.Thread, error) {
+	thread, ok := r.threadData[id]
+	if !ok { // Threads the mock does not hold are visible
+		return &models.Thread{ID: &id}, nil
+	}
+	return &thread, nil
+}
 func (r *mockForumRepo) UpdateThread(id int, thread models.Thread) (*models.Thread, error) {
 	var resultThread models.Thread
 	_, ok := r.threadData[id]
@@ -184,16 +286,113 @@ func (r *mockForumRepo) UpdateThread(id int, thread models.Thread) (*models.Thre
 	resultThread = r.threadData[id]
 	return &resultThread, nil
 }
-func (r *mockForumRepo) DeleteThread(id int) error {
-	_, ok := r.threadData[id]
+func (r *mockForumRepo) DeleteThread(id int, deletedBy uuid.UUID) error {
+	x, ok := r.threadData[id]
 	if !ok {
 		return r.NotFoundErr()
 	}
+	r.deletedThread[id] = x
 	delete(r.threadData, id)
 	return nil
 }
+func (r *mockForumRepo) RestoreThread(id int, restoredBy *uuid.UUID) error {
+	x, ok := r.deletedThread[id]
+	if !ok {
+		return r.NotFoundErr()
+	}
+	if x.TopicID != nil {
+		if _, ok := r.deletedTopic[*x.TopicID]; ok {
+			return r.TopicTrashedErr()
+		}
+	}
+	r.threadData[id] = x
+	delete(r.deletedThread, id)
+	return nil
+}
+func (r *mockForumRepo) FindThreadsByTopicID(topicID int, limit uint64, order string) (*[]models.Thread, error) {
+	var pinned, rest []models.Thread
+	for _, t := range r.threadData {
+		if t.TopicID == nil || *t.TopicID != topicID {
+			continue
+		}
+		if t.PinnedAt != nil {
+			pinned = append(pinned, t)
+		} else {
+			rest = append(rest, t)
+		}
+	}
+	threads := append(pinned, rest...)
+	if len(threads) == 0 {
+		return nil, r.NotFoundErr()
+	}
+	if limit != 0 && int(limit) < len(threads) {
+		threads = threads[:limit]
+	}
+	return &threads, nil
+}
+func (r *mockForumRepo) CreateThreadOperation(id int, op models.ThreadOperation, managerID *uuid.UUID) (*models.ThreadOperation, error) {
+	thread, ok := r.threadData[id]
+	if !ok {
+		return nil, r.NotFoundErr()
+	}
+	if owner := r.topicData[*thread.TopicID].UserID; managerID != nil && (owner == nil || *owner != *managerID) {
+		return nil, r.threadForbiddenErr
+	}
+	version := 1
+	if thread.Version != nil {
+		version = *thread.Version
+	}
+	if version != *op.Version {
+		return nil, r.conflictErr
+	}
+	now := time.Now()
+	switch *op.Operation {
+	case "pin":
+		thread.PinnedAt = &now
+	case "lock":
+		thread.LockedAt = &now
+	case "merge":
+		if *op.TargetThreadID == id {
+			return nil, r.threadOperationErr
+		}
+	}
+	version++
+	thread.Version = &version
+	r.threadData[id] = thread
+	op.ThreadID, op.Version = &id, &version
+	return &op, nil
+}
+func (r *mockForumRepo) FindThreadOperations(threadID int) (*[]models.ThreadOperation, error) {
+	return nil, r.NotFoundErr()
+}
 
 func (r *mockForumRepo) CreateMessage(message models.Message) (*models.Message, error) {
+	if thread, ok := r.threadData[*message.ThreadID]; ok && thread.LockedAt != nil {
+		return nil, r.ThreadLockedErr()
+	}
+	if message.ReplyTo != nil {
+		parent, ok := r.messageData[*message.ReplyTo]
+		if !ok || *parent.ThreadID != *message.ThreadID {
+			return nil, r.ReplyErr()
+		}
+		if message.Quote != nil && !strings.Contains(*parent.Body, *message.Quote) {
+			return nil, r.QuoteErr()
+		}
+	}
+	if message.Body != nil {
+		var mentions []models.UserRef
+		for _, word := range strings.Fields(*message.Body) {
+			if username, ok := strings.CutPrefix(word, "@"); ok {
+				mentions = append(mentions, models.UserRef{ID: &genericUUID, Username: &username})
+			}
+		}
+		if len(mentions) > 10 {
+			return nil, r.MentionsErr()
+		}
+		if mentions != nil {
+			message.Mentions = &mentions
+		}
+	}
 	id := 1
 	message.ID = &id
 	r.messageData[id] = message
@@ -210,7 +409,7 @@ func (r *mockForumRepo) FindMessageByID(id int) (*models.Message, error) {
 	}
 	return &message, nil
 }
-func (r *mockForumRepo) FindMessages(query []string, limit uint64, order string) (*[]models.Message, error) {
+func (r *mockForumRepo) FindMessages(query []string, page models.PageQuery, order string) (*models.Page[models.Message], error) {
 	var (
 		topicID       int              = 2
 		messageIDs    []int            = []int{1, 2, 3}
@@ -237,24 +436,32 @@ func (r *mockForumRepo) FindMessages(query []string, limit uint64, order string)
 			resultMessages = append(resultMessages, m)
 		}
 	}
-	if limit != 0 {
-		resultMessages = resultMessages[:limit]
+	total := int64(len(resultMessages))
+	if page.Limit != 0 && int(page.Limit) < len(resultMessages) {
+		resultMessages = resultMessages[:page.Limit]
 	}
-	return &resultMessages, nil
+	return &models.Page[models.Message]{Items: resultMessages, Total: total}, nil
 }
-func (r *mockForumRepo) FindMessagesByThreadID(threadID int) (*[]models.Message, error) {
+func (r *mockForumRepo) FindMessagesByThreadID(threadID int, nested bool, page models.PageQuery) (*models.Page[models.Message], error) {
 	var (
 		messageIDs    []int            = []int{1, 2}
 		messageTitles []string         = []string{"message one", "message two"}
 		t             []models.Message = []models.Message{
 			{ID: &messageIDs[0], ThreadID: &threadID, UserID: &genericUUID, Title: &messageTitles[0]},
-			{ID: &messageIDs[1], ThreadID: &threadID, UserID: &genericUUID, Title: &messageTitles[1]},
+			{ID: &messageIDs[1], ThreadID: &threadID, UserID: &genericUUID, Title: &messageTitles[1], ReplyTo: &messageIDs[0],
+				Parent: &models.ReplyContext{ID: &messageIDs[0], UserID: &genericUUID, Excerpt: &messageTitles[0]}},
 		}
 	)
 	if threadID != 1 {
 		return nil, r.NotFoundErr()
 	}
-	return &t, nil
+	if nested {
+		reply := t[1]
+		reply.Parent = nil
+		t = []models.Message{t[0]}
+		t[0].Replies = &[]models.Message{reply}
+	}
+	return &models.Page[models.Message]{Items: t, Total: int64(len(t))}, nil
 }
 func (r *mockForumRepo) UpdateMessage(id int, message models.Message) (*models.Message, error) {
 	var resultMessage models.Message
@@ -270,17 +477,66 @@ func (r *mockForumRepo) UpdateMessage(id int, message models.Message) (*models.M
 	resultMessage = r.messageData[id]
 	return &resultMessage, nil
 }
-func (r *mockForumRepo) DeleteMessage(id int) error {
-	_, ok := r.messageData[id]
+func (r *mockForumRepo) ModeratesContent(targetType string, targetID int, userID uuid.UUID) (bool, error) {
+	return moderatesMockContent(targetType, userID), nil
+}
+func (r *mockForumRepo) FindMentions(userID uuid.UUID, limit uint64) (*[]models.Message, error) {
+	var messages []models.Message
+	for _, m := range r.messageData {
+		if m.Mentions != nil && slices.ContainsFunc(*m.Mentions, func(u models.UserRef) bool { return *u.ID == userID }) {
+			messages = append(messages, m)
+		}
+	}
+	if len(messages) == 0 {
+		return nil, r.NotFoundErr()
+	}
+	return &messages, nil
+}
+func (r *mockForumRepo) DeleteMessage(id int, deletedBy uuid.UUID) error {
+	x, ok := r.messageData[id]
 	if !ok {
 		return r.NotFoundErr()
 	}
+	r.deletedMessage[id] = x
 	delete(r.messageData, id)
 	return nil
 }
+func (r *mockForumRepo) RestoreMessage(id int, restoredBy *uuid.UUID) error {
+	x, ok := r.deletedMessage[id]
+	if !ok {
+		return r.NotFoundErr()
+	}
+	if x.ThreadID != nil {
+		if _, ok := r.deletedThread[*x.ThreadID]; ok {
+			return r.ThreadTrashedErr()
+		}
+	}
+	r.messageData[id] = x
+	delete(r.deletedMessage, id)
+	return nil
+}
+
+func (m *mockMarkdownRenderer) Render(source string) (string, error) {
+	return "<p>" + html.EscapeString(source) + "</p>", nil
+}
 
-func (r *mockForumRepo) NotFoundErr() error { return r.notFoundErr }
-func (r *mockForumRepo) ConflictErr() error { return r.conflictErr }
+func (r *mockForumRepo) NotFoundErr() error        { return r.notFoundErr }
+func (r *mockForumRepo) CursorErr() error          { return r.cursorErr }
+func (r *mockForumRepo) ConflictErr() error        { return r.conflictErr }
+func (r *mockForumRepo) ThreadLockedErr() error    { return r.threadLockedErr }
+func (r *mockForumRepo) ReplyErr() error           { return r.replyErr }
+func (r *mockForumRepo) QuoteErr() error           { return r.quoteErr }
+func (r *mockForumRepo) MentionsErr() error        { return r.mentionsErr }
+func (r *mockForumRepo) ThreadForbiddenErr() error { return r.threadForbiddenErr }
+func (r *mockForumRepo) ThreadOperationErr() error { return r.threadOperationErr }
+func (r *mockForumRepo) DemoThreadErr() error      { return r.demoThreadErr }
+func (r *mockForumRepo) SlugConflictErr() error    { return r.slugConflictErr }
+func (r *mockForumRepo) ParentErr() error          { return r.parentErr }
+func (r *mockForumRepo) ChildrenErr() error        { return r.childrenErr }
+func (r *mockForumRepo) ThreadAccessErr() error    { return r.threadAccessErr }
+func (r *mockForumRepo) AccountAgeErr() error      { return r.accountAgeErr }
+func (r *mockForumRepo) TopicTrashedErr() error    { return r.topicTrashedErr }
+func (r *mockForumRepo) ThreadTrashedErr() error   { return r.threadTrashedErr }
 
 func TestPostTopic(t *testing.T) {
 	// Setup
@@ -349,6 +605,58 @@ func TestGetTopics(t *testing.T) {
 	}
 }
 
+func TestPostTopicSlugConflict(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/game-hangar/v1/topics", strings.NewReader(`{"name":"Cool topic","slug":"taken"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	h := &ForumHandler{logger: e.Logger, validator: v, repository: &mf}
+
+	// Assertions
+	if assert.NoError(t, h.PostTopic(c)) {
+		assert.Equal(t, http.StatusConflict, rec.Code)
+		assert.Equal(t, `{"code":409,"message":"Category slug is already taken!"}`+"\n", rec.Body.String())
+	}
+}
+
+func TestGetTopicBySlug(t *testing.T) {
+	slug := "cool-topic"
+	mf.topicData[2] = models.Topic{ID: &[]int{2}[0], Name: &[]string{"Cool topic"}[0], Slug: &slug}
+	defer delete(mf.topicData, 2)
+
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/game-hangar/v1/topics/slug", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/slug/:slug")
+	c.SetParamNames("slug")
+	c.SetParamValues(slug)
+	h := &ForumHandler{logger: e.Logger, validator: v, repository: &mf}
+
+	// Assertions
+	if assert.NoError(t, h.GetTopicBySlug(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, `{"id":2,"name":"Cool topic","slug":"cool-topic"}`+"\n", rec.Body.String())
+	}
+}
+
+func TestGetTopicsModeUnprocessable(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/game-hangar/v1/topics?mode=tree", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	h := &ForumHandler{logger: e.Logger, validator: v, repository: &mf}
+
+	// Assertions
+	if assert.NoError(t, h.GetTopics(c)) {
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	}
+}
+
 func TestPatchTopic(t *testing.T) {
 	// Setup
 	e := echo.New()
@@ -433,6 +741,7 @@ func TestDeleteTopic(t *testing.T) {
 	c.SetPath("/:id")
 	c.SetParamNames("id")
 	c.SetParamValues("1")
+	c.Set("userID", genericUUID)
 	h := &ForumHandler{logger: e.Logger, validator: v, repository: &mf}
 
 	// Assertions
@@ -450,6 +759,7 @@ func TestDeleteTopicUnprocesable(t *testing.T) {
 	c.SetPath("/:id")
 	c.SetParamNames("id")
 	c.SetParamValues("9bc3c90")
+	c.Set("userID", genericUUID)
 	h := &ForumHandler{logger: e.Logger, validator: v, repository: &mf}
 
 	// Assertions
@@ -467,6 +777,7 @@ func TestDeleteTopicNotFound(t *testing.T) {
 	c.SetPath("/:id")
 	c.SetParamNames("id")
 	c.SetParamValues("93")
+	c.Set("userID", genericUUID)
 	h := &ForumHandler{logger: e.Logger, validator: v, repository: &mf}
 
 	// Assertions
@@ -483,7 +794,7 @@ func TestPostThread(t *testing.T) {
 	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 	rec := httptest.NewRecorder()
 	c := e.NewContext(req, rec)
-	h := &ForumHandler{logger: e.Logger, validator: v, repository: &mf}
+	h := &ForumHandler{logger: e.Logger, validator: v, repository: &mf, contentFilter: &mcf, markdown: &mmd}
 
 	// Assertions
 	if assert.NoError(t, h.PostThread(c)) {
@@ -492,6 +803,37 @@ func TestPostThread(t *testing.T) {
 	}
 }
 
+func TestPostThreadRestricted(t *testing.T) {
+	access := "moderators"
+	mf.topicData[7] = models.Topic{ID: &[]int{7}[0], ThreadAccess: &access}
+	defer delete(mf.topicData, 7)
+	// The mock stores every new thread as thread 1
+	posted := mf.threadData[1]
+	defer func() { mf.threadData[1] = posted }()
+	threadJSON := `{"title":"Cool Thread","userID":"` + genericUUID.String() + `","topicID":7}`
+
+	for _, tier := range []string{"freetier", "moderator"} {
+		// Setup
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/game-hangar/v1/threads", strings.NewReader(threadJSON))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.Set("userTier", tier)
+		h := &ForumHandler{logger: e.Logger, validator: v, repository: &mf, contentFilter: &mcf, markdown: &mmd}
+
+		// Assertions
+		if assert.NoError(t, h.PostThread(c)) {
+			if tier == "moderator" {
+				assert.Equal(t, http.StatusCreated, rec.Code)
+			} else {
+				assert.Equal(t, http.StatusForbidden, rec.Code)
+				assert.Equal(t, `{"code":403,"message":"Creating threads in this category is restricted!"}`+"\n", rec.Body.String())
+			}
+		}
+	}
+}
+
 func TestGetThreadByID(t *testing.T) {
 	// Setup
 	e := echo.New()
@@ -583,7 +925,7 @@ func TestPatchThread(t *testing.T) {
 	c.SetPath("/:id")
 	c.SetParamNames("id")
 	c.SetParamValues("1")
-	h := &ForumHandler{logger: e.Logger, validator: v, repository: &mf}
+	h := &ForumHandler{logger: e.Logger, validator: v, repository: &mf, contentFilter: &mcf, markdown: &mmd}
 
 	// Assertions
 	if assert.NoError(t, h.PatchThread(c)) {
@@ -602,7 +944,7 @@ func TestPatchThreadNotFound(t *testing.T) {
 	c.SetPath("/:id")
 	c.SetParamNames("id")
 	c.SetParamValues("93")
-	h := &ForumHandler{logger: e.Logger, validator: v, repository: &mf}
+	h := &ForumHandler{logger: e.Logger, validator: v, repository: &mf, contentFilter: &mcf, markdown: &mmd}
 
 	// Assertions
 	if assert.NoError(t, h.PatchThread(c)) {
@@ -620,6 +962,7 @@ func TestDeleteThread(t *testing.T) {
 	c.SetPath("/:id")
 	c.SetParamNames("id")
 	c.SetParamValues("1")
+	c.Set("userID", genericUUID)
 	h := &ForumHandler{logger: e.Logger, validator: v, repository: &mf}
 
 	// Assertions
@@ -637,6 +980,7 @@ func TestDeleteThreadUnprocesable(t *testing.T) {
 	c.SetPath("/:id")
 	c.SetParamNames("id")
 	c.SetParamValues("9bc3c90")
+	c.Set("userID", genericUUID)
 	h := &ForumHandler{logger: e.Logger, validator: v, repository: &mf}
 
 	// Assertions
@@ -654,6 +998,7 @@ func TestDeleteThreadNotFound(t *testing.T) {
 	c.SetPath("/:id")
 	c.SetParamNames("id")
 	c.SetParamValues("93")
+	c.Set("userID", genericUUID)
 	h := &ForumHandler{logger: e.Logger, validator: v, repository: &mf}
 
 	// Assertions
@@ -670,7 +1015,7 @@ func TestPostMessage(t *testing.T) {
 	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 	rec := httptest.NewRecorder()
 	c := e.NewContext(req, rec)
-	h := &ForumHandler{logger: e.Logger, validator: v, repository: &mf}
+	h := &ForumHandler{logger: e.Logger, validator: v, repository: &mf, contentFilter: &mcf, markdown: &mmd}
 
 	// Assertions
 	if assert.NoError(t, h.PostMessage(c)) {
@@ -679,6 +1024,29 @@ func TestPostMessage(t *testing.T) {
 	}
 }
 
+func TestPostMessageAccountAge(t *testing.T) {
+	minAge := 7
+	mf.topicData[8] = models.Topic{ID: &[]int{8}[0], MinAccountAgeDays: &minAge}
+	mf.threadData[8] = models.Thread{ID: &[]int{8}[0], TopicID: &[]int{8}[0]}
+	defer delete(mf.topicData, 8)
+	defer delete(mf.threadData, 8)
+
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/game-hangar/v1/messages",
+		strings.NewReader(`{"title":"Cool message","userID":"`+genericUUID.String()+`","threadID":8}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	h := &ForumHandler{logger: e.Logger, validator: v, repository: &mf, contentFilter: &mcf, markdown: &mmd}
+
+	// Assertions
+	if assert.NoError(t, h.PostMessage(c)) {
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.Equal(t, `{"code":403,"message":"Account is too new to post in this category!"}`+"\n", rec.Body.String())
+	}
+}
+
 func TestGetMessageByID(t *testing.T) {
 	// Setup
 	e := echo.New()
@@ -770,7 +1138,7 @@ func TestPatchMessage(t *testing.T) {
 	c.SetPath("/:id")
 	c.SetParamNames("id")
 	c.SetParamValues("1")
-	h := &ForumHandler{logger: e.Logger, validator: v, repository: &mf}
+	h := &ForumHandler{logger: e.Logger, validator: v, repository: &mf, contentFilter: &mcf, markdown: &mmd}
 
 	// Assertions
 	if assert.NoError(t, h.PatchMessage(c)) {
@@ -789,7 +1157,7 @@ func TestPatchMessageNotFound(t *testing.T) {
 	c.SetPath("/:id")
 	c.SetParamNames("id")
 	c.SetParamValues("93")
-	h := &ForumHandler{logger: e.Logger, validator: v, repository: &mf}
+	h := &ForumHandler{logger: e.Logger, validator: v, repository: &mf, contentFilter: &mcf, markdown: &mmd}
 
 	// Assertions
 	if assert.NoError(t, h.PatchMessage(c)) {
@@ -807,6 +1175,7 @@ func TestDeleteMessage(t *testing.T) {
 	c.SetPath("/:id")
 	c.SetParamNames("id")
 	c.SetParamValues("1")
+	c.Set("userID", genericUUID)
 	h := &ForumHandler{logger: e.Logger, validator: v, repository: &mf}
 
 	// Assertions
@@ -824,6 +1193,7 @@ func TestDeleteMessageUnprocesable(t *testing.T) {
 	c.SetPath("/:id")
 	c.SetParamNames("id")
 	c.SetParamValues("9bc3c90")
+	c.Set("userID", genericUUID)
 	h := &ForumHandler{logger: e.Logger, validator: v, repository: &mf}
 
 	// Assertions
@@ -841,6 +1211,7 @@ func TestDeleteMessageNotFound(t *testing.T) {
 	c.SetPath("/:id")
 	c.SetParamNames("id")
 	c.SetParamValues("93")
+	c.Set("userID", genericUUID)
 	h := &ForumHandler{logger: e.Logger, validator: v, repository: &mf}
 
 	// Assertions
@@ -849,3 +1220,367 @@ func TestDeleteMessageNotFound(t *testing.T) {
 		assert.Equal(t, notFoundResponse, rec.Body.String())
 	}
 }
+
+func TestRestoreThread(t *testing.T) {
+	// Setup
+	deletedID := 7
+	mf.deletedThread[deletedID] = models.Thread{ID: &deletedID}
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/game-hangar/v1/", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/:id/restore")
+	c.SetParamNames("id")
+	c.SetParamValues("7")
+	c.Set("userID", genericUUID)
+	h := &ForumHandler{logger: e.Logger, validator: v, repository: &mf}
+
+	// Assertions
+	if assert.NoError(t, h.RestoreThread(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.NotContains(t, mf.deletedThread, deletedID)
+	}
+	delete(mf.threadData, deletedID)
+}
+
+func TestRestoreThreadTopicTrashed(t *testing.T) {
+	// Setup
+	deletedID, deletedTopicID := 8, 8
+	mf.deletedTopic[deletedTopicID] = models.Topic{ID: &deletedTopicID}
+	mf.deletedThread[deletedID] = models.Thread{ID: &deletedID, TopicID: &deletedTopicID}
+	defer delete(mf.deletedTopic, deletedTopicID)
+	defer delete(mf.deletedThread, deletedID)
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/game-hangar/v1/", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/:id/restore")
+	c.SetParamNames("id")
+	c.SetParamValues("8")
+	c.Set("userID", genericUUID)
+	h := &ForumHandler{logger: e.Logger, validator: v, repository: &mf}
+
+	// Assertions: the thread stays in the trash with its topic
+	if assert.NoError(t, h.RestoreThread(c)) {
+		assert.Equal(t, http.StatusConflict, rec.Code)
+		assert.Contains(t, mf.deletedThread, deletedID)
+	}
+}
+
+func TestRestoreMessageThreadTrashed(t *testing.T) {
+	// Setup
+	deletedID, deletedThreadID := 8, 8
+	mf.deletedThread[deletedThreadID] = models.Thread{ID: &deletedThreadID}
+	mf.deletedMessage[deletedID] = models.Message{ID: &deletedID, ThreadID: &deletedThreadID}
+	defer delete(mf.deletedThread, deletedThreadID)
+	defer delete(mf.deletedMessage, deletedID)
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/game-hangar/v1/", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/:id/restore")
+	c.SetParamNames("id")
+	c.SetParamValues("8")
+	c.Set("userID", genericUUID)
+	h := &ForumHandler{logger: e.Logger, validator: v, repository: &mf}
+
+	// Assertions: the message stays in the trash with its thread
+	if assert.NoError(t, h.RestoreMessage(c)) {
+		assert.Equal(t, http.StatusConflict, rec.Code)
+		assert.Contains(t, mf.deletedMessage, deletedID)
+	}
+}
+
+func TestRestoreMessageNotFound(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/game-hangar/v1/", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/:id/restore")
+	c.SetParamNames("id")
+	c.SetParamValues("93")
+	c.Set("userID", genericUUID)
+	h := &ForumHandler{logger: e.Logger, validator: v, repository: &mf}
+
+	// Assertions
+	if assert.NoError(t, h.RestoreMessage(c)) {
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	}
+}
+
+func TestPostMessageLockedThread(t *testing.T) {
+	// Setup
+	lockedID := 8
+	lockedAt := time.Now()
+	mf.threadData[lockedID] = models.Thread{ID: &lockedID, LockedAt: &lockedAt}
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/game-hangar/v1/messages",
+		strings.NewReader(`{"title":"Cool message","userID":"`+genericUUID.String()+`","threadID":8}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	h := &ForumHandler{logger: e.Logger, validator: v, repository: &mf, contentFilter: &mcf, markdown: &mmd}
+
+	// Assertions
+	if assert.NoError(t, h.PostMessage(c)) {
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	}
+	delete(mf.threadData, lockedID)
+}
+
+func TestGetThreadByIDHidden(t *testing.T) {
+	// Setup
+	hiddenID := 9
+	hiddenAt := time.Now()
+	mf.threadData[hiddenID] = models.Thread{ID: &hiddenID, UserID: &genericUUID, HiddenAt: &hiddenAt}
+	h := &ForumHandler{logger: echo.New().Logger, validator: v, repository: &mf}
+	get := func(setup func(c echo.Context)) *httptest.ResponseRecorder {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/game-hangar/v1/threads", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/:id")
+		c.SetParamNames("id")
+		c.SetParamValues("9")
+		setup(c)
+		assert.NoError(t, h.GetThreadByID(c))
+		return rec
+	}
+
+	// Assertions
+	assert.Equal(t, http.StatusNotFound, get(func(c echo.Context) {}).Code)
+	assert.Equal(t, http.StatusNotFound, get(func(c echo.Context) { c.Set("userID", uuid.New()) }).Code)
+	assert.Equal(t, http.StatusOK, get(func(c echo.Context) { c.Set("userID", genericUUID) }).Code)
+	assert.Equal(t, http.StatusOK, get(func(c echo.Context) { c.Set("userTier", "moderator") }).Code)
+	assert.Equal(t, http.StatusOK, get(func(c echo.Context) { c.Set("userID", categoryModeratorID) }).Code)
+	delete(mf.threadData, hiddenID)
+}
+
+func TestPostMessageReply(t *testing.T) {
+	// Setup
+	parentID, otherThreadID := 11, 2
+	parentBody := "Jumping feels floaty, try a shorter apex"
+	original, hasOriginal := mf.messageData[1]
+	mf.messageData[parentID] = models.Message{ID: &parentID, ThreadID: &otherThreadID, UserID: &genericUUID, Body: &parentBody}
+	h := &ForumHandler{logger: echo.New().Logger, validator: v, repository: &mf, contentFilter: &mcf, markdown: &mmd}
+	post := func(body string) *httptest.ResponseRecorder {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/game-hangar/v1/messages", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		assert.NoError(t, h.PostMessage(c))
+		return rec
+	}
+	reply := func(threadID int, quote string) string {
+		return fmt.Sprintf(`{"title":"Re","userID":"%v","threadID":%v,"replyTo":%v%v}`, genericUUID, threadID, parentID, quote)
+	}
+
+	// Assertions
+	rec := post(reply(otherThreadID, `,"quote":"shorter apex"`))
+	if assert.Equal(t, http.StatusCreated, rec.Code) {
+		assert.Contains(t, rec.Body.String(), `"replyTo":11,"quote":"shorter apex"`)
+	}
+	assert.Equal(t, http.StatusUnprocessableEntity, post(reply(1, "")).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, post(reply(otherThreadID, `,"quote":"longer apex"`)).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity,
+		post(`{"title":"Re","userID":"`+genericUUID.String()+`","threadID":2,"quote":"apex"}`).Code)
+
+	delete(mf.messageData, parentID)
+	delete(mf.messageData, 1)
+	if hasOriginal {
+		mf.messageData[1] = original
+	}
+}
+
+func TestPostMessageMentions(t *testing.T) {
+	// Setup
+	original, hasOriginal := mf.messageData[1]
+	h := &ForumHandler{logger: echo.New().Logger, validator: v, repository: &mf, contentFilter: &mcf, markdown: &mmd}
+	post := func(body string) *httptest.ResponseRecorder {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/game-hangar/v1/messages",
+			strings.NewReader(fmt.Sprintf(`{"title":"Hi","userID":"%v","threadID":1,"body":"%v"}`, genericUUID, body)))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		assert.NoError(t, h.PostMessage(c))
+		return rec
+	}
+	getMentions := func(userID string) *httptest.ResponseRecorder {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/game-hangar/v1/users/"+userID+"/mentions", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/:id/mentions")
+		c.SetParamNames("id")
+		c.SetParamValues(userID)
+		assert.NoError(t, h.GetMentions(c))
+		return rec
+	}
+
+	// Assertions
+	assert.Equal(t, http.StatusUnprocessableEntity, post(strings.Repeat("@spammer ", 11)).Code)
+
+	rec := post("Nice jump @godette")
+	if assert.Equal(t, http.StatusCreated, rec.Code) {
+		assert.Contains(t, rec.Body.String(), `"mentions":[{"id":"`+genericUUID.String()+`","username":"godette"}]`)
+	}
+	rec = getMentions(genericUUID.String())
+	if assert.Equal(t, http.StatusOK, rec.Code) {
+		assert.Contains(t, rec.Body.String(), `"body":"Nice jump @godette"`)
+	}
+	assert.Equal(t, http.StatusNotFound, getMentions(uuid.NewString()).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, getMentions("godette").Code)
+
+	delete(mf.messageData, 1)
+	if hasOriginal {
+		mf.messageData[1] = original
+	}
+}
+
+func TestGetMessagesByThreadID(t *testing.T) {
+	// Setup
+	h := &ForumHandler{logger: echo.New().Logger, validator: v, repository: &mf}
+	get := func(mode string) *httptest.ResponseRecorder {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/game-hangar/v1/messages/thread?mode="+mode, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/:threadID")
+		c.SetParamNames("threadID")
+		c.SetParamValues("1")
+		assert.NoError(t, h.GetMessagesByThreadID(c))
+		return rec
+	}
+
+	// Assertions
+	rec := get("")
+	if assert.Equal(t, http.StatusOK, rec.Code) {
+		assert.Contains(t, rec.Body.String(), `"replyTo":1,"parent":{"id":1,"userID":"`+genericUUID.String()+`","excerpt":"message one"}`)
+	}
+	rec = get("nested")
+	if assert.Equal(t, http.StatusOK, rec.Code) {
+		assert.Contains(t, rec.Body.String(), `"replies":[{"id":2,`)
+		assert.NotContains(t, rec.Body.String(), `"parent"`)
+	}
+	assert.Equal(t, http.StatusUnprocessableEntity, get("tree").Code)
+}
+
+func TestGetMessagesOfHiddenThread(t *testing.T) {
+	// Setup
+	threadID, messageID := 1, 12
+	hiddenAt := time.Now()
+	shown, wasShown := mf.threadData[threadID]
+	mf.threadData[threadID] = models.Thread{ID: &threadID, UserID: &genericUUID, HiddenAt: &hiddenAt}
+	mf.messageData[messageID] = models.Message{ID: &messageID, ThreadID: &threadID, UserID: &genericUUID}
+	h := &ForumHandler{logger: echo.New().Logger, validator: v, repository: &mf}
+	get := func(userID uuid.UUID, param string, handle func(c echo.Context) error) int {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/game-hangar/v1/messages", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/:" + param)
+		c.SetParamNames(param)
+		c.SetParamValues(strconv.Itoa(map[string]int{"id": messageID, "threadID": threadID}[param]))
+		c.Set("userID", userID)
+		assert.NoError(t, handle(c))
+		return rec.Code
+	}
+
+	// Assertions
+	for _, handle := range []struct {
+		param  string
+		handle func(c echo.Context) error
+	}{{"id", h.GetMessageByID}, {"threadID", h.GetMessagesByThreadID}} {
+		assert.Equal(t, http.StatusNotFound, get(uuid.New(), handle.param, handle.handle))
+		assert.Equal(t, http.StatusOK, get(genericUUID, handle.param, handle.handle))
+		assert.Equal(t, http.StatusOK, get(categoryModeratorID, handle.param, handle.handle))
+	}
+	delete(mf.messageData, messageID)
+	delete(mf.threadData, threadID)
+	if wasShown {
+		mf.threadData[threadID] = shown
+	}
+}
+
+func TestPostThreadOperation(t *testing.T) {
+	// Setup
+	threadID, topicID, ownerID := 10, 10, uuid.New()
+	mf.topicData[topicID] = models.Topic{ID: &topicID, UserID: &ownerID}
+	mf.threadData[threadID] = models.Thread{ID: &threadID, TopicID: &topicID}
+	h := &ForumHandler{logger: echo.New().Logger, validator: v, repository: &mf, contentFilter: &mcf, markdown: &mmd}
+	post := func(body string, setup func(c echo.Context)) *httptest.ResponseRecorder {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/game-hangar/v1/threads", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/:id/operations")
+		c.SetParamNames("id")
+		c.SetParamValues("10")
+		setup(c)
+		assert.NoError(t, h.PostThreadOperation(c))
+		return rec
+	}
+	asOwner := func(c echo.Context) { c.Set("userTier", "freetier"); c.Set("userID", ownerID) }
+	asModerator := func(c echo.Context) { c.Set("userTier", "moderator"); c.Set("userID", genericUUID) }
+
+	// Assertions
+	assert.Equal(t, http.StatusUnauthorized, post(`{"operation":"pin","version":1}`, func(c echo.Context) {}).Code)
+	assert.Equal(t, http.StatusForbidden, post(`{"operation":"pin","version":1}`, func(c echo.Context) {
+		c.Set("userTier", "freetier")
+		c.Set("userID", genericUUID)
+	}).Code)
+	rec := post(`{"operation":"pin","version":1,"reason":"FAQ"}`, asOwner)
+	if assert.Equal(t, http.StatusCreated, rec.Code) {
+		assert.Contains(t, rec.Body.String(), `"userID":"`+ownerID.String()+`","operation":"pin","version":2`)
+		assert.NotNil(t, mf.threadData[threadID].PinnedAt)
+	}
+	assert.Equal(t, http.StatusConflict, post(`{"operation":"lock","version":1}`, asModerator).Code)
+	assert.Equal(t, http.StatusCreated, post(`{"operation":"lock","version":2}`, asModerator).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, post(`{"operation":"merge","version":3,"targetThreadID":10}`, asModerator).Code)
+
+	// Operations missing what they need
+	assert.Equal(t, http.StatusUnprocessableEntity, post(`{"operation":"pin"}`, asModerator).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, post(`{"operation":"move","version":3}`, asModerator).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, post(`{"operation":"merge","version":3}`, asModerator).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, post(`{"operation":"split","version":3,"title":"Off-topic"}`, asModerator).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, post(`{"operation":"split","version":3,"messageIDs":[1]}`, asModerator).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, post(`{"operation":"archive","version":3}`, asModerator).Code)
+	delete(mf.threadData, threadID)
+	delete(mf.topicData, topicID)
+}
+
+func TestGetThreadsByTopicID(t *testing.T) {
+	// Setup
+	topicID, pinnedID, otherID := 11, 12, 13
+	pinnedAt := time.Now()
+	mf.threadData[otherID] = models.Thread{ID: &otherID, TopicID: &topicID}
+	mf.threadData[pinnedID] = models.Thread{ID: &pinnedID, TopicID: &topicID, PinnedAt: &pinnedAt}
+	h := &ForumHandler{logger: echo.New().Logger, validator: v, repository: &mf}
+	get := func(id string) *httptest.ResponseRecorder {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/game-hangar/v1/topics", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/:id/threads")
+		c.SetParamNames("id")
+		c.SetParamValues(id)
+		assert.NoError(t, h.GetThreadsByTopicID(c))
+		return rec
+	}
+
+	// Assertions
+	rec := get("11")
+	if assert.Equal(t, http.StatusOK, rec.Code) {
+		assert.True(t, strings.HasPrefix(rec.Body.String(), `[{"id":12,`))
+	}
+	assert.Equal(t, http.StatusNotFound, get("9000").Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, get("abc").Code)
+	delete(mf.threadData, pinnedID)
+	delete(mf.threadData, otherID)
+}
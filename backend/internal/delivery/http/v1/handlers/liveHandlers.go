@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"gamehangar/internal/domain/models"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "gamehangar/docs"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	liveMaxThreads = 20
+	// Keeps idle connections from being closed by proxies
+	liveHeartbeat = 30 * time.Second
+)
+
+type LiveHub interface {
+	Subscribe(channels ...string) (<-chan models.LiveEvent, func(), error)
+}
+
+type LiveHandler struct {
+	logger     echo.Logger
+	repository LiveRepository
+	hub        LiveHub
+	validator  *validator.Validate
+}
+
+func NewLiveHandler(e *echo.Echo, repo LiveRepository, hub LiveHub, v *validator.Validate) *LiveHandler {
+	return &LiveHandler{
+		logger:     e.Logger,
+		repository: repo,
+		hub:        hub,
+		validator:  v,
+	}
+}
+
+//	@Summary		Streams live updates of threads and notifications of the current user as Server-Sent Events.
+//	@Description	Threads get message.created, message.edited, message.deleted, message.voted and thread.voted events,
+//	@Description	the current user gets notification and notification.read events. Events only carry IDs and counters.
+//	@Description	A reset event ends the stream when updates may have been missed: refetch and connect again.
+//	@Tags			Live
+//	@Produce		text/event-stream
+//	@param			sessionID	header		string	false	"Session ID"
+//	@Param			threads		query		string	false	"Comma-separated thread IDs"
+//	@Success		200			{string}	string
+//	@Failure		404			{object}	HTTPError
+//	@Failure		422			{object}	HTTPError
+//	@Failure		500			{object}	HTTPError
+//	@Failure		503			{object}	HTTPError
+//	@Router			/v1/live [get]
+func (h *LiveHandler) GetLiveEvents(c echo.Context) error {
+	var channels []string
+
+	if p := c.QueryParam("threads"); p != "" {
+		ids := strings.Split(p, ",")
+		err := h.validator.Var(ids, fmt.Sprintf("max=%v,unique,dive,number", liveMaxThreads))
+		if err != nil {
+			e := HTTPError{
+				Code:    http.StatusUnprocessableEntity,
+				Message: "Error in GetLiveEvents handler: " + err.Error(),
+			}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusUnprocessableEntity, &e)
+		}
+
+		for _, p := range ids {
+			id, _ := strconv.Atoi(p)
+			thread, err := h.repository.FindLiveThread(id)
+			if err == nil && thread.HiddenAt != nil {
+				var visible bool
+				visible, err = canViewHiddenPost(c, h.repository, thread.UserID, "thread", id)
+				if err == nil && !visible {
+					err = h.repository.NotFoundErr()
+				}
+			}
+			if err != nil {
+				if err == h.repository.NotFoundErr() {
+					e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
+					h.logger.Print(&e)
+					return c.JSON(http.StatusNotFound, &e)
+				}
+				e := HTTPError{
+					Code:    http.StatusInternalServerError,
+					Message: "Error in FindLiveThread repository: " + err.Error(),
+				}
+				h.logger.Print(&e)
+				return c.JSON(http.StatusInternalServerError, &e)
+			}
+			channels = append(channels, "thread:"+p)
+		}
+	}
+	if userID, ok := c.Get("userID").(uuid.UUID); ok {
+		channels = append(channels, "user:"+userID.String())
+	}
+	if len(channels) == 0 {
+		e := HTTPError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Error in GetLiveEvents handler: no threads given and no session provided",
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusUnprocessableEntity, &e)
+	}
+
+	events, unsubscribe, err := h.hub.Subscribe(channels...)
+	if err != nil {
+		e := HTTPError{Code: http.StatusServiceUnavailable, Message: err.Error()}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusServiceUnavailable, &e)
+	}
+	defer unsubscribe()
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.Header().Set(echo.HeaderConnection, "keep-alive")
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+	res.Flush()
+
+	heartbeat := time.NewTicker(liveHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request().Context().Done():
+			return nil
+		case <-heartbeat.C:
+			_, err = fmt.Fprint(res, ": heartbeat\n\n")
+		case event, ok := <-events:
+			if !ok {
+				fmt.Fprint(res, "event: reset\ndata: {}\n\n")
+				res.Flush()
+				return nil
+			}
+			data, _ := json.Marshal(event)
+			_, err = fmt.Fprintf(res, "event: %v\ndata: %s\n\n", event.Event, data)
+		}
+		if err != nil {
+			return nil
+		}
+		res.Flush()
+	}
+}
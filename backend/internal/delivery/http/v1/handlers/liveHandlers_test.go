@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"gamehangar/internal/domain/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockLiveRepo struct {
+	notFoundErr error
+}
+
+type mockLiveHub struct {
+	events   []models.LiveEvent
+	channels []string
+	closed   bool
+}
+
+var (
+	hiddenLiveThreadID = 2
+	liveHiddenAt       = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	mlr = mockLiveRepo{notFoundErr: errors.New("Not Found")}
+)
+
+func (r *mockLiveRepo) FindLiveThread(id int) (*models.Thread, error) {
+	switch id {
+	case 1:
+		return &models.Thread{ID: &id, UserID: &genericUUID}, nil
+	case hiddenLiveThreadID:
+		return &models.Thread{ID: &id, UserID: &genericUUID, HiddenAt: &liveHiddenAt}, nil
+	}
+	return nil, r.NotFoundErr()
+}
+func (r *mockLiveRepo) ModeratesContent(targetType string, targetID int, userID uuid.UUID) (bool, error) {
+	return moderatesMockContent(targetType, userID), nil
+}
+func (r *mockLiveRepo) NotFoundErr() error { return r.notFoundErr }
+
+// Sends its events, then ends the stream as if the client fell behind
+func (h *mockLiveHub) Subscribe(channels ...string) (<-chan models.LiveEvent, func(), error) {
+	if h.closed {
+		return nil, nil, errors.New("Live updates are shutting down!")
+	}
+	h.channels = channels
+	events := make(chan models.LiveEvent, len(h.events))
+	for _, e := range h.events {
+		events <- e
+	}
+	close(events)
+	return events, func() {}, nil
+}
+
+func TestGetLiveEvents(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/game-hangar/v1/live?threads=1", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set("userID", genericUUID)
+	hub := mockLiveHub{events: []models.LiveEvent{{
+		Channel: "thread:1",
+		Event:   "message.created",
+		Data:    json.RawMessage(`{"id":1,"threadID":1}`),
+	}}}
+	h := NewLiveHandler(e, &mlr, &hub, v)
+
+	// Assertions
+	if assert.NoError(t, h.GetLiveEvents(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "text/event-stream", rec.Header().Get(echo.HeaderContentType))
+		assert.Equal(t, []string{"thread:1", "user:" + genericUUID.String()}, hub.channels)
+		assert.Equal(t,
+			"event: message.created\n"+
+				`data: {"channel":"thread:1","event":"message.created","data":{"id":1,"threadID":1}}`+"\n\n"+
+				"event: reset\ndata: {}\n\n",
+			rec.Body.String())
+	}
+}
+
+func TestGetLiveEventsNotFound(t *testing.T) {
+	// Setup
+	e := echo.New()
+	h := NewLiveHandler(e, &mlr, &mockLiveHub{}, v)
+
+	// Hidden threads are only streamed to their author, moderators and moderators of their category
+	stranger := uuid.New()
+	for _, userID := range []uuid.UUID{stranger, genericUUID, categoryModeratorID} {
+		for _, threads := range []string{"3", "1,2"} {
+			req := httptest.NewRequest(http.MethodGet, "/game-hangar/v1/live?threads="+threads, nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.Set("userID", userID)
+
+			// Assertions
+			if assert.NoError(t, h.GetLiveEvents(c)) {
+				if userID != stranger && threads == "1,2" {
+					assert.Equal(t, http.StatusOK, rec.Code)
+				} else {
+					assert.Equal(t, http.StatusNotFound, rec.Code)
+					assert.Equal(t, notFoundResponse, rec.Body.String())
+				}
+			}
+		}
+	}
+}
+
+func TestGetLiveEventsUnprocessable(t *testing.T) {
+	// Setup
+	e := echo.New()
+	h := NewLiveHandler(e, &mlr, &mockLiveHub{}, v)
+
+	for _, target := range []string{"/game-hangar/v1/live", "/game-hangar/v1/live?threads=a", "/game-hangar/v1/live?threads=1,1"} {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		// Assertions
+		if assert.NoError(t, h.GetLiveEvents(c)) {
+			assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		}
+	}
+}
+
+func TestGetLiveEventsUnavailable(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/game-hangar/v1/live", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set("userID", genericUUID)
+	h := NewLiveHandler(e, &mlr, &mockLiveHub{closed: true}, v)
+
+	// Assertions
+	if assert.NoError(t, h.GetLiveEvents(c)) {
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	}
+}
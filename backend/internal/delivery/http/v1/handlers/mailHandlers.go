@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"gamehangar/internal/domain/models"
+	"net/http"
+
+	_ "gamehangar/docs"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type MailHandler struct {
+	logger     echo.Logger
+	repository MailRepository
+	validator  *validator.Validate
+}
+
+func NewMailHandler(e *echo.Echo, repo MailRepository, v *validator.Validate) *MailHandler {
+	return &MailHandler{
+		logger:     e.Logger,
+		repository: repo,
+		validator:  v,
+	}
+}
+
+//	@Summary	Fetches how often and in which language the current user gets notification emails.
+//	@Tags		Notifications
+//	@Produce	application/json
+//	@Security	ApiSessionCookie
+//	@param		sessionID	header		string	false	"Session ID"
+//	@Success	200			{object}	models.EmailPreferences
+//	@Failure	401			{object}	HTTPError
+//	@Failure	403			{object}	HTTPError
+//	@Failure	404			{object}	HTTPError
+//	@Failure	500			{object}	HTTPError
+//	@Router		/v1/email/preferences [get]
+func (h *MailHandler) GetEmailPreferences(c echo.Context) error {
+	userID, ok := c.Get("userID").(uuid.UUID)
+	if !ok {
+		e := HTTPError{Code: http.StatusUnauthorized, Message: "No session provided!"}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusUnauthorized, &e)
+	}
+
+	preferences, err := h.repository.FindEmailPreferences(userID)
+	if err != nil {
+		if err == h.repository.NotFoundErr() {
+			e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusNotFound, &e)
+		}
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in FindEmailPreferences repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+
+	return c.JSON(http.StatusOK, &preferences)
+}
+
+//	@Summary	Updates how often and in which language the current user gets notification emails.
+//	@Tags		Notifications
+//	@Accept		application/json
+//	@Produce	application/json
+//	@Security	ApiSessionCookie
+//	@param		sessionID	header		string					false	"Session ID"
+//	@Param		Preferences	body		models.EmailPreferences	true	"Email preferences"
+//	@Success	200			{object}	models.EmailPreferences
+//	@Failure	400			{object}	HTTPError
+//	@Failure	401			{object}	HTTPError
+//	@Failure	403			{object}	HTTPError
+//	@Failure	404			{object}	HTTPError
+//	@Failure	422			{object}	HTTPError
+//	@Failure	500			{object}	HTTPError
+//	@Router		/v1/email/preferences [patch]
+func (h *MailHandler) PatchEmailPreferences(c echo.Context) error {
+	var preferences models.EmailPreferences
+
+	userID, ok := c.Get("userID").(uuid.UUID)
+	if !ok {
+		e := HTTPError{Code: http.StatusUnauthorized, Message: "No session provided!"}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusUnauthorized, &e)
+	}
+
+	err := c.Bind(&preferences)
+	if err != nil {
+		e := HTTPError{
+			Code:    http.StatusBadRequest,
+			Message: "Error in PatchEmailPreferences handler: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusBadRequest, &e)
+	}
+
+	err = h.validator.Struct(&preferences)
+	if err != nil {
+		e := HTTPError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Error in PatchEmailPreferences handler: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusUnprocessableEntity, &e)
+	}
+
+	updPreferences, err := h.repository.UpdateEmailPreferences(userID, preferences)
+	if err != nil {
+		if err == h.repository.NotFoundErr() {
+			e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusNotFound, &e)
+		}
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in UpdateEmailPreferences repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+
+	return c.JSON(http.StatusOK, &updPreferences)
+}
+
+//	@Summary	Turns off all emails of the user the token in the email was issued to. Serves one-click unsubscribe (RFC 8058).
+//	@Tags		Notifications
+//	@Produce	text/plain
+//	@Param		token	path		string	true	"Unsubscribe token"
+//	@Success	200		{string}	string
+//	@Failure	404		{object}	HTTPError
+//	@Failure	422		{object}	HTTPError
+//	@Failure	500		{object}	HTTPError
+//	@Router		/v1/email/unsubscribe/{token} [post]
+func (h *MailHandler) Unsubscribe(c echo.Context) error {
+	p := c.Param("token")
+	err := h.validator.Var(p, "required,uuid")
+	if err != nil {
+		e := HTTPError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Error in Unsubscribe handler: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusUnprocessableEntity, &e)
+	}
+	token, _ := uuid.Parse(p)
+
+	err = h.repository.Unsubscribe(token)
+	if err != nil {
+		if err == h.repository.NotFoundErr() {
+			e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusNotFound, &e)
+		}
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in Unsubscribe repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+
+	return c.String(http.StatusOK, "Unsubscribed from all emails!")
+}
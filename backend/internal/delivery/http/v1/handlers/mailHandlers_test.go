@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"errors"
+	"gamehangar/internal/domain/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockMailRepo struct {
+	preferences      map[uuid.UUID]models.EmailPreferences
+	unsubscribeToken uuid.UUID
+	notFoundErr      error
+}
+
+var (
+	mailFrequency = "daily"
+	mailLocale    = "en"
+
+	mml = mockMailRepo{
+		preferences: map[uuid.UUID]models.EmailPreferences{genericUUID: {
+			Frequency: &mailFrequency, Locale: &mailLocale,
+		}},
+		unsubscribeToken: uuid.New(),
+		notFoundErr:      errors.New("Not Found"),
+	}
+)
+
+func (r *mockMailRepo) FindEmailPreferences(userID uuid.UUID) (*models.EmailPreferences, error) {
+	p, ok := r.preferences[userID]
+	if !ok {
+		return nil, r.NotFoundErr()
+	}
+	return &p, nil
+}
+func (r *mockMailRepo) UpdateEmailPreferences(userID uuid.UUID, preferences models.EmailPreferences) (*models.EmailPreferences, error) {
+	p, ok := r.preferences[userID]
+	if !ok {
+		return nil, r.NotFoundErr()
+	}
+	if preferences.Frequency != nil {
+		p.Frequency = preferences.Frequency
+	}
+	if preferences.Locale != nil {
+		p.Locale = preferences.Locale
+	}
+	r.preferences[userID] = p
+	return &p, nil
+}
+func (r *mockMailRepo) Unsubscribe(token uuid.UUID) error {
+	if token != r.unsubscribeToken {
+		return r.NotFoundErr()
+	}
+	return nil
+}
+func (r *mockMailRepo) NotFoundErr() error { return r.notFoundErr }
+
+func TestGetEmailPreferences(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/game-hangar/v1/email/preferences", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set("userID", genericUUID)
+	h := NewMailHandler(e, &mml, v)
+
+	// Assertions
+	if assert.NoError(t, h.GetEmailPreferences(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, `{"frequency":"daily","locale":"en"}`+"\n", rec.Body.String())
+	}
+}
+
+func TestPatchEmailPreferences(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPatch, "/game-hangar/v1/email/preferences",
+		strings.NewReader(`{"locale":"ru"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set("userID", genericUUID)
+	h := NewMailHandler(e, &mml, v)
+
+	// Assertions
+	if assert.NoError(t, h.PatchEmailPreferences(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, `{"frequency":"daily","locale":"ru"}`+"\n", rec.Body.String())
+	}
+}
+
+func TestPatchEmailPreferencesUnprocessable(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPatch, "/game-hangar/v1/email/preferences",
+		strings.NewReader(`{"frequency":"hourly"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set("userID", genericUUID)
+	h := NewMailHandler(e, &mml, v)
+
+	// Assertions
+	if assert.NoError(t, h.PatchEmailPreferences(c)) {
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	}
+}
+
+func TestUnsubscribe(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/game-hangar/v1/email/unsubscribe/:token", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("token")
+	c.SetParamValues("abc")
+	h := NewMailHandler(e, &mml, v)
+
+	// Assertions
+	if assert.NoError(t, h.Unsubscribe(c)) {
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	c.SetParamNames("token")
+	c.SetParamValues(uuid.NewString())
+
+	if assert.NoError(t, h.Unsubscribe(c)) {
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Equal(t, notFoundResponse, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	c.SetParamNames("token")
+	c.SetParamValues(mml.unsubscribeToken.String())
+
+	if assert.NoError(t, h.Unsubscribe(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "Unsubscribed from all emails!", rec.Body.String())
+	}
+}
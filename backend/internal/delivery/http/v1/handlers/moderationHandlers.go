@@ -0,0 +1,583 @@
+package handlers
+
+import (
+	"gamehangar/internal/domain/models"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "gamehangar/docs"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+const moderationMaxPageLength = 500
+
+type ModerationHandler struct {
+	logger     echo.Logger
+	repository ModerationRepository
+	validator  *validator.Validate
+}
+
+func NewModerationHandler(e *echo.Echo, repo ModerationRepository, v *validator.Validate) *ModerationHandler {
+	return &ModerationHandler{
+		logger:     e.Logger,
+		repository: repo,
+		validator:  v,
+	}
+}
+
+// Moderators are not bound by topic ownership nor by the posting rules of categories
+func isModerator(c echo.Context) bool {
+	switch c.Get("userTier") {
+	case "admin", "moderator":
+		return true
+	}
+	return false
+}
+
+// Hidden content is only shown to its author and to moderators
+func canViewHidden(c echo.Context, authorID *uuid.UUID) bool {
+	if isModerator(c) {
+		return true
+	}
+	userID, ok := c.Get("userID").(uuid.UUID)
+	return ok && authorID != nil && userID == *authorID
+}
+
+// Hidden threads and messages are also shown to moderators of their category
+func canViewHiddenPost(c echo.Context, r CategoryModerationRepository, authorID *uuid.UUID, targetType string, targetID int) (bool, error) {
+	if canViewHidden(c, authorID) {
+		return true, nil
+	}
+	userID, ok := c.Get("userID").(uuid.UUID)
+	if !ok {
+		return false, nil
+	}
+	return r.ModeratesContent(targetType, targetID, userID)
+}
+
+// Runs the content filter before the content is stored. Returns a nil verdict when
+// the content must not be stored, in which case the response is already sent
+func checkContent(c echo.Context, logger echo.Logger, f ContentFilter, subject models.FilterSubject) (*models.FilterVerdict, error) {
+	verdict, err := f.CheckContent(subject)
+	if err != nil {
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in CheckContent service: " + err.Error(),
+		}
+		logger.Print(&e)
+		return nil, c.JSON(http.StatusInternalServerError, &e)
+	}
+	if verdict.Action == "reject" {
+		e := HTTPError{Code: http.StatusUnprocessableEntity, Message: "Content rejected: " + verdict.Reason}
+		logger.Print(&e)
+		return nil, c.JSON(http.StatusUnprocessableEntity, &e)
+	}
+	return verdict, nil
+}
+
+// Joins the text fields that are set into the text checked by the content filter
+func filterText(fields ...*string) string {
+	var text []string
+	for _, f := range fields {
+		if f != nil {
+			text = append(text, *f)
+		}
+	}
+	return strings.Join(text, "\n")
+}
+
+// Returns the user of the session, or nil on public routes without one
+func sessionUserID(c echo.Context) *uuid.UUID {
+	userID, ok := c.Get("userID").(uuid.UUID)
+	if !ok {
+		return nil
+	}
+	return &userID
+}
+
+// Tells the content filter the content it checked is stored, and hides the content if the filter sent it to review.
+// Returns the time the content was hidden at, or nil if it stays visible
+func contentStored(logger echo.Logger, f ContentFilter, subject models.FilterSubject, targetID int, verdict models.FilterVerdict) *time.Time {
+	f.Remember(subject)
+	if verdict.Action != "review" {
+		return nil
+	}
+	err := f.HoldForReview(subject.Kind, targetID, verdict)
+	if err != nil {
+		logger.Print(&HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in HoldForReview service: " + err.Error(),
+		})
+		return nil
+	}
+	now := time.Now()
+	return &now
+}
+
+//	@Summary	Reports a piece of content to the moderators.
+//	@Tags		Moderation
+//	@Accept		application/json
+//	@Produce	application/json
+//	@Security	ApiSessionCookie
+//	@param		sessionID	header		string			false	"Session ID"
+//	@Param		Report		body		models.Report	true	"Create Report"
+//	@Success	201			{object}	models.Report
+//	@Failure	400			{object}	HTTPError
+//	@Failure	401			{object}	HTTPError
+//	@Failure	403			{object}	HTTPError
+//	@Failure	404			{object}	HTTPError
+//	@Failure	409			{object}	HTTPError
+//	@Failure	422			{object}	HTTPError
+//	@Failure	500			{object}	HTTPError
+//	@Router		/v1/reports [post]
+func (h *ModerationHandler) PostReport(c echo.Context) error {
+	var report models.Report
+
+	userID, ok := c.Get("userID").(uuid.UUID)
+	if !ok {
+		e := HTTPError{Code: http.StatusUnauthorized, Message: "No session provided!"}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusUnauthorized, &e)
+	}
+
+	err := c.Bind(&report)
+	if err != nil {
+		e := HTTPError{
+			Code:    http.StatusBadRequest,
+			Message: "Error in PostReport handler: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusBadRequest, &e)
+	}
+	report.ReporterID = &userID
+
+	err = h.validator.Struct(&report)
+	if err != nil {
+		e := HTTPError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Error in PostReport handler: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusUnprocessableEntity, &e)
+	}
+
+	newReport, err := h.repository.CreateReport(report)
+	if err != nil {
+		if err == h.repository.NotFoundErr() {
+			e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusNotFound, &e)
+		}
+		if err == h.repository.ConflictErr() {
+			e := HTTPError{Code: http.StatusConflict, Message: err.Error()}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusConflict, &e)
+		}
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in CreateReport repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+
+	return c.JSON(http.StatusCreated, &newReport)
+}
+
+//	@Summary	Fetches the moderator queue, oldest reports first.
+//	@Tags		Moderation
+//	@Produce	application/json
+//	@Security	ApiSessionCookie
+//	@param		sessionID	header		string	false	"Session ID"
+//	@Param		state		query		string	false	"Report state. Default open"	Enums(open, actioned, dismissed)
+//	@Param		targetType	query		string	false	"Content type"					Enums(demo, asset, thread, message)
+//	@Param		l			query		int		false	"Limit"
+//	@Param		offset		query		int		false	"Offset"
+//	@Success	200			{object}	[]models.Report
+//	@Failure	403			{object}	HTTPError
+//	@Failure	404			{object}	HTTPError
+//	@Failure	422			{object}	HTTPError
+//	@Failure	500			{object}	HTTPError
+//	@Router		/v1/reports [get]
+func (h *ModerationHandler) GetReports(c echo.Context) error {
+	return h.getReports(c, "GetReports", nil)
+}
+
+//	@Summary		Fetches the moderator queue of the topic, oldest reports first.
+//	@Description	Open to the moderators of the topic and its parent topics.
+//	@Description	Holds the reports on the threads and messages of the topic and its subtopics.
+//	@Tags			Moderation
+//	@Produce		application/json
+//	@Security		ApiSessionCookie
+//	@param			sessionID	header		string	false	"Session ID"
+//	@Param			id			path		int		true	"Topic ID"
+//	@Param			state		query		string	false	"Report state. Default open"	Enums(open, actioned, dismissed)
+//	@Param			targetType	query		string	false	"Content type"					Enums(thread, message)
+//	@Param			l			query		int		false	"Limit"
+//	@Param			offset		query		int		false	"Offset"
+//	@Success		200			{object}	[]models.Report
+//	@Failure		403			{object}	HTTPError
+//	@Failure		404			{object}	HTTPError
+//	@Failure		422			{object}	HTTPError
+//	@Failure		500			{object}	HTTPError
+//	@Router			/v1/topics/{id}/moderation/reports [get]
+func (h *ModerationHandler) GetTopicReports(c echo.Context) error {
+	p := c.Param("id")
+	err := h.validator.Var(p, "required,number")
+	if err != nil {
+		return h.unprocessable(c, "GetTopicReports", err)
+	}
+	topicID, _ := strconv.Atoi(p)
+
+	return h.getReports(c, "GetTopicReports", &topicID)
+}
+
+// Lists the reports matching the query, limited to the topic unless it is nil
+func (h *ModerationHandler) getReports(c echo.Context, handler string, topicID *int) error {
+	filter := models.ReportFilter{State: "open", TopicID: topicID, Limit: moderationMaxPageLength}
+
+	if p := c.QueryParam("state"); p != "" {
+		err := h.validator.Var(p, "oneof=open actioned dismissed")
+		if err != nil {
+			return h.unprocessable(c, handler, err)
+		}
+		filter.State = p
+	}
+	if p := c.QueryParam("targetType"); p != "" {
+		err := h.validator.Var(p, "oneof=demo asset thread message")
+		if err != nil {
+			return h.unprocessable(c, handler, err)
+		}
+		filter.TargetType = p
+	}
+	limit, offset, err := h.pagination(c)
+	if err != nil {
+		return h.unprocessable(c, handler, err)
+	}
+	filter.Limit, filter.Offset = min(limit, filter.Limit), offset
+
+	reports, err := h.repository.FindReports(filter)
+	if err != nil {
+		if err == h.repository.NotFoundErr() {
+			e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusNotFound, &e)
+		}
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in FindReports repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+
+	return c.JSON(http.StatusOK, &reports)
+}
+
+//	@Summary	Fetches a report by its ID.
+//	@Tags		Moderation
+//	@Produce	application/json
+//	@Security	ApiSessionCookie
+//	@param		sessionID	header		string	false	"Session ID"
+//	@Param		id			path		int		true	"Get Report of ID"
+//	@Success	200			{object}	models.Report
+//	@Failure	403			{object}	HTTPError
+//	@Failure	404			{object}	HTTPError
+//	@Failure	422			{object}	HTTPError
+//	@Failure	500			{object}	HTTPError
+//	@Router		/v1/reports/{id} [get]
+func (h *ModerationHandler) GetReportByID(c echo.Context) error {
+	p := c.Param("id")
+	err := h.validator.Var(p, "required,number")
+	if err != nil {
+		return h.unprocessable(c, "GetReportByID", err)
+	}
+	id, _ := strconv.ParseInt(p, 10, 64)
+
+	report, err := h.repository.FindReportByID(int(id))
+	if err != nil {
+		if err == h.repository.NotFoundErr() {
+			e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusNotFound, &e)
+		}
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in FindReportByID repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+
+	return c.JSON(http.StatusOK, &report)
+}
+
+//	@Summary	Dismisses an open report without acting on the content.
+//	@Tags		Moderation
+//	@Accept		application/json
+//	@Produce	application/json
+//	@Security	ApiSessionCookie
+//	@param		sessionID	header		string			false	"Session ID"
+//	@Param		id			path		int				true	"Dismiss Report of ID"
+//	@Param		Report		body		models.Report	true	"Report with the resolution note"
+//	@Success	200			{object}	models.Report
+//	@Failure	400			{object}	HTTPError
+//	@Failure	401			{object}	HTTPError
+//	@Failure	403			{object}	HTTPError
+//	@Failure	404			{object}	HTTPError
+//	@Failure	422			{object}	HTTPError
+//	@Failure	500			{object}	HTTPError
+//	@Router		/v1/reports/{id}/dismiss [post]
+func (h *ModerationHandler) DismissReport(c echo.Context) error {
+	var report models.Report
+
+	p := c.Param("id")
+	err := h.validator.Var(p, "required,number")
+	if err != nil {
+		return h.unprocessable(c, "DismissReport", err)
+	}
+	id, _ := strconv.ParseInt(p, 10, 64)
+
+	userID, ok := c.Get("userID").(uuid.UUID)
+	if !ok {
+		e := HTTPError{Code: http.StatusUnauthorized, Message: "No session provided!"}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusUnauthorized, &e)
+	}
+
+	err = c.Bind(&report)
+	if err != nil {
+		e := HTTPError{
+			Code:    http.StatusBadRequest,
+			Message: "Error in DismissReport handler: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusBadRequest, &e)
+	}
+	err = h.validator.Var(report.Resolution, "required,max=2000")
+	if err != nil {
+		return h.unprocessable(c, "DismissReport", err)
+	}
+	report.ResolvedBy = &userID
+
+	dismissed, err := h.repository.DismissReport(int(id), report)
+	if err != nil {
+		if err == h.repository.NotFoundErr() {
+			e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusNotFound, &e)
+		}
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in DismissReport repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+
+	return c.JSON(http.StatusOK, &dismissed)
+}
+
+//	@Summary	Hides, locks, deletes or warns the author of a piece of content. Resolves its open reports.
+//	@Tags		Moderation
+//	@Accept		application/json
+//	@Produce	application/json
+//	@Security	ApiSessionCookie
+//	@param		sessionID			header		string					false	"Session ID"
+//	@Param		ModerationAction	body		models.ModerationAction	true	"Create Moderation Action"
+//	@Success	201					{object}	models.ModerationAction
+//	@Failure	400					{object}	HTTPError
+//	@Failure	401					{object}	HTTPError
+//	@Failure	403					{object}	HTTPError
+//	@Failure	404					{object}	HTTPError
+//	@Failure	422					{object}	HTTPError
+//	@Failure	500					{object}	HTTPError
+//	@Router		/v1/moderation/actions [post]
+func (h *ModerationHandler) PostModerationAction(c echo.Context) error {
+	return h.createModerationAction(c, "PostModerationAction", nil)
+}
+
+//	@Summary		Takes a moderation action on a thread or message of the topic.
+//	@Description	Open to the moderators of the topic and its parent topics.
+//	@Description	Limited to hiding, locking and deleting content of the topic and its subtopics.
+//	@Tags			Moderation
+//	@Accept			application/json
+//	@Produce		application/json
+//	@Security		ApiSessionCookie
+//	@param			sessionID			header		string					false	"Session ID"
+//	@Param			id					path		int						true	"Topic ID"
+//	@Param			ModerationAction	body		models.ModerationAction	true	"Create Moderation Action"
+//	@Success		201					{object}	models.ModerationAction
+//	@Failure		400					{object}	HTTPError
+//	@Failure		401					{object}	HTTPError
+//	@Failure		403					{object}	HTTPError
+//	@Failure		404					{object}	HTTPError
+//	@Failure		422					{object}	HTTPError
+//	@Failure		500					{object}	HTTPError
+//	@Router			/v1/topics/{id}/moderation/actions [post]
+func (h *ModerationHandler) PostTopicModerationAction(c echo.Context) error {
+	p := c.Param("id")
+	err := h.validator.Var(p, "required,number")
+	if err != nil {
+		return h.unprocessable(c, "PostTopicModerationAction", err)
+	}
+	topicID, _ := strconv.Atoi(p)
+
+	return h.createModerationAction(c, "PostTopicModerationAction", &topicID)
+}
+
+// Takes the action in the request body, limited to the topic unless it is nil
+func (h *ModerationHandler) createModerationAction(c echo.Context, handler string, topicID *int) error {
+	var action models.ModerationAction
+
+	userID, ok := c.Get("userID").(uuid.UUID)
+	if !ok {
+		e := HTTPError{Code: http.StatusUnauthorized, Message: "No session provided!"}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusUnauthorized, &e)
+	}
+
+	err := c.Bind(&action)
+	if err != nil {
+		e := HTTPError{
+			Code:    http.StatusBadRequest,
+			Message: "Error in " + handler + " handler: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusBadRequest, &e)
+	}
+	action.ModeratorID = &userID
+	action.AuthorID = nil
+	action.TopicID = topicID
+
+	err = h.validator.Struct(&action)
+	if err != nil {
+		return h.unprocessable(c, handler, err)
+	}
+
+	newAction, err := h.repository.CreateModerationAction(action)
+	if err != nil {
+		if err == h.repository.NotFoundErr() {
+			e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusNotFound, &e)
+		}
+		if err == h.repository.UnsupportedActionErr() {
+			e := HTTPError{Code: http.StatusUnprocessableEntity, Message: err.Error()}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusUnprocessableEntity, &e)
+		}
+		if err == h.repository.OutOfCategoryErr() {
+			e := HTTPError{Code: http.StatusForbidden, Message: err.Error()}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusForbidden, &e)
+		}
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in CreateModerationAction repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+
+	return c.JSON(http.StatusCreated, &newAction)
+}
+
+//	@Summary	Fetches the moderation history, newest first.
+//	@Tags		Moderation
+//	@Produce	application/json
+//	@Security	ApiSessionCookie
+//	@param		sessionID	header		string	false	"Session ID"
+//	@Param		targetType	query		string	false	"Content type"	Enums(demo, asset, thread, message)
+//	@Param		targetID	query		int		false	"Content ID"
+//	@Param		authorID	query		string	false	"Content author ID"
+//	@Param		l			query		int		false	"Limit"
+//	@Param		offset		query		int		false	"Offset"
+//	@Success	200			{object}	[]models.ModerationAction
+//	@Failure	403			{object}	HTTPError
+//	@Failure	404			{object}	HTTPError
+//	@Failure	422			{object}	HTTPError
+//	@Failure	500			{object}	HTTPError
+//	@Router		/v1/moderation/actions [get]
+func (h *ModerationHandler) GetModerationActions(c echo.Context) error {
+	filter := models.ModerationActionFilter{Limit: moderationMaxPageLength}
+
+	if p := c.QueryParam("targetType"); p != "" {
+		err := h.validator.Var(p, "oneof=demo asset thread message")
+		if err != nil {
+			return h.unprocessable(c, "GetModerationActions", err)
+		}
+		filter.TargetType = p
+	}
+	if p := c.QueryParam("targetID"); p != "" {
+		err := h.validator.Var(p, "number,gt=0")
+		if err != nil {
+			return h.unprocessable(c, "GetModerationActions", err)
+		}
+		filter.TargetID, _ = strconv.Atoi(p)
+	}
+	if p := c.QueryParam("authorID"); p != "" {
+		err := h.validator.Var(p, "uuid")
+		if err != nil {
+			return h.unprocessable(c, "GetModerationActions", err)
+		}
+		authorID, _ := uuid.Parse(p)
+		filter.AuthorID = &authorID
+	}
+	limit, offset, err := h.pagination(c)
+	if err != nil {
+		return h.unprocessable(c, "GetModerationActions", err)
+	}
+	filter.Limit, filter.Offset = min(limit, filter.Limit), offset
+
+	actions, err := h.repository.FindModerationActions(filter)
+	if err != nil {
+		if err == h.repository.NotFoundErr() {
+			e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusNotFound, &e)
+		}
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in FindModerationActions repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+
+	return c.JSON(http.StatusOK, &actions)
+}
+
+// Parses the l and offset query parameters. A missing limit is returned as the maximum page length
+func (h *ModerationHandler) pagination(c echo.Context) (limit, offset uint64, err error) {
+	limit = moderationMaxPageLength
+	if p := c.QueryParam("l"); p != "" {
+		err = h.validator.Var(p, "number,gt=0")
+		if err != nil {
+			return 0, 0, err
+		}
+		limit, _ = strconv.ParseUint(p, 10, 64)
+	}
+	if p := c.QueryParam("offset"); p != "" {
+		err = h.validator.Var(p, "number,min=0")
+		if err != nil {
+			return 0, 0, err
+		}
+		offset, _ = strconv.ParseUint(p, 10, 64)
+	}
+	return limit, offset, nil
+}
+
+func (h *ModerationHandler) unprocessable(c echo.Context, handler string, err error) error {
+	e := HTTPError{
+		Code:    http.StatusUnprocessableEntity,
+		Message: "Error in " + handler + " handler: " + err.Error(),
+	}
+	h.logger.Print(&e)
+	return c.JSON(http.StatusUnprocessableEntity, &e)
+}
@@ -0,0 +1,359 @@
+package handlers
+
+import (
+	"errors"
+	"gamehangar/internal/domain/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockModerationRepo struct {
+	reports              map[int]models.Report
+	actions              []models.ModerationAction
+	notFoundErr          error
+	conflictErr          error
+	unsupportedActionErr error
+	outOfCategoryErr     error
+}
+
+// Rejects text mentioning casinos and sends text with links to review
+type mockContentFilter struct {
+	held       []string
+	remembered []string
+}
+
+type mockCaptchaVerifier struct {
+	token string
+}
+
+var (
+	mcf = mockContentFilter{}
+	mcv = mockCaptchaVerifier{token: "captcha-token"}
+
+	mmod = mockModerationRepo{
+		reports:              make(map[int]models.Report, 1),
+		notFoundErr:          errors.New("Not Found"),
+		conflictErr:          errors.New("Content already reported!"),
+		unsupportedActionErr: errors.New("Action is not supported for this content!"),
+		outOfCategoryErr:     errors.New("Content is not in this category!"),
+	}
+
+	// Moderates the category of every thread and message of the mocks
+	categoryModeratorID = uuid.New()
+
+	reportJSON       = `{"targetType":"thread","targetID":1,"reason":"spam","details":"Buy now"}`
+	moderationAction = `{"targetType":"thread","targetID":1,"action":"lock","reason":"Off-topic flame war"}`
+)
+
+func (r *mockModerationRepo) CreateReport(report models.Report) (*models.Report, error) {
+	for _, rep := range r.reports {
+		if *rep.ReporterID == *report.ReporterID && *rep.TargetType == *report.TargetType &&
+			*rep.TargetID == *report.TargetID && *rep.State == "open" {
+			return nil, r.ConflictErr()
+		}
+	}
+	id := len(r.reports) + 1
+	state := "open"
+	report.ID, report.State = &id, &state
+	r.reports[id] = report
+	return &report, nil
+}
+func (r *mockModerationRepo) FindReports(f models.ReportFilter) (*[]models.Report, error) {
+	var reports []models.Report
+	// Reports are all on thread 1, which lives in topic 1
+	for _, rep := range r.reports {
+		if *rep.State == f.State && (f.TopicID == nil || *f.TopicID == 1) {
+			reports = append(reports, rep)
+		}
+	}
+	if len(reports) == 0 {
+		return nil, r.NotFoundErr()
+	}
+	return &reports, nil
+}
+func (r *mockModerationRepo) FindReportByID(id int) (*models.Report, error) {
+	report, ok := r.reports[id]
+	if !ok {
+		return nil, r.NotFoundErr()
+	}
+	return &report, nil
+}
+func (r *mockModerationRepo) DismissReport(id int, report models.Report) (*models.Report, error) {
+	stored, ok := r.reports[id]
+	if !ok || *stored.State != "open" {
+		return nil, r.NotFoundErr()
+	}
+	state := "dismissed"
+	stored.State, stored.ResolvedBy, stored.Resolution = &state, report.ResolvedBy, report.Resolution
+	r.reports[id] = stored
+	return &stored, nil
+}
+func (r *mockModerationRepo) CreateModerationAction(action models.ModerationAction) (*models.ModerationAction, error) {
+	if (*action.Action == "lock" || *action.Action == "unlock") && *action.TargetType != "thread" {
+		return nil, r.UnsupportedActionErr()
+	}
+	// Only thread 1 lives in topic 1
+	if action.TopicID != nil && (*action.TopicID != 1 || *action.TargetType != "thread" || *action.TargetID != 1) {
+		return nil, r.OutOfCategoryErr()
+	}
+	id := len(r.actions) + 1
+	action.ID = &id
+	r.actions = append(r.actions, action)
+	return &action, nil
+}
+func (r *mockModerationRepo) FindModerationActions(f models.ModerationActionFilter) (*[]models.ModerationAction, error) {
+	if len(r.actions) == 0 {
+		return nil, r.NotFoundErr()
+	}
+	return &r.actions, nil
+}
+func (r *mockModerationRepo) ModeratesContent(targetType string, targetID int, userID uuid.UUID) (bool, error) {
+	return moderatesMockContent(targetType, userID), nil
+}
+func (r *mockModerationRepo) NotFoundErr() error          { return r.notFoundErr }
+func (r *mockModerationRepo) ConflictErr() error          { return r.conflictErr }
+func (r *mockModerationRepo) UnsupportedActionErr() error { return r.unsupportedActionErr }
+func (r *mockModerationRepo) OutOfCategoryErr() error     { return r.outOfCategoryErr }
+
+func moderatesMockContent(targetType string, userID uuid.UUID) bool {
+	return (targetType == "thread" || targetType == "message") && userID == categoryModeratorID
+}
+
+func (f *mockContentFilter) CheckContent(subject models.FilterSubject) (*models.FilterVerdict, error) {
+	if strings.Contains(subject.Text, "casino") {
+		return &models.FilterVerdict{Action: "reject", Filter: "blocklist", Reason: "Blocked word casino"}, nil
+	}
+	if strings.Contains(subject.Text, "http") {
+		return &models.FilterVerdict{Action: "review", Filter: "links", Reason: "Too many links"}, nil
+	}
+	return &models.FilterVerdict{Action: "allow"}, nil
+}
+func (f *mockContentFilter) HoldForReview(targetType string, targetID int, verdict models.FilterVerdict) error {
+	f.held = append(f.held, targetType)
+	return nil
+}
+func (f *mockContentFilter) Remember(subject models.FilterSubject) {
+	f.remembered = append(f.remembered, subject.Text)
+}
+
+func (v *mockCaptchaVerifier) VerifyCaptcha(token, remoteIP string) (bool, error) {
+	return token == v.token, nil
+}
+
+func TestPostReport(t *testing.T) {
+	// Setup
+	e := echo.New()
+	h := NewModerationHandler(e, &mmod, v)
+	post := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/game-hangar/v1/reports", strings.NewReader(reportJSON))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.Set("userID", genericUUID)
+		assert.NoError(t, h.PostReport(c))
+		return rec
+	}
+
+	// Assertions
+	rec := post()
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"state":"open"`)
+	assert.Equal(t, http.StatusConflict, post().Code)
+}
+
+func TestPostReportUnprocessable(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/game-hangar/v1/reports",
+		strings.NewReader(`{"targetType":"topic","targetID":1,"reason":"spam"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set("userID", genericUUID)
+	h := NewModerationHandler(e, &mmod, v)
+
+	// Assertions
+	if assert.NoError(t, h.PostReport(c)) {
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	}
+}
+
+func TestGetReports(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/game-hangar/v1/reports?state=open", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	h := NewModerationHandler(e, &mmod, v)
+
+	// Assertions
+	if assert.NoError(t, h.GetReports(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"reason":"spam"`)
+	}
+}
+
+func TestGetTopicReports(t *testing.T) {
+	// Setup
+	e := echo.New()
+	h := NewModerationHandler(e, &mmod, v)
+	get := func(topicID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/game-hangar/v1/topics/"+topicID+"/moderation/reports", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/game-hangar/v1/topics/:id/moderation/reports")
+		c.SetParamNames("id")
+		c.SetParamValues(topicID)
+		assert.NoError(t, h.GetTopicReports(c))
+		return rec
+	}
+
+	// Assertions
+	rec := get("1")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"reason":"spam"`)
+	assert.Equal(t, http.StatusNotFound, get("2").Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, get("a").Code)
+}
+
+func TestDismissReport(t *testing.T) {
+	// Setup
+	e := echo.New()
+	h := NewModerationHandler(e, &mmod, v)
+	dismiss := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/game-hangar/v1/reports",
+			strings.NewReader(`{"resolution":"Not spam"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/:id/dismiss")
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+		c.Set("userID", uuid.New())
+		assert.NoError(t, h.DismissReport(c))
+		return rec
+	}
+
+	// Assertions
+	rec := dismiss()
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"state":"dismissed"`)
+	assert.Equal(t, http.StatusNotFound, dismiss().Code)
+}
+
+func TestPostModerationAction(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/game-hangar/v1/moderation/actions", strings.NewReader(moderationAction))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set("userID", genericUUID)
+	h := NewModerationHandler(e, &mmod, v)
+
+	// Assertions
+	if assert.NoError(t, h.PostModerationAction(c)) {
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"moderatorID":"`+genericUUID.String()+`"`)
+	}
+}
+
+func TestPostModerationActionUnsupported(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/game-hangar/v1/moderation/actions",
+		strings.NewReader(`{"targetType":"message","targetID":1,"action":"lock","reason":"No"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set("userID", genericUUID)
+	h := NewModerationHandler(e, &mmod, v)
+
+	// Assertions
+	if assert.NoError(t, h.PostModerationAction(c)) {
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	}
+}
+
+func TestPostTopicModerationAction(t *testing.T) {
+	// Setup
+	e := echo.New()
+	h := NewModerationHandler(e, &mmod, v)
+	post := func(topicID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/game-hangar/v1/topics", strings.NewReader(moderationAction))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/:id/moderation/actions")
+		c.SetParamNames("id")
+		c.SetParamValues(topicID)
+		c.Set("userID", genericUUID)
+		assert.NoError(t, h.PostTopicModerationAction(c))
+		return rec
+	}
+
+	// Assertions
+	rec := post("1")
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"topicID":1`)
+	assert.Equal(t, http.StatusForbidden, post("2").Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, post("first").Code)
+}
+
+func TestGetModerationActions(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/game-hangar/v1/moderation/actions?targetType=thread&targetID=1", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	h := NewModerationHandler(e, &mmod, v)
+
+	// Assertions
+	if assert.NoError(t, h.GetModerationActions(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"action":"lock"`)
+	}
+}
+
+func TestPostThreadFilterRejected(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/game-hangar/v1/threads",
+		strings.NewReader(`{"title":"Best casino bonuses","userID":"`+genericUUID.String()+`","topicID":1}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	h := &ForumHandler{logger: e.Logger, validator: v, repository: &mf, contentFilter: &mcf, markdown: &mmd}
+
+	// Assertions
+	if assert.NoError(t, h.PostThread(c)) {
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		assert.Equal(t, `{"code":422,"message":"Content rejected: Blocked word casino"}`+"\n", rec.Body.String())
+		assert.NotContains(t, mcf.remembered, "Best casino bonuses")
+	}
+}
+
+func TestPostThreadFilterReview(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/game-hangar/v1/threads",
+		strings.NewReader(`{"title":"Free assets at http://example.com","userID":"`+genericUUID.String()+`","topicID":1}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	h := &ForumHandler{logger: e.Logger, validator: v, repository: &mf, contentFilter: &mcf, markdown: &mmd}
+
+	// Assertions
+	if assert.NoError(t, h.PostThread(c)) {
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"hiddenAt":`)
+		assert.Equal(t, []string{"thread"}, mcf.held)
+		assert.Contains(t, mcf.remembered, "Free assets at http://example.com")
+	}
+}
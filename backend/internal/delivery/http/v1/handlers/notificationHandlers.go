@@ -0,0 +1,290 @@
+package handlers
+
+import (
+	"gamehangar/internal/domain/models"
+	"net/http"
+	"strconv"
+
+	_ "gamehangar/docs"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+const notificationMaxPageLength = 100
+
+type NotificationHandler struct {
+	logger     echo.Logger
+	repository NotificationRepository
+	validator  *validator.Validate
+}
+
+func NewNotificationHandler(e *echo.Echo, repo NotificationRepository, v *validator.Validate) *NotificationHandler {
+	return &NotificationHandler{
+		logger:     e.Logger,
+		repository: repo,
+		validator:  v,
+	}
+}
+
+//	@Summary	Fetches notifications of the current user, most recently updated first.
+//	@Tags		Notifications
+//	@Produce	application/json
+//	@Security	ApiSessionCookie
+//	@param		sessionID	header		string	false	"Session ID"
+//	@Param		unread		query		bool	false	"Only unread notifications"
+//	@Param		l			query		int		false	"Limit"
+//	@Param		offset		query		int		false	"Offset"
+//	@Success	200			{object}	[]models.Notification
+//	@Failure	401			{object}	HTTPError
+//	@Failure	403			{object}	HTTPError
+//	@Failure	404			{object}	HTTPError
+//	@Failure	422			{object}	HTTPError
+//	@Failure	500			{object}	HTTPError
+//	@Router		/v1/notifications [get]
+func (h *NotificationHandler) GetNotifications(c echo.Context) error {
+	filter := models.NotificationFilter{Limit: notificationMaxPageLength}
+
+	userID, ok := c.Get("userID").(uuid.UUID)
+	if !ok {
+		return h.unauthorized(c)
+	}
+	filter.UserID = userID
+
+	if p := c.QueryParam("unread"); p != "" {
+		err := h.validator.Var(p, "boolean")
+		if err != nil {
+			return h.unprocessable(c, "GetNotifications", err)
+		}
+		filter.Unread, _ = strconv.ParseBool(p)
+	}
+	if p := c.QueryParam("l"); p != "" {
+		err := h.validator.Var(p, "number,gt=0")
+		if err != nil {
+			return h.unprocessable(c, "GetNotifications", err)
+		}
+		filter.Limit, _ = strconv.ParseUint(p, 10, 64)
+		filter.Limit = min(filter.Limit, notificationMaxPageLength)
+	}
+	if p := c.QueryParam("offset"); p != "" {
+		err := h.validator.Var(p, "number,min=0")
+		if err != nil {
+			return h.unprocessable(c, "GetNotifications", err)
+		}
+		filter.Offset, _ = strconv.ParseUint(p, 10, 64)
+	}
+
+	notifications, err := h.repository.FindNotifications(filter)
+	if err != nil {
+		if err == h.repository.NotFoundErr() {
+			e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusNotFound, &e)
+		}
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in FindNotifications repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+
+	return c.JSON(http.StatusOK, &notifications)
+}
+
+//	@Summary	Fetches the number of unread notifications of the current user.
+//	@Tags		Notifications
+//	@Produce	application/json
+//	@Security	ApiSessionCookie
+//	@param		sessionID	header		string	false	"Session ID"
+//	@Success	200			{object}	map[string]int
+//	@Failure	401			{object}	HTTPError
+//	@Failure	403			{object}	HTTPError
+//	@Failure	500			{object}	HTTPError
+//	@Router		/v1/notifications/unread [get]
+func (h *NotificationHandler) GetUnreadNotificationCount(c echo.Context) error {
+	userID, ok := c.Get("userID").(uuid.UUID)
+	if !ok {
+		return h.unauthorized(c)
+	}
+
+	count, err := h.repository.CountUnreadNotifications(userID)
+	if err != nil {
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in CountUnreadNotifications repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+
+	return c.JSON(http.StatusOK, map[string]int{"unread": count})
+}
+
+//	@Summary	Marks a notification of the current user as read.
+//	@Tags		Notifications
+//	@Produce	application/json
+//	@Security	ApiSessionCookie
+//	@param		sessionID	header		string	false	"Session ID"
+//	@Param		id			path		int		true	"Read Notification of ID"
+//	@Success	200			{object}	models.Notification
+//	@Failure	401			{object}	HTTPError
+//	@Failure	403			{object}	HTTPError
+//	@Failure	404			{object}	HTTPError
+//	@Failure	422			{object}	HTTPError
+//	@Failure	500			{object}	HTTPError
+//	@Router		/v1/notifications/{id}/read [post]
+func (h *NotificationHandler) ReadNotification(c echo.Context) error {
+	userID, ok := c.Get("userID").(uuid.UUID)
+	if !ok {
+		return h.unauthorized(c)
+	}
+
+	p := c.Param("id")
+	err := h.validator.Var(p, "required,number")
+	if err != nil {
+		return h.unprocessable(c, "ReadNotification", err)
+	}
+	id, _ := strconv.ParseInt(p, 10, 64)
+
+	notification, err := h.repository.MarkNotificationRead(int(id), userID)
+	if err != nil {
+		if err == h.repository.NotFoundErr() {
+			e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusNotFound, &e)
+		}
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in MarkNotificationRead repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+
+	return c.JSON(http.StatusOK, &notification)
+}
+
+//	@Summary	Marks all notifications of the current user as read.
+//	@Tags		Notifications
+//	@Produce	application/json
+//	@Security	ApiSessionCookie
+//	@param		sessionID	header		string	false	"Session ID"
+//	@Success	200			{object}	map[string]int64
+//	@Failure	401			{object}	HTTPError
+//	@Failure	403			{object}	HTTPError
+//	@Failure	500			{object}	HTTPError
+//	@Router		/v1/notifications/read [post]
+func (h *NotificationHandler) ReadAllNotifications(c echo.Context) error {
+	userID, ok := c.Get("userID").(uuid.UUID)
+	if !ok {
+		return h.unauthorized(c)
+	}
+
+	read, err := h.repository.MarkAllNotificationsRead(userID)
+	if err != nil {
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in MarkAllNotificationsRead repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+
+	return c.JSON(http.StatusOK, map[string]int64{"read": read})
+}
+
+//	@Summary	Fetches which notification types the current user receives.
+//	@Tags		Notifications
+//	@Produce	application/json
+//	@Security	ApiSessionCookie
+//	@param		sessionID	header		string	false	"Session ID"
+//	@Success	200			{object}	[]models.NotificationPreference
+//	@Failure	401			{object}	HTTPError
+//	@Failure	403			{object}	HTTPError
+//	@Failure	500			{object}	HTTPError
+//	@Router		/v1/notifications/preferences [get]
+func (h *NotificationHandler) GetNotificationPreferences(c echo.Context) error {
+	userID, ok := c.Get("userID").(uuid.UUID)
+	if !ok {
+		return h.unauthorized(c)
+	}
+
+	preferences, err := h.repository.FindNotificationPreferences(userID)
+	if err != nil {
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in FindNotificationPreferences repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+
+	return c.JSON(http.StatusOK, &preferences)
+}
+
+//	@Summary	Turns notification types on or off for the current user. Types not in the body are kept.
+//	@Tags		Notifications
+//	@Accept		application/json
+//	@Produce	application/json
+//	@Security	ApiSessionCookie
+//	@param		sessionID	header		string							false	"Session ID"
+//	@Param		Preferences	body		[]models.NotificationPreference	true	"Notification preferences"
+//	@Success	200			{object}	[]models.NotificationPreference
+//	@Failure	400			{object}	HTTPError
+//	@Failure	401			{object}	HTTPError
+//	@Failure	403			{object}	HTTPError
+//	@Failure	422			{object}	HTTPError
+//	@Failure	500			{object}	HTTPError
+//	@Router		/v1/notifications/preferences [patch]
+func (h *NotificationHandler) PatchNotificationPreferences(c echo.Context) error {
+	var preferences []models.NotificationPreference
+
+	userID, ok := c.Get("userID").(uuid.UUID)
+	if !ok {
+		return h.unauthorized(c)
+	}
+
+	err := c.Bind(&preferences)
+	if err != nil {
+		e := HTTPError{
+			Code:    http.StatusBadRequest,
+			Message: "Error in PatchNotificationPreferences handler: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusBadRequest, &e)
+	}
+
+	err = h.validator.Var(preferences, "min=1,unique=Type,dive")
+	if err != nil {
+		return h.unprocessable(c, "PatchNotificationPreferences", err)
+	}
+
+	updPreferences, err := h.repository.UpdateNotificationPreferences(userID, preferences)
+	if err != nil {
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in UpdateNotificationPreferences repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+
+	return c.JSON(http.StatusOK, &updPreferences)
+}
+
+func (h *NotificationHandler) unauthorized(c echo.Context) error {
+	e := HTTPError{Code: http.StatusUnauthorized, Message: "No session provided!"}
+	h.logger.Print(&e)
+	return c.JSON(http.StatusUnauthorized, &e)
+}
+
+func (h *NotificationHandler) unprocessable(c echo.Context, handler string, err error) error {
+	e := HTTPError{
+		Code:    http.StatusUnprocessableEntity,
+		Message: "Error in " + handler + " handler: " + err.Error(),
+	}
+	h.logger.Print(&e)
+	return c.JSON(http.StatusUnprocessableEntity, &e)
+}
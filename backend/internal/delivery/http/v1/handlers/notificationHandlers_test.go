@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"errors"
+	"gamehangar/internal/domain/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockNotificationRepo struct {
+	notifications map[int]models.Notification
+	preferences   map[string]bool
+	notFoundErr   error
+}
+
+var (
+	notificationType       = "reply"
+	notificationTargetType = "thread"
+	notificationTargetID   = 1
+	notificationCount      = 5
+
+	mn = mockNotificationRepo{
+		notifications: map[int]models.Notification{1: {
+			ID: &notificationTargetID, UserID: &genericUUID, Type: &notificationType,
+			TargetType: &notificationTargetType, TargetID: &notificationTargetID, Count: &notificationCount,
+		}},
+		preferences: make(map[string]bool),
+		notFoundErr: errors.New("Not Found"),
+	}
+)
+
+func (r *mockNotificationRepo) FindNotifications(f models.NotificationFilter) (*[]models.Notification, error) {
+	var notifications []models.Notification
+	for _, n := range r.notifications {
+		if *n.UserID == f.UserID && (!f.Unread || n.ReadAt == nil) {
+			notifications = append(notifications, n)
+		}
+	}
+	if len(notifications) == 0 {
+		return nil, r.NotFoundErr()
+	}
+	return &notifications, nil
+}
+func (r *mockNotificationRepo) CountUnreadNotifications(userID uuid.UUID) (int, error) {
+	notifications, err := r.FindNotifications(models.NotificationFilter{UserID: userID, Unread: true})
+	if err != nil {
+		return 0, nil
+	}
+	return len(*notifications), nil
+}
+func (r *mockNotificationRepo) MarkNotificationRead(id int, userID uuid.UUID) (*models.Notification, error) {
+	n, ok := r.notifications[id]
+	if !ok || *n.UserID != userID {
+		return nil, r.NotFoundErr()
+	}
+	now := time.Now()
+	n.ReadAt = &now
+	r.notifications[id] = n
+	return &n, nil
+}
+func (r *mockNotificationRepo) MarkAllNotificationsRead(userID uuid.UUID) (int64, error) {
+	var read int64
+	for id, n := range r.notifications {
+		if *n.UserID == userID && n.ReadAt == nil {
+			r.MarkNotificationRead(id, userID)
+			read++
+		}
+	}
+	return read, nil
+}
+func (r *mockNotificationRepo) FindNotificationPreferences(userID uuid.UUID) (*[]models.NotificationPreference, error) {
+	var preferences []models.NotificationPreference
+	for _, t := range models.NotificationTypes {
+		enabled, ok := r.preferences[t]
+		if !ok {
+			enabled = true
+		}
+		preferences = append(preferences, models.NotificationPreference{Type: &t, Enabled: &enabled})
+	}
+	return &preferences, nil
+}
+func (r *mockNotificationRepo) UpdateNotificationPreferences(userID uuid.UUID, preferences []models.NotificationPreference) (*[]models.NotificationPreference, error) {
+	for _, p := range preferences {
+		r.preferences[*p.Type] = *p.Enabled
+	}
+	return r.FindNotificationPreferences(userID)
+}
+func (r *mockNotificationRepo) NotFoundErr() error { return r.notFoundErr }
+
+func TestGetNotifications(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/game-hangar/v1/notifications?unread=true", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set("userID", genericUUID)
+	h := NewNotificationHandler(e, &mn, v)
+
+	// Assertions
+	if assert.NoError(t, h.GetNotifications(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"type":"reply"`)
+		assert.Contains(t, rec.Body.String(), `"count":5`)
+	}
+}
+
+func TestGetNotificationsUnauthorized(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/game-hangar/v1/notifications", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	h := NewNotificationHandler(e, &mn, v)
+
+	// Assertions
+	if assert.NoError(t, h.GetNotifications(c)) {
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	}
+}
+
+func TestReadNotification(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/game-hangar/v1/notifications/:id/read", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues("1")
+	c.Set("userID", uuid.New())
+	h := NewNotificationHandler(e, &mn, v)
+
+	// Assertions
+	if assert.NoError(t, h.ReadNotification(c)) {
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Equal(t, notFoundResponse, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues("1")
+	c.Set("userID", genericUUID)
+
+	if assert.NoError(t, h.ReadNotification(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"readAt":`)
+	}
+}
+
+func TestGetUnreadNotificationCount(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/game-hangar/v1/notifications/unread", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set("userID", genericUUID)
+	h := NewNotificationHandler(e, &mn, v)
+
+	// Assertions
+	if assert.NoError(t, h.GetUnreadNotificationCount(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, `{"unread":0}`+"\n", rec.Body.String())
+	}
+}
+
+func TestPatchNotificationPreferences(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPatch, "/game-hangar/v1/notifications/preferences",
+		strings.NewReader(`[{"type":"vote","enabled":false}]`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set("userID", genericUUID)
+	h := NewNotificationHandler(e, &mn, v)
+
+	// Assertions
+	if assert.NoError(t, h.PatchNotificationPreferences(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, `[{"type":"reply","enabled":true},{"type":"vote","enabled":false},{"type":"moderation","enabled":true},{"type":"mention","enabled":true}]`+"\n", rec.Body.String())
+	}
+}
+
+func TestPatchNotificationPreferencesUnprocessable(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPatch, "/game-hangar/v1/notifications/preferences",
+		strings.NewReader(`[{"type":"digest","enabled":false}]`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set("userID", genericUUID)
+	h := NewNotificationHandler(e, &mn, v)
+
+	// Assertions
+	if assert.NoError(t, h.PatchNotificationPreferences(c)) {
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	}
+}
@@ -7,52 +7,108 @@ import (
 	"github.com/google/uuid"
 )
 
+// Tells moderators of a category apart, see canViewHiddenPost
+type CategoryModerationRepository interface {
+	ModeratesContent(targetType string, targetID int, userID uuid.UUID) (bool, error)
+}
+
 type AssetRepository interface {
 	CreateAsset(asset models.Asset, assetFile, assetThumbnail io.Reader) (*models.Asset, error)
-	FindAssets(query []string, limit uint64, order string) (*[]models.Asset, error)
+	FindAssets(query []string, filter models.ListFilter, page models.PageQuery, order string) (*models.Page[models.Asset], error)
 	FindAssetByID(id int) (*models.Asset, error)
 	UpdateAsset(id int, asset models.Asset, assetFile, assetThumbnail io.Reader) (*models.Asset, error)
-	DeleteAsset(id int) error
+	DeleteAsset(id int, deletedBy uuid.UUID) error
+	RestoreAsset(id int, restoredBy *uuid.UUID) error
+	FindAssetUsages(assetID int) (*[]models.Demo, error)
 	NotFoundErr() error
+	CursorErr() error
 	ConflictErr() error
 }
 
+type AssetLibRepository interface {
+	FindAssetCategories() (*[]models.AssetCategory, error)
+	FindAssetLibAssets(query models.AssetLibQuery) (*[]models.Asset, uint64, error)
+	PeekAssetByID(id int) (*models.Asset, error)
+	NotFoundErr() error
+}
+
 type DemoRepository interface {
 	CreateDemo(demo models.Demo, demoFile, demoThumbnail io.Reader) (*models.Demo, error)
-	FindDemos(query []string, limit uint64, order string) (*[]models.Demo, error)
+	FindDemos(query []string, filter models.ListFilter, page models.PageQuery, order string) (*models.Page[models.Demo], error)
 	FindDemoByID(id int) (*models.Demo, error)
+	PeekDemoByID(id int) (*models.Demo, error)
 	UpdateDemo(id int, demo models.Demo, demoFile, demoThumbnail io.Reader) (*models.Demo, error)
-	DeleteDemo(id int) error
+	DeleteDemo(id int, deletedBy uuid.UUID) error
+	RestoreDemo(id int, restoredBy *uuid.UUID) error
+	FindDemoAssets(demoID int) (*[]models.DemoAsset, error)
+	UpdateDemoAssets(demoID int, demoAssets []models.DemoAsset) (*[]models.DemoAsset, error)
+	ForkDemo(id int, fork models.Demo) (*models.Demo, error)
+	FindDemoForks(id int) (*[]models.Demo, error)
+	FindDemoAncestry(id int) (*[]models.Demo, error)
 	NotFoundErr() error
+	CursorErr() error
+	RemixForbiddenErr() error
 }
 
 type ForumRepository interface {
 	CreateTopic(topic models.Topic) (*models.Topic, error)
-	FindTopics() (*[]models.Topic, error)
+	FindTopics(nested bool) (*[]models.Topic, error)
 	FindTopicByID(id int) (*models.Topic, error)
+	FindTopicBySlug(slug string) (*models.Topic, error)
 	UpdateTopic(id int, topic models.Topic) (*models.Topic, error)
-	DeleteTopic(id int) error
+	DeleteTopic(id int, deletedBy uuid.UUID) error
+	RestoreTopic(id int, restoredBy *uuid.UUID) error
+	CheckThreadRules(topicID int, userID uuid.UUID) error
+	CheckMessageRules(threadID int, userID uuid.UUID) error
 
 	CreateThread(thread models.Thread) (*models.Thread, error)
-	FindThreads(query []string, limit uint64, order string) (*[]models.Thread, error)
+	FindThreads(query []string, filter models.ListFilter, page models.PageQuery, order string) (*models.Page[models.Thread], error)
 	FindThreadByID(id int) (*models.Thread, error)
+	FindThreadVisibility(id int) (*models.Thread, error)
 	UpdateThread(id int, thread models.Thread) (*models.Thread, error)
-	DeleteThread(id int) error
+	DeleteThread(id int, deletedBy uuid.UUID) error
+	RestoreThread(id int, restoredBy *uuid.UUID) error
+	FindThreadsByTopicID(topicID int, limit uint64, order string) (*[]models.Thread, error)
+	CreateThreadOperation(id int, op models.ThreadOperation, managerID *uuid.UUID) (*models.ThreadOperation, error)
+	FindThreadOperations(threadID int) (*[]models.ThreadOperation, error)
 
 	CreateMessage(message models.Message) (*models.Message, error)
-	FindMessages(query []string, limit uint64, order string) (*[]models.Message, error)
-	FindMessagesByThreadID(threadID int) (*[]models.Message, error)
+	FindMessages(query []string, page models.PageQuery, order string) (*models.Page[models.Message], error)
+	FindMessagesByThreadID(threadID int, nested bool, page models.PageQuery) (*models.Page[models.Message], error)
 	FindMessageByID(id int) (*models.Message, error)
 	UpdateMessage(id int, message models.Message) (*models.Message, error)
-	DeleteMessage(id int) error
+	DeleteMessage(id int, deletedBy uuid.UUID) error
+	RestoreMessage(id int, restoredBy *uuid.UUID) error
+	FindMentions(userID uuid.UUID, limit uint64) (*[]models.Message, error)
+	CategoryModerationRepository
 
 	NotFoundErr() error
+	CursorErr() error
 	ConflictErr() error
+	ThreadLockedErr() error
+	ReplyErr() error
+	QuoteErr() error
+	MentionsErr() error
+	ThreadForbiddenErr() error
+	ThreadOperationErr() error
+	DemoThreadErr() error
+	SlugConflictErr() error
+	ParentErr() error
+	ChildrenErr() error
+	ThreadAccessErr() error
+	AccountAgeErr() error
+	TopicTrashedErr() error
+	ThreadTrashedErr() error
+}
+
+type TrashRepository interface {
+	FindTrash(userID uuid.UUID) (*[]models.TrashItem, error)
+	NotFoundErr() error
 }
 
 type UserRepository interface {
 	CreateUser(user models.User, profilePic io.Reader) (*models.User, error)
-	FindUsers(query []string, limit uint64) (*[]models.User, error)
+	FindUsers(query []string, page models.PageQuery) (*models.Page[models.User], error)
 	FindUserByID(id uuid.UUID) (*models.User, error)
 	UpdateUser(id uuid.UUID, user models.User, profilePic io.Reader) (*models.User, error)
 	DeleteUser(id uuid.UUID) error
@@ -66,4 +122,117 @@ type UserRepository interface {
 	DeleteAllUserSessions(userid uuid.UUID) error
 
 	NotFoundErr() error
+	CursorErr() error
+}
+
+type AuditRepository interface {
+	FindAuditEvents(filter models.AuditFilter, page models.PageQuery) (*models.Page[models.AuditEvent], error)
+	NotFoundErr() error
+	CursorErr() error
+}
+
+type ModerationRepository interface {
+	CreateReport(report models.Report) (*models.Report, error)
+	FindReports(filter models.ReportFilter) (*[]models.Report, error)
+	FindReportByID(id int) (*models.Report, error)
+	DismissReport(id int, report models.Report) (*models.Report, error)
+
+	CreateModerationAction(action models.ModerationAction) (*models.ModerationAction, error)
+	FindModerationActions(filter models.ModerationActionFilter) (*[]models.ModerationAction, error)
+	CategoryModerationRepository
+
+	NotFoundErr() error
+	ConflictErr() error
+	UnsupportedActionErr() error
+	OutOfCategoryErr() error
+}
+
+type SanctionRepository interface {
+	FindUserByID(id uuid.UUID) (*models.User, error)
+
+	CreateSanction(sanction models.Sanction) (*models.Sanction, error)
+	FindSanctionByID(id int) (*models.Sanction, error)
+	FindSanctionsByUserID(userID uuid.UUID) (*[]models.Sanction, error)
+	RevokeSanction(id int, revokedBy uuid.UUID) (*models.Sanction, error)
+
+	CreateSanctionNote(note models.SanctionNote) (*models.SanctionNote, error)
+	FindSanctionNotes(sanctionID int) (*[]models.SanctionNote, error)
+
+	NotFoundErr() error
+}
+
+type TopicModeratorRepository interface {
+	CreateTopicModerator(moderator models.TopicModerator) (*models.TopicModerator, error)
+	FindTopicModerators(topicID int) (*[]models.TopicModerator, error)
+	DeleteTopicModerator(topicID int, userID uuid.UUID) error
+
+	NotFoundErr() error
+}
+
+type NotificationRepository interface {
+	FindNotifications(filter models.NotificationFilter) (*[]models.Notification, error)
+	CountUnreadNotifications(userID uuid.UUID) (int, error)
+	MarkNotificationRead(id int, userID uuid.UUID) (*models.Notification, error)
+	MarkAllNotificationsRead(userID uuid.UUID) (int64, error)
+
+	FindNotificationPreferences(userID uuid.UUID) (*[]models.NotificationPreference, error)
+	UpdateNotificationPreferences(userID uuid.UUID, preferences []models.NotificationPreference) (*[]models.NotificationPreference, error)
+
+	NotFoundErr() error
+}
+
+type MailRepository interface {
+	FindEmailPreferences(userID uuid.UUID) (*models.EmailPreferences, error)
+	UpdateEmailPreferences(userID uuid.UUID, preferences models.EmailPreferences) (*models.EmailPreferences, error)
+	Unsubscribe(token uuid.UUID) error
+
+	NotFoundErr() error
+}
+
+type SubscriptionRepository interface {
+	CreateSubscription(subscription models.Subscription) (*models.Subscription, error)
+	FindSubscriptions(filter models.SubscriptionFilter) (*[]models.Subscription, error)
+	DeleteSubscription(userID uuid.UUID, targetType, target string) error
+	CountSubscribers(targetType, target string) (int, error)
+
+	FindFeed(filter models.FeedFilter) (*[]models.FeedItem, error)
+
+	NotFoundErr() error
+	ConflictErr() error
+}
+
+type LiveRepository interface {
+	FindLiveThread(id int) (*models.Thread, error)
+	CategoryModerationRepository
+	NotFoundErr() error
+}
+
+type RevisionRepository interface {
+	FindRevisions(targetType string, id int) (*models.RevisionHistory, error)
+	CategoryModerationRepository
+
+	NotFoundErr() error
+	UnsupportedErr() error
+}
+
+type TagRepository interface {
+	FindTags(filter models.TagFilter) (*[]models.Tag, error)
+	FindTagBySlug(slug string) (*models.Tag, error)
+	UpdateTag(slug string, tag models.Tag) (*models.Tag, error)
+
+	CreateTagAlias(slug string, alias models.TagAlias, userID uuid.UUID) (*models.Tag, error)
+	DeleteTagAlias(slug, alias string) error
+	MergeTags(slug string, merge models.TagMerge, userID uuid.UUID) (*models.Tag, error)
+
+	NotFoundErr() error
+	ConflictErr() error
+	RenameErr() error
+	MergeErr() error
+}
+
+type SearchRepository interface {
+	Search(query models.SearchQuery) (*models.SearchResults, error)
+
+	NotFoundErr() error
+	CursorErr() error
 }
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	_ "gamehangar/docs"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+type RevisionHandler struct {
+	logger     echo.Logger
+	repository RevisionRepository
+	validator  *validator.Validate
+}
+
+func NewRevisionHandler(e *echo.Echo, repo RevisionRepository, v *validator.Validate) *RevisionHandler {
+	return &RevisionHandler{
+		logger:     e.Logger,
+		repository: repo,
+		validator:  v,
+	}
+}
+
+//	@Summary		Fetches the edit history of a message.
+//	@Description	Each revision holds the content before an edit and the changes the edit made to it.
+//	@Description	Revisions of hidden messages are only shown to moderators and to moderators of their category.
+//	@Tags			Revisions
+//	@Produce		application/json
+//	@Param			id	path		int	true	"Get Revisions of Message of ID"
+//	@Success		200	{object}	models.RevisionHistory
+//	@Failure		404	{object}	HTTPError
+//	@Failure		422	{object}	HTTPError
+//	@Failure		500	{object}	HTTPError
+//	@Router			/v1/messages/{id}/revisions [get]
+func (h *RevisionHandler) GetMessageRevisions(c echo.Context) error {
+	return h.getRevisions(c, "message")
+}
+
+//	@Summary		Fetches the edit history of a thread.
+//	@Description	Each revision holds the content before an edit and the changes the edit made to it.
+//	@Description	Revisions of hidden threads are only shown to moderators and to moderators of their category.
+//	@Tags			Revisions
+//	@Produce		application/json
+//	@Param			id	path		int	true	"Get Revisions of Thread of ID"
+//	@Success		200	{object}	models.RevisionHistory
+//	@Failure		404	{object}	HTTPError
+//	@Failure		422	{object}	HTTPError
+//	@Failure		500	{object}	HTTPError
+//	@Router			/v1/threads/{id}/revisions [get]
+func (h *RevisionHandler) GetThreadRevisions(c echo.Context) error {
+	return h.getRevisions(c, "thread")
+}
+
+//	@Summary		Fetches the edit history of a demo.
+//	@Description	Each revision holds the content before an edit and the changes the edit made to it.
+//	@Description	Revisions of hidden demos are only shown to moderators.
+//	@Tags			Revisions
+//	@Produce		application/json
+//	@Param			id	path		int	true	"Get Revisions of Demo of ID"
+//	@Success		200	{object}	models.RevisionHistory
+//	@Failure		404	{object}	HTTPError
+//	@Failure		422	{object}	HTTPError
+//	@Failure		500	{object}	HTTPError
+//	@Router			/v1/demos/{id}/revisions [get]
+func (h *RevisionHandler) GetDemoRevisions(c echo.Context) error {
+	return h.getRevisions(c, "demo")
+}
+
+func (h *RevisionHandler) getRevisions(c echo.Context, targetType string) error {
+	p := c.Param("id")
+	err := h.validator.Var(p, "required,number")
+	if err != nil {
+		e := HTTPError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Error in GetRevisions handler: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusUnprocessableEntity, &e)
+	}
+	id, _ := strconv.ParseInt(p, 10, 64)
+
+	history, err := h.repository.FindRevisions(targetType, int(id))
+	if err != nil {
+		if err == h.repository.NotFoundErr() {
+			e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusNotFound, &e)
+		}
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in FindRevisions repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	// Revisions may still hold what got the content hidden, so not even its author sees them
+	if history.HiddenAt != nil {
+		visible, err := canViewHiddenPost(c, h.repository, nil, targetType, int(id))
+		if err != nil {
+			e := HTTPError{
+				Code:    http.StatusInternalServerError,
+				Message: "Error in ModeratesContent repository: " + err.Error(),
+			}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusInternalServerError, &e)
+		}
+		if !visible {
+			e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusNotFound, &e)
+		}
+	}
+
+	return c.JSON(http.StatusOK, &history)
+}
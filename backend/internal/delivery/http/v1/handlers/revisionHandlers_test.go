@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"errors"
+	"gamehangar/internal/domain/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockRevisionRepo struct {
+	data           map[string]map[int]models.RevisionHistory
+	notFoundErr    error
+	unsupportedErr error
+}
+
+var (
+	revisionTitle    = "Old title"
+	revisionEditedAt = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	revisionNumber   = 1
+	revisionHiddenAt = time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	mrv = mockRevisionRepo{
+		data:           make(map[string]map[int]models.RevisionHistory),
+		notFoundErr:    errors.New("Not Found"),
+		unsupportedErr: errors.New("Revisions are not supported for this type!"),
+	}
+
+	revisionJSONExpected = `{"targetType":"message","targetID":1,"userID":"` + genericUUID.String() +
+		`","revisions":[{"number":1,"title":"Old title","editedBy":"` + genericUUID.String() +
+		`","editedAt":"2025-01-01T00:00:00Z","diff":{"title":[{"op":"delete","text":"Old title"},{"op":"insert","text":"New title"}]}}]}` + "\n"
+)
+
+func (r *mockRevisionRepo) FindRevisions(targetType string, id int) (*models.RevisionHistory, error) {
+	history, ok := r.data[targetType][id]
+	if !ok {
+		return nil, r.NotFoundErr()
+	}
+	return &history, nil
+}
+func (r *mockRevisionRepo) ModeratesContent(targetType string, targetID int, userID uuid.UUID) (bool, error) {
+	return moderatesMockContent(targetType, userID), nil
+}
+func (r *mockRevisionRepo) NotFoundErr() error    { return r.notFoundErr }
+func (r *mockRevisionRepo) UnsupportedErr() error { return r.unsupportedErr }
+
+func setupRevisions() {
+	targetType, id := "message", 1
+	mrv.data["message"] = map[int]models.RevisionHistory{1: {
+		TargetType: &targetType, TargetID: &id, UserID: &genericUUID,
+		Revisions: []models.Revision{{
+			Number: &revisionNumber, Title: &revisionTitle, EditedBy: &genericUUID, EditedAt: &revisionEditedAt,
+			Diff: &models.Diff{Title: []models.DiffLine{{Op: "delete", Text: "Old title"}, {Op: "insert", Text: "New title"}}},
+		}},
+	}}
+	hiddenType, hiddenID := "thread", 2
+	mrv.data["thread"] = map[int]models.RevisionHistory{2: {
+		TargetType: &hiddenType, TargetID: &hiddenID, UserID: &genericUUID, HiddenAt: &revisionHiddenAt,
+		Revisions: []models.Revision{},
+	}}
+}
+
+func TestGetMessageRevisions(t *testing.T) {
+	// Setup
+	setupRevisions()
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/game-hangar/v1/messages/:id/revisions")
+	c.SetParamNames("id")
+	c.SetParamValues("1")
+	h := &RevisionHandler{logger: e.Logger, repository: &mrv, validator: validator.New()}
+
+	// Assertions
+	if assert.NoError(t, h.GetMessageRevisions(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, revisionJSONExpected, rec.Body.String())
+	}
+}
+
+func TestGetRevisionsNotFound(t *testing.T) {
+	// Setup
+	setupRevisions()
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/game-hangar/v1/demos/:id/revisions")
+	c.SetParamNames("id")
+	c.SetParamValues("1")
+	h := &RevisionHandler{logger: e.Logger, repository: &mrv, validator: validator.New()}
+
+	// Assertions
+	if assert.NoError(t, h.GetDemoRevisions(c)) {
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Equal(t, notFoundResponse, rec.Body.String())
+	}
+}
+
+func TestGetRevisionsInvalidID(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/game-hangar/v1/messages/:id/revisions")
+	c.SetParamNames("id")
+	c.SetParamValues("abc")
+	h := &RevisionHandler{logger: e.Logger, repository: &mrv, validator: validator.New()}
+
+	// Assertions
+	if assert.NoError(t, h.GetMessageRevisions(c)) {
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	}
+}
+
+func TestGetRevisionsHidden(t *testing.T) {
+	setupRevisions()
+	tests := []struct {
+		name string
+		tier string
+		user *uuid.UUID
+		code int
+	}{
+		{name: "anonymous", code: http.StatusNotFound},
+		{name: "author", tier: "user", user: &genericUUID, code: http.StatusNotFound},
+		{name: "moderator", tier: "moderator", user: &genericUUID, code: http.StatusOK},
+		{name: "category moderator", tier: "user", user: &categoryModeratorID, code: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetPath("/game-hangar/v1/threads/:id/revisions")
+			c.SetParamNames("id")
+			c.SetParamValues("2")
+			if tt.user != nil {
+				c.Set("userTier", tt.tier)
+				c.Set("userID", *tt.user)
+			}
+			h := &RevisionHandler{logger: e.Logger, repository: &mrv, validator: validator.New()}
+
+			// Assertions
+			if assert.NoError(t, h.GetThreadRevisions(c)) {
+				assert.Equal(t, tt.code, rec.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,313 @@
+package handlers
+
+import (
+	"gamehangar/internal/domain/models"
+	"net/http"
+	"strconv"
+
+	_ "gamehangar/docs"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type SanctionHandler struct {
+	logger     echo.Logger
+	repository SanctionRepository
+	validator  *validator.Validate
+}
+
+func NewSanctionHandler(e *echo.Echo, repo SanctionRepository, v *validator.Validate) *SanctionHandler {
+	return &SanctionHandler{
+		logger:     e.Logger,
+		repository: repo,
+		validator:  v,
+	}
+}
+
+//	@Summary	Fetches the sanction history of the User, newest first.
+//	@Tags		Sanctions
+//	@Produce	application/json
+//	@Security	ApiSessionCookie
+//	@param		sessionID	header		string	false	"Session ID"
+//	@Param		id			path		string	true	"User ID"
+//	@Success	200			{object}	[]models.Sanction
+//	@Failure	403			{object}	HTTPError
+//	@Failure	404			{object}	HTTPError
+//	@Failure	422			{object}	HTTPError
+//	@Failure	500			{object}	HTTPError
+//	@Router		/v1/users/{id}/sanctions [get]
+func (h *SanctionHandler) GetUserSanctions(c echo.Context) error {
+	p := c.Param("id")
+	err := h.validator.Var(p, "required,uuid")
+	if err != nil {
+		return h.unprocessable(c, "GetUserSanctions", err)
+	}
+	userID, _ := uuid.Parse(p)
+
+	sanctions, err := h.repository.FindSanctionsByUserID(userID)
+	if err != nil {
+		if err == h.repository.NotFoundErr() {
+			e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusNotFound, &e)
+		}
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in FindSanctionsByUserID repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+
+	return c.JSON(http.StatusOK, &sanctions)
+}
+
+//	@Summary	Bans or mutes the User until expiresAt or, if it is omitted, until revoked. A ban also ends all of the User's sessions.
+//	@Tags		Sanctions
+//	@Accept		application/json
+//	@Produce	application/json
+//	@Security	ApiSessionCookie
+//	@param		sessionID	header		string			false	"Session ID"
+//	@Param		id			path		string			true	"User ID"
+//	@Param		Sanction	body		models.Sanction	true	"Create Sanction"
+//	@Success	201			{object}	models.Sanction
+//	@Failure	400			{object}	HTTPError
+//	@Failure	401			{object}	HTTPError
+//	@Failure	403			{object}	HTTPError
+//	@Failure	404			{object}	HTTPError
+//	@Failure	422			{object}	HTTPError
+//	@Failure	500			{object}	HTTPError
+//	@Router		/v1/users/{id}/sanctions [post]
+func (h *SanctionHandler) PostSanction(c echo.Context) error {
+	var sanction models.Sanction
+
+	moderatorID, ok := c.Get("userID").(uuid.UUID)
+	if !ok {
+		e := HTTPError{Code: http.StatusUnauthorized, Message: "No session provided!"}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusUnauthorized, &e)
+	}
+
+	p := c.Param("id")
+	err := h.validator.Var(p, "required,uuid")
+	if err != nil {
+		return h.unprocessable(c, "PostSanction", err)
+	}
+	userID, _ := uuid.Parse(p)
+
+	err = c.Bind(&sanction)
+	if err != nil {
+		e := HTTPError{
+			Code:    http.StatusBadRequest,
+			Message: "Error in PostSanction handler: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusBadRequest, &e)
+	}
+	sanction.UserID, sanction.IssuedBy = &userID, &moderatorID
+
+	err = h.validator.Struct(&sanction)
+	if err != nil {
+		return h.unprocessable(c, "PostSanction", err)
+	}
+
+	_, err = h.repository.FindUserByID(userID)
+	if err != nil {
+		if err == h.repository.NotFoundErr() {
+			e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusNotFound, &e)
+		}
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in FindUserByID repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+
+	newSanction, err := h.repository.CreateSanction(sanction)
+	if err != nil {
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in CreateSanction repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+
+	return c.JSON(http.StatusCreated, &newSanction)
+}
+
+//	@Summary	Lifts a sanction before it expires.
+//	@Tags		Sanctions
+//	@Produce	application/json
+//	@Security	ApiSessionCookie
+//	@param		sessionID	header		string	false	"Session ID"
+//	@Param		id			path		int		true	"Sanction ID"
+//	@Success	200			{object}	models.Sanction
+//	@Failure	401			{object}	HTTPError
+//	@Failure	403			{object}	HTTPError
+//	@Failure	404			{object}	HTTPError
+//	@Failure	422			{object}	HTTPError
+//	@Failure	500			{object}	HTTPError
+//	@Router		/v1/sanctions/{id}/revoke [post]
+func (h *SanctionHandler) RevokeSanction(c echo.Context) error {
+	moderatorID, ok := c.Get("userID").(uuid.UUID)
+	if !ok {
+		e := HTTPError{Code: http.StatusUnauthorized, Message: "No session provided!"}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusUnauthorized, &e)
+	}
+
+	p := c.Param("id")
+	err := h.validator.Var(p, "required,number")
+	if err != nil {
+		return h.unprocessable(c, "RevokeSanction", err)
+	}
+	id, _ := strconv.ParseInt(p, 10, 64)
+
+	sanction, err := h.repository.RevokeSanction(int(id), moderatorID)
+	if err != nil {
+		if err == h.repository.NotFoundErr() {
+			e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusNotFound, &e)
+		}
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in RevokeSanction repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+
+	return c.JSON(http.StatusOK, &sanction)
+}
+
+//	@Summary	Fetches the appeal notes of a sanction, oldest first.
+//	@Tags		Sanctions
+//	@Produce	application/json
+//	@Security	ApiSessionCookie
+//	@param		sessionID	header		string	false	"Session ID"
+//	@Param		id			path		int		true	"Sanction ID"
+//	@Success	200			{object}	[]models.SanctionNote
+//	@Failure	403			{object}	HTTPError
+//	@Failure	404			{object}	HTTPError
+//	@Failure	422			{object}	HTTPError
+//	@Failure	500			{object}	HTTPError
+//	@Router		/v1/sanctions/{id}/notes [get]
+func (h *SanctionHandler) GetSanctionNotes(c echo.Context) error {
+	p := c.Param("id")
+	err := h.validator.Var(p, "required,number")
+	if err != nil {
+		return h.unprocessable(c, "GetSanctionNotes", err)
+	}
+	id, _ := strconv.ParseInt(p, 10, 64)
+
+	notes, err := h.repository.FindSanctionNotes(int(id))
+	if err != nil {
+		if err == h.repository.NotFoundErr() {
+			e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusNotFound, &e)
+		}
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in FindSanctionNotes repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+
+	return c.JSON(http.StatusOK, &notes)
+}
+
+//	@Summary	Appeals a sanction or replies to an appeal.
+//	@Tags		Sanctions
+//	@Accept		application/json
+//	@Produce	application/json
+//	@Security	ApiSessionCookie
+//	@param		sessionID		header		string				false	"Session ID"
+//	@Param		id				path		int					true	"Sanction ID"
+//	@Param		SanctionNote	body		models.SanctionNote	true	"Create SanctionNote"
+//	@Success	201				{object}	models.SanctionNote
+//	@Failure	400				{object}	HTTPError
+//	@Failure	401				{object}	HTTPError
+//	@Failure	403				{object}	HTTPError
+//	@Failure	404				{object}	HTTPError
+//	@Failure	422				{object}	HTTPError
+//	@Failure	500				{object}	HTTPError
+//	@Router		/v1/sanctions/{id}/notes [post]
+func (h *SanctionHandler) PostSanctionNote(c echo.Context) error {
+	var note models.SanctionNote
+
+	userID, ok := c.Get("userID").(uuid.UUID)
+	if !ok {
+		e := HTTPError{Code: http.StatusUnauthorized, Message: "No session provided!"}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusUnauthorized, &e)
+	}
+
+	p := c.Param("id")
+	err := h.validator.Var(p, "required,number")
+	if err != nil {
+		return h.unprocessable(c, "PostSanctionNote", err)
+	}
+	id, _ := strconv.ParseInt(p, 10, 64)
+
+	err = c.Bind(&note)
+	if err != nil {
+		e := HTTPError{
+			Code:    http.StatusBadRequest,
+			Message: "Error in PostSanctionNote handler: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusBadRequest, &e)
+	}
+	sanctionID := int(id)
+	note.SanctionID, note.AuthorID = &sanctionID, &userID
+
+	err = h.validator.Struct(&note)
+	if err != nil {
+		return h.unprocessable(c, "PostSanctionNote", err)
+	}
+
+	_, err = h.repository.FindSanctionByID(sanctionID)
+	if err != nil {
+		if err == h.repository.NotFoundErr() {
+			e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusNotFound, &e)
+		}
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in FindSanctionByID repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+
+	newNote, err := h.repository.CreateSanctionNote(note)
+	if err != nil {
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in CreateSanctionNote repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+
+	return c.JSON(http.StatusCreated, &newNote)
+}
+
+func (h *SanctionHandler) unprocessable(c echo.Context, handler string, err error) error {
+	e := HTTPError{
+		Code:    http.StatusUnprocessableEntity,
+		Message: "Error in " + handler + " handler: " + err.Error(),
+	}
+	h.logger.Print(&e)
+	return c.JSON(http.StatusUnprocessableEntity, &e)
+}
@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"errors"
+	"gamehangar/internal/domain/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockSanctionRepo struct {
+	sanctions   map[int]models.Sanction
+	notes       []models.SanctionNote
+	notFoundErr error
+}
+
+var (
+	ms = mockSanctionRepo{
+		sanctions:   make(map[int]models.Sanction, 1),
+		notFoundErr: errors.New("Not Found"),
+	}
+
+	sanctionJSON     = `{"type":"ban","reason":"Ban evasion"}`
+	sanctionNoteJSON = `{"body":"It was my brother"}`
+)
+
+func (r *mockSanctionRepo) FindUserByID(id uuid.UUID) (*models.User, error) {
+	if id != genericUUID {
+		return nil, r.NotFoundErr()
+	}
+	return &models.User{ID: &id}, nil
+}
+func (r *mockSanctionRepo) CreateSanction(sanction models.Sanction) (*models.Sanction, error) {
+	id := len(r.sanctions) + 1
+	sanction.ID = &id
+	r.sanctions[id] = sanction
+	return &sanction, nil
+}
+func (r *mockSanctionRepo) FindSanctionByID(id int) (*models.Sanction, error) {
+	sanction, ok := r.sanctions[id]
+	if !ok {
+		return nil, r.NotFoundErr()
+	}
+	return &sanction, nil
+}
+func (r *mockSanctionRepo) FindSanctionsByUserID(userID uuid.UUID) (*[]models.Sanction, error) {
+	var sanctions []models.Sanction
+	for _, s := range r.sanctions {
+		if *s.UserID == userID {
+			sanctions = append(sanctions, s)
+		}
+	}
+	if len(sanctions) == 0 {
+		return nil, r.NotFoundErr()
+	}
+	return &sanctions, nil
+}
+func (r *mockSanctionRepo) RevokeSanction(id int, revokedBy uuid.UUID) (*models.Sanction, error) {
+	sanction, ok := r.sanctions[id]
+	if !ok || sanction.RevokedAt != nil {
+		return nil, r.NotFoundErr()
+	}
+	now := time.Now()
+	sanction.RevokedAt, sanction.RevokedBy = &now, &revokedBy
+	r.sanctions[id] = sanction
+	return &sanction, nil
+}
+func (r *mockSanctionRepo) CreateSanctionNote(note models.SanctionNote) (*models.SanctionNote, error) {
+	id := len(r.notes) + 1
+	note.ID = &id
+	r.notes = append(r.notes, note)
+	return &note, nil
+}
+func (r *mockSanctionRepo) FindSanctionNotes(sanctionID int) (*[]models.SanctionNote, error) {
+	var notes []models.SanctionNote
+	for _, n := range r.notes {
+		if *n.SanctionID == sanctionID {
+			notes = append(notes, n)
+		}
+	}
+	if len(notes) == 0 {
+		return nil, r.NotFoundErr()
+	}
+	return &notes, nil
+}
+func (r *mockSanctionRepo) NotFoundErr() error { return r.notFoundErr }
+
+func TestPostSanction(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/game-hangar/v1/users/:id/sanctions", strings.NewReader(sanctionJSON))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues(genericUUID.String())
+	c.Set("userID", uuid.New())
+	h := NewSanctionHandler(e, &ms, v)
+
+	// Assertions
+	if assert.NoError(t, h.PostSanction(c)) {
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"type":"ban"`)
+		assert.Contains(t, rec.Body.String(), `"userID":"`+genericUUID.String()+`"`)
+	}
+}
+
+func TestPostSanctionUnprocessable(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/game-hangar/v1/users/:id/sanctions",
+		strings.NewReader(`{"type":"kick","reason":"Ban evasion"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues(genericUUID.String())
+	c.Set("userID", uuid.New())
+	h := NewSanctionHandler(e, &ms, v)
+
+	// Assertions
+	if assert.NoError(t, h.PostSanction(c)) {
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	}
+}
+
+func TestPostSanctionNotFound(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/game-hangar/v1/users/:id/sanctions", strings.NewReader(sanctionJSON))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues(uuid.New().String())
+	c.Set("userID", uuid.New())
+	h := NewSanctionHandler(e, &ms, v)
+
+	// Assertions
+	if assert.NoError(t, h.PostSanction(c)) {
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Equal(t, notFoundResponse, rec.Body.String())
+	}
+}
+
+func TestGetUserSanctions(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/game-hangar/v1/users/:id/sanctions", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues(genericUUID.String())
+	h := NewSanctionHandler(e, &ms, v)
+
+	// Assertions
+	if assert.NoError(t, h.GetUserSanctions(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"reason":"Ban evasion"`)
+	}
+}
+
+func TestPostSanctionNote(t *testing.T) {
+	// Setup
+	e := echo.New()
+	h := NewSanctionHandler(e, &ms, v)
+	post := func(id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/game-hangar/v1/sanctions/:id/notes", strings.NewReader(sanctionNoteJSON))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues(id)
+		c.Set("userID", genericUUID)
+		assert.NoError(t, h.PostSanctionNote(c))
+		return rec
+	}
+
+	// Assertions
+	rec := post("1")
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"authorID":"`+genericUUID.String()+`"`)
+	assert.Equal(t, http.StatusNotFound, post("999").Code)
+}
+
+func TestGetSanctionNotes(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/game-hangar/v1/sanctions/:id/notes", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues("1")
+	h := NewSanctionHandler(e, &ms, v)
+
+	// Assertions
+	if assert.NoError(t, h.GetSanctionNotes(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"body":"It was my brother"`)
+	}
+}
+
+func TestRevokeSanction(t *testing.T) {
+	// Setup
+	e := echo.New()
+	h := NewSanctionHandler(e, &ms, v)
+	revoke := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/game-hangar/v1/sanctions/:id/revoke", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+		c.Set("userID", uuid.New())
+		assert.NoError(t, h.RevokeSanction(c))
+		return rec
+	}
+
+	// Assertions
+	rec := revoke()
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"revokedAt":`)
+	assert.Equal(t, http.StatusNotFound, revoke().Code) // Already revoked
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"gamehangar/internal/domain/models"
+	"net/http"
+	"strings"
+
+	_ "gamehangar/docs"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+type SearchHandler struct {
+	logger     echo.Logger
+	repository SearchRepository
+	validator  *validator.Validate
+}
+
+func NewSearchHandler(e *echo.Echo, repo SearchRepository, v *validator.Validate) *SearchHandler {
+	return &SearchHandler{
+		logger:     e.Logger,
+		repository: repo,
+		validator:  v,
+	}
+}
+
+//	@Summary		Searches demos, assets, threads, messages and users together.
+//	@Description	Each type is paged on its own, most relevant first. Content also matches when tagged with a word of the query.
+//	@Description	Facets count the matches of every type and their most used tags, whichever types are searched.
+//	@Tags			Search
+//	@Produce		application/json
+//	@Param			q		query		string		true	"Search query, in web search syntax"
+//	@Param			type	query		[]string	false	"Types to return results of. Default all"	Enums(demo, asset, thread, message, user)
+//	@Param			tag		query		[]string	false	"Tags the content has, users are left out"
+//	@Param			l		query		int			false	"Page size of each type, at most 100. Default 20"
+//	@Param			c		query		string		false	"Page cursor from the next or prev of a previous page, for a single type"
+//	@Success		200		{object}	models.SearchResults
+//	@Failure		404		{object}	HTTPError
+//	@Failure		422		{object}	HTTPError
+//	@Failure		500		{object}	HTTPError
+//	@Router			/v1/search [get]
+func (h *SearchHandler) Search(c echo.Context) error {
+	query := models.SearchQuery{Query: c.QueryParam("q"), Types: models.SearchTypes}
+
+	err := h.validator.Var(query.Query, "required,max=200")
+	if err != nil {
+		return h.unprocessable(c, "Search", err)
+	}
+	page, ok, err := parsePageQuery(c, h.logger, h.validator, "Search")
+	if !ok {
+		return err
+	}
+	query.Page = page
+
+	if types := c.Request().URL.Query()["type"]; types != nil {
+		err = h.validator.Var(types, "unique,dive,oneof="+strings.Join(models.SearchTypes, " "))
+		if err != nil {
+			return h.unprocessable(c, "Search", err)
+		}
+		query.Types = types
+	}
+	if tags := c.Request().URL.Query()["tag"]; tags != nil {
+		err = h.validator.Var(tags, "max=40,dive,max=64,tag")
+		if err != nil {
+			return h.unprocessable(c, "Search", err)
+		}
+		query.Tags = tags
+	}
+
+	results, err := h.repository.Search(query)
+	if err != nil {
+		if err == h.repository.NotFoundErr() {
+			e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusNotFound, &e)
+		}
+		if err == h.repository.CursorErr() {
+			e := HTTPError{Code: http.StatusUnprocessableEntity, Message: err.Error()}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusUnprocessableEntity, &e)
+		}
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in Search repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+
+	return c.JSON(http.StatusOK, &results)
+}
+
+func (h *SearchHandler) unprocessable(c echo.Context, handler string, err error) error {
+	e := HTTPError{
+		Code:    http.StatusUnprocessableEntity,
+		Message: "Error in " + handler + " handler: " + err.Error(),
+	}
+	h.logger.Print(&e)
+	return c.JSON(http.StatusUnprocessableEntity, &e)
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"errors"
+	"gamehangar/internal/domain/models"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockSearchRepo struct {
+	query       models.SearchQuery
+	notFoundErr error
+	cursorErr   error
+}
+
+var msr = mockSearchRepo{
+	notFoundErr: errors.New("Not Found"),
+	cursorErr:   errors.New("Cursor is not valid!"),
+}
+
+// Only "platformer" matches, a demo and a user
+func (r *mockSearchRepo) Search(q models.SearchQuery) (*models.SearchResults, error) {
+	r.query = q
+	if q.Query != "platformer" {
+		return nil, r.notFoundErr
+	}
+	if q.Page.Cursor != "" && len(q.Types) != 1 {
+		return nil, r.cursorErr
+	}
+
+	results := models.SearchResults{
+		Results: map[string]*models.Page[models.SearchResult]{},
+		Facets: models.SearchFacets{
+			Types: map[string]int64{"demo": 1, "asset": 0, "thread": 0, "message": 0, "user": 1},
+			Tags:  map[string]int64{"2d": 1},
+		},
+	}
+	for _, t := range q.Types {
+		page := models.Page[models.SearchResult]{}
+		if t == "demo" || t == "user" {
+			id, headline, rank := "1", "A <b>platformer</b>", 0.6
+			page.Items = []models.SearchResult{{Type: &t, ID: &id, Title: &headline, Headline: &headline, Rank: &rank}}
+			page.Total = 1
+		}
+		results.Results[t] = &page
+	}
+	return &results, nil
+}
+func (r *mockSearchRepo) NotFoundErr() error { return r.notFoundErr }
+func (r *mockSearchRepo) CursorErr() error   { return r.cursorErr }
+
+func TestSearch(t *testing.T) {
+	// Setup
+	e := echo.New()
+	h := NewSearchHandler(e, &msr, v)
+	search := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/game-hangar/v1/search?"+query, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		assert.NoError(t, h.Search(c))
+		return rec
+	}
+
+	// Assertions
+	rec := search("q=platformer")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, models.SearchTypes, msr.query.Types)
+	assert.Contains(t, rec.Body.String(), `"headline":"A \u003cb\u003eplatformer\u003c/b\u003e","rank":0.6`)
+	assert.Contains(t, rec.Body.String(), `"facets":{"types":{"asset":0,"demo":1,"message":0,"thread":0,"user":1},"tags":{"2d":1}}`)
+
+	rec = search("q=platformer&type=demo&type=asset&tag=2D&l=5")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, slices.Equal([]string{"demo", "asset"}, msr.query.Types))
+	assert.Equal(t, []string{"2D"}, msr.query.Tags)
+	assert.Equal(t, uint64(5), msr.query.Page.Limit)
+	assert.NotContains(t, rec.Body.String(), `"user":{`)
+
+	assert.Equal(t, http.StatusOK, search("q=platformer&type=demo&c=next").Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, search("q=platformer&c=next").Code)
+	assert.Equal(t, http.StatusNotFound, search("q=shooter").Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, search("q=").Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, search("q=platformer&type=topic").Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, search("q=platformer&type=demo&type=demo").Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, search("q=platformer&tag=--").Code)
+}
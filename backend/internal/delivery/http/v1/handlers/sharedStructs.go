@@ -1,5 +1,53 @@
 package handlers
 
+import (
+	"errors"
+	"fmt"
+	"gamehangar/internal/domain/models"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	defaultPageSize uint64 = 20  // Page size of listings that do not ask for one
+	maxPageSize     uint64 = 100 // Larger page sizes are cut down to it
+)
+
+// Query parameters filtering each listing, see models.ListFilter
+var (
+	demoFilters = []string{"author", "tag", "tagMatch", "notTag", "createdFrom", "createdTo", "updatedFrom", "updatedTo",
+		"minRating", "minSize", "maxSize"}
+	assetFilters = []string{"tag", "tagMatch", "notTag", "createdFrom", "createdTo", "updatedFrom", "updatedTo",
+		"minRating", "godotVersion", "minSize", "maxSize"}
+	threadFilters = []string{"author", "tag", "tagMatch", "notTag", "topic", "createdFrom", "createdTo", "updatedFrom", "updatedTo",
+		"minRating"}
+	listFilters = []string{"author", "tag", "tagMatch", "notTag", "topic", "createdFrom", "createdTo", "updatedFrom", "updatedTo",
+		"minRating", "godotVersion", "minSize", "maxSize"}
+)
+
+// Tags need a letter or digit, otherwise their slug would be empty
+var tagRegexp = regexp.MustCompile(`[\p{L}\p{N}]`)
+
+// Returns the validator of request bodies, with the "tag" validation of tag names and
+// the "godotversion" validation of engine versions registered
+func NewValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	v.RegisterValidation("tag", func(fl validator.FieldLevel) bool {
+		return tagRegexp.MatchString(fl.Field().String())
+	})
+	v.RegisterValidation("godotversion", func(fl validator.FieldLevel) bool {
+		return godotVersionRegexp.MatchString(fl.Field().String())
+	})
+	return v
+}
+
 type HTTPError struct {
 	Code    int    `json:"code"`
 	Message string `json:"message"`
@@ -11,3 +59,197 @@ type ObjectUploader interface {
 	ObjectTooLargeErr() error
 	ObjectNotFoundErr() error
 }
+
+type ContentFilter interface {
+	CheckContent(subject models.FilterSubject) (*models.FilterVerdict, error)
+	HoldForReview(targetType string, targetID int, verdict models.FilterVerdict) error
+	Remember(subject models.FilterSubject)
+}
+
+// Renders user-authored Markdown to sanitized HTML
+type MarkdownRenderer interface {
+	Render(source string) (string, error)
+}
+
+type CaptchaVerifier interface {
+	VerifyCaptcha(token, remoteIP string) (bool, error)
+}
+
+// Renders the Markdown source to the HTML stored alongside it, a nil source clears html.
+// Returns false when rendering failed, in which case the response is already sent
+func renderMarkdown(c echo.Context, logger echo.Logger, m MarkdownRenderer, source *string, html **string) (bool, error) {
+	*html = nil
+	if source == nil {
+		return true, nil
+	}
+
+	rendered, err := m.Render(*source)
+	if err != nil {
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in Render markdown: " + err.Error(),
+		}
+		logger.Print(&e)
+		return false, c.JSON(http.StatusInternalServerError, &e)
+	}
+	*html = &rendered
+	return true, nil
+}
+
+// Reads the page size l and the cursor c of a listing. Returns false when the page size is malformed,
+// in which case the response is already sent
+func parsePageQuery(c echo.Context, logger echo.Logger, v *validator.Validate, handler string) (models.PageQuery, bool, error) {
+	page := models.PageQuery{Limit: defaultPageSize, Cursor: c.QueryParam("c")}
+
+	l := c.QueryParam("l")
+	if l == "" {
+		return page, true, nil
+	}
+	err := v.Var(l, "number")
+	if err != nil {
+		e := HTTPError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Error in " + handler + " handler: " + err.Error(),
+		}
+		logger.Print(&e)
+		return page, false, c.JSON(http.StatusUnprocessableEntity, &e)
+	}
+	limit, err := strconv.ParseUint(l, 10, 64)
+	if err != nil || limit > maxPageSize {
+		limit = maxPageSize
+	}
+	if limit != 0 {
+		page.Limit = limit
+	}
+	return page, true, nil
+}
+
+// Reads the filters of a listing, which supports the given filter parameters. Returns false when a filter
+// is malformed or not supported by the listing, in which case the response is already sent
+func parseListFilter(c echo.Context, logger echo.Logger, v *validator.Validate, handler string, supported []string) (models.ListFilter, bool, error) {
+	filter, err := readListFilter(c, v, supported)
+	if err != nil {
+		e := HTTPError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Error in " + handler + " handler: " + err.Error(),
+		}
+		logger.Print(&e)
+		return filter, false, c.JSON(http.StatusUnprocessableEntity, &e)
+	}
+	return filter, true, nil
+}
+
+func readListFilter(c echo.Context, v *validator.Validate, supported []string) (models.ListFilter, error) {
+	var (
+		f     models.ListFilter
+		query = c.QueryParams()
+	)
+
+	for _, name := range listFilters {
+		if query.Has(name) && !slices.Contains(supported, name) {
+			return f, fmt.Errorf("filter %v is not supported by this listing", name)
+		}
+	}
+
+	if p := query.Get("author"); p != "" {
+		err := v.Var(p, "uuid")
+		if err != nil {
+			return f, fmt.Errorf("invalid author: %w", err)
+		}
+		id := uuid.MustParse(p)
+		f.UserID = &id
+	}
+
+	if tags := query["tag"]; tags != nil {
+		err := v.Var(tags, "max=40,dive,required")
+		if err != nil {
+			return f, fmt.Errorf("invalid tag: %w", err)
+		}
+		f.Tags = tags
+	}
+	if p := query.Get("tagMatch"); p != "" {
+		err := v.Var(p, "oneof=all any")
+		if err != nil {
+			return f, fmt.Errorf("invalid tagMatch: %w", err)
+		}
+		f.AnyTag = p == "any"
+	}
+	if tags := query["notTag"]; tags != nil {
+		err := v.Var(tags, "max=40,dive,required")
+		if err != nil {
+			return f, fmt.Errorf("invalid notTag: %w", err)
+		}
+		f.ExcludeTags = tags
+	}
+
+	if p := query.Get("topic"); p != "" {
+		err := v.Var(p, "number,gt=0")
+		if err != nil {
+			return f, fmt.Errorf("invalid topic: %w", err)
+		}
+		id, err := strconv.Atoi(p)
+		if err != nil {
+			return f, fmt.Errorf("invalid topic: %w", err)
+		}
+		f.TopicID = &id
+	}
+
+	for name, t := range map[string]**time.Time{
+		"createdFrom": &f.CreatedFrom,
+		"createdTo":   &f.CreatedTo,
+		"updatedFrom": &f.UpdatedFrom,
+		"updatedTo":   &f.UpdatedTo,
+	} {
+		if p := query.Get(name); p != "" {
+			at, err := time.Parse(time.RFC3339, p)
+			if err != nil {
+				return f, fmt.Errorf("invalid %v: %w", name, err)
+			}
+			*t = &at
+		}
+	}
+	if f.CreatedFrom != nil && f.CreatedTo != nil && !f.CreatedFrom.Before(*f.CreatedTo) {
+		return f, errors.New("createdFrom must be before createdTo")
+	}
+	if f.UpdatedFrom != nil && f.UpdatedTo != nil && !f.UpdatedFrom.Before(*f.UpdatedTo) {
+		return f, errors.New("updatedFrom must be before updatedTo")
+	}
+
+	if p := query.Get("minRating"); p != "" {
+		err := v.Var(p, "numeric")
+		if err != nil {
+			return f, fmt.Errorf("invalid minRating: %w", err)
+		}
+		rating, err := strconv.ParseFloat(p, 64)
+		if err != nil {
+			return f, fmt.Errorf("invalid minRating: %w", err)
+		}
+		f.MinRating = &rating
+	}
+
+	if p := query.Get("godotVersion"); p != "" {
+		if !godotVersionRegexp.MatchString(p) {
+			return f, fmt.Errorf("invalid godotVersion %v", p)
+		}
+		f.GodotVersion = &p
+	}
+
+	for name, size := range map[string]**int64{"minSize": &f.MinSize, "maxSize": &f.MaxSize} {
+		if p := query.Get(name); p != "" {
+			err := v.Var(p, "number")
+			if err != nil {
+				return f, fmt.Errorf("invalid %v: %w", name, err)
+			}
+			bytes, err := strconv.ParseInt(p, 10, 64)
+			if err != nil {
+				return f, fmt.Errorf("invalid %v: %w", name, err)
+			}
+			*size = &bytes
+		}
+	}
+	if f.MinSize != nil && f.MaxSize != nil && *f.MinSize > *f.MaxSize {
+		return f, errors.New("minSize must not exceed maxSize")
+	}
+
+	return f, nil
+}
@@ -0,0 +1,312 @@
+package handlers
+
+import (
+	"gamehangar/internal/domain/models"
+	"net/http"
+	"strconv"
+	"time"
+
+	_ "gamehangar/docs"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+const subscriptionMaxPageLength = 100
+
+// Validation of the target of each subscription type
+var subscriptionTargetRules = map[string]string{
+	"thread": "required,number",
+	"topic":  "required,number",
+	"tag":    "required,max=64,tag",
+	"user":   "required,uuid",
+}
+
+type SubscriptionHandler struct {
+	logger     echo.Logger
+	repository SubscriptionRepository
+	validator  *validator.Validate
+}
+
+func NewSubscriptionHandler(e *echo.Echo, repo SubscriptionRepository, v *validator.Validate) *SubscriptionHandler {
+	return &SubscriptionHandler{
+		logger:     e.Logger,
+		repository: repo,
+		validator:  v,
+	}
+}
+
+//	@Summary	Fetches subscriptions of the current user, most recent first.
+//	@Tags		Subscriptions
+//	@Produce	application/json
+//	@Security	ApiSessionCookie
+//	@param		sessionID	header		string	false	"Session ID"
+//	@Param		type		query		string	false	"Only subscriptions to targets of type: thread, topic, tag or user"
+//	@Param		l			query		int		false	"Limit"
+//	@Param		offset		query		int		false	"Offset"
+//	@Success	200			{object}	[]models.Subscription
+//	@Failure	401			{object}	HTTPError
+//	@Failure	403			{object}	HTTPError
+//	@Failure	404			{object}	HTTPError
+//	@Failure	422			{object}	HTTPError
+//	@Failure	500			{object}	HTTPError
+//	@Router		/v1/subscriptions [get]
+func (h *SubscriptionHandler) GetSubscriptions(c echo.Context) error {
+	filter := models.SubscriptionFilter{Limit: subscriptionMaxPageLength}
+
+	userID, ok := c.Get("userID").(uuid.UUID)
+	if !ok {
+		return h.unauthorized(c)
+	}
+	filter.UserID = userID
+
+	if p := c.QueryParam("type"); p != "" {
+		err := h.validator.Var(p, "oneof=thread topic tag user")
+		if err != nil {
+			return h.unprocessable(c, "GetSubscriptions", err)
+		}
+		filter.TargetType = p
+	}
+	if p := c.QueryParam("l"); p != "" {
+		err := h.validator.Var(p, "number,gt=0")
+		if err != nil {
+			return h.unprocessable(c, "GetSubscriptions", err)
+		}
+		filter.Limit, _ = strconv.ParseUint(p, 10, 64)
+		filter.Limit = min(filter.Limit, subscriptionMaxPageLength)
+	}
+	if p := c.QueryParam("offset"); p != "" {
+		err := h.validator.Var(p, "number,min=0")
+		if err != nil {
+			return h.unprocessable(c, "GetSubscriptions", err)
+		}
+		filter.Offset, _ = strconv.ParseUint(p, 10, 64)
+	}
+
+	subscriptions, err := h.repository.FindSubscriptions(filter)
+	if err != nil {
+		if err == h.repository.NotFoundErr() {
+			e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusNotFound, &e)
+		}
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in FindSubscriptions repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+
+	return c.JSON(http.StatusOK, &subscriptions)
+}
+
+//	@Summary	Fetches new demos, assets and threads from the subscriptions of the current user, newest first. The feed is chronological and not ranked in any way.
+//	@Tags		Subscriptions
+//	@Produce	application/json
+//	@Security	ApiSessionCookie
+//	@param		sessionID	header		string	false	"Session ID"
+//	@Param		before		query		string	false	"Only items created before this time, RFC 3339. Pass createdAt of the last item to get the next page"
+//	@Param		l			query		int		false	"Limit"
+//	@Success	200			{object}	[]models.FeedItem
+//	@Failure	401			{object}	HTTPError
+//	@Failure	403			{object}	HTTPError
+//	@Failure	404			{object}	HTTPError
+//	@Failure	422			{object}	HTTPError
+//	@Failure	500			{object}	HTTPError
+//	@Router		/v1/subscriptions/feed [get]
+func (h *SubscriptionHandler) GetFeed(c echo.Context) error {
+	filter := models.FeedFilter{Limit: subscriptionMaxPageLength}
+
+	userID, ok := c.Get("userID").(uuid.UUID)
+	if !ok {
+		return h.unauthorized(c)
+	}
+	filter.UserID = userID
+
+	if p := c.QueryParam("before"); p != "" {
+		before, err := time.Parse(time.RFC3339Nano, p)
+		if err != nil {
+			return h.unprocessable(c, "GetFeed", err)
+		}
+		filter.Before = &before
+	}
+	if p := c.QueryParam("l"); p != "" {
+		err := h.validator.Var(p, "number,gt=0")
+		if err != nil {
+			return h.unprocessable(c, "GetFeed", err)
+		}
+		filter.Limit, _ = strconv.ParseUint(p, 10, 64)
+		filter.Limit = min(filter.Limit, subscriptionMaxPageLength)
+	}
+
+	feed, err := h.repository.FindFeed(filter)
+	if err != nil {
+		if err == h.repository.NotFoundErr() {
+			e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusNotFound, &e)
+		}
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in FindFeed repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+
+	return c.JSON(http.StatusOK, &feed)
+}
+
+//	@Summary	Subscribes the current user to a thread, topic, tag or user.
+//	@Tags		Subscriptions
+//	@Produce	application/json
+//	@Security	ApiSessionCookie
+//	@param		sessionID	header		string	false	"Session ID"
+//	@Param		type		path		string	true	"Target type: thread, topic, tag or user"
+//	@Param		target		path		string	true	"ID of the thread, topic or user, or the tag"
+//	@Success	201			{object}	models.Subscription
+//	@Failure	401			{object}	HTTPError
+//	@Failure	403			{object}	HTTPError
+//	@Failure	404			{object}	HTTPError
+//	@Failure	409			{object}	HTTPError
+//	@Failure	422			{object}	HTTPError
+//	@Failure	500			{object}	HTTPError
+//	@Router		/v1/subscriptions/{type}/{target} [post]
+func (h *SubscriptionHandler) Subscribe(c echo.Context) error {
+	userID, ok := c.Get("userID").(uuid.UUID)
+	if !ok {
+		return h.unauthorized(c)
+	}
+
+	targetType, target, err := h.target(c)
+	if err != nil {
+		return h.unprocessable(c, "Subscribe", err)
+	}
+
+	subscription, err := h.repository.CreateSubscription(models.Subscription{
+		UserID: &userID, TargetType: &targetType, Target: &target,
+	})
+	if err != nil {
+		switch err {
+		case h.repository.NotFoundErr():
+			e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusNotFound, &e)
+		case h.repository.ConflictErr():
+			e := HTTPError{Code: http.StatusConflict, Message: err.Error()}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusConflict, &e)
+		}
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in CreateSubscription repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+
+	return c.JSON(http.StatusCreated, &subscription)
+}
+
+//	@Summary	Unsubscribes the current user from a thread, topic, tag or user.
+//	@Tags		Subscriptions
+//	@Produce	text/plain
+//	@Security	ApiSessionCookie
+//	@param		sessionID	header		string	false	"Session ID"
+//	@Param		type		path		string	true	"Target type: thread, topic, tag or user"
+//	@Param		target		path		string	true	"ID of the thread, topic or user, or the tag"
+//	@Success	200			{string}	string
+//	@Failure	401			{object}	HTTPError
+//	@Failure	403			{object}	HTTPError
+//	@Failure	404			{object}	HTTPError
+//	@Failure	422			{object}	HTTPError
+//	@Failure	500			{object}	HTTPError
+//	@Router		/v1/subscriptions/{type}/{target} [delete]
+func (h *SubscriptionHandler) Unsubscribe(c echo.Context) error {
+	userID, ok := c.Get("userID").(uuid.UUID)
+	if !ok {
+		return h.unauthorized(c)
+	}
+
+	targetType, target, err := h.target(c)
+	if err != nil {
+		return h.unprocessable(c, "Unsubscribe", err)
+	}
+
+	err = h.repository.DeleteSubscription(userID, targetType, target)
+	if err != nil {
+		if err == h.repository.NotFoundErr() {
+			e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusNotFound, &e)
+		}
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in DeleteSubscription repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+
+	return c.String(http.StatusOK, "Subscription successfully deleted!")
+}
+
+//	@Summary	Fetches the number of subscribers of a thread, topic, tag or user.
+//	@Tags		Subscriptions
+//	@Produce	application/json
+//	@Param		type	path		string	true	"Target type: thread, topic, tag or user"
+//	@Param		target	path		string	true	"ID of the thread, topic or user, or the tag"
+//	@Success	200		{object}	map[string]int
+//	@Failure	422		{object}	HTTPError
+//	@Failure	500		{object}	HTTPError
+//	@Router		/v1/subscriptions/{type}/{target}/subscribers [get]
+func (h *SubscriptionHandler) GetSubscriberCount(c echo.Context) error {
+	targetType, target, err := h.target(c)
+	if err != nil {
+		return h.unprocessable(c, "GetSubscriberCount", err)
+	}
+
+	count, err := h.repository.CountSubscribers(targetType, target)
+	if err != nil {
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in CountSubscribers repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+
+	return c.JSON(http.StatusOK, map[string]int{"subscribers": count})
+}
+
+// Returns the validated type and target path parameters
+func (h *SubscriptionHandler) target(c echo.Context) (string, string, error) {
+	targetType, target := c.Param("type"), c.Param("target")
+
+	err := h.validator.Var(targetType, "oneof=thread topic tag user")
+	if err != nil {
+		return "", "", err
+	}
+	err = h.validator.Var(target, subscriptionTargetRules[targetType])
+	if err != nil {
+		return "", "", err
+	}
+	return targetType, target, nil
+}
+
+func (h *SubscriptionHandler) unauthorized(c echo.Context) error {
+	e := HTTPError{Code: http.StatusUnauthorized, Message: "No session provided!"}
+	h.logger.Print(&e)
+	return c.JSON(http.StatusUnauthorized, &e)
+}
+
+func (h *SubscriptionHandler) unprocessable(c echo.Context, handler string, err error) error {
+	e := HTTPError{
+		Code:    http.StatusUnprocessableEntity,
+		Message: "Error in " + handler + " handler: " + err.Error(),
+	}
+	h.logger.Print(&e)
+	return c.JSON(http.StatusUnprocessableEntity, &e)
+}
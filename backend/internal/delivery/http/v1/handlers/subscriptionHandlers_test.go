@@ -0,0 +1,255 @@
+package handlers
+
+import (
+	"errors"
+	"gamehangar/internal/domain/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockSubscriptionRepo struct {
+	subscriptions []models.Subscription
+	feed          []models.FeedItem
+	notFoundErr   error
+	conflictErr   error
+}
+
+var (
+	feedItemType  = "demo"
+	feedItemID    = 1
+	feedItemTitle = "Test"
+	feedCreatedAt = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	msb = mockSubscriptionRepo{
+		feed: []models.FeedItem{{
+			Type: &feedItemType, ID: &feedItemID, Title: &feedItemTitle, UserID: &genericUUID, CreatedAt: &feedCreatedAt,
+		}},
+		notFoundErr: errors.New("Not Found"),
+		conflictErr: errors.New("Already subscribed!"),
+	}
+)
+
+func (r *mockSubscriptionRepo) CreateSubscription(subscription models.Subscription) (*models.Subscription, error) {
+	if *subscription.TargetType == "thread" && *subscription.Target != "1" {
+		return nil, r.NotFoundErr()
+	}
+	for _, s := range r.subscriptions {
+		if *s.UserID == *subscription.UserID && *s.TargetType == *subscription.TargetType && *s.Target == *subscription.Target {
+			return nil, r.ConflictErr()
+		}
+	}
+	id, now := len(r.subscriptions)+1, time.Now()
+	subscription.ID, subscription.CreatedAt = &id, &now
+	r.subscriptions = append(r.subscriptions, subscription)
+	return &subscription, nil
+}
+func (r *mockSubscriptionRepo) FindSubscriptions(f models.SubscriptionFilter) (*[]models.Subscription, error) {
+	var subscriptions []models.Subscription
+	for _, s := range r.subscriptions {
+		if *s.UserID == f.UserID && (f.TargetType == "" || *s.TargetType == f.TargetType) {
+			subscriptions = append(subscriptions, s)
+		}
+	}
+	if len(subscriptions) == 0 {
+		return nil, r.NotFoundErr()
+	}
+	return &subscriptions, nil
+}
+func (r *mockSubscriptionRepo) DeleteSubscription(userID uuid.UUID, targetType, target string) error {
+	for i, s := range r.subscriptions {
+		if *s.UserID == userID && *s.TargetType == targetType && *s.Target == target {
+			r.subscriptions = append(r.subscriptions[:i], r.subscriptions[i+1:]...)
+			return nil
+		}
+	}
+	return r.NotFoundErr()
+}
+func (r *mockSubscriptionRepo) CountSubscribers(targetType, target string) (int, error) {
+	var count int
+	for _, s := range r.subscriptions {
+		if *s.TargetType == targetType && *s.Target == target {
+			count++
+		}
+	}
+	return count, nil
+}
+func (r *mockSubscriptionRepo) FindFeed(f models.FeedFilter) (*[]models.FeedItem, error) {
+	var feed []models.FeedItem
+	for _, item := range r.feed {
+		if f.Before == nil || item.CreatedAt.Before(*f.Before) {
+			feed = append(feed, item)
+		}
+	}
+	if len(feed) == 0 {
+		return nil, r.NotFoundErr()
+	}
+	return &feed, nil
+}
+func (r *mockSubscriptionRepo) NotFoundErr() error { return r.notFoundErr }
+func (r *mockSubscriptionRepo) ConflictErr() error { return r.conflictErr }
+
+func TestSubscribe(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/game-hangar/v1/subscriptions/:type/:target", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("type", "target")
+	c.SetParamValues("tag", "platformer")
+	c.Set("userID", genericUUID)
+	h := NewSubscriptionHandler(e, &msb, v)
+
+	// Assertions
+	if assert.NoError(t, h.Subscribe(c)) {
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"targetType":"tag","target":"platformer"`)
+	}
+
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	c.SetParamNames("type", "target")
+	c.SetParamValues("tag", "platformer")
+	c.Set("userID", genericUUID)
+
+	if assert.NoError(t, h.Subscribe(c)) {
+		assert.Equal(t, http.StatusConflict, rec.Code)
+	}
+}
+
+func TestSubscribeNotFound(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/game-hangar/v1/subscriptions/:type/:target", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("type", "target")
+	c.SetParamValues("thread", "2")
+	c.Set("userID", genericUUID)
+	h := NewSubscriptionHandler(e, &msb, v)
+
+	// Assertions
+	if assert.NoError(t, h.Subscribe(c)) {
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Equal(t, notFoundResponse, rec.Body.String())
+	}
+}
+
+func TestSubscribeUnprocessable(t *testing.T) {
+	// Setup
+	e := echo.New()
+	h := NewSubscriptionHandler(e, &msb, v)
+
+	for _, params := range [][]string{{"thread", "abc"}, {"user", "1"}, {"demo", "1"}} {
+		req := httptest.NewRequest(http.MethodPost, "/game-hangar/v1/subscriptions/:type/:target", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("type", "target")
+		c.SetParamValues(params...)
+		c.Set("userID", genericUUID)
+
+		// Assertions
+		if assert.NoError(t, h.Subscribe(c)) {
+			assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		}
+	}
+}
+
+func TestGetSubscriptions(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/game-hangar/v1/subscriptions?type=tag", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set("userID", genericUUID)
+	h := NewSubscriptionHandler(e, &msb, v)
+
+	// Assertions
+	if assert.NoError(t, h.GetSubscriptions(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"target":"platformer"`)
+	}
+}
+
+func TestGetSubscriberCount(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/game-hangar/v1/subscriptions/:type/:target/subscribers", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("type", "target")
+	c.SetParamValues("tag", "platformer")
+	h := NewSubscriptionHandler(e, &msb, v)
+
+	// Assertions
+	if assert.NoError(t, h.GetSubscriberCount(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, `{"subscribers":1}`+"\n", rec.Body.String())
+	}
+}
+
+func TestGetFeed(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/game-hangar/v1/subscriptions/feed?l=10", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set("userID", genericUUID)
+	h := NewSubscriptionHandler(e, &msb, v)
+
+	// Assertions
+	if assert.NoError(t, h.GetFeed(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"type":"demo"`)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/game-hangar/v1/subscriptions/feed?before=2025-01-01T00:00:00Z", nil)
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	c.Set("userID", genericUUID)
+
+	if assert.NoError(t, h.GetFeed(c)) {
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/game-hangar/v1/subscriptions/feed?before=yesterday", nil)
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	c.Set("userID", genericUUID)
+
+	if assert.NoError(t, h.GetFeed(c)) {
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	}
+}
+
+func TestUnsubscribeFromTag(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodDelete, "/game-hangar/v1/subscriptions/:type/:target", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("type", "target")
+	c.SetParamValues("tag", "platformer")
+	c.Set("userID", genericUUID)
+	h := NewSubscriptionHandler(e, &msb, v)
+
+	// Assertions
+	if assert.NoError(t, h.Unsubscribe(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	c.SetParamNames("type", "target")
+	c.SetParamValues("tag", "platformer")
+	c.Set("userID", genericUUID)
+
+	if assert.NoError(t, h.Unsubscribe(c)) {
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	}
+}
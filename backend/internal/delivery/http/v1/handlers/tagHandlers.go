@@ -0,0 +1,381 @@
+package handlers
+
+import (
+	"gamehangar/internal/domain/models"
+	"net/http"
+	"strconv"
+	"strings"
+
+	_ "gamehangar/docs"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	tagDefaultPageLength = 10 // Autocomplete suggestions returned when no limit is asked for
+	tagMaxPageLength     = 100
+)
+
+type TagHandler struct {
+	logger     echo.Logger
+	repository TagRepository
+	validator  *validator.Validate
+}
+
+func NewTagHandler(e *echo.Echo, repo TagRepository, v *validator.Validate) *TagHandler {
+	return &TagHandler{
+		logger:     e.Logger,
+		repository: repo,
+		validator:  v,
+	}
+}
+
+//	@Summary		Fetches tags for autocomplete, the most used first.
+//	@Description	Tags match the query by their slug or an alias. Usage counts are per content type.
+//	@Tags			Tags
+//	@Produce		application/json
+//	@Param			q		query		string	false	"Start of the tag in any spelling"
+//	@Param			type	query		string	false	"Only tags used with: demo, asset, thread or message"
+//	@Param			l		query		int		false	"Limit"
+//	@Success		200		{object}	[]models.Tag
+//	@Failure		404		{object}	HTTPError
+//	@Failure		422		{object}	HTTPError
+//	@Failure		500		{object}	HTTPError
+//	@Router			/v1/tags [get]
+func (h *TagHandler) GetTags(c echo.Context) error {
+	filter := models.TagFilter{Limit: tagDefaultPageLength}
+
+	if p := c.QueryParam("q"); p != "" {
+		err := h.validator.Var(p, "max=64")
+		if err != nil {
+			return h.unprocessable(c, "GetTags", err)
+		}
+		filter.Prefix = p
+	}
+	if p := c.QueryParam("type"); p != "" {
+		err := h.validator.Var(p, "oneof="+strings.Join(models.TagContentTypes, " "))
+		if err != nil {
+			return h.unprocessable(c, "GetTags", err)
+		}
+		filter.ContentType = p
+	}
+	if p := c.QueryParam("l"); p != "" {
+		err := h.validator.Var(p, "number,gt=0")
+		if err != nil {
+			return h.unprocessable(c, "GetTags", err)
+		}
+		filter.Limit, _ = strconv.ParseUint(p, 10, 64)
+		filter.Limit = min(filter.Limit, tagMaxPageLength)
+	}
+
+	tags, err := h.repository.FindTags(filter)
+	if err != nil {
+		if err == h.repository.NotFoundErr() {
+			e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusNotFound, &e)
+		}
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in FindTags repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+
+	return c.JSON(http.StatusOK, &tags)
+}
+
+//	@Summary	Fetches the tag by its slug or an alias.
+//	@Tags		Tags
+//	@Produce	application/json
+//	@Param		slug	path		string	true	"Tag slug or alias"
+//	@Success	200		{object}	models.Tag
+//	@Failure	404		{object}	HTTPError
+//	@Failure	422		{object}	HTTPError
+//	@Failure	500		{object}	HTTPError
+//	@Router		/v1/tags/{slug} [get]
+func (h *TagHandler) GetTag(c echo.Context) error {
+	slug := c.Param("slug")
+	err := h.validator.Var(slug, "required,max=64")
+	if err != nil {
+		return h.unprocessable(c, "GetTag", err)
+	}
+
+	tag, err := h.repository.FindTagBySlug(slug)
+	if err != nil {
+		if err == h.repository.NotFoundErr() {
+			e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusNotFound, &e)
+		}
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in FindTagBySlug repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+
+	return c.JSON(http.StatusOK, &tag)
+}
+
+//	@Summary		Renames the tag.
+//	@Description	The name may only change the case and spacing of the tag, merge it into another tag to change its slug.
+//	@Tags			Tags
+//	@Accept			application/json
+//	@Produce		application/json
+//	@Security		ApiSessionCookie
+//	@param			sessionID	header		string		false	"Session ID"
+//	@Param			slug		path		string		true	"Tag slug or alias"
+//	@Param			Tag			body		models.Tag	true	"Rename Tag"
+//	@Success		200			{object}	models.Tag
+//	@Failure		400			{object}	HTTPError
+//	@Failure		401			{object}	HTTPError
+//	@Failure		403			{object}	HTTPError
+//	@Failure		404			{object}	HTTPError
+//	@Failure		422			{object}	HTTPError
+//	@Failure		500			{object}	HTTPError
+//	@Router			/v1/tags/{slug} [patch]
+func (h *TagHandler) PatchTag(c echo.Context) error {
+	var tag models.Tag
+
+	slug := c.Param("slug")
+	err := h.validator.Var(slug, "required,max=64")
+	if err != nil {
+		return h.unprocessable(c, "PatchTag", err)
+	}
+
+	err = c.Bind(&tag)
+	if err != nil {
+		e := HTTPError{
+			Code:    http.StatusBadRequest,
+			Message: "Error in PatchTag handler: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusBadRequest, &e)
+	}
+
+	err = h.validator.Struct(&tag)
+	if err != nil {
+		return h.unprocessable(c, "PatchTag", err)
+	}
+	c.Set("auditTarget", slug)
+
+	updatedTag, err := h.repository.UpdateTag(slug, tag)
+	if err != nil {
+		switch err {
+		case h.repository.NotFoundErr():
+			e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusNotFound, &e)
+		case h.repository.RenameErr():
+			return h.unprocessable(c, "PatchTag", err)
+		}
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in UpdateTag repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+
+	return c.JSON(http.StatusOK, &updatedTag)
+}
+
+//	@Summary		Adds an alias to the tag.
+//	@Description	Content and subscriptions tagged with the alias get the tag instead. Tags in use are merged rather than aliased.
+//	@Tags			Tags
+//	@Accept			application/json
+//	@Produce		application/json
+//	@Security		ApiSessionCookie
+//	@param			sessionID	header		string			false	"Session ID"
+//	@Param			slug		path		string			true	"Tag slug or alias"
+//	@Param			TagAlias	body		models.TagAlias	true	"Add Tag Alias"
+//	@Success		201			{object}	models.Tag
+//	@Failure		400			{object}	HTTPError
+//	@Failure		401			{object}	HTTPError
+//	@Failure		403			{object}	HTTPError
+//	@Failure		404			{object}	HTTPError
+//	@Failure		409			{object}	HTTPError
+//	@Failure		422			{object}	HTTPError
+//	@Failure		500			{object}	HTTPError
+//	@Router			/v1/tags/{slug}/aliases [post]
+func (h *TagHandler) PostTagAlias(c echo.Context) error {
+	var alias models.TagAlias
+
+	adminID, ok := c.Get("userID").(uuid.UUID)
+	if !ok {
+		e := HTTPError{Code: http.StatusUnauthorized, Message: "No session provided!"}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusUnauthorized, &e)
+	}
+
+	slug := c.Param("slug")
+	err := h.validator.Var(slug, "required,max=64")
+	if err != nil {
+		return h.unprocessable(c, "PostTagAlias", err)
+	}
+
+	err = c.Bind(&alias)
+	if err != nil {
+		e := HTTPError{
+			Code:    http.StatusBadRequest,
+			Message: "Error in PostTagAlias handler: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusBadRequest, &e)
+	}
+
+	err = h.validator.Struct(&alias)
+	if err != nil {
+		return h.unprocessable(c, "PostTagAlias", err)
+	}
+	c.Set("auditTarget", slug+"/"+*alias.Slug)
+
+	tag, err := h.repository.CreateTagAlias(slug, alias, adminID)
+	if err != nil {
+		switch err {
+		case h.repository.NotFoundErr():
+			e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusNotFound, &e)
+		case h.repository.ConflictErr():
+			e := HTTPError{Code: http.StatusConflict, Message: err.Error()}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusConflict, &e)
+		}
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in CreateTagAlias repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+
+	return c.JSON(http.StatusCreated, &tag)
+}
+
+//	@Summary	Removes the alias from the tag.
+//	@Tags		Tags
+//	@Produce	text/plain
+//	@Security	ApiSessionCookie
+//	@param		sessionID	header		string	false	"Session ID"
+//	@Param		slug		path		string	true	"Tag slug or alias"
+//	@Param		alias		path		string	true	"Alias"
+//	@Success	200			{string}	string
+//	@Failure	403			{object}	HTTPError
+//	@Failure	404			{object}	HTTPError
+//	@Failure	422			{object}	HTTPError
+//	@Failure	500			{object}	HTTPError
+//	@Router		/v1/tags/{slug}/aliases/{alias} [delete]
+func (h *TagHandler) DeleteTagAlias(c echo.Context) error {
+	slug, alias := c.Param("slug"), c.Param("alias")
+	err := h.validator.Var(slug, "required,max=64")
+	if err != nil {
+		return h.unprocessable(c, "DeleteTagAlias", err)
+	}
+	err = h.validator.Var(alias, "required,max=64")
+	if err != nil {
+		return h.unprocessable(c, "DeleteTagAlias", err)
+	}
+	c.Set("auditTarget", slug+"/"+alias)
+
+	err = h.repository.DeleteTagAlias(slug, alias)
+	if err != nil {
+		if err == h.repository.NotFoundErr() {
+			e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusNotFound, &e)
+		}
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in DeleteTagAlias repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+
+	return c.String(http.StatusOK, "Tag alias successfully removed!")
+}
+
+//	@Summary		Merges the tag into another one.
+//	@Description	Everything tagged with the tag is retagged, subscriptions to it move over and it becomes an alias of the other tag, along with its aliases.
+//	@Tags			Tags
+//	@Accept			application/json
+//	@Produce		application/json
+//	@Security		ApiSessionCookie
+//	@param			sessionID	header		string			false	"Session ID"
+//	@Param			slug		path		string			true	"Tag slug or alias"
+//	@Param			TagMerge	body		models.TagMerge	true	"Merge Tag"
+//	@Success		200			{object}	models.Tag
+//	@Failure		400			{object}	HTTPError
+//	@Failure		401			{object}	HTTPError
+//	@Failure		403			{object}	HTTPError
+//	@Failure		404			{object}	HTTPError
+//	@Failure		422			{object}	HTTPError
+//	@Failure		500			{object}	HTTPError
+//	@Router			/v1/tags/{slug}/merge [post]
+func (h *TagHandler) PostTagMerge(c echo.Context) error {
+	var merge models.TagMerge
+
+	adminID, ok := c.Get("userID").(uuid.UUID)
+	if !ok {
+		e := HTTPError{Code: http.StatusUnauthorized, Message: "No session provided!"}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusUnauthorized, &e)
+	}
+
+	slug := c.Param("slug")
+	err := h.validator.Var(slug, "required,max=64")
+	if err != nil {
+		return h.unprocessable(c, "PostTagMerge", err)
+	}
+
+	err = c.Bind(&merge)
+	if err != nil {
+		e := HTTPError{
+			Code:    http.StatusBadRequest,
+			Message: "Error in PostTagMerge handler: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusBadRequest, &e)
+	}
+
+	err = h.validator.Struct(&merge)
+	if err != nil {
+		return h.unprocessable(c, "PostTagMerge", err)
+	}
+	c.Set("auditTarget", slug+"/"+*merge.Into)
+
+	tag, err := h.repository.MergeTags(slug, merge, adminID)
+	if err != nil {
+		switch err {
+		case h.repository.NotFoundErr():
+			e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusNotFound, &e)
+		case h.repository.MergeErr():
+			return h.unprocessable(c, "PostTagMerge", err)
+		}
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in MergeTags repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+
+	return c.JSON(http.StatusOK, &tag)
+}
+
+func (h *TagHandler) unprocessable(c echo.Context, handler string, err error) error {
+	e := HTTPError{
+		Code:    http.StatusUnprocessableEntity,
+		Message: "Error in " + handler + " handler: " + err.Error(),
+	}
+	h.logger.Print(&e)
+	return c.JSON(http.StatusUnprocessableEntity, &e)
+}
@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"errors"
+	"gamehangar/internal/domain/models"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockTagRepo struct {
+	tags        []models.Tag
+	notFoundErr error
+	conflictErr error
+	renameErr   error
+	mergeErr    error
+}
+
+var mtg = mockTagRepo{
+	tags: []models.Tag{
+		{ID: &tagID2D, Slug: &tagSlug2D, Name: &tagName2D, Usage: map[string]int{"demo": 2}},
+		{ID: &tagID3D, Slug: &tagSlug3D, Name: &tagSlug3D, Usage: map[string]int{"asset": 1}},
+	},
+	notFoundErr: errors.New("Not Found"),
+	conflictErr: errors.New("Tag or alias already exists!"),
+	renameErr:   errors.New("Name does not match the tag!"),
+	mergeErr:    errors.New("Tag cannot be merged into itself!"),
+}
+
+var (
+	tagID2D   = 1
+	tagID3D   = 2
+	tagSlug2D = "2d"
+	tagName2D = "2D"
+	tagSlug3D = "3d"
+)
+
+// Slugs are lowercase names in the mock, aliases resolve like slugs
+func (r *mockTagRepo) find(slug string) (*models.Tag, error) {
+	slug = strings.ToLower(slug)
+	for i, t := range r.tags {
+		if *t.Slug == slug || slices.Contains(t.Aliases, slug) {
+			return &r.tags[i], nil
+		}
+	}
+	return nil, r.notFoundErr
+}
+func (r *mockTagRepo) FindTags(f models.TagFilter) (*[]models.Tag, error) {
+	var tags []models.Tag
+	for _, t := range r.tags {
+		if strings.HasPrefix(*t.Slug, strings.ToLower(f.Prefix)) && (f.ContentType == "" || t.Usage[f.ContentType] > 0) {
+			tags = append(tags, t)
+		}
+	}
+	if len(tags) == 0 {
+		return nil, r.notFoundErr
+	}
+	return &tags, nil
+}
+func (r *mockTagRepo) FindTagBySlug(slug string) (*models.Tag, error) { return r.find(slug) }
+func (r *mockTagRepo) UpdateTag(slug string, tag models.Tag) (*models.Tag, error) {
+	found, err := r.find(slug)
+	if err != nil {
+		return nil, err
+	}
+	if strings.ToLower(*tag.Name) != *found.Slug {
+		return nil, r.renameErr
+	}
+	updated := *found
+	updated.Name = tag.Name
+	return &updated, nil
+}
+func (r *mockTagRepo) CreateTagAlias(slug string, alias models.TagAlias, userID uuid.UUID) (*models.Tag, error) {
+	found, err := r.find(slug)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := r.find(*alias.Slug); err == nil {
+		return nil, r.conflictErr
+	}
+	found.Aliases = append(found.Aliases, strings.ToLower(*alias.Slug))
+	return found, nil
+}
+func (r *mockTagRepo) DeleteTagAlias(slug, alias string) error {
+	found, err := r.find(slug)
+	if err != nil {
+		return err
+	}
+	i := slices.Index(found.Aliases, strings.ToLower(alias))
+	if i == -1 {
+		return r.notFoundErr
+	}
+	found.Aliases = slices.Delete(found.Aliases, i, i+1)
+	return nil
+}
+func (r *mockTagRepo) MergeTags(slug string, merge models.TagMerge, userID uuid.UUID) (*models.Tag, error) {
+	source, err := r.find(slug)
+	if err != nil {
+		return nil, err
+	}
+	target, err := r.find(*merge.Into)
+	if err != nil {
+		return nil, err
+	}
+	if source == target {
+		return nil, r.mergeErr
+	}
+	merged := *target
+	merged.Aliases = append(slices.Clone(target.Aliases), *source.Slug)
+	return &merged, nil
+}
+func (r *mockTagRepo) NotFoundErr() error { return r.notFoundErr }
+func (r *mockTagRepo) ConflictErr() error { return r.conflictErr }
+func (r *mockTagRepo) RenameErr() error   { return r.renameErr }
+func (r *mockTagRepo) MergeErr() error    { return r.mergeErr }
+
+func TestGetTags(t *testing.T) {
+	// Setup
+	e := echo.New()
+	h := NewTagHandler(e, &mtg, v)
+	get := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/game-hangar/v1/tags?"+query, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		assert.NoError(t, h.GetTags(c))
+		return rec
+	}
+
+	// Assertions
+	rec := get("q=2&type=demo&l=5")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"slug":"2d","name":"2D","usage":{"demo":2}`)
+	assert.NotContains(t, rec.Body.String(), `"slug":"3d"`)
+	assert.Equal(t, http.StatusNotFound, get("q=3&type=demo").Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, get("type=user").Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, get("l=few").Code)
+}
+
+func TestPatchTag(t *testing.T) {
+	// Setup
+	e := echo.New()
+	h := NewTagHandler(e, &mtg, v)
+	patch := func(slug, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPatch, "/game-hangar/v1/tags", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/:slug")
+		c.SetParamNames("slug")
+		c.SetParamValues(slug)
+		assert.NoError(t, h.PatchTag(c))
+		return rec
+	}
+
+	// Assertions
+	rec := patch("3d", `{"name":"3D"}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"name":"3D"`)
+	assert.Equal(t, http.StatusUnprocessableEntity, patch("3d", `{"name":"Voxel"}`).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, patch("3d", `{"name":"--"}`).Code)
+	assert.Equal(t, http.StatusNotFound, patch("4d", `{"name":"4D"}`).Code)
+}
+
+func TestTagAliases(t *testing.T) {
+	// Setup
+	e := echo.New()
+	h := NewTagHandler(e, &mtg, v)
+	post := func(slug, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/game-hangar/v1/tags", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/:slug/aliases")
+		c.SetParamNames("slug")
+		c.SetParamValues(slug)
+		c.Set("userID", uuid.New())
+		assert.NoError(t, h.PostTagAlias(c))
+		return rec
+	}
+	remove := func(alias string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodDelete, "/game-hangar/v1/tags", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/:slug/aliases/:alias")
+		c.SetParamNames("slug", "alias")
+		c.SetParamValues("2d", alias)
+		assert.NoError(t, h.DeleteTagAlias(c))
+		return rec
+	}
+
+	// Assertions
+	rec := post("2d", `{"slug":"TwoD"}`)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"aliases":["twod"]`)
+	assert.Equal(t, http.StatusConflict, post("2d", `{"slug":"3D"}`).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, post("2d", `{"slug":"?!"}`).Code)
+	assert.Equal(t, http.StatusNotFound, post("4d", `{"slug":"FourD"}`).Code)
+
+	rec = remove("twod")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "Tag alias successfully removed!", rec.Body.String())
+	assert.Equal(t, http.StatusNotFound, remove("twod").Code)
+}
+
+func TestPostTagMerge(t *testing.T) {
+	// Setup
+	e := echo.New()
+	h := NewTagHandler(e, &mtg, v)
+	merge := func(slug, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/game-hangar/v1/tags", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/:slug/merge")
+		c.SetParamNames("slug")
+		c.SetParamValues(slug)
+		c.Set("userID", uuid.New())
+		assert.NoError(t, h.PostTagMerge(c))
+		return rec
+	}
+
+	// Assertions
+	rec := merge("3d", `{"into":"2D"}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"slug":"2d"`)
+	assert.Contains(t, rec.Body.String(), `"3d"`)
+	assert.Equal(t, http.StatusUnprocessableEntity, merge("2d", `{"into":"2D"}`).Code)
+	assert.Equal(t, http.StatusNotFound, merge("3d", `{"into":"4D"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, merge("3d", `{"into":`).Code)
+}
+
+func TestTagValidation(t *testing.T) {
+	// Assertions
+	assert.NoError(t, v.Var("Godot 4.2", "tag"))
+	assert.NoError(t, v.Var("日本語", "tag"))
+	assert.Error(t, v.Var(" -- ", "tag"))
+
+	tags := []string{"2D", "#!"}
+	assert.Error(t, v.StructPartial(&models.Thread{Tags: &tags}, "Tags"))
+	tags = []string{"2D", "2D"}
+	assert.Error(t, v.StructPartial(&models.Thread{Tags: &tags}, "Tags"))
+	tags = []string{strings.Repeat("a", 65)}
+	assert.Error(t, v.StructPartial(&models.Thread{Tags: &tags}, "Tags"))
+}
@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"gamehangar/internal/domain/models"
+	"net/http"
+	"strconv"
+
+	_ "gamehangar/docs"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type TopicModeratorHandler struct {
+	logger     echo.Logger
+	repository TopicModeratorRepository
+	validator  *validator.Validate
+}
+
+func NewTopicModeratorHandler(e *echo.Echo, repo TopicModeratorRepository, v *validator.Validate) *TopicModeratorHandler {
+	return &TopicModeratorHandler{
+		logger:     e.Logger,
+		repository: repo,
+		validator:  v,
+	}
+}
+
+//	@Summary	Fetches the moderators assigned to the topic.
+//	@Tags		Topics
+//	@Produce	application/json
+//	@Param		id	path		int	true	"Topic ID"
+//	@Success	200	{object}	[]models.TopicModerator
+//	@Failure	404	{object}	HTTPError
+//	@Failure	422	{object}	HTTPError
+//	@Failure	500	{object}	HTTPError
+//	@Router		/v1/topics/{id}/moderators [get]
+func (h *TopicModeratorHandler) GetTopicModerators(c echo.Context) error {
+	p := c.Param("id")
+	err := h.validator.Var(p, "required,number")
+	if err != nil {
+		return h.unprocessable(c, "GetTopicModerators", err)
+	}
+	topicID, _ := strconv.Atoi(p)
+
+	moderators, err := h.repository.FindTopicModerators(topicID)
+	if err != nil {
+		if err == h.repository.NotFoundErr() {
+			e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusNotFound, &e)
+		}
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in FindTopicModerators repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+
+	return c.JSON(http.StatusOK, &moderators)
+}
+
+//	@Summary		Assigns the User to moderate the topic and its subtopics.
+//	@Description	Moderators of a topic may pin, lock, hide and delete its threads and messages.
+//	@Tags			Topics
+//	@Accept			application/json
+//	@Produce		application/json
+//	@Security		ApiSessionCookie
+//	@param			sessionID		header		string					false	"Session ID"
+//	@Param			id				path		int						true	"Topic ID"
+//	@Param			TopicModerator	body		models.TopicModerator	true	"Assign Topic Moderator"
+//	@Success		201				{object}	models.TopicModerator
+//	@Failure		400				{object}	HTTPError
+//	@Failure		401				{object}	HTTPError
+//	@Failure		403				{object}	HTTPError
+//	@Failure		404				{object}	HTTPError
+//	@Failure		422				{object}	HTTPError
+//	@Failure		500				{object}	HTTPError
+//	@Router			/v1/topics/{id}/moderators [post]
+func (h *TopicModeratorHandler) PostTopicModerator(c echo.Context) error {
+	var moderator models.TopicModerator
+
+	adminID, ok := c.Get("userID").(uuid.UUID)
+	if !ok {
+		e := HTTPError{Code: http.StatusUnauthorized, Message: "No session provided!"}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusUnauthorized, &e)
+	}
+
+	p := c.Param("id")
+	err := h.validator.Var(p, "required,number")
+	if err != nil {
+		return h.unprocessable(c, "PostTopicModerator", err)
+	}
+	topicID, _ := strconv.Atoi(p)
+
+	err = c.Bind(&moderator)
+	if err != nil {
+		e := HTTPError{
+			Code:    http.StatusBadRequest,
+			Message: "Error in PostTopicModerator handler: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusBadRequest, &e)
+	}
+	moderator.TopicID, moderator.AssignedBy = &topicID, &adminID
+	moderator.CreatedAt, moderator.Username = nil, nil
+
+	err = h.validator.Struct(&moderator)
+	if err != nil {
+		return h.unprocessable(c, "PostTopicModerator", err)
+	}
+	c.Set("auditTarget", p+"/"+moderator.UserID.String())
+
+	newModerator, err := h.repository.CreateTopicModerator(moderator)
+	if err != nil {
+		if err == h.repository.NotFoundErr() {
+			e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusNotFound, &e)
+		}
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in CreateTopicModerator repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+
+	return c.JSON(http.StatusCreated, &newModerator)
+}
+
+//	@Summary	Removes the User from the moderators of the topic.
+//	@Tags		Topics
+//	@Produce	text/plain
+//	@Security	ApiSessionCookie
+//	@param		sessionID	header		string	false	"Session ID"
+//	@Param		id			path		int		true	"Topic ID"
+//	@Param		userID		path		string	true	"User ID"
+//	@Success	200			{string}	string
+//	@Failure	403			{object}	HTTPError
+//	@Failure	404			{object}	HTTPError
+//	@Failure	422			{object}	HTTPError
+//	@Failure	500			{object}	HTTPError
+//	@Router		/v1/topics/{id}/moderators/{userID} [delete]
+func (h *TopicModeratorHandler) DeleteTopicModerator(c echo.Context) error {
+	p := c.Param("id")
+	err := h.validator.Var(p, "required,number")
+	if err != nil {
+		return h.unprocessable(c, "DeleteTopicModerator", err)
+	}
+	topicID, _ := strconv.Atoi(p)
+
+	u := c.Param("userID")
+	err = h.validator.Var(u, "required,uuid")
+	if err != nil {
+		return h.unprocessable(c, "DeleteTopicModerator", err)
+	}
+	userID, _ := uuid.Parse(u)
+	c.Set("auditTarget", p+"/"+u)
+
+	err = h.repository.DeleteTopicModerator(topicID, userID)
+	if err != nil {
+		if err == h.repository.NotFoundErr() {
+			e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusNotFound, &e)
+		}
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in DeleteTopicModerator repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+
+	return c.String(http.StatusOK, "Topic moderator successfully removed!")
+}
+
+func (h *TopicModeratorHandler) unprocessable(c echo.Context, handler string, err error) error {
+	e := HTTPError{
+		Code:    http.StatusUnprocessableEntity,
+		Message: "Error in " + handler + " handler: " + err.Error(),
+	}
+	h.logger.Print(&e)
+	return c.JSON(http.StatusUnprocessableEntity, &e)
+}
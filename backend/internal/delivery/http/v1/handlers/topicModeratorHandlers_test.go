@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"errors"
+	"gamehangar/internal/domain/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockTopicModeratorRepo struct {
+	moderators  []models.TopicModerator
+	notFoundErr error
+}
+
+var (
+	mtm = mockTopicModeratorRepo{
+		notFoundErr: errors.New("Not Found"),
+	}
+
+	topicModeratorJSON = `{"userID":"` + genericUUID.String() + `"}`
+)
+
+// Only topic 1 exists
+func (r *mockTopicModeratorRepo) CreateTopicModerator(m models.TopicModerator) (*models.TopicModerator, error) {
+	if *m.TopicID != 1 {
+		return nil, r.NotFoundErr()
+	}
+	r.moderators = append(r.moderators, m)
+	return &m, nil
+}
+func (r *mockTopicModeratorRepo) FindTopicModerators(topicID int) (*[]models.TopicModerator, error) {
+	var moderators []models.TopicModerator
+	for _, m := range r.moderators {
+		if *m.TopicID == topicID {
+			moderators = append(moderators, m)
+		}
+	}
+	if len(moderators) == 0 {
+		return nil, r.NotFoundErr()
+	}
+	return &moderators, nil
+}
+func (r *mockTopicModeratorRepo) DeleteTopicModerator(topicID int, userID uuid.UUID) error {
+	for i, m := range r.moderators {
+		if *m.TopicID == topicID && *m.UserID == userID {
+			r.moderators = append(r.moderators[:i], r.moderators[i+1:]...)
+			return nil
+		}
+	}
+	return r.NotFoundErr()
+}
+func (r *mockTopicModeratorRepo) NotFoundErr() error { return r.notFoundErr }
+
+func TestPostTopicModerator(t *testing.T) {
+	// Setup
+	e := echo.New()
+	h := NewTopicModeratorHandler(e, &mtm, v)
+	post := func(topicID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/game-hangar/v1/topics", strings.NewReader(topicModeratorJSON))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/:id/moderators")
+		c.SetParamNames("id")
+		c.SetParamValues(topicID)
+		c.Set("userID", uuid.New())
+		assert.NoError(t, h.PostTopicModerator(c))
+		return rec
+	}
+
+	// Assertions
+	rec := post("1")
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"userID":"`+genericUUID.String()+`"`)
+	assert.Equal(t, http.StatusNotFound, post("2").Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, post("first").Code)
+}
+
+func TestGetTopicModerators(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/game-hangar/v1/topics", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/:id/moderators")
+	c.SetParamNames("id")
+	c.SetParamValues("1")
+	h := NewTopicModeratorHandler(e, &mtm, v)
+
+	// Assertions
+	if assert.NoError(t, h.GetTopicModerators(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), genericUUID.String())
+	}
+}
+
+func TestDeleteTopicModerator(t *testing.T) {
+	// Setup
+	e := echo.New()
+	h := NewTopicModeratorHandler(e, &mtm, v)
+	remove := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodDelete, "/game-hangar/v1/topics", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/:id/moderators/:userID")
+		c.SetParamNames("id", "userID")
+		c.SetParamValues("1", genericUUID.String())
+		assert.NoError(t, h.DeleteTopicModerator(c))
+		return rec
+	}
+
+	// Assertions
+	rec := remove()
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "Topic moderator successfully removed!", rec.Body.String())
+	assert.Equal(t, http.StatusNotFound, remove().Code)
+}
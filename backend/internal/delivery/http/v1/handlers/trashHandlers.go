@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"net/http"
+
+	_ "gamehangar/docs"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type TrashHandler struct {
+	logger     echo.Logger
+	repository TrashRepository
+}
+
+// Returns whose deletions the user may restore, to pass to the Restore* repositories. Admins
+// may restore anything and get nil, other users only what they deleted themselves
+func restorer(c echo.Context, userID uuid.UUID) *uuid.UUID {
+	if c.Get("userTier") == "admin" {
+		return nil
+	}
+	return &userID
+}
+
+func NewTrashHandler(e *echo.Echo, repo TrashRepository) *TrashHandler {
+	return &TrashHandler{
+		logger:     e.Logger,
+		repository: repo,
+	}
+}
+
+//	@Summary	Fetches content deleted by the current user that can still be restored.
+//	@Tags		Trash
+//	@Produce	application/json
+//	@Success	200	{object}	[]models.TrashItem
+//	@Failure	401	{object}	HTTPError
+//	@Failure	403	{object}	HTTPError
+//	@Failure	404	{object}	HTTPError
+//	@Failure	500	{object}	HTTPError
+//	@Router		/v1/trash [get]
+func (h *TrashHandler) GetTrash(c echo.Context) error {
+	userID, ok := c.Get("userID").(uuid.UUID)
+	if !ok {
+		e := HTTPError{Code: http.StatusUnauthorized, Message: "No session provided!"}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusUnauthorized, &e)
+	}
+
+	trash, err := h.repository.FindTrash(userID)
+	if err != nil {
+		if err == h.repository.NotFoundErr() {
+			e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusNotFound, &e)
+		}
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in FindTrash repository: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+
+	return c.JSON(http.StatusOK, &trash)
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"errors"
+	"gamehangar/internal/domain/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockTrashRepo struct {
+	data        map[uuid.UUID][]models.TrashItem
+	notFoundErr error
+}
+
+var (
+	trashType      = "demo"
+	trashID        = 1
+	trashTitle     = "Deleted demo"
+	trashDeletedAt = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	trashPurgeAt   = trashDeletedAt.Add(30 * 24 * time.Hour)
+
+	mtr = mockTrashRepo{
+		data:        make(map[uuid.UUID][]models.TrashItem, 1),
+		notFoundErr: errors.New("Not Found"),
+	}
+
+	trashJSONExpected = `[{"type":"demo","id":1,"title":"Deleted demo","deletedAt":"2025-01-01T00:00:00Z","deletedBy":"` +
+		genericUUID.String() + `","purgeAt":"2025-01-31T00:00:00Z"}]` + "\n"
+)
+
+func (r *mockTrashRepo) FindTrash(userID uuid.UUID) (*[]models.TrashItem, error) {
+	trash, ok := r.data[userID]
+	if !ok {
+		return nil, r.NotFoundErr()
+	}
+	return &trash, nil
+}
+func (r *mockTrashRepo) NotFoundErr() error { return r.notFoundErr }
+
+func TestGetTrash(t *testing.T) {
+	// Setup
+	mtr.data[genericUUID] = []models.TrashItem{{
+		Type: &trashType, ID: &trashID, Title: &trashTitle,
+		DeletedAt: &trashDeletedAt, DeletedBy: &genericUUID, PurgeAt: &trashPurgeAt,
+	}}
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/game-hangar/v1/trash", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set("userID", genericUUID)
+	h := &TrashHandler{logger: e.Logger, repository: &mtr}
+
+	// Assertions
+	if assert.NoError(t, h.GetTrash(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, trashJSONExpected, rec.Body.String())
+	}
+}
+
+func TestGetTrashEmpty(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/game-hangar/v1/trash", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set("userID", uuid.New())
+	h := &TrashHandler{logger: e.Logger, repository: &mtr}
+
+	// Assertions
+	if assert.NoError(t, h.GetTrash(c)) {
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Equal(t, notFoundResponse, rec.Body.String())
+	}
+}
+
+func TestGetTrashUnauthorized(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/game-hangar/v1/trash", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	h := &TrashHandler{logger: e.Logger, repository: &mtr}
+
+	// Assertions
+	if assert.NoError(t, h.GetTrash(c)) {
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"gamehangar/internal/domain/models"
 	"mime/multipart"
 	"net/http"
-	"strconv"
 	"time"
 
 	_ "gamehangar/docs"
@@ -18,23 +17,28 @@ type UserAuthorizer interface {
 	IdentifyUser(email, username *string) (user *models.User, err error)
 	CreatePasswordHash(password *string) (hash *string, err error)
 	CheckPassword(password *string, userID uuid.UUID) (err error)
+	CheckSanctions(userID uuid.UUID, obj, act string) (*models.Sanction, error)
 }
 
 type UserHandler struct {
-	logger         echo.Logger
-	repository     UserRepository
-	validator      *validator.Validate
-	objectUploader ObjectUploader
-	userAuthorizer UserAuthorizer
+	logger          echo.Logger
+	repository      UserRepository
+	validator       *validator.Validate
+	objectUploader  ObjectUploader
+	userAuthorizer  UserAuthorizer
+	contentFilter   ContentFilter
+	captchaVerifier CaptchaVerifier
 }
 
-func NewUserHandler(e *echo.Echo, repo UserRepository, v *validator.Validate, o ObjectUploader, a UserAuthorizer) *UserHandler {
+func NewUserHandler(e *echo.Echo, repo UserRepository, v *validator.Validate, o ObjectUploader, a UserAuthorizer, f ContentFilter, cv CaptchaVerifier) *UserHandler {
 	return &UserHandler{
-		logger:         e.Logger,
-		repository:     repo,
-		validator:      v,
-		objectUploader: o,
-		userAuthorizer: a,
+		logger:          e.Logger,
+		repository:      repo,
+		validator:       v,
+		objectUploader:  o,
+		userAuthorizer:  a,
+		contentFilter:   f,
+		captchaVerifier: cv,
 	}
 }
 
@@ -84,41 +88,38 @@ func (h *UserHandler) GetUserById(c echo.Context) error {
 // @Tags		Users
 // @Produce	application/json
 // @Param		q	query		string	false	"Keyword Query"
-// @Param		l	query		int		false	"Record number limit"
-// @Success	200	{object}	models.User
+// @Param		l	query		int		false	"Page size, at most 100. Default 20"
+// @Param		c	query		string	false	"Page cursor from the next or prev of a previous page"
+// @Success	200	{object}	models.Page[models.User]
 // @Failure	400	{object}	HTTPError
 // @Failure	404	{object}	HTTPError
+// @Failure	422	{object}	HTTPError
 // @Failure	500	{object}	HTTPError
 // @Router		/v1/users [get]
 func (h *UserHandler) GetUsers(c echo.Context) error {
 	var (
 		err   error
-		limit uint64
-		users *[]models.User
+		users *models.Page[models.User]
 	)
 
-	l := c.Request().URL.Query()["l"]
-	if l != nil {
-		err = h.validator.Var(l[0], "omitnil,number,min=0")
-		if err != nil {
-			e := HTTPError{
-				Code:    http.StatusUnprocessableEntity,
-				Message: "Error in GetUsers repository: " + err.Error(),
-			}
-			h.logger.Print(&e)
-			return c.JSON(http.StatusUnprocessableEntity, &e)
-		}
-		limit, err = strconv.ParseUint(l[0], 10, 64)
+	page, ok, err := parsePageQuery(c, h.logger, h.validator, "GetUsers")
+	if !ok {
+		return err
 	}
 	tags := c.Request().URL.Query()["q"]
 
-	users, err = h.repository.FindUsers(tags, limit)
+	users, err = h.repository.FindUsers(tags, page)
 	if err != nil {
 		if err == h.repository.NotFoundErr() {
 			e := HTTPError{Code: http.StatusNotFound, Message: "Not Found!"}
 			h.logger.Print(&e)
 			return c.JSON(http.StatusNotFound, &e)
 		}
+		if err == h.repository.CursorErr() {
+			e := HTTPError{Code: http.StatusUnprocessableEntity, Message: err.Error()}
+			h.logger.Print(&e)
+			return c.JSON(http.StatusUnprocessableEntity, &e)
+		}
 		e := HTTPError{
 			Code:    http.StatusInternalServerError,
 			Message: "Error in FindUsers repository: " + err.Error(),
@@ -190,6 +191,14 @@ func (h *UserHandler) PatchUser(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, &e)
 	}
 
+	if user.Username != nil || user.DisplayName != nil {
+		verdict, err := checkContent(c, h.logger, h.contentFilter,
+			models.FilterSubject{Kind: "username", AuthorID: sessionUserID(c), Text: filterText(user.Username, user.DisplayName)})
+		if verdict == nil {
+			return err
+		}
+	}
+
 	var profilePicMultipartFile multipart.File
 	profilePicFormFile, err := c.FormFile("picFile")
 	if profilePicFormFile != nil {
@@ -316,6 +325,7 @@ func (h *UserHandler) PostRole(c echo.Context) error {
 		return c.JSON(http.StatusUnprocessableEntity, &e)
 	}
 
+	c.Set("auditTarget", roleSlice[0])
 	err = h.repository.CreateRole(roleSlice[0])
 	if err != nil {
 		e := HTTPError{
@@ -363,6 +373,7 @@ func (h *UserHandler) DeleteRole(c echo.Context) error {
 		return c.JSON(http.StatusUnprocessableEntity, &e)
 	}
 
+	c.Set("auditTarget", roleSlice[0])
 	err = h.repository.DeleteRole(roleSlice[0])
 	if err != nil {
 		if err == h.repository.NotFoundErr() {
@@ -390,9 +401,11 @@ func (h *UserHandler) DeleteRole(c echo.Context) error {
 // @Produce	application/json
 // @Param		User		formData	models.User	true	"Create User"
 // @param		password	header		string		true	"Password"
+// @param		captcha		header		string		true	"Captcha token"
 // @param		picFile		formData	file		false	"Profile picture"
 // @Success	201			{object}	models.User
 // @Failure	400			{object}	HTTPError
+// @Failure	403			{object}	HTTPError
 // @Failure	404			{object}	HTTPError
 // @Failure	413			{object}	HTTPError
 // @Failure	422			{object}	HTTPError
@@ -401,7 +414,31 @@ func (h *UserHandler) DeleteRole(c echo.Context) error {
 func (h *UserHandler) Register(c echo.Context) error {
 	var user models.User
 
-	err := c.Bind(&user)
+	captchaSlice, ok := c.Request().Header["Captcha"]
+	if !ok {
+		e := HTTPError{
+			Code:    http.StatusBadRequest,
+			Message: "No captcha token provided!",
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusBadRequest, &e)
+	}
+	passed, err := h.captchaVerifier.VerifyCaptcha(captchaSlice[0], c.RealIP())
+	if err != nil {
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in VerifyCaptcha service: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	if !passed {
+		e := HTTPError{Code: http.StatusForbidden, Message: "Captcha verification failed!"}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusForbidden, &e)
+	}
+
+	err = c.Bind(&user)
 	if err != nil {
 		e := HTTPError{
 			Code:    http.StatusBadRequest,
@@ -433,6 +470,12 @@ func (h *UserHandler) Register(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, &e)
 	}
 
+	verdict, err := checkContent(c, h.logger, h.contentFilter,
+		models.FilterSubject{Kind: "username", Text: filterText(user.Username, user.DisplayName)})
+	if verdict == nil {
+		return err
+	}
+
 	passwordSlice, ok := c.Request().Header["Password"]
 	if !ok {
 		e := HTTPError{
@@ -497,6 +540,7 @@ func (h *UserHandler) Register(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, &e)
 	}
 
+	c.Set("auditTarget", newUser.ID.String())
 	session, err := h.repository.CreateSession(models.Session{UserID: newUser.ID})
 	if err != nil {
 		e := HTTPError{
@@ -654,6 +698,7 @@ func (h *UserHandler) ResetPassword(c echo.Context) error {
 // @param		password	header		string	true	"Password"
 // @Success	200			{string}	string
 // @Failure	400			{object}	HTTPError
+// @Failure	403			{object}	HTTPError
 // @Failure	422			{object}	HTTPError
 // @Failure	500			{object}	HTTPError
 // @Router		/v1/login [post]
@@ -717,11 +762,33 @@ func (h *UserHandler) Login(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, &e)
 	}
 
+	// A failed login is recorded in the audit log without an actor, with the user as its target
+	c.Set("auditTarget", user.ID.String())
 	if h.userAuthorizer.CheckPassword(&password, *user.ID) != nil {
 		e := HTTPError{Code: http.StatusUnauthorized, Message: "Password incorrect!"}
 		h.logger.Print(&e)
 		return c.JSON(http.StatusUnauthorized, &e)
 	}
+	c.Set("userID", *user.ID)
+
+	sanction, err := h.userAuthorizer.CheckSanctions(*user.ID, "login", http.MethodPost)
+	if err != nil {
+		e := HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Error in CheckSanctions service: " + err.Error(),
+		}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	if sanction != nil {
+		message := "User is banned: " + *sanction.Reason
+		if sanction.ExpiresAt != nil {
+			message += " (until " + sanction.ExpiresAt.Format(time.RFC3339) + ")"
+		}
+		e := HTTPError{Code: http.StatusForbidden, Message: message}
+		h.logger.Print(&e)
+		return c.JSON(http.StatusForbidden, &e)
+	}
 
 	session, err := h.repository.CreateSession(models.Session{UserID: user.ID})
 	if err != nil {
@@ -28,6 +28,7 @@ type mockUserRepo struct {
 	sessionData map[string]models.Session
 	userData    map[string]models.User
 	notFoundErr error
+	cursorErr   error
 	conflictErr error
 }
 
@@ -38,6 +39,7 @@ var (
 		sessionData: make(map[string]models.Session, 1),
 		userData:    make(map[string]models.User, 1),
 		notFoundErr: errors.New("Not Found"),
+		cursorErr:   errors.New("Cursor is not valid!"),
 		conflictErr: errors.New("Record conflict!"),
 	}
 	au = mockUserAuthorizer{&mu}
@@ -61,8 +63,8 @@ var (
 
 	userJSON                  = `{"username":"Cool user","email":"test@example.com","role":"` + role + `"}`
 	userJSONExpected          = `{"id":"` + genericUUID.String() + `","username":"Cool user","email":"test@example.com","verified":false,"role":"` + role + `","profilePic":null}` + "\n"
-	userJSONExpectedMany      = `[{"id":"` + genericUUID.String() + `","username":"Cool user","email":"test@example.com","verified":false,"role":"` + role + `","profilePic":null}]` + "\n"
-	userJSONExpectedManyLimit = `[{"username":"cheeseboiger","email":"link.com","profilePic":null}]` + "\n"
+	userJSONExpectedMany      = `{"items":[{"id":"` + genericUUID.String() + `","username":"Cool user","email":"test@example.com","verified":false,"role":"` + role + `","profilePic":null}],"total":1}` + "\n"
+	userJSONExpectedManyLimit = `{"items":[{"username":"cheeseboiger","email":"link.com","profilePic":null}],"total":1}` + "\n"
 	userJSONUpdate            = `{"username":"Updated cool user"}`
 	userJSONUpdateExpected    = `{"id":"` + genericUUID.String() + `","username":"Updated cool user","email":"test@example.com","verified":false,"role":"` + role + `","profilePic":null}` + "\n"
 	userJSONVerifyExpected    = `{"id":"` + genericUUID.String() + `","username":"Updated cool user","email":"test@example.com","verified":true,"role":"` + role + `","profilePic":null}` + "\n"
@@ -132,7 +134,7 @@ func (r *mockUserRepo) CreateUser(user models.User, profilePic io.Reader) (*mode
 	}
 	return &resultUser, nil
 }
-func (r *mockUserRepo) FindUsers(query []string, limit uint64) (*[]models.User, error) {
+func (r *mockUserRepo) FindUsers(query []string, page models.PageQuery) (*models.Page[models.User], error) {
 	var (
 		userNames  []string      = []string{"cheeseboiger", "user two", "user three"}
 		userEmails []string      = []string{"link.com", "example.com", "e.com"}
@@ -154,9 +156,8 @@ func (r *mockUserRepo) FindUsers(query []string, limit uint64) (*[]models.User,
 		for _, u := range r.userData {
 			resultUsers = append(resultUsers, u)
 		}
-		return &resultUsers, nil
 	}
-	return &resultUsers, nil
+	return &models.Page[models.User]{Items: resultUsers, Total: int64(len(resultUsers))}, nil
 }
 func (r *mockUserRepo) FindUserByID(id uuid.UUID) (*models.User, error) {
 	user, ok := r.userData[id.String()]
@@ -193,6 +194,7 @@ func (r *mockUserRepo) DeleteUser(id uuid.UUID) error {
 }
 
 func (r *mockUserRepo) NotFoundErr() error { return r.notFoundErr }
+func (r *mockUserRepo) CursorErr() error   { return r.cursorErr }
 func (r *mockUserRepo) ConflictErr() error { return r.conflictErr }
 
 func (a *mockUserAuthorizer) IdentifyUser(email, username *string) (*models.User, error) {
@@ -226,6 +228,16 @@ func (a *mockUserAuthorizer) CheckPassword(password *string, userID uuid.UUID) e
 	return nil
 }
 
+// Bans are looked up in the sanction mock
+func (a *mockUserAuthorizer) CheckSanctions(userID uuid.UUID, obj, act string) (*models.Sanction, error) {
+	for _, s := range ms.sanctions {
+		if *s.UserID == userID && *s.Type == "ban" && s.RevokedAt == nil {
+			return &s, nil
+		}
+	}
+	return nil, nil
+}
+
 func TestPostRole(t *testing.T) {
 	// Setup
 	e := echo.New()
@@ -295,9 +307,10 @@ func TestRegister(t *testing.T) {
 	req := httptest.NewRequest(http.MethodPost, "/game-hangar/v1/register", bodyBuffer)
 	req.Header.Set(echo.HeaderContentType, mw.FormDataContentType())
 	req.Header.Set("password", userPassword)
+	req.Header.Set("Captcha", mcv.token)
 	rec := httptest.NewRecorder()
 	c := e.NewContext(req, rec)
-	h := &UserHandler{logger: e.Logger, validator: v, repository: &mu, userAuthorizer: &au, objectUploader: &mockFileUploader}
+	h := &UserHandler{logger: e.Logger, validator: v, repository: &mu, userAuthorizer: &au, objectUploader: &mockFileUploader, contentFilter: &mcf, captchaVerifier: &mcv}
 
 	// Assertions
 	if assert.NoError(t, h.Register(c)) {
@@ -308,6 +321,23 @@ func TestRegister(t *testing.T) {
 	}
 }
 
+func TestRegisterCaptchaFailed(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/game-hangar/v1/register", nil)
+	req.Header.Set("password", userPassword)
+	req.Header.Set("Captcha", "forged")
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	h := &UserHandler{logger: e.Logger, validator: v, repository: &mu, userAuthorizer: &au, contentFilter: &mcf, captchaVerifier: &mcv}
+
+	// Assertions
+	if assert.NoError(t, h.Register(c)) {
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.Equal(t, `{"code":403,"message":"Captcha verification failed!"}`+"\n", rec.Body.String())
+	}
+}
+
 func TestVerify(t *testing.T) {
 	// Setup
 	e := echo.New()
@@ -440,6 +470,29 @@ func TestLoginIncorrectPassword(t *testing.T) { // Password is changed by this t
 	if assert.NoError(t, h.Login(c)) {
 		assert.Equal(t, http.StatusUnauthorized, rec.Code)
 		assert.Equal(t, passwordIncorrectResponse, rec.Body.String())
+		assert.Nil(t, c.Get("userID"))
+		assert.Equal(t, genericUUID.String(), c.Get("auditTarget"))
+	}
+}
+
+func TestLoginBanned(t *testing.T) {
+	// Setup
+	banType, banReason := "ban", "Cheating"
+	ms.sanctions[100] = models.Sanction{UserID: &genericUUID, Type: &banType, Reason: &banReason}
+	defer delete(ms.sanctions, 100)
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/game-hangar/v1/login", nil)
+	req.Header.Set("Username", userUsername)
+	req.Header.Set("password", userPasswordReset)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	h := &UserHandler{logger: e.Logger, validator: v, repository: &mu, userAuthorizer: &au}
+
+	// Assertions
+	if assert.NoError(t, h.Login(c)) {
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.Equal(t, `{"code":403,"message":"User is banned: Cheating"}`+"\n", rec.Body.String())
 	}
 }
 
@@ -524,7 +577,7 @@ func TestPatchUser(t *testing.T) {
 	c.SetPath("/:id")
 	c.SetParamNames("id")
 	c.SetParamValues(genericUUID.String())
-	h := &UserHandler{logger: e.Logger, validator: v, repository: &mu, userAuthorizer: &au, objectUploader: &mockFileUploader}
+	h := &UserHandler{logger: e.Logger, validator: v, repository: &mu, userAuthorizer: &au, objectUploader: &mockFileUploader, contentFilter: &mcf}
 
 	// Assertions
 	if assert.NoError(t, h.PatchUser(c)) {
@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"gamehangar/internal/delivery/http/v1/handlers"
+
+	"github.com/labstack/echo/v4"
+)
+
+type AssetLibRoutes struct {
+	handler *handlers.AssetLibHandler
+}
+
+func NewAssetLibRoutes(h *handlers.AssetLibHandler) *AssetLibRoutes {
+	return &AssetLibRoutes{
+		handler: h,
+	}
+}
+
+// Point the editor's AssetLib tab at <host>/game-hangar/v1/asset-library
+func (r *AssetLibRoutes) InitRoutes(e *echo.Echo) {
+	assetLibGroup := e.Group("/game-hangar/v1/asset-library")
+
+	assetLibGroup.GET("/configure", r.handler.GetConfiguration)
+	assetLibGroup.GET("/asset", r.handler.GetAssets)
+	assetLibGroup.GET("/asset/:id", r.handler.GetAssetByID)
+}
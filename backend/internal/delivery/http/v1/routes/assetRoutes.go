@@ -32,4 +32,6 @@ func (r *AssetRoutes) InitRoutes(e *echo.Echo) {
 	assetGroup.GET("", r.handler.GetAssets)
 	protectedAssetGroup.PATCH("/:id", r.handler.PatchAsset)
 	protectedAssetGroup.DELETE("/:id", r.handler.DeleteAsset)
+	protectedAssetGroup.POST("/:id/restore", r.handler.RestoreAsset)
+	assetGroup.GET("/:id/used-in", r.handler.GetAssetUsages)
 }
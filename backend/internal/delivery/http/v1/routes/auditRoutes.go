@@ -0,0 +1,31 @@
+package routes
+
+import (
+	"gamehangar/internal/delivery/http/v1/handlers"
+
+	casbin_mw "github.com/labstack/echo-contrib/casbin"
+	"github.com/labstack/echo/v4"
+)
+
+type AuditRoutes struct {
+	handler    *handlers.AuditHandler
+	authorizer Authorizer
+}
+
+func NewAuditRoutes(h *handlers.AuditHandler, a Authorizer) *AuditRoutes {
+	return &AuditRoutes{
+		handler:    h,
+		authorizer: a,
+	}
+}
+
+func (r *AuditRoutes) InitRoutes(e *echo.Echo) {
+	auditGroup := e.Group("/game-hangar/v1/audit")
+
+	protectedAuditGroup := auditGroup.Group("")
+	protectedAuditGroup.Use(casbin_mw.MiddlewareWithConfig(casbin_mw.Config{
+		EnforceHandler: r.authorizer.CheckPermissions,
+	}))
+
+	protectedAuditGroup.GET("", r.handler.GetAuditEvents)
+}
@@ -32,4 +32,10 @@ func (r *DemoRoutes) InitRoutes(e *echo.Echo) {
 	demoGroup.GET("", r.handler.GetDemos)
 	protectedDemoGroup.PATCH("/:id", r.handler.PatchDemo)
 	protectedDemoGroup.DELETE("/:id", r.handler.DeleteDemo)
+	protectedDemoGroup.POST("/:id/restore", r.handler.RestoreDemo)
+	demoGroup.GET("/:id/assets", r.handler.GetDemoAssets)
+	protectedDemoGroup.PUT("/:id/assets", r.handler.PutDemoAssets)
+	protectedDemoGroup.POST("/:id/fork", r.handler.ForkDemo)
+	demoGroup.GET("/:id/forks", r.handler.GetDemoForks)
+	demoGroup.GET("/:id/ancestry", r.handler.GetDemoAncestry)
 }
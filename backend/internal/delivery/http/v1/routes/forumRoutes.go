@@ -29,9 +29,12 @@ func (r *ForumRoutes) InitRoutes(e *echo.Echo) {
 
 	protectedTopicGroup.POST("", r.handler.PostTopic)
 	topicGroup.GET("/:id", r.handler.GetTopicByID)
+	topicGroup.GET("/slug/:slug", r.handler.GetTopicBySlug)
 	topicGroup.GET("", r.handler.GetTopics)
+	topicGroup.GET("/:id/threads", r.handler.GetThreadsByTopicID)
 	protectedTopicGroup.PATCH("/:id", r.handler.PatchTopic)
 	protectedTopicGroup.DELETE("/:id", r.handler.DeleteTopic)
+	protectedTopicGroup.POST("/:id/restore", r.handler.RestoreTopic)
 
 	threadGroup := e.Group("/game-hangar/v1/threads")
 
@@ -45,6 +48,9 @@ func (r *ForumRoutes) InitRoutes(e *echo.Echo) {
 	threadGroup.GET("", r.handler.GetThreads)
 	protectedThreadGroup.PATCH("/:id", r.handler.PatchThread)
 	protectedThreadGroup.DELETE("/:id", r.handler.DeleteThread)
+	protectedThreadGroup.POST("/:id/restore", r.handler.RestoreThread)
+	protectedThreadGroup.POST("/:id/operations", r.handler.PostThreadOperation)
+	threadGroup.GET("/:id/operations", r.handler.GetThreadOperations)
 
 	messageGroup := e.Group("/game-hangar/v1/messages")
 
@@ -59,4 +65,8 @@ func (r *ForumRoutes) InitRoutes(e *echo.Echo) {
 	messageGroup.GET("", r.handler.GetMessages)
 	protectedMessageGroup.PATCH("/:id", r.handler.PatchMessage)
 	protectedMessageGroup.DELETE("/:id", r.handler.DeleteMessage)
+	protectedMessageGroup.POST("/:id/restore", r.handler.RestoreMessage)
+
+	mentionGroup := e.Group("/game-hangar/v1/users")
+	mentionGroup.GET("/:id/mentions", r.handler.GetMentions)
 }
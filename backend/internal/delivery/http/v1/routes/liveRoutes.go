@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"gamehangar/internal/delivery/http/v1/handlers"
+
+	"github.com/labstack/echo/v4"
+)
+
+type LiveRoutes struct {
+	handler *handlers.LiveHandler
+}
+
+func NewLiveRoutes(h *handlers.LiveHandler) *LiveRoutes {
+	return &LiveRoutes{
+		handler: h,
+	}
+}
+
+// Public, the session identified by IdentifySession adds the notifications of its user
+func (r *LiveRoutes) InitRoutes(e *echo.Echo) {
+	liveGroup := e.Group("/game-hangar/v1/live")
+
+	liveGroup.GET("", r.handler.GetLiveEvents)
+}
@@ -0,0 +1,33 @@
+package routes
+
+import (
+	"gamehangar/internal/delivery/http/v1/handlers"
+
+	casbin_mw "github.com/labstack/echo-contrib/casbin"
+	"github.com/labstack/echo/v4"
+)
+
+type MailRoutes struct {
+	handler    *handlers.MailHandler
+	authorizer Authorizer
+}
+
+func NewMailRoutes(h *handlers.MailHandler, a Authorizer) *MailRoutes {
+	return &MailRoutes{
+		handler:    h,
+		authorizer: a,
+	}
+}
+
+func (r *MailRoutes) InitRoutes(e *echo.Echo) {
+	mailGroup := e.Group("/game-hangar/v1/email")
+
+	protectedMailGroup := mailGroup.Group("")
+	protectedMailGroup.Use(casbin_mw.MiddlewareWithConfig(casbin_mw.Config{
+		EnforceHandler: r.authorizer.CheckPermissions,
+	}))
+
+	protectedMailGroup.GET("/preferences", r.handler.GetEmailPreferences)
+	protectedMailGroup.PATCH("/preferences", r.handler.PatchEmailPreferences)
+	mailGroup.POST("/unsubscribe/:token", r.handler.Unsubscribe)
+}
@@ -0,0 +1,55 @@
+package routes
+
+import (
+	"gamehangar/internal/delivery/http/v1/handlers"
+
+	casbin_mw "github.com/labstack/echo-contrib/casbin"
+	"github.com/labstack/echo/v4"
+)
+
+type ModerationRoutes struct {
+	handler    *handlers.ModerationHandler
+	authorizer Authorizer
+}
+
+func NewModerationRoutes(h *handlers.ModerationHandler, a Authorizer) *ModerationRoutes {
+	return &ModerationRoutes{
+		handler:    h,
+		authorizer: a,
+	}
+}
+
+func (r *ModerationRoutes) InitRoutes(e *echo.Echo) {
+	reportGroup := e.Group("/game-hangar/v1/reports")
+
+	protectedReportGroup := reportGroup.Group("")
+	protectedReportGroup.Use(casbin_mw.MiddlewareWithConfig(casbin_mw.Config{
+		EnforceHandler: r.authorizer.CheckPermissions,
+	}))
+
+	protectedReportGroup.POST("", r.handler.PostReport)
+	protectedReportGroup.GET("", r.handler.GetReports)
+	protectedReportGroup.GET("/:id", r.handler.GetReportByID)
+	protectedReportGroup.POST("/:id/dismiss", r.handler.DismissReport)
+
+	moderationGroup := e.Group("/game-hangar/v1/moderation")
+
+	protectedModerationGroup := moderationGroup.Group("")
+	protectedModerationGroup.Use(casbin_mw.MiddlewareWithConfig(casbin_mw.Config{
+		EnforceHandler: r.authorizer.CheckPermissions,
+	}))
+
+	protectedModerationGroup.POST("/actions", r.handler.PostModerationAction)
+	protectedModerationGroup.GET("/actions", r.handler.GetModerationActions)
+
+	// Open to moderators of the topic through the authorizer
+	topicModerationGroup := e.Group("/game-hangar/v1/topics/:id/moderation")
+
+	protectedTopicModerationGroup := topicModerationGroup.Group("")
+	protectedTopicModerationGroup.Use(casbin_mw.MiddlewareWithConfig(casbin_mw.Config{
+		EnforceHandler: r.authorizer.CheckPermissions,
+	}))
+
+	protectedTopicModerationGroup.POST("/actions", r.handler.PostTopicModerationAction)
+	protectedTopicModerationGroup.GET("/reports", r.handler.GetTopicReports)
+}
@@ -0,0 +1,36 @@
+package routes
+
+import (
+	"gamehangar/internal/delivery/http/v1/handlers"
+
+	casbin_mw "github.com/labstack/echo-contrib/casbin"
+	"github.com/labstack/echo/v4"
+)
+
+type NotificationRoutes struct {
+	handler    *handlers.NotificationHandler
+	authorizer Authorizer
+}
+
+func NewNotificationRoutes(h *handlers.NotificationHandler, a Authorizer) *NotificationRoutes {
+	return &NotificationRoutes{
+		handler:    h,
+		authorizer: a,
+	}
+}
+
+func (r *NotificationRoutes) InitRoutes(e *echo.Echo) {
+	notificationGroup := e.Group("/game-hangar/v1/notifications")
+
+	protectedNotificationGroup := notificationGroup.Group("")
+	protectedNotificationGroup.Use(casbin_mw.MiddlewareWithConfig(casbin_mw.Config{
+		EnforceHandler: r.authorizer.CheckPermissions,
+	}))
+
+	protectedNotificationGroup.GET("", r.handler.GetNotifications)
+	protectedNotificationGroup.GET("/unread", r.handler.GetUnreadNotificationCount)
+	protectedNotificationGroup.POST("/read", r.handler.ReadAllNotifications)
+	protectedNotificationGroup.POST("/:id/read", r.handler.ReadNotification)
+	protectedNotificationGroup.GET("/preferences", r.handler.GetNotificationPreferences)
+	protectedNotificationGroup.PATCH("/preferences", r.handler.PatchNotificationPreferences)
+}
@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"gamehangar/internal/delivery/http/v1/handlers"
+
+	"github.com/labstack/echo/v4"
+)
+
+type RevisionRoutes struct {
+	handler *handlers.RevisionHandler
+}
+
+func NewRevisionRoutes(h *handlers.RevisionHandler) *RevisionRoutes {
+	return &RevisionRoutes{
+		handler: h,
+	}
+}
+
+// Public, the session identified by IdentifySession lets moderators see revisions of hidden content
+func (r *RevisionRoutes) InitRoutes(e *echo.Echo) {
+	e.GET("/game-hangar/v1/messages/:id/revisions", r.handler.GetMessageRevisions)
+	e.GET("/game-hangar/v1/threads/:id/revisions", r.handler.GetThreadRevisions)
+	e.GET("/game-hangar/v1/demos/:id/revisions", r.handler.GetDemoRevisions)
+}
@@ -0,0 +1,43 @@
+package routes
+
+import (
+	"gamehangar/internal/delivery/http/v1/handlers"
+
+	casbin_mw "github.com/labstack/echo-contrib/casbin"
+	"github.com/labstack/echo/v4"
+)
+
+type SanctionRoutes struct {
+	handler    *handlers.SanctionHandler
+	authorizer Authorizer
+}
+
+func NewSanctionRoutes(h *handlers.SanctionHandler, a Authorizer) *SanctionRoutes {
+	return &SanctionRoutes{
+		handler:    h,
+		authorizer: a,
+	}
+}
+
+func (r *SanctionRoutes) InitRoutes(e *echo.Echo) {
+	userSanctionGroup := e.Group("/game-hangar/v1/users/:id/sanctions")
+
+	protectedUserSanctionGroup := userSanctionGroup.Group("")
+	protectedUserSanctionGroup.Use(casbin_mw.MiddlewareWithConfig(casbin_mw.Config{
+		EnforceHandler: r.authorizer.CheckPermissions,
+	}))
+
+	protectedUserSanctionGroup.GET("", r.handler.GetUserSanctions)
+	protectedUserSanctionGroup.POST("", r.handler.PostSanction)
+
+	sanctionGroup := e.Group("/game-hangar/v1/sanctions")
+
+	protectedSanctionGroup := sanctionGroup.Group("")
+	protectedSanctionGroup.Use(casbin_mw.MiddlewareWithConfig(casbin_mw.Config{
+		EnforceHandler: r.authorizer.CheckPermissions,
+	}))
+
+	protectedSanctionGroup.POST("/:id/revoke", r.handler.RevokeSanction)
+	protectedSanctionGroup.GET("/:id/notes", r.handler.GetSanctionNotes)
+	protectedSanctionGroup.POST("/:id/notes", r.handler.PostSanctionNote)
+}
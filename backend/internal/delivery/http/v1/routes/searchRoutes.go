@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"gamehangar/internal/delivery/http/v1/handlers"
+
+	"github.com/labstack/echo/v4"
+)
+
+type SearchRoutes struct {
+	handler *handlers.SearchHandler
+}
+
+func NewSearchRoutes(h *handlers.SearchHandler) *SearchRoutes {
+	return &SearchRoutes{
+		handler: h,
+	}
+}
+
+// Public, searches only what is listed publicly
+func (r *SearchRoutes) InitRoutes(e *echo.Echo) {
+	e.GET("/game-hangar/v1/search", r.handler.Search)
+}
@@ -0,0 +1,35 @@
+package routes
+
+import (
+	"gamehangar/internal/delivery/http/v1/handlers"
+
+	casbin_mw "github.com/labstack/echo-contrib/casbin"
+	"github.com/labstack/echo/v4"
+)
+
+type SubscriptionRoutes struct {
+	handler    *handlers.SubscriptionHandler
+	authorizer Authorizer
+}
+
+func NewSubscriptionRoutes(h *handlers.SubscriptionHandler, a Authorizer) *SubscriptionRoutes {
+	return &SubscriptionRoutes{
+		handler:    h,
+		authorizer: a,
+	}
+}
+
+func (r *SubscriptionRoutes) InitRoutes(e *echo.Echo) {
+	subscriptionGroup := e.Group("/game-hangar/v1/subscriptions")
+
+	protectedSubscriptionGroup := subscriptionGroup.Group("")
+	protectedSubscriptionGroup.Use(casbin_mw.MiddlewareWithConfig(casbin_mw.Config{
+		EnforceHandler: r.authorizer.CheckPermissions,
+	}))
+
+	protectedSubscriptionGroup.GET("", r.handler.GetSubscriptions)
+	protectedSubscriptionGroup.GET("/feed", r.handler.GetFeed)
+	protectedSubscriptionGroup.POST("/:type/:target", r.handler.Subscribe)
+	protectedSubscriptionGroup.DELETE("/:type/:target", r.handler.Unsubscribe)
+	subscriptionGroup.GET("/:type/:target/subscribers", r.handler.GetSubscriberCount)
+}
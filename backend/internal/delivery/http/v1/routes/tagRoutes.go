@@ -0,0 +1,37 @@
+package routes
+
+import (
+	"gamehangar/internal/delivery/http/v1/handlers"
+
+	casbin_mw "github.com/labstack/echo-contrib/casbin"
+	"github.com/labstack/echo/v4"
+)
+
+type TagRoutes struct {
+	handler    *handlers.TagHandler
+	authorizer Authorizer
+}
+
+func NewTagRoutes(h *handlers.TagHandler, a Authorizer) *TagRoutes {
+	return &TagRoutes{
+		handler:    h,
+		authorizer: a,
+	}
+}
+
+// Tags are listed publicly, renamed, aliased and merged by admins only
+func (r *TagRoutes) InitRoutes(e *echo.Echo) {
+	tagGroup := e.Group("/game-hangar/v1/tags")
+
+	protectedTagGroup := tagGroup.Group("")
+	protectedTagGroup.Use(casbin_mw.MiddlewareWithConfig(casbin_mw.Config{
+		EnforceHandler: r.authorizer.CheckPermissions,
+	}))
+
+	tagGroup.GET("", r.handler.GetTags)
+	tagGroup.GET("/:slug", r.handler.GetTag)
+	protectedTagGroup.PATCH("/:slug", r.handler.PatchTag)
+	protectedTagGroup.POST("/:slug/aliases", r.handler.PostTagAlias)
+	protectedTagGroup.DELETE("/:slug/aliases/:alias", r.handler.DeleteTagAlias)
+	protectedTagGroup.POST("/:slug/merge", r.handler.PostTagMerge)
+}
@@ -0,0 +1,34 @@
+package routes
+
+import (
+	"gamehangar/internal/delivery/http/v1/handlers"
+
+	casbin_mw "github.com/labstack/echo-contrib/casbin"
+	"github.com/labstack/echo/v4"
+)
+
+type TopicModeratorRoutes struct {
+	handler    *handlers.TopicModeratorHandler
+	authorizer Authorizer
+}
+
+func NewTopicModeratorRoutes(h *handlers.TopicModeratorHandler, a Authorizer) *TopicModeratorRoutes {
+	return &TopicModeratorRoutes{
+		handler:    h,
+		authorizer: a,
+	}
+}
+
+// Moderators are listed publicly, assigned and removed by admins only
+func (r *TopicModeratorRoutes) InitRoutes(e *echo.Echo) {
+	topicModeratorGroup := e.Group("/game-hangar/v1/topics/:id/moderators")
+
+	protectedTopicModeratorGroup := topicModeratorGroup.Group("")
+	protectedTopicModeratorGroup.Use(casbin_mw.MiddlewareWithConfig(casbin_mw.Config{
+		EnforceHandler: r.authorizer.CheckPermissions,
+	}))
+
+	topicModeratorGroup.GET("", r.handler.GetTopicModerators)
+	protectedTopicModeratorGroup.POST("", r.handler.PostTopicModerator)
+	protectedTopicModeratorGroup.DELETE("/:userID", r.handler.DeleteTopicModerator)
+}
@@ -0,0 +1,31 @@
+package routes
+
+import (
+	"gamehangar/internal/delivery/http/v1/handlers"
+
+	casbin_mw "github.com/labstack/echo-contrib/casbin"
+	"github.com/labstack/echo/v4"
+)
+
+type TrashRoutes struct {
+	handler    *handlers.TrashHandler
+	authorizer Authorizer
+}
+
+func NewTrashRoutes(h *handlers.TrashHandler, a Authorizer) *TrashRoutes {
+	return &TrashRoutes{
+		handler:    h,
+		authorizer: a,
+	}
+}
+
+func (r *TrashRoutes) InitRoutes(e *echo.Echo) {
+	trashGroup := e.Group("/game-hangar/v1/trash")
+
+	protectedTrashGroup := trashGroup.Group("")
+	protectedTrashGroup.Use(casbin_mw.MiddlewareWithConfig(casbin_mw.Config{
+		EnforceHandler: r.authorizer.CheckPermissions,
+	}))
+
+	protectedTrashGroup.GET("", r.handler.GetTrash)
+}
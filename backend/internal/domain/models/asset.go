@@ -6,7 +6,7 @@ type Asset struct {
 	ID           *int       `form:"id" json:"id,omitempty"`
 	Name         *string    `form:"name" json:"name,omitempty" validate:"required_if=Method POST,omitnil,max=90"`
 	Description  *string    `form:"description" json:"description,omitempty"`
-	Tags         *[]string  `form:"tags" json:"tags,omitempty" validate:"omitnil,unique,max=40"`
+	Tags         *[]string  `form:"tags" json:"tags,omitempty" validate:"omitnil,unique,max=40,dive,max=64,tag"`
 	CreatedAt    *time.Time `json:"createdAt,omitempty"`
 	UpdatedAt    *time.Time `json:"updatedAt,omitzero"`
 	Version      *int       `form:"version" json:"version,omitempty" validate:"required_if=Method PATCH,omitnil,number,gt=0"`
@@ -16,6 +16,14 @@ type Asset struct {
 	Views        *uint      `json:"views,omitzero" validate:"omitnil,number,min=0"`
 	Key          *string    `json:"key"` // Links to an S3 bucket
 	ThumbnailKey *string    `json:"thumbnailKey"`
+	UsageCount   *uint      `json:"usageCount,omitzero"` // Number of demos declaring this asset
+	CategoryID   *int       `form:"categoryID" json:"categoryID,omitempty" validate:"omitnil,number,gt=0"`
+	GodotVersion *string    `form:"godotVersion" json:"godotVersion,omitempty" validate:"omitnil,max=16,godotversion"`
+	SupportLevel *string    `form:"supportLevel" json:"supportLevel,omitempty" validate:"omitnil,oneof=official community testing"`
+	License      *string    `form:"license" json:"license,omitempty" validate:"omitnil,max=64"`
+	DownloadHash *string    `json:"downloadHash,omitempty"` // SHA-256 of the project file
+	HiddenAt     *time.Time `json:"hiddenAt,omitempty"`     // Set by moderators. Hidden assets are only shown to moderators
+	FileSize     *int64     `json:"fileSize,omitempty"`     // Bytes of the project file, set from the upload
 	Method       string     `json:"-"`
 }
 
@@ -0,0 +1,75 @@
+package models
+
+import "time"
+
+// Types below follow the JSON format of the Godot Asset Library API,
+// which is what the editor's AssetLib tab speaks. Numbers are sent as strings
+// on purpose: the editor expects them that way.
+
+type AssetCategory struct {
+	ID   *int    `json:"id"`
+	Name *string `json:"name"`
+	Type *int    `json:"type"` // 0 for addons, 1 for projects
+}
+
+// Query parameters accepted by GET /asset
+type AssetLibQuery struct {
+	Type         string   // "addon", "project" or "any"
+	CategoryID   int      // 0 for any category
+	SupportLevel []string // Any of "official", "community", "testing"
+	Filter       string   // Text filter on asset name
+	GodotVersion string   // "major.minor", empty for any version
+	Sort         string   // "rating", "cost", "name" or "updated"
+	Reverse      bool
+	Limit        uint64
+	Offset       uint64
+}
+
+type AssetLibCategory struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type AssetLibConfiguration struct {
+	Categories []AssetLibCategory `json:"categories"`
+}
+
+type AssetLibSummary struct {
+	AssetID       string    `json:"asset_id"`
+	Title         string    `json:"title"`
+	Author        string    `json:"author"`
+	AuthorID      string    `json:"author_id"`
+	Category      string    `json:"category"`
+	CategoryID    string    `json:"category_id"`
+	GodotVersion  string    `json:"godot_version"`
+	Rating        string    `json:"rating"`
+	Cost          string    `json:"cost"`
+	SupportLevel  string    `json:"support_level"`
+	IconURL       string    `json:"icon_url"`
+	Version       string    `json:"version"`
+	VersionString string    `json:"version_string"`
+	ModifyDate    time.Time `json:"modify_date"`
+}
+
+type AssetLibPage struct {
+	Result     []AssetLibSummary `json:"result"`
+	Page       uint64            `json:"page"`
+	Pages      uint64            `json:"pages"`
+	PageLength uint64            `json:"page_length"`
+	TotalItems uint64            `json:"total_items"`
+}
+
+type AssetLibAsset struct {
+	AssetLibSummary
+	Type             string   `json:"type"`
+	Description      string   `json:"description"`
+	DownloadProvider string   `json:"download_provider"`
+	DownloadCommit   string   `json:"download_commit"`
+	DownloadHash     string   `json:"download_hash"`
+	DownloadURL      string   `json:"download_url"`
+	BrowseURL        string   `json:"browse_url"`
+	IssuesURL        string   `json:"issues_url"`
+	Searchable       string   `json:"searchable"`
+	Previews         []string `json:"previews"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// A single append-only record of a security- or content-relevant action
+type AuditEvent struct {
+	ID         *int64     `json:"id"`
+	ActorID    *uuid.UUID `json:"actorID"`
+	Action     *string    `json:"action"`     // Method and route of a request, e.g. "DELETE threads/:id", or a change, e.g. "delete"
+	TargetType *string    `json:"targetType"` // e.g. demos, threads, users, sessions, roles
+	TargetID   *string    `json:"targetID"`
+	IP         *string    `json:"ip"`
+	Status     *int       `json:"status"` // Requests only, as is the IP
+	Before     *string    `json:"before"` // Changes only, the changed row as JSON
+	After      *string    `json:"after"`
+	CreatedAt  *time.Time `json:"createdAt"`
+}
+
+type AuditFilter struct {
+	ActorID    *uuid.UUID
+	TargetType *string
+	From       *time.Time
+	To         *time.Time
+}
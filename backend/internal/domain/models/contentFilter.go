@@ -0,0 +1,18 @@
+package models
+
+import "github.com/google/uuid"
+
+// User-submitted text that is checked by the content filter before it is stored
+type FilterSubject struct {
+	Kind     string     // One of message, thread, demo, username
+	AuthorID *uuid.UUID // Nil for registrations
+	Text     string
+}
+
+// Outcome of the content filter. Rejected content is not stored,
+// content sent to review is stored hidden and reported to the moderators
+type FilterVerdict struct {
+	Action string `json:"action"` // One of allow, review, reject
+	Filter string `json:"filter,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
@@ -10,7 +10,7 @@ type Demo struct {
 	ID           *int       `form:"id" json:"id,omitempty"`
 	Title        *string    `form:"title" json:"title,omitempty" validate:"required_if=Method POST,omitnil,lt=90"`
 	Description  *string    `form:"description" json:"description,omitempty" validate:"omitnil,max=5000"`
-	Tags         *[]string  `form:"tags" json:"tags,omitempty" validate:"omitnil,unique,max=40"`
+	Tags         *[]string  `form:"tags" json:"tags,omitempty" validate:"omitnil,unique,max=40,dive,max=64,tag"`
 	UserID       *uuid.UUID `form:"userID" json:"userID,omitempty" validate:"required_if=Method POST,omitnil,uuid4"`
 	ThreadID     *int       `form:"threadID" json:"threadID,omitempty" validate:"required_if=Method PATCH,omitnil,number"`
 	CreatedAt    *time.Time `json:"createdAt,omitzero"`
@@ -21,5 +21,23 @@ type Demo struct {
 	Views        *uint      `json:"views,omitzero" validate:"omitnil,number,min=0"`
 	Key          *string    `json:"key"` // Links to an S3 bucket
 	ThumbnailKey *string    `json:"thumbnailKey"`
-	Method       string     `json:"-"`
+	ForkedFrom   *int       `json:"forkedFrom,omitempty"`
+	AllowRemix   *bool      `form:"allowRemix" json:"allowRemix,omitempty"`
+	HiddenAt     *time.Time `json:"hiddenAt,omitempty"` // Set by moderators. Hidden demos are only shown to their author and moderators
+	// Sanitized HTML rendered from the Markdown description
+	DescriptionHTML *string `json:"descriptionHTML,omitempty"`
+	// Set when the title, description or tags were edited, see Revision
+	EditedAt  *time.Time `json:"editedAt,omitempty"`
+	EditedBy  *uuid.UUID `json:"editedBy,omitempty"` // Set to the session user on update
+	Revisions *int       `json:"revisions,omitempty"`
+	FileSize  *int64     `json:"fileSize,omitempty"` // Bytes of the project file, set from the upload
+	Method    string     `json:"-"`
+}
+
+// Links a demo to an asset it uses. A nil AssetVersion means "any/latest"
+type DemoAsset struct {
+	DemoID       *int   `json:"demoID,omitempty"`
+	AssetID      *int   `json:"assetID,omitempty" validate:"required,number"`
+	AssetVersion *int   `json:"assetVersion,omitempty" validate:"omitnil,number,gt=0"`
+	Asset        *Asset `json:"asset,omitempty"`
 }
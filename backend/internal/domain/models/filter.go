@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Narrows a listing of demos, assets or threads. Nil and empty fields do not filter,
+// date ranges include From and exclude To
+type ListFilter struct {
+	UserID       *uuid.UUID
+	Tags         []string
+	AnyTag       bool // Matches content with any of Tags instead of all of them
+	ExcludeTags  []string
+	TopicID      *int // Includes the subcategories of the topic
+	CreatedFrom  *time.Time
+	CreatedTo    *time.Time
+	UpdatedFrom  *time.Time
+	UpdatedTo    *time.Time
+	MinRating    *float64
+	GodotVersion *string // Matches assets compatible with the version, as AssetLib does
+	MinSize      *int64
+	MaxSize      *int64
+}
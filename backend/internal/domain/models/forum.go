@@ -6,40 +6,122 @@ import (
 	"github.com/google/uuid"
 )
 
+// Forum category, which may be nested under a parent category
 type Topic struct {
-	ID      *int    `json:"id,omitempty"`
-	Name    *string `json:"name,omitempty" validate:"required,lt=90"`
-	Version *int    `json:"version,omitempty" validate:"required_if=Method PATCH,omitnil,number,gt=0"`
-	Method  string  `json:"-"`
+	ID          *int    `json:"id,omitempty"`
+	Name        *string `json:"name,omitempty" validate:"required,lt=90"`
+	Version     *int    `json:"version,omitempty" validate:"required_if=Method PATCH,omitnil,number,gt=0"`
+	Subscribers *int    `json:"subscribers,omitempty"`
+	// Owner of the topic, who may manage its threads. Set to the session user on creation
+	UserID *uuid.UUID `json:"userID,omitempty"`
+	// Normalized on write, derived from the name when left out
+	Slug        *string `json:"slug,omitempty" validate:"omitnil,min=1,max=90"`
+	Description *string `json:"description,omitempty" validate:"omitnil,max=2000"`
+	Icon        *string `json:"icon,omitempty" validate:"omitnil,max=255"`
+	SortOrder   *int    `json:"sortOrder,omitempty" validate:"omitnil,number"` // Lower first among siblings
+	// 0 moves the category to the top level on update
+	ParentID *int `json:"parentID,omitempty" validate:"omitnil,number,min=0"`
+	// Who may create threads, one of everyone, owner, moderators. Moderators are never restricted
+	ThreadAccess *string `json:"threadAccess,omitempty" validate:"omitnil,oneof=everyone owner moderators"`
+	// Accounts younger than this may neither create threads nor post messages in the category
+	MinAccountAgeDays *int `json:"minAccountAgeDays,omitempty" validate:"omitnil,number,min=0,max=3650"`
+	// Set in nested listings
+	Children *[]Topic `json:"children,omitempty"`
+	Method   string   `json:"-"`
+}
+
+// User assigned to moderate the threads and messages of a category and its subcategories
+type TopicModerator struct {
+	TopicID    *int       `json:"topicID"`
+	UserID     *uuid.UUID `json:"userID" validate:"required"`
+	AssignedBy *uuid.UUID `json:"assignedBy,omitempty"`
+	CreatedAt  *time.Time `json:"createdAt"`
+	Username   *string    `json:"username,omitempty"`
 }
 
 type Thread struct {
-	ID        *int       `json:"id,omitempty"`
-	Title     *string    `json:"title,omitempty" validate:"required_if=Method POST,omitnil,lt=90"`
-	UserID    *uuid.UUID `json:"userID,omitempty" validate:"required_if=Method POST,omitnil,uuid4"`
-	TopicID   *int       `json:"topicID,omitempty" validate:"required_if=Method POST,omitnil,number"`
-	Tags      *[]string  `json:"tags,omitempty" validate:"omitnil,unique,max=40"`
-	CreatedAt *time.Time `json:"createdAt,omitzero"`
-	UpdatedAt *time.Time `json:"updatedAt,omitzero"`
-	Upvotes   *uint      `json:"upvotes,omitempty" validate:"omitnil,number,min=0"`
-	Downvotes *uint      `json:"downvotes,omitempty" validate:"omitnil,number,min=0"`
-	Rating    *float64   `json:"rating,omitzero" validate:"omitnil,excluded_if=Method GET"`
-	Views     *uint      `json:"views,omitzero" validate:"omitnil,number,min=0"`
+	ID          *int       `json:"id,omitempty"`
+	Title       *string    `json:"title,omitempty" validate:"required_if=Method POST,omitnil,lt=90"`
+	UserID      *uuid.UUID `json:"userID,omitempty" validate:"required_if=Method POST,omitnil,uuid4"`
+	TopicID     *int       `json:"topicID,omitempty" validate:"required_if=Method POST,omitnil,number"`
+	Tags        *[]string  `json:"tags,omitempty" validate:"omitnil,unique,max=40,dive,max=64,tag"`
+	CreatedAt   *time.Time `json:"createdAt,omitzero"`
+	UpdatedAt   *time.Time `json:"updatedAt,omitzero"`
+	Upvotes     *uint      `json:"upvotes,omitempty" validate:"omitnil,number,min=0"`
+	Downvotes   *uint      `json:"downvotes,omitempty" validate:"omitnil,number,min=0"`
+	Rating      *float64   `json:"rating,omitzero" validate:"omitnil,excluded_if=Method GET"`
+	Views       *uint      `json:"views,omitzero" validate:"omitnil,number,min=0"`
+	HiddenAt    *time.Time `json:"hiddenAt,omitempty"` // Set by moderators. Hidden threads are only shown to their author and moderators
+	LockedAt    *time.Time `json:"lockedAt,omitempty"` // Locked threads accept no new messages
+	Subscribers *int       `json:"subscribers,omitempty"`
+	// Set when the title or tags were edited, see Revision
+	EditedAt  *time.Time `json:"editedAt,omitempty"`
+	EditedBy  *uuid.UUID `json:"editedBy,omitempty"` // Set to the session user on update
+	Revisions *int       `json:"revisions,omitempty"`
+	PinnedAt  *time.Time `json:"pinnedAt,omitempty"` // Pinned threads are listed first in their topic
+	Version   *int       `json:"version,omitempty"`  // Incremented by every ThreadOperation
 	Method    string     `json:"-"`
 }
 
+// Pin, lock, move, merge or split of a thread by a moderator or the owner of its topic
+type ThreadOperation struct {
+	ID        *int       `json:"id"`
+	ThreadID  *int       `json:"threadID"`
+	UserID    *uuid.UUID `json:"userID"` // Set to the session user
+	Operation *string    `json:"operation" validate:"required,oneof=pin unpin lock unlock move merge split"`
+	// Version of the thread the operation is meant for, the version it results in once recorded
+	Version     *int `json:"version" validate:"required,number,gt=0"`
+	FromTopicID *int `json:"fromTopicID,omitempty"`
+	// Topic to move the thread to
+	TopicID *int `json:"topicID,omitempty" validate:"required_if=Operation move,omitnil,number,gt=0"`
+	// Thread to merge the thread into, or the new thread of a split
+	TargetThreadID *int `json:"targetThreadID,omitempty" validate:"required_if=Operation merge,omitnil,number,gt=0"`
+	// Messages to split into a new thread titled Title
+	MessageIDs *[]int     `json:"messageIDs,omitempty" validate:"required_if=Operation split,omitnil,min=1,max=100,unique"`
+	Title      *string    `json:"title,omitempty" validate:"required_if=Operation split,omitnil,min=1,lt=90"`
+	Reason     *string    `json:"reason,omitempty" validate:"omitnil,max=2000"`
+	CreatedAt  *time.Time `json:"createdAt"`
+}
+
 type Message struct {
 	ID        *int       `json:"id,omitempty"`
 	ThreadID  *int       `json:"threadID,omitempty" validate:"required_if=Method POST,omitnil,number"`
 	UserID    *uuid.UUID `json:"userID,omitempty" validate:"required_if=Method POST,omitnil,uuid4"`
 	Title     *string    `json:"title,omitempty" validate:"required_if=Method POST,omitnil,lt=90"`
 	Body      *string    `json:"body,omitempty" validate:"omitnil,max=10000"`
-	Tags      *[]string  `json:"tags,omitempty" validate:"omitnil,unique,max=40"`
+	Tags      *[]string  `json:"tags,omitempty" validate:"omitnil,unique,max=40,dive,max=64,tag"`
 	CreatedAt *time.Time `json:"createdAt,omitzero"`
 	UpdatedAt *time.Time `json:"updatedAt,omitzero"`
 	Upvotes   *uint      `json:"upvotes,omitempty" validate:"omitnil,number,min=0"`
 	Downvotes *uint      `json:"downvotes,omitempty" validate:"omitnil,number,min=0"`
 	Rating    *float64   `json:"rating,omitzero" validate:"omitnil,excluded_if=Method GET"`
 	Views     *uint      `json:"views,omitzero" validate:"omitnil,number,min=0"`
-	Method    string     `json:"-"`
+	HiddenAt  *time.Time `json:"hiddenAt,omitempty"`
+	// Parent message in the same thread
+	ReplyTo *int `json:"replyTo,omitempty" validate:"omitnil,excluded_unless=Method POST,number"`
+	// Excerpt of the parent message, which must contain it
+	Quote      *string `json:"quote,omitempty" validate:"omitnil,excluded_unless=Method POST,excluded_without=ReplyTo,min=1,max=1000"`
+	ReplyCount *int    `json:"replyCount,omitempty"` // Visible direct replies
+	BodyHTML   *string `json:"bodyHTML,omitempty"`   // Sanitized HTML rendered from the Markdown body
+	// Set when the title, body or tags were edited, see Revision
+	EditedAt  *time.Time `json:"editedAt,omitempty"`
+	EditedBy  *uuid.UUID `json:"editedBy,omitempty"` // Set to the session user on update
+	Revisions *int       `json:"revisions,omitempty"`
+	// Set in flat listings of a thread
+	Parent *ReplyContext `json:"parent,omitempty"`
+	// Set in nested listings of a thread
+	Replies *[]Message `json:"replies,omitempty"`
+	// Marks a deleted or hidden message that is only listed to keep its replies in place
+	Deleted *bool `json:"deleted,omitempty"`
+	// Users mentioned as @username in the body
+	Mentions *[]UserRef `json:"mentions,omitempty"`
+	Method   string     `json:"-"`
+}
+
+// The replied message as shown with a reply
+type ReplyContext struct {
+	ID      *int       `json:"id"`
+	UserID  *uuid.UUID `json:"userID,omitempty"`
+	Excerpt *string    `json:"excerpt,omitempty"` // The quote of the reply, or the start of the replied message
+	Deleted bool       `json:"deleted,omitempty"` // The replied message was deleted or hidden
 }
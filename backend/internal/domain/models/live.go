@@ -0,0 +1,11 @@
+package models
+
+import "encoding/json"
+
+// Published by triggers in the database, see migration 017_live_events.sql. Channel is either
+// thread:<id> or user:<id>, the latter only carries notifications of the user
+type LiveEvent struct {
+	Channel string          `json:"channel"`
+	Event   string          `json:"event"`
+	Data    json.RawMessage `json:"data"`
+}
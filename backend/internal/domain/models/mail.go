@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type EmailPreferences struct {
+	Frequency *string `json:"frequency" validate:"omitnil,oneof=off immediate daily weekly"`
+	Locale    *string `json:"locale" validate:"omitnil,oneof=en ru"`
+}
+
+// Outbound mail, rendered when queued
+type Mail struct {
+	ID             *int64
+	UserID         *uuid.UUID
+	Recipient      *string
+	Subject        *string
+	HTML           *string
+	Text           *string
+	UnsubscribeURL *string
+	Attempts       *int
+}
+
+// A user that is due some mail, with what is needed to address and localize it
+type MailRecipient struct {
+	UserID           uuid.UUID
+	Email            string
+	Name             string
+	Locale           string
+	UnsubscribeToken uuid.UUID
+	Since            time.Time // Previous digest
+}
+
+// A notification along with the title of its target
+type MailNotification struct {
+	Notification
+	TargetTitle string
+}
+
+// What a single mail tells the recipient about: new notifications right away,
+// or everything since the previous digest
+type NotificationMail struct {
+	Recipient     MailRecipient
+	Notifications []MailNotification
+	Demos         []Demo // New demos in subscribed tags and by followed users, digests only
+}
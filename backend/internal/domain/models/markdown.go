@@ -0,0 +1,9 @@
+package models
+
+// Stored Markdown of a message body or a demo description along with its rendered HTML
+type MarkdownSource struct {
+	Type   *string // One of message, demo
+	ID     *int
+	Source *string
+	HTML   *string
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// A user's complaint about a piece of content, waiting in the moderator queue
+type Report struct {
+	ID         *int       `json:"id"`
+	ReporterID *uuid.UUID `json:"reporterID"`
+	TargetType *string    `json:"targetType" validate:"required,oneof=demo asset thread message"`
+	TargetID   *int       `json:"targetID" validate:"required,number,gt=0"`
+	Reason     *string    `json:"reason" validate:"required,oneof=spam abuse harassment nsfw illegal copyright other"`
+	Details    *string    `json:"details,omitempty" validate:"omitnil,max=2000"`
+	State      *string    `json:"state"` // One of open, actioned, dismissed
+	CreatedAt  *time.Time `json:"createdAt"`
+	ResolvedAt *time.Time `json:"resolvedAt,omitempty"`
+	ResolvedBy *uuid.UUID `json:"resolvedBy,omitempty"`
+	Resolution *string    `json:"resolution,omitempty"`
+}
+
+type ReportFilter struct {
+	State      string
+	TargetType string
+	TopicID    *int // Reports on the threads and messages of the topic and its subtopics
+	Limit      uint64
+	Offset     uint64
+}
+
+type ModerationAction struct {
+	ID          *int       `json:"id"`
+	ModeratorID *uuid.UUID `json:"moderatorID"`
+	TargetType  *string    `json:"targetType" validate:"required,oneof=demo asset thread message"`
+	TargetID    *int       `json:"targetID" validate:"required,number,gt=0"`
+	AuthorID    *uuid.UUID `json:"authorID"` // Author of the content at the time of the action
+	Action      *string    `json:"action" validate:"required,oneof=hide unhide lock unlock delete warn"`
+	Reason      *string    `json:"reason" validate:"required,min=1,max=2000"`
+	CreatedAt   *time.Time `json:"createdAt"`
+	// Set when taken by a moderator of the category, whose actions are limited to its threads and messages
+	TopicID *int `json:"topicID,omitempty"`
+}
+
+type ModerationActionFilter struct {
+	TargetType string
+	TargetID   int
+	AuthorID   *uuid.UUID
+	Limit      uint64
+	Offset     uint64
+}
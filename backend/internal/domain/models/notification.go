@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var NotificationTypes = []string{"reply", "vote", "moderation", "mention"}
+
+// Activity on content of the recipient. Events of the same type on the same target
+// are coalesced into one unread notification, e.g. 5 new replies in a thread
+type Notification struct {
+	ID         *int       `json:"id"`
+	UserID     *uuid.UUID `json:"userID"`
+	Type       *string    `json:"type"`       // One of reply, vote, moderation, mention
+	TargetType *string    `json:"targetType"` // One of demo, asset, thread, message
+	TargetID   *int       `json:"targetID"`
+	ActorID    *uuid.UUID `json:"actorID,omitempty"` // Latest user behind the events, votes are anonymous
+	Count      *int       `json:"count"`
+	CreatedAt  *time.Time `json:"createdAt"`
+	UpdatedAt  *time.Time `json:"updatedAt"`
+	ReadAt     *time.Time `json:"readAt,omitempty"`
+}
+
+type NotificationFilter struct {
+	UserID uuid.UUID
+	Unread bool
+	Limit  uint64
+	Offset uint64
+}
+
+type NotificationPreference struct {
+	Type    *string `json:"type" validate:"required,oneof=reply vote moderation mention"`
+	Enabled *bool   `json:"enabled" validate:"required"`
+}
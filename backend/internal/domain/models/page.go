@@ -0,0 +1,16 @@
+package models
+
+// One page of a listing. Next and Prev are opaque cursors of the neighbouring pages, nil at either end.
+// Total counts every matching record and is only a hint, the listing may change between pages
+type Page[T any] struct {
+	Items []T     `json:"items"`
+	Next  *string `json:"next,omitempty"`
+	Prev  *string `json:"prev,omitempty"`
+	Total int64   `json:"total"`
+}
+
+// Requested page of a listing, an empty Cursor asks for the first page
+type PageQuery struct {
+	Limit  uint64
+	Cursor string
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Edit history of a message, thread or demo, oldest revision first
+type RevisionHistory struct {
+	TargetType *string    `json:"targetType"`
+	TargetID   *int       `json:"targetID"`
+	UserID     *uuid.UUID `json:"userID"` // Author of the target
+	HiddenAt   *time.Time `json:"hiddenAt,omitempty"`
+	Revisions  []Revision `json:"revisions"`
+}
+
+// Content as it was before an edit, along with the changes the edit made
+type Revision struct {
+	Number   *int       `json:"number"` // 1 is the original content
+	Title    *string    `json:"title"`
+	Body     *string    `json:"body,omitempty"` // Message body or demo description
+	Tags     *[]string  `json:"tags,omitempty"`
+	EditedBy *uuid.UUID `json:"editedBy,omitempty"` // Nil for edits made by the site, e.g. demo thread syncing
+	EditedAt *time.Time `json:"editedAt"`
+	Diff     *Diff      `json:"diff"`
+}
+
+// Changes from a revision to the next one, or to the current content
+type Diff struct {
+	Title       []DiffLine `json:"title,omitempty"`
+	Body        []DiffLine `json:"body,omitempty"`
+	AddedTags   []string   `json:"addedTags,omitempty"`
+	RemovedTags []string   `json:"removedTags,omitempty"`
+}
+
+type DiffLine struct {
+	Op   string `json:"op"` // One of equal, insert, delete
+	Text string `json:"text"`
+}
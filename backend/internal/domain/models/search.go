@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+)
+
+var SearchTypes = []string{"demo", "asset", "thread", "message", "user"}
+
+// A demo, asset, thread, message or user matching a search
+type SearchResult struct {
+	Type  *string `json:"type"`
+	ID    *string `json:"id"` // User IDs are UUIDs, the others numbers
+	Title *string `json:"title"`
+	// Escaped fragments of the text around the matches, which are wrapped in <b> tags
+	Headline *string `json:"headline"`
+	// ts_rank of the text match, plus 1 when a tag matches a word of the query
+	Rank      *float64   `json:"rank"`
+	Tags      *[]string  `json:"tags,omitempty"`
+	CreatedAt *time.Time `json:"createdAt"`
+}
+
+// Query uses the web search syntax, Tags narrow the results to content having all of them.
+// Each of Types is paged on its own, a Cursor is only valid for the type it was returned for
+type SearchQuery struct {
+	Query string
+	Types []string
+	Tags  []string
+	Page  PageQuery
+}
+
+type SearchResults struct {
+	Results map[string]*Page[SearchResult] `json:"results"` // By type
+	Facets  SearchFacets                   `json:"facets"`
+}
+
+// Counts of the matches of every type and of their most used tags, regardless of the searched types
+type SearchFacets struct {
+	Types map[string]int64 `json:"types"`
+	Tags  map[string]int64 `json:"tags"`
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var SubscriptionTargetTypes = []string{"thread", "topic", "tag", "user"}
+
+// Follows a thread, a topic, a tag or a user. Target is the ID of the thread, topic or user, or the tag slug
+type Subscription struct {
+	ID         *int       `json:"id"`
+	UserID     *uuid.UUID `json:"userID"`
+	TargetType *string    `json:"targetType"`
+	Target     *string    `json:"target"`
+	CreatedAt  *time.Time `json:"createdAt"`
+}
+
+type SubscriptionFilter struct {
+	UserID     uuid.UUID
+	TargetType string
+	Limit      uint64
+	Offset     uint64
+}
+
+// A new demo, asset or thread in the following feed
+type FeedItem struct {
+	Type      *string    `json:"type"` // One of demo, asset, thread
+	ID        *int       `json:"id"`
+	Title     *string    `json:"title"`
+	UserID    *uuid.UUID `json:"userID,omitempty"` // Assets do not record their author
+	Tags      *[]string  `json:"tags"`
+	CreatedAt *time.Time `json:"createdAt"`
+}
+
+// The feed is strictly chronological, Before pages back from the last item of the previous page
+type FeedFilter struct {
+	UserID uuid.UUID
+	Before *time.Time
+	Limit  uint64
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+)
+
+var TagContentTypes = []string{"demo", "asset", "thread", "message"}
+
+// Content stores tags by slug, which their aliases resolve to. Name is only shown
+type Tag struct {
+	ID   *int    `json:"id,omitempty"`
+	Slug *string `json:"slug,omitempty"`
+	// Renaming may only change how the slug is spelled, see TagMerge to change it
+	Name *string `json:"name,omitempty" validate:"required,max=64,tag"`
+	// Number of demos, assets, threads and messages tagged with it, not counting deleted ones
+	Usage     map[string]int `json:"usage,omitempty"`
+	Aliases   []string       `json:"aliases,omitempty"`
+	CreatedAt *time.Time     `json:"createdAt,omitempty"`
+}
+
+// Another spelling of a tag, slugified on write. It may be neither a tag nor an alias already
+type TagAlias struct {
+	Slug *string `json:"slug" validate:"required,max=64,tag"`
+}
+
+// Retags everything tagged with the merged tag, which becomes an alias of Into
+type TagMerge struct {
+	Into *string `json:"into" validate:"required,max=64,tag"`
+}
+
+// Prefix matches slugs and aliases, tags of ContentType are the ones it tags at least once
+type TagFilter struct {
+	Prefix      string
+	ContentType string
+	Limit       uint64
+}
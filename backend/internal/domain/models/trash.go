@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// A soft-deleted record awaiting restore or purge
+type TrashItem struct {
+	Type      *string    `json:"type"` // One of demo, asset, topic, thread, message
+	ID        *int       `json:"id"`
+	Title     *string    `json:"title"`
+	DeletedAt *time.Time `json:"deletedAt"`
+	DeletedBy *uuid.UUID `json:"deletedBy"`
+	PurgeAt   *time.Time `json:"purgeAt"`
+}
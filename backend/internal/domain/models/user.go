@@ -21,6 +21,35 @@ type User struct {
 	Role        *string    `form:"role" json:"role,omitempty" validate:"required_if=Method POST,omitnil,max=255"`
 	CreatedAt   *time.Time `json:"createdAt,omitzero"`
 	Karma       *int       `json:"karma,omitempty" validate:"omitnil,number"`
+	Subscribers *int       `json:"subscribers,omitempty"`
 	ProfilePic  *string    `json:"profilePic"`
 	Method      string     `json:"-"`
 }
+
+// A user as linked from content, e.g. a mention
+type UserRef struct {
+	ID       *uuid.UUID `json:"id"`
+	Username *string    `json:"username"`
+}
+
+// Restricts a user until ExpiresAt or, if it is nil, until revoked
+type Sanction struct {
+	ID        *int       `json:"id"`
+	UserID    *uuid.UUID `json:"userID"`
+	Type      *string    `json:"type" validate:"required,oneof=ban post_mute upload_mute"`
+	Reason    *string    `json:"reason" validate:"required,min=1,max=2000"`
+	IssuedBy  *uuid.UUID `json:"issuedBy"`
+	CreatedAt *time.Time `json:"createdAt"`
+	ExpiresAt *time.Time `json:"expiresAt" validate:"omitnil,gt"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+	RevokedBy *uuid.UUID `json:"revokedBy,omitempty"`
+}
+
+// An appeal by the sanctioned user or a reply by a moderator
+type SanctionNote struct {
+	ID         *int       `json:"id"`
+	SanctionID *int       `json:"sanctionID"`
+	AuthorID   *uuid.UUID `json:"authorID"`
+	Body       *string    `json:"body" validate:"required,min=1,max=5000"`
+	CreatedAt  *time.Time `json:"createdAt"`
+}
@@ -1,12 +1,22 @@
 package psqlCasbinClient
 
 import (
+	"regexp"
+
 	"github.com/casbin/casbin/v2"
 	pgxadapter "github.com/pckhoi/casbin-pgx-adapter/v3"
 )
 
 type CasbinConfig struct{}
 
+// Role policies name their objects by route patterns such as demos/:id/fork, and roles inherit the policies
+// of the roles they are grouped with. Policies of single users name the objects they own and are matched
+// as they are by the model
+const roleMatcher = `g(r.sub, p.sub) && keyMatch2(r.obj, p.obj) && r.act == p.act || r.sub == "admin"`
+
+// Objects of the policies owners of demos get to edit them
+var demoObject = regexp.MustCompile(`^demos/[0-9]+$`)
+
 type CasbinClient struct {
 	enforcer *casbin.Enforcer
 }
@@ -19,23 +29,92 @@ func (c CasbinConfig) NewCasbinClient(connstring, modelPath string) (*CasbinClie
 		return nil, err
 	}
 
-	_, err = ce.AddPolicies([][]string{
+	// Rules that are already stored are skipped, so databases seeded by earlier versions get the new ones
+	_, err = ce.AddPoliciesEx([][]string{
 		{"admin"},
 		{"freetier", "assets", "POST"},
 		{"freetier", "demos", "POST"},
 		{"freetier", "threads", "POST"},
 		{"freetier", "messages", "POST"},
+		{"freetier", "demos/:id/fork", "POST"},
+		{"freetier", "demos/:id/restore", "POST"},
+		{"freetier", "threads/:id/restore", "POST"},
+		{"freetier", "threads/:id/operations", "POST"}, // Limited to topics the user owns
+		{"freetier", "messages/:id/restore", "POST"},
+		{"freetier", "trash", "GET"},
+		{"freetier", "reports", "POST"},
+		{"freetier", "notifications", "GET"},
+		{"freetier", "notifications/unread", "GET"},
+		{"freetier", "notifications/read", "POST"},
+		{"freetier", "notifications/:id/read", "POST"},
+		{"freetier", "notifications/preferences", "GET"},
+		{"freetier", "notifications/preferences", "PATCH"},
+		{"freetier", "email/preferences", "GET"},
+		{"freetier", "email/preferences", "PATCH"},
+		{"freetier", "subscriptions", "GET"},
+		{"freetier", "subscriptions/feed", "GET"},
+		{"freetier", "subscriptions/:type/:target", "POST"},
+		{"freetier", "subscriptions/:type/:target", "DELETE"},
+		{"moderator", "reports", "GET"},
+		{"moderator", "reports/:id", "GET"},
+		{"moderator", "reports/:id/dismiss", "POST"},
+		{"moderator", "moderation/actions", "POST"},
+		{"moderator", "moderation/actions", "GET"},
+		{"moderator", "topics/:id/moderation/actions", "POST"},
+		{"moderator", "topics/:id/moderation/reports", "GET"},
+		{"moderator", "users/:id/sanctions", "GET"},
+		{"moderator", "users/:id/sanctions", "POST"},
+		{"moderator", "sanctions/:id/revoke", "POST"},
+		{"moderator", "sanctions/:id/notes", "GET"},
+		{"moderator", "sanctions/:id/notes", "POST"},
 		{"paidtier", "demos", "POSTExtended"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Paid tier users and moderators may do all that free tier users may
+	_, err = ce.AddGroupingPoliciesEx([][]string{
 		{"paidtier", "freetier"},
+		{"moderator", "freetier"},
 	})
 	if err != nil {
 		return nil, err
 	}
+	// Stored as a policy by earlier versions, where it granted nothing
+	_, err = ce.RemovePolicy("paidtier", "freetier")
+	if err != nil {
+		return nil, err
+	}
+	err = grantDemoAssets(ce)
+	if err != nil {
+		return nil, err
+	}
 	ce.LoadPolicy()
 
 	return &CasbinClient{enforcer: ce}, nil
 }
 
+// Owners of demos stored before the assets of a demo could be replaced only have the policies to edit
+// and delete them, so they get the one to replace their assets too
+func grantDemoAssets(ce *casbin.Enforcer) error {
+	var rules [][]string
+
+	policies, err := ce.GetFilteredPolicy(2, "PATCH")
+	if err != nil {
+		return err
+	}
+	for _, p := range policies {
+		if demoObject.MatchString(p[1]) {
+			rules = append(rules, []string{p[0], p[1] + "/assets", "PUT"})
+		}
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+	_, err = ce.AddPoliciesEx(rules)
+	return err
+}
+
 func (c *CasbinClient) AddPermissions(params ...any) (bool, error) {
 	return c.enforcer.AddPolicy(params...)
 }
@@ -51,3 +130,8 @@ func (c *CasbinClient) RemovePermissionsForObject(obj, act string) (bool, error)
 func (c *CasbinClient) Enforce(sub, obj, act string) (bool, error) {
 	return c.enforcer.Enforce(sub, obj, act)
 }
+
+// Enforces the policies of the role, whose objects may be route patterns
+func (c *CasbinClient) EnforceRole(role, obj, act string) (bool, error) {
+	return c.enforcer.EnforceWithMatcher(roleMatcher, role, obj, act)
+}
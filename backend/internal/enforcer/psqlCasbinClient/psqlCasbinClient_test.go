@@ -86,3 +86,74 @@ func TestRemovePermissionsForObject(t *testing.T) {
 	}
 	_, err = testCasbinClient.RemovePermissionsForObject(object, roleExtented[0][2])
 }
+
+func TestEnforcePatterns(t *testing.T) {
+	// Route patterns only match for roles
+	pass, err := testCasbinClient.EnforceRole("freetier", "demos/5/fork", "POST")
+	assert.NoError(t, err)
+	assert.True(t, pass)
+	pass, err = testCasbinClient.EnforceRole("freetier", "demos/5/restore/fork", "POST")
+	assert.NoError(t, err)
+	assert.False(t, pass)
+
+	// Policies of single objects stay exact even when their objects look like patterns
+	owned := []string{"Chief", "demos/:id", "PATCH"}
+	_, err = testCasbinClient.AddPermissions(owned)
+	assert.NoError(t, err)
+	defer testCasbinClient.RemovePermissions(owned)
+	pass, err = testCasbinClient.Enforce(owned[0], "demos/5", owned[2])
+	assert.NoError(t, err)
+	assert.False(t, pass)
+	pass, err = testCasbinClient.Enforce(owned[0], owned[1], owned[2])
+	assert.NoError(t, err)
+	assert.True(t, pass)
+}
+
+func TestEnforceInheritedRole(t *testing.T) {
+	// Moderators inherit the policies of free tier users
+	pass, err := testCasbinClient.EnforceRole("moderator", "demos/5/fork", "POST")
+	assert.NoError(t, err)
+	assert.True(t, pass)
+	pass, err = testCasbinClient.EnforceRole("moderator", "reports/5/dismiss", "POST")
+	assert.NoError(t, err)
+	assert.True(t, pass)
+	pass, err = testCasbinClient.EnforceRole("freetier", "reports/5/dismiss", "POST")
+	assert.NoError(t, err)
+	assert.False(t, pass)
+
+	// Paid tier users too, on top of their own
+	pass, err = testCasbinClient.EnforceRole("paidtier", "reports", "POST")
+	assert.NoError(t, err)
+	assert.True(t, pass)
+	pass, err = testCasbinClient.EnforceRole("paidtier", "demos", "POSTExtended")
+	assert.NoError(t, err)
+	assert.True(t, pass)
+	pass, err = testCasbinClient.EnforceRole("freetier", "demos", "POSTExtended")
+	assert.NoError(t, err)
+	assert.False(t, pass)
+}
+
+func TestNewCasbinClientSeeded(t *testing.T) {
+	// A database seeded before a rule was added still gets it, though it has all the others
+	missing := []string{"freetier", "trash", "GET"}
+	_, err := testCasbinClient.RemovePermissions(missing)
+	assert.NoError(t, err)
+	// Owners of demos stored before their assets could be replaced get to replace them
+	owned := []string{"Chief", "demos/7", "PATCH"}
+	_, err = testCasbinClient.AddPermissions(owned)
+	assert.NoError(t, err)
+	defer testCasbinClient.RemovePermissions(owned)
+
+	wd, _ := os.Getwd()
+	seeded, err := CasbinConfig{}.NewCasbinClient(os.Getenv("PSQL_CONNSTRING"), wd+"/rbac_model.conf")
+	if assert.NoError(t, err) {
+		pass, err := seeded.EnforceRole(missing[0], missing[1], missing[2])
+		assert.NoError(t, err)
+		assert.True(t, pass)
+		pass, err = seeded.Enforce(owned[0], "demos/7/assets", "PUT")
+		assert.NoError(t, err)
+		assert.True(t, pass)
+		_, err = seeded.RemovePermissions(owned[0], "demos/7/assets", "PUT")
+		assert.NoError(t, err)
+	}
+}
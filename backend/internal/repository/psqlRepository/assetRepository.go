@@ -1,7 +1,10 @@
 package psqlRepository
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
@@ -9,7 +12,8 @@ import (
 
 	"gamehangar/internal/domain/models"
 
-	"github.com/jackc/pgx/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type PsqlAssetRepository struct {
@@ -29,6 +33,9 @@ func NewPsqlAssetRepository(dbClient psqlDatabaseClient, o ObjectUploader) *Psql
 
 func (r *PsqlAssetRepository) NotFoundErr() error { return r.databaseClient.ErrNoRows() }
 
+// Returns "Cursor is not valid!" when the page cursor is malformed or made for another order
+func (r *PsqlAssetRepository) CursorErr() error { return cursorErr }
+
 // Returns "Record conflict!" to specify conflicting record versions on update
 func (r *PsqlAssetRepository) ConflictErr() error { return r.conflictErr }
 
@@ -39,21 +46,36 @@ func (r *PsqlAssetRepository) CreateAsset(asset models.Asset, assetFile, assetTh
 	}
 	defer conn.Release()
 
-	err = conn.QueryRow(context.Background(),
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(context.Background())
+
+	err = tx.QueryRow(context.Background(),
 		`INSERT INTO asset.assets
-		(name, description, tags) 
+		(name, description, tags, category_id, godot_version, support_level, license, file_size) 
 		VALUES
-		($1, $2, $3)
+		($1, $2, $3, $4, $5, COALESCE($6, 'community'), COALESCE($7, 'MIT'), $8)
 		RETURNING
-		(id, name, description, tags, created_at, updated_at, version, upvotes, downvotes, rating, views, object_key, thumbnail_key)`,
-		asset.Name, asset.Description, asset.Tags,
+		(id, name, description, tags, created_at, updated_at, version, upvotes, downvotes, rating, views, object_key, thumbnail_key, usage_count, category_id, godot_version, support_level, license, download_hash, hidden_at, file_size)`,
+		asset.Name, asset.Description, asset.Tags, asset.CategoryID, asset.GodotVersion, asset.SupportLevel, asset.License, asset.FileSize,
 	).Scan(&asset)
 	if err != nil {
 		return nil, err
 	}
+	change := auditChange{action: "create", table: "asset.assets", targetType: "assets", targetID: *asset.ID}
+	err = change.record(tx)
+	if err != nil {
+		return nil, err
+	}
+	err = tx.Commit(context.Background())
+	if err != nil {
+		return nil, err
+	}
 
 	if assetFile != nil {
-		err = r.objectUploader.PutObject(*asset.Key, assetFile)
+		asset.DownloadHash, err = r.putHashedObject(conn, *asset.ID, *asset.Key, assetFile)
 		if err != nil {
 			return nil, err
 		}
@@ -72,7 +94,17 @@ func (r *PsqlAssetRepository) CreateAsset(asset models.Asset, assetFile, assetTh
 	return &asset, nil
 }
 
+// Finds the asset and counts a view of it
 func (r *PsqlAssetRepository) FindAssetByID(id int) (*models.Asset, error) {
+	return r.findAsset(id, true)
+}
+
+// Finds the asset without counting a view, for reads that are not someone viewing it
+func (r *PsqlAssetRepository) PeekAssetByID(id int) (*models.Asset, error) {
+	return r.findAsset(id, false)
+}
+
+func (r *PsqlAssetRepository) findAsset(id int, countView bool) (*models.Asset, error) {
 	var asset models.Asset
 	conn, err := r.databaseClient.AcquireConn()
 	if err != nil {
@@ -80,14 +112,16 @@ func (r *PsqlAssetRepository) FindAssetByID(id int) (*models.Asset, error) {
 	}
 	defer conn.Release()
 
-	err = conn.QueryRow(context.Background(),
-		`UPDATE asset.assets SET 
+	columns := `(id, name, description, tags, created_at, updated_at, version, upvotes, downvotes, rating, views, object_key, thumbnail_key, usage_count, category_id, godot_version, support_level, license, download_hash, hidden_at, file_size)`
+	query := `SELECT ` + columns + ` FROM asset.assets WHERE id = $1 AND deleted_at IS NULL`
+	if countView {
+		query = `UPDATE asset.assets SET 
 		views=views+1
-		WHERE id = $1
+		WHERE id = $1 AND deleted_at IS NULL
 		RETURNING
-		(id, name, description, tags, created_at, updated_at, version, upvotes, downvotes, rating, views, object_key, thumbnail_key)`,
-		id,
-	).Scan(&asset)
+		` + columns
+	}
+	err = conn.QueryRow(context.Background(), query, id).Scan(&asset)
 	if err != nil {
 		return nil, err
 	}
@@ -104,8 +138,12 @@ func (r *PsqlAssetRepository) FindAssetByID(id int) (*models.Asset, error) {
 	return &asset, nil
 }
 
-func (r *PsqlAssetRepository) FindAssets(keywords []string, limit uint64, order string) (*[]models.Asset, error) {
-	var assets []models.Asset
+// Returns the page of visible assets passing the filter and matching the keywords by text, word prefix, tag or similar name, all of them without keywords
+func (r *PsqlAssetRepository) FindAssets(keywords []string, filter models.ListFilter, page models.PageQuery, order string) (*models.Page[models.Asset], error) {
+	key, ok := assetSortKeys[order]
+	if !ok {
+		order, key = "newest-updated", assetSortKeys["newest-updated"]
+	}
 
 	conn, err := r.databaseClient.AcquireConn()
 	if err != nil {
@@ -113,81 +151,35 @@ func (r *PsqlAssetRepository) FindAssets(keywords []string, limit uint64, order
 	}
 	defer conn.Release()
 
-	var rows pgx.Rows
+	q := pageQuery{
+		source: `SELECT * FROM asset.assets WHERE deleted_at IS NULL AND hidden_at IS NULL`,
+		columns: `id, name, description, tags, created_at, updated_at, version, upvotes, downvotes, rating, views,
+			object_key, thumbnail_key, usage_count, category_id, godot_version, support_level, license, download_hash, hidden_at, file_size`,
+		order:  order,
+		key:    key,
+		idCast: "INTEGER",
+	}
 	if len(keywords) != 0 {
-		query :=
-			`SELECT (id, name, description, tags, created_at, updated_at, version, upvotes, downvotes, rating, views, object_key, thumbnail_key) 
-				FROM
-				((SELECT id, name, description, tags, created_at, updated_at, version, upvotes, downvotes, rating, views, object_key, thumbnail_key
-				FROM asset.assets
-				WHERE asset_ts @@ to_tsquery_multilang($1))
-			UNION
-				(SELECT id, name, description, tags, created_at, updated_at, version, upvotes, downvotes, rating, views, object_key, thumbnail_key 
-				FROM asset.assets
-				WHERE tags && ($2) COLLATE case_insensitive))`
-
-		switch order {
-		case "newest-updated":
-			query = query + ` ORDER BY updated_at DESC`
-		case "highest-rated":
-			query = query + ` ORDER BY rating DESC`
-		case "most-views":
-			query = query + ` ORDER BY views DESC`
-		default:
-			query = query + ` ORDER BY updated_at DESC`
-		}
-		if limit != 0 {
-			query = query + fmt.Sprintf(` LIMIT %v`, limit)
-		}
-		rows, err = conn.Query(context.Background(),
-			query, strings.Join(keywords, " | "), keywords,
-		)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		query := `SELECT 
-			(id, name, description, tags, created_at, updated_at, version, upvotes, downvotes, rating, views, object_key, thumbnail_key) 
-			FROM asset.assets`
-
-		switch order {
-		case "newest-updated":
-			query = query + ` ORDER BY updated_at DESC`
-		case "highest-rated":
-			query = query + ` ORDER BY rating DESC`
-		case "most-views":
-			query = query + ` ORDER BY views DESC`
-		default:
-			query = query + ` ORDER BY updated_at DESC`
-		}
-		if limit != 0 {
-			query = query + fmt.Sprintf(` LIMIT %v`, limit)
-		}
-		rows, err = conn.Query(context.Background(), query)
-		if err != nil {
-			return nil, err
-		}
+		q.args = []any{strings.Join(keywords, " | "), keywords}
 	}
-	defer rows.Close()
-	for rows.Next() {
-		var asset models.Asset
-		err = rows.Scan(&asset)
-		if err != nil {
-			return nil, err
-		}
-		asset.Key, _ = r.objectUploader.GetObjectLink(*asset.Key)
-		asset.ThumbnailKey, _ = r.objectUploader.GetObjectLink(*asset.ThumbnailKey)
-		assets = append(assets, asset)
+	q.filter(filter)
+	// Keywords go last, so that the fuzzy fallback counts the matches the filters leave
+	if len(keywords) != 0 {
+		q.source = keywordMatch(q.source, `asset_ts`, `name`)
 	}
-	err = rows.Err()
+
+	assets, err := queryPage[models.Asset](conn, q, page)
 	if err != nil {
 		return nil, err
 	}
-	if len(assets) == 0 {
+	if len(assets.Items) == 0 {
 		return nil, r.NotFoundErr()
 	}
-
-	return &assets, nil
+	for i := range assets.Items {
+		assets.Items[i].Key, _ = r.objectUploader.GetObjectLink(*assets.Items[i].Key)
+		assets.Items[i].ThumbnailKey, _ = r.objectUploader.GetObjectLink(*assets.Items[i].ThumbnailKey)
+	}
+	return assets, nil
 }
 
 func (r *PsqlAssetRepository) UpdateAsset(id int, asset models.Asset, assetFile, assetThumbnail io.Reader) (*models.Asset, error) {
@@ -202,21 +194,43 @@ func (r *PsqlAssetRepository) UpdateAsset(id int, asset models.Asset, assetFile,
 		return nil, r.ConflictErr()
 	}
 
-	err = conn.QueryRow(context.Background(),
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(context.Background())
+
+	change := auditChange{action: "update", table: "asset.assets", targetType: "assets", targetID: id}
+	err = change.snapshot(tx)
+	if err != nil {
+		return nil, err
+	}
+	err = tx.QueryRow(context.Background(),
 		`UPDATE asset.assets SET 
-		name=COALESCE($1, name), description=COALESCE($2, description), tags=COALESCE($3, tags), updated_at=NOW(), upvotes=COALESCE($4, upvotes), downvotes=COALESCE($5, downvotes)
-			WHERE id = $6
+		name=COALESCE($1, name), description=COALESCE($2, description), tags=COALESCE($3, tags), updated_at=NOW(), upvotes=COALESCE($4, upvotes), downvotes=COALESCE($5, downvotes),
+		category_id=COALESCE($6, category_id), godot_version=COALESCE($7, godot_version), support_level=COALESCE($8, support_level), license=COALESCE($9, license),
+		file_size=COALESCE($11, file_size)
+			WHERE id = $10 AND deleted_at IS NULL
 		RETURNING
-			(id, name, description, tags, created_at, updated_at, version, upvotes, downvotes, rating, views, object_key, thumbnail_key)`,
+			(id, name, description, tags, created_at, updated_at, version, upvotes, downvotes, rating, views, object_key, thumbnail_key, usage_count, category_id, godot_version, support_level, license, download_hash, hidden_at, file_size)`,
 		asset.Name, asset.Description, asset.Tags, asset.Upvotes, asset.Downvotes,
-		id,
+		asset.CategoryID, asset.GodotVersion, asset.SupportLevel, asset.License,
+		id, asset.FileSize,
 	).Scan(&asset)
 	if err != nil {
 		return nil, err
 	}
+	err = change.record(tx)
+	if err != nil {
+		return nil, err
+	}
+	err = tx.Commit(context.Background())
+	if err != nil {
+		return nil, err
+	}
 
 	if assetFile != nil {
-		err = r.objectUploader.PutObject(*asset.Key, assetFile)
+		asset.DownloadHash, err = r.putHashedObject(conn, *asset.ID, *asset.Key, assetFile)
 		if err != nil {
 			return nil, err
 		}
@@ -235,28 +249,266 @@ func (r *PsqlAssetRepository) UpdateAsset(id int, asset models.Asset, assetFile,
 	return &asset, nil
 }
 
-func (r *PsqlAssetRepository) DeleteAsset(id int) error {
+// Moves the asset to the trash. Its objects are removed when the trash is purged
+func (r *PsqlAssetRepository) DeleteAsset(id int, deletedBy uuid.UUID) error {
 	conn, err := r.databaseClient.AcquireConn()
 	if err != nil {
 		return err
 	}
 	defer conn.Release()
 
-	err = r.objectUploader.DeleteObject(fmt.Sprintf("asset-%v", id))
+	change := auditChange{actorID: &deletedBy, action: "delete", table: "asset.assets", targetType: "assets", targetID: id}
+	ct, err := change.exec(conn,
+		`UPDATE asset.assets SET deleted_at=NOW(), deleted_by=$2 WHERE id=$1 AND deleted_at IS NULL`,
+		id, deletedBy,
+	)
 	if err != nil {
 		return err
 	}
-	err = r.objectUploader.DeleteObject(fmt.Sprintf("asset-thumbnail-%v", id))
+	if ct.RowsAffected() == 0 {
+		return r.databaseClient.ErrNoRows()
+	}
+	return nil
+}
+
+// Restores the asset from the trash. A nil restoredBy skips the check of who deleted it
+func (r *PsqlAssetRepository) RestoreAsset(id int, restoredBy *uuid.UUID) error {
+	conn, err := r.databaseClient.AcquireConn()
 	if err != nil {
 		return err
 	}
+	defer conn.Release()
 
-	ct, err := conn.Exec(context.Background(), `DELETE FROM asset.assets WHERE id=$1`, id)
+	change := auditChange{actorID: restoredBy, action: "restore", table: "asset.assets", targetType: "assets", targetID: id}
+	ct, err := change.exec(conn,
+		`UPDATE asset.assets SET deleted_at=NULL, deleted_by=NULL
+		WHERE id=$1 AND deleted_at IS NOT NULL AND ($2::UUID IS NULL OR deleted_by=$2)`,
+		id, restoredBy,
+	)
+	if err != nil {
+		return err
+	}
 	if ct.RowsAffected() == 0 {
-		if err != nil {
-			return err
-		}
 		return r.databaseClient.ErrNoRows()
 	}
 	return nil
 }
+
+// Returns demos that declare the asset of ID
+func (r *PsqlAssetRepository) FindAssetUsages(assetID int) (*[]models.Demo, error) {
+	var demos []models.Demo
+
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(context.Background(),
+		`SELECT (d.id, d.title, d.description, d.tags, d.user_id, d.thread_id, d.created_at, d.updated_at,
+			d.upvotes, d.downvotes, d.rating, d.views, d.object_key, d.thumbnail_key)
+		FROM demo.demo_assets da
+		JOIN demo.demos d ON d.id = da.demo_id
+		WHERE da.asset_id = $1 AND d.deleted_at IS NULL AND d.hidden_at IS NULL
+		ORDER BY d.updated_at DESC`,
+		assetID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var demo models.Demo
+		err = rows.Scan(&demo)
+		if err != nil {
+			return nil, err
+		}
+		demo.Key, _ = r.objectUploader.GetObjectLink(*demo.Key)
+		demo.ThumbnailKey, _ = r.objectUploader.GetObjectLink(*demo.ThumbnailKey)
+		demos = append(demos, demo)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+	if len(demos) == 0 {
+		return nil, r.NotFoundErr()
+	}
+	return &demos, nil
+}
+
+// Uploads the asset project file and stores its SHA-256 for AssetLib clients
+func (r *PsqlAssetRepository) putHashedObject(conn *pgxpool.Conn, id int, key string, file io.Reader) (*string, error) {
+	hash := sha256.New()
+	if rs, ok := file.(io.ReadSeeker); ok {
+		_, err := io.Copy(hash, rs)
+		if err != nil {
+			return nil, err
+		}
+		_, err = rs.Seek(0, io.SeekStart)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		var buf bytes.Buffer
+		_, err := io.Copy(&buf, io.TeeReader(file, hash))
+		if err != nil {
+			return nil, err
+		}
+		file = &buf
+	}
+
+	err := r.objectUploader.PutObject(key, file)
+	if err != nil {
+		return nil, err
+	}
+
+	downloadHash := hex.EncodeToString(hash.Sum(nil))
+	_, err = conn.Exec(context.Background(),
+		`UPDATE asset.assets SET download_hash=$1 WHERE id=$2`, downloadHash, id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &downloadHash, nil
+}
+
+func (r *PsqlAssetRepository) FindAssetCategories() (*[]models.AssetCategory, error) {
+	var categories []models.AssetCategory
+
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(context.Background(),
+		`SELECT (id, name, type) FROM asset.categories ORDER BY id`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var category models.AssetCategory
+		err = rows.Scan(&category)
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+	if len(categories) == 0 {
+		return nil, r.NotFoundErr()
+	}
+	return &categories, nil
+}
+
+// Returns the condition matching assets whose column holds a version compatible with the version in param:
+// made for an older minor version of the same major version. Malformed stored versions never match
+func godotCompatible(column, param string) string {
+	return fmt.Sprintf(`CASE WHEN %[1]v ~ '^\d+(\.\d+)*$' THEN
+			split_part(%[1]v, '.', 1) = split_part(%[2]v, '.', 1)
+			AND string_to_array(%[1]v, '.')::INTEGER[] <= string_to_array(%[2]v, '.')::INTEGER[]
+		END`, column, param)
+}
+
+// Returns a page of assets filtered the way the Godot AssetLib API does,
+// along with the total number of matching assets. Pages past the end have no assets
+func (r *PsqlAssetRepository) FindAssetLibAssets(q models.AssetLibQuery) (*[]models.Asset, uint64, error) {
+	var (
+		assets []models.Asset
+		total  uint64
+		where  = []string{`a.deleted_at IS NULL`, `a.hidden_at IS NULL`}
+		args   []any
+	)
+
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return nil, 0, err
+	}
+	defer conn.Release()
+
+	switch q.Type {
+	case "addon":
+		where = append(where, `c.type = 0`)
+	case "project":
+		where = append(where, `c.type = 1`)
+	}
+	if q.CategoryID != 0 {
+		args = append(args, q.CategoryID)
+		where = append(where, fmt.Sprintf(`a.category_id = $%v`, len(args)))
+	}
+	if len(q.SupportLevel) != 0 {
+		args = append(args, q.SupportLevel)
+		where = append(where, fmt.Sprintf(`a.support_level = ANY($%v)`, len(args)))
+	}
+	if q.Filter != "" {
+		args = append(args, "%"+q.Filter+"%")
+		where = append(where, fmt.Sprintf(`a.name ILIKE $%v`, len(args)))
+	}
+	if q.GodotVersion != "" {
+		args = append(args, q.GodotVersion)
+		where = append(where, godotCompatible(`a.godot_version`, fmt.Sprintf(`$%v`, len(args))))
+	}
+
+	source := `FROM asset.assets a
+		LEFT JOIN asset.categories c ON c.id = a.category_id
+		WHERE ` + strings.Join(where, ` AND `)
+
+	// Counted apart from the page, so pages past the end still tell how many assets there are
+	err = conn.QueryRow(context.Background(), `SELECT COUNT(*) `+source, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+	if total == 0 {
+		return nil, 0, r.NotFoundErr()
+	}
+
+	query := `SELECT (a.id, a.name, a.description, a.tags, a.created_at, a.updated_at, a.version, a.upvotes, a.downvotes, a.rating, a.views,
+			a.object_key, a.thumbnail_key, a.usage_count, a.category_id, a.godot_version, a.support_level, a.license, a.download_hash)
+		` + source
+
+	switch q.Sort {
+	case "rating":
+		query = query + ` ORDER BY a.rating`
+	case "cost":
+		query = query + ` ORDER BY a.license`
+	case "name":
+		query = query + ` ORDER BY a.name`
+	default:
+		query = query + ` ORDER BY a.updated_at`
+	}
+	// Matches AssetLib behaviour: names sort ascending, everything else descending
+	if (q.Sort == "name" || q.Sort == "cost") == q.Reverse {
+		query = query + ` DESC`
+	} else {
+		query = query + ` ASC`
+	}
+	query = query + fmt.Sprintf(` LIMIT %v OFFSET %v`, q.Limit, q.Offset)
+
+	rows, err := conn.Query(context.Background(), query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var asset models.Asset
+		err = rows.Scan(&asset)
+		if err != nil {
+			return nil, 0, err
+		}
+		asset.Key, _ = r.objectUploader.GetObjectLink(*asset.Key)
+		asset.ThumbnailKey, _ = r.objectUploader.GetObjectLink(*asset.ThumbnailKey)
+		assets = append(assets, asset)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return &assets, total, nil
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"gamehangar/internal/config/psqlDatabseConfig"
 	"gamehangar/internal/database/psqlDatabase"
+	"gamehangar/internal/database/psqlDatabase/psqlTestDatabase"
 	"gamehangar/internal/domain/models"
 	"gamehangar/internal/enforcer/psqlCasbinClient"
 	"gamehangar/pkg/ternMigrate"
@@ -24,7 +25,8 @@ func (s *MockS3) GetObjectLink(objectKey string) (*string, error) {
 	l := "https://example.com"
 	return &l, nil
 }
-func (s *MockS3) DeleteObject(objectKey string) error { return nil }
+func (s *MockS3) DeleteObject(objectKey string) error    { return nil }
+func (s *MockS3) CopyObject(srcKey, dstKey string) error { return nil }
 
 var (
 	independent  bool = false
@@ -61,178 +63,9 @@ func ResetDB() {
 		panic("Error setting up new DatabaseClient")
 	}
 	c, _ := testDBClient.AcquireConn() // WARNING! Integration tests DROP TABLEs
-	_, err = c.Exec(context.Background(), `
-		DROP TRIGGER IF EXISTS increment_asset_version_on_update ON asset.assets; 
-		DROP TRIGGER IF EXISTS increment_topic_version_on_update ON forum.topics; 
-		DROP TRIGGER IF EXISTS increment_role_version_on_update ON "user".roles; 
-
-		DROP COLLATION IF EXISTS case_insensitive;
-
-		DROP INDEX IF EXISTS demo_gin_index_ts;
-		DROP INDEX IF EXISTS asset_gin_index_ts;
-		DROP INDEX IF EXISTS thread_gin_index_ts;
-		DROP INDEX IF EXISTS message_gin_index_ts;
-
-		DROP SCHEMA IF EXISTS "asset" CASCADE;
-		DROP SCHEMA IF EXISTS "demo" CASCADE;
-		DROP SCHEMA IF EXISTS "user" CASCADE;
-		DROP SCHEMA IF EXISTS "forum" CASCADE;
-
-		CREATE SCHEMA IF NOT EXISTS demo;
-		CREATE SCHEMA IF NOT EXISTS forum;
-		CREATE SCHEMA IF NOT EXISTS "user";
-		CREATE SCHEMA IF NOT EXISTS asset;
-
-		CREATE TABLE "user".users (
-		"id" UUID DEFAULT gen_random_uuid() PRIMARY KEY,
-		"username" VARCHAR(255) NOT NULL UNIQUE,
-		"display_name" VARCHAR(255),
-		"email" VARCHAR(255) NOT NULL UNIQUE,
-		"password" VARCHAR(255) NOT NULL,
-		"verified" BOOLEAN NOT NULL DEFAULT false,
-		"role" VARCHAR(255) NOT NULL,
-		"created_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
-		"karma" INTEGER NOT NULL DEFAULT 0
-		);
-
-		CREATE TABLE "user".sessions (
-		"id" UUID DEFAULT gen_random_uuid() PRIMARY KEY,
-		"user_id" UUID NOT NULL REFERENCES "user".users (id) ON DELETE CASCADE
-		);
-
-		CREATE TABLE forum.topics (
-		"id"  INTEGER PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
-		"name" VARCHAR(255) NOT NULL,
-		"version" INTEGER NOT NULL DEFAULT 1
-		);
-
-		CREATE TABLE forum.threads (
-		"id" INTEGER PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
-		"title" VARCHAR(255) NOT NULL,
-		"user_id" UUID NOT NULL,
-		"topic_id" INTEGER NOT NULL REFERENCES forum.topics (id) ON DELETE CASCADE,
-		"tags" VARCHAR(255)[],
-		"created_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
-		"updated_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
-		"upvotes" INTEGER NOT NULL DEFAULT 1,
-		"downvotes" INTEGER NOT NULL DEFAULT 1,
-		"rating" DECIMAL GENERATED ALWAYS AS (upvotes::DECIMAL / downvotes) STORED,
-		"views" INTEGER NOT NULL DEFAULT 0
-		);
-
-		CREATE TABLE forum.messages (
-		"id" INTEGER PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
-		"thread_id" INTEGER NOT NULL REFERENCES forum.threads (id) ON DELETE CASCADE,
-		"user_id" UUID NOT NULL,
-		"title" VARCHAR(255) NOT NULL,
-		"body" VARCHAR NOT NULL,
-		"tags" VARCHAR(255)[],
-		"created_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
-		"updated_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
-		"upvotes" INTEGER NOT NULL DEFAULT 1,
-		"downvotes" INTEGER NOT NULL DEFAULT 1,
-		"rating" DECIMAL GENERATED ALWAYS AS (upvotes::DECIMAL / downvotes) STORED,
-		"views" INTEGER NOT NULL DEFAULT 0
-		);
-
-		CREATE TABLE asset.assets (
-		"id" INTEGER PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
-		"name" TEXT NOT NULL,
-		"description" TEXT,
-		"tags" TEXT[],
-		"version" INTEGER NOT NULL DEFAULT 1,
-		"created_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
-		"updated_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
-		"upvotes" INTEGER NOT NULL DEFAULT 1,
-		"downvotes" INTEGER NOT NULL DEFAULT 1,		
-		"rating" DECIMAL GENERATED ALWAYS AS (upvotes::DECIMAL / downvotes) STORED,
-		"views" INTEGER NOT NULL DEFAULT 0,
-		"object_key" VARCHAR(255) GENERATED ALWAYS AS ('asset-' || asset.assets.id) STORED,
-		"thumbnail_key" VARCHAR(255) GENERATED ALWAYS AS ('asset-thumbnail-' || asset.assets.id) STORED
-		);
-
-		CREATE TABLE demo.demos (
-		"id"  INTEGER PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
-		"title" TEXT NOT NULL,
-		"description" TEXT,
-		"tags" TEXT[],
-		"user_id" UUID NOT NULL,
-		"created_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
-		"updated_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
-		"upvotes" INTEGER NOT NULL DEFAULT 1,
-		"downvotes" INTEGER NOT NULL DEFAULT 1,
-		"rating" DECIMAL GENERATED ALWAYS AS (upvotes::DECIMAL / downvotes) STORED,
-		"views" INTEGER NOT NULL DEFAULT 0,
-		"thread_id" INTEGER NOT NULL REFERENCES forum.threads (id) ON DELETE CASCADE,
-		"object_key" VARCHAR(255) GENERATED ALWAYS AS ('demo-' || demo.demos.id) STORED,
-		"thumbnail_key" VARCHAR(255) GENERATED ALWAYS AS ('demo-thumbnail-' || demo.demos.id) STORED
-		);
-
-		CREATE OR REPLACE FUNCTION increment_version()
-		RETURNS TRIGGER AS
-		$func$
-		BEGIN
-		NEW.version := OLD.version + 1;
-		RETURN NEW;
-		END;
-		$func$ LANGUAGE plpgsql;
-
-		CREATE TRIGGER increment_asset_version_on_update
-		BEFORE UPDATE ON asset.assets
-		FOR EACH ROW
-		WHEN ((OLD.name IS DISTINCT FROM NEW.name) 
-		OR (OLD.description IS DISTINCT FROM NEW.description)
-		OR (OLD.tags IS DISTINCT FROM NEW.tags))
-		EXECUTE FUNCTION increment_version();
-
-		CREATE TRIGGER increment_topic_version_on_update
-		BEFORE UPDATE ON forum.topics
-		FOR EACH ROW
-		EXECUTE FUNCTION increment_version();
-
-		CREATE COLLATION IF NOT EXISTS case_insensitive (provider = icu, locale = 'und-u-ks-level2', deterministic = false);
-
-		CREATE OR REPLACE FUNCTION to_tsvector_multilang(text) RETURNS tsvector AS $$
-		BEGIN
-		RETURN 
-		to_tsvector('english', $1) || 
-		to_tsvector('russian', $1);
-		END;
-		$$ LANGUAGE plpgsql IMMUTABLE;
-
-		CREATE OR REPLACE FUNCTION to_tsquery_multilang(text) RETURNS tsquery AS $$
-		BEGIN
-		RETURN
-		websearch_to_tsquery('english', $1) || 
-		websearch_to_tsquery('russian', $1);
-		END;
-		$$ LANGUAGE plpgsql IMMUTABLE;
-
-		ALTER TABLE asset.assets ADD COLUMN asset_ts tsvector GENERATED ALWAYS AS (
-		setweight(to_tsvector_multilang("name"), 'A') ||
-		setweight(to_tsvector_multilang(COALESCE("description", '')), 'B')
-		) STORED;
-		CREATE INDEX asset_gin_index_ts ON asset.assets USING GIN (asset_ts);
-
-		ALTER TABLE demo.demos ADD COLUMN demo_ts tsvector GENERATED ALWAYS AS (
-		setweight(to_tsvector_multilang("title"), 'A') ||
-		setweight(to_tsvector_multilang(COALESCE("description", '')), 'B')
-		) STORED;
-		CREATE INDEX demo_gin_index_ts ON demo.demos USING GIN (demo_ts);
-
-		ALTER TABLE forum.threads ADD COLUMN thread_ts tsvector GENERATED ALWAYS AS (
-		setweight(to_tsvector_multilang("title"), 'A')
-		) STORED;
-		CREATE INDEX thread_gin_index_ts ON forum.threads USING GIN (thread_ts);
-
-		ALTER TABLE forum.messages ADD COLUMN message_ts tsvector GENERATED ALWAYS AS (
-		setweight(to_tsvector_multilang("title"), 'A') ||
-		setweight(to_tsvector_multilang(COALESCE("body", '')), 'B')
-		) STORED;
-		CREATE INDEX message_gin_index_ts ON forum.messages USING GIN (message_ts);
-		`)
+	err = psqlTestDatabase.Reset(c.Conn(), databaseConfig)
 	if err != nil {
-		panic("Error resetting assets schema" + err.Error())
+		panic("Error resetting test database: " + err.Error())
 	}
 	err = c.QueryRow(
 		context.Background(),
@@ -290,6 +123,16 @@ func TestFindAssetByID(t *testing.T) {
 	}
 }
 
+func TestPeekAssetByID(t *testing.T) {
+	r := PsqlAssetRepository{databaseClient: testDBClient, conflictErr: errors.New("Record conflict!"), objectUploader: testS3Client}
+	asset, err := r.PeekAssetByID(assetID)
+	if assert.NoError(t, err) { // Views stay as FindAssetByID left them
+		assert.Equal(t, uint(1), *asset.Views)
+	}
+	_, err = r.PeekAssetByID(39)
+	assert.Equal(t, r.NotFoundErr(), err)
+}
+
 func TestFindAssetByIDNoRows(t *testing.T) {
 	r := PsqlAssetRepository{databaseClient: testDBClient, conflictErr: errors.New("Record conflict!"), objectUploader: testS3Client}
 	_, err := r.FindAssetByID(39)
@@ -300,7 +143,7 @@ func TestFindAssetByIDNoRows(t *testing.T) {
 
 func TestFindAssets(t *testing.T) {
 	r := PsqlAssetRepository{databaseClient: testDBClient, conflictErr: errors.New("Record conflict!"), objectUploader: testS3Client}
-	_, err := r.FindAssets(nil, 0, "")
+	_, err := r.FindAssets(nil, models.ListFilter{}, models.PageQuery{}, "")
 	assert.NoError(t, err)
 
 }
@@ -323,18 +166,18 @@ func TestFindAssetsByQuery(t *testing.T) {
 		resultAsset, err := r.CreateAsset(d, nil, nil)
 		assert.NoError(t, err)
 
-		queryAssets, err := r.FindAssets([]string{q}, 0, "")
+		queryAssets, err := r.FindAssets([]string{q}, models.ListFilter{}, models.PageQuery{}, "")
 		if assert.NoError(t, err) {
-			queriedAsset := *queryAssets
+			queriedAsset := queryAssets.Items
 			assert.Equal(t, resultAsset.Name, queriedAsset[0].Name)
 			assert.Equal(t, resultAsset.Description, queriedAsset[0].Description)
 		}
 	}
 
 	// Try to query both and check ordering
-	assets, err := r.FindAssets([]string{"cheeseboiger"}, 0, "newest-updated")
+	assets, err := r.FindAssets([]string{"cheeseboiger"}, models.ListFilter{}, models.PageQuery{}, "newest-updated")
 	if assert.NoError(t, err) {
-		a := *assets
+		a := assets.Items
 		assert.Len(t, a, 2)
 		var timeOrder, timeOrderExpected []time.Time
 		timeOrderExpected = []time.Time{*a[0].UpdatedAt, *a[1].UpdatedAt}
@@ -348,9 +191,9 @@ func TestFindAssetsByQuery(t *testing.T) {
 		)
 	}
 	// Query with limit
-	assets, err = r.FindAssets([]string{"cheeseboiger"}, 1, "")
+	assets, err = r.FindAssets([]string{"cheeseboiger"}, models.ListFilter{}, models.PageQuery{Limit: 1}, "")
 	if assert.NoError(t, err) {
-		assert.Len(t, *assets, 1)
+		assert.Len(t, assets.Items, 1)
 	}
 }
 
@@ -413,21 +256,70 @@ func TestUpdateAssetConflict(t *testing.T) {
 	}
 }
 
+func TestFindAssetLibAssets(t *testing.T) {
+	r := PsqlAssetRepository{databaseClient: testDBClient, conflictErr: errors.New("Record conflict!"), objectUploader: testS3Client}
+	categoryID, godotVersion := 1, "4.1"
+	current, err := r.FindAssetByID(assetID)
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = r.UpdateAsset(assetID, models.Asset{CategoryID: &categoryID, GodotVersion: &godotVersion, Version: current.Version}, nil, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	categories, err := r.FindAssetCategories()
+	if assert.NoError(t, err) {
+		assert.Len(t, *categories, 2)
+	}
+
+	// Versions stored before they were validated match nothing instead of failing the listing
+	malformedName, malformedVersion := "Malformed Version Asset", "4.x"
+	malformed, err := r.CreateAsset(models.Asset{Name: &malformedName, CategoryID: &categoryID, GodotVersion: &malformedVersion}, nil, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer r.DeleteAsset(*malformed.ID, userID)
+
+	assets, total, err := r.FindAssetLibAssets(models.AssetLibQuery{Type: "addon", GodotVersion: "4.3", Limit: 10})
+	if assert.NoError(t, err) {
+		assert.Equal(t, uint64(1), total)
+		assert.Equal(t, assetID, *(*assets)[0].ID)
+	}
+	// Pages past the end are empty but still count the matches
+	assets, total, err = r.FindAssetLibAssets(models.AssetLibQuery{Type: "addon", GodotVersion: "4.3", Limit: 10, Offset: 10})
+	if assert.NoError(t, err) {
+		assert.Equal(t, uint64(1), total)
+		assert.Empty(t, *assets)
+	}
+
+	listingVersion := "4.3"
+	listed, err := r.FindAssets(nil, models.ListFilter{GodotVersion: &listingVersion}, models.PageQuery{}, "")
+	if assert.NoError(t, err) {
+		assert.Len(t, listed.Items, 1)
+	}
+
+	_, _, err = r.FindAssetLibAssets(models.AssetLibQuery{GodotVersion: "3.5", Limit: 10})
+	assert.Equal(t, r.NotFoundErr(), err)
+	_, _, err = r.FindAssetLibAssets(models.AssetLibQuery{Type: "project", Limit: 10})
+	assert.Equal(t, r.NotFoundErr(), err)
+}
+
 func TestDeleteAsset(t *testing.T) {
 	r := PsqlAssetRepository{databaseClient: testDBClient, conflictErr: errors.New("Record conflict!"), objectUploader: testS3Client}
-	err := r.DeleteAsset(assetID)
+	err := r.DeleteAsset(assetID, userID)
 	if assert.NoError(t, err) {
 		teardownAsset(&r)
 	}
 }
 
 func teardownAsset(r *PsqlAssetRepository) {
-	remainderAssets, err := r.FindAssets(nil, 0, "")
+	remainderAssets, err := r.FindAssets(nil, models.ListFilter{}, models.PageQuery{}, "")
 	if err != nil {
 		panic(err)
 	}
-	for _, a := range *remainderAssets {
-		err = r.DeleteAsset(*a.ID)
+	for _, a := range remainderAssets.Items {
+		err = r.DeleteAsset(*a.ID, userID)
 		if err != nil {
 			panic(err)
 		}
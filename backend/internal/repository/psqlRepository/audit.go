@@ -0,0 +1,87 @@
+package psqlRepository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Columns left out of audit summaries: secrets, search vectors and rendered HTML
+var auditOmitted = []string{"password", "demo_ts", "asset_ts", "thread_ts", "message_ts", "user_ts", "body_html", "description_html"}
+
+// A change of a single row, recorded in the audit log by the transaction making it, so that every
+// committed change has its event. The summaries before and after it are the row as JSON.
+// Events of changes carry no IP or status, those come with the event of the request
+type auditChange struct {
+	actorID    *uuid.UUID
+	action     string // e.g. "create", "delete", "purge"
+	table      string // e.g. demo.demos
+	targetType string // e.g. demos
+	targetID   any    // Value of the id column of the row
+	before     *string
+}
+
+// Keeps the row as it is before the change
+func (a *auditChange) snapshot(tx pgx.Tx) error {
+	var err error
+	a.before, err = auditSummary(tx, a.table, a.targetID)
+	return err
+}
+
+// Appends the change to the audit log, with the row as it is after it
+func (a *auditChange) record(tx pgx.Tx) error {
+	after, err := auditSummary(tx, a.table, a.targetID)
+	if err != nil {
+		return err
+	}
+	return recordAuditEvent(tx, a.actorID, a.action, a.targetType, fmt.Sprint(a.targetID), a.before, after)
+}
+
+// Runs a statement changing the row in a transaction recording it. A statement affecting no rows is not recorded
+func (a *auditChange) exec(conn *pgxpool.Conn, query string, args ...any) (pgconn.CommandTag, error) {
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		return pgconn.CommandTag{}, err
+	}
+	defer tx.Rollback(context.Background())
+
+	err = a.snapshot(tx)
+	if err != nil {
+		return pgconn.CommandTag{}, err
+	}
+	ct, err := tx.Exec(context.Background(), query, args...)
+	if err != nil || ct.RowsAffected() == 0 {
+		return ct, err
+	}
+	err = a.record(tx)
+	if err != nil {
+		return ct, err
+	}
+	return ct, tx.Commit(context.Background())
+}
+
+// Returns the row as JSON, nil when there is no such row
+func auditSummary(tx pgx.Tx, table string, id any) (*string, error) {
+	var summary *string
+	err := tx.QueryRow(context.Background(),
+		`SELECT (to_jsonb(t) - $2::TEXT[])::TEXT FROM `+table+` t WHERE id = $1`,
+		id, auditOmitted,
+	).Scan(&summary)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	return summary, err
+}
+
+func recordAuditEvent(tx pgx.Tx, actorID *uuid.UUID, action, targetType, targetID string, before, after *string) error {
+	_, err := tx.Exec(context.Background(),
+		`INSERT INTO audit.events (actor_id, action, target_type, target_id, before, after)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		actorID, action, targetType, targetID, before, after,
+	)
+	return err
+}
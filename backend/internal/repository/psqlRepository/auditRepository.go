@@ -0,0 +1,146 @@
+package psqlRepository
+
+import (
+	"context"
+	"fmt"
+	"gamehangar/internal/domain/models"
+	"strings"
+)
+
+type PsqlAuditRepository struct {
+	databaseClient psqlDatabaseClient
+}
+
+// Requires PsqlDatabaseClient since it implements PostgeSQL-specific query logic
+func NewPsqlAuditRepository(dbClient psqlDatabaseClient) *PsqlAuditRepository {
+	return &PsqlAuditRepository{
+		databaseClient: dbClient,
+	}
+}
+
+func (r *PsqlAuditRepository) NotFoundErr() error { return r.databaseClient.ErrNoRows() }
+
+// Returns "Cursor is not valid!"
+func (r *PsqlAuditRepository) CursorErr() error { return cursorErr }
+
+// Appends an event to the audit log. Events can never be updated or deleted
+func (r *PsqlAuditRepository) CreateAuditEvent(event models.AuditEvent) error {
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	_, err = conn.Exec(context.Background(),
+		`INSERT INTO audit.events (actor_id, action, target_type, target_id, ip, status, before, after)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		event.ActorID, event.Action, event.TargetType, event.TargetID, event.IP, event.Status, event.Before, event.After,
+	)
+	return err
+}
+
+// Returns a page of the audit events matching the filter, newest first
+func (r *PsqlAuditRepository) FindAuditEvents(f models.AuditFilter, page models.PageQuery) (*models.Page[models.AuditEvent], error) {
+	var (
+		where = []string{`TRUE`}
+		args  []any
+	)
+
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	if f.ActorID != nil {
+		args = append(args, *f.ActorID)
+		where = append(where, fmt.Sprintf(`actor_id = $%v`, len(args)))
+	}
+	if f.TargetType != nil {
+		args = append(args, *f.TargetType)
+		where = append(where, fmt.Sprintf(`target_type = $%v`, len(args)))
+	}
+	if f.From != nil {
+		args = append(args, *f.From)
+		where = append(where, fmt.Sprintf(`created_at >= $%v`, len(args)))
+	}
+	if f.To != nil {
+		args = append(args, *f.To)
+		where = append(where, fmt.Sprintf(`created_at < $%v`, len(args)))
+	}
+
+	events, err := queryPage[models.AuditEvent](conn, pageQuery{
+		source:  `SELECT * FROM audit.events WHERE ` + strings.Join(where, ` AND `),
+		args:    args,
+		columns: `id, actor_id, action, target_type, target_id, ip, status, before, after, created_at`,
+		order:   auditOrder,
+		key:     auditSortKey,
+		idCast:  "BIGINT",
+	}, page)
+	if err != nil {
+		return nil, err
+	}
+	if len(events.Items) == 0 {
+		return nil, r.NotFoundErr()
+	}
+	return events, nil
+}
+
+// Enforcer that records the policy changes made through it in the audit log.
+// Policies live outside the database transactions of the repositories, so they are recorded on their own
+type AuditedEnforcer struct {
+	Enforcer
+	repository *PsqlAuditRepository
+}
+
+func NewAuditedEnforcer(e Enforcer, r *PsqlAuditRepository) *AuditedEnforcer {
+	return &AuditedEnforcer{
+		Enforcer:   e,
+		repository: r,
+	}
+}
+
+func (e *AuditedEnforcer) AddPermissions(params ...any) (bool, error) {
+	added, err := e.Enforcer.AddPermissions(params...)
+	if err != nil || !added {
+		return added, err
+	}
+	return added, e.record("grant", fmt.Sprint(params[0]), nil, policySummary(params...))
+}
+
+func (e *AuditedEnforcer) RemovePermissions(params ...any) (bool, error) {
+	removed, err := e.Enforcer.RemovePermissions(params...)
+	if err != nil || !removed {
+		return removed, err
+	}
+	return removed, e.record("revoke", fmt.Sprint(params[0]), policySummary(params...), nil)
+}
+
+func (e *AuditedEnforcer) RemovePermissionsForObject(obj, act string) (bool, error) {
+	removed, err := e.Enforcer.RemovePermissionsForObject(obj, act)
+	if err != nil || !removed {
+		return removed, err
+	}
+	return removed, e.record("revoke", obj, policySummary("*", obj, act), nil)
+}
+
+// Policies are targeted by their subject, a user or a role, or by their object when removed for all subjects
+func (e *AuditedEnforcer) record(action, targetID string, before, after *string) error {
+	targetType := "policies"
+	return e.repository.CreateAuditEvent(models.AuditEvent{
+		Action:     &action,
+		TargetType: &targetType,
+		TargetID:   &targetID,
+		Before:     before,
+		After:      after,
+	})
+}
+
+func policySummary(params ...any) *string {
+	summary := make([]string, len(params))
+	for i, p := range params {
+		summary[i] = fmt.Sprint(p)
+	}
+	s := strings.Join(summary, ", ")
+	return &s
+}
@@ -0,0 +1,105 @@
+package psqlRepository
+
+import (
+	"context"
+	"gamehangar/internal/domain/models"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	auditActorID    = uuid.New()
+	auditAction     = "DELETE threads/:id"
+	auditTargetType = "threads"
+	auditTargetID   = "1"
+	auditIP         = "192.0.2.1"
+	auditStatus     = 200
+	auditAfter      = "Thread successfully deleted!"
+)
+
+func TestCreateAuditEvent(t *testing.T) {
+	r := NewPsqlAuditRepository(testDBClient)
+	err := r.CreateAuditEvent(models.AuditEvent{
+		ActorID: &auditActorID, Action: &auditAction, TargetType: &auditTargetType,
+		TargetID: &auditTargetID, IP: &auditIP, Status: &auditStatus, After: &auditAfter,
+	})
+	assert.NoError(t, err)
+}
+
+func TestFindAuditEvents(t *testing.T) {
+	r := NewPsqlAuditRepository(testDBClient)
+	from := time.Now().Add(-time.Hour)
+	filter := models.AuditFilter{ActorID: &auditActorID, TargetType: &auditTargetType, From: &from}
+	events, err := r.FindAuditEvents(filter, models.PageQuery{})
+	if assert.NoError(t, err) {
+		assert.Len(t, events.Items, 1)
+		assert.Equal(t, auditAction, *events.Items[0].Action)
+		assert.Equal(t, auditAfter, *events.Items[0].After)
+	}
+
+	to := time.Now().Add(-time.Hour)
+	_, err = r.FindAuditEvents(models.AuditFilter{ActorID: &auditActorID, To: &to}, models.PageQuery{})
+	assert.Equal(t, r.NotFoundErr(), err)
+	_, err = r.FindAuditEvents(filter, models.PageQuery{Limit: 1, Cursor: "stale"})
+	assert.Equal(t, r.CursorErr(), err)
+}
+
+func TestAuditEventsAppendOnly(t *testing.T) {
+	c, _ := testDBClient.AcquireConn()
+	defer c.Release()
+	_, err := c.Exec(context.Background(), `UPDATE audit.events SET status = 500`)
+	assert.Error(t, err)
+	_, err = c.Exec(context.Background(), `DELETE FROM audit.events`)
+	assert.Error(t, err)
+}
+
+func TestAuditedChanges(t *testing.T) {
+	r := NewPsqlAuditRepository(testDBClient)
+	targetType := "assets"
+	events, err := r.FindAuditEvents(models.AuditFilter{ActorID: &userID, TargetType: &targetType}, models.PageQuery{})
+	if assert.NoError(t, err) {
+		i := slices.IndexFunc(events.Items, func(e models.AuditEvent) bool { return *e.TargetID == strconv.Itoa(assetID) })
+		if !assert.NotEqual(t, -1, i) {
+			return
+		}
+		deleted := events.Items[i]
+		assert.Equal(t, "delete", *deleted.Action)
+		assert.Nil(t, deleted.IP)
+		assert.Nil(t, deleted.Status)
+		assert.Contains(t, *deleted.Before, `"deleted_at": null`)
+		assert.NotContains(t, *deleted.After, `"deleted_at": null`)
+		assert.NotContains(t, *deleted.After, `"asset_ts"`)
+	}
+}
+
+type mockPolicyEnforcer struct{}
+
+func (e mockPolicyEnforcer) AddPermissions(...any) (bool, error)    { return true, nil }
+func (e mockPolicyEnforcer) RemovePermissions(...any) (bool, error) { return false, nil }
+func (e mockPolicyEnforcer) RemovePermissionsForObject(obj, act string) (bool, error) {
+	return true, nil
+}
+
+func TestAuditedEnforcer(t *testing.T) {
+	r := NewPsqlAuditRepository(testDBClient)
+	e := NewAuditedEnforcer(mockPolicyEnforcer{}, r)
+	subject := uuid.New()
+	_, err := e.AddPermissions(subject.String(), "demos/1", "PATCH")
+	assert.NoError(t, err)
+	_, err = e.RemovePermissions(subject.String(), "demos/1", "DELETE") // Not removed, so not recorded
+	assert.NoError(t, err)
+
+	targetType := "policies"
+	events, err := r.FindAuditEvents(models.AuditFilter{TargetType: &targetType}, models.PageQuery{Limit: 1})
+	if assert.NoError(t, err) {
+		granted := events.Items[0]
+		assert.Equal(t, "grant", *granted.Action)
+		assert.Equal(t, subject.String(), *granted.TargetID)
+		assert.Equal(t, subject.String()+", demos/1, PATCH", *granted.After)
+	}
+}
@@ -9,26 +9,35 @@ import (
 
 	"gamehangar/internal/domain/models"
 
-	"github.com/jackc/pgx/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 type PsqlDemoRepository struct {
-	databaseClient psqlDatabaseClient
-	objectUploader ObjectUploader
-	enforcer       Enforcer
+	databaseClient    psqlDatabaseClient
+	objectUploader    ObjectUploader
+	enforcer          Enforcer
+	remixForbiddenErr error
 }
 
 // Requires PsqlDatabaseClient since it implements PostgeSQL-specific query logic
 func NewPsqlDemoRepository(dbClient psqlDatabaseClient, o ObjectUploader, e Enforcer) *PsqlDemoRepository {
 	return &PsqlDemoRepository{
-		databaseClient: dbClient,
-		objectUploader: o,
-		enforcer:       e,
+		databaseClient:    dbClient,
+		objectUploader:    o,
+		enforcer:          e,
+		remixForbiddenErr: errors.New("Remixing is not allowed for this demo!"),
 	}
 }
 
 func (r *PsqlDemoRepository) NotFoundErr() error { return r.databaseClient.ErrNoRows() }
 
+// Returns "Cursor is not valid!" when the page cursor is malformed or made for another order
+func (r *PsqlDemoRepository) CursorErr() error { return cursorErr }
+
+// Returns "Remixing is not allowed for this demo!" when forking a demo with remixing disabled
+func (r *PsqlDemoRepository) RemixForbiddenErr() error { return r.remixForbiddenErr }
+
 func (r *PsqlDemoRepository) CreateDemo(demo models.Demo, demoFile, demoThumbnail io.Reader) (*models.Demo, error) {
 	conn, err := r.databaseClient.AcquireConn()
 	if err != nil {
@@ -36,18 +45,33 @@ func (r *PsqlDemoRepository) CreateDemo(demo models.Demo, demoFile, demoThumbnai
 	}
 	defer conn.Release()
 
-	err = conn.QueryRow(context.Background(),
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(context.Background())
+
+	err = tx.QueryRow(context.Background(),
 		`INSERT INTO demo.demos
-		(title, description, tags, user_id, thread_id) 
+		(title, description, tags, user_id, thread_id, allow_remix, description_html, file_size) 
 		VALUES
-		($1, $2, $3, $4, $5)
+		($1, $2, $3, $4, $5, COALESCE($6, true), $7, $8)
 		RETURNING
-		(id, title, description, tags, user_id, thread_id, created_at, updated_at, upvotes, downvotes, rating, views, object_key, thumbnail_key)`,
-		demo.Title, demo.Description, demo.Tags, demo.UserID, demo.ThreadID,
+		(id, title, description, tags, user_id, thread_id, created_at, updated_at, upvotes, downvotes, rating, views, object_key, thumbnail_key, forked_from, allow_remix, hidden_at, description_html, edited_at, edited_by, revisions, file_size)`,
+		demo.Title, demo.Description, demo.Tags, demo.UserID, demo.ThreadID, demo.AllowRemix, demo.DescriptionHTML, demo.FileSize,
 	).Scan(&demo)
 	if err != nil {
 		return nil, err
 	}
+	change := auditChange{actorID: demo.UserID, action: "create", table: "demo.demos", targetType: "demos", targetID: *demo.ID}
+	err = change.record(tx)
+	if err != nil {
+		return nil, err
+	}
+	err = tx.Commit(context.Background())
+	if err != nil {
+		return nil, err
+	}
 	_, err = r.enforcer.AddPermissions(demo.UserID.String(), fmt.Sprintf("demos/%v", *demo.ID), "PATCH")
 	if err != nil {
 		return nil, err
@@ -56,6 +80,10 @@ func (r *PsqlDemoRepository) CreateDemo(demo models.Demo, demoFile, demoThumbnai
 	if err != nil {
 		return nil, err
 	}
+	_, err = r.enforcer.AddPermissions(demo.UserID.String(), fmt.Sprintf("demos/%v/assets", *demo.ID), "PUT")
+	if err != nil {
+		return nil, err
+	}
 
 	if demoFile != nil {
 		err = r.objectUploader.PutObject(*demo.Key, demoFile)
@@ -76,7 +104,17 @@ func (r *PsqlDemoRepository) CreateDemo(demo models.Demo, demoFile, demoThumbnai
 	return &demo, nil
 }
 
+// Finds the demo and counts a view of it
 func (r *PsqlDemoRepository) FindDemoByID(id int) (*models.Demo, error) {
+	return r.findDemo(id, true)
+}
+
+// Finds the demo without counting a view, for reads that are not someone viewing it
+func (r *PsqlDemoRepository) PeekDemoByID(id int) (*models.Demo, error) {
+	return r.findDemo(id, false)
+}
+
+func (r *PsqlDemoRepository) findDemo(id int, countView bool) (*models.Demo, error) {
 	var demo models.Demo
 	conn, err := r.databaseClient.AcquireConn()
 	if err != nil {
@@ -84,13 +122,15 @@ func (r *PsqlDemoRepository) FindDemoByID(id int) (*models.Demo, error) {
 	}
 	defer conn.Release()
 
-	err = conn.QueryRow(context.Background(),
-		`UPDATE demo.demos SET views=views+1
-		WHERE id = $1
+	columns := `(id, title, description, tags, user_id, thread_id, created_at, updated_at, upvotes, downvotes, rating, views, object_key, thumbnail_key, forked_from, allow_remix, hidden_at, description_html, edited_at, edited_by, revisions, file_size)`
+	query := `SELECT ` + columns + ` FROM demo.demos WHERE id = $1 AND deleted_at IS NULL`
+	if countView {
+		query = `UPDATE demo.demos SET views=views+1
+		WHERE id = $1 AND deleted_at IS NULL
 		RETURNING
-		(id, title, description, tags, user_id, thread_id, created_at, updated_at, upvotes, downvotes, rating, views, object_key, thumbnail_key)`,
-		id,
-	).Scan(&demo)
+		` + columns
+	}
+	err = conn.QueryRow(context.Background(), query, id).Scan(&demo)
 	if err != nil {
 		return nil, err
 	}
@@ -107,8 +147,12 @@ func (r *PsqlDemoRepository) FindDemoByID(id int) (*models.Demo, error) {
 	return &demo, nil
 }
 
-func (r *PsqlDemoRepository) FindDemos(keywords []string, limit uint64, order string) (*[]models.Demo, error) {
-	var demos []models.Demo
+// Returns the page of visible demos passing the filter and matching the keywords by text, word prefix, tag or similar title, all of them without keywords
+func (r *PsqlDemoRepository) FindDemos(keywords []string, filter models.ListFilter, page models.PageQuery, order string) (*models.Page[models.Demo], error) {
+	key, ok := contentSortKeys[order]
+	if !ok {
+		order, key = "newest-updated", contentSortKeys["newest-updated"]
+	}
 
 	conn, err := r.databaseClient.AcquireConn()
 	if err != nil {
@@ -116,81 +160,35 @@ func (r *PsqlDemoRepository) FindDemos(keywords []string, limit uint64, order st
 	}
 	defer conn.Release()
 
-	var rows pgx.Rows
+	q := pageQuery{
+		source: `SELECT * FROM demo.demos WHERE deleted_at IS NULL AND hidden_at IS NULL`,
+		columns: `id, title, description, tags, user_id, thread_id, created_at, updated_at, upvotes, downvotes, rating, views,
+			object_key, thumbnail_key, forked_from, allow_remix, hidden_at, description_html, edited_at, edited_by, revisions, file_size`,
+		order:  order,
+		key:    key,
+		idCast: "INTEGER",
+	}
 	if len(keywords) != 0 {
-		query := `SELECT (id, title, description, tags, user_id,
-			thread_id, created_at, updated_at, upvotes, downvotes, rating, views, object_key, thumbnail_key)
-			FROM 
-			((SELECT id, title, description, tags, user_id,
-				thread_id, created_at, updated_at, upvotes, downvotes, rating, views, object_key, thumbnail_key
-			FROM demo.demos
-			WHERE demo_ts @@ to_tsquery_multilang($1))
-			UNION
-			(SELECT id, title, description, tags, user_id,
-				thread_id, created_at, updated_at, upvotes, downvotes, rating, views, object_key, thumbnail_key
-			FROM demo.demos
-			WHERE tags && ($2) COLLATE case_insensitive))`
-
-		switch order {
-		case "newest-updated":
-			query = query + ` ORDER BY updated_at DESC`
-		case "highest-rated":
-			query = query + ` ORDER BY rating DESC`
-		case "most-views":
-			query = query + ` ORDER BY views DESC`
-		default:
-			query = query + ` ORDER BY updated_at DESC`
-		}
-		if limit != 0 {
-			query = query + fmt.Sprintf(` LIMIT %v`, limit)
-		}
-		rows, err = conn.Query(context.Background(),
-			query, strings.Join(keywords, " | "), keywords,
-		)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		query := `SELECT (id, title, description, tags, user_id, thread_id, created_at, updated_at, upvotes, downvotes, rating, views, object_key, thumbnail_key) 
-		FROM demo.demos`
-
-		switch order {
-		case "newest-updated":
-			query = query + ` ORDER BY updated_at DESC`
-		case "highest-rated":
-			query = query + ` ORDER BY upvotes DESC`
-		case "most-views":
-			query = query + ` ORDER BY views DESC`
-		default:
-			query = query + ` ORDER BY updated_at DESC`
-		}
-		if limit != 0 {
-			query = query + fmt.Sprintf(` LIMIT %v`, limit)
-		}
-		rows, err = conn.Query(context.Background(), query)
-		if err != nil {
-			return nil, err
-		}
+		q.args = []any{strings.Join(keywords, " | "), keywords}
 	}
-	defer rows.Close()
-	for rows.Next() {
-		var demo models.Demo
-		err = rows.Scan(&demo)
-		if err != nil {
-			return nil, err
-		}
-		demo.Key, _ = r.objectUploader.GetObjectLink(*demo.Key)
-		demo.ThumbnailKey, _ = r.objectUploader.GetObjectLink(*demo.ThumbnailKey)
-		demos = append(demos, demo)
+	q.filter(filter)
+	// Keywords go last, so that the fuzzy fallback counts the matches the filters leave
+	if len(keywords) != 0 {
+		q.source = keywordMatch(q.source, `demo_ts`, `title`)
 	}
-	err = rows.Err()
+
+	demos, err := queryPage[models.Demo](conn, q, page)
 	if err != nil {
 		return nil, err
 	}
-	if len(demos) == 0 {
+	if len(demos.Items) == 0 {
 		return nil, r.NotFoundErr()
 	}
-	return &demos, nil
+	for i := range demos.Items {
+		demos.Items[i].Key, _ = r.objectUploader.GetObjectLink(*demos.Items[i].Key)
+		demos.Items[i].ThumbnailKey, _ = r.objectUploader.GetObjectLink(*demos.Items[i].ThumbnailKey)
+	}
+	return demos, nil
 }
 
 func (r *PsqlDemoRepository) UpdateDemo(id int, demo models.Demo, demoFile, demoThumbnail io.Reader) (*models.Demo, error) {
@@ -200,21 +198,42 @@ func (r *PsqlDemoRepository) UpdateDemo(id int, demo models.Demo, demoFile, demo
 	}
 	defer conn.Release()
 
-	err = conn.QueryRow(context.Background(),
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(context.Background())
+
+	change := auditChange{actorID: demo.EditedBy, action: "update", table: "demo.demos", targetType: "demos", targetID: id}
+	err = change.snapshot(tx)
+	if err != nil {
+		return nil, err
+	}
+	err = tx.QueryRow(context.Background(),
 		`UPDATE demo.demos SET 
 			title=COALESCE($1, title), description=COALESCE($2, description),
 		tags=COALESCE($3, tags), user_id=COALESCE($4, user_id),
 			thread_id=COALESCE($5, thread_id), updated_at=NOW(),
-		upvotes=COALESCE($6, upvotes), downvotes=COALESCE($7, downvotes)
-			WHERE id = $8
+		upvotes=COALESCE($6, upvotes), downvotes=COALESCE($7, downvotes),
+			allow_remix=COALESCE($8, allow_remix), description_html=COALESCE($10, description_html),
+			edited_by=$11, file_size=COALESCE($12, file_size)
+			WHERE id = $9 AND deleted_at IS NULL
 		RETURNING
-			(id, title, description, tags, user_id, thread_id, created_at, updated_at, upvotes, downvotes, rating, views, object_key, thumbnail_key)`,
+			(id, title, description, tags, user_id, thread_id, created_at, updated_at, upvotes, downvotes, rating, views, object_key, thumbnail_key, forked_from, allow_remix, hidden_at, description_html, edited_at, edited_by, revisions, file_size)`,
 		demo.Title, demo.Description, demo.Tags, demo.UserID, demo.ThreadID,
-		demo.Upvotes, demo.Downvotes, id,
+		demo.Upvotes, demo.Downvotes, demo.AllowRemix, id, demo.DescriptionHTML, demo.EditedBy, demo.FileSize,
 	).Scan(&demo)
 	if err != nil {
 		return nil, err
 	}
+	err = change.record(tx)
+	if err != nil {
+		return nil, err
+	}
+	err = tx.Commit(context.Background())
+	if err != nil {
+		return nil, err
+	}
 
 	if demoFile != nil {
 		err = r.objectUploader.PutObject(*demo.Key, demoFile)
@@ -235,36 +254,339 @@ func (r *PsqlDemoRepository) UpdateDemo(id int, demo models.Demo, demoFile, demo
 	return &demo, err
 }
 
-func (r *PsqlDemoRepository) DeleteDemo(id int) error {
+// Moves the demo to the trash. Its objects and policies are removed when the trash is purged
+func (r *PsqlDemoRepository) DeleteDemo(id int, deletedBy uuid.UUID) error {
 	conn, err := r.databaseClient.AcquireConn()
 	if err != nil {
 		return err
 	}
 	defer conn.Release()
 
-	err = r.objectUploader.DeleteObject(fmt.Sprintf("demo-%v", id))
+	change := auditChange{actorID: &deletedBy, action: "delete", table: "demo.demos", targetType: "demos", targetID: id}
+	ct, err := change.exec(conn,
+		`UPDATE demo.demos SET deleted_at=NOW(), deleted_by=$2 WHERE id=$1 AND deleted_at IS NULL`,
+		id, deletedBy,
+	)
 	if err != nil {
 		return err
 	}
-	err = r.objectUploader.DeleteObject(fmt.Sprintf("demo-thumbnail-%v", id))
+	if ct.RowsAffected() == 0 {
+		return r.databaseClient.ErrNoRows()
+	}
+	return nil
+}
+
+// Restores the demo from the trash. A nil restoredBy skips the check of who deleted it
+func (r *PsqlDemoRepository) RestoreDemo(id int, restoredBy *uuid.UUID) error {
+	conn, err := r.databaseClient.AcquireConn()
 	if err != nil {
 		return err
 	}
+	defer conn.Release()
 
-	ct, err := conn.Exec(context.Background(), `DELETE FROM demo.demos WHERE id=$1`, id)
+	change := auditChange{actorID: restoredBy, action: "restore", table: "demo.demos", targetType: "demos", targetID: id}
+	ct, err := change.exec(conn,
+		`UPDATE demo.demos SET deleted_at=NULL, deleted_by=NULL
+		WHERE id=$1 AND deleted_at IS NOT NULL AND ($2::UUID IS NULL OR deleted_by=$2)`,
+		id, restoredBy,
+	)
+	if err != nil {
+		return err
+	}
 	if ct.RowsAffected() == 0 {
+		return r.databaseClient.ErrNoRows()
+	}
+	return nil
+}
+
+func (r *PsqlDemoRepository) FindDemoAssets(demoID int) (*[]models.DemoAsset, error) {
+	var demoAssets []models.DemoAsset
+
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(context.Background(),
+		`SELECT da.demo_id, da.asset_id, da.asset_version,
+			(a.id, a.name, a.description, a.tags, a.created_at, a.updated_at, a.version,
+			a.upvotes, a.downvotes, a.rating, a.views, a.object_key, a.thumbnail_key, a.usage_count,
+			a.category_id, a.godot_version, a.support_level, a.license, a.download_hash)
+		FROM demo.demo_assets da
+		JOIN asset.assets a ON a.id = da.asset_id
+		WHERE da.demo_id = $1 AND a.deleted_at IS NULL AND a.hidden_at IS NULL
+		ORDER BY da.created_at`,
+		demoID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			demoAsset models.DemoAsset
+			asset     models.Asset
+		)
+		err = rows.Scan(&demoAsset.DemoID, &demoAsset.AssetID, &demoAsset.AssetVersion, &asset)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		return r.databaseClient.ErrNoRows()
+		asset.Key, _ = r.objectUploader.GetObjectLink(*asset.Key)
+		asset.ThumbnailKey, _ = r.objectUploader.GetObjectLink(*asset.ThumbnailKey)
+		demoAsset.Asset = &asset
+		demoAssets = append(demoAssets, demoAsset)
 	}
-	changed, err := r.enforcer.RemovePermissionsForObject(fmt.Sprintf("demos/%v", id), "PATCH")
-	if err != nil || !changed {
-		return errors.New("Somehow did not changed anything WTF?")
+	err = rows.Err()
+	if err != nil {
+		return nil, err
 	}
-	changed, err = r.enforcer.RemovePermissionsForObject(fmt.Sprintf("demos/%v", id), "DELETE")
-	if err != nil || !changed {
-		return err
+	if len(demoAssets) == 0 {
+		return nil, r.NotFoundErr()
 	}
-	return nil
+	return &demoAssets, nil
+}
+
+// Replaces the full set of assets declared by the demo. Returns NotFoundErr when the demo
+// or one of the assets does not exist
+func (r *PsqlDemoRepository) UpdateDemoAssets(demoID int, demoAssets []models.DemoAsset) (*[]models.DemoAsset, error) {
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(context.Background())
+
+	err = tx.QueryRow(context.Background(), `SELECT id FROM demo.demos WHERE id = $1 AND deleted_at IS NULL`, demoID).Scan(&demoID)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(context.Background(), `DELETE FROM demo.demo_assets WHERE demo_id = $1`, demoID)
+	if err != nil {
+		return nil, err
+	}
+	for _, da := range demoAssets {
+		_, err = tx.Exec(context.Background(),
+			`INSERT INTO demo.demo_assets
+			(demo_id, asset_id, asset_version)
+			VALUES
+			($1, $2, $3)
+			ON CONFLICT (demo_id, asset_id) DO UPDATE SET asset_version = EXCLUDED.asset_version`,
+			demoID, da.AssetID, da.AssetVersion,
+		)
+		if err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23503" { // No such asset
+				return nil, r.NotFoundErr()
+			}
+			return nil, err
+		}
+	}
+	err = tx.Commit(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	if len(demoAssets) == 0 {
+		return &[]models.DemoAsset{}, nil
+	}
+	return r.FindDemoAssets(demoID)
+}
+
+// Copies the demo of ID into a new demo owned by fork.UserID and linked to fork.ThreadID.
+// The project and thumbnail objects and the declared assets are copied server-side.
+func (r *PsqlDemoRepository) ForkDemo(id int, fork models.Demo) (*models.Demo, error) {
+	var allowRemix bool
+
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(context.Background())
+
+	err = tx.QueryRow(context.Background(),
+		`SELECT allow_remix FROM demo.demos WHERE id = $1 AND deleted_at IS NULL FOR SHARE`, id,
+	).Scan(&allowRemix)
+	if err != nil {
+		return nil, err
+	}
+	if !allowRemix {
+		return nil, r.RemixForbiddenErr()
+	}
+
+	var src models.Demo
+	err = tx.QueryRow(context.Background(),
+		`SELECT (id, title, description, tags, user_id, thread_id, created_at, updated_at, upvotes, downvotes, rating, views, object_key, thumbnail_key, forked_from, allow_remix, hidden_at, description_html, edited_at, edited_by, revisions, file_size)
+		FROM demo.demos WHERE id = $1`, id,
+	).Scan(&src)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.QueryRow(context.Background(),
+		`INSERT INTO demo.demos
+		(title, description, tags, user_id, thread_id, forked_from, allow_remix, description_html, file_size)
+		SELECT COALESCE($1, title), COALESCE($2, description), COALESCE($3, tags), $4, $5, id, COALESCE($6, true),
+			COALESCE($8, description_html), file_size
+		FROM demo.demos WHERE id = $7
+		RETURNING
+		(id, title, description, tags, user_id, thread_id, created_at, updated_at, upvotes, downvotes, rating, views, object_key, thumbnail_key, forked_from, allow_remix, hidden_at, description_html, edited_at, edited_by, revisions, file_size)`,
+		fork.Title, fork.Description, fork.Tags, fork.UserID, fork.ThreadID, fork.AllowRemix, id, fork.DescriptionHTML,
+	).Scan(&fork)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(context.Background(),
+		`INSERT INTO demo.demo_assets (demo_id, asset_id, asset_version)
+		SELECT $1, asset_id, asset_version FROM demo.demo_assets WHERE demo_id = $2`,
+		*fork.ID, id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	change := auditChange{actorID: fork.UserID, action: "fork", table: "demo.demos", targetType: "demos", targetID: *fork.ID}
+	err = change.record(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Objects are copied before the commit so a failed copy leaves no demo behind, and
+	// deleted again when the copy or commit fails after them
+	var copied []string
+	defer func() {
+		for _, key := range copied {
+			r.objectUploader.DeleteObject(key)
+		}
+	}()
+	for _, key := range [][2]string{{*src.Key, *fork.Key}, {*src.ThumbnailKey, *fork.ThumbnailKey}} {
+		err = r.objectUploader.CopyObject(key[0], key[1])
+		if err != nil {
+			return nil, err
+		}
+		copied = append(copied, key[1])
+	}
+
+	err = tx.Commit(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	copied = nil
+
+	for _, p := range [][]any{
+		{fork.UserID.String(), fmt.Sprintf("demos/%v", *fork.ID), "PATCH"},
+		{fork.UserID.String(), fmt.Sprintf("demos/%v", *fork.ID), "DELETE"},
+		{fork.UserID.String(), fmt.Sprintf("demos/%v/assets", *fork.ID), "PUT"},
+	} {
+		_, err = r.enforcer.AddPermissions(p...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	fork.Key, _ = r.objectUploader.GetObjectLink(*fork.Key)
+	fork.ThumbnailKey, _ = r.objectUploader.GetObjectLink(*fork.ThumbnailKey)
+
+	return &fork, nil
+}
+
+// Returns direct forks of the demo of ID
+func (r *PsqlDemoRepository) FindDemoForks(id int) (*[]models.Demo, error) {
+	var demos []models.Demo
+
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(context.Background(),
+		`SELECT (id, title, description, tags, user_id, thread_id, created_at, updated_at, upvotes, downvotes, rating, views, object_key, thumbnail_key, forked_from, allow_remix, hidden_at, description_html, edited_at, edited_by, revisions, file_size)
+		FROM demo.demos WHERE forked_from = $1 AND deleted_at IS NULL AND hidden_at IS NULL
+		ORDER BY created_at DESC`,
+		id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var demo models.Demo
+		err = rows.Scan(&demo)
+		if err != nil {
+			return nil, err
+		}
+		demo.Key, _ = r.objectUploader.GetObjectLink(*demo.Key)
+		demo.ThumbnailKey, _ = r.objectUploader.GetObjectLink(*demo.ThumbnailKey)
+		demos = append(demos, demo)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+	if len(demos) == 0 {
+		return nil, r.NotFoundErr()
+	}
+	return &demos, nil
+}
+
+// Walks up the fork lineage of the demo of ID. The result starts with the direct parent and ends with the root
+func (r *PsqlDemoRepository) FindDemoAncestry(id int) (*[]models.Demo, error) {
+	var demos []models.Demo
+
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(context.Background(),
+		`WITH RECURSIVE ancestry (id, forked_from, depth) AS (
+			SELECT id, forked_from, 0 FROM demo.demos WHERE id = $1
+		UNION ALL
+			SELECT d.id, d.forked_from, a.depth + 1
+			FROM demo.demos d JOIN ancestry a ON d.id = a.forked_from
+			WHERE a.depth < 100
+		)
+		SELECT (d.id, d.title, d.description, d.tags, d.user_id, d.thread_id, d.created_at, d.updated_at, d.upvotes,
+			d.downvotes, d.rating, d.views, d.object_key, d.thumbnail_key, d.forked_from, d.allow_remix, d.hidden_at, d.description_html, d.edited_at, d.edited_by, d.revisions, d.file_size)
+		FROM ancestry a JOIN demo.demos d ON d.id = a.id
+		WHERE a.depth > 0 AND d.deleted_at IS NULL AND d.hidden_at IS NULL
+		ORDER BY a.depth`,
+		id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var demo models.Demo
+		err = rows.Scan(&demo)
+		if err != nil {
+			return nil, err
+		}
+		demo.Key, _ = r.objectUploader.GetObjectLink(*demo.Key)
+		demo.ThumbnailKey, _ = r.objectUploader.GetObjectLink(*demo.ThumbnailKey)
+		demos = append(demos, demo)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+	if len(demos) == 0 {
+		return nil, r.NotFoundErr()
+	}
+	return &demos, nil
 }
@@ -7,10 +7,11 @@ import (
 	"gamehangar/internal/domain/models"
 	// "gamehangar/pkg/ternMigrate"
 	// "os"
+	"errors"
 	"testing"
 	"time"
 
-	// "github.com/google/uuid"
+	"github.com/google/uuid"
 	// "github.com/joho/godotenv"
 	"github.com/stretchr/testify/assert"
 )
@@ -56,6 +57,16 @@ func TestFindDemoByID(t *testing.T) {
 	}
 }
 
+func TestPeekDemoByID(t *testing.T) {
+	r := PsqlDemoRepository{databaseClient: testDBClient, enforcer: testEnforcer, objectUploader: testS3Client}
+	demo, err := r.PeekDemoByID(demoID)
+	if assert.NoError(t, err) { // Views stay as FindDemoByID left them
+		assert.Equal(t, uint(1), *demo.Views)
+	}
+	_, err = r.PeekDemoByID(9000)
+	assert.Equal(t, r.NotFoundErr(), err)
+}
+
 func TestFindDemoByIDNoRows(t *testing.T) {
 	r := PsqlDemoRepository{databaseClient: testDBClient, enforcer: testEnforcer, objectUploader: testS3Client}
 	_, err := r.FindDemoByID(9000)
@@ -84,9 +95,9 @@ func TestFindDemosByQuery(t *testing.T) {
 		resultDemo, err := r.CreateDemo(d, nil, nil)
 		assert.NoError(t, err)
 
-		queryDemos, err := r.FindDemos([]string{q}, 0, "highest-rated")
+		queryDemos, err := r.FindDemos([]string{q}, models.ListFilter{}, models.PageQuery{}, "highest-rated")
 		if assert.NoError(t, err) {
-			queriedDemo := *queryDemos
+			queriedDemo := queryDemos.Items
 			assert.Equal(t, resultDemo.Title, queriedDemo[0].Title)
 			assert.Equal(t, resultDemo.Description, queriedDemo[0].Description)
 			assert.Equal(t, resultDemo.Tags, queriedDemo[0].Tags)
@@ -94,9 +105,9 @@ func TestFindDemosByQuery(t *testing.T) {
 	}
 
 	// Try to query both and check ordering
-	demos, err := r.FindDemos([]string{"cheeseboiger"}, 0, "newest-updated")
+	demos, err := r.FindDemos([]string{"cheeseboiger"}, models.ListFilter{}, models.PageQuery{}, "newest-updated")
 	if assert.NoError(t, err) {
-		d := *demos
+		d := demos.Items
 		assert.Len(t, d, 2)
 		var timeOrder, timeOrderExpected []time.Time
 		timeOrderExpected = []time.Time{*d[0].UpdatedAt, *d[1].UpdatedAt}
@@ -110,18 +121,117 @@ func TestFindDemosByQuery(t *testing.T) {
 		)
 	}
 	// Query with limit
-	demos, err = r.FindDemos([]string{"cheeseboiger"}, 1, "newest-updated")
+	demos, err = r.FindDemos([]string{"cheeseboiger"}, models.ListFilter{}, models.PageQuery{Limit: 1}, "newest-updated")
+	if assert.NoError(t, err) {
+		assert.Len(t, demos.Items, 1)
+	}
+}
+
+func TestFindDemosFuzzy(t *testing.T) {
+	demoTitleFuzzy := "Hovercraft Platformer"
+	r := PsqlDemoRepository{databaseClient: testDBClient, enforcer: testEnforcer, objectUploader: testS3Client}
+	resultDemo, err := r.CreateDemo(models.Demo{Title: &demoTitleFuzzy, ThreadID: &threadID, UserID: &userID}, nil, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// Misspelled words and the word being typed both match
+	for _, q := range []string{"platfrmer", "hovercrft", "hoverc"} {
+		demos, err := r.FindDemos([]string{q}, models.ListFilter{}, models.PageQuery{}, "newest-updated")
+		if assert.NoError(t, err, q) && assert.Len(t, demos.Items, 1, q) {
+			assert.Equal(t, resultDemo.ID, demos.Items[0].ID)
+		}
+	}
+	_, err = r.FindDemos([]string{"spaceship"}, models.ListFilter{}, models.PageQuery{}, "newest-updated")
+	assert.Equal(t, r.NotFoundErr(), err)
+
+	// Matches outside of the filters do not keep the misspelled title out
+	var created []int
+	sequelTitle, typoTitle, typoTags := "Platformer Sequel", "Platfrmer Typo", []string{"typo"}
+	for range fuzzyFallbackBelow {
+		d, err := r.CreateDemo(models.Demo{Title: &sequelTitle, ThreadID: &threadID, UserID: &userID}, nil, nil)
+		if assert.NoError(t, err) {
+			created = append(created, *d.ID)
+		}
+	}
+	typo, err := r.CreateDemo(models.Demo{Title: &typoTitle, ThreadID: &threadID, UserID: &userID, Tags: &typoTags}, nil, nil)
 	if assert.NoError(t, err) {
-		assert.Len(t, *demos, 1)
+		created = append(created, *typo.ID)
+		demos, err := r.FindDemos([]string{"platformer"}, models.ListFilter{Tags: typoTags}, models.PageQuery{}, "newest-updated")
+		if assert.NoError(t, err) && assert.Len(t, demos.Items, 1) {
+			assert.Equal(t, typo.ID, demos.Items[0].ID)
+		}
+	}
+	for _, id := range created {
+		assert.NoError(t, r.DeleteDemo(id, userID))
 	}
 }
 
 func TestFindDemos(t *testing.T) {
 	r := PsqlDemoRepository{databaseClient: testDBClient, enforcer: testEnforcer, objectUploader: testS3Client}
-	_, err := r.FindDemos(nil, 0, "")
+	_, err := r.FindDemos(nil, models.ListFilter{}, models.PageQuery{}, "")
 	assert.NoError(t, err)
 }
 
+func TestFindDemosFilter(t *testing.T) {
+	var (
+		sizedTitle       = "Sized Demo"
+		sizedFileSize    = int64(2048)
+		minSize, maxSize = int64(1024), int64(4096)
+		sized            = models.Demo{Title: &sizedTitle, ThreadID: &threadID, UserID: &userID, FileSize: &sizedFileSize}
+		future           = time.Now().Add(time.Hour)
+		stranger         = uuid.New()
+	)
+	r := PsqlDemoRepository{databaseClient: testDBClient, enforcer: testEnforcer, objectUploader: testS3Client}
+	_, err := r.CreateDemo(sized, nil, nil)
+	assert.NoError(t, err)
+
+	// Tags match regardless of case, all of them unless any is asked for
+	demos, err := r.FindDemos(nil, models.ListFilter{Tags: []string{"cheeseboiger", "rock the casbah"}}, models.PageQuery{}, "")
+	if assert.NoError(t, err) {
+		assert.Len(t, demos.Items, 2)
+	}
+	_, err = r.FindDemos(nil, models.ListFilter{Tags: []string{"cheeseboiger", "test"}}, models.PageQuery{}, "")
+	assert.Equal(t, r.NotFoundErr(), err)
+	demos, err = r.FindDemos(nil, models.ListFilter{Tags: []string{"cheeseboiger", "test"}, AnyTag: true}, models.PageQuery{}, "")
+	if assert.NoError(t, err) {
+		assert.Len(t, demos.Items, 3)
+	}
+
+	// Untagged demos are kept by excluded tags
+	demos, err = r.FindDemos(nil, models.ListFilter{ExcludeTags: []string{"CHEESEBOIGER"}}, models.PageQuery{}, "")
+	if assert.NoError(t, err) {
+		titles := []string{}
+		for _, d := range demos.Items {
+			titles = append(titles, *d.Title)
+			if d.Tags != nil {
+				assert.NotContains(t, *d.Tags, "cheeseboiger")
+			}
+		}
+		assert.Contains(t, titles, sizedTitle)
+	}
+
+	// Filters combine with keywords
+	_, err = r.FindDemos([]string{"seven"}, models.ListFilter{ExcludeTags: []string{"cheeseboiger"}}, models.PageQuery{}, "")
+	assert.Equal(t, r.NotFoundErr(), err)
+	demos, err = r.FindDemos([]string{"seven"}, models.ListFilter{UserID: &userID}, models.PageQuery{}, "highest-rated")
+	if assert.NoError(t, err) {
+		assert.Len(t, demos.Items, 1)
+	}
+
+	_, err = r.FindDemos(nil, models.ListFilter{UserID: &stranger}, models.PageQuery{}, "")
+	assert.Equal(t, r.NotFoundErr(), err)
+	_, err = r.FindDemos(nil, models.ListFilter{CreatedFrom: &future}, models.PageQuery{}, "")
+	assert.Equal(t, r.NotFoundErr(), err)
+
+	// Demos uploaded before sizes were recorded never match a size range
+	demos, err = r.FindDemos(nil, models.ListFilter{MinSize: &minSize, MaxSize: &maxSize}, models.PageQuery{}, "most-views")
+	if assert.NoError(t, err) && assert.Len(t, demos.Items, 1) {
+		assert.Equal(t, sizedTitle, *demos.Items[0].Title)
+		assert.Equal(t, sizedFileSize, *demos.Items[0].FileSize)
+	}
+}
+
 func TestUpdateDemo(t *testing.T) {
 	r := PsqlDemoRepository{databaseClient: testDBClient, enforcer: testEnforcer, objectUploader: testS3Client}
 
@@ -141,21 +251,118 @@ func TestUpdateDemo(t *testing.T) {
 	}
 }
 
+func TestUpdateDemoAssets(t *testing.T) {
+	r := PsqlDemoRepository{databaseClient: testDBClient, enforcer: testEnforcer, objectUploader: testS3Client}
+	ra := PsqlAssetRepository{databaseClient: testDBClient, objectUploader: testS3Client}
+
+	usedAsset, err := ra.CreateAsset(asset, nil, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	demoAssets, err := r.UpdateDemoAssets(demoID, []models.DemoAsset{{AssetID: usedAsset.ID, AssetVersion: usedAsset.Version}})
+	if assert.NoError(t, err) {
+		assert.Len(t, *demoAssets, 1)
+		assert.Equal(t, usedAsset.ID, (*demoAssets)[0].Asset.ID)
+		assert.Equal(t, uint(1), *(*demoAssets)[0].Asset.UsageCount)
+	}
+
+	usages, err := ra.FindAssetUsages(*usedAsset.ID)
+	if assert.NoError(t, err) {
+		assert.Len(t, *usages, 1)
+	}
+
+	missingID, version := 1<<30, 1
+	_, err = r.UpdateDemoAssets(demoID, []models.DemoAsset{{AssetID: &missingID, AssetVersion: &version}})
+	assert.Equal(t, r.NotFoundErr(), err)
+	usages, err = ra.FindAssetUsages(*usedAsset.ID)
+	if assert.NoError(t, err) {
+		assert.Len(t, *usages, 1)
+	}
+
+	_, err = r.UpdateDemoAssets(demoID, []models.DemoAsset{})
+	assert.NoError(t, err)
+	_, err = r.FindDemoAssets(demoID)
+	assert.Equal(t, r.NotFoundErr(), err)
+
+	assert.NoError(t, ra.DeleteAsset(*usedAsset.ID, userID))
+}
+
+func TestForkDemo(t *testing.T) {
+	r := PsqlDemoRepository{databaseClient: testDBClient, enforcer: testEnforcer, objectUploader: testS3Client}
+	forkTitle := "Test Fork"
+	fork, err := r.ForkDemo(demoID, models.Demo{Title: &forkTitle, UserID: &userID, ThreadID: &threadID})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, demoID, *fork.ForkedFrom)
+	assert.Equal(t, forkTitle, *fork.Title)
+
+	forks, err := r.FindDemoForks(demoID)
+	if assert.NoError(t, err) {
+		assert.Len(t, *forks, 1)
+	}
+	ancestry, err := r.FindDemoAncestry(*fork.ID)
+	if assert.NoError(t, err) {
+		assert.Len(t, *ancestry, 1)
+		assert.Equal(t, demoID, *(*ancestry)[0].ID)
+	}
+
+	f := false
+	_, err = r.UpdateDemo(*fork.ID, models.Demo{AllowRemix: &f}, nil, nil)
+	assert.NoError(t, err)
+	_, err = r.ForkDemo(*fork.ID, models.Demo{UserID: &userID, ThreadID: &threadID})
+	assert.Equal(t, r.RemixForbiddenErr(), err)
+
+	assert.NoError(t, r.DeleteDemo(*fork.ID, userID))
+}
+
+// Fails the copy of the thumbnail, after the project was copied
+type thumbnailFailingS3 struct {
+	MockS3
+	copies  int
+	deleted []string
+}
+
+func (s *thumbnailFailingS3) CopyObject(srcKey, dstKey string) error {
+	s.copies++
+	if s.copies == 2 {
+		return errors.New("Copying the thumbnail failed")
+	}
+	return nil
+}
+func (s *thumbnailFailingS3) DeleteObject(objectKey string) error {
+	s.deleted = append(s.deleted, objectKey)
+	return nil
+}
+
+func TestForkDemoCopyFailed(t *testing.T) {
+	s3 := thumbnailFailingS3{}
+	r := PsqlDemoRepository{databaseClient: testDBClient, enforcer: testEnforcer, objectUploader: &s3}
+	_, err := r.ForkDemo(demoID, models.Demo{UserID: &userID, ThreadID: &threadID})
+	assert.Error(t, err)
+
+	// The copied project is deleted along with the fork
+	assert.Len(t, s3.deleted, 1)
+	_, err = r.FindDemoForks(demoID)
+	assert.Equal(t, r.NotFoundErr(), err)
+}
+
 func TestDeleteDemo(t *testing.T) {
 	r := PsqlDemoRepository{databaseClient: testDBClient, enforcer: testEnforcer, objectUploader: testS3Client}
-	err := r.DeleteDemo(demoID)
+	err := r.DeleteDemo(demoID, userID)
 	if assert.NoError(t, err) {
 		teardownDemo(&r)
 	}
 }
 
 func teardownDemo(r *PsqlDemoRepository) {
-	remainderDemo, err := r.FindDemos(nil, 0, "")
+	remainderDemo, err := r.FindDemos(nil, models.ListFilter{}, models.PageQuery{}, "")
 	if err != nil {
 		panic(err)
 	}
-	for _, d := range *remainderDemo {
-		err = r.DeleteDemo(*d.ID)
+	for _, d := range remainderDemo.Items {
+		err = r.DeleteDemo(*d.ID, userID)
 		if err != nil {
 			panic(err)
 		}
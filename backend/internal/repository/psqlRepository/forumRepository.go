@@ -5,31 +5,237 @@ import (
 	"errors"
 	"fmt"
 	"gamehangar/internal/domain/models"
+	"regexp"
+	"slices"
 	"strings"
+	"time"
+	"unicode"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 type PsqlForumRepository struct {
-	databaseClient psqlDatabaseClient
-	enforcer       Enforcer
-	conflictErr    error
+	databaseClient  psqlDatabaseClient
+	enforcer        Enforcer
+	conflictErr     error
+	threadLockedErr error
+	replyErr        error
+	quoteErr        error
+	mentionsErr     error
+
+	threadForbiddenErr error
+	threadOperationErr error
+	demoThreadErr      error
+
+	slugConflictErr  error
+	parentErr        error
+	childrenErr      error
+	threadAccessErr  error
+	accountAgeErr    error
+	topicTrashedErr  error
+	threadTrashedErr error
+}
+
+// Runes of the replied message shown with a reply that quotes nothing
+const replyExcerptLength = 200
+
+// Distinct users a message may mention, so it cannot be used to ping everyone
+const maxMentions = 10
+
+// Runes of a derived slug before the suffix that makes it unique
+const maxSlugBase = 80
+
+// Scanned into models.Topic in field order
+const topicColumns = `id, name, version, subscribers, user_id, slug, description, icon, sort_order,
+	parent_id, thread_access, min_account_age_days`
+
+// Scanned into models.Message in field order
+const messageColumns = `id, thread_id, user_id, title, body, tags, created_at, updated_at, upvotes, downvotes, rating, views,
+	hidden_at, reply_to, quote, replies, body_html, edited_at, edited_by, revisions`
+
+// Condition on forum.messages rows that a reply below the message, however deep, is neither deleted nor hidden
+const hasVisibleReplies = `EXISTS (WITH RECURSIVE below AS (
+		SELECT r.id, r.deleted_at, r.hidden_at FROM forum.messages r WHERE r.reply_to = messages.id
+		UNION ALL
+		SELECT m.id, m.deleted_at, m.hidden_at FROM forum.messages m JOIN below b ON m.reply_to = b.id
+	) SELECT 1 FROM below WHERE deleted_at IS NULL AND hidden_at IS NULL)`
+
+// Condition on forum.messages rows that their thread is neither deleted nor hidden. Messages of hidden
+// threads pass when showHidden, an SQL boolean, holds, for callers that check who may see the thread
+func threadOfMessageVisible(showHidden string) string {
+	return `EXISTS (SELECT 1 FROM forum.threads t WHERE t.id = messages.thread_id AND t.deleted_at IS NULL
+		AND (t.hidden_at IS NULL OR ` + showHidden + `))`
 }
 
+var (
+	// @username at the start of a word, so e-mail addresses are not mistaken for mentions
+	mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_@.])@([\p{L}\p{N}_][\p{L}\p{N}_.-]*)`)
+	// Mentions in code are not resolved
+	codePattern = regexp.MustCompile("(?s)```.*?```|`[^`\n]*`")
+)
+
 // Requires PsqlDatabaseClient since it implements PostgeSQL-specific query logic
 func NewPsqlForumRepository(dbClient psqlDatabaseClient, e Enforcer) *PsqlForumRepository {
 	return &PsqlForumRepository{
-		databaseClient: dbClient,
-		enforcer:       e,
-		conflictErr:    errors.New("Record conflict!"),
+		databaseClient:  dbClient,
+		enforcer:        e,
+		conflictErr:     errors.New("Record conflict!"),
+		threadLockedErr: errors.New("Thread is locked!"),
+		replyErr:        errors.New("Replied message is not in this thread!"),
+		quoteErr:        errors.New("Quote is not part of the replied message!"),
+		mentionsErr:     fmt.Errorf("Messages can mention at most %v users!", maxMentions),
+
+		threadForbiddenErr: errors.New("Only moderators and the topic owner can manage this thread!"),
+		threadOperationErr: errors.New("Operation is not valid for this thread!"),
+		demoThreadErr:      errors.New("Demo threads cannot be merged into other threads!"),
+
+		slugConflictErr:  errors.New("Category slug is already taken!"),
+		parentErr:        errors.New("Parent category is not valid!"),
+		childrenErr:      errors.New("Category has subcategories!"),
+		threadAccessErr:  errors.New("Creating threads in this category is restricted!"),
+		accountAgeErr:    errors.New("Account is too new to post in this category!"),
+		topicTrashedErr:  errors.New("Topic of the thread is in the trash, restore it first!"),
+		threadTrashedErr: errors.New("Thread of the message is in the trash, restore it first!"),
 	}
 }
 
 func (r *PsqlForumRepository) NotFoundErr() error { return r.databaseClient.ErrNoRows() }
 
+// Returns "Cursor is not valid!" when the page cursor is malformed or made for another order
+func (r *PsqlForumRepository) CursorErr() error { return cursorErr }
+
 // Returns "Record conflict!" to specify conflicting record versions on update
 func (r *PsqlForumRepository) ConflictErr() error { return r.conflictErr }
 
+// Returns "Thread is locked!" when posting to a thread locked by a moderator
+func (r *PsqlForumRepository) ThreadLockedErr() error { return r.threadLockedErr }
+
+// Returns "Replied message is not in this thread!" when replying to a message of another thread,
+// or one that is deleted or hidden
+func (r *PsqlForumRepository) ReplyErr() error { return r.replyErr }
+
+// Returns "Quote is not part of the replied message!" when the quote is not found in the replied message
+func (r *PsqlForumRepository) QuoteErr() error { return r.quoteErr }
+
+// Returns "Messages can mention at most 10 users!" when the body mentions more than maxMentions users
+func (r *PsqlForumRepository) MentionsErr() error { return r.mentionsErr }
+
+// Returns "Only moderators and the topic owner can manage this thread!" when managing a thread,
+// or moving it to a topic, the user neither owns nor moderates
+func (r *PsqlForumRepository) ThreadForbiddenErr() error { return r.threadForbiddenErr }
+
+// Returns "Operation is not valid for this thread!", e.g. when moving a thread to its own topic,
+// merging it into itself or splitting messages of another thread
+func (r *PsqlForumRepository) ThreadOperationErr() error { return r.threadOperationErr }
+
+// Returns "Demo threads cannot be merged into other threads!" since the demo keeps its thread
+func (r *PsqlForumRepository) DemoThreadErr() error { return r.demoThreadErr }
+
+// Returns "Category slug is already taken!" when another live category has the slug
+func (r *PsqlForumRepository) SlugConflictErr() error { return r.slugConflictErr }
+
+// Returns "Parent category is not valid!" when the parent does not exist, or is the category itself
+// or one of its subcategories
+func (r *PsqlForumRepository) ParentErr() error { return r.parentErr }
+
+// Returns "Category has subcategories!" when deleting a category before its subcategories
+func (r *PsqlForumRepository) ChildrenErr() error { return r.childrenErr }
+
+// Returns "Creating threads in this category is restricted!" when the thread access of the category
+// excludes the user
+func (r *PsqlForumRepository) ThreadAccessErr() error { return r.threadAccessErr }
+
+// Returns "Account is too new to post in this category!" when the account is younger than
+// the minimum account age of the category
+func (r *PsqlForumRepository) AccountAgeErr() error { return r.accountAgeErr }
+
+// Returns "Topic of the thread is in the trash, restore it first!" when restoring a thread
+// that would be unreachable in its deleted topic
+func (r *PsqlForumRepository) TopicTrashedErr() error { return r.topicTrashedErr }
+
+// Returns "Thread of the message is in the trash, restore it first!" when restoring a message
+// that would be unreachable in its deleted thread
+func (r *PsqlForumRepository) ThreadTrashedErr() error { return r.threadTrashedErr }
+
+// Categories are addressed by slug, e.g. "Godot 4: Tips" becomes "godot-4-tips"
+func slugify(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, c := range strings.ToLower(s) {
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) {
+			dash = true
+			continue
+		}
+		if dash && b.Len() > 0 {
+			b.WriteByte('-')
+		}
+		b.WriteRune(c)
+		dash = false
+	}
+	return b.String()
+}
+
+// Picks the first slug among base, base-2, base-3... not taken by a live category
+func freeSlug(tx pgx.Tx, base string) (string, error) {
+	if runes := []rune(base); len(runes) > maxSlugBase {
+		base = strings.TrimRight(string(runes[:maxSlugBase]), "-")
+	}
+	if base == "" {
+		base = "topic"
+	}
+	rows, err := tx.Query(context.Background(),
+		`SELECT slug FROM forum.topics WHERE deleted_at IS NULL AND (slug = $1 OR slug LIKE $1 || '-%')`,
+		base,
+	)
+	if err != nil {
+		return "", err
+	}
+	taken, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return "", err
+	}
+
+	slug := base
+	for i := 2; slices.Contains(taken, slug); i++ {
+		slug = fmt.Sprintf("%v-%v", base, i)
+	}
+	return slug, nil
+}
+
+// The parent must be a live category that is neither the category itself nor one of its subcategories.
+// Pass 0 as id for a category that does not exist yet
+func (r *PsqlForumRepository) checkParent(tx pgx.Tx, parentID, id int) error {
+	var found, cycle bool
+	err := tx.QueryRow(context.Background(),
+		`WITH RECURSIVE ancestors AS (
+			SELECT id, parent_id FROM forum.topics WHERE id = $1 AND deleted_at IS NULL
+			UNION
+			SELECT tp.id, tp.parent_id FROM forum.topics tp JOIN ancestors a ON tp.id = a.parent_id
+		)
+		SELECT COUNT(*) > 0, COALESCE(BOOL_OR(id = $2), false) FROM ancestors`,
+		parentID, id,
+	).Scan(&found, &cycle)
+	if err != nil {
+		return err
+	}
+	if !found || cycle {
+		return r.parentErr
+	}
+	return nil
+}
+
+// Replaces unique violations of the slug index with slugConflictErr
+func (r *PsqlForumRepository) slugErr(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return r.slugConflictErr
+	}
+	return err
+}
+
+// The slug is derived from the name when left out, otherwise it must be free
 func (r *PsqlForumRepository) CreateTopic(topic models.Topic) (*models.Topic, error) {
 	conn, err := r.databaseClient.AcquireConn()
 	if err != nil {
@@ -37,16 +243,53 @@ func (r *PsqlForumRepository) CreateTopic(topic models.Topic) (*models.Topic, er
 	}
 	defer conn.Release()
 
-	err = conn.QueryRow(context.Background(),
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(context.Background())
+
+	if topic.ParentID != nil && *topic.ParentID == 0 {
+		topic.ParentID = nil
+	}
+	if topic.ParentID != nil {
+		err = r.checkParent(tx, *topic.ParentID, 0)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var slug string
+	if topic.Slug != nil {
+		slug = slugify(*topic.Slug)
+	}
+	if slug == "" {
+		slug, err = freeSlug(tx, slugify(*topic.Name))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = tx.QueryRow(context.Background(),
 		`INSERT INTO forum.topics
-		(name) 
+		(name, user_id, slug, description, icon, sort_order, parent_id, thread_access, min_account_age_days) 
 		VALUES
-		($1)
+		($1, $2, $3, $4, $5, COALESCE($6, 0), $7, COALESCE($8, 'everyone'), COALESCE($9, 0))
 		RETURNING
-		(id, name, version)`,
-		topic.Name,
+		(`+topicColumns+`)`,
+		topic.Name, topic.UserID, slug, topic.Description, topic.Icon, topic.SortOrder, topic.ParentID,
+		topic.ThreadAccess, topic.MinAccountAgeDays,
 	).Scan(&topic)
+	if err != nil {
+		return nil, r.slugErr(err)
+	}
+	change := auditChange{actorID: topic.UserID, action: "create", table: "forum.topics", targetType: "topics", targetID: *topic.ID}
+	err = change.record(tx)
+	if err != nil {
+		return nil, err
+	}
 
+	err = tx.Commit(context.Background())
 	if err != nil {
 		return nil, err
 	}
@@ -62,7 +305,7 @@ func (r *PsqlForumRepository) FindTopicByID(id int) (*models.Topic, error) {
 	defer conn.Release()
 
 	err = conn.QueryRow(context.Background(),
-		`SELECT (id, name, version) FROM forum.topics WHERE id = $1 LIMIT 1`,
+		`SELECT (`+topicColumns+`) FROM forum.topics WHERE id = $1 AND deleted_at IS NULL LIMIT 1`,
 		id,
 	).Scan(&topic)
 	if err != nil {
@@ -71,7 +314,37 @@ func (r *PsqlForumRepository) FindTopicByID(id int) (*models.Topic, error) {
 	return &topic, nil
 }
 
-func (r *PsqlForumRepository) FindTopics() (*[]models.Topic, error) {
+func (r *PsqlForumRepository) FindTopicBySlug(slug string) (*models.Topic, error) {
+	var topic models.Topic
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	err = conn.QueryRow(context.Background(),
+		`SELECT (`+topicColumns+`) FROM forum.topics WHERE slug = $1 AND deleted_at IS NULL LIMIT 1`,
+		slugify(slug),
+	).Scan(&topic)
+	if err != nil {
+		return nil, err
+	}
+	return &topic, nil
+}
+
+// Finds the category of the slug, creating it when there is none.
+// Used for categories the server relies on, e.g. the one demo threads are synced to
+func (r *PsqlForumRepository) ProvisionTopic(topic models.Topic) (*models.Topic, error) {
+	found, err := r.FindTopicBySlug(*topic.Slug)
+	if err != r.NotFoundErr() {
+		return found, err
+	}
+	return r.CreateTopic(topic)
+}
+
+// Categories are ordered by sort order and name among their siblings. The nested mode lists
+// top level categories with their subcategories as children
+func (r *PsqlForumRepository) FindTopics(nested bool) (*[]models.Topic, error) {
 	var topics []models.Topic
 
 	conn, err := r.databaseClient.AcquireConn()
@@ -80,7 +353,9 @@ func (r *PsqlForumRepository) FindTopics() (*[]models.Topic, error) {
 	}
 	defer conn.Release()
 
-	rows, err := conn.Query(context.Background(), `SELECT (id, name, version) FROM forum.topics`)
+	rows, err := conn.Query(context.Background(),
+		`SELECT (`+topicColumns+`) FROM forum.topics WHERE deleted_at IS NULL ORDER BY sort_order, name, id`,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -100,9 +375,43 @@ func (r *PsqlForumRepository) FindTopics() (*[]models.Topic, error) {
 	if len(topics) == 0 {
 		return nil, r.NotFoundErr()
 	}
+	if nested {
+		topics = nestTopics(topics)
+	}
 	return &topics, nil
 }
 
+// Categories whose parent is in the trash are listed at the top level
+func nestTopics(topics []models.Topic) []models.Topic {
+	live := make(map[int]bool, len(topics))
+	for _, t := range topics {
+		live[*t.ID] = true
+	}
+
+	var roots []models.Topic
+	children := make(map[int][]models.Topic)
+	for _, t := range topics {
+		if t.ParentID != nil && live[*t.ParentID] {
+			children[*t.ParentID] = append(children[*t.ParentID], t)
+		} else {
+			roots = append(roots, t)
+		}
+	}
+
+	var attach func(level []models.Topic) []models.Topic
+	attach = func(level []models.Topic) []models.Topic {
+		for i := range level {
+			if c, ok := children[*level[i].ID]; ok {
+				c = attach(c)
+				level[i].Children = &c
+			}
+		}
+		return level
+	}
+	return attach(roots)
+}
+
+// A parent ID of 0 moves the category to the top level. The slug is kept on rename
 func (r *PsqlForumRepository) UpdateTopic(id int, topic models.Topic) (*models.Topic, error) {
 	conn, err := r.databaseClient.AcquireConn()
 	if err != nil {
@@ -110,7 +419,13 @@ func (r *PsqlForumRepository) UpdateTopic(id int, topic models.Topic) (*models.T
 	}
 	defer conn.Release()
 
-	err = conn.QueryRow(
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(context.Background())
+
+	err = tx.QueryRow(
 		context.Background(),
 		`SELECT (id) FROM forum.topics WHERE id=$1 AND version=$2`,
 		id, *topic.Version,
@@ -119,39 +434,227 @@ func (r *PsqlForumRepository) UpdateTopic(id int, topic models.Topic) (*models.T
 		return nil, r.ConflictErr()
 	}
 
-	err = conn.QueryRow(context.Background(),
+	if topic.ParentID != nil && *topic.ParentID != 0 {
+		err = r.checkParent(tx, *topic.ParentID, id)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var slug *string
+	if topic.Slug != nil && slugify(*topic.Slug) != "" {
+		s := slugify(*topic.Slug)
+		slug = &s
+	}
+
+	change := auditChange{action: "update", table: "forum.topics", targetType: "topics", targetID: id}
+	err = change.snapshot(tx)
+	if err != nil {
+		return nil, err
+	}
+	err = tx.QueryRow(context.Background(),
 		`UPDATE forum.topics SET 
-		name=COALESCE($1, name)
-		WHERE id = $2
+		name=COALESCE($1, name),
+		slug=COALESCE($2, slug),
+		description=COALESCE($3, description),
+		icon=COALESCE($4, icon),
+		sort_order=COALESCE($5, sort_order),
+		parent_id=CASE WHEN $6::INTEGER IS NULL THEN parent_id ELSE NULLIF($6, 0) END,
+		thread_access=COALESCE($7, thread_access),
+		min_account_age_days=COALESCE($8, min_account_age_days)
+		WHERE id = $9 AND deleted_at IS NULL
 		RETURNING
-		(id, name, version)`,
-		topic.Name, id,
+		(`+topicColumns+`)`,
+		topic.Name, slug, topic.Description, topic.Icon, topic.SortOrder, topic.ParentID,
+		topic.ThreadAccess, topic.MinAccountAgeDays, id,
 	).Scan(&topic)
+	if err != nil {
+		return nil, r.slugErr(err)
+	}
+	err = change.record(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit(context.Background())
 	if err != nil {
 		return nil, err
 	}
 	return &topic, err
 }
 
-func (r *PsqlForumRepository) DeleteTopic(id int) error {
+// Moves the topic to the trash along with its threads and their messages. Subcategories must be deleted first
+func (r *PsqlForumRepository) DeleteTopic(id int, deletedBy uuid.UUID) error {
 	conn, err := r.databaseClient.AcquireConn()
 	if err != nil {
 		return err
 	}
 	defer conn.Release()
 
-	err = r.DeleteThreadsOfTopic(id)
+	tx, err := conn.Begin(context.Background())
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback(context.Background())
 
-	ct, err := conn.Exec(context.Background(), `DELETE FROM forum.topics WHERE id=$1`, id)
+	change := auditChange{actorID: &deletedBy, action: "delete", table: "forum.topics", targetType: "topics", targetID: id}
+	err = change.snapshot(tx)
+	if err != nil {
+		return err
+	}
+	ct, err := tx.Exec(context.Background(),
+		`UPDATE forum.topics SET deleted_at=NOW(), deleted_by=$2 WHERE id=$1 AND deleted_at IS NULL`,
+		id, deletedBy,
+	)
 	if ct.RowsAffected() == 0 {
 		if err != nil {
 			return err
 		}
 		return r.databaseClient.ErrNoRows()
 	}
+
+	var hasChildren bool
+	err = tx.QueryRow(context.Background(),
+		`SELECT EXISTS (SELECT 1 FROM forum.topics WHERE parent_id=$1 AND deleted_at IS NULL)`, id,
+	).Scan(&hasChildren)
+	if err != nil {
+		return err
+	}
+	if hasChildren {
+		return r.childrenErr
+	}
+
+	// NOW() is fixed within a transaction, so children share the topic's deleted_at
+	_, err = tx.Exec(context.Background(),
+		`UPDATE forum.messages SET deleted_at=NOW(), deleted_by=$2
+		WHERE deleted_at IS NULL AND thread_id IN (SELECT id FROM forum.threads WHERE topic_id=$1 AND deleted_at IS NULL)`,
+		id, deletedBy,
+	)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(context.Background(),
+		`UPDATE forum.threads SET deleted_at=NOW(), deleted_by=$2 WHERE topic_id=$1 AND deleted_at IS NULL`,
+		id, deletedBy,
+	)
+	if err != nil {
+		return err
+	}
+	err = change.record(tx)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(context.Background())
+}
+
+// Restores the topic from the trash along with threads and messages deleted with it.
+// A nil restoredBy skips the check of who deleted it
+func (r *PsqlForumRepository) RestoreTopic(id int, restoredBy *uuid.UUID) error {
+	var deletedAt time.Time
+
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(context.Background())
+
+	err = tx.QueryRow(context.Background(),
+		`SELECT deleted_at FROM forum.topics WHERE id=$1 AND deleted_at IS NOT NULL AND ($2::UUID IS NULL OR deleted_by=$2)`,
+		id, restoredBy,
+	).Scan(&deletedAt)
+	if err != nil {
+		return err
+	}
+
+	change := auditChange{actorID: restoredBy, action: "restore", table: "forum.topics", targetType: "topics", targetID: id}
+	err = change.snapshot(tx)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(context.Background(),
+		`UPDATE forum.messages SET deleted_at=NULL, deleted_by=NULL
+		WHERE deleted_at=$2 AND thread_id IN (SELECT id FROM forum.threads WHERE topic_id=$1 AND deleted_at=$2)`,
+		id, deletedAt,
+	)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(context.Background(),
+		`UPDATE forum.threads SET deleted_at=NULL, deleted_by=NULL WHERE topic_id=$1 AND deleted_at=$2`,
+		id, deletedAt,
+	)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(context.Background(),
+		`UPDATE forum.topics SET deleted_at=NULL, deleted_by=NULL WHERE id=$1`, id,
+	)
+	if err != nil {
+		return r.slugErr(err)
+	}
+	err = change.record(tx)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(context.Background())
+}
+
+// Checks the posting rules of the category before the user creates a thread in it
+func (r *PsqlForumRepository) CheckThreadRules(topicID int, userID uuid.UUID) error {
+	return r.checkPostingRules(`$1`, topicID, userID, true)
+}
+
+// Checks the posting rules of the category of the thread before the user posts a message to it
+func (r *PsqlForumRepository) CheckMessageRules(threadID int, userID uuid.UUID) error {
+	return r.checkPostingRules(`(SELECT topic_id FROM forum.threads WHERE id = $1 AND deleted_at IS NULL)`, threadID, userID, false)
+}
+
+// Moderators of the category are not bound by its rules
+func (r *PsqlForumRepository) checkPostingRules(topicID string, id int, userID uuid.UUID, newThread bool) error {
+	var access string
+	var ownerID *uuid.UUID
+	var tooNew, moderates bool
+
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	err = conn.QueryRow(context.Background(),
+		`SELECT tp.thread_access, tp.user_id, u.created_at > NOW() - MAKE_INTERVAL(days => tp.min_account_age_days),
+		forum.moderates_topic(tp.id, u.id)
+		FROM forum.topics tp, "user".users u
+		WHERE tp.id = `+topicID+` AND tp.deleted_at IS NULL AND u.id = $2`,
+		id, userID,
+	).Scan(&access, &ownerID, &tooNew, &moderates)
+	if err != nil {
+		return err
+	}
+	if moderates {
+		return nil
+	}
+
+	if newThread {
+		switch access {
+		case "moderators":
+			return r.threadAccessErr
+		case "owner":
+			if ownerID == nil || *ownerID != userID {
+				return r.threadAccessErr
+			}
+		}
+	}
+	if tooNew {
+		return r.accountAgeErr
+	}
 	return nil
 }
 
@@ -162,18 +665,34 @@ func (r *PsqlForumRepository) CreateThread(thread models.Thread) (*models.Thread
 	}
 	defer conn.Release()
 
-	err = conn.QueryRow(context.Background(),
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(context.Background())
+
+	err = tx.QueryRow(context.Background(),
 		`INSERT INTO forum.threads
 			(title, user_id, topic_id, tags)
 		VALUES
 			($1, $2, $3, $4)
 		RETURNING
-			(id, title, user_id, topic_id, tags, created_at, updated_at, upvotes, downvotes, rating, views)`,
+			(id, title, user_id, topic_id, tags, created_at, updated_at, upvotes, downvotes, rating, views,
+			hidden_at, locked_at, subscribers, edited_at, edited_by, revisions, pinned_at, version)`,
 		thread.Title, thread.UserID, thread.TopicID, thread.Tags,
 	).Scan(&thread)
 	if err != nil {
 		return nil, err
 	}
+	change := auditChange{actorID: thread.UserID, action: "create", table: "forum.threads", targetType: "threads", targetID: *thread.ID}
+	err = change.record(tx)
+	if err != nil {
+		return nil, err
+	}
+	err = tx.Commit(context.Background())
+	if err != nil {
+		return nil, err
+	}
 
 	_, err = r.enforcer.AddPermissions(thread.UserID.String(), fmt.Sprintf("threads/%v", *thread.ID), "PATCH")
 	if err != nil {
@@ -198,9 +717,10 @@ func (r *PsqlForumRepository) FindThreadByID(id int) (*models.Thread, error) {
 	err = conn.QueryRow(context.Background(),
 		`UPDATE forum.threads SET 
 		views=views+1
-		WHERE id = $1
+		WHERE id = $1 AND deleted_at IS NULL
 		RETURNING
-		(id, title, user_id, topic_id, tags, created_at, updated_at, upvotes, downvotes, rating, views)`,
+		(id, title, user_id, topic_id, tags, created_at, updated_at, upvotes, downvotes, rating, views,
+		hidden_at, locked_at, subscribers, edited_at, edited_by, revisions, pinned_at, version)`,
 		id,
 	).Scan(&thread)
 	if err != nil {
@@ -209,8 +729,10 @@ func (r *PsqlForumRepository) FindThreadByID(id int) (*models.Thread, error) {
 	return &thread, nil
 }
 
-func (r *PsqlForumRepository) FindThreads(keywords []string, limit uint64, order string) (*[]models.Thread, error) {
-	var threads []models.Thread
+// Returns the author and hidden state of the thread unless it is deleted, telling who may see its messages.
+// Unlike FindThreadByID it counts no view
+func (r *PsqlForumRepository) FindThreadVisibility(id int) (*models.Thread, error) {
+	thread := models.Thread{ID: &id}
 
 	conn, err := r.databaseClient.AcquireConn()
 	if err != nil {
@@ -218,167 +740,538 @@ func (r *PsqlForumRepository) FindThreads(keywords []string, limit uint64, order
 	}
 	defer conn.Release()
 
-	var rows pgx.Rows
-	if len(keywords) != 0 {
-		query := `SELECT (id, title, user_id, topic_id, tags, created_at, updated_at, upvotes, downvotes, rating, views) 
-				FROM
-				((SELECT id, title, user_id, topic_id, tags, created_at, updated_at, upvotes, downvotes, rating, views
-				FROM forum.threads
-				WHERE thread_ts @@ to_tsquery_multilang($1))
-			UNION
-				(SELECT id, title, user_id, topic_id, tags, created_at, updated_at, upvotes, downvotes, rating, views 
-				FROM forum.threads
-				WHERE tags && ($2) COLLATE case_insensitive))`
-
-		switch order {
-		case "newest-updated":
-			query = query + ` ORDER BY updated_at DESC`
-		case "highest-rated":
-			query = query + ` ORDER BY rating DESC`
-		case "most-views":
-			query = query + ` ORDER BY views DESC`
-		default:
-			query = query + ` ORDER BY updated_at DESC`
-		}
-		if limit != 0 {
-			query = query + fmt.Sprintf(` LIMIT %v`, limit)
-		}
-		rows, err = conn.Query(context.Background(),
-			query, strings.Join(keywords, " | "), keywords,
-		)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		query := `SELECT (id, title, user_id, topic_id, tags, created_at, updated_at, upvotes, downvotes, rating, views)
-		FROM forum.threads`
-
-		switch order {
-		case "newest-updated":
-			query = query + ` ORDER BY updated_at DESC`
-		case "highest-rated":
-			query = query + ` ORDER BY rating DESC`
-		case "most-views":
-			query = query + ` ORDER BY views DESC`
-		default:
-			query = query + ` ORDER BY updated_at DESC`
-		}
-		if limit != 0 {
-			query = query + fmt.Sprintf(` LIMIT %v`, limit)
-		}
-		rows, err = conn.Query(context.Background(), query)
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	defer rows.Close()
-	for rows.Next() {
-		var thread models.Thread
-		err = rows.Scan(&thread)
-		if err != nil {
-			return nil, err
-		}
-		threads = append(threads, thread)
-	}
-	err = rows.Err()
+	err = conn.QueryRow(context.Background(),
+		`SELECT user_id, hidden_at FROM forum.threads WHERE id = $1 AND deleted_at IS NULL`, id,
+	).Scan(&thread.UserID, &thread.HiddenAt)
 	if err != nil {
 		return nil, err
 	}
-	if len(threads) == 0 {
-		return nil, r.NotFoundErr()
-	}
-	return &threads, nil
+	return &thread, nil
 }
 
-func (r *PsqlForumRepository) UpdateThread(id int, thread models.Thread) (*models.Thread, error) {
+// Returns the page of visible threads passing the filter and matching the keywords by text, word prefix, tag or similar title, all of them without keywords
+func (r *PsqlForumRepository) FindThreads(keywords []string, filter models.ListFilter, page models.PageQuery, order string) (*models.Page[models.Thread], error) {
+	key, ok := contentSortKeys[order]
+	if !ok {
+		order, key = "newest-updated", contentSortKeys["newest-updated"]
+	}
+
 	conn, err := r.databaseClient.AcquireConn()
 	if err != nil {
 		return nil, err
 	}
 	defer conn.Release()
 
-	err = conn.QueryRow(context.Background(),
-		`UPDATE forum.threads SET 
-			title=COALESCE($1, title), user_id=COALESCE($2, user_id), topic_id=COALESCE($3, topic_id),
-		tags=COALESCE($4, tags), upvotes=COALESCE($5, upvotes), downvotes=COALESCE($6, downvotes),
-			updated_at=NOW()
-			WHERE id = $7
+	q := pageQuery{
+		source: `SELECT * FROM forum.threads WHERE deleted_at IS NULL AND hidden_at IS NULL`,
+		columns: `id, title, user_id, topic_id, tags, created_at, updated_at, upvotes, downvotes, rating, views,
+			hidden_at, locked_at, subscribers, edited_at, edited_by, revisions, pinned_at, version`,
+		order:  order,
+		key:    key,
+		idCast: "INTEGER",
+	}
+	if len(keywords) != 0 {
+		q.args = []any{strings.Join(keywords, " | "), keywords}
+	}
+	q.filter(filter)
+	// Keywords go last, so that the fuzzy fallback counts the matches the filters leave
+	if len(keywords) != 0 {
+		q.source = keywordMatch(q.source, `thread_ts`, `title`)
+	}
+
+	threads, err := queryPage[models.Thread](conn, q, page)
+	if err != nil {
+		return nil, err
+	}
+	if len(threads.Items) == 0 {
+		return nil, r.NotFoundErr()
+	}
+	return threads, nil
+}
+
+// Returns the visible threads of the topic, pinned threads first with the latest pinned on top
+func (r *PsqlForumRepository) FindThreadsByTopicID(topicID int, limit uint64, order string) (*[]models.Thread, error) {
+	var threads []models.Thread
+
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	query := `SELECT (id, title, user_id, topic_id, tags, created_at, updated_at, upvotes, downvotes, rating, views,
+		hidden_at, locked_at, subscribers, edited_at, edited_by, revisions, pinned_at, version)
+	FROM forum.threads WHERE topic_id = $1 AND deleted_at IS NULL AND hidden_at IS NULL
+	ORDER BY pinned_at DESC NULLS LAST`
+
+	switch order {
+	case "newest-updated":
+		query = query + `, updated_at DESC`
+	case "highest-rated":
+		query = query + `, rating DESC`
+	case "most-views":
+		query = query + `, views DESC`
+	default:
+		query = query + `, updated_at DESC`
+	}
+	if limit != 0 {
+		query = query + fmt.Sprintf(` LIMIT %v`, limit)
+	}
+
+	rows, err := conn.Query(context.Background(), query, topicID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var thread models.Thread
+		err = rows.Scan(&thread)
+		if err != nil {
+			return nil, err
+		}
+		threads = append(threads, thread)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+	if len(threads) == 0 {
+		return nil, r.NotFoundErr()
+	}
+	return &threads, nil
+}
+
+func (r *PsqlForumRepository) UpdateThread(id int, thread models.Thread) (*models.Thread, error) {
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(context.Background())
+
+	change := auditChange{actorID: thread.EditedBy, action: "update", table: "forum.threads", targetType: "threads", targetID: id}
+	err = change.snapshot(tx)
+	if err != nil {
+		return nil, err
+	}
+	err = tx.QueryRow(context.Background(),
+		`UPDATE forum.threads SET 
+			title=COALESCE($1, title), user_id=COALESCE($2, user_id), topic_id=COALESCE($3, topic_id),
+		tags=COALESCE($4, tags), upvotes=COALESCE($5, upvotes), downvotes=COALESCE($6, downvotes),
+			updated_at=NOW(), edited_by=$8
+			WHERE id = $7 AND deleted_at IS NULL
 		RETURNING
-			(id, title, user_id, topic_id, tags, created_at, updated_at, upvotes, downvotes, rating, views)`,
+			(id, title, user_id, topic_id, tags, created_at, updated_at, upvotes, downvotes, rating, views,
+			hidden_at, locked_at, subscribers, edited_at, edited_by, revisions, pinned_at, version)`,
 		thread.Title, thread.UserID, thread.TopicID, thread.Tags,
-		thread.Upvotes, thread.Downvotes, id,
+		thread.Upvotes, thread.Downvotes, id, thread.EditedBy,
 	).Scan(&thread)
 	if err != nil {
 		return nil, err
 	}
+	err = change.record(tx)
+	if err != nil {
+		return nil, err
+	}
+	err = tx.Commit(context.Background())
+	if err != nil {
+		return nil, err
+	}
 	return &thread, nil
 }
 
-func (r *PsqlForumRepository) DeleteThread(id int) error {
+// Moves the thread to the trash along with its messages
+func (r *PsqlForumRepository) DeleteThread(id int, deletedBy uuid.UUID) error {
 	conn, err := r.databaseClient.AcquireConn()
 	if err != nil {
 		return err
 	}
 	defer conn.Release()
 
-	ct, err := conn.Exec(context.Background(), `DELETE FROM forum.threads WHERE id=$1`, id)
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(context.Background())
+
+	change := auditChange{actorID: &deletedBy, action: "delete", table: "forum.threads", targetType: "threads", targetID: id}
+	err = change.snapshot(tx)
+	if err != nil {
+		return err
+	}
+	ct, err := tx.Exec(context.Background(),
+		`UPDATE forum.threads SET deleted_at=NOW(), deleted_by=$2 WHERE id=$1 AND deleted_at IS NULL`,
+		id, deletedBy,
+	)
 	if ct.RowsAffected() == 0 {
 		if err != nil {
 			return err
 		}
 		return r.databaseClient.ErrNoRows()
 	}
-	_, err = r.enforcer.RemovePermissionsForObject(fmt.Sprintf("threads/%v", id), "PATCH")
+
+	// NOW() is fixed within a transaction, so messages share the thread's deleted_at
+	_, err = tx.Exec(context.Background(),
+		`UPDATE forum.messages SET deleted_at=NOW(), deleted_by=$2 WHERE thread_id=$1 AND deleted_at IS NULL`,
+		id, deletedBy,
+	)
 	if err != nil {
 		return err
 	}
-	_, err = r.enforcer.RemovePermissionsForObject(fmt.Sprintf("threads/%v", id), "DELETE")
+	err = change.record(tx)
 	if err != nil {
 		return err
 	}
-	return nil
+
+	return tx.Commit(context.Background())
 }
 
-func (r *PsqlForumRepository) DeleteThreadsOfTopic(topicID int) error {
+// Deletes the thread for good, skipping the trash, e.g. the thread posted for a demo that could not be
+// stored. A thread a demo refers to is not found
+func (r *PsqlForumRepository) PurgeThread(id int, purgedBy uuid.UUID) error {
 	conn, err := r.databaseClient.AcquireConn()
 	if err != nil {
 		return err
 	}
 	defer conn.Release()
 
-	rows, err := conn.Query(context.Background(), `SELECT id FROM forum.threads WHERE topic_id = $1`, topicID)
+	change := auditChange{actorID: &purgedBy, action: "purge", table: "forum.threads", targetType: "threads", targetID: id}
+	ct, err := change.exec(conn,
+		`DELETE FROM forum.threads WHERE id=$1 AND NOT EXISTS (SELECT 1 FROM demo.demos WHERE thread_id=$1)`, id,
+	)
 	if err != nil {
 		return err
 	}
-	defer rows.Close()
-	for rows.Next() {
-		var thread int
-		err = rows.Scan(&thread)
+	if ct.RowsAffected() == 0 {
+		return r.databaseClient.ErrNoRows()
+	}
+
+	for _, act := range []string{"PATCH", "DELETE"} {
+		_, err = r.enforcer.RemovePermissionsForObject(fmt.Sprintf("threads/%v", id), act)
 		if err != nil {
 			return err
 		}
-		err = r.DeleteMessagesOfThread(thread)
+	}
+	return nil
+}
+
+// Restores the thread from the trash along with messages deleted with it.
+// A nil restoredBy skips the check of who deleted it
+func (r *PsqlForumRepository) RestoreThread(id int, restoredBy *uuid.UUID) error {
+	var (
+		deletedAt    time.Time
+		topicTrashed bool
+	)
+
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(context.Background())
+
+	err = tx.QueryRow(context.Background(),
+		`SELECT t.deleted_at, tp.deleted_at IS NOT NULL FROM forum.threads t JOIN forum.topics tp ON tp.id = t.topic_id
+		WHERE t.id=$1 AND t.deleted_at IS NOT NULL AND ($2::UUID IS NULL OR t.deleted_by=$2)`,
+		id, restoredBy,
+	).Scan(&deletedAt, &topicTrashed)
+	if err != nil {
+		return err
+	}
+	if topicTrashed {
+		return r.topicTrashedErr
+	}
+
+	change := auditChange{actorID: restoredBy, action: "restore", table: "forum.threads", targetType: "threads", targetID: id}
+	err = change.snapshot(tx)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(context.Background(),
+		`UPDATE forum.messages SET deleted_at=NULL, deleted_by=NULL WHERE thread_id=$1 AND deleted_at=$2`,
+		id, deletedAt,
+	)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(context.Background(),
+		`UPDATE forum.threads SET deleted_at=NULL, deleted_by=NULL WHERE id=$1`, id,
+	)
+	if err != nil {
+		return err
+	}
+	err = change.record(tx)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(context.Background())
+}
+
+// Moderators manage threads in every topic and pass a nil managerID, other users only in topics
+// they own or moderate, see forum.moderates_topic
+func managesTopic(tx pgx.Tx, managerID *uuid.UUID, topicID int) (bool, error) {
+	if managerID == nil {
+		return true, nil
+	}
+	var manages bool
+	err := tx.QueryRow(context.Background(),
+		`SELECT EXISTS (SELECT 1 FROM forum.topics WHERE id = $1 AND user_id = $2) OR forum.moderates_topic($1, $2)`,
+		topicID, managerID,
+	).Scan(&manages)
+	return manages, err
+}
+
+// Moderators of the category of hidden content may view it, see moderatesContent
+func (r *PsqlForumRepository) ModeratesContent(targetType string, targetID int, userID uuid.UUID) (bool, error) {
+	return moderatesContent(r.databaseClient, targetType, targetID, userID)
+}
+
+// Applies the operation to the thread, increments its version and records the operation.
+// The operation must be meant for the current version of the thread, see managesTopic for managerID
+func (r *PsqlForumRepository) CreateThreadOperation(id int, op models.ThreadOperation, managerID *uuid.UUID) (*models.ThreadOperation, error) {
+	var (
+		topicID    int
+		version    int
+		manages    bool
+		demoThread bool
+		splitBy    *uuid.UUID
+	)
+
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(context.Background())
+
+	err = tx.QueryRow(context.Background(),
+		`SELECT t.topic_id, t.version, EXISTS (SELECT 1 FROM demo.demos d WHERE d.thread_id = t.id)
+		FROM forum.threads t
+		WHERE t.id = $1 AND t.deleted_at IS NULL
+		FOR UPDATE OF t`,
+		id,
+	).Scan(&topicID, &version, &demoThread)
+	if err != nil {
+		return nil, err
+	}
+	manages, err = managesTopic(tx, managerID, topicID)
+	if err != nil {
+		return nil, err
+	}
+	if !manages {
+		return nil, r.threadForbiddenErr
+	}
+	if version != *op.Version {
+		return nil, r.conflictErr
+	}
+
+	change := auditChange{actorID: op.UserID, action: *op.Operation, table: "forum.threads", targetType: "threads", targetID: id}
+	err = change.snapshot(tx)
+	if err != nil {
+		return nil, err
+	}
+	switch *op.Operation {
+	case "pin":
+		_, err = tx.Exec(context.Background(), `UPDATE forum.threads SET pinned_at=COALESCE(pinned_at, NOW()) WHERE id = $1`, id)
+	case "unpin":
+		_, err = tx.Exec(context.Background(), `UPDATE forum.threads SET pinned_at=NULL WHERE id = $1`, id)
+	case "lock":
+		_, err = tx.Exec(context.Background(), `UPDATE forum.threads SET locked_at=COALESCE(locked_at, NOW()) WHERE id = $1`, id)
+	case "unlock":
+		_, err = tx.Exec(context.Background(), `UPDATE forum.threads SET locked_at=NULL WHERE id = $1`, id)
+	case "move":
+		if *op.TopicID == topicID {
+			return nil, r.threadOperationErr
+		}
+		err = tx.QueryRow(context.Background(),
+			`SELECT id FROM forum.topics WHERE id = $1 AND deleted_at IS NULL`, op.TopicID,
+		).Scan(op.TopicID)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		_, err = r.enforcer.RemovePermissionsForObject(fmt.Sprintf("threads/%v", thread), "PATCH")
+		manages, err = managesTopic(tx, managerID, *op.TopicID)
 		if err != nil {
-			return err
+			return nil, err
+		}
+		if !manages {
+			return nil, r.threadForbiddenErr
 		}
-		_, err = r.enforcer.RemovePermissionsForObject(fmt.Sprintf("threads/%v", thread), "DELETE")
+		op.FromTopicID = &topicID
+		_, err = tx.Exec(context.Background(), `UPDATE forum.threads SET topic_id=$2 WHERE id = $1`, id, op.TopicID)
+	case "merge":
+		if *op.TargetThreadID == id {
+			return nil, r.threadOperationErr
+		}
+		// The merged thread goes to the trash, which would leave its demo without a thread
+		if demoThread {
+			return nil, r.demoThreadErr
+		}
+		var targetTopicID int
+		err = tx.QueryRow(context.Background(),
+			`SELECT topic_id FROM forum.threads WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`,
+			op.TargetThreadID,
+		).Scan(&targetTopicID)
 		if err != nil {
-			return err
+			return nil, err
+		}
+		manages, err = managesTopic(tx, managerID, targetTopicID)
+		if err != nil {
+			return nil, err
+		}
+		if !manages {
+			return nil, r.threadForbiddenErr
+		}
+		// Messages in the trash move along, so they are restored into the thread they ended up in
+		_, err = tx.Exec(context.Background(), `UPDATE forum.messages SET thread_id=$2 WHERE thread_id = $1`, id, op.TargetThreadID)
+		if err != nil {
+			return nil, err
+		}
+		_, err = tx.Exec(context.Background(), `UPDATE forum.threads SET version=version+1 WHERE id = $1`, op.TargetThreadID)
+		if err != nil {
+			return nil, err
+		}
+		_, err = tx.Exec(context.Background(),
+			`UPDATE forum.threads SET deleted_at=NOW(), deleted_by=$2 WHERE id = $1`, id, op.UserID,
+		)
+	case "split":
+		var count int
+		err = tx.QueryRow(context.Background(),
+			`SELECT COUNT(*) FROM forum.messages WHERE thread_id = $1 AND id = ANY($2) AND deleted_at IS NULL`,
+			id, op.MessageIDs,
+		).Scan(&count)
+		if err != nil {
+			return nil, err
 		}
+		if count != len(*op.MessageIDs) {
+			return nil, r.threadOperationErr
+		}
+		// The new thread belongs to the author of its first message and keeps the topic and tags
+		err = tx.QueryRow(context.Background(),
+			`INSERT INTO forum.threads
+				(title, user_id, topic_id, tags)
+			SELECT $2, m.user_id, t.topic_id, t.tags
+			FROM forum.threads t, forum.messages m
+			WHERE t.id = $1 AND m.id = (SELECT MIN(id) FROM forum.messages WHERE id = ANY($3))
+			RETURNING id, user_id`,
+			id, op.Title, op.MessageIDs,
+		).Scan(&op.TargetThreadID, &splitBy)
+		if err != nil {
+			return nil, err
+		}
+		// Replies that are split from their parent lose it, see forum.keep_replies_in_thread
+		_, err = tx.Exec(context.Background(),
+			`UPDATE forum.messages SET thread_id=$2 WHERE id = ANY($1)`, op.MessageIDs, op.TargetThreadID,
+		)
+	default:
+		return nil, r.threadOperationErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.QueryRow(context.Background(),
+		`UPDATE forum.threads SET version=version+1 WHERE id = $1 RETURNING version`, id,
+	).Scan(&op.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.QueryRow(context.Background(),
+		`INSERT INTO forum.thread_operations
+		(thread_id, user_id, operation, version, from_topic_id, topic_id, target_thread_id, message_ids, title, reason)
+		VALUES
+		($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING
+		(id, thread_id, user_id, operation, version, from_topic_id, topic_id, target_thread_id, message_ids, title, reason, created_at)`,
+		id, op.UserID, op.Operation, op.Version, op.FromTopicID, op.TopicID, op.TargetThreadID, op.MessageIDs, op.Title, op.Reason,
+	).Scan(&op)
+	if err != nil {
+		return nil, err
+	}
+	err = change.record(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	if splitBy != nil {
+		_, err = r.enforcer.AddPermissions(splitBy.String(), fmt.Sprintf("threads/%v", *op.TargetThreadID), "PATCH")
+		if err != nil {
+			return nil, err
+		}
+		_, err = r.enforcer.AddPermissions(splitBy.String(), fmt.Sprintf("threads/%v", *op.TargetThreadID), "DELETE")
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &op, nil
+}
+
+// Returns the operations on the thread, including merges into it and splits from it, oldest first
+func (r *PsqlForumRepository) FindThreadOperations(threadID int) (*[]models.ThreadOperation, error) {
+	var ops []models.ThreadOperation
+
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(context.Background(),
+		`SELECT (id, thread_id, user_id, operation, version, from_topic_id, topic_id, target_thread_id, message_ids, title, reason, created_at)
+		FROM forum.thread_operations WHERE thread_id = $1 OR target_thread_id = $1 ORDER BY id`,
+		threadID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var op models.ThreadOperation
+		err = rows.Scan(&op)
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, op)
 	}
 	err = rows.Err()
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+	if len(ops) == 0 {
+		return nil, r.NotFoundErr()
+	}
+	return &ops, nil
 }
 
 func (r *PsqlForumRepository) CreateMessage(message models.Message) (*models.Message, error) {
+	var locked bool
+
+	mentions := parseMentions(message.Body)
+	if len(mentions) > maxMentions {
+		return nil, r.mentionsErr
+	}
+
 	conn, err := r.databaseClient.AcquireConn()
 	if err != nil {
 		return nil, err
@@ -386,17 +1279,62 @@ func (r *PsqlForumRepository) CreateMessage(message models.Message) (*models.Mes
 	defer conn.Release()
 
 	err = conn.QueryRow(context.Background(),
+		`SELECT locked_at IS NOT NULL FROM forum.threads WHERE id = $1 AND deleted_at IS NULL`, message.ThreadID,
+	).Scan(&locked)
+	if err != nil {
+		return nil, err
+	}
+	if locked {
+		return nil, r.threadLockedErr
+	}
+
+	if message.ReplyTo != nil {
+		var parentBody string
+		err = conn.QueryRow(context.Background(),
+			`SELECT body FROM forum.messages
+			WHERE id = $1 AND thread_id = $2 AND deleted_at IS NULL AND hidden_at IS NULL`,
+			message.ReplyTo, message.ThreadID,
+		).Scan(&parentBody)
+		if err != nil {
+			if err == r.databaseClient.ErrNoRows() {
+				return nil, r.replyErr
+			}
+			return nil, err
+		}
+		if message.Quote != nil && !strings.Contains(parentBody, *message.Quote) {
+			return nil, r.quoteErr
+		}
+	}
+
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(context.Background())
+
+	err = tx.QueryRow(context.Background(),
 		`INSERT INTO forum.messages
-		(thread_id, user_id, title, body, tags) 
+		(thread_id, user_id, title, body, tags, reply_to, quote, body_html) 
 		VALUES
-		($1, $2, $3, $4, $5)
+		($1, $2, $3, $4, $5, $6, $7, $8)
 		RETURNING
-		(id, thread_id, user_id, title, body, tags, created_at, updated_at, upvotes, downvotes, rating, views)`,
-		message.ThreadID, message.UserID, message.Title, message.Body, message.Tags,
+		(id, thread_id, user_id, title, body, tags, created_at, updated_at, upvotes, downvotes, rating, views,
+		hidden_at, reply_to, quote, replies, body_html, edited_at, edited_by, revisions)`,
+		message.ThreadID, message.UserID, message.Title, message.Body, message.Tags, message.ReplyTo, message.Quote,
+		message.BodyHTML,
 	).Scan(&message)
 	if err != nil {
 		return nil, err
 	}
+	change := auditChange{actorID: message.UserID, action: "create", table: "forum.messages", targetType: "messages", targetID: *message.ID}
+	err = change.record(tx)
+	if err != nil {
+		return nil, err
+	}
+	err = tx.Commit(context.Background())
+	if err != nil {
+		return nil, err
+	}
 
 	_, err = r.enforcer.AddPermissions(message.UserID.String(), fmt.Sprintf("messages/%v", *message.ID), "PATCH")
 	if err != nil {
@@ -406,9 +1344,20 @@ func (r *PsqlForumRepository) CreateMessage(message models.Message) (*models.Mes
 	if err != nil {
 		return nil, err
 	}
-	return &message, nil
+
+	err = r.saveMentions(conn.Conn(), *message.ID, mentions)
+	if err != nil {
+		return nil, err
+	}
+	messages := []models.Message{message}
+	err = r.findMentions(conn.Conn(), messages)
+	if err != nil {
+		return nil, err
+	}
+	return &messages[0], nil
 }
 
+// Messages of deleted threads are not found. Whether the thread is hidden is left to the caller, see FindThreadVisibility
 func (r *PsqlForumRepository) FindMessageByID(id int) (*models.Message, error) {
 	var message models.Message
 	conn, err := r.databaseClient.AcquireConn()
@@ -420,19 +1369,30 @@ func (r *PsqlForumRepository) FindMessageByID(id int) (*models.Message, error) {
 	err = conn.QueryRow(context.Background(),
 		`UPDATE forum.messages SET 
 		views=views+1
-		WHERE id = $1
+		WHERE id = $1 AND deleted_at IS NULL AND `+threadOfMessageVisible("TRUE")+`
 		RETURNING
-		(id, thread_id, user_id, title, body, tags, created_at, updated_at, upvotes, downvotes, rating, views)`,
+		(id, thread_id, user_id, title, body, tags, created_at, updated_at, upvotes, downvotes, rating, views,
+		hidden_at, reply_to, quote, replies, body_html, edited_at, edited_by, revisions)`,
 		id,
 	).Scan(&message)
 	if err != nil {
 		return nil, err
 	}
-	return &message, nil
+
+	messages := []models.Message{message}
+	err = r.findMentions(conn.Conn(), messages)
+	if err != nil {
+		return nil, err
+	}
+	return &messages[0], nil
 }
 
-func (r *PsqlForumRepository) FindMessages(keywords []string, limit uint64, order string) (*[]models.Message, error) {
-	var messages []models.Message
+// Returns the page of visible messages matching the keywords by text or tag, all of them without keywords
+func (r *PsqlForumRepository) FindMessages(keywords []string, page models.PageQuery, order string) (*models.Page[models.Message], error) {
+	key, ok := contentSortKeys[order]
+	if !ok {
+		order, key = "newest-updated", contentSortKeys["newest-updated"]
+	}
 
 	conn, err := r.databaseClient.AcquireConn()
 	if err != nil {
@@ -440,102 +1400,381 @@ func (r *PsqlForumRepository) FindMessages(keywords []string, limit uint64, orde
 	}
 	defer conn.Release()
 
-	var rows pgx.Rows
+	q := pageQuery{
+		source: `SELECT * FROM forum.messages WHERE deleted_at IS NULL AND hidden_at IS NULL AND ` + threadOfMessageVisible("FALSE"),
+		columns: `id, thread_id, user_id, title, body, tags, created_at, updated_at, upvotes, downvotes, rating, views,
+			hidden_at, reply_to, quote, replies, body_html, edited_at, edited_by, revisions`,
+		order:  order,
+		key:    key,
+		idCast: "INTEGER",
+	}
 	if len(keywords) != 0 {
-		query := `SELECT (id, thread_id, user_id, title, body, tags, created_at, updated_at, upvotes, downvotes, rating, views) 
-			FROM
-				((SELECT id, thread_id, user_id, title, body, tags, created_at, updated_at, upvotes, downvotes, rating, views
-				FROM forum.messages
-				WHERE message_ts @@ to_tsquery_multilang($1))
-			UNION
-				(SELECT id, thread_id, user_id, title, body, tags, created_at, updated_at, upvotes, downvotes, rating, views
-				FROM forum.messages
-				WHERE tags && ($2) COLLATE case_insensitive))`
-
-		switch order {
-		case "newest-updated":
-			query = query + ` ORDER BY updated_at DESC`
-		case "highest-rated":
-			query = query + ` ORDER BY rating DESC`
-		case "most-views":
-			query = query + ` ORDER BY views DESC`
-		default:
-			query = query + ` ORDER BY updated_at DESC`
-		}
-		if limit != 0 {
-			query = query + fmt.Sprintf(` LIMIT %v`, limit)
-		}
-		rows, err = conn.Query(context.Background(),
-			query, strings.Join(keywords, " | "), keywords,
+		q.source = q.source + ` AND (message_ts @@ to_tsquery_multilang($1) OR tags::TEXT[] && tag.canonical($2))`
+		q.args = []any{strings.Join(keywords, " | "), keywords}
+	}
+
+	messages, err := queryPage[models.Message](conn, q, page)
+	if err != nil {
+		return nil, err
+	}
+	if len(messages.Items) == 0 {
+		return nil, r.NotFoundErr()
+	}
+
+	err = r.findMentions(conn.Conn(), messages.Items)
+	if err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// Returns the visible messages of the thread, oldest first. Flat listings give each reply the context of
+// its parent, nested listings put replies under their parent. Deleted and hidden messages with visible
+// replies are kept as tombstones in nested listings, which are paged by their top-level messages.
+// Views are counted on the first page only. Deleted threads have no messages, hidden ones are left to the caller
+func (r *PsqlForumRepository) FindMessagesByThreadID(thread_id int, nested bool, page models.PageQuery) (*models.Page[models.Message], error) {
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	if page.Cursor == "" {
+		_, err = conn.Exec(context.Background(),
+			`UPDATE forum.threads SET 
+			views=views+1
+			WHERE id = $1 AND deleted_at IS NULL`, thread_id,
 		)
 		if err != nil {
 			return nil, err
 		}
-	} else {
-		query := `SELECT (id, thread_id, user_id, title, body, tags, created_at, updated_at, upvotes, downvotes, rating, views)
-			FROM forum.messages`
+	}
 
-		switch order {
-		case "newest-updated":
-			query = query + ` ORDER BY updated_at DESC`
-		case "highest-rated":
-			query = query + ` ORDER BY rating DESC`
-		case "most-views":
-			query = query + ` ORDER BY views DESC`
-		default:
-			query = query + ` ORDER BY updated_at DESC`
-		}
-		if limit != 0 {
-			query = query + fmt.Sprintf(` LIMIT %v`, limit)
-		}
-		rows, err = conn.Query(context.Background(), query)
+	q := pageQuery{
+		source: `SELECT * FROM forum.messages WHERE thread_id=$1 AND deleted_at IS NULL AND hidden_at IS NULL
+			AND ` + threadOfMessageVisible("TRUE"),
+		args:      []any{thread_id},
+		columns:   messageColumns,
+		order:     threadOrder,
+		key:       threadSortKey,
+		idCast:    "INTEGER",
+		ascending: true,
+	}
+	if nested {
+		q.source = `SELECT * FROM forum.messages WHERE thread_id=$1 AND reply_to IS NULL
+			AND (deleted_at IS NULL AND hidden_at IS NULL OR ` + hasVisibleReplies + `)
+			AND ` + threadOfMessageVisible("TRUE")
+	}
+	thread, err := queryPage[models.Message](conn, q, page)
+	if err != nil {
+		return nil, err
+	}
+	if len(thread.Items) == 0 {
+		return nil, r.NotFoundErr()
+	}
+
+	if nested {
+		thread.Items, err = r.findReplies(conn.Conn(), thread.Items)
 		if err != nil {
 			return nil, err
 		}
+		return thread, nil
 	}
+	err = r.findReplyContext(conn.Conn(), thread.Items)
+	if err != nil {
+		return nil, err
+	}
+	err = r.findMentions(conn.Conn(), thread.Items)
+	if err != nil {
+		return nil, err
+	}
+	return thread, nil
+}
+
+// Returns the top-level messages of a page of a nested listing with all their replies under them
+func (r *PsqlForumRepository) findReplies(conn *pgx.Conn, roots []models.Message) ([]models.Message, error) {
+	var (
+		ids      = make([]int, len(roots))
+		messages []models.Message
+		gone     = make(map[int]bool)
+	)
 
+	for i, m := range roots {
+		ids[i] = *m.ID
+	}
+	rows, err := conn.Query(context.Background(),
+		`WITH RECURSIVE tree AS (
+			SELECT * FROM forum.messages WHERE id = ANY($1)
+			UNION ALL
+			SELECT m.* FROM forum.messages m JOIN tree t ON m.reply_to = t.id
+		)
+		SELECT (`+messageColumns+`), deleted_at IS NOT NULL OR hidden_at IS NOT NULL
+		FROM tree ORDER BY created_at, id`,
+		ids,
+	)
+	if err != nil {
+		return nil, err
+	}
 	defer rows.Close()
 	for rows.Next() {
-		var message models.Message
-		err = rows.Scan(&message)
+		var (
+			message models.Message
+			isGone  bool
+		)
+		err = rows.Scan(&message, &isGone)
 		if err != nil {
 			return nil, err
 		}
+		if isGone {
+			gone[*message.ID] = true
+		}
 		messages = append(messages, message)
 	}
 	err = rows.Err()
 	if err != nil {
 		return nil, err
 	}
-	if len(messages) == 0 {
-		return nil, r.NotFoundErr()
+
+	err = r.findMentions(conn, messages)
+	if err != nil {
+		return nil, err
 	}
-	return &messages, nil
+	return nestReplies(messages, gone), nil
 }
 
-func (r *PsqlForumRepository) FindMessagesByThreadID(thread_id int) (*[]models.Message, error) {
-	var messages []models.Message
+// Gives each reply of a page of a flat listing the context of its parent, which may be on another page
+func (r *PsqlForumRepository) findReplyContext(conn *pgx.Conn, messages []models.Message) error {
+	var (
+		ids     []int
+		parents = make(map[int]models.Message)
+	)
 
-	conn, err := r.databaseClient.AcquireConn()
+	for _, m := range messages {
+		if m.ReplyTo != nil {
+			ids = append(ids, *m.ReplyTo)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	rows, err := conn.Query(context.Background(),
+		`SELECT id, user_id, body FROM forum.messages
+		WHERE id = ANY($1) AND deleted_at IS NULL AND hidden_at IS NULL`,
+		ids,
+	)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var parent models.Message
+		err = rows.Scan(&parent.ID, &parent.UserID, &parent.Body)
+		if err != nil {
+			return err
+		}
+		parents[*parent.ID] = parent
+	}
+	err = rows.Err()
+	if err != nil {
+		return err
 	}
-	defer conn.Release()
 
-	_, err = conn.Exec(context.Background(),
-		`UPDATE forum.threads SET 
-		views=views+1
-		WHERE id = $1`, thread_id,
+	withReplyContext(messages, parents)
+	return nil
+}
+
+// Sets the context of the parent of each reply. Parents missing from the visible ones are shown as deleted
+func withReplyContext(messages []models.Message, visible map[int]models.Message) {
+	for i := range messages {
+		m := &messages[i]
+		if m.ReplyTo == nil {
+			continue
+		}
+		parent, ok := visible[*m.ReplyTo]
+		m.Parent = &models.ReplyContext{ID: m.ReplyTo, Deleted: !ok}
+		if ok {
+			m.Parent.UserID = parent.UserID
+			m.Parent.Excerpt = m.Quote
+			if m.Parent.Excerpt == nil && parent.Body != nil {
+				m.Parent.Excerpt = excerpt(*parent.Body)
+			}
+		}
+	}
+}
+
+// Returns the top-level messages with their replies nested under them. Messages are expected oldest
+// first, so parents come before their replies. Gone messages are turned into tombstones and left out
+// unless they have visible replies
+func nestReplies(messages []models.Message, gone map[int]bool) []models.Message {
+	var (
+		children = make(map[int][]int, len(messages))
+		byID     = make(map[int]*models.Message, len(messages))
+		roots    []int
+		build    func(id int) (models.Message, bool)
 	)
-	if err != nil {
-		return nil, err
+
+	for i := range messages {
+		m := &messages[i]
+		byID[*m.ID] = m
+		if m.ReplyTo != nil {
+			children[*m.ReplyTo] = append(children[*m.ReplyTo], *m.ID)
+		} else {
+			roots = append(roots, *m.ID)
+		}
+	}
+
+	build = func(id int) (models.Message, bool) {
+		var replies []models.Message
+
+		m := *byID[id]
+		for _, child := range children[id] {
+			if reply, ok := build(child); ok {
+				replies = append(replies, reply)
+			}
+		}
+		if gone[id] {
+			if len(replies) == 0 {
+				return m, false
+			}
+			deleted := true
+			m = models.Message{
+				ID: m.ID, ThreadID: m.ThreadID, CreatedAt: m.CreatedAt, ReplyTo: m.ReplyTo, ReplyCount: m.ReplyCount,
+				Deleted: &deleted,
+			}
+		}
+		if len(replies) != 0 {
+			m.Replies = &replies
+		}
+		return m, true
+	}
+
+	var nested []models.Message
+	for _, id := range roots {
+		if m, ok := build(id); ok {
+			nested = append(nested, m)
+		}
+	}
+	return nested
+}
+
+// Returns the start of the body, cut at replyExcerptLength runes
+func excerpt(body string) *string {
+	runes := []rune(body)
+	if len(runes) > replyExcerptLength {
+		body = strings.TrimSpace(string(runes[:replyExcerptLength])) + "…"
 	}
+	return &body
+}
+
+// Returns the distinct usernames mentioned in the body, nil when there is no body.
+// Usernames differing only in case are the same mention
+func parseMentions(body *string) []string {
+	if body == nil {
+		return nil
+	}
+
+	var (
+		mentions = []string{}
+		seen     = make(map[string]bool)
+	)
+	for _, m := range mentionPattern.FindAllStringSubmatch(codePattern.ReplaceAllString(*body, " "), -1) {
+		// A mention at the end of a sentence
+		username := strings.TrimRight(m[1], ".-")
+		if key := strings.ToLower(username); !seen[key] {
+			seen[key] = true
+			mentions = append(mentions, username)
+		}
+	}
+	return mentions
+}
+
+// Replaces the mentions of the message. A username matching several users case-insensitively
+// mentions the one matching it exactly, if any. Usernames of no user are ignored
+func (r *PsqlForumRepository) saveMentions(conn *pgx.Conn, messageID int, usernames []string) error {
+	_, err := conn.Exec(context.Background(),
+		`WITH mentioned AS (
+			SELECT DISTINCT ON (n.username) u.id
+			FROM unnest($2::VARCHAR[]) AS n (username)
+			JOIN "user".users u ON u.username = n.username COLLATE case_insensitive
+			ORDER BY n.username, u.username = n.username DESC
+		), removed AS (
+			DELETE FROM forum.mentions WHERE message_id = $1 AND user_id NOT IN (SELECT id FROM mentioned)
+		)
+		INSERT INTO forum.mentions (message_id, user_id) SELECT $1, id FROM mentioned
+		ON CONFLICT DO NOTHING`,
+		messageID, usernames,
+	)
+	return err
+}
+
+// Sets the users mentioned in each message
+func (r *PsqlForumRepository) findMentions(conn *pgx.Conn, messages []models.Message) error {
+	var (
+		ids      = make([]int, len(messages))
+		mentions = make(map[int][]models.UserRef)
+	)
 
+	for i, m := range messages {
+		ids[i] = *m.ID
+	}
 	rows, err := conn.Query(context.Background(),
-		`SELECT (id, thread_id, user_id, title, body, tags, created_at, updated_at, upvotes, downvotes, rating, views)
-		FROM forum.messages WHERE thread_id=$1`,
-		thread_id,
+		`SELECT mn.message_id, u.id, u.username FROM forum.mentions mn
+		JOIN "user".users u ON u.id = mn.user_id
+		WHERE mn.message_id = ANY($1)
+		ORDER BY mn.message_id, u.username`,
+		ids,
 	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			messageID int
+			user      models.UserRef
+		)
+		err = rows.Scan(&messageID, &user.ID, &user.Username)
+		if err != nil {
+			return err
+		}
+		mentions[messageID] = append(mentions[messageID], user)
+	}
+	err = rows.Err()
+	if err != nil {
+		return err
+	}
+
+	for i := range messages {
+		if m, ok := mentions[*messages[i].ID]; ok {
+			messages[i].Mentions = &m
+		}
+	}
+	return nil
+}
+
+// Returns the visible messages mentioning the user, newest mention first
+func (r *PsqlForumRepository) FindMentions(userID uuid.UUID, limit uint64) (*[]models.Message, error) {
+	var messages []models.Message
+
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	query := `SELECT (m.id, m.thread_id, m.user_id, m.title, m.body, m.tags, m.created_at, m.updated_at, m.upvotes,
+		m.downvotes, m.rating, m.views, m.hidden_at, m.reply_to, m.quote, m.replies, m.body_html, m.edited_at,
+		m.edited_by, m.revisions)
+		FROM forum.mentions mn
+		JOIN forum.messages m ON m.id = mn.message_id
+		JOIN forum.threads t ON t.id = m.thread_id
+		WHERE mn.user_id = $1 AND m.deleted_at IS NULL AND m.hidden_at IS NULL
+			AND t.deleted_at IS NULL AND t.hidden_at IS NULL
+		ORDER BY mn.created_at DESC, m.id DESC`
+	if limit != 0 {
+		query = query + fmt.Sprintf(` LIMIT %v`, limit)
+	}
+	rows, err := conn.Query(context.Background(), query, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -555,88 +1794,132 @@ func (r *PsqlForumRepository) FindMessagesByThreadID(thread_id int) (*[]models.M
 	if len(messages) == 0 {
 		return nil, r.NotFoundErr()
 	}
+
+	err = r.findMentions(conn.Conn(), messages)
+	if err != nil {
+		return nil, err
+	}
 	return &messages, nil
 }
 
+// Mentions are resolved again when the body is updated, only newly mentioned users are notified
 func (r *PsqlForumRepository) UpdateMessage(id int, message models.Message) (*models.Message, error) {
+	mentions := parseMentions(message.Body)
+	if len(mentions) > maxMentions {
+		return nil, r.mentionsErr
+	}
+
 	conn, err := r.databaseClient.AcquireConn()
 	if err != nil {
 		return nil, err
 	}
 	defer conn.Release()
 
-	err = conn.QueryRow(context.Background(),
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(context.Background())
+
+	change := auditChange{actorID: message.EditedBy, action: "update", table: "forum.messages", targetType: "messages", targetID: id}
+	err = change.snapshot(tx)
+	if err != nil {
+		return nil, err
+	}
+	err = tx.QueryRow(context.Background(),
 		`UPDATE forum.messages SET 
 		thread_id=COALESCE($1, thread_id), user_id=COALESCE($2, user_id), title=COALESCE($3, title), 
 		body=COALESCE($4, body), tags=COALESCE($5, tags), updated_at=NOW(),
-		upvotes=COALESCE($6, upvotes), downvotes=COALESCE($7, downvotes)
-		WHERE id = $8
+		upvotes=COALESCE($6, upvotes), downvotes=COALESCE($7, downvotes), body_html=COALESCE($9, body_html),
+		edited_by=$10
+		WHERE id = $8 AND deleted_at IS NULL
 		RETURNING
-		(id, thread_id, user_id, title, body, tags, created_at, updated_at, upvotes, downvotes, rating, views)`,
+		(id, thread_id, user_id, title, body, tags, created_at, updated_at, upvotes, downvotes, rating, views,
+		hidden_at, reply_to, quote, replies, body_html, edited_at, edited_by, revisions)`,
 		message.ThreadID, message.UserID, message.Title, message.Body,
-		message.Tags, message.Upvotes, message.Downvotes, id,
+		message.Tags, message.Upvotes, message.Downvotes, id, message.BodyHTML, message.EditedBy,
 	).Scan(&message)
 	if err != nil {
 		return nil, err
 	}
-	return &message, nil
-}
-
-func (r *PsqlForumRepository) DeleteMessage(id int) error {
-	conn, err := r.databaseClient.AcquireConn()
+	err = change.record(tx)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	err = tx.Commit(context.Background())
+	if err != nil {
+		return nil, err
 	}
-	defer conn.Release()
 
-	ct, err := conn.Exec(context.Background(), `DELETE FROM forum.messages WHERE id=$1`, id)
-	if ct.RowsAffected() == 0 {
+	if mentions != nil {
+		err = r.saveMentions(conn.Conn(), id, mentions)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		return r.databaseClient.ErrNoRows()
 	}
-	_, err = r.enforcer.RemovePermissionsForObject(fmt.Sprintf("messages/%v", id), "PATCH")
+	messages := []models.Message{message}
+	err = r.findMentions(conn.Conn(), messages)
+	if err != nil {
+		return nil, err
+	}
+	return &messages[0], nil
+}
+
+// Moves the message to the trash. Its policies are removed when the trash is purged
+func (r *PsqlForumRepository) DeleteMessage(id int, deletedBy uuid.UUID) error {
+	conn, err := r.databaseClient.AcquireConn()
 	if err != nil {
 		return err
 	}
-	_, err = r.enforcer.RemovePermissionsForObject(fmt.Sprintf("messages/%v", id), "DELETE")
+	defer conn.Release()
+
+	change := auditChange{actorID: &deletedBy, action: "delete", table: "forum.messages", targetType: "messages", targetID: id}
+	ct, err := change.exec(conn,
+		`UPDATE forum.messages SET deleted_at=NOW(), deleted_by=$2 WHERE id=$1 AND deleted_at IS NULL`,
+		id, deletedBy,
+	)
 	if err != nil {
 		return err
 	}
+	if ct.RowsAffected() == 0 {
+		return r.databaseClient.ErrNoRows()
+	}
 	return nil
 }
 
-func (r *PsqlForumRepository) DeleteMessagesOfThread(threadID int) error {
+// Restores the message from the trash. A nil restoredBy skips the check of who deleted it
+func (r *PsqlForumRepository) RestoreMessage(id int, restoredBy *uuid.UUID) error {
 	conn, err := r.databaseClient.AcquireConn()
 	if err != nil {
 		return err
 	}
 	defer conn.Release()
 
-	rows, err := conn.Query(context.Background(), `SELECT id FROM forum.messages WHERE thread_id = $1`, threadID)
+	change := auditChange{actorID: restoredBy, action: "restore", table: "forum.messages", targetType: "messages", targetID: id}
+	ct, err := change.exec(conn,
+		`UPDATE forum.messages SET deleted_at=NULL, deleted_by=NULL
+		WHERE id=$1 AND deleted_at IS NOT NULL AND ($2::UUID IS NULL OR deleted_by=$2)
+		AND thread_id IN (SELECT id FROM forum.threads WHERE deleted_at IS NULL)`,
+		id, restoredBy,
+	)
 	if err != nil {
 		return err
 	}
-	defer rows.Close()
-	for rows.Next() {
-		var message int
-		err = rows.Scan(&message)
-		if err != nil {
-			return err
-		}
-		_, err = r.enforcer.RemovePermissionsForObject(fmt.Sprintf("messages/%v", message), "PATCH")
-		if err != nil {
-			return err
-		}
-		_, err = r.enforcer.RemovePermissionsForObject(fmt.Sprintf("messages/%v", message), "DELETE")
-		if err != nil {
-			return err
-		}
+	if ct.RowsAffected() != 0 {
+		return nil
 	}
-	err = rows.Err()
+
+	var threadTrashed bool
+	err = conn.QueryRow(context.Background(),
+		`SELECT EXISTS (SELECT 1 FROM forum.messages m JOIN forum.threads t ON t.id = m.thread_id
+		WHERE m.id=$1 AND m.deleted_at IS NOT NULL AND ($2::UUID IS NULL OR m.deleted_by=$2) AND t.deleted_at IS NOT NULL)`,
+		id, restoredBy,
+	).Scan(&threadTrashed)
 	if err != nil {
 		return err
 	}
-	return nil
+	if threadTrashed {
+		return r.threadTrashedErr
+	}
+	return r.databaseClient.ErrNoRows()
 }
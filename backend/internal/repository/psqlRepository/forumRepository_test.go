@@ -8,9 +8,12 @@ import (
 	"gamehangar/internal/domain/models"
 	// "gamehangar/pkg/ternMigrate"
 	// "os"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
 	// "github.com/joho/godotenv"
 	"github.com/stretchr/testify/assert"
 )
@@ -52,6 +55,17 @@ func init() {
 	}
 }
 
+// Category settings of a topic created without any
+func withCategoryDefaults(tp models.Topic, slug string) models.Topic {
+	subscribers, sortOrder, minAccountAge, access := 0, 0, 0, "everyone"
+	tp.Subscribers = &subscribers
+	tp.Slug = &slug
+	tp.SortOrder = &sortOrder
+	tp.ThreadAccess = &access
+	tp.MinAccountAgeDays = &minAccountAge
+	return tp
+}
+
 func TestCreateTopic(t *testing.T) {
 	r := PsqlForumRepository{databaseClient: testDBClient, enforcer: testEnforcer, conflictErr: errors.New("Record conflict!")}
 	_, err := r.CreateTopic(topic)
@@ -74,7 +88,7 @@ func TestFindTopicByIDNoRows(t *testing.T) {
 
 func TestFindTopics(t *testing.T) {
 	r := PsqlForumRepository{databaseClient: testDBClient, enforcer: testEnforcer, conflictErr: errors.New("Record conflict!")}
-	_, err := r.FindTopics()
+	_, err := r.FindTopics(false)
 	assert.NoError(t, err)
 }
 
@@ -84,7 +98,7 @@ func TestUpdateTopic(t *testing.T) {
 	resultTopic, err := r.UpdateTopic(topicID, topicUpdated)
 	assert.NoError(t, err)
 
-	modifiedTopic := topic
+	modifiedTopic := withCategoryDefaults(topic, "test") // Slug is kept on rename
 	modifiedTopic.ID = &topicID
 	modifiedTopic.Name = &topicNameUpdated
 	newVersion := *topicUpdated.Version + 1
@@ -97,7 +111,7 @@ func TestUpdateTopicMultiple(t *testing.T) {
 	r := PsqlForumRepository{databaseClient: testDBClient, enforcer: testEnforcer, conflictErr: errors.New("Record conflict!")}
 	topicID = 1
 
-	modifiedTopic := topic // Manual update
+	modifiedTopic := withCategoryDefaults(topic, "test") // Manual update
 	modifiedTopic.ID = &topicID
 
 	for i := 2; i < 6; i++ {
@@ -128,7 +142,7 @@ func TestUpdateTopicConflict(t *testing.T) {
 
 func TestDeleteTopic(t *testing.T) {
 	r := PsqlForumRepository{databaseClient: testDBClient, enforcer: testEnforcer, conflictErr: errors.New("Record conflict!")}
-	err := r.DeleteTopic(topicID)
+	err := r.DeleteTopic(topicID, userID)
 	assert.NoError(t, err)
 }
 
@@ -159,7 +173,7 @@ func TestFindThreadByIDNoRows(t *testing.T) {
 
 func TestFindThreads(t *testing.T) {
 	r := PsqlForumRepository{databaseClient: testDBClient, enforcer: testEnforcer}
-	_, err := r.FindThreads(nil, 0, "")
+	_, err := r.FindThreads(nil, models.ListFilter{}, models.PageQuery{}, "")
 	assert.NoError(t, err)
 }
 
@@ -190,18 +204,18 @@ func TestFindThreadsByQuery(t *testing.T) {
 		resultThread, err := r.CreateThread(th)
 		assert.NoError(t, err)
 
-		queryThreads, err := r.FindThreads([]string{q}, 0, "highest-rated")
+		queryThreads, err := r.FindThreads([]string{q}, models.ListFilter{}, models.PageQuery{}, "highest-rated")
 		t.Log(queryThreads)
 		if assert.NoError(t, err) {
-			queriedThread := *queryThreads
+			queriedThread := queryThreads.Items
 			assert.Equal(t, resultThread.Title, queriedThread[0].Title)
 		}
 	}
 
 	// Try to query both and check ordering
-	threads, err := r.FindThreads([]string{"cheeseboiger"}, 0, "newest-updated")
+	threads, err := r.FindThreads([]string{"cheeseboiger"}, models.ListFilter{}, models.PageQuery{}, "newest-updated")
 	if assert.NoError(t, err) {
-		th := *threads
+		th := threads.Items
 		assert.Len(t, th, 2)
 		var timeOrder, timeOrderExpected []time.Time
 		timeOrderExpected = []time.Time{*th[0].UpdatedAt, *th[1].UpdatedAt}
@@ -215,10 +229,43 @@ func TestFindThreadsByQuery(t *testing.T) {
 		)
 	}
 	// Query with limit
-	threads, err = r.FindThreads([]string{"cheeseboiger"}, 1, "most-views")
+	threads, err = r.FindThreads([]string{"cheeseboiger"}, models.ListFilter{}, models.PageQuery{Limit: 1}, "most-views")
 	if assert.NoError(t, err) {
-		assert.Len(t, *threads, 1)
+		assert.Len(t, threads.Items, 1)
+	}
+}
+
+func TestFindThreadsPages(t *testing.T) {
+	r := PsqlForumRepository{databaseClient: testDBClient, enforcer: testEnforcer}
+
+	first, err := r.FindThreads([]string{"cheeseboiger"}, models.ListFilter{}, models.PageQuery{Limit: 1}, "most-views")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, int64(2), first.Total)
+	assert.Nil(t, first.Prev)
+	if !assert.NotNil(t, first.Next) {
+		return
+	}
+
+	second, err := r.FindThreads([]string{"cheeseboiger"}, models.ListFilter{}, models.PageQuery{Limit: 1, Cursor: *first.Next}, "most-views")
+	if assert.NoError(t, err) && assert.Len(t, second.Items, 1) {
+		assert.NotEqual(t, *first.Items[0].ID, *second.Items[0].ID)
+		assert.Nil(t, second.Next)
+		if assert.NotNil(t, second.Prev) {
+			back, err := r.FindThreads([]string{"cheeseboiger"}, models.ListFilter{}, models.PageQuery{Limit: 1, Cursor: *second.Prev}, "most-views")
+			if assert.NoError(t, err) {
+				assert.Equal(t, *first.Items[0].ID, *back.Items[0].ID)
+				assert.Nil(t, back.Prev)
+			}
+		}
 	}
+
+	// Cursors only page the order they were made for
+	_, err = r.FindThreads([]string{"cheeseboiger"}, models.ListFilter{}, models.PageQuery{Limit: 1, Cursor: *first.Next}, "newest-updated")
+	assert.Equal(t, r.CursorErr(), err)
+	_, err = r.FindThreads(nil, models.ListFilter{}, models.PageQuery{Cursor: "not-a-cursor"}, "most-views")
+	assert.Equal(t, r.CursorErr(), err)
 }
 
 func TestUpdateThread(t *testing.T) {
@@ -243,8 +290,64 @@ func TestUpdateThread(t *testing.T) {
 
 func TestDeleteThread(t *testing.T) {
 	r := PsqlForumRepository{databaseClient: testDBClient, enforcer: testEnforcer}
-	err := r.DeleteThread(threadID)
+	err := r.DeleteThread(threadID, userID)
 	assert.NoError(t, err)
+	_, err = r.FindThreadByID(threadID)
+	assert.Equal(t, r.NotFoundErr(), err)
+}
+
+func TestRestoreThread(t *testing.T) {
+	r := PsqlForumRepository{databaseClient: testDBClient, enforcer: testEnforcer}
+	otherUser := uuid.New()
+	err := r.RestoreThread(threadID, &otherUser)
+	assert.Equal(t, r.NotFoundErr(), err)
+
+	err = r.RestoreThread(threadID, &userID)
+	if assert.NoError(t, err) {
+		_, err = r.FindThreadByID(threadID)
+		assert.NoError(t, err)
+	}
+}
+
+func TestRestoreThreadTopicTrashed(t *testing.T) {
+	r := PsqlForumRepository{databaseClient: testDBClient, enforcer: testEnforcer, topicTrashedErr: errors.New("Topic of the thread is in the trash, restore it first!")}
+	topicName, threadTitle := "Trashed Topic", "Thread of a trashed topic"
+	tp, err := r.CreateTopic(models.Topic{Name: &topicName, UserID: &userID})
+	if !assert.NoError(t, err) {
+		return
+	}
+	th, err := r.CreateThread(models.Thread{Title: &threadTitle, UserID: &userID, TopicID: tp.ID})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, r.DeleteThread(*th.ID, userID))
+	assert.NoError(t, r.DeleteTopic(*tp.ID, userID))
+
+	// The thread cannot be restored into a topic in the trash
+	assert.Equal(t, r.TopicTrashedErr(), r.RestoreThread(*th.ID, &userID))
+	assert.NoError(t, r.RestoreTopic(*tp.ID, &userID))
+	assert.NoError(t, r.RestoreThread(*th.ID, &userID))
+}
+
+func TestRestoreMessageThreadTrashed(t *testing.T) {
+	r := NewPsqlForumRepository(testDBClient, testEnforcer)
+	threadTitle := "Thread of a trashed message"
+	th, err := r.CreateThread(models.Thread{Title: &threadTitle, UserID: &userID, TopicID: thread.TopicID})
+	if !assert.NoError(t, err) {
+		return
+	}
+	m, err := r.CreateMessage(models.Message{Title: &messageTitle, UserID: &userID, ThreadID: th.ID})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, r.DeleteMessage(*m.ID, userID))
+	assert.NoError(t, r.DeleteThread(*th.ID, userID))
+
+	// The message cannot be restored into a thread in the trash
+	assert.Equal(t, r.ThreadTrashedErr(), r.RestoreMessage(*m.ID, &userID))
+	assert.NoError(t, r.RestoreThread(*th.ID, &userID))
+	assert.NoError(t, r.RestoreMessage(*m.ID, &userID))
+	assert.NoError(t, r.DeleteThread(*th.ID, userID))
 }
 
 func TestCreateMessage(t *testing.T) {
@@ -273,18 +376,365 @@ func TestFindMessageByIDNoRows(t *testing.T) {
 
 func TestFindMessageByThreadID(t *testing.T) {
 	r := PsqlForumRepository{databaseClient: testDBClient, enforcer: testEnforcer}
-	_, err := r.FindMessagesByThreadID(threadID)
+	_, err := r.FindMessagesByThreadID(threadID, false, models.PageQuery{})
 	assert.NoError(t, err)
 }
 
 func TestFindMessageByThreadIDNoRows(t *testing.T) {
 	r := PsqlForumRepository{databaseClient: testDBClient, enforcer: testEnforcer}
-	_, err := r.FindMessagesByThreadID(9000)
+	_, err := r.FindMessagesByThreadID(9000, false, models.PageQuery{})
 	if assert.Error(t, err) {
 		assert.Equal(t, r.NotFoundErr(), err)
 	}
 }
 
+func TestReplyThreading(t *testing.T) {
+	r := NewPsqlForumRepository(testDBClient, testEnforcer)
+	th, err := r.CreateThread(thread)
+	if !assert.NoError(t, err) {
+		return
+	}
+	m := message
+	m.ThreadID = th.ID
+	root, err := r.CreateMessage(m)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	quote := "integration testing"
+	m.ReplyTo, m.Quote = root.ID, &quote
+	reply, err := r.CreateMessage(m)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, *root.ID, *reply.ReplyTo)
+	m.ReplyTo, m.Quote = reply.ID, nil
+	nestedReply, err := r.CreateMessage(m)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	missingQuote := "unit testing"
+	m.ReplyTo, m.Quote = root.ID, &missingQuote
+	_, err = r.CreateMessage(m)
+	assert.Equal(t, r.QuoteErr(), err)
+	m.ThreadID, m.Quote = &threadID, nil
+	_, err = r.CreateMessage(m)
+	assert.Equal(t, r.ReplyErr(), err)
+
+	root, err = r.FindMessageByID(*root.ID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 1, *root.ReplyCount)
+	}
+
+	// The deleted reply stays in place as a tombstone
+	assert.NoError(t, r.DeleteMessage(*reply.ID, userID))
+	nested, err := r.FindMessagesByThreadID(*th.ID, true, models.PageQuery{})
+	if assert.NoError(t, err) && assert.Len(t, nested.Items, 1) && assert.Len(t, *nested.Items[0].Replies, 1) {
+		tombstone := (*nested.Items[0].Replies)[0]
+		assert.True(t, *tombstone.Deleted)
+		assert.Nil(t, tombstone.Body)
+		if assert.Len(t, *tombstone.Replies, 1) {
+			assert.Equal(t, *nestedReply.ID, *(*tombstone.Replies)[0].ID)
+		}
+	}
+	flat, err := r.FindMessagesByThreadID(*th.ID, false, models.PageQuery{})
+	if assert.NoError(t, err) && assert.Len(t, flat.Items, 2) {
+		assert.Nil(t, flat.Items[0].Parent)
+		assert.True(t, flat.Items[1].Parent.Deleted)
+		assert.Nil(t, flat.Items[1].Parent.Excerpt)
+	}
+
+	assert.NoError(t, r.RestoreMessage(*reply.ID, &userID))
+	flat, err = r.FindMessagesByThreadID(*th.ID, false, models.PageQuery{})
+	if assert.NoError(t, err) && assert.Len(t, flat.Items, 3) {
+		assert.Equal(t, quote, *flat.Items[1].Parent.Excerpt)
+		assert.Equal(t, messageBody, *flat.Items[2].Parent.Excerpt)
+	}
+
+	// Replies keep the context of parents on earlier pages
+	page, err := r.FindMessagesByThreadID(*th.ID, false, models.PageQuery{Limit: 2})
+	if assert.NoError(t, err) && assert.NotNil(t, page.Next) {
+		assert.Equal(t, int64(3), page.Total)
+		page, err = r.FindMessagesByThreadID(*th.ID, false, models.PageQuery{Limit: 2, Cursor: *page.Next})
+		if assert.NoError(t, err) && assert.Len(t, page.Items, 1) {
+			assert.Equal(t, *nestedReply.ID, *page.Items[0].ID)
+			assert.Equal(t, messageBody, *page.Items[0].Parent.Excerpt)
+			assert.Nil(t, page.Next)
+		}
+	}
+
+	// Nested pages hold top-level messages with all their replies, a deleted one without visible replies is left out
+	m.ThreadID, m.ReplyTo = th.ID, nil
+	second, err := r.CreateMessage(m)
+	if !assert.NoError(t, err) {
+		return
+	}
+	gone, err := r.CreateMessage(m)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, r.DeleteMessage(*gone.ID, userID))
+	page, err = r.FindMessagesByThreadID(*th.ID, true, models.PageQuery{Limit: 1})
+	if assert.NoError(t, err) && assert.Len(t, page.Items, 1) && assert.NotNil(t, page.Next) {
+		assert.Equal(t, int64(2), page.Total)
+		assert.Equal(t, *root.ID, *page.Items[0].ID)
+		assert.Len(t, *(*page.Items[0].Replies)[0].Replies, 1)
+		page, err = r.FindMessagesByThreadID(*th.ID, true, models.PageQuery{Limit: 1, Cursor: *page.Next})
+		if assert.NoError(t, err) && assert.Len(t, page.Items, 1) {
+			assert.Equal(t, *second.ID, *page.Items[0].ID)
+			assert.Nil(t, page.Next)
+			assert.NotNil(t, page.Prev)
+		}
+	}
+}
+
+func TestMentions(t *testing.T) {
+	var (
+		mentionerID = uuid.New()
+		body        = "Thanks @Mike-Pech. Ping `@mike-pech` or mail mike@mike-pech.dev, @nobody"
+		spam        = strings.Repeat("@mike-pech ", maxMentions) + "@nobody"
+		noMentions  = "Thanks everyone"
+	)
+	r := NewPsqlForumRepository(testDBClient, testEnforcer)
+	m, err := r.CreateMessage(models.Message{Title: &messageTitle, Body: &body, UserID: &mentionerID, ThreadID: &threadID})
+	if !assert.NoError(t, err) || !assert.NotNil(t, m.Mentions) {
+		return
+	}
+	if assert.Len(t, *m.Mentions, 1) {
+		assert.Equal(t, userID, *(*m.Mentions)[0].ID)
+		assert.Equal(t, "mike-pech", *(*m.Mentions)[0].Username)
+	}
+
+	mentions, err := r.FindMentions(userID, 0)
+	if assert.NoError(t, err) {
+		assert.Equal(t, *m.ID, *(*mentions)[0].ID)
+	}
+	notifications, err := NewPsqlNotificationRepository(testDBClient).FindNotifications(
+		models.NotificationFilter{UserID: userID, Unread: true},
+	)
+	if assert.NoError(t, err) {
+		assert.True(t, slices.ContainsFunc(*notifications, func(n models.Notification) bool {
+			return *n.Type == "mention" && *n.TargetID == *m.ID && *n.ActorID == mentionerID
+		}))
+	}
+
+	_, err = r.CreateMessage(models.Message{Title: &messageTitle, Body: &spam, UserID: &mentionerID, ThreadID: &threadID})
+	assert.Equal(t, r.MentionsErr(), err)
+
+	m, err = r.UpdateMessage(*m.ID, models.Message{Body: &noMentions})
+	if assert.NoError(t, err) {
+		assert.Nil(t, m.Mentions)
+	}
+	_, err = r.FindMentions(userID, 0)
+	assert.Equal(t, r.NotFoundErr(), err)
+}
+
+func TestThreadOperations(t *testing.T) {
+	var (
+		ownerID   = uuid.New()
+		otherName = "Other topic"
+		splitName = "Split off"
+		reason    = "Off-topic"
+	)
+	r := NewPsqlForumRepository(testDBClient, testEnforcer)
+	tp, err := r.CreateTopic(models.Topic{Name: &topicName, UserID: &ownerID})
+	if !assert.NoError(t, err) {
+		return
+	}
+	other, err := r.CreateTopic(models.Topic{Name: &otherName, UserID: &ownerID})
+	if !assert.NoError(t, err) {
+		return
+	}
+	th, err := r.CreateThread(models.Thread{Title: &threadTitle, UserID: &userID, TopicID: tp.ID})
+	if !assert.NoError(t, err) {
+		return
+	}
+	var messageIDs []int
+	for range 3 {
+		m, err := r.CreateMessage(models.Message{Title: &messageTitle, Body: &messageBody, UserID: &userID, ThreadID: th.ID})
+		if !assert.NoError(t, err) {
+			return
+		}
+		messageIDs = append(messageIDs, *m.ID)
+	}
+	operate := func(op string, version int, managerID *uuid.UUID, set func(op *models.ThreadOperation)) (*models.ThreadOperation, error) {
+		o := models.ThreadOperation{Operation: &op, Version: &version, UserID: &ownerID, Reason: &reason}
+		if set != nil {
+			set(&o)
+		}
+		return r.CreateThreadOperation(*th.ID, o, managerID)
+	}
+
+	stranger := uuid.New()
+	_, err = operate("pin", 1, &stranger, nil)
+	assert.Equal(t, r.ThreadForbiddenErr(), err)
+	op, err := operate("pin", 1, &ownerID, nil)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 2, *op.Version)
+	}
+	_, err = operate("lock", 1, nil, nil)
+	assert.Equal(t, r.ConflictErr(), err)
+	_, err = operate("lock", 2, nil, nil)
+	assert.NoError(t, err)
+
+	threads, err := r.FindThreadsByTopicID(*tp.ID, 0, "")
+	if assert.NoError(t, err) {
+		assert.Equal(t, *th.ID, *(*threads)[0].ID)
+		assert.NotNil(t, (*threads)[0].PinnedAt)
+		assert.NotNil(t, (*threads)[0].LockedAt)
+		assert.Equal(t, 3, *(*threads)[0].Version)
+	}
+
+	op, err = operate("move", 3, &ownerID, func(o *models.ThreadOperation) { o.TopicID = other.ID })
+	if assert.NoError(t, err) {
+		assert.Equal(t, *tp.ID, *op.FromTopicID)
+	}
+
+	op, err = operate("split", 4, nil, func(o *models.ThreadOperation) {
+		o.Title = &splitName
+		o.MessageIDs = &[]int{messageIDs[1], messageIDs[2]}
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	split := *op.TargetThreadID
+	messages, err := r.FindMessagesByThreadID(split, false, models.PageQuery{})
+	if assert.NoError(t, err) {
+		assert.Len(t, messages.Items, 2)
+	}
+	_, err = operate("split", 5, nil, func(o *models.ThreadOperation) {
+		o.Title = &splitName
+		o.MessageIDs = &[]int{messageIDs[1]}
+	})
+	assert.Equal(t, r.ThreadOperationErr(), err)
+
+	_, err = operate("merge", 5, nil, func(o *models.ThreadOperation) { o.TargetThreadID = th.ID })
+	assert.Equal(t, r.ThreadOperationErr(), err)
+	_, err = operate("merge", 5, nil, func(o *models.ThreadOperation) { o.TargetThreadID = &split })
+	if assert.NoError(t, err) {
+		messages, err = r.FindMessagesByThreadID(split, false, models.PageQuery{})
+		if assert.NoError(t, err) {
+			assert.Len(t, messages.Items, 3)
+		}
+		_, err = r.FindThreadByID(*th.ID)
+		assert.Equal(t, r.NotFoundErr(), err)
+	}
+
+	ops, err := r.FindThreadOperations(*th.ID)
+	if assert.NoError(t, err) {
+		assert.Len(t, *ops, 5)
+	}
+	ops, err = r.FindThreadOperations(split)
+	if assert.NoError(t, err) {
+		assert.Len(t, *ops, 2)
+	}
+
+	assert.NoError(t, r.DeleteTopic(*tp.ID, userID))
+	assert.NoError(t, r.DeleteTopic(*other.ID, userID))
+}
+
+func TestTopicCategories(t *testing.T) {
+	var (
+		parentName  = "Godot 4: Tips & Tricks"
+		childName   = "Shaders"
+		explicit    = "  Visual Shaders!  "
+		moderators  = "moderators"
+		owner       = "owner"
+		minAge      = 30
+		noAge       = 0
+		topLevel    = 0
+		ownerID     = uuid.New()
+		description = "All about shaders"
+	)
+	r := NewPsqlForumRepository(testDBClient, testEnforcer)
+
+	parent, err := r.CreateTopic(models.Topic{Name: &parentName, UserID: &ownerID})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "godot-4-tips-tricks", *parent.Slug)
+	assert.Equal(t, "everyone", *parent.ThreadAccess)
+
+	// Derived slugs are made unique, explicit ones are normalized and must be free
+	twin, err := r.CreateTopic(models.Topic{Name: &parentName})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "godot-4-tips-tricks-2", *twin.Slug)
+		assert.NoError(t, r.DeleteTopic(*twin.ID, userID))
+	}
+	child, err := r.CreateTopic(models.Topic{Name: &childName, Slug: &explicit, ParentID: parent.ID, Description: &description})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "visual-shaders", *child.Slug)
+	assert.Equal(t, *parent.ID, *child.ParentID)
+	_, err = r.CreateTopic(models.Topic{Name: &childName, Slug: child.Slug})
+	assert.Equal(t, r.SlugConflictErr(), err)
+
+	found, err := r.FindTopicBySlug("Visual-Shaders")
+	if assert.NoError(t, err) {
+		assert.Equal(t, *child.ID, *found.ID)
+	}
+
+	// Neither the category itself nor its subcategories may become its parent
+	_, err = r.UpdateTopic(*parent.ID, models.Topic{Version: parent.Version, ParentID: child.ID})
+	assert.Equal(t, r.ParentErr(), err)
+	_, err = r.UpdateTopic(*parent.ID, models.Topic{Version: parent.Version, ParentID: parent.ID})
+	assert.Equal(t, r.ParentErr(), err)
+	_, err = r.CreateTopic(models.Topic{Name: &childName, ParentID: &[]int{9000}[0]})
+	assert.Equal(t, r.ParentErr(), err)
+
+	topics, err := r.FindTopics(true)
+	if assert.NoError(t, err) {
+		i := slices.IndexFunc(*topics, func(tp models.Topic) bool { return *tp.ID == *parent.ID })
+		if assert.NotEqual(t, -1, i) && assert.NotNil(t, (*topics)[i].Children) {
+			assert.Equal(t, *child.ID, *(*(*topics)[i].Children)[0].ID)
+		}
+		assert.False(t, slices.ContainsFunc(*topics, func(tp models.Topic) bool { return *tp.ID == *child.ID }))
+	}
+
+	assert.Equal(t, r.ChildrenErr(), r.DeleteTopic(*parent.ID, userID))
+
+	// Posting rules
+	parent, err = r.UpdateTopic(*parent.ID, models.Topic{Version: parent.Version, ThreadAccess: &moderators})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, r.ThreadAccessErr(), r.CheckThreadRules(*parent.ID, userID))
+	parent, err = r.UpdateTopic(*parent.ID, models.Topic{Version: parent.Version, ThreadAccess: &owner})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, r.ThreadAccessErr(), r.CheckThreadRules(*parent.ID, userID))
+	assert.Equal(t, r.NotFoundErr(), r.CheckThreadRules(*parent.ID, ownerID)) // No such user
+	assert.NoError(t, r.CheckThreadRules(*child.ID, userID))
+
+	th, err := r.CreateThread(models.Thread{Title: &threadTitle, UserID: &userID, TopicID: child.ID})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, r.CheckMessageRules(*th.ID, userID))
+	child, err = r.UpdateTopic(*child.ID, models.Topic{Version: child.Version, MinAccountAgeDays: &minAge})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, r.AccountAgeErr(), r.CheckThreadRules(*child.ID, userID))
+	assert.Equal(t, r.AccountAgeErr(), r.CheckMessageRules(*th.ID, userID))
+	child, err = r.UpdateTopic(*child.ID, models.Topic{Version: child.Version, MinAccountAgeDays: &noAge, ParentID: &topLevel})
+	if assert.NoError(t, err) {
+		assert.Nil(t, child.ParentID)
+		assert.Equal(t, description, *child.Description)
+	}
+
+	provisioned, err := r.ProvisionTopic(models.Topic{Name: &childName, Slug: child.Slug})
+	if assert.NoError(t, err) {
+		assert.Equal(t, *child.ID, *provisioned.ID)
+	}
+
+	assert.NoError(t, r.DeleteTopic(*child.ID, userID))
+	assert.NoError(t, r.DeleteTopic(*parent.ID, userID))
+}
+
 func TestFindMessagesByQuery(t *testing.T) {
 	var (
 		messageTitleAlt string         = "The Magnificent Seven"
@@ -303,17 +753,17 @@ func TestFindMessagesByQuery(t *testing.T) {
 		resultMessage, err := r.CreateMessage(m)
 		assert.NoError(t, err)
 
-		queryMessages, err := r.FindMessages([]string{q}, 0, "highest-rated")
+		queryMessages, err := r.FindMessages([]string{q}, models.PageQuery{}, "highest-rated")
 		if assert.NoError(t, err) {
-			queriedMessage := *queryMessages
+			queriedMessage := queryMessages.Items
 			assert.Equal(t, resultMessage.Title, queriedMessage[0].Title)
 		}
 	}
 
 	// Try to query both and check ordering
-	messages, err := r.FindMessages([]string{"cheeseboiger"}, 0, "newest-updated")
+	messages, err := r.FindMessages([]string{"cheeseboiger"}, models.PageQuery{}, "newest-updated")
 	if assert.NoError(t, err) {
-		m := *messages
+		m := messages.Items
 		assert.Len(t, m, 2)
 		var timeOrder, timeOrderExpected []time.Time
 		timeOrderExpected = []time.Time{*m[0].UpdatedAt, *m[1].UpdatedAt}
@@ -327,15 +777,15 @@ func TestFindMessagesByQuery(t *testing.T) {
 		)
 	}
 	// Query with limit
-	messages, err = r.FindMessages([]string{"cheeseboiger"}, 1, "most-views")
+	messages, err = r.FindMessages([]string{"cheeseboiger"}, models.PageQuery{Limit: 1}, "most-views")
 	if assert.NoError(t, err) {
-		assert.Len(t, *messages, 1)
+		assert.Len(t, messages.Items, 1)
 	}
 }
 
 func TestFindMessages(t *testing.T) {
 	r := PsqlForumRepository{databaseClient: testDBClient, enforcer: testEnforcer}
-	_, err := r.FindMessages(nil, 0, "")
+	_, err := r.FindMessages(nil, models.PageQuery{}, "")
 	assert.NoError(t, err)
 }
 
@@ -361,19 +811,19 @@ func TestUpdateMessage(t *testing.T) {
 
 func TestDeleteMessage(t *testing.T) {
 	r := PsqlForumRepository{databaseClient: testDBClient, enforcer: testEnforcer}
-	err := r.DeleteMessage(messageID)
+	err := r.DeleteMessage(messageID, userID)
 	if assert.NoError(t, err) {
 		teardownForum(&r)
 	}
 }
 
 func teardownForum(r *PsqlForumRepository) {
-	remainderTopics, err := r.FindTopics()
+	remainderTopics, err := r.FindTopics(false)
 	if err != nil {
 		panic(err)
 	}
 	for _, t := range *remainderTopics {
-		err = r.DeleteTopic(*t.ID)
+		err = r.DeleteTopic(*t.ID, userID)
 		if err != nil {
 			panic(err)
 		}
@@ -0,0 +1,26 @@
+package psqlRepository
+
+import "fmt"
+
+// Matches below which a keyword search also takes the rows whose title is similar to the keywords,
+// as similar as pg_trgm.word_similarity_threshold requires
+const fuzzyFallbackBelow = 5
+
+// Narrows the source to the content whose search vector matches the keywords in $1, has a word starting
+// with the last of them, the one still being typed, or whose tags include one of the words in $2.
+// Misspelled titles match as well while few rows do otherwise
+func keywordMatch(source, ts, title string) string {
+	return withFuzzyFallback(source,
+		`(`+ts+` @@ to_tsquery_multilang($1) OR `+ts+` @@ to_tsquery_prefix($1) OR tags::TEXT[] && tag.canonical($2))`,
+		title,
+	)
+}
+
+// Narrows the source, a query with a WHERE clause, to the rows matching. Widens the match with the rows
+// whose title is similar to the keywords in $1 when fewer than fuzzyFallbackBelow rows of the source
+// match otherwise, so the source must already be narrowed by the filters of the listing
+func withFuzzyFallback(source, match, title string) string {
+	return fmt.Sprintf(`%[1]v AND (%[2]v OR ($1 <%% %[3]v AND
+		(SELECT COUNT(*) FROM (%[1]v AND %[2]v LIMIT %[4]v) AS matched) < %[4]v))`,
+		source, match, title, fuzzyFallbackBelow)
+}
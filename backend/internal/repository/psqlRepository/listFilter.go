@@ -0,0 +1,62 @@
+package psqlRepository
+
+import (
+	"fmt"
+	"gamehangar/internal/domain/models"
+)
+
+// Narrows the source of the listing by the filter. Handlers reject filters on columns
+// the listed table does not have
+func (q *pageQuery) filter(f models.ListFilter) {
+	where := func(format string, arg any) {
+		q.args = append(q.args, arg)
+		q.source = q.source + ` AND ` + fmt.Sprintf(format, len(q.args))
+	}
+
+	if f.UserID != nil {
+		where(`user_id = $%v`, *f.UserID)
+	}
+	if len(f.Tags) != 0 {
+		if f.AnyTag {
+			where(`tags::TEXT[] && tag.canonical($%v)`, f.Tags)
+		} else {
+			where(`tags::TEXT[] @> tag.canonical($%v)`, f.Tags)
+		}
+	}
+	if len(f.ExcludeTags) != 0 {
+		where(`NOT (COALESCE(tags::TEXT[], '{}') && tag.canonical($%v))`, f.ExcludeTags)
+	}
+	if f.TopicID != nil {
+		where(`topic_id IN (
+			WITH RECURSIVE subtopics AS (
+				SELECT id FROM forum.topics WHERE id = $%v
+				UNION
+				SELECT tp.id FROM forum.topics tp JOIN subtopics s ON tp.parent_id = s.id
+			)
+			SELECT id FROM subtopics)`, *f.TopicID)
+	}
+	if f.CreatedFrom != nil {
+		where(`created_at >= $%v`, *f.CreatedFrom)
+	}
+	if f.CreatedTo != nil {
+		where(`created_at < $%v`, *f.CreatedTo)
+	}
+	if f.UpdatedFrom != nil {
+		where(`updated_at >= $%v`, *f.UpdatedFrom)
+	}
+	if f.UpdatedTo != nil {
+		where(`updated_at < $%v`, *f.UpdatedTo)
+	}
+	if f.MinRating != nil {
+		where(`COALESCE(rating, 0) >= $%v`, *f.MinRating)
+	}
+	if f.GodotVersion != nil {
+		where(godotCompatible(`godot_version`, `$%[1]v`), *f.GodotVersion)
+	}
+	if f.MinSize != nil {
+		where(`file_size >= $%v`, *f.MinSize)
+	}
+	if f.MaxSize != nil {
+		where(`file_size <= $%v`, *f.MaxSize)
+	}
+}
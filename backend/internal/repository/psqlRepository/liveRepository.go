@@ -0,0 +1,78 @@
+package psqlRepository
+
+import (
+	"context"
+	"encoding/json"
+	"gamehangar/internal/domain/models"
+
+	"github.com/google/uuid"
+)
+
+// Events are published by triggers on the content tables, see migration 017_live_events.sql
+type PsqlLiveRepository struct {
+	databaseClient psqlDatabaseClient
+}
+
+// Requires PsqlDatabaseClient since it implements PostgeSQL-specific query logic
+func NewPsqlLiveRepository(dbClient psqlDatabaseClient) *PsqlLiveRepository {
+	return &PsqlLiveRepository{
+		databaseClient: dbClient,
+	}
+}
+
+func (r *PsqlLiveRepository) NotFoundErr() error { return r.databaseClient.ErrNoRows() }
+
+// Moderators of the category of a hidden thread may follow it, see moderatesContent
+func (r *PsqlLiveRepository) ModeratesContent(targetType string, targetID int, userID uuid.UUID) (bool, error) {
+	return moderatesContent(r.databaseClient, targetType, targetID, userID)
+}
+
+// Returns the author and the hidden state of the thread. Unlike FindThreadByID it does not count a view
+func (r *PsqlLiveRepository) FindLiveThread(id int) (*models.Thread, error) {
+	thread := models.Thread{ID: &id}
+
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	err = conn.QueryRow(context.Background(),
+		`SELECT user_id, hidden_at FROM forum.threads WHERE id = $1 AND deleted_at IS NULL`, id,
+	).Scan(&thread.UserID, &thread.HiddenAt)
+	if err != nil {
+		return nil, err
+	}
+	return &thread, nil
+}
+
+// Calls handle for every event published in the database until ctx is cancelled or the connection
+// fails. Events published while no instance listens are lost
+func (r *PsqlLiveRepository) ListenLiveEvents(ctx context.Context, handle func(models.LiveEvent)) error {
+	c, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return err
+	}
+	// The connection keeps listening, so it does not go back to the pool
+	conn := c.Hijack()
+	defer conn.Close(context.Background())
+
+	_, err = conn.Exec(ctx, `LISTEN live_events`)
+	if err != nil {
+		return err
+	}
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		var event models.LiveEvent
+		err = json.Unmarshal([]byte(n.Payload), &event)
+		if err != nil {
+			continue
+		}
+		handle(event)
+	}
+}
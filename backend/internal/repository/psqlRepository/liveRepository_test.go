@@ -0,0 +1,159 @@
+package psqlRepository
+
+import (
+	"context"
+	"encoding/json"
+	"gamehangar/internal/domain/models"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindLiveThread(t *testing.T) {
+	fr := NewPsqlForumRepository(testDBClient, testEnforcer)
+	th, err := fr.CreateThread(thread)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	r := NewPsqlLiveRepository(testDBClient)
+	liveThread, err := r.FindLiveThread(*th.ID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, userID, *liveThread.UserID)
+		assert.Nil(t, liveThread.HiddenAt)
+	}
+
+	_, err = r.FindLiveThread(-1)
+	assert.Equal(t, r.NotFoundErr(), err)
+}
+
+func TestListenLiveEvents(t *testing.T) {
+	fr := NewPsqlForumRepository(testDBClient, testEnforcer)
+	th, err := fr.CreateThread(thread)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	events := make(chan models.LiveEvent, 1)
+	listening := make(chan error)
+
+	r := NewPsqlLiveRepository(testDBClient)
+	go func() {
+		listening <- r.ListenLiveEvents(ctx, func(e models.LiveEvent) {
+			if e.Channel == "thread:"+strconv.Itoa(*th.ID) {
+				events <- e
+				cancel()
+			}
+		})
+	}()
+
+	// LISTEN may not have run yet, so the message is posted until its event arrives
+	m := message
+	m.ThreadID = th.ID
+	for {
+		_, err = fr.CreateMessage(m)
+		if !assert.NoError(t, err) {
+			return
+		}
+		select {
+		case e := <-events:
+			assert.Equal(t, "message.created", e.Event)
+			var data struct {
+				ThreadID int `json:"threadID"`
+			}
+			if assert.NoError(t, json.Unmarshal(e.Data, &data)) {
+				assert.Equal(t, *th.ID, data.ThreadID)
+			}
+			<-listening
+			return
+		case err = <-listening:
+			t.Fatal("Stopped listening: " + err.Error())
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+func TestListenLiveEventsMerge(t *testing.T) {
+	fr := NewPsqlForumRepository(testDBClient, testEnforcer)
+	from, err := fr.CreateThread(thread)
+	if !assert.NoError(t, err) {
+		return
+	}
+	into, err := fr.CreateThread(thread)
+	if !assert.NoError(t, err) {
+		return
+	}
+	m := message
+	m.ThreadID = from.ID
+	merged, err := fr.CreateMessage(m)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	events := make(chan models.LiveEvent, 16)
+	listening := make(chan error)
+
+	r := NewPsqlLiveRepository(testDBClient)
+	go func() {
+		listening <- r.ListenLiveEvents(ctx, func(e models.LiveEvent) {
+			if e.Channel == "thread:"+strconv.Itoa(*from.ID) || e.Channel == "thread:"+strconv.Itoa(*into.ID) {
+				events <- e
+			}
+		})
+	}()
+
+	// LISTEN may not have run yet, so a message is posted to the thread merged into until its event arrives
+	m.ThreadID = into.ID
+	for ready := false; !ready; {
+		_, err = fr.CreateMessage(m)
+		if !assert.NoError(t, err) {
+			return
+		}
+		select {
+		case <-events:
+			ready = true
+		case err = <-listening:
+			t.Fatal("Stopped listening: " + err.Error())
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+
+	merge, version := "merge", 1
+	_, err = fr.CreateThreadOperation(*from.ID, models.ThreadOperation{
+		Operation: &merge, Version: &version, UserID: &userID, TargetThreadID: into.ID,
+	}, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var deleted, created bool
+	for !deleted || !created {
+		select {
+		case e := <-events:
+			var data struct {
+				ID int `json:"id"`
+			}
+			if !assert.NoError(t, json.Unmarshal(e.Data, &data)) || data.ID != *merged.ID {
+				continue
+			}
+			switch e.Event {
+			case "message.deleted":
+				assert.Equal(t, "thread:"+strconv.Itoa(*from.ID), e.Channel)
+				deleted = true
+			case "message.created":
+				assert.Equal(t, "thread:"+strconv.Itoa(*into.ID), e.Channel)
+				created = true
+			}
+		case <-ctx.Done():
+			t.Fatal("Missing events of the merged message")
+		}
+	}
+	cancel()
+	<-listening
+}
@@ -0,0 +1,431 @@
+package psqlRepository
+
+import (
+	"context"
+	"errors"
+	"gamehangar/internal/domain/models"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type PsqlMailRepository struct {
+	databaseClient psqlDatabaseClient
+	conflictErr    error
+}
+
+// Requires PsqlDatabaseClient since it implements PostgeSQL-specific query logic
+func NewPsqlMailRepository(dbClient psqlDatabaseClient) *PsqlMailRepository {
+	return &PsqlMailRepository{
+		databaseClient: dbClient,
+		conflictErr:    errors.New("Mail already queued!"),
+	}
+}
+
+func (r *PsqlMailRepository) NotFoundErr() error { return r.databaseClient.ErrNoRows() }
+
+// Returns "Mail already queued!" when another instance of the service queued the same mail first
+func (r *PsqlMailRepository) ConflictErr() error { return r.conflictErr }
+
+// Title of the notification target, for the subject and body of the mail
+const mailTargetTitle = `COALESCE(CASE n.target_type
+		WHEN 'demo' THEN (SELECT title FROM demo.demos WHERE id = n.target_id)
+		WHEN 'asset' THEN (SELECT name FROM asset.assets WHERE id = n.target_id)
+		WHEN 'thread' THEN (SELECT title FROM forum.threads WHERE id = n.target_id)
+		WHEN 'message' THEN (SELECT COALESCE(m.title, t.title) FROM forum.messages m
+			JOIN forum.threads t ON t.id = m.thread_id WHERE m.id = n.target_id)
+	END, '')`
+
+func (r *PsqlMailRepository) FindEmailPreferences(userID uuid.UUID) (*models.EmailPreferences, error) {
+	var preferences models.EmailPreferences
+
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	err = conn.QueryRow(context.Background(),
+		`SELECT (frequency, locale) FROM notification.email_preferences WHERE user_id = $1`, userID,
+	).Scan(&preferences)
+	if err != nil {
+		return nil, err
+	}
+	return &preferences, nil
+}
+
+func (r *PsqlMailRepository) UpdateEmailPreferences(userID uuid.UUID, preferences models.EmailPreferences) (*models.EmailPreferences, error) {
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	err = conn.QueryRow(context.Background(),
+		`UPDATE notification.email_preferences SET
+		frequency=COALESCE($2, frequency), locale=COALESCE($3, locale)
+		WHERE user_id = $1
+		RETURNING
+		(frequency, locale)`,
+		userID, preferences.Frequency, preferences.Locale,
+	).Scan(&preferences)
+	if err != nil {
+		return nil, err
+	}
+	return &preferences, nil
+}
+
+// Turns off all mail of the user the token was issued to
+func (r *PsqlMailRepository) Unsubscribe(token uuid.UUID) error {
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	ct, err := conn.Exec(context.Background(),
+		`UPDATE notification.email_preferences SET frequency='off' WHERE unsubscribe_token = $1`, token,
+	)
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return r.NotFoundErr()
+	}
+	return nil
+}
+
+// Returns unread notifications not mailed yet of users who want mail right away, grouped by user.
+// Notifications are left alone for settle after they are created, so bursts are mailed as one
+func (r *PsqlMailRepository) FindUnmailedNotifications(settle time.Duration) (*[]models.NotificationMail, error) {
+	var mails []models.NotificationMail
+
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(context.Background(),
+		`SELECT u.id, u.email, COALESCE(u.display_name, u.username), p.locale, p.unsubscribe_token, p.last_digest_at,
+			n.id, n.user_id, n.type, n.target_type, n.target_id, n.actor_id, n.count, n.created_at, n.updated_at,
+			`+mailTargetTitle+`
+		FROM notification.notifications n
+		JOIN notification.email_preferences p ON p.user_id = n.user_id
+		JOIN "user".users u ON u.id = n.user_id
+		WHERE p.frequency = 'immediate' AND n.read_at IS NULL AND n.emailed_at IS NULL
+			AND n.created_at <= NOW() - make_interval(secs => $1)
+		ORDER BY u.id, n.updated_at`,
+		settle.Seconds(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			recipient    models.MailRecipient
+			notification models.MailNotification
+		)
+		err = rows.Scan(
+			&recipient.UserID, &recipient.Email, &recipient.Name, &recipient.Locale, &recipient.UnsubscribeToken, &recipient.Since,
+			&notification.ID, &notification.UserID, &notification.Type, &notification.TargetType, &notification.TargetID,
+			&notification.ActorID, &notification.Count, &notification.CreatedAt, &notification.UpdatedAt,
+			&notification.TargetTitle,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if len(mails) == 0 || mails[len(mails)-1].Recipient.UserID != recipient.UserID {
+			mails = append(mails, models.NotificationMail{Recipient: recipient})
+		}
+		mails[len(mails)-1].Notifications = append(mails[len(mails)-1].Notifications, notification)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+	if len(mails) == 0 {
+		return nil, r.NotFoundErr()
+	}
+	return &mails, nil
+}
+
+// Returns up to limit users whose daily or weekly digest is due, with the replies
+// and the new demos in their subscribed tags or by users they follow since their previous digest
+func (r *PsqlMailRepository) FindDueDigests(limit int) (*[]models.NotificationMail, error) {
+	var mails []models.NotificationMail
+
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(context.Background(),
+		`SELECT u.id, u.email, COALESCE(u.display_name, u.username), p.locale, p.unsubscribe_token, p.last_digest_at
+		FROM notification.email_preferences p
+		JOIN "user".users u ON u.id = p.user_id
+		WHERE (p.frequency = 'daily' AND p.last_digest_at <= NOW() - INTERVAL '1 day')
+			OR (p.frequency = 'weekly' AND p.last_digest_at <= NOW() - INTERVAL '7 days')
+		ORDER BY p.last_digest_at
+		LIMIT $1`,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var recipient models.MailRecipient
+		err = rows.Scan(&recipient.UserID, &recipient.Email, &recipient.Name, &recipient.Locale,
+			&recipient.UnsubscribeToken, &recipient.Since)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		mails = append(mails, models.NotificationMail{Recipient: recipient})
+	}
+	rows.Close()
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+	if len(mails) == 0 {
+		return nil, r.NotFoundErr()
+	}
+
+	for i := range mails {
+		err = r.findDigestContent(conn.Conn(), &mails[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &mails, nil
+}
+
+// Digests are kept short, the site has the rest
+const digestMaxItems = 20
+
+func (r *PsqlMailRepository) findDigestContent(conn *pgx.Conn, mail *models.NotificationMail) error {
+	rows, err := conn.Query(context.Background(),
+		`SELECT n.id, n.user_id, n.type, n.target_type, n.target_id, n.actor_id, n.count, n.created_at, n.updated_at,
+			`+mailTargetTitle+`
+		FROM notification.notifications n
+		WHERE n.user_id = $1 AND n.type = 'reply' AND n.read_at IS NULL AND n.updated_at > $2
+		ORDER BY n.updated_at DESC
+		LIMIT $3`,
+		mail.Recipient.UserID, mail.Recipient.Since, digestMaxItems,
+	)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var notification models.MailNotification
+		err = rows.Scan(
+			&notification.ID, &notification.UserID, &notification.Type, &notification.TargetType, &notification.TargetID,
+			&notification.ActorID, &notification.Count, &notification.CreatedAt, &notification.UpdatedAt,
+			&notification.TargetTitle,
+		)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		mail.Notifications = append(mail.Notifications, notification)
+	}
+	rows.Close()
+	err = rows.Err()
+	if err != nil {
+		return err
+	}
+
+	rows, err = conn.Query(context.Background(),
+		`SELECT id, title, tags, user_id, created_at
+		FROM demo.demos
+		WHERE created_at > $2 AND user_id <> $1 AND deleted_at IS NULL AND hidden_at IS NULL
+			AND (tags && ARRAY(SELECT tag::TEXT FROM subscription.subscriptions WHERE user_id = $1 AND tag IS NOT NULL)
+				OR user_id IN (SELECT followed_user_id FROM subscription.subscriptions WHERE user_id = $1))
+		ORDER BY created_at DESC
+		LIMIT $3`,
+		mail.Recipient.UserID, mail.Recipient.Since, digestMaxItems,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var demo models.Demo
+		err = rows.Scan(&demo.ID, &demo.Title, &demo.Tags, &demo.UserID, &demo.CreatedAt)
+		if err != nil {
+			return err
+		}
+		mail.Demos = append(mail.Demos, demo)
+	}
+	return rows.Err()
+}
+
+// Queues the mail and marks the notifications it covers as mailed
+func (r *PsqlMailRepository) QueueMail(mail models.Mail, notificationIDs []int) error {
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(context.Background())
+
+	err = r.queueMail(tx, &mail, notificationIDs)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit(context.Background())
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Queues the digest of the recipient, if there is anything to tell, and starts the next digest period
+func (r *PsqlMailRepository) QueueDigest(recipient models.MailRecipient, mail *models.Mail, notificationIDs []int) error {
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(context.Background())
+
+	ct, err := tx.Exec(context.Background(),
+		`UPDATE notification.email_preferences SET last_digest_at=NOW() WHERE user_id = $1 AND last_digest_at = $2`,
+		recipient.UserID, recipient.Since,
+	)
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return r.conflictErr
+	}
+
+	if mail != nil {
+		err = r.queueMail(tx, mail, notificationIDs)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tx.Commit(context.Background())
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *PsqlMailRepository) queueMail(tx pgx.Tx, mail *models.Mail, notificationIDs []int) error {
+	if len(notificationIDs) != 0 {
+		ct, err := tx.Exec(context.Background(),
+			`UPDATE notification.notifications SET emailed_at=NOW() WHERE id = ANY($1) AND emailed_at IS NULL`,
+			notificationIDs,
+		)
+		if err != nil {
+			return err
+		}
+		if ct.RowsAffected() != int64(len(notificationIDs)) {
+			return r.conflictErr
+		}
+	}
+
+	_, err := tx.Exec(context.Background(),
+		`INSERT INTO notification.outbox
+		(user_id, recipient, subject, html, text, unsubscribe_url)
+		VALUES
+		($1, $2, $3, $4, $5, $6)`,
+		mail.UserID, mail.Recipient, mail.Subject, mail.HTML, mail.Text, mail.UnsubscribeURL,
+	)
+	return err
+}
+
+// Takes up to limit mails that are due for delivery. Taken mails are not handed out
+// again for lease, so that instances of the service do not send the same mail twice
+func (r *PsqlMailRepository) ClaimMail(limit int, lease time.Duration) (*[]models.Mail, error) {
+	var mails []models.Mail
+
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(context.Background(),
+		`UPDATE notification.outbox SET attempts=attempts+1, next_attempt_at=NOW() + make_interval(secs => $2)
+		WHERE id IN (
+			SELECT id FROM notification.outbox
+			WHERE sent_at IS NULL AND failed_at IS NULL AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING
+		(id, user_id, recipient, subject, html, text, unsubscribe_url, attempts)`,
+		limit, lease.Seconds(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var mail models.Mail
+		err = rows.Scan(&mail)
+		if err != nil {
+			return nil, err
+		}
+		mails = append(mails, mail)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+	if len(mails) == 0 {
+		return nil, r.NotFoundErr()
+	}
+	return &mails, nil
+}
+
+func (r *PsqlMailRepository) MarkMailSent(id int64) error {
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	_, err = conn.Exec(context.Background(),
+		`UPDATE notification.outbox SET sent_at=NOW(), last_error=NULL WHERE id = $1`, id,
+	)
+	return err
+}
+
+// Schedules the next attempt at retryAt, or gives up on the mail if retryAt is nil
+func (r *PsqlMailRepository) MarkMailFailed(id int64, reason string, retryAt *time.Time) error {
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	_, err = conn.Exec(context.Background(),
+		`UPDATE notification.outbox SET
+		last_error=$2, next_attempt_at=COALESCE($3, next_attempt_at),
+		failed_at=CASE WHEN $3::TIMESTAMPTZ IS NULL THEN NOW() END
+		WHERE id = $1`,
+		id, reason, retryAt,
+	)
+	return err
+}
@@ -0,0 +1,158 @@
+package psqlRepository
+
+import (
+	"context"
+	"gamehangar/internal/domain/models"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	mailedNotificationIDs []int
+	mailID                int64
+
+	mailRecipient = "test@email.com"
+	mailSubject   = "1 new reply"
+	mailBody      = "Hi"
+)
+
+func TestUpdateEmailPreferences(t *testing.T) {
+	r := NewPsqlMailRepository(testDBClient)
+
+	// Every user gets preferences when created
+	preferences, err := r.FindEmailPreferences(userID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "daily", *preferences.Frequency)
+		assert.Equal(t, "en", *preferences.Locale)
+	}
+
+	frequency := "immediate"
+	preferences, err = r.UpdateEmailPreferences(userID, models.EmailPreferences{Frequency: &frequency})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "immediate", *preferences.Frequency)
+		assert.Equal(t, "en", *preferences.Locale)
+	}
+}
+
+func TestFindUnmailedNotifications(t *testing.T) {
+	fr := NewPsqlForumRepository(testDBClient, testEnforcer)
+	th, err := fr.CreateThread(thread)
+	if !assert.NoError(t, err) {
+		return
+	}
+	replier := uuid.New()
+	_, err = fr.CreateMessage(models.Message{
+		Title: &messageTitle, Body: &messageBody, UserID: &replier, ThreadID: th.ID, Tags: &messageTags,
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	r := NewPsqlMailRepository(testDBClient)
+	mails, err := r.FindUnmailedNotifications(time.Hour)
+	assert.Equal(t, r.NotFoundErr(), err)
+
+	mails, err = r.FindUnmailedNotifications(0)
+	if assert.NoError(t, err) && assert.Len(t, *mails, 1) {
+		mail := (*mails)[0]
+		assert.Equal(t, userID, mail.Recipient.UserID)
+		assert.Equal(t, threadTitle, mail.Notifications[0].TargetTitle)
+		for _, n := range mail.Notifications {
+			mailedNotificationIDs = append(mailedNotificationIDs, *n.ID)
+		}
+	}
+}
+
+func TestQueueMail(t *testing.T) {
+	r := NewPsqlMailRepository(testDBClient)
+	mail := models.Mail{UserID: &userID, Recipient: &mailRecipient, Subject: &mailSubject, HTML: &mailBody, Text: &mailBody}
+
+	assert.NoError(t, r.QueueMail(mail, mailedNotificationIDs))
+	assert.Equal(t, r.ConflictErr(), r.QueueMail(mail, mailedNotificationIDs))
+
+	_, err := r.FindUnmailedNotifications(0)
+	assert.Equal(t, r.NotFoundErr(), err)
+}
+
+func TestClaimMail(t *testing.T) {
+	r := NewPsqlMailRepository(testDBClient)
+	mails, err := r.ClaimMail(10, time.Minute)
+	if assert.NoError(t, err) && assert.Len(t, *mails, 1) {
+		mail := (*mails)[0]
+		assert.Equal(t, 1, *mail.Attempts)
+		assert.Equal(t, mailSubject, *mail.Subject)
+		mailID = *mail.ID
+	}
+
+	// Claimed mail is leased to the claimer
+	_, err = r.ClaimMail(10, time.Minute)
+	assert.Equal(t, r.NotFoundErr(), err)
+
+	retryAt := time.Now().Add(-time.Second)
+	assert.NoError(t, r.MarkMailFailed(mailID, "421 Try again later", &retryAt))
+	mails, err = r.ClaimMail(10, time.Minute)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 2, *(*mails)[0].Attempts)
+	}
+
+	assert.NoError(t, r.MarkMailSent(mailID))
+	_, err = r.ClaimMail(10, time.Minute)
+	assert.Equal(t, r.NotFoundErr(), err)
+}
+
+func TestFindDueDigests(t *testing.T) {
+	r := NewPsqlMailRepository(testDBClient)
+	_, err := r.FindDueDigests(10)
+	assert.Equal(t, r.NotFoundErr(), err)
+
+	frequency := "daily"
+	_, err = r.UpdateEmailPreferences(userID, models.EmailPreferences{Frequency: &frequency})
+	if !assert.NoError(t, err) {
+		return
+	}
+	c, _ := testDBClient.AcquireConn()
+	defer c.Release()
+	_, err = c.Exec(context.Background(),
+		`UPDATE notification.email_preferences SET last_digest_at = NOW() - INTERVAL '2 days' WHERE user_id = $1`, userID)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	digests, err := r.FindDueDigests(10)
+	if assert.NoError(t, err) && assert.Len(t, *digests, 1) {
+		digest := (*digests)[0]
+		assert.Equal(t, userID, digest.Recipient.UserID)
+		assert.NotEmpty(t, digest.Notifications)
+
+		assert.NoError(t, r.QueueDigest(digest.Recipient, nil, nil))
+		assert.Equal(t, r.ConflictErr(), r.QueueDigest(digest.Recipient, nil, nil))
+	}
+
+	_, err = r.FindDueDigests(10)
+	assert.Equal(t, r.NotFoundErr(), err)
+}
+
+func TestUnsubscribe(t *testing.T) {
+	var token uuid.UUID
+
+	c, _ := testDBClient.AcquireConn()
+	defer c.Release()
+	err := c.QueryRow(context.Background(),
+		`SELECT unsubscribe_token FROM notification.email_preferences WHERE user_id = $1`, userID,
+	).Scan(&token)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	r := NewPsqlMailRepository(testDBClient)
+	assert.NoError(t, r.Unsubscribe(token))
+	assert.Equal(t, r.NotFoundErr(), r.Unsubscribe(uuid.New()))
+
+	preferences, err := r.FindEmailPreferences(userID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "off", *preferences.Frequency)
+	}
+}
@@ -0,0 +1,98 @@
+package psqlRepository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gamehangar/internal/domain/models"
+)
+
+type PsqlMarkdownRepository struct {
+	databaseClient psqlDatabaseClient
+	unsupportedErr error
+}
+
+// Table of each Markdown source type with its source and HTML columns
+type markdownTarget struct {
+	table  string
+	source string
+	html   string
+}
+
+var markdownTargets = map[string]markdownTarget{
+	"message": {table: "forum.messages", source: "body", html: "body_html"},
+	"demo":    {table: "demo.demos", source: "description", html: "description_html"},
+}
+
+// Requires PsqlDatabaseClient since it implements PostgeSQL-specific query logic
+func NewPsqlMarkdownRepository(dbClient psqlDatabaseClient) *PsqlMarkdownRepository {
+	return &PsqlMarkdownRepository{
+		databaseClient: dbClient,
+		unsupportedErr: errors.New("Markdown is not supported for this type!"),
+	}
+}
+
+func (r *PsqlMarkdownRepository) NotFoundErr() error { return r.databaseClient.ErrNoRows() }
+
+// Returns "Markdown is not supported for this type!" for unknown source types
+func (r *PsqlMarkdownRepository) UnsupportedErr() error { return r.unsupportedErr }
+
+// Returns sources without rendered HTML, including deleted and hidden ones since they can be restored
+func (r *PsqlMarkdownRepository) FindUnrenderedMarkdown(limit int) (*[]models.MarkdownSource, error) {
+	var sources []models.MarkdownSource
+
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(context.Background(),
+		`SELECT (type, id, source, NULL::TEXT) FROM
+			((SELECT 'message' AS type, id, body AS source FROM forum.messages WHERE body_html IS NULL)
+		UNION ALL
+			(SELECT 'demo', id, description FROM demo.demos WHERE description_html IS NULL AND description IS NOT NULL)) unrendered
+		LIMIT $1`,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var source models.MarkdownSource
+		err = rows.Scan(&source)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, source)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+	if len(sources) == 0 {
+		return nil, r.NotFoundErr()
+	}
+	return &sources, nil
+}
+
+// Stores the HTML unless the source was changed in the meantime, its new HTML was stored along with it then
+func (r *PsqlMarkdownRepository) UpdateRenderedMarkdown(source models.MarkdownSource) error {
+	t, ok := markdownTargets[*source.Type]
+	if !ok {
+		return r.unsupportedErr
+	}
+
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	_, err = conn.Exec(context.Background(),
+		fmt.Sprintf(`UPDATE %v SET %v = $3 WHERE id = $1 AND %v = $2`, t.table, t.html, t.source),
+		source.ID, source.Source, source.HTML,
+	)
+	return err
+}
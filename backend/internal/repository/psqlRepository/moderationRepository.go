@@ -0,0 +1,506 @@
+package psqlRepository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gamehangar/internal/domain/models"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type PsqlModerationRepository struct {
+	databaseClient       psqlDatabaseClient
+	conflictErr          error
+	unsupportedActionErr error
+	outOfCategoryErr     error
+}
+
+// Table and author column of each content type that can be reported and moderated,
+// and the query for the category of the content of ID $1 where it has one
+type moderationTarget struct {
+	table  string
+	author string
+	topic  string
+}
+
+var moderationTargets = map[string]moderationTarget{
+	"demo":  {table: "demo.demos", author: "user_id"},
+	"asset": {table: "asset.assets", author: "NULL::UUID"}, // Assets do not record their author
+	"thread": {table: "forum.threads", author: "user_id",
+		topic: `SELECT topic_id FROM forum.threads WHERE id = $1`},
+	"message": {table: "forum.messages", author: "user_id",
+		topic: `SELECT t.topic_id FROM forum.messages m JOIN forum.threads t ON t.id = m.thread_id WHERE m.id = $1`},
+}
+
+// Actions moderators of a category may take on its content. Warnings are left to global moderators
+var categoryActions = []string{"hide", "unhide", "lock", "unlock", "delete"}
+
+// Requires PsqlDatabaseClient since it implements PostgeSQL-specific query logic
+func NewPsqlModerationRepository(dbClient psqlDatabaseClient) *PsqlModerationRepository {
+	return &PsqlModerationRepository{
+		databaseClient:       dbClient,
+		conflictErr:          errors.New("Content already reported!"),
+		unsupportedActionErr: errors.New("Action is not supported for this content!"),
+		outOfCategoryErr:     errors.New("Content is not in this category!"),
+	}
+}
+
+func (r *PsqlModerationRepository) NotFoundErr() error { return r.databaseClient.ErrNoRows() }
+
+// Returns "Content already reported!" when the user already has an open report on the content
+func (r *PsqlModerationRepository) ConflictErr() error { return r.conflictErr }
+
+// Returns "Action is not supported for this content!", e.g. when locking a message
+// or warning the author of an asset
+func (r *PsqlModerationRepository) UnsupportedActionErr() error { return r.unsupportedActionErr }
+
+// Returns "Content is not in this category!" when an action limited to a category targets content
+// outside of it and its subcategories
+func (r *PsqlModerationRepository) OutOfCategoryErr() error { return r.outOfCategoryErr }
+
+func (r *PsqlModerationRepository) CreateReport(report models.Report) (*models.Report, error) {
+	target, ok := moderationTargets[*report.TargetType]
+	if !ok {
+		return nil, r.unsupportedActionErr
+	}
+
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	err = conn.QueryRow(context.Background(),
+		`INSERT INTO moderation.reports
+		(reporter_id, target_type, target_id, reason, details)
+		SELECT $1, $2, $3, $4, $5
+		WHERE EXISTS (SELECT 1 FROM `+target.table+` WHERE id = $3 AND deleted_at IS NULL)
+		RETURNING
+		(id, reporter_id, target_type, target_id, reason, details, state, created_at, resolved_at, resolved_by, resolution)`,
+		report.ReporterID, report.TargetType, report.TargetID, report.Reason, report.Details,
+	).Scan(&report)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, r.conflictErr
+		}
+		return nil, err
+	}
+	return &report, nil
+}
+
+func (r *PsqlModerationRepository) FindReportByID(id int) (*models.Report, error) {
+	var report models.Report
+
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	err = conn.QueryRow(context.Background(),
+		`SELECT (id, reporter_id, target_type, target_id, reason, details, state, created_at, resolved_at, resolved_by, resolution)
+		FROM moderation.reports WHERE id = $1`, id,
+	).Scan(&report)
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// Returns the moderator queue, oldest reports first
+func (r *PsqlModerationRepository) FindReports(f models.ReportFilter) (*[]models.Report, error) {
+	var (
+		reports []models.Report
+		where   = []string{`TRUE`}
+		args    []any
+	)
+
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	if f.State != "" {
+		args = append(args, f.State)
+		where = append(where, fmt.Sprintf(`state = $%v`, len(args)))
+	}
+	if f.TargetType != "" {
+		args = append(args, f.TargetType)
+		where = append(where, fmt.Sprintf(`target_type = $%v`, len(args)))
+	}
+	if f.TopicID != nil {
+		args = append(args, f.TopicID)
+		where = append(where, fmt.Sprintf(`CASE target_type
+			WHEN 'thread' THEN (SELECT topic_id FROM forum.threads WHERE id = target_id)
+			WHEN 'message' THEN (SELECT t.topic_id FROM forum.messages m JOIN forum.threads t ON t.id = m.thread_id WHERE m.id = target_id)
+		END IN (
+			WITH RECURSIVE subtopics AS (
+				SELECT id FROM forum.topics WHERE id = $%v
+				UNION
+				SELECT tp.id FROM forum.topics tp JOIN subtopics s ON tp.parent_id = s.id
+			)
+			SELECT id FROM subtopics
+		)`, len(args)))
+	}
+
+	query := `SELECT (id, reporter_id, target_type, target_id, reason, details, state, created_at, resolved_at, resolved_by, resolution)
+		FROM moderation.reports WHERE ` + strings.Join(where, ` AND `) + ` ORDER BY created_at, id`
+	if f.Limit != 0 {
+		args = append(args, f.Limit)
+		query = query + fmt.Sprintf(` LIMIT $%v`, len(args))
+	}
+	if f.Offset != 0 {
+		args = append(args, f.Offset)
+		query = query + fmt.Sprintf(` OFFSET $%v`, len(args))
+	}
+
+	rows, err := conn.Query(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var report models.Report
+		err = rows.Scan(&report)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+	if len(reports) == 0 {
+		return nil, r.NotFoundErr()
+	}
+	return &reports, nil
+}
+
+// Closes an open report without acting on the content
+func (r *PsqlModerationRepository) DismissReport(id int, report models.Report) (*models.Report, error) {
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(context.Background())
+
+	change := auditChange{actorID: report.ResolvedBy, action: "dismiss", table: "moderation.reports", targetType: "reports", targetID: id}
+	err = change.snapshot(tx)
+	if err != nil {
+		return nil, err
+	}
+	err = tx.QueryRow(context.Background(),
+		`UPDATE moderation.reports SET
+		state='dismissed', resolved_at=NOW(), resolved_by=$2, resolution=$3
+		WHERE id = $1 AND state = 'open'
+		RETURNING
+		(id, reporter_id, target_type, target_id, reason, details, state, created_at, resolved_at, resolved_by, resolution)`,
+		id, report.ResolvedBy, report.Resolution,
+	).Scan(&report)
+	if err != nil {
+		return nil, err
+	}
+	err = change.record(tx)
+	if err != nil {
+		return nil, err
+	}
+	err = tx.Commit(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// Applies the action to the content, records it and marks all open reports on the content as actioned.
+// An action with a TopicID is limited to the content of that category, see categoryActions
+func (r *PsqlModerationRepository) CreateModerationAction(action models.ModerationAction) (*models.ModerationAction, error) {
+	target, ok := moderationTargets[*action.TargetType]
+	if !ok {
+		return nil, r.unsupportedActionErr
+	}
+	if action.TopicID != nil && (target.topic == "" || !slices.Contains(categoryActions, *action.Action)) {
+		return nil, r.unsupportedActionErr
+	}
+
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(context.Background())
+
+	err = tx.QueryRow(context.Background(),
+		`SELECT `+target.author+` FROM `+target.table+` WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`,
+		action.TargetID,
+	).Scan(&action.AuthorID)
+	if err != nil {
+		return nil, err
+	}
+
+	if action.TopicID != nil {
+		var inCategory bool
+		err = tx.QueryRow(context.Background(),
+			`WITH RECURSIVE ancestors AS (
+				SELECT id, parent_id FROM forum.topics WHERE id = (`+target.topic+`)
+				UNION
+				SELECT tp.id, tp.parent_id FROM forum.topics tp JOIN ancestors a ON tp.id = a.parent_id
+			)
+			SELECT EXISTS (SELECT 1 FROM ancestors WHERE id = $2)`,
+			action.TargetID, action.TopicID,
+		).Scan(&inCategory)
+		if err != nil {
+			return nil, err
+		}
+		if !inCategory {
+			return nil, r.outOfCategoryErr
+		}
+	}
+
+	change := auditChange{
+		actorID:    action.ModeratorID,
+		action:     *action.Action,
+		table:      target.table,
+		targetType: *action.TargetType + "s",
+		targetID:   *action.TargetID,
+	}
+	err = change.snapshot(tx)
+	if err != nil {
+		return nil, err
+	}
+	switch *action.Action {
+	case "hide":
+		_, err = tx.Exec(context.Background(), `UPDATE `+target.table+` SET hidden_at=COALESCE(hidden_at, NOW()) WHERE id = $1`, action.TargetID)
+	case "unhide":
+		_, err = tx.Exec(context.Background(), `UPDATE `+target.table+` SET hidden_at=NULL WHERE id = $1`, action.TargetID)
+	case "lock", "unlock":
+		if *action.TargetType != "thread" {
+			return nil, r.unsupportedActionErr
+		}
+		if *action.Action == "lock" {
+			_, err = tx.Exec(context.Background(), `UPDATE forum.threads SET locked_at=COALESCE(locked_at, NOW()) WHERE id = $1`, action.TargetID)
+		} else {
+			_, err = tx.Exec(context.Background(), `UPDATE forum.threads SET locked_at=NULL WHERE id = $1`, action.TargetID)
+		}
+		if err != nil {
+			return nil, err
+		}
+		// Like the operations on the thread, so it shows in its history and stale edits conflict
+		_, err = tx.Exec(context.Background(),
+			`WITH bumped AS (
+				UPDATE forum.threads SET version=version+1 WHERE id = $1 RETURNING version
+			)
+			INSERT INTO forum.thread_operations (thread_id, user_id, operation, version, reason)
+			SELECT $1, $2, $3, version, $4 FROM bumped`,
+			action.TargetID, action.ModeratorID, action.Action, action.Reason,
+		)
+	case "delete":
+		// Deleted content goes to the moderator's trash, so its author cannot restore it
+		_, err = tx.Exec(context.Background(),
+			`UPDATE `+target.table+` SET deleted_at=NOW(), deleted_by=$2 WHERE id = $1`,
+			action.TargetID, action.ModeratorID)
+		if err == nil && *action.TargetType == "thread" {
+			_, err = tx.Exec(context.Background(),
+				`UPDATE forum.messages SET deleted_at=NOW(), deleted_by=$2 WHERE thread_id = $1 AND deleted_at IS NULL`,
+				action.TargetID, action.ModeratorID)
+		}
+	case "warn":
+		if action.AuthorID == nil {
+			return nil, r.unsupportedActionErr
+		}
+	default:
+		return nil, r.unsupportedActionErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.QueryRow(context.Background(),
+		`INSERT INTO moderation.actions
+		(moderator_id, target_type, target_id, author_id, action, reason, topic_id)
+		VALUES
+		($1, $2, $3, $4, $5, $6, $7)
+		RETURNING
+		(id, moderator_id, target_type, target_id, author_id, action, reason, created_at, topic_id)`,
+		action.ModeratorID, action.TargetType, action.TargetID, action.AuthorID, action.Action, action.Reason, action.TopicID,
+	).Scan(&action)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(context.Background(),
+		`UPDATE moderation.reports SET
+		state='actioned', resolved_at=NOW(), resolved_by=$3, resolution=$4
+		WHERE target_type = $1 AND target_id = $2 AND state = 'open'`,
+		action.TargetType, action.TargetID, action.ModeratorID, action.Reason,
+	)
+	if err != nil {
+		return nil, err
+	}
+	err = change.record(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return &action, nil
+}
+
+// Returns the moderation history, newest first
+func (r *PsqlModerationRepository) FindModerationActions(f models.ModerationActionFilter) (*[]models.ModerationAction, error) {
+	var (
+		actions []models.ModerationAction
+		where   = []string{`TRUE`}
+		args    []any
+	)
+
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	if f.TargetType != "" {
+		args = append(args, f.TargetType)
+		where = append(where, fmt.Sprintf(`target_type = $%v`, len(args)))
+	}
+	if f.TargetID != 0 {
+		args = append(args, f.TargetID)
+		where = append(where, fmt.Sprintf(`target_id = $%v`, len(args)))
+	}
+	if f.AuthorID != nil {
+		args = append(args, *f.AuthorID)
+		where = append(where, fmt.Sprintf(`author_id = $%v`, len(args)))
+	}
+
+	query := `SELECT (id, moderator_id, target_type, target_id, author_id, action, reason, created_at, topic_id)
+		FROM moderation.actions WHERE ` + strings.Join(where, ` AND `) + ` ORDER BY created_at DESC, id DESC`
+	if f.Limit != 0 {
+		args = append(args, f.Limit)
+		query = query + fmt.Sprintf(` LIMIT $%v`, len(args))
+	}
+	if f.Offset != 0 {
+		args = append(args, f.Offset)
+		query = query + fmt.Sprintf(` OFFSET $%v`, len(args))
+	}
+
+	rows, err := conn.Query(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var action models.ModerationAction
+		err = rows.Scan(&action)
+		if err != nil {
+			return nil, err
+		}
+		actions = append(actions, action)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+	if len(actions) == 0 {
+		return nil, r.NotFoundErr()
+	}
+	return &actions, nil
+}
+
+// Hides content flagged by the content filter and files a report without a reporter for it,
+// so it waits in the moderator queue until a moderator unhides or deletes it
+func (r *PsqlModerationRepository) HoldForReview(targetType string, targetID int, details string) error {
+	target, ok := moderationTargets[targetType]
+	if !ok {
+		return r.unsupportedActionErr
+	}
+
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(context.Background())
+
+	ct, err := tx.Exec(context.Background(),
+		`UPDATE `+target.table+` SET hidden_at=COALESCE(hidden_at, NOW()) WHERE id = $1 AND deleted_at IS NULL`,
+		targetID)
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return r.NotFoundErr()
+	}
+
+	_, err = tx.Exec(context.Background(),
+		`INSERT INTO moderation.reports
+		(target_type, target_id, reason, details)
+		VALUES
+		($1, $2, 'spam', $3)`,
+		targetType, targetID, details,
+	)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit(context.Background())
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Moderators of the category of reported content may read its reports, see moderatesContent
+func (r *PsqlModerationRepository) ModeratesContent(targetType string, targetID int, userID uuid.UUID) (bool, error) {
+	return moderatesContent(r.databaseClient, targetType, targetID, userID)
+}
+
+// Reports whether the user moderates the category of the content or one of its parents,
+// see forum.moderates_topic. Content without a category, e.g. a demo, is left to global moderators
+func moderatesContent(dbClient psqlDatabaseClient, targetType string, targetID int, userID uuid.UUID) (bool, error) {
+	var moderates bool
+
+	target, ok := moderationTargets[targetType]
+	if !ok || target.topic == "" {
+		return false, nil
+	}
+
+	conn, err := dbClient.AcquireConn()
+	if err != nil {
+		return false, err
+	}
+	defer conn.Release()
+
+	err = conn.QueryRow(context.Background(),
+		`SELECT COALESCE(forum.moderates_topic((`+target.topic+`), $2), FALSE)`, targetID, userID,
+	).Scan(&moderates)
+	if err != nil {
+		return false, err
+	}
+	return moderates, nil
+}
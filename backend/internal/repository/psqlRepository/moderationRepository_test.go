@@ -0,0 +1,247 @@
+package psqlRepository
+
+import (
+	"gamehangar/internal/domain/models"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	moderatedThreadID int
+	reportID          int
+
+	reportType       = "thread"
+	reportReason     = "spam"
+	moderationReason = "Spam"
+	moderatorID      = uuid.New()
+)
+
+func TestCreateReport(t *testing.T) {
+	fr := NewPsqlForumRepository(testDBClient, testEnforcer)
+	th, err := fr.CreateThread(thread)
+	if !assert.NoError(t, err) {
+		return
+	}
+	moderatedThreadID = *th.ID
+
+	r := NewPsqlModerationRepository(testDBClient)
+	report, err := r.CreateReport(models.Report{
+		ReporterID: &userID, TargetType: &reportType, TargetID: &moderatedThreadID, Reason: &reportReason,
+	})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "open", *report.State)
+		reportID = *report.ID
+	}
+
+	_, err = r.CreateReport(models.Report{
+		ReporterID: &userID, TargetType: &reportType, TargetID: &moderatedThreadID, Reason: &reportReason,
+	})
+	assert.Equal(t, r.ConflictErr(), err)
+
+	missingID := 1 << 30
+	_, err = r.CreateReport(models.Report{
+		ReporterID: &userID, TargetType: &reportType, TargetID: &missingID, Reason: &reportReason,
+	})
+	assert.Equal(t, r.NotFoundErr(), err)
+}
+
+func TestFindReports(t *testing.T) {
+	r := NewPsqlModerationRepository(testDBClient)
+	reports, err := r.FindReports(models.ReportFilter{State: "open", TargetType: reportType})
+	if assert.NoError(t, err) {
+		assert.Equal(t, reportID, *(*reports)[0].ID)
+	}
+}
+
+func TestCreateModerationActionHide(t *testing.T) {
+	r := NewPsqlModerationRepository(testDBClient)
+	fr := NewPsqlForumRepository(testDBClient, testEnforcer)
+	hide := "hide"
+	message, err := fr.CreateMessage(models.Message{Title: &messageTitle, UserID: &userID, ThreadID: &moderatedThreadID})
+	if !assert.NoError(t, err) {
+		return
+	}
+	action, err := r.CreateModerationAction(models.ModerationAction{
+		ModeratorID: &moderatorID, TargetType: &reportType, TargetID: &moderatedThreadID, Action: &hide, Reason: &moderationReason,
+	})
+	if assert.NoError(t, err) {
+		assert.Equal(t, userID, *action.AuthorID)
+	}
+
+	thread, err := fr.FindThreadByID(moderatedThreadID)
+	if assert.NoError(t, err) {
+		assert.NotNil(t, thread.HiddenAt)
+	}
+	threads, err := fr.FindThreads(nil, models.ListFilter{}, models.PageQuery{}, "newest-updated")
+	if err == nil {
+		for _, th := range threads.Items {
+			assert.NotEqual(t, moderatedThreadID, *th.ID)
+		}
+	}
+	visibility, err := fr.FindThreadVisibility(moderatedThreadID)
+	if assert.NoError(t, err) {
+		assert.NotNil(t, visibility.HiddenAt)
+	}
+
+	// Messages of the hidden thread are left out of listings, but found for those who may see the thread
+	messages, err := fr.FindMessages(nil, models.PageQuery{}, "newest-updated")
+	if err == nil {
+		for _, m := range messages.Items {
+			assert.NotEqual(t, moderatedThreadID, *m.ThreadID)
+		}
+	}
+	_, err = fr.FindMessageByID(*message.ID)
+	assert.NoError(t, err)
+
+	report, err := r.FindReportByID(reportID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "actioned", *report.State)
+		assert.Equal(t, moderatorID, *report.ResolvedBy)
+	}
+}
+
+func TestCreateModerationActionLock(t *testing.T) {
+	r := NewPsqlModerationRepository(testDBClient)
+	fr := NewPsqlForumRepository(testDBClient, testEnforcer)
+	lock := "lock"
+	_, err := r.CreateModerationAction(models.ModerationAction{
+		ModeratorID: &moderatorID, TargetType: &reportType, TargetID: &moderatedThreadID, Action: &lock, Reason: &moderationReason,
+	})
+	assert.NoError(t, err)
+
+	_, err = fr.CreateMessage(models.Message{Title: &messageTitle, UserID: &userID, ThreadID: &moderatedThreadID})
+	assert.Equal(t, fr.ThreadLockedErr(), err)
+
+	ops, err := fr.FindThreadOperations(moderatedThreadID)
+	if assert.NoError(t, err) && assert.NotEmpty(t, *ops) {
+		last := (*ops)[len(*ops)-1]
+		assert.Equal(t, "lock", *last.Operation)
+		assert.Equal(t, moderatorID, *last.UserID)
+	}
+
+	messageType := "message"
+	_, err = r.CreateModerationAction(models.ModerationAction{
+		ModeratorID: &moderatorID, TargetType: &messageType, TargetID: &messageID, Action: &lock, Reason: &moderationReason,
+	})
+	assert.Error(t, err)
+}
+
+func TestDismissReport(t *testing.T) {
+	r := NewPsqlModerationRepository(testDBClient)
+	report, err := r.CreateReport(models.Report{
+		ReporterID: &userID, TargetType: &reportType, TargetID: &moderatedThreadID, Reason: &reportReason,
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	resolution := "Not spam"
+	dismissed, err := r.DismissReport(*report.ID, models.Report{ResolvedBy: &moderatorID, Resolution: &resolution})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "dismissed", *dismissed.State)
+	}
+	_, err = r.DismissReport(*report.ID, models.Report{ResolvedBy: &moderatorID, Resolution: &resolution})
+	assert.Equal(t, r.NotFoundErr(), err)
+}
+
+func TestFindModerationActions(t *testing.T) {
+	r := NewPsqlModerationRepository(testDBClient)
+	actions, err := r.FindModerationActions(models.ModerationActionFilter{AuthorID: &userID})
+	if assert.NoError(t, err) {
+		assert.Len(t, *actions, 2)
+		assert.Equal(t, "lock", *(*actions)[0].Action)
+	}
+}
+
+func TestCreateModerationActionTopic(t *testing.T) {
+	var (
+		unlock    = "unlock"
+		warn      = "warn"
+		elsewhere = "Elsewhere"
+	)
+	r := NewPsqlModerationRepository(testDBClient)
+	fr := NewPsqlForumRepository(testDBClient, testEnforcer)
+	ur := PsqlUserRepository{databaseClient: testDBClient, enforcer: testEnforcer, objectUploader: testS3Client}
+	th, err := fr.FindThreadByID(moderatedThreadID)
+	if !assert.NoError(t, err) {
+		return
+	}
+	other, err := fr.CreateTopic(models.Topic{Name: &elsewhere})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	moderator, err := ur.CreateTopicModerator(models.TopicModerator{TopicID: th.TopicID, UserID: &userID, AssignedBy: &moderatorID})
+	if assert.NoError(t, err) {
+		assert.Equal(t, userName, *moderator.Username)
+	}
+	moderators, err := ur.FindTopicModerators(*th.TopicID)
+	if assert.NoError(t, err) {
+		assert.Len(t, *moderators, 1)
+	}
+	ok, err := ur.IsTopicModerator(*th.TopicID, userID)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = ur.IsTopicModerator(*other.ID, userID)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	ok, err = r.ModeratesContent(reportType, moderatedThreadID, userID)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = r.ModeratesContent("demo", 1, userID)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	reports, err := r.FindReports(models.ReportFilter{State: "actioned", TopicID: th.TopicID})
+	if assert.NoError(t, err) {
+		assert.True(t, slices.ContainsFunc(*reports, func(rep models.Report) bool { return *rep.TargetID == moderatedThreadID }))
+	}
+	_, err = r.FindReports(models.ReportFilter{State: "actioned", TopicID: other.ID})
+	assert.Equal(t, r.NotFoundErr(), err)
+
+	action, err := r.CreateModerationAction(models.ModerationAction{
+		ModeratorID: &userID, TargetType: &reportType, TargetID: &moderatedThreadID, Action: &unlock, Reason: &moderationReason, TopicID: th.TopicID,
+	})
+	if assert.NoError(t, err) {
+		assert.Equal(t, *th.TopicID, *action.TopicID)
+	}
+	_, err = r.CreateModerationAction(models.ModerationAction{
+		ModeratorID: &userID, TargetType: &reportType, TargetID: &moderatedThreadID, Action: &unlock, Reason: &moderationReason, TopicID: other.ID,
+	})
+	assert.Equal(t, r.OutOfCategoryErr(), err)
+	_, err = r.CreateModerationAction(models.ModerationAction{
+		ModeratorID: &userID, TargetType: &reportType, TargetID: &moderatedThreadID, Action: &warn, Reason: &moderationReason, TopicID: th.TopicID,
+	})
+	assert.Equal(t, r.UnsupportedActionErr(), err)
+
+	assert.NoError(t, ur.DeleteTopicModerator(*th.TopicID, userID))
+	assert.Equal(t, ur.NotFoundErr(), ur.DeleteTopicModerator(*th.TopicID, userID))
+	assert.NoError(t, fr.DeleteTopic(*other.ID, userID))
+}
+
+func TestHoldForReview(t *testing.T) {
+	r := NewPsqlModerationRepository(testDBClient)
+	fr := NewPsqlForumRepository(testDBClient, testEnforcer)
+	th, err := fr.CreateThread(thread)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	err = r.HoldForReview(reportType, *th.ID, "Content filter (links): 3 links from a new account, 2 allowed")
+	assert.NoError(t, err)
+
+	held, err := fr.FindThreadByID(*th.ID)
+	if assert.NoError(t, err) {
+		assert.NotNil(t, held.HiddenAt)
+	}
+	reports, err := r.FindReports(models.ReportFilter{State: "open", TargetType: reportType})
+	if assert.NoError(t, err) {
+		last := (*reports)[len(*reports)-1]
+		assert.Equal(t, *th.ID, *last.TargetID)
+		assert.Nil(t, last.ReporterID)
+	}
+
+	assert.Equal(t, r.UnsupportedActionErr(), r.HoldForReview("user", 1, ""))
+}
@@ -0,0 +1,205 @@
+package psqlRepository
+
+import (
+	"context"
+	"fmt"
+	"gamehangar/internal/domain/models"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Notifications are created by triggers on the content tables, see migration 014_notifications.sql
+type PsqlNotificationRepository struct {
+	databaseClient psqlDatabaseClient
+}
+
+// Requires PsqlDatabaseClient since it implements PostgeSQL-specific query logic
+func NewPsqlNotificationRepository(dbClient psqlDatabaseClient) *PsqlNotificationRepository {
+	return &PsqlNotificationRepository{
+		databaseClient: dbClient,
+	}
+}
+
+func (r *PsqlNotificationRepository) NotFoundErr() error { return r.databaseClient.ErrNoRows() }
+
+// Returns notifications of the user, most recently updated first
+func (r *PsqlNotificationRepository) FindNotifications(f models.NotificationFilter) (*[]models.Notification, error) {
+	var (
+		notifications []models.Notification
+		where         = []string{`user_id = $1`}
+		args          = []any{f.UserID}
+	)
+
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	if f.Unread {
+		where = append(where, `read_at IS NULL`)
+	}
+
+	query := `SELECT (id, user_id, type, target_type, target_id, actor_id, count, created_at, updated_at, read_at)
+		FROM notification.notifications WHERE ` + strings.Join(where, ` AND `) + ` ORDER BY updated_at DESC, id DESC`
+	if f.Limit != 0 {
+		args = append(args, f.Limit)
+		query = query + fmt.Sprintf(` LIMIT $%v`, len(args))
+	}
+	if f.Offset != 0 {
+		args = append(args, f.Offset)
+		query = query + fmt.Sprintf(` OFFSET $%v`, len(args))
+	}
+
+	rows, err := conn.Query(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var notification models.Notification
+		err = rows.Scan(&notification)
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, notification)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+	if len(notifications) == 0 {
+		return nil, r.NotFoundErr()
+	}
+	return &notifications, nil
+}
+
+func (r *PsqlNotificationRepository) CountUnreadNotifications(userID uuid.UUID) (int, error) {
+	var count int
+
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Release()
+
+	err = conn.QueryRow(context.Background(),
+		`SELECT COUNT(*) FROM notification.notifications WHERE user_id = $1 AND read_at IS NULL`, userID,
+	).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// Marks a notification of the user as read. Later events on the same target start a new notification
+func (r *PsqlNotificationRepository) MarkNotificationRead(id int, userID uuid.UUID) (*models.Notification, error) {
+	var notification models.Notification
+
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	err = conn.QueryRow(context.Background(),
+		`UPDATE notification.notifications SET read_at=COALESCE(read_at, NOW())
+		WHERE id = $1 AND user_id = $2
+		RETURNING
+		(id, user_id, type, target_type, target_id, actor_id, count, created_at, updated_at, read_at)`,
+		id, userID,
+	).Scan(&notification)
+	if err != nil {
+		return nil, err
+	}
+	return &notification, nil
+}
+
+// Returns the number of notifications marked as read
+func (r *PsqlNotificationRepository) MarkAllNotificationsRead(userID uuid.UUID) (int64, error) {
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Release()
+
+	ct, err := conn.Exec(context.Background(),
+		`UPDATE notification.notifications SET read_at=NOW() WHERE user_id = $1 AND read_at IS NULL`, userID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return ct.RowsAffected(), nil
+}
+
+// Returns the preference of every notification type, types the user never set are enabled
+func (r *PsqlNotificationRepository) FindNotificationPreferences(userID uuid.UUID) (*[]models.NotificationPreference, error) {
+	var preferences []models.NotificationPreference
+
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(context.Background(),
+		`SELECT (t.type, COALESCE(p.enabled, TRUE))
+		FROM unnest($2::VARCHAR[]) WITH ORDINALITY AS t (type, n)
+		LEFT JOIN notification.preferences p ON p.user_id = $1 AND p.type = t.type
+		ORDER BY t.n`,
+		userID, models.NotificationTypes,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var preference models.NotificationPreference
+		err = rows.Scan(&preference)
+		if err != nil {
+			return nil, err
+		}
+		preferences = append(preferences, preference)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+	return &preferences, nil
+}
+
+// Sets the given preferences and returns the preference of every type
+func (r *PsqlNotificationRepository) UpdateNotificationPreferences(userID uuid.UUID, preferences []models.NotificationPreference) (*[]models.NotificationPreference, error) {
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(context.Background())
+
+	for _, p := range preferences {
+		_, err = tx.Exec(context.Background(),
+			`INSERT INTO notification.preferences
+			(user_id, type, enabled)
+			VALUES
+			($1, $2, $3)
+			ON CONFLICT (user_id, type) DO UPDATE SET enabled=EXCLUDED.enabled`,
+			userID, p.Type, p.Enabled,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = tx.Commit(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return r.FindNotificationPreferences(userID)
+}
@@ -0,0 +1,131 @@
+package psqlRepository
+
+import (
+	"gamehangar/internal/domain/models"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	notifiedThreadID int
+	notificationID   int
+
+	replierID = uuid.New()
+)
+
+func TestNotifyReply(t *testing.T) {
+	fr := NewPsqlForumRepository(testDBClient, testEnforcer)
+	th, err := fr.CreateThread(thread)
+	if !assert.NoError(t, err) {
+		return
+	}
+	notifiedThreadID = *th.ID
+
+	// The author's own message in the thread is not a reply to them
+	for _, author := range []uuid.UUID{userID, replierID, replierID} {
+		_, err = fr.CreateMessage(models.Message{
+			Title: &messageTitle, Body: &messageBody, UserID: &author, ThreadID: &notifiedThreadID, Tags: &messageTags,
+		})
+		assert.NoError(t, err)
+	}
+
+	r := NewPsqlNotificationRepository(testDBClient)
+	notifications, err := r.FindNotifications(models.NotificationFilter{UserID: userID, Unread: true})
+	if assert.NoError(t, err) {
+		n := (*notifications)[0]
+		assert.Equal(t, "reply", *n.Type)
+		assert.Equal(t, notifiedThreadID, *n.TargetID)
+		assert.Equal(t, replierID, *n.ActorID)
+		assert.Equal(t, 2, *n.Count)
+		notificationID = *n.ID
+	}
+
+	_, err = r.FindNotifications(models.NotificationFilter{UserID: replierID})
+	assert.Equal(t, r.NotFoundErr(), err)
+}
+
+func TestNotifyVote(t *testing.T) {
+	var upvotes uint = 5
+
+	fr := NewPsqlForumRepository(testDBClient, testEnforcer)
+	_, err := fr.UpdateThread(notifiedThreadID, models.Thread{Upvotes: &upvotes})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	r := NewPsqlNotificationRepository(testDBClient)
+	notifications, err := r.FindNotifications(models.NotificationFilter{UserID: userID, Limit: 1})
+	if assert.NoError(t, err) {
+		n := (*notifications)[0]
+		assert.Equal(t, "vote", *n.Type)
+		assert.Nil(t, n.ActorID)
+		assert.Equal(t, 4, *n.Count)
+	}
+}
+
+func TestUpdateNotificationPreferences(t *testing.T) {
+	var (
+		upvotes     uint = 10
+		voteType         = "vote"
+		disabled         = false
+		preferences      = []models.NotificationPreference{{Type: &voteType, Enabled: &disabled}}
+	)
+
+	r := NewPsqlNotificationRepository(testDBClient)
+	updPreferences, err := r.UpdateNotificationPreferences(userID, preferences)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Len(t, *updPreferences, len(models.NotificationTypes))
+	for _, p := range *updPreferences {
+		assert.Equal(t, *p.Type != voteType, *p.Enabled)
+	}
+
+	fr := NewPsqlForumRepository(testDBClient, testEnforcer)
+	_, err = fr.UpdateThread(notifiedThreadID, models.Thread{Upvotes: &upvotes})
+	assert.NoError(t, err)
+
+	notifications, err := r.FindNotifications(models.NotificationFilter{UserID: userID, Limit: 1})
+	if assert.NoError(t, err) {
+		assert.Equal(t, 4, *(*notifications)[0].Count)
+	}
+}
+
+func TestMarkNotificationRead(t *testing.T) {
+	r := NewPsqlNotificationRepository(testDBClient)
+	n, err := r.MarkNotificationRead(notificationID, userID)
+	if assert.NoError(t, err) {
+		assert.NotNil(t, n.ReadAt)
+	}
+
+	_, err = r.MarkNotificationRead(notificationID, replierID)
+	assert.Equal(t, r.NotFoundErr(), err)
+
+	// Replies after reading start a new notification
+	fr := NewPsqlForumRepository(testDBClient, testEnforcer)
+	_, err = fr.CreateMessage(models.Message{
+		Title: &messageTitle, Body: &messageBody, UserID: &replierID, ThreadID: &notifiedThreadID, Tags: &messageTags,
+	})
+	assert.NoError(t, err)
+
+	notifications, err := r.FindNotifications(models.NotificationFilter{UserID: userID, Limit: 1})
+	if assert.NoError(t, err) {
+		assert.NotEqual(t, notificationID, *(*notifications)[0].ID)
+		assert.Equal(t, 1, *(*notifications)[0].Count)
+	}
+}
+
+func TestMarkAllNotificationsRead(t *testing.T) {
+	r := NewPsqlNotificationRepository(testDBClient)
+	read, err := r.MarkAllNotificationsRead(userID)
+	if assert.NoError(t, err) {
+		assert.NotZero(t, read)
+	}
+
+	count, err := r.CountUnreadNotifications(userID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 0, count)
+	}
+}
@@ -0,0 +1,189 @@
+package psqlRepository
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gamehangar/internal/domain/models"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Position of a row in a listing. The sort value and id are kept as text and cast back by the query,
+// Prev cursors page backwards from the row
+type cursor struct {
+	Order string `json:"o"`
+	Value string `json:"v"`
+	ID    string `json:"i"`
+	Prev  bool   `json:"p,omitempty"`
+}
+
+// Expression a listing is sorted on, highest first unless the listing is ascending, ties broken by id.
+// Cast is the SQL type a cursor value is cast back to
+type sortKey struct {
+	expr string
+	cast string
+}
+
+// Listing paged by keyset. Source selects the listed rows and must have an id column and the columns
+// of the sort key, columns is the composite row scanned into each item. Ascending listings are sorted
+// lowest first
+type pageQuery struct {
+	source    string
+	args      []any
+	columns   string
+	order     string
+	key       sortKey
+	idCast    string
+	ascending bool
+}
+
+var (
+	cursorErr = errors.New("Cursor is not valid!")
+
+	// Sort keys of demos, assets, threads and messages by listing order
+	contentSortKeys = map[string]sortKey{
+		"newest-updated": {expr: "updated_at", cast: "TIMESTAMPTZ"},
+		"highest-rated":  {expr: "COALESCE(rating, 0)", cast: "NUMERIC"},
+		"most-views":     {expr: "views", cast: "BIGINT"},
+	}
+	assetSortKeys = map[string]sortKey{
+		"newest-updated": contentSortKeys["newest-updated"],
+		"highest-rated":  contentSortKeys["highest-rated"],
+		"most-views":     contentSortKeys["most-views"],
+		"most-used":      {expr: "usage_count", cast: "BIGINT"},
+	}
+	userSortKey = sortKey{expr: "karma", cast: "BIGINT"}
+	// Sort key of the messages of a thread, which are listed ascending
+	threadSortKey = sortKey{expr: "created_at", cast: "TIMESTAMPTZ"}
+	// Sort key of the audit log, which is listed newest first
+	auditSortKey = sortKey{expr: "created_at", cast: "TIMESTAMPTZ"}
+)
+
+// Messages of a thread are listed oldest first
+const threadOrder = "oldest-created"
+
+// Audit events are listed newest first
+const auditOrder = "newest-created"
+
+func encodeCursor(c cursor) *string {
+	b, _ := json.Marshal(c)
+	s := base64.RawURLEncoding.EncodeToString(b)
+	return &s
+}
+
+// Returns nil for an empty cursor and cursorErr when it is malformed or was made for another order
+func decodeCursor(s, order string) (*cursor, error) {
+	var c cursor
+
+	if s == "" {
+		return nil, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, cursorErr
+	}
+	err = json.Unmarshal(b, &c)
+	if err != nil || c.Order != order {
+		return nil, cursorErr
+	}
+	return &c, nil
+}
+
+// Returns the page of the listing after the cursor, or before it for Prev cursors.
+// A zero limit returns the rest of the listing. The page has no items when nothing is left
+func queryPage[T any](conn *pgxpool.Conn, q pageQuery, page models.PageQuery) (*models.Page[T], error) {
+	var (
+		result    models.Page[T]
+		positions []cursor
+	)
+
+	at, err := decodeCursor(page.Cursor, q.order)
+	if err != nil {
+		return nil, err
+	}
+
+	err = conn.QueryRow(context.Background(),
+		`SELECT COUNT(*) FROM (`+q.source+`) AS page`, q.args...,
+	).Scan(&result.Total)
+	if err != nil {
+		return nil, err
+	}
+
+	args := q.args
+	backwards := at != nil && at.Prev
+	direction, compare := "DESC", "<"
+	if backwards != q.ascending {
+		direction, compare = "ASC", ">"
+	}
+	query := fmt.Sprintf(`SELECT (%s), (%s)::TEXT, id::TEXT FROM (%s) AS page`, q.columns, q.key.expr, q.source)
+	if at != nil {
+		args = append(slices.Clone(q.args), at.Value, at.ID)
+		query = query + fmt.Sprintf(` WHERE (%s, id) %s ($%d::TEXT::%s, $%d::TEXT::%s)`,
+			q.key.expr, compare, len(args)-1, q.key.cast, len(args), q.idCast)
+	}
+	query = query + fmt.Sprintf(` ORDER BY %s %s, id %s`, q.key.expr, direction, direction)
+	if page.Limit != 0 {
+		// One more row tells whether there is a page beyond this one
+		query = query + fmt.Sprintf(` LIMIT %v`, page.Limit+1)
+	}
+
+	rows, err := conn.Query(context.Background(), query, args...)
+	if err != nil {
+		return nil, castErr(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			item     T
+			position = cursor{Order: q.order}
+		)
+		err = rows.Scan(&item, &position.Value, &position.ID)
+		if err != nil {
+			return nil, err
+		}
+		result.Items = append(result.Items, item)
+		positions = append(positions, position)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, castErr(err)
+	}
+
+	more := page.Limit != 0 && uint64(len(result.Items)) > page.Limit
+	if more {
+		result.Items, positions = result.Items[:page.Limit], positions[:page.Limit]
+	}
+	if backwards {
+		slices.Reverse(result.Items)
+		slices.Reverse(positions)
+	}
+	if len(positions) == 0 {
+		return &result, nil
+	}
+
+	// Paging backwards starts from a row after this page, paging forwards from one before it
+	if more || backwards {
+		result.Next = encodeCursor(positions[len(positions)-1])
+	}
+	if more && backwards || at != nil && !backwards {
+		first := positions[0]
+		first.Prev = true
+		result.Prev = encodeCursor(first)
+	}
+	return &result, nil
+}
+
+// Returns cursorErr for data exceptions, raised when the value of a forged cursor does not cast back
+func castErr(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && strings.HasPrefix(pgErr.Code, "22") {
+		return cursorErr
+	}
+	return err
+}
@@ -0,0 +1,69 @@
+package psqlRepository
+
+import (
+	"context"
+	"time"
+)
+
+// Keeps rate limit buckets in PostgreSQL so that limits hold across API instances
+type PsqlRateLimitRepository struct {
+	databaseClient psqlDatabaseClient
+}
+
+// Requires PsqlDatabaseClient since it implements PostgeSQL-specific query logic
+func NewPsqlRateLimitRepository(dbClient psqlDatabaseClient) *PsqlRateLimitRepository {
+	return &PsqlRateLimitRepository{databaseClient: dbClient}
+}
+
+// Refills the bucket by the time passed since it was last used, then takes a token from it.
+// Each statement is atomic, so concurrent requests never overdraw the bucket
+func (r *PsqlRateLimitRepository) TakeToken(key string, burst int, rate float64) (float64, bool, error) {
+	var tokens float64
+
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return 0, false, err
+	}
+	defer conn.Release()
+
+	err = conn.QueryRow(context.Background(),
+		`INSERT INTO ratelimit.buckets AS b
+		(key, tokens, updated_at)
+		VALUES
+		($1, $2, NOW())
+		ON CONFLICT (key) DO UPDATE SET
+		tokens = LEAST($2, b.tokens + EXTRACT(EPOCH FROM NOW() - b.updated_at) * $3),
+		updated_at = NOW()
+		RETURNING tokens`,
+		key, float64(burst), rate,
+	).Scan(&tokens)
+	if err != nil {
+		return 0, false, err
+	}
+
+	err = conn.QueryRow(context.Background(),
+		`UPDATE ratelimit.buckets SET tokens = tokens - 1 WHERE key = $1 AND tokens >= 1 RETURNING tokens`,
+		key,
+	).Scan(&tokens)
+	if err != nil {
+		if err == r.databaseClient.ErrNoRows() {
+			return tokens, false, nil
+		}
+		return 0, false, err
+	}
+	return tokens, true, nil
+}
+
+func (r *PsqlRateLimitRepository) PurgeBuckets(before time.Time) (int64, error) {
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Release()
+
+	ct, err := conn.Exec(context.Background(), `DELETE FROM ratelimit.buckets WHERE updated_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return ct.RowsAffected(), nil
+}
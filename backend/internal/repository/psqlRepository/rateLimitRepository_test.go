@@ -0,0 +1,34 @@
+package psqlRepository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTakeToken(t *testing.T) {
+	r := NewPsqlRateLimitRepository(testDBClient)
+
+	for i := 1; i >= 0; i-- {
+		tokens, allowed, err := r.TakeToken("ip:192.0.2.1:read", 2, 0.001)
+		if assert.NoError(t, err) {
+			assert.True(t, allowed)
+			assert.InDelta(t, float64(i), tokens, 0.1)
+		}
+	}
+
+	_, allowed, err := r.TakeToken("ip:192.0.2.1:read", 2, 0.001)
+	if assert.NoError(t, err) {
+		assert.False(t, allowed)
+	}
+}
+
+func TestPurgeBuckets(t *testing.T) {
+	r := NewPsqlRateLimitRepository(testDBClient)
+
+	purged, err := r.PurgeBuckets(time.Now().Add(time.Minute))
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(1), purged)
+	}
+}
@@ -0,0 +1,207 @@
+package psqlRepository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gamehangar/internal/domain/models"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+type PsqlRevisionRepository struct {
+	databaseClient psqlDatabaseClient
+	unsupportedErr error
+}
+
+// Table of each revision type with its revisions and the column revisions keep as the body
+type revisionTarget struct {
+	table     string
+	revisions string
+	key       string
+	body      string
+}
+
+var revisionTargets = map[string]revisionTarget{
+	"message": {table: "forum.messages", revisions: "forum.message_revisions", key: "message_id", body: "body"},
+	"thread":  {table: "forum.threads", revisions: "forum.thread_revisions", key: "thread_id", body: "NULL::TEXT"},
+	"demo":    {table: "demo.demos", revisions: "demo.demo_revisions", key: "demo_id", body: "description"},
+}
+
+// Bodies with more lines than this product are diffed as a whole, lines are only matched below it
+const maxDiffCells = 1 << 20
+
+// Requires PsqlDatabaseClient since it implements PostgeSQL-specific query logic
+func NewPsqlRevisionRepository(dbClient psqlDatabaseClient) *PsqlRevisionRepository {
+	return &PsqlRevisionRepository{
+		databaseClient: dbClient,
+		unsupportedErr: errors.New("Revisions are not supported for this type!"),
+	}
+}
+
+func (r *PsqlRevisionRepository) NotFoundErr() error { return r.databaseClient.ErrNoRows() }
+
+// Returns "Revisions are not supported for this type!" for unknown target types
+func (r *PsqlRevisionRepository) UnsupportedErr() error { return r.unsupportedErr }
+
+// Moderators of the category of hidden content may read its revisions, see moderatesContent
+func (r *PsqlRevisionRepository) ModeratesContent(targetType string, targetID int, userID uuid.UUID) (bool, error) {
+	return moderatesContent(r.databaseClient, targetType, targetID, userID)
+}
+
+// Returns the revisions of the target, each with the changes its edit made. A target that was
+// never edited has no revisions. Deleted targets are not found
+func (r *PsqlRevisionRepository) FindRevisions(targetType string, id int) (*models.RevisionHistory, error) {
+	var (
+		history = models.RevisionHistory{TargetType: &targetType, TargetID: &id, Revisions: []models.Revision{}}
+		current models.Revision
+	)
+
+	t, ok := revisionTargets[targetType]
+	if !ok {
+		return nil, r.unsupportedErr
+	}
+
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	err = conn.QueryRow(context.Background(),
+		fmt.Sprintf(`SELECT user_id, hidden_at, title, %v, tags FROM %v WHERE id = $1 AND deleted_at IS NULL`, t.body, t.table),
+		id,
+	).Scan(&history.UserID, &history.HiddenAt, &current.Title, &current.Body, &current.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := conn.Query(context.Background(),
+		fmt.Sprintf(`SELECT title, %v, tags, edited_by, edited_at FROM %v WHERE %v = $1 ORDER BY id`, t.body, t.revisions, t.key),
+		id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var revision models.Revision
+		err = rows.Scan(&revision.Title, &revision.Body, &revision.Tags, &revision.EditedBy, &revision.EditedAt)
+		if err != nil {
+			return nil, err
+		}
+		history.Revisions = append(history.Revisions, revision)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range history.Revisions {
+		next := current
+		if i+1 < len(history.Revisions) {
+			next = history.Revisions[i+1]
+		}
+		number := i + 1
+		history.Revisions[i].Number = &number
+		history.Revisions[i].Diff = diffRevisions(history.Revisions[i], next)
+	}
+	return &history, nil
+}
+
+// Returns the changes from one revision to the next
+func diffRevisions(from, to models.Revision) *models.Diff {
+	var diff models.Diff
+
+	if deref(from.Title) != deref(to.Title) {
+		diff.Title = diffLines(deref(from.Title), deref(to.Title))
+	}
+	if deref(from.Body) != deref(to.Body) {
+		diff.Body = diffLines(deref(from.Body), deref(to.Body))
+	}
+
+	var fromTags, toTags []string
+	if from.Tags != nil {
+		fromTags = *from.Tags
+	}
+	if to.Tags != nil {
+		toTags = *to.Tags
+	}
+	for _, tag := range toTags {
+		if !slices.Contains(fromTags, tag) {
+			diff.AddedTags = append(diff.AddedTags, tag)
+		}
+	}
+	for _, tag := range fromTags {
+		if !slices.Contains(toTags, tag) {
+			diff.RemovedTags = append(diff.RemovedTags, tag)
+		}
+	}
+	return &diff
+}
+
+// Returns a line diff of the texts that keeps their longest common subsequence of lines
+func diffLines(from, to string) []models.DiffLine {
+	var (
+		a    = strings.Split(from, "\n")
+		b    = strings.Split(to, "\n")
+		diff []models.DiffLine
+	)
+
+	if len(a)*len(b) > maxDiffCells {
+		for _, line := range a {
+			diff = append(diff, models.DiffLine{Op: "delete", Text: line})
+		}
+		for _, line := range b {
+			diff = append(diff, models.DiffLine{Op: "insert", Text: line})
+		}
+		return diff
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			diff = append(diff, models.DiffLine{Op: "equal", Text: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			diff = append(diff, models.DiffLine{Op: "delete", Text: a[i]})
+			i++
+		default:
+			diff = append(diff, models.DiffLine{Op: "insert", Text: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		diff = append(diff, models.DiffLine{Op: "delete", Text: a[i]})
+	}
+	for ; j < len(b); j++ {
+		diff = append(diff, models.DiffLine{Op: "insert", Text: b[j]})
+	}
+	return diff
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,80 @@
+package psqlRepository
+
+import (
+	"testing"
+
+	"gamehangar/internal/domain/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindRevisions(t *testing.T) {
+	var (
+		editorID = uuid.New()
+		body     = "First line\nSecond line"
+		newBody  = "First line\nEdited line"
+		newTitle = "Edited title"
+	)
+	fr := NewPsqlForumRepository(testDBClient, testEnforcer)
+	r := NewPsqlRevisionRepository(testDBClient)
+	// Runs after teardownForum, so it brings its own thread
+	tp, err := fr.CreateTopic(models.Topic{Name: &topicName})
+	if !assert.NoError(t, err) {
+		return
+	}
+	th, err := fr.CreateThread(models.Thread{Title: &threadTitle, UserID: &userID, TopicID: tp.ID})
+	if !assert.NoError(t, err) {
+		return
+	}
+	m, err := fr.CreateMessage(models.Message{Title: &messageTitle, Body: &body, UserID: &userID, ThreadID: th.ID})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	history, err := r.FindRevisions("message", *m.ID)
+	if assert.NoError(t, err) {
+		assert.Empty(t, history.Revisions)
+	}
+
+	m, err = fr.UpdateMessage(*m.ID, models.Message{Body: &newBody, EditedBy: &userID})
+	if assert.NoError(t, err) {
+		assert.Equal(t, 1, *m.Revisions)
+		assert.NotNil(t, m.EditedAt)
+	}
+	m, err = fr.UpdateMessage(*m.ID, models.Message{Title: &newTitle, EditedBy: &editorID})
+	if assert.NoError(t, err) {
+		assert.Equal(t, 2, *m.Revisions)
+		assert.Equal(t, editorID, *m.EditedBy)
+	}
+	// Updates that leave the content as it is are no edits
+	m, err = fr.UpdateMessage(*m.ID, models.Message{Title: &newTitle})
+	if assert.NoError(t, err) {
+		assert.Equal(t, 2, *m.Revisions)
+		assert.Equal(t, editorID, *m.EditedBy)
+	}
+
+	history, err = r.FindRevisions("message", *m.ID)
+	if assert.NoError(t, err) && assert.Len(t, history.Revisions, 2) {
+		first, second := history.Revisions[0], history.Revisions[1]
+		assert.Equal(t, body, *first.Body)
+		assert.Equal(t, userID, *first.EditedBy)
+		assert.Equal(t, []models.DiffLine{
+			{Op: "equal", Text: "First line"},
+			{Op: "delete", Text: "Second line"},
+			{Op: "insert", Text: "Edited line"},
+		}, first.Diff.Body)
+		assert.Nil(t, first.Diff.Title)
+		assert.Equal(t, messageTitle, *second.Title)
+		assert.Equal(t, editorID, *second.EditedBy)
+		assert.Nil(t, second.Diff.Body)
+		assert.NotNil(t, second.Diff.Title)
+	}
+
+	_, err = r.FindRevisions("asset", *m.ID)
+	assert.Equal(t, r.UnsupportedErr(), err)
+	_, err = r.FindRevisions("message", -1)
+	assert.Equal(t, r.NotFoundErr(), err)
+
+	assert.NoError(t, fr.DeleteTopic(*tp.ID, userID))
+}
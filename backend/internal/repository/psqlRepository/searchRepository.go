@@ -0,0 +1,192 @@
+package psqlRepository
+
+import (
+	"context"
+	"fmt"
+	"gamehangar/internal/domain/models"
+	"strings"
+)
+
+type PsqlSearchRepository struct {
+	databaseClient psqlDatabaseClient
+}
+
+// Searched table of each result type. Text is what headlines are cut from, users are not tagged
+type searchSource struct {
+	table   string
+	ts      string
+	title   string
+	text    string
+	tags    string
+	visible string
+	idCast  string
+}
+
+var searchSources = map[string]searchSource{
+	"demo": {table: "demo.demos", ts: "demo_ts", title: "title", text: "title || ' ' || COALESCE(description, '')",
+		tags: "tags::TEXT[]", visible: "deleted_at IS NULL AND hidden_at IS NULL", idCast: "INTEGER"},
+	"asset": {table: "asset.assets", ts: "asset_ts", title: "name", text: "name || ' ' || COALESCE(description, '')",
+		tags: "tags::TEXT[]", visible: "deleted_at IS NULL AND hidden_at IS NULL", idCast: "INTEGER"},
+	"thread": {table: "forum.threads", ts: "thread_ts", title: "title", text: "title",
+		tags: "tags::TEXT[]", visible: "deleted_at IS NULL AND hidden_at IS NULL", idCast: "INTEGER"},
+	"message": {table: "forum.messages", ts: "message_ts", title: "title", text: "title || ' ' || COALESCE(body, '')",
+		tags: "tags::TEXT[]", visible: "deleted_at IS NULL AND hidden_at IS NULL AND " + threadOfMessageVisible("FALSE"), idCast: "INTEGER"},
+	"user": {table: `"user".users`, ts: "user_ts", title: "username", text: "username || ' ' || COALESCE(display_name, '')",
+		visible: "TRUE", idCast: "UUID"},
+}
+
+// Most used tags among the matches counted in the facets
+const searchFacetTags = 20
+
+// Requires PsqlDatabaseClient since it implements PostgeSQL-specific query logic
+func NewPsqlSearchRepository(dbClient psqlDatabaseClient) *PsqlSearchRepository {
+	return &PsqlSearchRepository{
+		databaseClient: dbClient,
+	}
+}
+
+func (r *PsqlSearchRepository) NotFoundErr() error { return r.databaseClient.ErrNoRows() }
+
+// Returns "Cursor is not valid!" when the page cursor is malformed, made for another type
+// or given for several types at once
+func (r *PsqlSearchRepository) CursorErr() error { return cursorErr }
+
+// Returns a page of matches of every searched type, the most relevant first, with the facets of the search.
+// Returns NotFoundErr when nothing of any type matches
+func (r *PsqlSearchRepository) Search(q models.SearchQuery) (*models.SearchResults, error) {
+	var (
+		counts  []string
+		tagged  []string
+		results = models.SearchResults{
+			Results: map[string]*models.Page[models.SearchResult]{},
+			Facets:  models.SearchFacets{Types: map[string]int64{}, Tags: map[string]int64{}},
+		}
+		matched int64
+	)
+
+	if q.Page.Cursor != "" && len(q.Types) != 1 {
+		return nil, cursorErr
+	}
+
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	// Every source takes the same arguments, users only the first one
+	_, args := searchSources["demo"].match(q)
+	for _, t := range models.SearchTypes {
+		source, _ := searchSources[t].match(q)
+		counts = append(counts, fmt.Sprintf(`SELECT '%v', COUNT(*) FROM (%v) AS matched`, t, source))
+		if searchSources[t].tags != "" {
+			tagged = append(tagged, fmt.Sprintf(`SELECT unnest(tags) AS slug FROM (%v) AS matched`, source))
+		}
+	}
+
+	rows, err := conn.Query(context.Background(), strings.Join(counts, ` UNION ALL `), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			t     string
+			count int64
+		)
+		err = rows.Scan(&t, &count)
+		if err != nil {
+			return nil, err
+		}
+		results.Facets.Types[t] = count
+		matched += count
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+	if matched == 0 {
+		return nil, r.NotFoundErr()
+	}
+
+	rows, err = conn.Query(context.Background(),
+		`SELECT slug, COUNT(*) FROM (`+strings.Join(tagged, ` UNION ALL `)+`) AS tagged
+		GROUP BY slug ORDER BY COUNT(*) DESC, slug LIMIT `+fmt.Sprint(searchFacetTags),
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			slug  string
+			count int64
+		)
+		err = rows.Scan(&slug, &count)
+		if err != nil {
+			return nil, err
+		}
+		results.Facets.Tags[slug] = count
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, t := range q.Types {
+		s := searchSources[t]
+		source, args := s.match(q)
+		page, err := queryPage[models.SearchResult](conn, pageQuery{
+			source:  source,
+			args:    args,
+			columns: fmt.Sprintf(`'%v', id::TEXT, title, ts_headline_multilang(text, $1), rank::FLOAT8, tags, created_at`, t),
+			order:   "relevance-" + t,
+			key:     sortKey{expr: "rank", cast: "REAL"},
+			idCast:  s.idCast,
+		}, q.Page)
+		if err != nil {
+			return nil, err
+		}
+		results.Results[t] = page
+	}
+	return &results, nil
+}
+
+// Returns the query selecting the matches of the search in the source and its arguments: the query,
+// the words tags are matched against and the tags to narrow by. Users never have the tags narrowed by
+func (s searchSource) match(q models.SearchQuery) (string, []any) {
+	var (
+		args  = []any{q.Query}
+		where = s.ts + ` @@ to_tsquery_multilang($1)`
+		rank  = `ts_rank(` + s.ts + `, to_tsquery_multilang($1))`
+		tags  = `NULL::TEXT[]`
+	)
+
+	if s.tags != "" {
+		tags = s.tags
+		args = append(args, searchWords(q.Query))
+		where = `(` + where + ` OR ` + s.tags + ` && tag.canonical($2))`
+		rank = rank + ` + CASE WHEN ` + s.tags + ` && tag.canonical($2) THEN 1::REAL ELSE 0::REAL END`
+		if len(q.Tags) != 0 {
+			args = append(args, q.Tags)
+			where = where + ` AND ` + s.tags + ` @> tag.canonical($3)`
+		}
+	} else if len(q.Tags) != 0 {
+		where = where + ` AND FALSE`
+	}
+
+	return fmt.Sprintf(`SELECT id, %v AS title, %v AS text, %v AS tags, created_at, %v AS rank FROM %v WHERE %v AND %v`,
+		s.title, s.text, tags, rank, s.table, s.visible, where), args
+}
+
+// Returns the query as a whole and its words, leaving out the excluded ones and the OR operator
+func searchWords(query string) []string {
+	words := []string{query}
+	for _, w := range strings.Fields(query) {
+		if !strings.HasPrefix(w, "-") && !strings.EqualFold(w, "or") {
+			words = append(words, strings.Trim(w, `"`))
+		}
+	}
+	return words
+}
@@ -0,0 +1,61 @@
+package psqlRepository
+
+import (
+	"gamehangar/internal/domain/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	searchTitle       = "Jumping through a searchable platformer"
+	searchTaggedTitle = "Castles and keys"
+	searchTags        = []string{"Searchable"}
+)
+
+func TestSearch(t *testing.T) {
+	fr := NewPsqlForumRepository(testDBClient, testEnforcer)
+	_, err := fr.CreateThread(models.Thread{Title: &searchTitle, UserID: &userID, TopicID: &topicID})
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = fr.CreateThread(models.Thread{Title: &searchTaggedTitle, Tags: &searchTags, UserID: &userID, TopicID: &topicID})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	r := NewPsqlSearchRepository(testDBClient)
+	results, err := r.Search(models.SearchQuery{Query: "searchable", Types: []string{"thread"}, Page: models.PageQuery{Limit: 1}})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, int64(2), results.Facets.Types["thread"])
+	assert.Equal(t, int64(1), results.Facets.Tags["searchable"])
+	assert.NotContains(t, results.Results, "demo")
+
+	// Tagged content ranks above text matches
+	threads := results.Results["thread"]
+	if !assert.Len(t, threads.Items, 1) || !assert.NotNil(t, threads.Next) {
+		return
+	}
+	assert.Equal(t, searchTaggedTitle, *threads.Items[0].Title)
+	assert.GreaterOrEqual(t, *threads.Items[0].Rank, 1.0)
+	results, err = r.Search(models.SearchQuery{Query: "searchable", Types: []string{"thread"}, Page: models.PageQuery{Limit: 1, Cursor: *threads.Next}})
+	if assert.NoError(t, err) && assert.Len(t, results.Results["thread"].Items, 1) {
+		result := results.Results["thread"].Items[0]
+		assert.Equal(t, searchTitle, *result.Title)
+		assert.Contains(t, *result.Headline, "<b>searchable</b>")
+	}
+
+	// Narrowing by tag keeps the tagged thread only
+	results, err = r.Search(models.SearchQuery{Query: "searchable", Types: models.SearchTypes, Tags: []string{"SEARCHABLE"}})
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(1), results.Facets.Types["thread"])
+		assert.Zero(t, results.Facets.Types["user"])
+	}
+
+	_, err = r.Search(models.SearchQuery{Query: "searchable", Types: models.SearchTypes, Page: models.PageQuery{Cursor: *threads.Next}})
+	assert.Equal(t, r.CursorErr(), err)
+	_, err = r.Search(models.SearchQuery{Query: "unsearchableness", Types: models.SearchTypes})
+	assert.Equal(t, r.NotFoundErr(), err)
+}
@@ -21,4 +21,5 @@ type ObjectUploader interface {
 	PutObject(objectKey string, file io.Reader) error
 	GetObjectLink(objectKey string) (*string, error)
 	DeleteObject(objectKey string) error
+	CopyObject(srcKey, dstKey string) error
 }
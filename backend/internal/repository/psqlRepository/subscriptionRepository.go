@@ -0,0 +1,277 @@
+package psqlRepository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gamehangar/internal/domain/models"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type PsqlSubscriptionRepository struct {
+	databaseClient psqlDatabaseClient
+	conflictErr    error
+	unsupportedErr error
+}
+
+// Column of each target type in subscription.subscriptions, its type and the table it references.
+// Any tag can be subscribed to, it is stored by its canonical slug and looked up by it
+type subscriptionTarget struct {
+	column string
+	cast   string
+	table  string
+	exists string
+	lookup string // Turns the target parameter into the stored value, a cast by default
+}
+
+// Returns the expression of the target parameter to compare the column to
+func (t subscriptionTarget) param(n int) string {
+	if t.lookup != "" {
+		return fmt.Sprintf(t.lookup, n)
+	}
+	return fmt.Sprintf(`$%v::TEXT::%v`, n, t.cast)
+}
+
+var subscriptionTargets = map[string]subscriptionTarget{
+	"thread": {column: "thread_id", cast: "INTEGER", table: "forum.threads", exists: "deleted_at IS NULL"},
+	"topic":  {column: "topic_id", cast: "INTEGER", table: "forum.topics", exists: "deleted_at IS NULL"},
+	"tag":    {column: "tag", cast: "VARCHAR", lookup: `(tag.canonical(ARRAY[$%v::TEXT]))[1]`},
+	"user":   {column: "followed_user_id", cast: "UUID", table: `"user".users`, exists: "TRUE"},
+}
+
+const subscriptionColumns = `(id, user_id,
+	CASE WHEN thread_id IS NOT NULL THEN 'thread' WHEN topic_id IS NOT NULL THEN 'topic' WHEN tag IS NOT NULL THEN 'tag' ELSE 'user' END,
+	COALESCE(thread_id::VARCHAR, topic_id::VARCHAR, tag, followed_user_id::VARCHAR),
+	created_at)`
+
+// Requires PsqlDatabaseClient since it implements PostgeSQL-specific query logic
+func NewPsqlSubscriptionRepository(dbClient psqlDatabaseClient) *PsqlSubscriptionRepository {
+	return &PsqlSubscriptionRepository{
+		databaseClient: dbClient,
+		conflictErr:    errors.New("Already subscribed!"),
+		unsupportedErr: errors.New("Subscriptions are not supported for this target!"),
+	}
+}
+
+func (r *PsqlSubscriptionRepository) NotFoundErr() error { return r.databaseClient.ErrNoRows() }
+
+// Returns "Already subscribed!" when the user is already subscribed to the target
+func (r *PsqlSubscriptionRepository) ConflictErr() error { return r.conflictErr }
+
+// Returns "Subscriptions are not supported for this target!" for unknown target types
+func (r *PsqlSubscriptionRepository) UnsupportedErr() error { return r.unsupportedErr }
+
+// Returns NotFoundErr when the thread, topic or user does not exist
+func (r *PsqlSubscriptionRepository) CreateSubscription(subscription models.Subscription) (*models.Subscription, error) {
+	target, ok := subscriptionTargets[*subscription.TargetType]
+	if !ok {
+		return nil, r.unsupportedErr
+	}
+
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	exists := `TRUE`
+	if target.table != "" {
+		exists = fmt.Sprintf(`EXISTS (SELECT 1 FROM %v WHERE id = $2::TEXT::%v AND %v)`, target.table, target.cast, target.exists)
+	}
+
+	err = conn.QueryRow(context.Background(),
+		fmt.Sprintf(`INSERT INTO subscription.subscriptions
+		(user_id, %v)
+		SELECT $1, $2::TEXT::%v
+		WHERE `+exists+`
+		RETURNING
+		`+subscriptionColumns, target.column, target.cast),
+		subscription.UserID, subscription.Target,
+	).Scan(&subscription)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, r.conflictErr
+		}
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+// Returns subscriptions of the user, most recent first
+func (r *PsqlSubscriptionRepository) FindSubscriptions(f models.SubscriptionFilter) (*[]models.Subscription, error) {
+	var (
+		subscriptions []models.Subscription
+		where         = []string{`user_id = $1`}
+		args          = []any{f.UserID}
+	)
+
+	if f.TargetType != "" {
+		target, ok := subscriptionTargets[f.TargetType]
+		if !ok {
+			return nil, r.unsupportedErr
+		}
+		where = append(where, target.column+` IS NOT NULL`)
+	}
+
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	query := `SELECT ` + subscriptionColumns + ` FROM subscription.subscriptions
+		WHERE ` + strings.Join(where, ` AND `) + ` ORDER BY created_at DESC, id DESC`
+	if f.Limit != 0 {
+		args = append(args, f.Limit)
+		query = query + fmt.Sprintf(` LIMIT $%v`, len(args))
+	}
+	if f.Offset != 0 {
+		args = append(args, f.Offset)
+		query = query + fmt.Sprintf(` OFFSET $%v`, len(args))
+	}
+
+	rows, err := conn.Query(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var subscription models.Subscription
+		err = rows.Scan(&subscription)
+		if err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+	if len(subscriptions) == 0 {
+		return nil, r.NotFoundErr()
+	}
+	return &subscriptions, nil
+}
+
+func (r *PsqlSubscriptionRepository) DeleteSubscription(userID uuid.UUID, targetType, target string) error {
+	t, ok := subscriptionTargets[targetType]
+	if !ok {
+		return r.unsupportedErr
+	}
+
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	ct, err := conn.Exec(context.Background(),
+		fmt.Sprintf(`DELETE FROM subscription.subscriptions WHERE user_id = $1 AND %v = %v`, t.column, t.param(2)),
+		userID, target,
+	)
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return r.NotFoundErr()
+	}
+	return nil
+}
+
+func (r *PsqlSubscriptionRepository) CountSubscribers(targetType, target string) (int, error) {
+	var count int
+
+	t, ok := subscriptionTargets[targetType]
+	if !ok {
+		return 0, r.unsupportedErr
+	}
+
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Release()
+
+	err = conn.QueryRow(context.Background(),
+		fmt.Sprintf(`SELECT COUNT(*) FROM subscription.subscriptions WHERE %v = %v`, t.column, t.param(1)),
+		target,
+	).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// Returns new demos, assets and threads matching the subscriptions of the user, newest first.
+// Demos and threads match by tag and author, assets by tag only and threads also by topic.
+// Threads of demos are left out, the demo stands for them. Nothing is ranked
+func (r *PsqlSubscriptionRepository) FindFeed(f models.FeedFilter) (*[]models.FeedItem, error) {
+	var (
+		items []models.FeedItem
+		where = []string{`TRUE`}
+		args  = []any{f.UserID}
+	)
+
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	if f.Before != nil {
+		args = append(args, f.Before)
+		where = append(where, fmt.Sprintf(`created_at < $%v`, len(args)))
+	}
+
+	query := `WITH s AS (SELECT * FROM subscription.subscriptions WHERE user_id = $1),
+		followed_tags AS (SELECT ARRAY(SELECT tag::TEXT FROM s WHERE tag IS NOT NULL) AS tags)
+		SELECT (type, id, title, user_id, tags, created_at) FROM (
+			SELECT 'demo' AS type, d.id, d.title, d.user_id, d.tags, d.created_at
+			FROM demo.demos d
+			WHERE d.deleted_at IS NULL AND d.hidden_at IS NULL AND d.user_id <> $1
+				AND (d.tags && (SELECT tags FROM followed_tags) OR d.user_id IN (SELECT followed_user_id FROM s))
+			UNION ALL
+			SELECT 'asset', a.id, a.name, NULL::UUID, a.tags, a.created_at
+			FROM asset.assets a
+			WHERE a.deleted_at IS NULL AND a.hidden_at IS NULL AND a.tags && (SELECT tags FROM followed_tags)
+			UNION ALL
+			SELECT 'thread', t.id, t.title, t.user_id, t.tags::TEXT[], t.created_at
+			FROM forum.threads t
+			WHERE t.deleted_at IS NULL AND t.hidden_at IS NULL AND t.user_id <> $1
+				AND NOT EXISTS (SELECT 1 FROM demo.demos WHERE thread_id = t.id)
+				AND (t.tags::TEXT[] && (SELECT tags FROM followed_tags) OR t.user_id IN (SELECT followed_user_id FROM s)
+					OR t.topic_id IN (SELECT topic_id FROM s))
+		) feed
+		WHERE ` + strings.Join(where, ` AND `) + `
+		ORDER BY created_at DESC, type, id DESC`
+	if f.Limit != 0 {
+		args = append(args, f.Limit)
+		query = query + fmt.Sprintf(` LIMIT $%v`, len(args))
+	}
+
+	rows, err := conn.Query(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var item models.FeedItem
+		err = rows.Scan(&item)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, r.NotFoundErr()
+	}
+	return &items, nil
+}
@@ -0,0 +1,128 @@
+package psqlRepository
+
+import (
+	"gamehangar/internal/domain/models"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	subscribedThreadID int
+	subscribedTopicID  int
+
+	subscriptionTag = "subscribed"
+	subscribedAt    time.Time
+)
+
+func TestCreateSubscription(t *testing.T) {
+	fr := NewPsqlForumRepository(testDBClient, testEnforcer)
+	th, err := fr.CreateThread(thread)
+	if !assert.NoError(t, err) {
+		return
+	}
+	subscribedThreadID = *th.ID
+	to, err := fr.CreateTopic(topic)
+	if !assert.NoError(t, err) {
+		return
+	}
+	subscribedTopicID = *to.ID
+
+	r := NewPsqlSubscriptionRepository(testDBClient)
+	for _, target := range [][2]string{
+		{"thread", strconv.Itoa(subscribedThreadID)},
+		{"topic", strconv.Itoa(subscribedTopicID)},
+		{"tag", subscriptionTag},
+	} {
+		subscription, err := r.CreateSubscription(models.Subscription{UserID: &userID, TargetType: &target[0], Target: &target[1]})
+		if assert.NoError(t, err) {
+			assert.Equal(t, target[0], *subscription.TargetType)
+			assert.Equal(t, target[1], *subscription.Target)
+			subscribedAt = *subscription.CreatedAt
+		}
+
+		_, err = r.CreateSubscription(models.Subscription{UserID: &userID, TargetType: &target[0], Target: &target[1]})
+		assert.Equal(t, r.ConflictErr(), err)
+	}
+
+	targetType, target := "user", uuid.NewString()
+	_, err = r.CreateSubscription(models.Subscription{UserID: &userID, TargetType: &targetType, Target: &target})
+	assert.Equal(t, r.NotFoundErr(), err)
+
+	th, err = fr.FindThreadByID(subscribedThreadID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 1, *th.Subscribers)
+	}
+	to, err = fr.FindTopicByID(subscribedTopicID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 1, *to.Subscribers)
+	}
+	count, err := r.CountSubscribers("tag", subscriptionTag)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 1, count)
+	}
+}
+
+func TestFindSubscriptions(t *testing.T) {
+	r := NewPsqlSubscriptionRepository(testDBClient)
+	subscriptions, err := r.FindSubscriptions(models.SubscriptionFilter{UserID: userID})
+	if assert.NoError(t, err) {
+		assert.Len(t, *subscriptions, 3)
+	}
+
+	subscriptions, err = r.FindSubscriptions(models.SubscriptionFilter{UserID: userID, TargetType: "tag"})
+	if assert.NoError(t, err) && assert.Len(t, *subscriptions, 1) {
+		assert.Equal(t, subscriptionTag, *(*subscriptions)[0].Target)
+	}
+
+	_, err = r.FindSubscriptions(models.SubscriptionFilter{UserID: userID, TargetType: "user"})
+	assert.Equal(t, r.NotFoundErr(), err)
+}
+
+func TestFindFeed(t *testing.T) {
+	var (
+		author        = uuid.New()
+		inTopicTitle  = "In subscribed topic"
+		withTagTitle  = "With subscribed tag"
+		otherTitle    = "Not subscribed"
+		subscribedTag = []string{subscriptionTag}
+	)
+
+	fr := NewPsqlForumRepository(testDBClient, testEnforcer)
+	for _, th := range []models.Thread{
+		{Title: &inTopicTitle, UserID: &author, TopicID: &subscribedTopicID},
+		{Title: &withTagTitle, UserID: &author, TopicID: &topicID, Tags: &subscribedTag},
+		{Title: &otherTitle, UserID: &author, TopicID: &topicID},
+	} {
+		_, err := fr.CreateThread(th)
+		if !assert.NoError(t, err) {
+			return
+		}
+	}
+
+	r := NewPsqlSubscriptionRepository(testDBClient)
+	feed, err := r.FindFeed(models.FeedFilter{UserID: userID, Limit: 10})
+	if assert.NoError(t, err) && assert.Len(t, *feed, 2) {
+		assert.Equal(t, withTagTitle, *(*feed)[0].Title)
+		assert.Equal(t, inTopicTitle, *(*feed)[1].Title)
+		assert.Equal(t, "thread", *(*feed)[1].Type)
+	}
+
+	_, err = r.FindFeed(models.FeedFilter{UserID: userID, Before: &subscribedAt})
+	assert.Equal(t, r.NotFoundErr(), err)
+}
+
+func TestDeleteSubscription(t *testing.T) {
+	r := NewPsqlSubscriptionRepository(testDBClient)
+	assert.NoError(t, r.DeleteSubscription(userID, "thread", strconv.Itoa(subscribedThreadID)))
+	assert.Equal(t, r.NotFoundErr(), r.DeleteSubscription(userID, "thread", strconv.Itoa(subscribedThreadID)))
+
+	fr := NewPsqlForumRepository(testDBClient, testEnforcer)
+	th, err := fr.FindThreadByID(subscribedThreadID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 0, *th.Subscribers)
+	}
+}
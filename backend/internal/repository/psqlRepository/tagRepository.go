@@ -0,0 +1,322 @@
+package psqlRepository
+
+import (
+	"context"
+	"errors"
+	"gamehangar/internal/domain/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type PsqlTagRepository struct {
+	databaseClient psqlDatabaseClient
+	conflictErr    error
+	renameErr      error
+	mergeErr       error
+}
+
+// Usage leaves out content types the tag is not used with
+const tagColumns = `t.id, t.slug, t.name,
+	(SELECT jsonb_object_agg(u.content_type, u.count) FROM tag.usage u WHERE u.tag_id = t.id AND u.count > 0),
+	ARRAY(SELECT a.slug FROM tag.aliases a WHERE a.tag_id = t.id ORDER BY a.slug),
+	t.created_at`
+
+// Slug of the tag the parameter names, following aliases
+const tagLookup = `(tag.canonical(ARRAY[$1::TEXT]))[1]`
+
+// Requires PsqlDatabaseClient since it implements PostgeSQL-specific query logic
+func NewPsqlTagRepository(dbClient psqlDatabaseClient) *PsqlTagRepository {
+	return &PsqlTagRepository{
+		databaseClient: dbClient,
+		conflictErr:    errors.New("Tag or alias already exists!"),
+		renameErr:      errors.New("Name does not match the tag!"),
+		mergeErr:       errors.New("Tag cannot be merged into itself!"),
+	}
+}
+
+func (r *PsqlTagRepository) NotFoundErr() error { return r.databaseClient.ErrNoRows() }
+
+// Returns "Tag or alias already exists!" when the alias is taken by a tag or another alias
+func (r *PsqlTagRepository) ConflictErr() error { return r.conflictErr }
+
+// Returns "Name does not match the tag!" when the new name has another slug
+func (r *PsqlTagRepository) RenameErr() error { return r.renameErr }
+
+// Returns "Tag cannot be merged into itself!" when both tags are the same
+func (r *PsqlTagRepository) MergeErr() error { return r.mergeErr }
+
+// Returns tags starting with the prefix or having an alias that does, the most used first.
+// With a content type only tags of that type are returned, ordered by their use with it
+func (r *PsqlTagRepository) FindTags(f models.TagFilter) (*[]models.Tag, error) {
+	var tags []models.Tag
+
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(context.Background(),
+		`SELECT `+tagColumns+` FROM tag.tags t
+		WHERE ($1 = '' OR t.slug LIKE tag.slugify($1) || '%'
+			OR EXISTS (SELECT 1 FROM tag.aliases a WHERE a.tag_id = t.id AND a.slug LIKE tag.slugify($1) || '%'))
+		AND ($2 = '' OR EXISTS (SELECT 1 FROM tag.usage u WHERE u.tag_id = t.id AND u.content_type = $2 AND u.count > 0))
+		ORDER BY (SELECT COALESCE(SUM(u.count), 0) FROM tag.usage u WHERE u.tag_id = t.id AND ($2 = '' OR u.content_type = $2)) DESC,
+			t.slug
+		LIMIT $3`,
+		f.Prefix, f.ContentType, f.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		tag, err := scanTag(rows)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, *tag)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+	if len(tags) == 0 {
+		return nil, r.NotFoundErr()
+	}
+	return &tags, nil
+}
+
+// Finds the tag by its slug or an alias, which may be spelled in any way
+func (r *PsqlTagRepository) FindTagBySlug(slug string) (*models.Tag, error) {
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	return scanTag(conn.QueryRow(context.Background(),
+		`SELECT `+tagColumns+` FROM tag.tags t WHERE t.slug = `+tagLookup,
+		slug,
+	))
+}
+
+// Renames the tag, the name must have the slug of the tag. Whitespace in the name is collapsed
+func (r *PsqlTagRepository) UpdateTag(slug string, tag models.Tag) (*models.Tag, error) {
+	var current, renamed string
+
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	err = conn.QueryRow(context.Background(),
+		`SELECT slug, tag.slugify($2) FROM tag.tags WHERE slug = `+tagLookup,
+		slug, tag.Name,
+	).Scan(&current, &renamed)
+	if err != nil {
+		return nil, err
+	}
+	if current != renamed {
+		return nil, r.renameErr
+	}
+
+	return scanTag(conn.QueryRow(context.Background(),
+		`UPDATE tag.tags t SET name = regexp_replace(trim($2), '[[:space:]]+', ' ', 'g')
+		WHERE t.slug = $1
+		RETURNING `+tagColumns,
+		current, tag.Name,
+	))
+}
+
+// Makes the slug of the alias resolve to the tag. Returns NotFoundErr when there is no such tag
+func (r *PsqlTagRepository) CreateTagAlias(slug string, alias models.TagAlias, userID uuid.UUID) (*models.Tag, error) {
+	var (
+		tagID int
+		taken bool
+	)
+
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	err = conn.QueryRow(context.Background(),
+		`SELECT id, EXISTS (SELECT 1 FROM tag.tags WHERE slug = tag.slugify($2))
+		FROM tag.tags WHERE slug = `+tagLookup,
+		slug, alias.Slug,
+	).Scan(&tagID, &taken)
+	if err != nil {
+		return nil, err
+	}
+	// Used tags are merged rather than aliased, so their content is retagged
+	if taken {
+		return nil, r.conflictErr
+	}
+
+	_, err = conn.Exec(context.Background(),
+		`INSERT INTO tag.aliases (slug, tag_id, created_by) VALUES (tag.slugify($1), $2, $3)`,
+		alias.Slug, tagID, userID,
+	)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, r.conflictErr
+		}
+		return nil, err
+	}
+
+	return scanTag(conn.QueryRow(context.Background(),
+		`SELECT `+tagColumns+` FROM tag.tags t WHERE t.id = $1`,
+		tagID,
+	))
+}
+
+// Content keeps the canonical slug of the tag, so removing an alias only stops resolving it
+func (r *PsqlTagRepository) DeleteTagAlias(slug, alias string) error {
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	ct, err := conn.Exec(context.Background(),
+		`DELETE FROM tag.aliases a USING tag.tags t
+		WHERE a.tag_id = t.id AND t.slug = `+tagLookup+` AND a.slug = tag.slugify($2)`,
+		slug, alias,
+	)
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return r.NotFoundErr()
+	}
+	return nil
+}
+
+// Merges the tag into another one, whose alias it becomes along with its own aliases.
+// Content tagged with it is retagged as edited by the user, subscriptions to it move over
+func (r *PsqlTagRepository) MergeTags(slug string, merge models.TagMerge, userID uuid.UUID) (*models.Tag, error) {
+	var (
+		sourceID, targetID int
+		sourceSlug         string
+	)
+
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(context.Background())
+
+	err = tx.QueryRow(context.Background(),
+		`SELECT id, slug FROM tag.tags WHERE slug = `+tagLookup+` FOR UPDATE`,
+		slug,
+	).Scan(&sourceID, &sourceSlug)
+	if err != nil {
+		return nil, err
+	}
+	err = tx.QueryRow(context.Background(),
+		`SELECT id FROM tag.tags WHERE slug = `+tagLookup+` FOR UPDATE`,
+		merge.Into,
+	).Scan(&targetID)
+	if err != nil {
+		return nil, err
+	}
+	if sourceID == targetID {
+		return nil, r.mergeErr
+	}
+	change := auditChange{actorID: &userID, action: "merge", table: "tag.tags", targetType: "tags", targetID: sourceID}
+	err = change.snapshot(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(context.Background(), `UPDATE tag.aliases SET tag_id = $2 WHERE tag_id = $1`, sourceID, targetID)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec(context.Background(), `DELETE FROM tag.tags WHERE id = $1`, sourceID)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec(context.Background(),
+		`INSERT INTO tag.aliases (slug, tag_id, created_by) VALUES ($1, $2, $3)`,
+		sourceSlug, targetID, userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Rewriting the tags resolves the new alias, recording revisions where they are kept
+	for _, table := range []string{`demo.demos`, `forum.threads`, `forum.messages`} {
+		_, err = tx.Exec(context.Background(),
+			`UPDATE `+table+` SET tags = tags, edited_by = $2 WHERE tags::TEXT[] @> ARRAY[$1::TEXT]`,
+			sourceSlug, userID,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+	_, err = tx.Exec(context.Background(),
+		`UPDATE asset.assets SET tags = tags WHERE tags @> ARRAY[$1::TEXT]`,
+		sourceSlug,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(context.Background(),
+		`DELETE FROM subscription.subscriptions s
+		WHERE s.tag = $1 AND EXISTS (
+			SELECT 1 FROM subscription.subscriptions d
+			JOIN tag.tags t ON t.slug = d.tag
+			WHERE d.user_id = s.user_id AND t.id = $2
+		)`,
+		sourceSlug, targetID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec(context.Background(), `UPDATE subscription.subscriptions SET tag = tag WHERE tag = $1`, sourceSlug)
+	if err != nil {
+		return nil, err
+	}
+	err = change.record(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	tag, err := scanTag(tx.QueryRow(context.Background(),
+		`SELECT `+tagColumns+` FROM tag.tags t WHERE t.id = $1`,
+		targetID,
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return tag, nil
+}
+
+func scanTag(row pgx.Row) (*models.Tag, error) {
+	var tag models.Tag
+
+	err := row.Scan(&tag.ID, &tag.Slug, &tag.Name, &tag.Usage, &tag.Aliases, &tag.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
@@ -0,0 +1,114 @@
+package psqlRepository
+
+import (
+	"gamehangar/internal/domain/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	taggedThreadID int
+
+	tagSpellings = []string{" Pixel  Art ", "pixel-art", "PIXEL_ART", "Voxels", "C#"}
+)
+
+func TestTagNormalization(t *testing.T) {
+	fr := NewPsqlForumRepository(testDBClient, testEnforcer)
+	th, err := fr.CreateThread(models.Thread{Title: &threadTitle, Tags: &tagSpellings, UserID: &userID, TopicID: &topicID})
+	if !assert.NoError(t, err) {
+		return
+	}
+	taggedThreadID = *th.ID
+	assert.Equal(t, []string{"pixel-art", "voxels", "c#"}, *th.Tags)
+
+	r := NewPsqlTagRepository(testDBClient)
+	tag, err := r.FindTagBySlug("Pixel Art")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "pixel-art", *tag.Slug)
+		assert.Equal(t, "Pixel Art", *tag.Name)
+		assert.Equal(t, 1, tag.Usage["thread"])
+	}
+
+	// Deleted content is not counted
+	err = fr.DeleteThread(taggedThreadID, userID)
+	if assert.NoError(t, err) {
+		tag, err = r.FindTagBySlug("pixel-art")
+		if assert.NoError(t, err) {
+			assert.Zero(t, tag.Usage["thread"])
+		}
+	}
+	assert.NoError(t, fr.RestoreThread(taggedThreadID, &userID))
+}
+
+func TestFindTags(t *testing.T) {
+	r := NewPsqlTagRepository(testDBClient)
+	tags, err := r.FindTags(models.TagFilter{Prefix: "PIX", ContentType: "thread", Limit: 10})
+	if assert.NoError(t, err) && assert.Len(t, *tags, 1) {
+		assert.Equal(t, "pixel-art", *(*tags)[0].Slug)
+	}
+
+	_, err = r.FindTags(models.TagFilter{Prefix: "pix", ContentType: "asset", Limit: 10})
+	assert.Equal(t, r.NotFoundErr(), err)
+}
+
+func TestUpdateTag(t *testing.T) {
+	r := NewPsqlTagRepository(testDBClient)
+	name := "Pixel-Art"
+	tag, err := r.UpdateTag("pixel art", models.Tag{Name: &name})
+	if assert.NoError(t, err) {
+		assert.Equal(t, name, *tag.Name)
+	}
+
+	name = "Pixels"
+	_, err = r.UpdateTag("pixel-art", models.Tag{Name: &name})
+	assert.Equal(t, r.RenameErr(), err)
+	_, err = r.UpdateTag("unknown", models.Tag{Name: &name})
+	assert.Equal(t, r.NotFoundErr(), err)
+}
+
+func TestTagAliases(t *testing.T) {
+	r := NewPsqlTagRepository(testDBClient)
+	alias := "Sprites"
+	tag, err := r.CreateTagAlias("pixel-art", models.TagAlias{Slug: &alias}, userID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"sprites"}, tag.Aliases)
+	}
+	_, err = r.CreateTagAlias("voxels", models.TagAlias{Slug: &alias}, userID)
+	assert.Equal(t, r.ConflictErr(), err)
+	alias = "Voxels"
+	_, err = r.CreateTagAlias("pixel-art", models.TagAlias{Slug: &alias}, userID)
+	assert.Equal(t, r.ConflictErr(), err)
+
+	// New content tagged with the alias gets the tag
+	fr := NewPsqlForumRepository(testDBClient, testEnforcer)
+	tags := []string{"SPRITES"}
+	th, err := fr.CreateThread(models.Thread{Title: &threadTitle, Tags: &tags, UserID: &userID, TopicID: &topicID})
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"pixel-art"}, *th.Tags)
+	}
+
+	assert.NoError(t, r.DeleteTagAlias("pixel-art", "sprites"))
+	assert.Equal(t, r.NotFoundErr(), r.DeleteTagAlias("pixel-art", "sprites"))
+}
+
+func TestMergeTags(t *testing.T) {
+	r := NewPsqlTagRepository(testDBClient)
+	into := "Pixel Art"
+	tag, err := r.MergeTags("voxels", models.TagMerge{Into: &into}, userID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "pixel-art", *tag.Slug)
+		assert.Contains(t, tag.Aliases, "voxels")
+	}
+
+	fr := NewPsqlForumRepository(testDBClient, testEnforcer)
+	th, err := fr.FindThreadByID(taggedThreadID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"pixel-art", "c#"}, *th.Tags)
+	}
+
+	_, err = r.MergeTags("voxels", models.TagMerge{Into: &into}, userID)
+	assert.Equal(t, r.MergeErr(), err)
+	_, err = r.FindTags(models.TagFilter{Prefix: "vox", ContentType: "thread", Limit: 10})
+	assert.NoError(t, err)
+}
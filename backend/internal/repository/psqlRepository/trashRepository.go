@@ -0,0 +1,210 @@
+package psqlRepository
+
+import (
+	"context"
+	"fmt"
+	"gamehangar/internal/domain/models"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PsqlTrashRepository struct {
+	databaseClient psqlDatabaseClient
+	objectUploader ObjectUploader
+	enforcer       Enforcer
+	retention      time.Duration
+}
+
+// Describes how to purge expired rows of a single table.
+// selectQuery takes the retention in seconds and returns ids with their object keys
+type purgeSpec struct {
+	table       string
+	targetType  string // Target type of the purge in the audit log
+	selectQuery string
+	policies    [][2]string // Object format and action of owner policies
+}
+
+// Order matters: demos go before the threads they reference, threads before their topics
+var purgeSpecs = []purgeSpec{
+	{
+		table:      "demo.demos",
+		targetType: "demos",
+		selectQuery: `SELECT id, ARRAY[object_key, thumbnail_key] FROM demo.demos
+			WHERE deleted_at < NOW() - make_interval(secs => $1)`,
+		policies: [][2]string{{"demos/%v", "PATCH"}, {"demos/%v", "DELETE"}, {"demos/%v/assets", "PUT"}},
+	},
+	{
+		table:      "asset.assets",
+		targetType: "assets",
+		selectQuery: `SELECT id, ARRAY[object_key, thumbnail_key] FROM asset.assets
+			WHERE deleted_at < NOW() - make_interval(secs => $1)`,
+	},
+	{
+		// Messages with replies stay as tombstones until their replies are purged
+		table:      "forum.messages",
+		targetType: "messages",
+		selectQuery: `SELECT id, ARRAY[]::TEXT[] FROM forum.messages m
+			WHERE deleted_at < NOW() - make_interval(secs => $1)
+			AND NOT EXISTS (SELECT 1 FROM forum.messages r WHERE r.reply_to = m.id)`,
+		policies: [][2]string{{"messages/%v", "PATCH"}, {"messages/%v", "DELETE"}},
+	},
+	{
+		// A thread still referenced by a demo would take the demo with it
+		table:      "forum.threads",
+		targetType: "threads",
+		selectQuery: `SELECT id, ARRAY[]::TEXT[] FROM forum.threads t
+			WHERE deleted_at < NOW() - make_interval(secs => $1)
+			AND NOT EXISTS (SELECT 1 FROM demo.demos d WHERE d.thread_id = t.id)`,
+		policies: [][2]string{{"threads/%v", "PATCH"}, {"threads/%v", "DELETE"}},
+	},
+	{
+		table:      "forum.topics",
+		targetType: "topics",
+		selectQuery: `SELECT id, ARRAY[]::TEXT[] FROM forum.topics tp
+			WHERE deleted_at < NOW() - make_interval(secs => $1)
+			AND NOT EXISTS (SELECT 1 FROM forum.threads t WHERE t.topic_id = tp.id)`,
+	},
+}
+
+// Requires PsqlDatabaseClient since it implements PostgeSQL-specific query logic
+func NewPsqlTrashRepository(dbClient psqlDatabaseClient, o ObjectUploader, e Enforcer, retention time.Duration) *PsqlTrashRepository {
+	return &PsqlTrashRepository{
+		databaseClient: dbClient,
+		objectUploader: o,
+		enforcer:       e,
+		retention:      retention, // Time deleted content is kept before purging
+	}
+}
+
+func (r *PsqlTrashRepository) NotFoundErr() error { return r.databaseClient.ErrNoRows() }
+
+// Returns content deleted by the user. Children deleted together with their parent are not listed
+func (r *PsqlTrashRepository) FindTrash(userID uuid.UUID) (*[]models.TrashItem, error) {
+	var trash []models.TrashItem
+
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(context.Background(),
+		`SELECT (type, id, title, deleted_at, deleted_by, deleted_at + make_interval(secs => $2))
+		FROM
+			((SELECT 'demo' AS type, id, title, deleted_at, deleted_by
+			FROM demo.demos WHERE deleted_by = $1 AND deleted_at IS NOT NULL)
+		UNION ALL
+			(SELECT 'asset', id, name, deleted_at, deleted_by
+			FROM asset.assets WHERE deleted_by = $1 AND deleted_at IS NOT NULL)
+		UNION ALL
+			(SELECT 'topic', id, name, deleted_at, deleted_by
+			FROM forum.topics WHERE deleted_by = $1 AND deleted_at IS NOT NULL)
+		UNION ALL
+			(SELECT 'thread', t.id, t.title, t.deleted_at, t.deleted_by
+			FROM forum.threads t WHERE t.deleted_by = $1 AND t.deleted_at IS NOT NULL
+			AND NOT EXISTS (SELECT 1 FROM forum.topics tp WHERE tp.id = t.topic_id AND tp.deleted_at = t.deleted_at))
+		UNION ALL
+			(SELECT 'message', m.id, m.title, m.deleted_at, m.deleted_by
+			FROM forum.messages m WHERE m.deleted_by = $1 AND m.deleted_at IS NOT NULL
+			AND NOT EXISTS (SELECT 1 FROM forum.threads t WHERE t.id = m.thread_id AND t.deleted_at = m.deleted_at))) trash
+		ORDER BY deleted_at DESC`,
+		userID, r.retention.Seconds(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var item models.TrashItem
+		err = rows.Scan(&item)
+		if err != nil {
+			return nil, err
+		}
+		trash = append(trash, item)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+	if len(trash) == 0 {
+		return nil, r.NotFoundErr()
+	}
+	return &trash, nil
+}
+
+// Permanently removes content deleted longer than the retention period ago,
+// along with its objects and Casbin policies. Returns the number of purged records
+func (r *PsqlTrashRepository) PurgeTrash() (int64, error) {
+	var purged int64
+
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Release()
+
+	for _, spec := range purgeSpecs {
+		n, err := r.purge(conn, spec)
+		purged += n
+		if err != nil {
+			return purged, err
+		}
+	}
+	return purged, nil
+}
+
+func (r *PsqlTrashRepository) purge(conn *pgxpool.Conn, spec purgeSpec) (int64, error) {
+	var (
+		purged int64
+		ids    []int
+		keys   [][]string
+	)
+
+	rows, err := conn.Query(context.Background(), spec.selectQuery, r.retention.Seconds())
+	if err != nil {
+		return 0, err
+	}
+	for rows.Next() {
+		var (
+			id         int
+			objectKeys []string
+		)
+		err = rows.Scan(&id, &objectKeys)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+		keys = append(keys, objectKeys)
+	}
+	rows.Close()
+	err = rows.Err()
+	if err != nil {
+		return 0, err
+	}
+
+	// Objects go first so that a failed object removal is retried on the next purge
+	for i, id := range ids {
+		for _, key := range keys[i] {
+			err = r.objectUploader.DeleteObject(key)
+			if err != nil {
+				return purged, err
+			}
+		}
+		change := auditChange{action: "purge", table: spec.table, targetType: spec.targetType, targetID: id}
+		_, err = change.exec(conn, `DELETE FROM `+spec.table+` WHERE id=$1`, id)
+		if err != nil {
+			return purged, err
+		}
+		for _, p := range spec.policies {
+			_, err = r.enforcer.RemovePermissionsForObject(fmt.Sprintf(p[0], id), p[1])
+			if err != nil {
+				return purged, err
+			}
+		}
+		purged++
+	}
+	return purged, nil
+}
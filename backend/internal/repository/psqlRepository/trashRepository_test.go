@@ -0,0 +1,39 @@
+package psqlRepository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Runs after the other repository tests, which leave their deleted records in the trash
+func TestFindTrash(t *testing.T) {
+	r := NewPsqlTrashRepository(testDBClient, testS3Client, testEnforcer, time.Hour)
+	trash, err := r.FindTrash(userID)
+	if assert.NoError(t, err) {
+		assert.NotEmpty(t, *trash)
+		for _, item := range *trash {
+			assert.Equal(t, userID, *item.DeletedBy)
+			assert.Equal(t, item.DeletedAt.Add(time.Hour), *item.PurgeAt)
+		}
+	}
+}
+
+func TestPurgeTrashRetention(t *testing.T) {
+	r := NewPsqlTrashRepository(testDBClient, testS3Client, testEnforcer, time.Hour)
+	purged, err := r.PurgeTrash()
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(0), purged)
+	}
+}
+
+func TestPurgeTrash(t *testing.T) {
+	r := NewPsqlTrashRepository(testDBClient, testS3Client, testEnforcer, 0)
+	purged, err := r.PurgeTrash()
+	if assert.NoError(t, err) {
+		assert.Greater(t, purged, int64(0))
+	}
+	_, err = r.FindTrash(userID)
+	assert.Equal(t, r.NotFoundErr(), err)
+}
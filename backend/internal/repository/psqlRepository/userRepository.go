@@ -2,12 +2,13 @@ package psqlRepository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"gamehangar/internal/domain/models"
 	"io"
 
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 type PsqlUserRepository struct {
@@ -26,6 +27,9 @@ func NewPsqlUserRepository(dbClient psqlDatabaseClient, e Enforcer, o ObjectUplo
 }
 func (r *PsqlUserRepository) NotFoundErr() error { return r.databaseClient.ErrNoRows() }
 
+// Returns "Cursor is not valid!" when the page cursor is malformed or made for another order
+func (r *PsqlUserRepository) CursorErr() error { return cursorErr }
+
 func (r *PsqlUserRepository) CreateUser(user models.User, profilePic io.Reader) (*models.User, error) {
 	conn, err := r.databaseClient.AcquireConn()
 	if err != nil {
@@ -33,7 +37,13 @@ func (r *PsqlUserRepository) CreateUser(user models.User, profilePic io.Reader)
 	}
 	defer conn.Release()
 
-	err = conn.QueryRow(context.Background(),
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(context.Background())
+
+	err = tx.QueryRow(context.Background(),
 		`INSERT INTO "user".users
 			(username, display_name, email, password, role) 
 		VALUES
@@ -45,6 +55,15 @@ func (r *PsqlUserRepository) CreateUser(user models.User, profilePic io.Reader)
 	if err != nil {
 		return nil, err
 	}
+	change := auditChange{actorID: user.ID, action: "create", table: `"user".users`, targetType: "users", targetID: *user.ID}
+	err = change.record(tx)
+	if err != nil {
+		return nil, err
+	}
+	err = tx.Commit(context.Background())
+	if err != nil {
+		return nil, err
+	}
 
 	if profilePic != nil {
 		err = r.objectUploader.PutObject(*user.Username, profilePic)
@@ -65,6 +84,10 @@ func (r *PsqlUserRepository) CreateUser(user models.User, profilePic io.Reader)
 	if err != nil {
 		return nil, err
 	}
+	_, err = r.enforcer.AddPermissions(user.ID.String(), "users/"+user.ID.String()+"/sanctions", "GET")
+	if err != nil {
+		return nil, err
+	}
 	return &user, nil
 }
 
@@ -77,7 +100,7 @@ func (r *PsqlUserRepository) FindUserByID(id uuid.UUID) (*models.User, error) {
 	defer conn.Release()
 
 	err = conn.QueryRow(context.Background(),
-		`SELECT (id, username, display_name, email, password, verified, role, created_at, karma)
+		`SELECT (id, username, display_name, email, password, verified, role, created_at, karma, subscribers)
 		FROM "user".users WHERE id = $1 LIMIT 1`,
 		id,
 	).Scan(&user)
@@ -128,69 +151,40 @@ func (r *PsqlUserRepository) FindUserByUsername(username string) (*models.User,
 	return &user, nil
 }
 
-func (r *PsqlUserRepository) FindUsers(keywords []string, limit uint64) (*[]models.User, error) {
-	var users []models.User
-
+// Returns the page of users whose email or username contains the first keyword, or whose username is
+// similar to it when few do, all of them without keywords. Users are listed by karma
+func (r *PsqlUserRepository) FindUsers(keywords []string, page models.PageQuery) (*models.Page[models.User], error) {
 	conn, err := r.databaseClient.AcquireConn()
 	if err != nil {
 		return nil, err
 	}
 	defer conn.Release()
 
-	var rows pgx.Rows
+	q := pageQuery{
+		source:  `SELECT * FROM "user".users WHERE TRUE`,
+		columns: `id, username, display_name, email, password, verified, role, created_at, karma`,
+		order:   "most-karma",
+		key:     userSortKey,
+		idCast:  "UUID",
+	}
 	if len(keywords) != 0 {
-		query :=
-			`SELECT (id, username, display_name, email, password, verified, role, created_at, karma) 
-				FROM
-					((SELECT id, username, display_name, email, password, verified, role, created_at, karma
-					FROM "user".users
-					WHERE LOWER(email) LIKE '%' || LOWER($1) || '%')
-				UNION
-					(SELECT id, username, display_name, email, password, verified, role, created_at, karma
-					FROM "user".users
-					WHERE LOWER(username) LIKE '%' || LOWER($2) || '%'))
-			ORDER BY karma DESC`
-		if limit != 0 {
-			query = query + fmt.Sprintf(` LIMIT %v`, limit)
-		}
-		rows, err = conn.Query(context.Background(),
-			query, keywords[0], keywords[0],
+		q.source = withFuzzyFallback(q.source,
+			`(LOWER(email) LIKE '%' || LOWER($1) || '%' OR username ILIKE '%' || $1 || '%')`, `username`,
 		)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		query := `SELECT 
-			(id, username, display_name, email, password, verified, role, created_at, karma) 
-			FROM "user".users
-			ORDER BY karma DESC`
-		if limit != 0 {
-			query = query + fmt.Sprintf(` LIMIT %v`, limit)
-		}
-		rows, err = conn.Query(context.Background(), query)
-		if err != nil {
-			return nil, err
-		}
+		q.args = []any{keywords[0]}
 	}
 
-	defer rows.Close()
-	for rows.Next() {
-		var user models.User
-		err = rows.Scan(&user)
-		if err != nil {
-			return nil, err
-		}
-		user.ProfilePic, _ = r.objectUploader.GetObjectLink(*user.Username)
-		users = append(users, user)
-	}
-	err = rows.Err()
+	users, err := queryPage[models.User](conn, q, page)
 	if err != nil {
 		return nil, err
 	}
-	if len(users) == 0 {
+	if len(users.Items) == 0 {
 		return nil, r.NotFoundErr()
 	}
-	return &users, nil
+	for i := range users.Items {
+		users.Items[i].ProfilePic, _ = r.objectUploader.GetObjectLink(*users.Items[i].Username)
+	}
+	return users, nil
 }
 
 func (r *PsqlUserRepository) UpdateUser(id uuid.UUID, user models.User, profilePic io.Reader) (*models.User, error) {
@@ -200,7 +194,18 @@ func (r *PsqlUserRepository) UpdateUser(id uuid.UUID, user models.User, profileP
 	}
 	defer conn.Release()
 
-	err = conn.QueryRow(context.Background(),
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(context.Background())
+
+	change := auditChange{action: "update", table: `"user".users`, targetType: "users", targetID: id}
+	err = change.snapshot(tx)
+	if err != nil {
+		return nil, err
+	}
+	err = tx.QueryRow(context.Background(),
 		`UPDATE "user".users SET 
 			username=COALESCE($1, username), display_name=COALESCE($2, display_name), email=COALESCE($3, email), 
 			password=COALESCE($4, password), verified=COALESCE($5, verified), role=COALESCE($6, role), 
@@ -214,6 +219,14 @@ func (r *PsqlUserRepository) UpdateUser(id uuid.UUID, user models.User, profileP
 	if err != nil {
 		return nil, err
 	}
+	err = change.record(tx)
+	if err != nil {
+		return nil, err
+	}
+	err = tx.Commit(context.Background())
+	if err != nil {
+		return nil, err
+	}
 
 	if profilePic != nil {
 		err = r.objectUploader.PutObject(*user.Username, profilePic)
@@ -244,13 +257,32 @@ func (r *PsqlUserRepository) DeleteUser(id uuid.UUID) error {
 		return err
 	}
 
-	ct, err := conn.Exec(context.Background(), `DELETE FROM "user".users WHERE id=$1`, id)
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(context.Background())
+
+	change := auditChange{action: "delete", table: `"user".users`, targetType: "users", targetID: id}
+	err = change.snapshot(tx)
+	if err != nil {
+		return err
+	}
+	ct, err := tx.Exec(context.Background(), `DELETE FROM "user".users WHERE id=$1`, id)
 	if ct.RowsAffected() == 0 {
 		if err != nil {
 			return err
 		}
 		return r.databaseClient.ErrNoRows()
 	}
+	err = change.record(tx)
+	if err != nil {
+		return err
+	}
+	err = tx.Commit(context.Background())
+	if err != nil {
+		return err
+	}
 
 	_, err = r.enforcer.RemovePermissions(id.String(), "users/"+id.String(), "PATCH")
 	if err != nil {
@@ -260,6 +292,10 @@ func (r *PsqlUserRepository) DeleteUser(id uuid.UUID) error {
 	if err != nil {
 		return err
 	}
+	_, err = r.enforcer.RemovePermissions(id.String(), "users/"+id.String()+"/sanctions", "GET")
+	if err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -280,7 +316,13 @@ func (r *PsqlUserRepository) CreateSession(session models.Session) (*models.Sess
 	}
 	defer conn.Release()
 
-	err = conn.QueryRow(context.Background(),
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(context.Background())
+
+	err = tx.QueryRow(context.Background(),
 		`INSERT INTO "user".sessions
 		(user_id) 
 		VALUES
@@ -292,6 +334,15 @@ func (r *PsqlUserRepository) CreateSession(session models.Session) (*models.Sess
 	if err != nil {
 		return nil, err
 	}
+	change := auditChange{actorID: session.UserID, action: "login", table: `"user".sessions`, targetType: "sessions", targetID: *session.ID}
+	err = change.record(tx)
+	if err != nil {
+		return nil, err
+	}
+	err = tx.Commit(context.Background())
+	if err != nil {
+		return nil, err
+	}
 	_, err = r.enforcer.AddPermissions(session.UserID.String(), "logout/"+session.ID.String(), "DELETE")
 	if err != nil {
 		return nil, err
@@ -317,40 +368,74 @@ func (r *PsqlUserRepository) FindSessionByID(id uuid.UUID) (*models.Session, err
 	return &session, nil
 }
 
+// Revokes all sessions of the user, on behalf of the user
 func (r *PsqlUserRepository) DeleteAllUserSessions(userID uuid.UUID) error {
+	return r.revokeSessions(userID, &userID)
+}
+
+func (r *PsqlUserRepository) revokeSessions(userID uuid.UUID, actorID *uuid.UUID) error {
+	var (
+		sessions  []uuid.UUID
+		summaries []*string
+	)
+
 	conn, err := r.databaseClient.AcquireConn()
 	if err != nil {
 		return err
 	}
 	defer conn.Release()
 
-	rows, err := conn.Query(context.Background(),
-		`select (id) from "user".sessions where user_id = $1`,
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(context.Background())
+
+	rows, err := tx.Query(context.Background(),
+		`DELETE FROM "user".sessions s WHERE user_id = $1 RETURNING s.id, to_jsonb(s)::TEXT`,
 		userID,
 	)
-	defer rows.Close()
+	if err != nil {
+		return err
+	}
 	for rows.Next() {
-		var session models.Session
-		err = rows.Scan(&session)
+		var (
+			id      uuid.UUID
+			summary *string
+		)
+		err = rows.Scan(&id, &summary)
 		if err != nil {
+			rows.Close()
 			return err
 		}
-		_, err = r.enforcer.RemovePermissions(userID.String(), "logout/"+session.ID.String(), "DELETE")
+		sessions = append(sessions, id)
+		summaries = append(summaries, summary)
+	}
+	rows.Close()
+	err = rows.Err()
+	if err != nil {
+		return err
+	}
+	if len(sessions) == 0 {
+		return r.databaseClient.ErrNoRows()
+	}
+
+	for i, id := range sessions {
+		err = recordAuditEvent(tx, actorID, "revoke", "sessions", id.String(), summaries[i], nil)
 		if err != nil {
 			return err
 		}
 	}
-	err = rows.Err()
+	err = tx.Commit(context.Background())
 	if err != nil {
 		return err
 	}
 
-	ct, err := conn.Exec(context.Background(), `DELETE FROM "user".sessions WHERE user_id=$1`, userID)
-	if ct.RowsAffected() == 0 {
+	for _, id := range sessions {
+		_, err = r.enforcer.RemovePermissions(userID.String(), "logout/"+id.String(), "DELETE")
 		if err != nil {
 			return err
 		}
-		return r.databaseClient.ErrNoRows()
 	}
 	return nil
 }
@@ -364,17 +449,36 @@ func (r *PsqlUserRepository) DeleteSession(id uuid.UUID) error {
 	}
 	defer conn.Release()
 
-	err = conn.QueryRow(context.Background(),
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(context.Background())
+
+	err = tx.QueryRow(context.Background(),
 		`SELECT (id, user_id) FROM "user".sessions WHERE id = $1 LIMIT 1`,
 		id,
 	).Scan(&session)
+	if err != nil {
+		return err
+	}
 
-	ct, err := conn.Exec(context.Background(), `DELETE FROM "user".sessions WHERE id=$1`, id)
-	if ct.RowsAffected() == 0 {
-		if err != nil {
-			return err
-		}
-		return r.databaseClient.ErrNoRows()
+	change := auditChange{actorID: session.UserID, action: "logout", table: `"user".sessions`, targetType: "sessions", targetID: id}
+	err = change.snapshot(tx)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(context.Background(), `DELETE FROM "user".sessions WHERE id=$1`, id)
+	if err != nil {
+		return err
+	}
+	err = change.record(tx)
+	if err != nil {
+		return err
+	}
+	err = tx.Commit(context.Background())
+	if err != nil {
+		return err
 	}
 	_, err = r.enforcer.RemovePermissions(session.UserID.String(), "logout/"+session.ID.String(), "DELETE")
 	if err != nil {
@@ -382,3 +486,366 @@ func (r *PsqlUserRepository) DeleteSession(id uuid.UUID) error {
 	}
 	return nil
 }
+
+// Issues a sanction. A ban also revokes all sessions of the user.
+// The sanctioned user is allowed to appeal through the sanction's notes
+func (r *PsqlUserRepository) CreateSanction(sanction models.Sanction) (*models.Sanction, error) {
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(context.Background())
+
+	err = tx.QueryRow(context.Background(),
+		`INSERT INTO "user".sanctions
+		(user_id, type, reason, issued_by, expires_at)
+		VALUES
+		($1, $2, $3, $4, $5)
+		RETURNING
+		(id, user_id, type, reason, issued_by, created_at, expires_at, revoked_at, revoked_by)`,
+		sanction.UserID, sanction.Type, sanction.Reason, sanction.IssuedBy, sanction.ExpiresAt,
+	).Scan(&sanction)
+	if err != nil {
+		return nil, err
+	}
+	change := auditChange{actorID: sanction.IssuedBy, action: "create", table: `"user".sanctions`, targetType: "sanctions", targetID: *sanction.ID}
+	err = change.record(tx)
+	if err != nil {
+		return nil, err
+	}
+	err = tx.Commit(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = r.enforcer.AddPermissions(sanction.UserID.String(), fmt.Sprintf("sanctions/%v/notes", *sanction.ID), "GET")
+	if err != nil {
+		return nil, err
+	}
+	_, err = r.enforcer.AddPermissions(sanction.UserID.String(), fmt.Sprintf("sanctions/%v/notes", *sanction.ID), "POST")
+	if err != nil {
+		return nil, err
+	}
+
+	if *sanction.Type == "ban" {
+		err = r.revokeSessions(*sanction.UserID, sanction.IssuedBy)
+		if err != nil && err != r.NotFoundErr() {
+			return nil, err
+		}
+	}
+	return &sanction, nil
+}
+
+func (r *PsqlUserRepository) FindSanctionByID(id int) (*models.Sanction, error) {
+	var sanction models.Sanction
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	err = conn.QueryRow(context.Background(),
+		`SELECT (id, user_id, type, reason, issued_by, created_at, expires_at, revoked_at, revoked_by)
+		FROM "user".sanctions WHERE id = $1`,
+		id,
+	).Scan(&sanction)
+	if err != nil {
+		return nil, err
+	}
+	return &sanction, nil
+}
+
+// Returns the sanction history of the user, newest first
+func (r *PsqlUserRepository) FindSanctionsByUserID(userID uuid.UUID) (*[]models.Sanction, error) {
+	return r.findSanctions(
+		`SELECT (id, user_id, type, reason, issued_by, created_at, expires_at, revoked_at, revoked_by)
+		FROM "user".sanctions WHERE user_id = $1 ORDER BY created_at DESC, id DESC`,
+		userID,
+	)
+}
+
+// Returns sanctions of the user that are neither expired nor revoked
+func (r *PsqlUserRepository) FindActiveSanctions(userID uuid.UUID) (*[]models.Sanction, error) {
+	return r.findSanctions(
+		`SELECT (id, user_id, type, reason, issued_by, created_at, expires_at, revoked_at, revoked_by)
+		FROM "user".sanctions
+		WHERE user_id = $1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())
+		ORDER BY expires_at DESC NULLS FIRST`,
+		userID,
+	)
+}
+
+func (r *PsqlUserRepository) findSanctions(query string, args ...any) (*[]models.Sanction, error) {
+	var sanctions []models.Sanction
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var sanction models.Sanction
+		err = rows.Scan(&sanction)
+		if err != nil {
+			return nil, err
+		}
+		sanctions = append(sanctions, sanction)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+	if len(sanctions) == 0 {
+		return nil, r.NotFoundErr()
+	}
+	return &sanctions, nil
+}
+
+// Lifts a sanction before it expires
+func (r *PsqlUserRepository) RevokeSanction(id int, revokedBy uuid.UUID) (*models.Sanction, error) {
+	var sanction models.Sanction
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(context.Background())
+
+	change := auditChange{actorID: &revokedBy, action: "revoke", table: `"user".sanctions`, targetType: "sanctions", targetID: id}
+	err = change.snapshot(tx)
+	if err != nil {
+		return nil, err
+	}
+	err = tx.QueryRow(context.Background(),
+		`UPDATE "user".sanctions SET revoked_at=NOW(), revoked_by=$2
+		WHERE id = $1 AND revoked_at IS NULL
+		RETURNING
+		(id, user_id, type, reason, issued_by, created_at, expires_at, revoked_at, revoked_by)`,
+		id, revokedBy,
+	).Scan(&sanction)
+	if err != nil {
+		return nil, err
+	}
+	err = change.record(tx)
+	if err != nil {
+		return nil, err
+	}
+	err = tx.Commit(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return &sanction, nil
+}
+
+func (r *PsqlUserRepository) CreateSanctionNote(note models.SanctionNote) (*models.SanctionNote, error) {
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	err = conn.QueryRow(context.Background(),
+		`INSERT INTO "user".sanction_notes
+		(sanction_id, author_id, body)
+		VALUES
+		($1, $2, $3)
+		RETURNING
+		(id, sanction_id, author_id, body, created_at)`,
+		note.SanctionID, note.AuthorID, note.Body,
+	).Scan(&note)
+	if err != nil {
+		return nil, err
+	}
+	return &note, nil
+}
+
+func (r *PsqlUserRepository) FindSanctionNotes(sanctionID int) (*[]models.SanctionNote, error) {
+	var notes []models.SanctionNote
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(context.Background(),
+		`SELECT (id, sanction_id, author_id, body, created_at)
+		FROM "user".sanction_notes WHERE sanction_id = $1 ORDER BY created_at, id`,
+		sanctionID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var note models.SanctionNote
+		err = rows.Scan(&note)
+		if err != nil {
+			return nil, err
+		}
+		notes = append(notes, note)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+	if len(notes) == 0 {
+		return nil, r.NotFoundErr()
+	}
+	return &notes, nil
+}
+
+// Assigns the user to moderate the category. Assigning a moderator again only records who did it
+func (r *PsqlUserRepository) CreateTopicModerator(moderator models.TopicModerator) (*models.TopicModerator, error) {
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(context.Background())
+
+	err = tx.QueryRow(context.Background(),
+		`WITH moderator AS (
+			INSERT INTO forum.topic_moderators
+			(topic_id, user_id, assigned_by)
+			SELECT id, $2, $3 FROM forum.topics WHERE id = $1 AND deleted_at IS NULL
+			ON CONFLICT (topic_id, user_id) DO UPDATE SET assigned_by = EXCLUDED.assigned_by
+			RETURNING topic_id, user_id, assigned_by, created_at
+		)
+		SELECT (m.topic_id, m.user_id, m.assigned_by, m.created_at, u.username)
+		FROM moderator m JOIN "user".users u ON u.id = m.user_id`,
+		moderator.TopicID, moderator.UserID, moderator.AssignedBy,
+	).Scan(&moderator)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" { // No such user
+			return nil, r.NotFoundErr()
+		}
+		return nil, err
+	}
+
+	var after *string
+	err = tx.QueryRow(context.Background(),
+		`SELECT to_jsonb(m)::TEXT FROM forum.topic_moderators m WHERE topic_id = $1 AND user_id = $2`,
+		moderator.TopicID, moderator.UserID,
+	).Scan(&after)
+	if err != nil {
+		return nil, err
+	}
+	err = recordAuditEvent(tx, moderator.AssignedBy, "create", "topic_moderators",
+		fmt.Sprintf("%v/%v", *moderator.TopicID, *moderator.UserID), nil, after)
+	if err != nil {
+		return nil, err
+	}
+	err = tx.Commit(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return &moderator, nil
+}
+
+// Returns the moderators assigned to the category itself, in order of assignment
+func (r *PsqlUserRepository) FindTopicModerators(topicID int) (*[]models.TopicModerator, error) {
+	var moderators []models.TopicModerator
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(context.Background(),
+		`SELECT (m.topic_id, m.user_id, m.assigned_by, m.created_at, u.username)
+		FROM forum.topic_moderators m
+		JOIN forum.topics tp ON tp.id = m.topic_id
+		JOIN "user".users u ON u.id = m.user_id
+		WHERE m.topic_id = $1 AND tp.deleted_at IS NULL
+		ORDER BY m.created_at, u.username`,
+		topicID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var moderator models.TopicModerator
+		err = rows.Scan(&moderator)
+		if err != nil {
+			return nil, err
+		}
+		moderators = append(moderators, moderator)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+	if len(moderators) == 0 {
+		return nil, r.NotFoundErr()
+	}
+	return &moderators, nil
+}
+
+func (r *PsqlUserRepository) DeleteTopicModerator(topicID int, userID uuid.UUID) error {
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(context.Background())
+
+	var before *string
+	err = tx.QueryRow(context.Background(),
+		`DELETE FROM forum.topic_moderators m WHERE topic_id = $1 AND user_id = $2 RETURNING to_jsonb(m)::TEXT`,
+		topicID, userID,
+	).Scan(&before)
+	if err != nil {
+		return err
+	}
+	err = recordAuditEvent(tx, nil, "delete", "topic_moderators", fmt.Sprintf("%v/%v", topicID, userID), before, nil)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(context.Background())
+}
+
+// Moderators of a category also moderate its subcategories
+func (r *PsqlUserRepository) IsTopicModerator(topicID int, userID uuid.UUID) (bool, error) {
+	var moderates bool
+	conn, err := r.databaseClient.AcquireConn()
+	if err != nil {
+		return false, err
+	}
+	defer conn.Release()
+
+	err = conn.QueryRow(context.Background(),
+		`SELECT forum.moderates_topic($1, $2)`, topicID, userID,
+	).Scan(&moderates)
+	if err != nil {
+		return false, err
+	}
+	return moderates, nil
+}
@@ -33,6 +33,13 @@ var (
 	userUpdated      models.User = models.User{Username: &userNameUpdated, DisplayName: &userDisplayName, Karma: &userKarmaUpdated}
 
 	session models.Session
+
+	sanction          models.Sanction
+	sanctionType      string = "upload_mute"
+	sanctionReason    string = "Uploading other people's demos"
+	sanctionNoteBody  string = "I have the author's permission"
+	sanctionBanType   string = "ban"
+	sanctionBanReason string = "Ban evasion"
 )
 
 func init() {
@@ -135,6 +142,65 @@ func TestFindSessionByIDNoRows(t *testing.T) {
 	}
 }
 
+func TestCreateSanction(t *testing.T) {
+	r := PsqlUserRepository{databaseClient: testDBClient, enforcer: testEnforcer, objectUploader: testS3Client}
+	resultSanction, err := r.CreateSanction(models.Sanction{UserID: &userID, Type: &sanctionType, Reason: &sanctionReason})
+	if assert.NoError(t, err) {
+		sanction = *resultSanction
+		assert.Nil(t, sanction.ExpiresAt)
+	}
+}
+
+func TestFindActiveSanctions(t *testing.T) {
+	r := PsqlUserRepository{databaseClient: testDBClient, enforcer: testEnforcer, objectUploader: testS3Client}
+	sanctions, err := r.FindActiveSanctions(userID)
+	if assert.NoError(t, err) {
+		assert.Len(t, *sanctions, 1)
+	}
+}
+
+func TestCreateSanctionNote(t *testing.T) {
+	r := PsqlUserRepository{databaseClient: testDBClient, enforcer: testEnforcer, objectUploader: testS3Client}
+	_, err := r.CreateSanctionNote(models.SanctionNote{SanctionID: sanction.ID, AuthorID: &userID, Body: &sanctionNoteBody})
+	assert.NoError(t, err)
+
+	notes, err := r.FindSanctionNotes(*sanction.ID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, sanctionNoteBody, *(*notes)[0].Body)
+	}
+}
+
+func TestRevokeSanction(t *testing.T) {
+	r := PsqlUserRepository{databaseClient: testDBClient, enforcer: testEnforcer, objectUploader: testS3Client}
+	resultSanction, err := r.RevokeSanction(*sanction.ID, userID)
+	if assert.NoError(t, err) {
+		assert.NotNil(t, resultSanction.RevokedAt)
+	}
+
+	_, err = r.FindActiveSanctions(userID)
+	assert.Equal(t, r.NotFoundErr(), err)
+
+	sanctions, err := r.FindSanctionsByUserID(userID)
+	if assert.NoError(t, err) {
+		assert.Len(t, *sanctions, 1)
+	}
+}
+
+func TestCreateSanctionBan(t *testing.T) {
+	r := PsqlUserRepository{databaseClient: testDBClient, enforcer: testEnforcer, objectUploader: testS3Client}
+	ban, err := r.CreateSanction(models.Sanction{UserID: &userID, Type: &sanctionBanType, Reason: &sanctionBanReason})
+	assert.NoError(t, err)
+
+	_, err = r.FindSessionByID(*session.ID) // A ban ends all sessions of the user
+	assert.Equal(t, r.NotFoundErr(), err)
+
+	_, err = r.RevokeSanction(*ban.ID, userID)
+	assert.NoError(t, err)
+	resultSession, err := r.CreateSession(models.Session{UserID: &userID})
+	assert.NoError(t, err)
+	session = *resultSession
+}
+
 func TestDeleteSession(t *testing.T) {
 	r := PsqlUserRepository{databaseClient: testDBClient, enforcer: testEnforcer, objectUploader: testS3Client}
 	err := r.DeleteSession(*session.ID)
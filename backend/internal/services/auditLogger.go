@@ -0,0 +1,102 @@
+package services
+
+import (
+	"errors"
+	"gamehangar/internal/domain/models"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type AuditRepository interface {
+	CreateAuditEvent(event models.AuditEvent) error
+}
+
+// Routes that do not name their target type in the first path segment
+var auditTargetTypes = map[string]string{
+	"login":          "sessions",
+	"logout":         "sessions",
+	"register":       "users",
+	"reset-password": "users",
+	"verify":         "users",
+}
+
+// Records every state-changing request in the audit log with its actor, IP and status.
+// The changes themselves are recorded by the repositories making them, with the state before and after.
+// Handlers may name a target that is not in the path with "auditTarget" on the context
+type AuditLogger struct {
+	repository AuditRepository
+	logger     echo.Logger
+}
+
+func NewAuditLogger(r AuditRepository, l echo.Logger) *AuditLogger {
+	return &AuditLogger{
+		repository: r,
+		logger:     l,
+	}
+}
+
+// Appends the event to the audit log. Failing to record never fails the request
+func (a *AuditLogger) Record(event models.AuditEvent) {
+	err := a.repository.CreateAuditEvent(event)
+	if err != nil {
+		a.logger.Errorf("Error recording audit event: %v", err)
+	}
+}
+
+// Must be registered on the Echo instance itself so that requests
+// rejected by the authorizer are recorded too
+func (a *AuditLogger) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			switch c.Request().Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				return next(c)
+			}
+
+			err := next(c)
+
+			status := c.Response().Status
+			var he *echo.HTTPError
+			if err != nil && errors.As(err, &he) {
+				status = he.Code
+			} else if err != nil {
+				status = http.StatusInternalServerError
+			}
+			a.Record(newAuditEvent(c, status))
+
+			return err
+		}
+	}
+}
+
+func newAuditEvent(c echo.Context, status int) models.AuditEvent {
+	route := strings.TrimPrefix(c.Path(), "/game-hangar/v1/")
+	action := c.Request().Method + " " + route
+	targetType := strings.Split(route, "/")[0]
+	if t, ok := auditTargetTypes[targetType]; ok {
+		targetType = t
+	}
+
+	event := models.AuditEvent{
+		Action:     &action,
+		TargetType: &targetType,
+		Status:     &status,
+	}
+	// Anything but an address, which fits the column, is left out
+	if ip := c.RealIP(); net.ParseIP(ip) != nil {
+		event.IP = &ip
+	}
+	if actorID, ok := c.Get("userID").(uuid.UUID); ok {
+		event.ActorID = &actorID
+	}
+	if target, ok := c.Get("auditTarget").(string); ok {
+		event.TargetID = &target
+	} else if id := c.Param("id"); id != "" {
+		event.TargetID = &id
+	}
+	return event
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"gamehangar/internal/domain/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockAuditRepo struct {
+	events []models.AuditEvent
+}
+
+func (r *mockAuditRepo) CreateAuditEvent(event models.AuditEvent) error {
+	r.events = append(r.events, event)
+	return nil
+}
+
+func TestAuditLoggerMiddleware(t *testing.T) {
+	r := &mockAuditRepo{}
+	e := echo.New()
+	e.Use(NewAuditLogger(r, e.Logger).Middleware())
+	actorID := uuid.New()
+	e.DELETE("/game-hangar/v1/threads/:id", func(c echo.Context) error {
+		c.Set("userID", actorID)
+		return c.String(http.StatusOK, "Thread successfully deleted!")
+	})
+	e.GET("/game-hangar/v1/threads/:id", func(c echo.Context) error {
+		return c.String(http.StatusOK, "Thread")
+	})
+	e.POST("/game-hangar/v1/roles", func(c echo.Context) error {
+		return echo.ErrForbidden
+	})
+
+	req := httptest.NewRequest(http.MethodDelete, "/game-hangar/v1/threads/7", nil)
+	req.Header.Set(echo.HeaderXRealIP, "192.0.2.1")
+	e.ServeHTTP(httptest.NewRecorder(), req)
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/game-hangar/v1/threads/7", nil))
+	req = httptest.NewRequest(http.MethodPost, "/game-hangar/v1/roles", nil)
+	req.Header.Set(echo.HeaderXRealIP, strings.Repeat("f", 64))
+	e.ServeHTTP(httptest.NewRecorder(), req)
+
+	if assert.Len(t, r.events, 2) {
+		deleted := r.events[0]
+		assert.Equal(t, "DELETE threads/:id", *deleted.Action)
+		assert.Equal(t, "threads", *deleted.TargetType)
+		assert.Equal(t, "7", *deleted.TargetID)
+		assert.Equal(t, actorID, *deleted.ActorID)
+		assert.Equal(t, "192.0.2.1", *deleted.IP)
+		assert.Equal(t, http.StatusOK, *deleted.Status)
+		assert.Nil(t, deleted.Before)
+		assert.Nil(t, deleted.After)
+
+		denied := r.events[1]
+		assert.Equal(t, "POST roles", *denied.Action)
+		assert.Equal(t, http.StatusForbidden, *denied.Status)
+		assert.Nil(t, denied.ActorID)
+		assert.Nil(t, denied.IP)
+		assert.Nil(t, denied.TargetID)
+	}
+}
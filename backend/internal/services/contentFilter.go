@@ -0,0 +1,267 @@
+package services
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"gamehangar/internal/domain/models"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	FilterAllow  = "allow"
+	FilterReview = "review"
+	FilterReject = "reject"
+)
+
+// A single stage of the content filter pipeline. Returns nil when the subject passes
+type Filter interface {
+	Check(subject models.FilterSubject) (*models.FilterVerdict, error)
+}
+
+// A filter comparing content with content stored before it. It only learns about content once it is stored,
+// so that content failing to be stored can be submitted again
+type RememberingFilter interface {
+	Filter
+	Remember(subject models.FilterSubject)
+}
+
+type ReviewQueue interface {
+	HoldForReview(targetType string, targetID int, details string) error
+}
+
+type ContentFilterUserRepository interface {
+	FindUserByID(id uuid.UUID) (*models.User, error)
+}
+
+type ContentFilter struct {
+	filters     []Filter
+	reviewQueue ReviewQueue
+}
+
+func NewContentFilter(q ReviewQueue, filters ...Filter) *ContentFilter {
+	return &ContentFilter{
+		filters:     filters,
+		reviewQueue: q,
+	}
+}
+
+// Runs the subject through every filter. A rejection stops the pipeline,
+// a review verdict is kept unless a later filter rejects the subject
+func (f *ContentFilter) CheckContent(subject models.FilterSubject) (*models.FilterVerdict, error) {
+	verdict := &models.FilterVerdict{Action: FilterAllow}
+
+	for _, filter := range f.filters {
+		v, err := filter.Check(subject)
+		if err != nil {
+			return nil, err
+		}
+		if v == nil {
+			continue
+		}
+		if v.Action == FilterReject {
+			return v, nil
+		}
+		if verdict.Action == FilterAllow {
+			verdict = v
+		}
+	}
+
+	// There is no content to hide for a username, so it cannot wait for a moderator
+	if subject.Kind == "username" && verdict.Action == FilterReview {
+		verdict.Action = FilterReject
+	}
+	return verdict, nil
+}
+
+// Hides the stored content and files a report for it in the moderator queue
+func (f *ContentFilter) HoldForReview(targetType string, targetID int, verdict models.FilterVerdict) error {
+	return f.reviewQueue.HoldForReview(targetType, targetID, "Content filter ("+verdict.Filter+"): "+verdict.Reason)
+}
+
+// Tells the filters that compare content with earlier content that the subject is stored
+func (f *ContentFilter) Remember(subject models.FilterSubject) {
+	for _, filter := range f.filters {
+		if r, ok := filter.(RememberingFilter); ok {
+			r.Remember(subject)
+		}
+	}
+}
+
+// Matches whole words case-insensitively and regular expressions as they are
+type BlocklistFilter struct {
+	words    *regexp.Regexp
+	patterns []*regexp.Regexp
+	action   string
+}
+
+// Entries wrapped in slashes, e.g. /fr[e3]{2} v-?bucks/, are regular expressions, others are words.
+// Action is either review or reject
+func NewBlocklistFilter(entries []string, action string) (*BlocklistFilter, error) {
+	var words []string
+
+	if action != FilterReview && action != FilterReject {
+		return nil, fmt.Errorf("Unknown blocklist action %q", action)
+	}
+	f := &BlocklistFilter{action: action}
+
+	for _, entry := range entries {
+		if len(entry) > 2 && strings.HasPrefix(entry, "/") && strings.HasSuffix(entry, "/") {
+			p, err := regexp.Compile(entry[1 : len(entry)-1])
+			if err != nil {
+				return nil, err
+			}
+			f.patterns = append(f.patterns, p)
+			continue
+		}
+		words = append(words, regexp.QuoteMeta(entry))
+	}
+	if len(words) != 0 {
+		f.words = regexp.MustCompile(`(?i)\b(` + strings.Join(words, "|") + `)\b`)
+	}
+
+	return f, nil
+}
+
+// Reads blocklist entries, one per line. Empty lines and lines starting with # are skipped
+func LoadBlocklist(path string) ([]string, error) {
+	var entries []string
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		entries = append(entries, line)
+	}
+	return entries, scanner.Err()
+}
+
+func (f *BlocklistFilter) Check(subject models.FilterSubject) (*models.FilterVerdict, error) {
+	if f.words != nil {
+		if match := f.words.FindString(subject.Text); match != "" {
+			return &models.FilterVerdict{Action: f.action, Filter: "blocklist", Reason: "Blocked word " + match}, nil
+		}
+	}
+	for _, p := range f.patterns {
+		if p.MatchString(subject.Text) {
+			return &models.FilterVerdict{Action: f.action, Filter: "blocklist", Reason: "Blocked pattern " + p.String()}, nil
+		}
+	}
+	return nil, nil
+}
+
+var linkPattern = regexp.MustCompile(`(?i)\b(https?://|www\.)`)
+
+// Sends posts of new accounts with too many links to review.
+// Registrations have no account yet and count as new
+type LinkLimitFilter struct {
+	users         ContentFilterUserRepository
+	maxLinks      int
+	newAccountAge time.Duration
+}
+
+func NewLinkLimitFilter(r ContentFilterUserRepository, maxLinks int, newAccountAge time.Duration) *LinkLimitFilter {
+	return &LinkLimitFilter{
+		users:         r,
+		maxLinks:      maxLinks,
+		newAccountAge: newAccountAge,
+	}
+}
+
+func (f *LinkLimitFilter) Check(subject models.FilterSubject) (*models.FilterVerdict, error) {
+	links := len(linkPattern.FindAllStringIndex(subject.Text, -1))
+	if links <= f.maxLinks {
+		return nil, nil
+	}
+
+	if subject.AuthorID != nil {
+		user, err := f.users.FindUserByID(*subject.AuthorID)
+		if err != nil {
+			return nil, err
+		}
+		if user.CreatedAt != nil && time.Since(*user.CreatedAt) >= f.newAccountAge {
+			return nil, nil
+		}
+	}
+	return &models.FilterVerdict{
+		Action: FilterReview,
+		Filter: "links",
+		Reason: fmt.Sprintf("%v links from a new account, %v allowed", links, f.maxLinks),
+	}, nil
+}
+
+// Rejects the same text posted by the same author again within the window.
+// Posts are remembered in memory once they are stored, so every instance of the service keeps its own history
+type DuplicateFilter struct {
+	window time.Duration
+	mu     sync.Mutex
+	seen   map[string]time.Time
+}
+
+func NewDuplicateFilter(window time.Duration) *DuplicateFilter {
+	return &DuplicateFilter{
+		window: window,
+		seen:   make(map[string]time.Time),
+	}
+}
+
+func (f *DuplicateFilter) Check(subject models.FilterSubject) (*models.FilterVerdict, error) {
+	key, ok := duplicateKey(subject)
+	if !ok {
+		return nil, nil
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := time.Now()
+	for k, t := range f.seen {
+		if now.Sub(t) > f.window {
+			delete(f.seen, k)
+		}
+	}
+	if _, ok := f.seen[key]; ok {
+		return &models.FilterVerdict{Action: FilterReject, Filter: "duplicate", Reason: "Duplicate post"}, nil
+	}
+	return nil, nil
+}
+
+func (f *DuplicateFilter) Remember(subject models.FilterSubject) {
+	key, ok := duplicateKey(subject)
+	if !ok {
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.seen[key] = time.Now()
+}
+
+// Returns the author and the hash of the normalized text, false when the subject is not checked for duplicates
+func duplicateKey(subject models.FilterSubject) (string, bool) {
+	if subject.AuthorID == nil || subject.Kind == "username" {
+		return "", false
+	}
+
+	text := strings.Join(strings.Fields(strings.ToLower(subject.Text)), " ")
+	if text == "" {
+		return "", false
+	}
+	hash := sha256.Sum256([]byte(text))
+	return subject.AuthorID.String() + ":" + hex.EncodeToString(hash[:]), true
+}
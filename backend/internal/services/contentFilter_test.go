@@ -0,0 +1,123 @@
+package services
+
+import (
+	"errors"
+	"gamehangar/internal/domain/models"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockFilterUserRepo struct {
+	users map[uuid.UUID]models.User
+}
+
+func (r *mockFilterUserRepo) FindUserByID(id uuid.UUID) (*models.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return nil, errors.New("Not Found")
+	}
+	return &user, nil
+}
+
+type mockReviewQueue struct {
+	details []string
+}
+
+func (q *mockReviewQueue) HoldForReview(targetType string, targetID int, details string) error {
+	q.details = append(q.details, details)
+	return nil
+}
+
+var (
+	filterNewUserID = uuid.New()
+	filterOldUserID = uuid.New()
+	filterNewUserAt = time.Now().Add(-time.Hour)
+	filterOldUserAt = time.Now().Add(-30 * 24 * time.Hour)
+	filterUsers     = mockFilterUserRepo{users: map[uuid.UUID]models.User{
+		filterNewUserID: {ID: &filterNewUserID, CreatedAt: &filterNewUserAt},
+		filterOldUserID: {ID: &filterOldUserID, CreatedAt: &filterOldUserAt},
+	}}
+)
+
+func TestBlocklistFilter(t *testing.T) {
+	blocklist, err := NewBlocklistFilter([]string{"casino", `/fr[e3]{2} v-?bucks/`}, FilterReject)
+	assert.NoError(t, err)
+	f := NewContentFilter(&mockReviewQueue{}, blocklist)
+
+	verdict, err := f.CheckContent(models.FilterSubject{Kind: "message", Text: "Best CASINO in town"})
+	if assert.NoError(t, err) {
+		assert.Equal(t, FilterReject, verdict.Action)
+	}
+	verdict, err = f.CheckContent(models.FilterSubject{Kind: "message", Text: "get fr33 vbucks"})
+	if assert.NoError(t, err) {
+		assert.Equal(t, FilterReject, verdict.Action)
+	}
+	verdict, err = f.CheckContent(models.FilterSubject{Kind: "message", Text: "Occasional casinos are whole other words"})
+	if assert.NoError(t, err) {
+		assert.Equal(t, FilterAllow, verdict.Action)
+	}
+
+	_, err = NewBlocklistFilter([]string{"casino"}, "ignore")
+	assert.Error(t, err)
+}
+
+func TestLinkLimitFilter(t *testing.T) {
+	q := &mockReviewQueue{}
+	f := NewContentFilter(q, NewLinkLimitFilter(&filterUsers, 1, 72*time.Hour))
+	text := "See https://example.com and www.example.org"
+
+	verdict, err := f.CheckContent(models.FilterSubject{Kind: "message", AuthorID: &filterNewUserID, Text: text})
+	if assert.NoError(t, err) {
+		assert.Equal(t, FilterReview, verdict.Action)
+		assert.NoError(t, f.HoldForReview("message", 1, *verdict))
+		assert.Equal(t, []string{"Content filter (links): 2 links from a new account, 1 allowed"}, q.details)
+	}
+	verdict, err = f.CheckContent(models.FilterSubject{Kind: "message", AuthorID: &filterOldUserID, Text: text})
+	if assert.NoError(t, err) {
+		assert.Equal(t, FilterAllow, verdict.Action)
+	}
+	verdict, err = f.CheckContent(models.FilterSubject{Kind: "username", Text: text}) // Usernames cannot wait for review
+	if assert.NoError(t, err) {
+		assert.Equal(t, FilterReject, verdict.Action)
+	}
+}
+
+func TestDuplicateFilter(t *testing.T) {
+	f := NewContentFilter(&mockReviewQueue{}, NewDuplicateFilter(time.Minute))
+
+	subject := models.FilterSubject{Kind: "message", AuthorID: &filterOldUserID, Text: "Hello  there"}
+	verdict, err := f.CheckContent(subject)
+	if assert.NoError(t, err) {
+		assert.Equal(t, FilterAllow, verdict.Action)
+	}
+	// Posts that failed to be stored may be submitted again
+	verdict, err = f.CheckContent(subject)
+	if assert.NoError(t, err) {
+		assert.Equal(t, FilterAllow, verdict.Action)
+	}
+	f.Remember(subject)
+	verdict, err = f.CheckContent(models.FilterSubject{Kind: "message", AuthorID: &filterOldUserID, Text: "hello there"})
+	if assert.NoError(t, err) {
+		assert.Equal(t, FilterReject, verdict.Action)
+	}
+	verdict, err = f.CheckContent(models.FilterSubject{Kind: "message", AuthorID: &filterNewUserID, Text: "hello there"})
+	if assert.NoError(t, err) {
+		assert.Equal(t, FilterAllow, verdict.Action)
+	}
+}
+
+func TestLocalCaptcha(t *testing.T) {
+	v := NewLocalCaptcha("token")
+
+	ok, err := v.VerifyCaptcha("token", "127.0.0.1")
+	if assert.NoError(t, err) {
+		assert.True(t, ok)
+	}
+	ok, err = v.VerifyCaptcha("forged", "127.0.0.1")
+	if assert.NoError(t, err) {
+		assert.False(t, ok)
+	}
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"gamehangar/internal/domain/models"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// Events a client may fall behind by before it is disconnected
+	liveClientBuffer = 64
+	// Delay before listening again after the database connection failed, doubled up to a minute
+	liveReconnectDelay = time.Second
+)
+
+type LiveRepository interface {
+	ListenLiveEvents(ctx context.Context, handle func(models.LiveEvent)) error
+}
+
+type liveClient struct {
+	events   chan models.LiveEvent
+	channels []string
+	removed  bool
+}
+
+// Fans events published in the database out to the clients of this instance.
+// A client's events are closed when it falls behind, when events may have been
+// missed while reconnecting to the database and on shutdown. Clients should then
+// refetch what they show and subscribe again
+type LiveHub struct {
+	repository LiveRepository
+	logger     echo.Logger
+
+	mu       sync.Mutex
+	channels map[string]map[*liveClient]struct{}
+	closed   bool
+}
+
+func NewLiveHub(r LiveRepository, l echo.Logger) *LiveHub {
+	return &LiveHub{
+		repository: r,
+		logger:     l,
+		channels:   make(map[string]map[*liveClient]struct{}),
+	}
+}
+
+// Returned by Subscribe once the hub has shut down
+var ErrLiveHubClosed = errors.New("Live updates are shutting down!")
+
+// Returns the events published on the channels and a function that ends the subscription
+func (h *LiveHub) Subscribe(channels ...string) (<-chan models.LiveEvent, func(), error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil, nil, ErrLiveHubClosed
+	}
+
+	c := &liveClient{events: make(chan models.LiveEvent, liveClientBuffer), channels: channels}
+	for _, channel := range channels {
+		if h.channels[channel] == nil {
+			h.channels[channel] = make(map[*liveClient]struct{})
+		}
+		h.channels[channel][c] = struct{}{}
+	}
+
+	return c.events, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.remove(c)
+	}, nil
+}
+
+// Sends the event to the clients subscribed to its channel without blocking
+func (h *LiveHub) Publish(event models.LiveEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for c := range h.channels[event.Channel] {
+		select {
+		case c.events <- event:
+		default:
+			h.remove(c)
+		}
+	}
+}
+
+// Listens for events until ctx is cancelled, then disconnects all clients
+func (h *LiveHub) Run(ctx context.Context) {
+	delay := liveReconnectDelay
+
+	for {
+		start := time.Now()
+		err := h.repository.ListenLiveEvents(ctx, h.Publish)
+		if ctx.Err() != nil {
+			break
+		}
+		h.logger.Errorf("Error listening for live events: %v", err)
+		h.disconnect(false)
+
+		if time.Since(start) > time.Minute {
+			delay = liveReconnectDelay
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, time.Minute)
+	}
+
+	h.disconnect(true)
+}
+
+// Disconnects all clients. A closed hub takes no new subscriptions
+func (h *LiveHub) disconnect(shutdown bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, clients := range h.channels {
+		for c := range clients {
+			h.remove(c)
+		}
+	}
+	h.closed = h.closed || shutdown
+}
+
+// Must be called with mu held. Does nothing if the client is already removed
+func (h *LiveHub) remove(c *liveClient) {
+	if c.removed {
+		return
+	}
+	c.removed = true
+	for _, channel := range c.channels {
+		delete(h.channels[channel], c)
+		if len(h.channels[channel]) == 0 {
+			delete(h.channels, channel)
+		}
+	}
+	close(c.events)
+}
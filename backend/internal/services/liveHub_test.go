@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"gamehangar/internal/domain/models"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// Publishes its events once listening, then fails or blocks until ctx is cancelled
+type mockLiveRepo struct {
+	events []models.LiveEvent
+	fail   bool
+}
+
+func (r *mockLiveRepo) ListenLiveEvents(ctx context.Context, handle func(models.LiveEvent)) error {
+	for _, e := range r.events {
+		handle(e)
+	}
+	if r.fail {
+		return errors.New("connection lost")
+	}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestLiveHubPublish(t *testing.T) {
+	h := NewLiveHub(&mockLiveRepo{}, echo.New().Logger)
+
+	thread, unsubscribeThread, err := h.Subscribe("thread:1")
+	if !assert.NoError(t, err) {
+		return
+	}
+	both, unsubscribeBoth, err := h.Subscribe("thread:1", "user:1")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	h.Publish(models.LiveEvent{Channel: "thread:1", Event: "message.created"})
+	h.Publish(models.LiveEvent{Channel: "user:1", Event: "notification"})
+	h.Publish(models.LiveEvent{Channel: "thread:2", Event: "message.created"})
+
+	assert.Equal(t, "message.created", (<-thread).Event)
+	assert.Equal(t, "message.created", (<-both).Event)
+	assert.Equal(t, "notification", (<-both).Event)
+	assert.Len(t, thread, 0)
+
+	unsubscribeThread()
+	unsubscribeThread()
+	_, ok := <-thread
+	assert.False(t, ok)
+
+	unsubscribeBoth()
+	assert.Empty(t, h.channels)
+}
+
+func TestLiveHubBackpressure(t *testing.T) {
+	h := NewLiveHub(&mockLiveRepo{}, echo.New().Logger)
+
+	events, unsubscribe, err := h.Subscribe("thread:1")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer unsubscribe()
+
+	// The client that never reads is disconnected once its buffer is full
+	for range liveClientBuffer + 1 {
+		h.Publish(models.LiveEvent{Channel: "thread:1", Event: "message.voted"})
+	}
+
+	var received int
+	for range events {
+		received++
+	}
+	assert.Equal(t, liveClientBuffer, received)
+}
+
+func TestLiveHubRun(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	h := NewLiveHub(&mockLiveRepo{events: []models.LiveEvent{{Channel: "thread:1", Event: "message.created"}}}, echo.New().Logger)
+
+	events, unsubscribe, err := h.Subscribe("thread:1")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer unsubscribe()
+
+	done := make(chan struct{})
+	go func() {
+		h.Run(ctx)
+		close(done)
+	}()
+
+	assert.Equal(t, "message.created", (<-events).Event)
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return on shutdown")
+	}
+
+	_, ok := <-events
+	assert.False(t, ok)
+	_, _, err = h.Subscribe("thread:1")
+	assert.Equal(t, ErrLiveHubClosed, err)
+}
+
+func TestLiveHubReconnect(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	h := NewLiveHub(&mockLiveRepo{fail: true}, echo.New().Logger)
+
+	events, unsubscribe, err := h.Subscribe("thread:1")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer unsubscribe()
+
+	// Events may have been missed while the connection was down, so clients start over
+	go h.Run(ctx)
+	select {
+	case _, ok := <-events:
+		assert.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("Client was not disconnected")
+	}
+
+	_, unsubscribe, err = h.Subscribe("thread:1")
+	if assert.NoError(t, err) {
+		unsubscribe()
+	}
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"gamehangar/internal/domain/models"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+type MailTransport interface {
+	Send(mail models.Mail) error
+}
+
+// Returned by transports when sending the mail again cannot succeed, e.g. the mailbox does not exist
+type PermanentMailError struct {
+	Err error
+}
+
+func (e *PermanentMailError) Error() string { return e.Err.Error() }
+func (e *PermanentMailError) Unwrap() error { return e.Err }
+
+type SMTPTransport struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+// Authenticates only when a username is given. The connection is upgraded with STARTTLS when the server offers it
+func NewSMTPTransport(host string, port int, username, password, from string) *SMTPTransport {
+	t := &SMTPTransport{
+		addr: net.JoinHostPort(host, strconv.Itoa(port)),
+		from: from,
+	}
+	if username != "" {
+		t.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return t
+}
+
+func (t *SMTPTransport) Send(mail models.Mail) error {
+	msg, err := buildMail(t.from, mail)
+	if err != nil {
+		return err
+	}
+
+	err = smtp.SendMail(t.addr, t.auth, t.from, []string{*mail.Recipient}, msg)
+	var smtpErr *textproto.Error
+	if errors.As(err, &smtpErr) && smtpErr.Code >= 500 {
+		return &PermanentMailError{Err: err}
+	}
+	return err
+}
+
+// Writes every mail to an .eml file in dir, or to the log if dir is empty. Meant for development
+type FileTransport struct {
+	dir    string
+	from   string
+	logger echo.Logger
+}
+
+func NewFileTransport(dir, from string, l echo.Logger) *FileTransport {
+	return &FileTransport{
+		dir:    dir,
+		from:   from,
+		logger: l,
+	}
+}
+
+func (t *FileTransport) Send(mail models.Mail) error {
+	if t.dir == "" {
+		t.logger.Infof("Mail to %v: %v\n%v", *mail.Recipient, *mail.Subject, *mail.Text)
+		return nil
+	}
+
+	msg, err := buildMail(t.from, mail)
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%v-%v.eml", time.Now().UTC().Format("20060102T150405"), *mail.ID)
+	return os.WriteFile(filepath.Join(t.dir, name), msg, 0o644)
+}
+
+// Builds a multipart/alternative message with the text and HTML bodies
+func buildMail(from string, mail models.Mail) ([]byte, error) {
+	var body, msg bytes.Buffer
+
+	w := multipart.NewWriter(&body)
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain", *mail.Text},
+		{"text/html", *mail.HTML},
+	} {
+		pw, err := w.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType + "; charset=UTF-8"},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qw := quotedprintable.NewWriter(pw)
+		_, err = qw.Write([]byte(part.content))
+		if err != nil {
+			return nil, err
+		}
+		err = qw.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	err := w.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	header := []string{
+		"From: " + from,
+		"To: " + *mail.Recipient,
+		"Subject: " + mime.QEncoding.Encode("UTF-8", *mail.Subject),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: multipart/alternative; boundary=" + w.Boundary(),
+	}
+	if mail.ID != nil {
+		_, domain, _ := strings.Cut(from, "@")
+		header = append(header, fmt.Sprintf("Message-ID: <outbox-%v@%v>", *mail.ID, strings.Trim(domain, "> ")))
+	}
+	// One-click unsubscribe, RFC 8058
+	if mail.UnsubscribeURL != nil {
+		header = append(header,
+			"List-Unsubscribe: <"+*mail.UnsubscribeURL+">",
+			"List-Unsubscribe-Post: List-Unsubscribe=One-Click",
+		)
+	}
+
+	msg.WriteString(strings.Join(header, "\r\n") + "\r\n\r\n")
+	msg.Write(body.Bytes())
+	return msg.Bytes(), nil
+}
@@ -0,0 +1,297 @@
+package services
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"gamehangar/internal/domain/models"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+//go:embed mailTemplates
+var mailTemplateFS embed.FS
+
+const (
+	// Events keep coalescing into a notification for this long before it is mailed
+	mailSettle = 5 * time.Minute
+	// A mail handed to an instance of the service is not handed out again for this long
+	mailLease       = 10 * time.Minute
+	mailBatchSize   = 100
+	mailMaxAttempts = 8
+)
+
+// Plural form index of n: one or other in English, one, few or many in Russian
+var mailPluralRules = map[string]func(n int) int{
+	"en": func(n int) int {
+		if n == 1 {
+			return 0
+		}
+		return 1
+	},
+	"ru": func(n int) int {
+		switch {
+		case n%10 == 1 && n%100 != 11:
+			return 0
+		case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+			return 1
+		}
+		return 2
+	},
+}
+
+type MailRepository interface {
+	FindUnmailedNotifications(settle time.Duration) (*[]models.NotificationMail, error)
+	FindDueDigests(limit int) (*[]models.NotificationMail, error)
+	QueueMail(mail models.Mail, notificationIDs []int) error
+	QueueDigest(recipient models.MailRecipient, mail *models.Mail, notificationIDs []int) error
+
+	ClaimMail(limit int, lease time.Duration) (*[]models.Mail, error)
+	MarkMailSent(id int64) error
+	MarkMailFailed(id int64, reason string, retryAt *time.Time) error
+
+	NotFoundErr() error
+	ConflictErr() error
+}
+
+type mailTemplates struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// What the templates see of a notification or a demo
+type mailItem struct {
+	Type       string
+	TargetType string
+	TargetID   int
+	Title      string
+	Count      int
+	Tags       []string
+}
+
+type mailData struct {
+	Name           string
+	UnsubscribeURL string
+	Notifications  []mailItem
+	Demos          []mailItem
+}
+
+// Mails notifications right away or as daily and weekly digests, as each user prefers,
+// through the outbox in the repository
+type Mailer struct {
+	repository MailRepository
+	transport  MailTransport
+	templates  map[string]mailTemplates
+	siteURL    string
+	apiURL     string
+	logger     echo.Logger
+}
+
+// Links in mail lead to siteURL, which also serves the unsubscribe page for a token.
+// One-click unsubscribe goes to the API at apiURL
+func NewMailer(r MailRepository, t MailTransport, siteURL, apiURL string, l echo.Logger) (*Mailer, error) {
+	m := &Mailer{
+		repository: r,
+		transport:  t,
+		templates:  make(map[string]mailTemplates, len(mailPluralRules)),
+		siteURL:    strings.TrimSuffix(siteURL, "/"),
+		apiURL:     strings.TrimSuffix(apiURL, "/"),
+		logger:     l,
+	}
+
+	for locale, rule := range mailPluralRules {
+		funcs := texttemplate.FuncMap{
+			"plural": func(n int, forms ...string) string { return forms[min(rule(n), len(forms)-1)] },
+			"link":   func(targetType string, id int) string { return fmt.Sprintf("%v/%vs/%v", m.siteURL, targetType, id) },
+			"join":   strings.Join,
+		}
+
+		text, err := texttemplate.New(locale).Funcs(funcs).ParseFS(mailTemplateFS,
+			"mailTemplates/"+locale+"/*.tmpl", "mailTemplates/"+locale+"/*.txt")
+		if err != nil {
+			return nil, err
+		}
+		html, err := htmltemplate.New(locale).Funcs(htmltemplate.FuncMap(funcs)).ParseFS(mailTemplateFS,
+			"mailTemplates/"+locale+"/*.tmpl", "mailTemplates/"+locale+"/*.html")
+		if err != nil {
+			return nil, err
+		}
+		m.templates[locale] = mailTemplates{text: text, html: html}
+	}
+
+	return m, nil
+}
+
+// Renders the mail in the locale of the recipient. Kind is either notification or digest
+func (m *Mailer) Render(kind string, n models.NotificationMail) (*models.Mail, error) {
+	var subject, text, html strings.Builder
+
+	t, ok := m.templates[n.Recipient.Locale]
+	if !ok {
+		t = m.templates["en"]
+	}
+
+	data := mailData{
+		Name:           n.Recipient.Name,
+		UnsubscribeURL: m.siteURL + "/unsubscribe?token=" + n.Recipient.UnsubscribeToken.String(),
+	}
+	for _, notification := range n.Notifications {
+		data.Notifications = append(data.Notifications, mailItem{
+			Type:       *notification.Type,
+			TargetType: *notification.TargetType,
+			TargetID:   *notification.TargetID,
+			Title:      notification.TargetTitle,
+			Count:      *notification.Count,
+		})
+	}
+	for _, demo := range n.Demos {
+		item := mailItem{TargetType: "demo", TargetID: *demo.ID, Title: *demo.Title}
+		if demo.Tags != nil {
+			item.Tags = *demo.Tags
+		}
+		data.Demos = append(data.Demos, item)
+	}
+
+	err := t.text.ExecuteTemplate(&subject, kind+".subject", data)
+	if err != nil {
+		return nil, err
+	}
+	err = t.text.ExecuteTemplate(&text, kind+".txt", data)
+	if err != nil {
+		return nil, err
+	}
+	err = t.html.ExecuteTemplate(&html, kind+".html", data)
+	if err != nil {
+		return nil, err
+	}
+
+	mail := models.Mail{
+		UserID:         &n.Recipient.UserID,
+		Recipient:      &n.Recipient.Email,
+		Subject:        new(string),
+		HTML:           new(string),
+		Text:           new(string),
+		UnsubscribeURL: new(string),
+	}
+	*mail.Subject, *mail.Text, *mail.HTML = subject.String(), text.String(), html.String()
+	*mail.UnsubscribeURL = m.apiURL + "/game-hangar/v1/email/unsubscribe/" + n.Recipient.UnsubscribeToken.String()
+	return &mail, nil
+}
+
+// Queues and delivers mail on start and then every interval until ctx is cancelled
+func (m *Mailer) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		m.queueNotifications()
+		m.queueDigests()
+		m.deliver()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *Mailer) queueNotifications() {
+	mails, err := m.repository.FindUnmailedNotifications(mailSettle)
+	if err != nil {
+		if err != m.repository.NotFoundErr() {
+			m.logger.Errorf("Error finding notifications to mail: %v", err)
+		}
+		return
+	}
+
+	for _, n := range *mails {
+		var notificationIDs []int
+
+		mail, err := m.Render("notification", n)
+		if err != nil {
+			m.logger.Errorf("Error rendering notification mail: %v", err)
+			continue
+		}
+		for _, notification := range n.Notifications {
+			notificationIDs = append(notificationIDs, *notification.ID)
+		}
+
+		err = m.repository.QueueMail(*mail, notificationIDs)
+		if err != nil && err != m.repository.ConflictErr() {
+			m.logger.Errorf("Error queueing notification mail: %v", err)
+		}
+	}
+}
+
+func (m *Mailer) queueDigests() {
+	digests, err := m.repository.FindDueDigests(mailBatchSize)
+	if err != nil {
+		if err != m.repository.NotFoundErr() {
+			m.logger.Errorf("Error finding due digests: %v", err)
+		}
+		return
+	}
+
+	for _, d := range *digests {
+		var mail *models.Mail
+
+		// An empty digest is not sent, but it still starts the next period
+		if len(d.Notifications) != 0 || len(d.Demos) != 0 {
+			mail, err = m.Render("digest", d)
+			if err != nil {
+				m.logger.Errorf("Error rendering digest: %v", err)
+				continue
+			}
+		}
+
+		err = m.repository.QueueDigest(d.Recipient, mail, nil)
+		if err != nil && err != m.repository.ConflictErr() {
+			m.logger.Errorf("Error queueing digest: %v", err)
+		}
+	}
+}
+
+// Sends due mail from the outbox. Failed mail is retried with exponential backoff
+// unless the transport reports that retrying cannot help
+func (m *Mailer) deliver() {
+	mails, err := m.repository.ClaimMail(mailBatchSize, mailLease)
+	if err != nil {
+		if err != m.repository.NotFoundErr() {
+			m.logger.Errorf("Error claiming mail: %v", err)
+		}
+		return
+	}
+
+	for _, mail := range *mails {
+		var (
+			permanent *PermanentMailError
+			retryAt   *time.Time
+		)
+
+		err = m.transport.Send(mail)
+		if err == nil {
+			err = m.repository.MarkMailSent(*mail.ID)
+			if err != nil {
+				m.logger.Errorf("Error marking mail %v sent: %v", *mail.ID, err)
+			}
+			continue
+		}
+
+		if !errors.As(err, &permanent) && *mail.Attempts < mailMaxAttempts {
+			t := time.Now().Add(min(time.Minute<<*mail.Attempts, 12*time.Hour))
+			retryAt = &t
+		}
+		m.logger.Warnf("Error sending mail %v, attempt %v: %v", *mail.ID, *mail.Attempts, err)
+
+		err = m.repository.MarkMailFailed(*mail.ID, err.Error(), retryAt)
+		if err != nil {
+			m.logger.Errorf("Error marking mail %v failed: %v", *mail.ID, err)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"errors"
+	"gamehangar/internal/domain/models"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockMailRepo struct {
+	queued []models.Mail
+	outbox []models.Mail
+	sent   []int64
+	failed map[int64]*time.Time
+}
+
+func (r *mockMailRepo) FindUnmailedNotifications(settle time.Duration) (*[]models.NotificationMail, error) {
+	return &[]models.NotificationMail{mailNotification("en")}, nil
+}
+func (r *mockMailRepo) FindDueDigests(limit int) (*[]models.NotificationMail, error) {
+	return nil, r.NotFoundErr()
+}
+func (r *mockMailRepo) QueueMail(mail models.Mail, notificationIDs []int) error {
+	r.queued = append(r.queued, mail)
+	return nil
+}
+func (r *mockMailRepo) QueueDigest(recipient models.MailRecipient, mail *models.Mail, notificationIDs []int) error {
+	return nil
+}
+func (r *mockMailRepo) ClaimMail(limit int, lease time.Duration) (*[]models.Mail, error) {
+	if len(r.outbox) == 0 {
+		return nil, r.NotFoundErr()
+	}
+	mails := r.outbox
+	r.outbox = nil
+	return &mails, nil
+}
+func (r *mockMailRepo) MarkMailSent(id int64) error {
+	r.sent = append(r.sent, id)
+	return nil
+}
+func (r *mockMailRepo) MarkMailFailed(id int64, reason string, retryAt *time.Time) error {
+	r.failed[id] = retryAt
+	return nil
+}
+func (r *mockMailRepo) NotFoundErr() error { return errors.New("Not Found") }
+func (r *mockMailRepo) ConflictErr() error { return errors.New("Mail already queued!") }
+
+// Fails mail to recipients starting with "temporary" or "permanent"
+type mockMailTransport struct{}
+
+func (t *mockMailTransport) Send(mail models.Mail) error {
+	switch {
+	case strings.HasPrefix(*mail.Recipient, "temporary"):
+		return &textproto.Error{Code: 421, Msg: "Try again later"}
+	case strings.HasPrefix(*mail.Recipient, "permanent"):
+		return &PermanentMailError{Err: &textproto.Error{Code: 550, Msg: "No such mailbox"}}
+	}
+	return nil
+}
+
+func mailNotification(locale string) models.NotificationMail {
+	id, notificationType, targetType, count := 1, "reply", "thread", 5
+	demoID, demoTitle, demoTags := 2, "Platformer", []string{"2d", "platformer"}
+
+	return models.NotificationMail{
+		Recipient: models.MailRecipient{UserID: uuid.New(), Email: "user@example.com", Name: "Mike", Locale: locale},
+		Notifications: []models.MailNotification{{
+			Notification: models.Notification{ID: &id, Type: &notificationType, TargetType: &targetType, TargetID: &id, Count: &count},
+			TargetTitle:  "Jump <physics>",
+		}},
+		Demos: []models.Demo{{ID: &demoID, Title: &demoTitle, Tags: &demoTags}},
+	}
+}
+
+func TestMailerRender(t *testing.T) {
+	m, err := NewMailer(&mockMailRepo{}, &mockMailTransport{}, "https://example.com/", "https://api.example.com", echo.New().Logger)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	mail, err := m.Render("notification", mailNotification("en"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "5 new replies in “Jump <physics>”", *mail.Subject)
+		assert.Contains(t, *mail.Text, "https://example.com/threads/1")
+		assert.Contains(t, *mail.HTML, "Jump &lt;physics&gt;")
+		assert.True(t, strings.HasPrefix(*mail.UnsubscribeURL, "https://api.example.com/game-hangar/v1/email/unsubscribe/"))
+	}
+
+	mail, err = m.Render("digest", mailNotification("ru"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Дайджест Game Hangar: ответы в 1 теме, 1 новое демо", *mail.Subject)
+		assert.Contains(t, *mail.Text, "5 новых ответов в теме «Jump <physics>»")
+		assert.Contains(t, *mail.Text, "Platformer [2d, platformer]")
+	}
+
+	// Unknown locales fall back to English
+	_, err = m.Render("digest", mailNotification("de"))
+	assert.NoError(t, err)
+}
+
+func TestMailerDeliver(t *testing.T) {
+	var (
+		ids        = []int64{1, 2, 3}
+		recipients = []string{"user@example.com", "temporary@example.com", "permanent@example.com"}
+		attempts   = 1
+	)
+
+	r := &mockMailRepo{failed: make(map[int64]*time.Time)}
+	for i := range ids {
+		r.outbox = append(r.outbox, models.Mail{ID: &ids[i], Recipient: &recipients[i], Attempts: &attempts})
+	}
+	m, err := NewMailer(r, &mockMailTransport{}, "https://example.com", "https://example.com", echo.New().Logger)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	m.queueNotifications()
+	m.deliver()
+
+	assert.Len(t, r.queued, 1)
+	assert.Equal(t, []int64{1}, r.sent)
+	if assert.NotNil(t, r.failed[2]) {
+		assert.WithinDuration(t, time.Now().Add(2*time.Minute), *r.failed[2], time.Second)
+	}
+	assert.Contains(t, r.failed, int64(3))
+	assert.Nil(t, r.failed[3])
+}
+
+func TestBuildMail(t *testing.T) {
+	var (
+		id          int64 = 1
+		recipient         = "user@example.com"
+		subject           = "Новый ответ"
+		body              = "Hi"
+		unsubscribe       = "https://example.com/game-hangar/v1/email/unsubscribe/token"
+	)
+
+	msg, err := buildMail("Game Hangar <mail@example.com>", models.Mail{
+		ID: &id, Recipient: &recipient, Subject: &subject, HTML: &body, Text: &body, UnsubscribeURL: &unsubscribe,
+	})
+	if assert.NoError(t, err) {
+		assert.Contains(t, string(msg), "Subject: =?UTF-8?q?")
+		assert.Contains(t, string(msg), "Message-ID: <outbox-1@example.com>")
+		assert.Contains(t, string(msg), "List-Unsubscribe: <"+unsubscribe+">")
+		assert.Contains(t, string(msg), "Content-Type: text/html; charset=UTF-8")
+	}
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"gamehangar/internal/domain/models"
+	"regexp"
+
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/labstack/echo/v4"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	highlighting "github.com/yuin/goldmark-highlighting/v2"
+	"github.com/yuin/goldmark/extension"
+)
+
+// Sources rendered per batch when rendering stored text that has no HTML yet
+const markdownBatchSize = 100
+
+type MarkdownRepository interface {
+	FindUnrenderedMarkdown(limit int) (*[]models.MarkdownSource, error)
+	UpdateRenderedMarkdown(source models.MarkdownSource) error
+	NotFoundErr() error
+}
+
+// Renders user-authored Markdown to HTML that clients can show as is. Supports CommonMark,
+// GFM tables, strikethrough and autolinks. Code blocks are highlighted with CSS classes
+// (e.g. ```gdscript), so the frontend only needs a Chroma stylesheet.
+// Raw HTML in the source is dropped and the output is sanitized with a strict allowlist
+type MarkdownRenderer struct {
+	repository MarkdownRepository
+	logger     echo.Logger
+	markdown   goldmark.Markdown
+	policy     *bluemonday.Policy
+}
+
+func NewMarkdownRenderer(r MarkdownRepository, l echo.Logger) *MarkdownRenderer {
+	return &MarkdownRenderer{
+		repository: r,
+		logger:     l,
+		markdown: goldmark.New(
+			goldmark.WithExtensions(
+				extension.NewTable(extension.WithTableCellAlignMethod(extension.TableCellAlignAttribute)),
+				extension.Strikethrough,
+				extension.Linkify,
+				highlighting.NewHighlighting(
+					highlighting.WithFormatOptions(chromahtml.WithClasses(true)),
+				),
+			),
+		),
+		policy: markdownPolicy(),
+	}
+}
+
+func markdownPolicy() *bluemonday.Policy {
+	p := bluemonday.NewPolicy()
+
+	p.AllowElements("p", "br", "hr", "h1", "h2", "h3", "h4", "h5", "h6", "blockquote",
+		"ul", "ol", "li", "strong", "em", "del", "code", "pre", "span",
+		"table", "thead", "tbody", "tr", "th", "td")
+	p.AllowAttrs("start").Matching(bluemonday.Integer).OnElements("ol")
+	p.AllowAttrs("align").Matching(regexp.MustCompile(`^(left|center|right)$`)).OnElements("th", "td")
+	// Chroma token classes, e.g. <span class="nf">
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^(chroma|line|cl|[a-z]{1,2}[0-9]?)$`)).OnElements("pre", "span")
+
+	p.AllowAttrs("href").OnElements("a")
+	p.AllowURLSchemes("http", "https", "mailto")
+	p.AllowRelativeURLs(true)
+	p.RequireNoFollowOnLinks(true)
+	p.RequireNoReferrerOnLinks(true)
+	p.AddTargetBlankToFullyQualifiedLinks(true)
+
+	return p
+}
+
+func (r *MarkdownRenderer) Render(source string) (string, error) {
+	var b bytes.Buffer
+
+	err := r.markdown.Convert([]byte(source), &b)
+	if err != nil {
+		return "", err
+	}
+	return r.policy.Sanitize(b.String()), nil
+}
+
+// Renders stored text that has no HTML yet, e.g. text written before rendering was introduced
+// or whose HTML was cleared to render it again. Stops when everything is rendered or ctx is cancelled
+func (r *MarkdownRenderer) RenderStored(ctx context.Context) {
+	var rendered int
+
+	for ctx.Err() == nil {
+		sources, err := r.repository.FindUnrenderedMarkdown(markdownBatchSize)
+		if err != nil {
+			if err != r.repository.NotFoundErr() {
+				r.logger.Errorf("Error finding unrendered Markdown: %v", err)
+			}
+			break
+		}
+
+		for _, s := range *sources {
+			html, err := r.Render(*s.Source)
+			if err == nil {
+				s.HTML = &html
+				err = r.repository.UpdateRenderedMarkdown(s)
+			}
+			if err != nil {
+				r.logger.Errorf("Error rendering Markdown of %v %v: %v", *s.Type, *s.ID, err)
+				return
+			}
+			rendered++
+		}
+	}
+
+	if rendered != 0 {
+		r.logger.Infof("Rendered Markdown of %v records", rendered)
+	}
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"gamehangar/internal/domain/models"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockMarkdownRepo struct {
+	sources  []models.MarkdownSource
+	rendered map[int]string
+}
+
+func (r *mockMarkdownRepo) FindUnrenderedMarkdown(limit int) (*[]models.MarkdownSource, error) {
+	var sources []models.MarkdownSource
+	for _, s := range r.sources {
+		if _, ok := r.rendered[*s.ID]; !ok && len(sources) < limit {
+			sources = append(sources, s)
+		}
+	}
+	if len(sources) == 0 {
+		return nil, r.NotFoundErr()
+	}
+	return &sources, nil
+}
+func (r *mockMarkdownRepo) UpdateRenderedMarkdown(source models.MarkdownSource) error {
+	r.rendered[*source.ID] = *source.HTML
+	return nil
+}
+func (r *mockMarkdownRepo) NotFoundErr() error { return errNotFound }
+
+var errNotFound = errors.New("Not Found")
+
+func TestMarkdownRender(t *testing.T) {
+	m := NewMarkdownRenderer(&mockMarkdownRepo{}, echo.New().Logger)
+
+	for name, tc := range map[string]struct {
+		source   string
+		contains []string
+		excludes []string
+	}{
+		"emphasis": {
+			source:   "**Jump** _higher_ ~~lower~~",
+			contains: []string{"<strong>Jump</strong>", "<em>higher</em>", "<del>lower</del>"},
+		},
+		"table": {
+			source:   "| Node | Speed |\n| :-- | --: |\n| Player | 300 |",
+			contains: []string{"<table>", `<th align="left">Node</th>`, `<td align="right">300</td>`},
+		},
+		"gdscript": {
+			source:   "```gdscript\nfunc _ready():\n\tprint(\"hi\")\n```",
+			contains: []string{`<pre class="chroma">`, `<span class="kd">func</span>`, `<span class="nf">_ready</span>`},
+			excludes: []string{"style="},
+		},
+		"raw html": {
+			source:   "<script>alert(1)</script><img src=x onerror=alert(1)>\n\n<b onclick=\"x\">bold</b>",
+			excludes: []string{"<script", "<img", "onerror", "onclick", "<b"},
+		},
+		"links": {
+			source:   "[site](https://example.com) [bad](javascript:alert(1)) www.godotengine.org",
+			contains: []string{`<a href="https://example.com" rel="nofollow noreferrer noopener" target="_blank">site</a>`, `href="http://www.godotengine.org"`},
+			excludes: []string{"javascript:"},
+		},
+	} {
+		html, err := m.Render(tc.source)
+		if assert.NoError(t, err, name) {
+			for _, s := range tc.contains {
+				assert.Contains(t, html, s, name)
+			}
+			for _, s := range tc.excludes {
+				assert.NotContains(t, html, s, name)
+			}
+		}
+	}
+}
+
+func TestMarkdownRenderStored(t *testing.T) {
+	var (
+		r = mockMarkdownRepo{rendered: make(map[int]string)}
+		m = NewMarkdownRenderer(&r, echo.New().Logger)
+	)
+	for i := range markdownBatchSize + 1 {
+		id, kind, source := i, "message", "*Hi*"
+		r.sources = append(r.sources, models.MarkdownSource{Type: &kind, ID: &id, Source: &source})
+	}
+
+	m.RenderStored(context.Background())
+	assert.Len(t, r.rendered, markdownBatchSize+1)
+	assert.Equal(t, "<p><em>Hi</em></p>\n", r.rendered[markdownBatchSize])
+}
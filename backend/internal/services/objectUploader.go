@@ -189,5 +189,27 @@ func (u *ObjectUploader) DeleteObject(objectKey string) error {
 	return nil
 }
 
+// Copies an object within the bucket without downloading it
+func (u *ObjectUploader) CopyObject(srcKey, dstKey string) error {
+	_, err := u.s3Client.CopyObject(context.Background(), &s3.CopyObjectInput{
+		Bucket:     aws.String(u.bucketName),
+		CopySource: aws.String(u.bucketName + "/" + srcKey),
+		Key:        aws.String(dstKey),
+	})
+	if err != nil {
+		var noKey *types.NoSuchKey
+		if errors.As(err, &noKey) {
+			return u.errObjectNotFound
+		}
+		return err
+	}
+	err = s3.NewObjectExistsWaiter(u.s3Client).Wait(
+		context.Background(), &s3.HeadObjectInput{Bucket: aws.String(u.bucketName), Key: aws.String(dstKey)}, time.Minute)
+	if err != nil {
+		return u.errObjectNotFound
+	}
+	return nil
+}
+
 func (u *ObjectUploader) ObjectTooLargeErr() error { return u.errObjectTooLarge }
 func (u *ObjectUploader) ObjectNotFoundErr() error { return u.errObjectNotFound }
@@ -0,0 +1,243 @@
+package services
+
+import (
+	"context"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// Route classes that are limited separately
+const (
+	RateLimitAuth   = "auth"
+	RateLimitWrite  = "write"
+	RateLimitUpload = "upload"
+	RateLimitRead   = "read"
+)
+
+// Token bucket: Burst requests at once, refilled evenly over Period
+type RateLimit struct {
+	Burst  int
+	Period time.Duration
+}
+
+// Tokens added to the bucket per second
+func (l RateLimit) rate() float64 { return float64(l.Burst) / l.Period.Seconds() }
+
+// Limits of every route class by user tier. Requests without a session use "anonymous",
+// unknown tiers fall back to "freetier"
+type RateLimits map[string]map[string]RateLimit
+
+var DefaultRateLimits = RateLimits{
+	"anonymous": {
+		RateLimitAuth:   {Burst: 10, Period: time.Minute},
+		RateLimitWrite:  {Burst: 10, Period: time.Minute},
+		RateLimitUpload: {Burst: 5, Period: time.Hour},
+		RateLimitRead:   {Burst: 120, Period: time.Minute},
+	},
+	"freetier": {
+		RateLimitAuth:   {Burst: 10, Period: time.Minute},
+		RateLimitWrite:  {Burst: 30, Period: time.Minute},
+		RateLimitUpload: {Burst: 10, Period: time.Hour},
+		RateLimitRead:   {Burst: 300, Period: time.Minute},
+	},
+	"paidtier":  paidtierRateLimits,
+	"moderator": paidtierRateLimits,
+	"admin":     paidtierRateLimits,
+}
+
+var paidtierRateLimits = map[string]RateLimit{
+	RateLimitAuth:   {Burst: 10, Period: time.Minute},
+	RateLimitWrite:  {Burst: 120, Period: time.Minute},
+	RateLimitUpload: {Burst: 60, Period: time.Hour},
+	RateLimitRead:   {Burst: 1200, Period: time.Minute},
+}
+
+// Routes that authenticate or create accounts, limited by IP regardless of the session
+var rateLimitAuthRoutes = []string{"login", "register", "reset-password", "verify"}
+
+type RateLimitStore interface {
+	// Refills the bucket of the key and takes a token from it if there is one.
+	// Returns the tokens left in the bucket
+	TakeToken(key string, burst int, rate float64) (tokens float64, allowed bool, err error)
+	// Drops buckets that have not been used since before
+	PurgeBuckets(before time.Time) (int64, error)
+}
+
+type RateLimiter struct {
+	store  RateLimitStore
+	limits RateLimits
+	logger echo.Logger
+}
+
+func NewRateLimiter(s RateLimitStore, limits RateLimits, l echo.Logger) *RateLimiter {
+	return &RateLimiter{
+		store:  s,
+		limits: limits,
+		logger: l,
+	}
+}
+
+// Returns the route class of the request
+func RateLimitClass(c echo.Context) string {
+	segment, _, _ := strings.Cut(strings.TrimPrefix(c.Request().URL.Path, "/game-hangar/v1/"), "/")
+	for _, route := range rateLimitAuthRoutes {
+		if segment == route {
+			return RateLimitAuth
+		}
+	}
+
+	switch c.Request().Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return RateLimitRead
+	}
+	if strings.HasPrefix(c.Request().Header.Get(echo.HeaderContentType), echo.MIMEMultipartForm) {
+		return RateLimitUpload
+	}
+	return RateLimitWrite
+}
+
+// Tells clients apart by the address they connect from, since anonymous clients are limited by IP.
+// Behind proxies in the trusted CIDR ranges the client is the last untrusted address of X-Forwarded-For.
+// Headers are never trusted otherwise, or clients could pick a fresh IP for every request
+func ClientIPExtractor(trustedProxies []string) (echo.IPExtractor, error) {
+	if len(trustedProxies) == 0 {
+		return echo.ExtractIPDirect(), nil
+	}
+	options := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+	for _, cidr := range trustedProxies {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, echo.TrustIPRange(ipNet))
+	}
+	return echo.ExtractIPFromXFFHeader(options...), nil
+}
+
+// Must be registered after UserAuthorizer.IdentifySession, which tells users apart from anonymous clients
+func (l *RateLimiter) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			class := RateLimitClass(c)
+
+			tier, key := "anonymous", "ip:"+c.RealIP()
+			if userID, ok := c.Get("userID").(uuid.UUID); ok && class != RateLimitAuth {
+				tier, key = "freetier", "user:"+userID.String()
+				if t, ok := c.Get("userTier").(string); ok {
+					if _, ok := l.limits[t]; ok {
+						tier = t
+					}
+				}
+			}
+			limit, ok := l.limits[tier][class]
+			if !ok {
+				return next(c)
+			}
+
+			tokens, allowed, err := l.store.TakeToken(key+":"+class, limit.Burst, limit.rate())
+			if err != nil {
+				// An unavailable store must not take the API down with it
+				l.logger.Errorf("Error taking rate limit token: %v", err)
+				return next(c)
+			}
+
+			h := c.Response().Header()
+			h.Set("RateLimit-Limit", strconv.Itoa(limit.Burst))
+			h.Set("RateLimit-Remaining", strconv.Itoa(int(math.Floor(tokens))))
+			h.Set("RateLimit-Reset", strconv.Itoa(int(math.Ceil((float64(limit.Burst)-tokens)/limit.rate()))))
+			if !allowed {
+				h.Set("Retry-After", strconv.Itoa(int(math.Ceil((1-tokens)/limit.rate()))))
+				return c.JSON(http.StatusTooManyRequests, map[string]any{
+					"code":    http.StatusTooManyRequests,
+					"message": "Too many requests!",
+				})
+			}
+			return next(c)
+		}
+	}
+}
+
+// Purges idle buckets every interval until ctx is cancelled. A bucket idle
+// for longer than the longest period is full again and can be dropped
+func (l *RateLimiter) Run(ctx context.Context, interval time.Duration) {
+	var idle time.Duration
+	for _, classes := range l.limits {
+		for _, limit := range classes {
+			idle = max(idle, limit.Period)
+		}
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		_, err := l.store.PurgeBuckets(time.Now().Add(-idle))
+		if err != nil {
+			l.logger.Errorf("Error purging rate limit buckets: %v", err)
+		}
+	}
+}
+
+type rateLimitBucket struct {
+	tokens    float64
+	updatedAt time.Time
+}
+
+// Keeps buckets in memory, so every instance of the service limits on its own
+type MemoryRateLimitStore struct {
+	mu      sync.Mutex
+	buckets map[string]*rateLimitBucket
+}
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{buckets: make(map[string]*rateLimitBucket)}
+}
+
+func (s *MemoryRateLimitStore) TakeToken(key string, burst int, rate float64) (float64, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &rateLimitBucket{tokens: float64(burst), updatedAt: now}
+		s.buckets[key] = b
+	}
+	b.tokens = min(float64(burst), b.tokens+now.Sub(b.updatedAt).Seconds()*rate)
+	b.updatedAt = now
+
+	if b.tokens < 1 {
+		return b.tokens, false, nil
+	}
+	b.tokens--
+	return b.tokens, true, nil
+}
+
+func (s *MemoryRateLimitStore) PurgeBuckets(before time.Time) (int64, error) {
+	var purged int64
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, b := range s.buckets {
+		if b.updatedAt.Before(before) {
+			delete(s.buckets, key)
+			purged++
+		}
+	}
+	return purged, nil
+}
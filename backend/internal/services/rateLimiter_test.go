@@ -0,0 +1,138 @@
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiterMiddleware(t *testing.T) {
+	limits := RateLimits{
+		"anonymous": {RateLimitAuth: {Burst: 2, Period: time.Hour}, RateLimitRead: {Burst: 1, Period: time.Hour}},
+		"freetier":  {RateLimitRead: {Burst: 2, Period: time.Hour}},
+	}
+	userID := uuid.New()
+	e := echo.New()
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if c.Request().Header.Get("Sessionid") != "" {
+				c.Set("userID", userID)
+				c.Set("userTier", "freetier")
+			}
+			return next(c)
+		}
+	})
+	e.Use(NewRateLimiter(NewMemoryRateLimitStore(), limits, e.Logger).Middleware())
+	e.GET("/game-hangar/v1/demos", func(c echo.Context) error { return c.String(http.StatusOK, "Demos") })
+	e.POST("/game-hangar/v1/login", func(c echo.Context) error { return c.String(http.StatusOK, "Login successful") })
+
+	serve := func(method, path string, session bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set(echo.HeaderXRealIP, "192.0.2.1")
+		if session {
+			req.Header.Set("Sessionid", "session")
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve(http.MethodGet, "/game-hangar/v1/demos", false)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "0", rec.Header().Get("RateLimit-Remaining"))
+
+	rec = serve(http.MethodGet, "/game-hangar/v1/demos", false)
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "3600", rec.Header().Get("Retry-After"))
+
+	// Users have their own bucket and tier
+	rec = serve(http.MethodGet, "/game-hangar/v1/demos", true)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("RateLimit-Remaining"))
+
+	// Auth routes are limited by IP in their own class
+	assert.Equal(t, http.StatusOK, serve(http.MethodPost, "/game-hangar/v1/login", true).Code)
+	assert.Equal(t, http.StatusOK, serve(http.MethodPost, "/game-hangar/v1/login", false).Code)
+	assert.Equal(t, http.StatusTooManyRequests, serve(http.MethodPost, "/game-hangar/v1/login", true).Code)
+}
+
+func TestClientIPExtractor(t *testing.T) {
+	limits := RateLimits{"anonymous": {RateLimitRead: {Burst: 1, Period: time.Hour}}}
+	direct, err := ClientIPExtractor(nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	proxied, err := ClientIPExtractor([]string{"10.0.0.0/8"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = ClientIPExtractor([]string{"10.0.0.1"})
+	assert.Error(t, err)
+
+	for _, extractor := range []echo.IPExtractor{direct, proxied} {
+		e := echo.New()
+		e.IPExtractor = extractor
+		e.Use(NewRateLimiter(NewMemoryRateLimitStore(), limits, e.Logger).Middleware())
+		e.GET("/game-hangar/v1/demos", func(c echo.Context) error { return c.String(http.StatusOK, c.RealIP()) })
+		serve := func(remoteAddr, forwardedFor string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodGet, "/game-hangar/v1/demos", nil)
+			req.RemoteAddr = remoteAddr
+			req.Header.Set(echo.HeaderXForwardedFor, forwardedFor)
+			req.Header.Set(echo.HeaderXRealIP, forwardedFor)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			return rec
+		}
+
+		// Spoofed headers do not reset the bucket of a client connecting directly
+		assert.Equal(t, http.StatusOK, serve("192.0.2.1:1234", "198.51.100.1").Code)
+		assert.Equal(t, http.StatusTooManyRequests, serve("192.0.2.1:1234", "198.51.100.2").Code)
+	}
+
+	// Behind a trusted proxy the forwarded client is limited, whatever it prepends to the header
+	e := echo.New()
+	e.IPExtractor = proxied
+	e.GET("/game-hangar/v1/demos", func(c echo.Context) error { return c.String(http.StatusOK, c.RealIP()) })
+	req := httptest.NewRequest(http.MethodGet, "/game-hangar/v1/demos", nil)
+	req.RemoteAddr = "10.0.0.2:1234"
+	req.Header.Set(echo.HeaderXForwardedFor, "198.51.100.9, 192.0.2.3")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, "192.0.2.3", rec.Body.String())
+}
+
+func TestRateLimitClass(t *testing.T) {
+	e := echo.New()
+	class := func(method, path, contentType string) string {
+		req := httptest.NewRequest(method, path, strings.NewReader(""))
+		req.Header.Set(echo.HeaderContentType, contentType)
+		return RateLimitClass(e.NewContext(req, httptest.NewRecorder()))
+	}
+
+	assert.Equal(t, RateLimitAuth, class(http.MethodPost, "/game-hangar/v1/register", echo.MIMEMultipartForm))
+	assert.Equal(t, RateLimitRead, class(http.MethodGet, "/game-hangar/v1/demos/1", ""))
+	assert.Equal(t, RateLimitUpload, class(http.MethodPost, "/game-hangar/v1/demos", echo.MIMEMultipartForm+"; boundary=x"))
+	assert.Equal(t, RateLimitWrite, class(http.MethodPost, "/game-hangar/v1/messages", echo.MIMEApplicationJSON))
+}
+
+func TestMemoryRateLimitStore(t *testing.T) {
+	s := NewMemoryRateLimitStore()
+
+	tokens, allowed, err := s.TakeToken("ip:192.0.2.1:read", 2, 1000)
+	if assert.NoError(t, err) {
+		assert.True(t, allowed)
+		assert.Less(t, tokens, 2.0)
+	}
+
+	purged, err := s.PurgeBuckets(time.Now().Add(time.Minute))
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(1), purged)
+	}
+}
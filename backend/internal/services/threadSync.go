@@ -1,15 +1,19 @@
 package services
 
-import "gamehangar/internal/domain/models"
+import (
+	"gamehangar/internal/domain/models"
+
+	"github.com/google/uuid"
+)
 
 type ForumRepository interface {
 	CreateThread(thread models.Thread) (*models.Thread, error)
 	UpdateThread(id int, thread models.Thread) (*models.Thread, error)
-	DeleteThread(id int) error
+	PurgeThread(id int, purgedBy uuid.UUID) error
 }
 
 type DemoRepository interface {
-	FindDemoByID(id int) (*models.Demo, error)
+	PeekDemoByID(id int) (*models.Demo, error)
 	NotFoundErr() error
 }
 
@@ -51,6 +55,12 @@ func (s *ThreadSyncer) PostThread(demo models.Demo) (*int, error) {
 	return t.ID, nil
 }
 
+// Removes the thread posted for a demo that could not be stored. It skips the trash,
+// which would let the user restore a thread without its demo
+func (s *ThreadSyncer) DropThread(threadID int, userID uuid.UUID) error {
+	return s.threadRepository.PurgeThread(threadID, userID)
+}
+
 func (s *ThreadSyncer) PatchThread(demoID int, demo models.Demo) error {
 	thread := models.Thread{
 		Title:     demo.Title,
@@ -61,7 +71,7 @@ func (s *ThreadSyncer) PatchThread(demoID int, demo models.Demo) error {
 	}
 
 	if demo.ThreadID == nil {
-		d, err := s.demoRepository.FindDemoByID(demoID)
+		d, err := s.demoRepository.PeekDemoByID(demoID)
 		if notFoundErr := s.demoRepository.NotFoundErr(); notFoundErr == err {
 			return notFoundErr
 		}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"gamehangar/internal/config/psqlDatabseConfig"
 	"gamehangar/internal/database/psqlDatabase"
+	"gamehangar/internal/database/psqlDatabase/psqlTestDatabase"
 	"gamehangar/internal/domain/models"
 	"gamehangar/internal/enforcer/psqlCasbinClient"
 	"gamehangar/internal/repository/psqlRepository"
@@ -23,7 +24,8 @@ func (s *MockS3) GetObjectLink(objectKey string) (*string, error) {
 	l := "https://example.com"
 	return &l, nil
 }
-func (s *MockS3) DeleteObject(objectKey string) error { return nil }
+func (s *MockS3) DeleteObject(objectKey string) error    { return nil }
+func (s *MockS3) CopyObject(srcKey, dstKey string) error { return nil }
 
 var (
 	independent bool = false
@@ -46,14 +48,17 @@ var (
 )
 
 func init() {
-	var err error
+	var (
+		err            error
+		databaseConfig *psqlDatabseConfig.PsqlDatabaseConfig
+	)
 	wd, _ := os.Getwd()
 	if independent || 1 == 1 { // HACK!
 		err := godotenv.Load(wd + "/../../.env")
 		if err != nil {
 			panic("Error loading .env file:" + err.Error() + ": " + wd)
 		}
-		databaseConfig, err := psqlDatabseConfig.PsqlConfig{}.NewConfig(
+		databaseConfig, err = psqlDatabseConfig.PsqlConfig{}.NewConfig(
 			psqlDatabase.MigrationFiles, os.Getenv("PSQL_MIGRATE_ROOT_DIR"),
 		)
 		if err != nil {
@@ -67,96 +72,9 @@ func init() {
 		}
 	}
 	c, _ := testDBClient.AcquireConn() // WARNING! Integration tests DROP TABLEs
-	_, err = c.Exec(context.Background(), `
-		DROP SCHEMA IF EXISTS "demo" CASCADE;
-		DROP SCHEMA IF EXISTS "forum" CASCADE;
-
-		DROP INDEX IF EXISTS demo_gin_index_ts;
-
-		DROP COLLATION IF EXISTS case_insensitive;
-
-		CREATE SCHEMA IF NOT EXISTS forum;
-
-		CREATE TABLE forum.topics (
-		"id"  INTEGER PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
-		"name" VARCHAR(255) NOT NULL,
-		"version" INTEGER NOT NULL DEFAULT 1
-		);
-
-		CREATE TABLE forum.threads (
-		"id" INTEGER PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
-		"title" VARCHAR(255) NOT NULL,
-		"user_id" UUID NOT NULL,
-		"topic_id" INTEGER NOT NULL REFERENCES forum.topics (id) ON DELETE CASCADE,
-		"tags" VARCHAR(255)[],
-		"created_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
-		"updated_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
-		"upvotes" INTEGER NOT NULL DEFAULT 1,
-		"downvotes" INTEGER NOT NULL DEFAULT 1,
-		"rating" DECIMAL GENERATED ALWAYS AS (upvotes::DECIMAL / downvotes) STORED,
-		"views" INTEGER NOT NULL DEFAULT 0
-		);
-
-		CREATE TABLE forum.messages (
-		"id" INTEGER PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
-		"thread_id" INTEGER NOT NULL REFERENCES forum.threads (id) ON DELETE CASCADE,
-		"user_id" UUID NOT NULL,
-		"title" VARCHAR(255) NOT NULL,
-		"body" VARCHAR NOT NULL,
-		"tags" VARCHAR(255)[],
-		"created_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
-		"updated_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
-		"upvotes" INTEGER NOT NULL DEFAULT 1,
-		"downvotes" INTEGER NOT NULL DEFAULT 1,
-		"rating" DECIMAL GENERATED ALWAYS AS (upvotes::DECIMAL / downvotes) STORED,
-		"views" INTEGER NOT NULL DEFAULT 0
-		);
-
-		CREATE SCHEMA IF NOT EXISTS demo;
-
-		CREATE TABLE demo.demos (
-		"id"  INTEGER PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
-		"title" TEXT NOT NULL,
-		"description" TEXT,
-		"tags" TEXT[],
-		"user_id" UUID NOT NULL,
-		"created_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
-		"updated_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
-		"upvotes" INTEGER NOT NULL DEFAULT 1,
-		"downvotes" INTEGER NOT NULL DEFAULT 1,
-		"rating" DECIMAL GENERATED ALWAYS AS (upvotes::DECIMAL / downvotes) STORED,
-		"thread_id" INTEGER NOT NULL REFERENCES forum.threads (id) ON DELETE CASCADE,
-		"views" INTEGER NOT NULL DEFAULT 0,
-		"object_key" VARCHAR(255) GENERATED ALWAYS AS ('demo-' || demo.demos.id) STORED,
-		"thumbnail_key" VARCHAR(255) GENERATED ALWAYS AS ('demo-thumbnail-' || demo.demos.id) STORED
-		);
-
-		CREATE COLLATION IF NOT EXISTS case_insensitive (provider = icu, locale = 'und-u-ks-level2', deterministic = false);
-
-		CREATE OR REPLACE FUNCTION to_tsvector_multilang(text) RETURNS tsvector AS $$
-		BEGIN
-			RETURN 
-			to_tsvector('english', $1) || 
-			to_tsvector('russian', $1);
-		END;
-		$$ LANGUAGE plpgsql IMMUTABLE;
-
-		CREATE OR REPLACE FUNCTION to_tsquery_multilang(text) RETURNS tsquery AS $$
-		BEGIN
-			RETURN
-			websearch_to_tsquery('english', $1) || 
-			websearch_to_tsquery('russian', $1);
-		END;
-		$$ LANGUAGE plpgsql IMMUTABLE;
-
-		ALTER TABLE demo.demos ADD COLUMN demo_ts tsvector GENERATED ALWAYS AS (
-		setweight(to_tsvector_multilang("title"), 'A') ||
-		setweight(to_tsvector_multilang(COALESCE("description", '')), 'B')
-		) STORED;
-		CREATE INDEX demo_gin_index_ts ON demo.demos USING GIN (demo_ts);
-		`)
+	err = psqlTestDatabase.Reset(c.Conn(), databaseConfig)
 	if err != nil {
-		panic("Error resetting demo schema" + err.Error())
+		panic("Error resetting test database: " + err.Error())
 	}
 	err = c.QueryRow(
 		context.Background(),
@@ -215,6 +133,22 @@ func TestPostThread(t *testing.T) {
 	demoUpdated.ID = demoCreated.ID
 }
 
+func TestDropThread(t *testing.T) {
+	threadID, err := threadSyncer.PostThread(demo)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.NoError(t, threadSyncer.DropThread(*threadID, userID))
+	_, err = forumRepository.FindThreadByID(*threadID)
+	assert.Equal(t, forumRepository.NotFoundErr(), err)
+	// Nothing is left in the trash to restore
+	assert.Equal(t, forumRepository.NotFoundErr(), forumRepository.RestoreThread(*threadID, &userID))
+
+	// Threads of stored demos stay
+	assert.Equal(t, forumRepository.NotFoundErr(), threadSyncer.DropThread(*demo.ThreadID, userID))
+}
+
 func TestPatchThread(t *testing.T) {
 	var err error
 
@@ -240,17 +174,17 @@ func TestPatchThread(t *testing.T) {
 }
 
 func teardownSyncer(rd *psqlRepository.PsqlDemoRepository, rf *psqlRepository.PsqlForumRepository) {
-	remainderDemos, err := rd.FindDemos(nil, 0, "")
+	remainderDemos, err := rd.FindDemos(nil, models.ListFilter{}, models.PageQuery{}, "")
 	if err != nil {
 		panic(err)
 	}
-	for _, d := range *remainderDemos {
-		err = rd.DeleteDemo(*d.ID)
+	for _, d := range remainderDemos.Items {
+		err = rd.DeleteDemo(*d.ID, userID)
 		if err != nil {
 			panic(err)
 		}
 	}
-	err = rf.DeleteTopic(topicID)
+	err = rf.DeleteTopic(topicID, userID)
 	if err != nil {
 		panic(err)
 	}
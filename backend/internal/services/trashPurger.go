@@ -0,0 +1,48 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+type TrashRepository interface {
+	PurgeTrash() (int64, error)
+}
+
+// Periodically purges soft-deleted content older than the repository's retention period
+type TrashPurger struct {
+	repository TrashRepository
+	interval   time.Duration
+	logger     echo.Logger
+}
+
+func NewTrashPurger(r TrashRepository, interval time.Duration, l echo.Logger) *TrashPurger {
+	return &TrashPurger{
+		repository: r,
+		interval:   interval,
+		logger:     l,
+	}
+}
+
+// Purges the trash on start and then every interval until ctx is cancelled
+func (p *TrashPurger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := p.repository.PurgeTrash()
+		if err != nil {
+			p.logger.Errorf("Error purging trash: %v", err)
+		} else if purged != 0 {
+			p.logger.Infof("Purged %v records from trash", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -2,6 +2,9 @@ package services
 
 import (
 	"gamehangar/internal/domain/models"
+	"net/http"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/google/uuid"
@@ -14,11 +17,26 @@ type UserAuthorizerRepository interface {
 	FindUserByID(id uuid.UUID) (*models.User, error)
 	FindUserByEmail(email string) (user *models.User, err error)
 	FindUserByUsername(username string) (user *models.User, err error)
+	FindActiveSanctions(userID uuid.UUID) (*[]models.Sanction, error)
+	IsTopicModerator(topicID int, userID uuid.UUID) (bool, error)
 	NotFoundErr() error
 }
 
 type Enforcer interface {
 	Enforce(sub, obj, act string) (bool, error)
+	EnforceRole(role, obj, act string) (bool, error)
+}
+
+// First path segments of the objects a mute takes away write access to
+var mutedObjects = map[string][]string{
+	"post_mute":   {"topics", "threads", "messages"},
+	"upload_mute": {"demos", "assets"},
+}
+
+// Objects below topics/:id that moderators of the category may act on
+var topicScopedObjects = map[string]string{
+	"moderation/actions": http.MethodPost,
+	"moderation/reports": http.MethodGet,
 }
 
 type UserAuthorizer struct {
@@ -70,39 +88,108 @@ func (a *UserAuthorizer) CheckPassword(password *string, userID uuid.UUID) error
 	return bcrypt.CompareHashAndPassword([]byte(*user.Password), []byte(*password))
 }
 
-func (a *UserAuthorizer) CheckPermissions(c echo.Context, user string) (bool, error) {
-	var (
-		obj, act  string
-		sessionID uuid.UUID
-	)
+// Returns the active sanction that forbids the user to act on the obj, or nil if there is none.
+// A ban forbids everything, mutes only forbid creating and editing content of their kind
+func (a *UserAuthorizer) CheckSanctions(userID uuid.UUID, obj, act string) (*models.Sanction, error) {
+	sanctions, err := a.repository.FindActiveSanctions(userID)
+	if err != nil {
+		if err == a.repository.NotFoundErr() {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	segment, _, _ := strings.Cut(obj, "/")
+	for _, sanction := range *sanctions {
+		if *sanction.Type == "ban" {
+			return &sanction, nil
+		}
+		if act != http.MethodPost && act != http.MethodPatch && act != http.MethodPut {
+			continue
+		}
+		if slices.Contains(mutedObjects[*sanction.Type], segment) {
+			return &sanction, nil
+		}
+	}
+	return nil, nil
+}
+
+// Returns the user of the session in the sessionID cookie or header
+func (a *UserAuthorizer) sessionUser(c echo.Context) (*models.User, error) {
+	var sessionID uuid.UUID
 
 	cookie, err := c.Cookie("sessionID")
 	if err != nil {
 		sessionSlice, ok := c.Request().Header["Sessionid"]
 		if !ok {
-			return false, err
+			return nil, err
 		}
 		sessionID, err = uuid.Parse(sessionSlice[0])
 	} else {
 		sessionID, err = uuid.Parse(cookie.Value)
 	}
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
 	session, err := a.repository.FindSessionByID(sessionID)
-	sub, err := a.repository.FindUserByID(*session.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return a.repository.FindUserByID(*session.UserID)
+}
+
+// Middleware that identifies the user on public routes without requiring a session,
+// so handlers can tailor responses, e.g. show hidden content to its author
+func (a *UserAuthorizer) IdentifySession(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		sub, err := a.sessionUser(c)
+		if err == nil {
+			c.Set("userTier", *sub.Role)
+			c.Set("userID", *sub.ID)
+		}
+		return next(c)
+	}
+}
+
+func (a *UserAuthorizer) CheckPermissions(c echo.Context, user string) (bool, error) {
+	var obj, act string
+
+	sub, err := a.sessionUser(c)
 	if err != nil {
 		return false, err
 	}
 	c.Set("userTier", *sub.Role)
+	c.Set("userID", *sub.ID)
 
 	obj = strings.TrimPrefix(c.Request().URL.Path, "/game-hangar/v1/")
 
 	act = c.Request().Method
 
+	sanction, err := a.CheckSanctions(*sub.ID, obj, act)
+	if sanction != nil || err != nil {
+		return false, err
+	}
+
 	eft1, err := a.enforcer.Enforce(sub.ID.String(), obj, act) // Check user permissions over the obj
-	eft2, err := a.enforcer.Enforce(*sub.Role, obj, act)       // Check user role permissions over the obj
+	eft2, err := a.enforcer.EnforceRole(*sub.Role, obj, act)   // Check user role permissions over the obj
+	if eft1 || eft2 || err != nil {
+		return (eft1 || eft2), err
+	}
 
-	return (eft1 || eft2), err
+	return a.checkTopicScope(*sub.ID, obj, act) // Check category moderator permissions over the obj
+}
+
+// Casbin policies cannot tell which category an object is in,
+// so objects scoped to a category are checked against its moderators instead
+func (a *UserAuthorizer) checkTopicScope(userID uuid.UUID, obj, act string) (bool, error) {
+	segments := strings.SplitN(obj, "/", 3)
+	if len(segments) != 3 || segments[0] != "topics" || topicScopedObjects[segments[2]] != act {
+		return false, nil
+	}
+	topicID, err := strconv.Atoi(segments[1])
+	if err != nil {
+		return false, nil
+	}
+	return a.repository.IsTopicModerator(topicID, userID)
 }
@@ -54,30 +54,8 @@ func init() {
 			panic("Error setting up new DatabaseClient")
 		}
 	}
-	c, _ := testDBClient.AcquireConn() // WARNING! Integration tests DROP TABLEs
-	_, err = c.Exec(context.Background(), `
-		DROP TRIGGER IF EXISTS increment_role_version_on_update ON "user".roles; 
-		DROP SCHEMA IF EXISTS "user" CASCADE;
-
-		CREATE SCHEMA IF NOT EXISTS "user";
-
-		CREATE TABLE "user".users (
-		"id" UUID DEFAULT gen_random_uuid() PRIMARY KEY,
-		"username" VARCHAR(255) NOT NULL UNIQUE,
-		"display_name" VARCHAR(255),
-		"email" VARCHAR(255) NOT NULL UNIQUE,
-		"password" VARCHAR(255) NOT NULL,
-		"verified" BOOLEAN NOT NULL DEFAULT false,
-		"role" VARCHAR(255) NOT NULL,
-		"created_at" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
-		"karma" INTEGER NOT NULL DEFAULT 0
-		);
-
-		CREATE TABLE "user".sessions (
-		"id" UUID DEFAULT gen_random_uuid() PRIMARY KEY,
-		"user_id" UUID NOT NULL REFERENCES "user".users (id) ON DELETE CASCADE
-		);
-		`)
+	c, _ := testDBClient.AcquireConn() // WARNING! Integration tests DELETE users
+	_, err = c.Exec(context.Background(), `TRUNCATE "user".users CASCADE`)
 	if err != nil {
 		panic("Error resetting user schema" + err.Error())
 	}
@@ -123,6 +101,37 @@ func TestIdentifyUserNil(t *testing.T) {
 	}
 }
 
+func TestCheckSanctions(t *testing.T) {
+	sanction, err := userAuthorizer.CheckSanctions(userID, "messages", "POST")
+	if assert.NoError(t, err) {
+		assert.Nil(t, sanction)
+	}
+
+	sanctionType, reason := "post_mute", "Flooding"
+	s, err := userRepository.CreateSanction(models.Sanction{UserID: &userID, Type: &sanctionType, Reason: &reason})
+	assert.NoError(t, err)
+
+	sanction, err = userAuthorizer.CheckSanctions(userID, "messages", "POST")
+	if assert.NoError(t, err) && assert.NotNil(t, sanction) {
+		assert.Equal(t, *s.ID, *sanction.ID)
+	}
+	sanction, err = userAuthorizer.CheckSanctions(userID, "demos", "POST")
+	if assert.NoError(t, err) {
+		assert.Nil(t, sanction)
+	}
+	sanction, err = userAuthorizer.CheckSanctions(userID, "messages/1", "GET")
+	if assert.NoError(t, err) {
+		assert.Nil(t, sanction)
+	}
+
+	_, err = userRepository.RevokeSanction(*s.ID, userID)
+	assert.NoError(t, err)
+	sanction, err = userAuthorizer.CheckSanctions(userID, "messages", "POST")
+	if assert.NoError(t, err) {
+		assert.Nil(t, sanction)
+	}
+}
+
 func TestPasswordHashCreateCheck(t *testing.T) {
 	hash, err := userAuthorizer.CreatePasswordHash(&testPassword)
 	assert.NoError(t, err)